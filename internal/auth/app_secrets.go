@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"wyrmlock/internal/config"
+)
+
+// SecretName returns the name of the secret that unlocks appPath, or ""
+// when the app uses the global secret
+func (a *Authenticator) SecretName(appPath string) string {
+	for _, app := range a.config.BlockedApps {
+		if app.Path == appPath {
+			return app.Secret
+		}
+	}
+	return ""
+}
+
+// attemptKey returns the brute force counter of appPath. Apps sharing a
+// secret share a counter, so a group cannot be guessed app by app, and a
+// global lockout scope has one counter for every app.
+func (a *Authenticator) attemptKey(appPath string) string {
+	if a.config.Auth.LockoutScope == config.LockoutScopeGlobal {
+		return "global"
+	}
+	if name := a.SecretName(appPath); name != "" {
+		return "secret:" + name
+	}
+	return appPath
+}
+
+// appSecretPath returns the file holding the named app secret
+func (a *Authenticator) appSecretPath(name string) (string, error) {
+	if !config.IsValidSecretName(name) {
+		return "", fmt.Errorf("invalid secret name: %q", name)
+	}
+	return filepath.Join(a.config.Auth.AppSecretDir, name), nil
+}
+
+// secretSource is where the secret for an attempt is kept; the zero value
+// is the global secret
+type secretSource struct {
+	// file is a per-app or per-user secret file
+	file string
+
+	// desktop is the secret read from the launching user's desktop keyring
+	desktop *desktopSecret
+}
+
+// global reports whether the source is the global secret
+func (s secretSource) global() bool {
+	return s.file == "" && s.desktop == nil
+}
+
+// secretSourceFor returns where the secret for a launch of appPath by uid
+// is kept. Child accounts are always checked against the parent password,
+// so they cannot unlock with a secret of their own.
+func (a *Authenticator) secretSourceFor(appPath string, uid int) (secretSource, error) {
+	if a.IsChild(uid) {
+		path, err := a.parentSecretFile()
+		return secretSource{file: path}, err
+	}
+	if name := a.SecretName(appPath); name != "" {
+		path, err := a.appSecretPath(name)
+		return secretSource{file: path}, err
+	}
+	if path := a.userSecretFile(uid); path != "" {
+		return secretSource{file: path}, nil
+	}
+	if desktop := a.lookupDesktopSecret(uid); desktop != nil {
+		return secretSource{desktop: desktop}, nil
+	}
+	return secretSource{}, nil
+}
+
+// readSecretFile reads a per-app or per-user secret. These are read on
+// every attempt so changes apply without a restart.
+func readSecretFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s is not set", ErrSecretNotFound, filepath.Base(path))
+	}
+	return data, err
+}
+
+// SetAppSecret saves the named app secret in the form SetSecret uses for
+// the global one
+func (a *Authenticator) SetAppSecret(name string, secret []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	path, err := a.appSecretPath(name)
+	if err != nil {
+		return err
+	}
+	return a.writeSecretFile(path, secret)
+}
+
+// writeSecretFile stores a per-app or per-user secret readable by root only
+func (a *Authenticator) writeSecretFile(path string, secret []byte) error {
+	dataToStore, err := a.storedForm(secret)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create secret directory: %w", err)
+	}
+	if err := os.WriteFile(path, dataToStore, 0600); err != nil {
+		return fmt.Errorf("failed to write secret: %w", err)
+	}
+	return nil
+}
+
+// RemoveAppSecret deletes the named app secret
+func (a *Authenticator) RemoveAppSecret(name string) error {
+	path, err := a.appSecretPath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: app secret %s is not set", ErrSecretNotFound, name)
+		}
+		return err
+	}
+	return nil
+}
+
+// HasAppSecret reports whether the named app secret is set
+func (a *Authenticator) HasAppSecret(name string) bool {
+	path, err := a.appSecretPath(name)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,90 @@
+package auth_test
+
+import (
+	"testing"
+
+	"wyrmlock/internal/auth"
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/testutil"
+)
+
+// TestAppSecrets tests that apps naming a secret are unlocked with it and
+// share its attempt counter
+func TestAppSecrets(t *testing.T) {
+	secretPath, cleanup := testutil.CreateTempFile(t, mustHash(t, "global-password"))
+	defer cleanup()
+
+	cfg := testutil.SetupTestConfig(t)
+	cfg.Auth.UseZeroKnowledgeProof = false
+	cfg.Auth.SecretPath = secretPath
+	cfg.Auth.AppSecretDir = t.TempDir()
+	cfg.BlockedApps = []config.BlockedApp{
+		{Path: "/usr/bin/steam", Secret: "games"},
+		{Path: "/usr/bin/lutris", Secret: "games"},
+		{Path: "/usr/bin/firefox"},
+	}
+
+	a, err := auth.NewAuthenticator(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create authenticator: %v", err)
+	}
+
+	// Not set yet: fail closed rather than accept the global password
+	if ok, err := a.Authenticate([]byte("global-password"), "/usr/bin/steam"); ok || err == nil {
+		t.Errorf("Expected an unset app secret to fail, got %v, %v", ok, err)
+	}
+
+	if err := a.SetAppSecret("games", []byte("games-password")); err != nil {
+		t.Fatalf("SetAppSecret failed: %v", err)
+	}
+	if !a.HasAppSecret("games") {
+		t.Error("Expected the app secret to be set")
+	}
+
+	tests := []struct {
+		app, password string
+		want          bool
+	}{
+		{"/usr/bin/steam", "games-password", true},
+		{"/usr/bin/lutris", "games-password", true},
+		{"/usr/bin/steam", "global-password", false},
+		{"/usr/bin/firefox", "global-password", true},
+		{"/usr/bin/firefox", "games-password", false},
+	}
+	for _, tt := range tests {
+		ok, err := a.Authenticate([]byte(tt.password), tt.app)
+		if err != nil {
+			t.Fatalf("Authenticate(%s) failed: %v", tt.app, err)
+		}
+		if ok != tt.want {
+			t.Errorf("Authenticate(%q, %s) = %v, want %v", tt.password, tt.app, ok, tt.want)
+		}
+	}
+
+	// A failure on one app of the group counts for the other
+	before := a.GetRemainingAttempts("/usr/bin/lutris")
+	if _, err := a.Authenticate([]byte("wrong"), "/usr/bin/steam"); err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	if after := a.GetRemainingAttempts("/usr/bin/lutris"); after != before-1 {
+		t.Errorf("Expected the group to share attempts, got %d then %d", before, after)
+	}
+
+	if err := a.RemoveAppSecret("games"); err != nil {
+		t.Fatalf("RemoveAppSecret failed: %v", err)
+	}
+	if a.HasAppSecret("games") {
+		t.Error("Expected the app secret to be removed")
+	}
+	if err := a.SetAppSecret("../escape", []byte("x")); err == nil {
+		t.Error("Expected an invalid secret name to be rejected")
+	}
+}
+
+func mustHash(t *testing.T, password string) []byte {
+	hash, err := auth.GenerateHash([]byte(password), "bcrypt")
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	return hash
+}
@@ -14,6 +14,7 @@ import (
 	"wyrmlock/internal/config"
 	"wyrmlock/internal/keychain"
 	"wyrmlock/internal/logging"
+	"wyrmlock/internal/yubikey"
 
 	"github.com/cossacklabs/themis/gothemis/compare"
 )
@@ -80,6 +81,9 @@ type Authenticator struct {
 	// Optional keychain access
 	keychainIntegration *keychain.KeychainIntegration
 
+	// Enrollment of the yubikey backend; nil until set up
+	yubikey *yubikey.Enrollment
+
 	// Brute force protection
 	bruteForceProtection *BruteForceProtection
 }
@@ -99,51 +103,109 @@ type protocolState struct {
 
 // NewAuthenticator creates a new authenticator with the given configuration
 func NewAuthenticator(cfg *config.Config) (*Authenticator, error) {
+	maxAttempts, lockoutDuration := DefaultMaxAuthAttempts, DefaultLockoutDuration
+	if cfg.Auth.MaxAttempts > 0 {
+		maxAttempts = cfg.Auth.MaxAttempts
+	}
+	if cfg.Auth.LockoutDuration > 0 {
+		lockoutDuration = time.Duration(cfg.Auth.LockoutDuration) * time.Second
+	}
+
 	auth := &Authenticator{
-		config: cfg,
-		logger: logging.NewLogger("auth", true),
-		bruteForceProtection: NewBruteForceProtection(
-			DefaultMaxAuthAttempts,
-			DefaultLockoutDuration,
-		),
+		config:               cfg,
+		logger:               logging.NewLogger("auth", true),
+		bruteForceProtection: NewBruteForceProtection(maxAttempts, lockoutDuration),
+	}
+	auth.bruteForceProtection.SetBackoff(
+		time.Duration(cfg.Auth.BackoffBase)*time.Second,
+		time.Duration(cfg.Auth.BackoffMax)*time.Second,
+	)
+
+	// Apps may allow fewer or more attempts than the default; a global
+	// scope has a single counter, so only the default applies
+	if cfg.Auth.LockoutScope != config.LockoutScopeGlobal {
+		for _, app := range cfg.BlockedApps {
+			if app.MaxAttempts > 0 {
+				auth.bruteForceProtection.SetAppLimit(auth.attemptKey(app.Path), app.MaxAttempts)
+			}
+		}
+	}
+
+	// Only the daemon can write the state file; other processes count in
+	// memory
+	if cfg.Auth.LockoutStateFile != "" {
+		if err := auth.bruteForceProtection.Persist(cfg.Auth.LockoutStateFile); err != nil {
+			auth.logger.Debugf("Failed attempts are not kept across restarts: %v", err)
+		}
+	}
+
+	if err := auth.loadSecret(); err != nil {
+		return nil, err
 	}
 
-	// Initialize based on configuration
-	if cfg.Auth.SecretPath != "" {
+	// Additional validation for traditional auth mode
+	if cfg.Auth.Backend != config.AuthBackendYubiKey && !cfg.Auth.UseZeroKnowledgeProof && cfg.Auth.HashAlgorithm == "" {
+		return nil, errors.New("hash algorithm must be specified when not using ZKP")
+	}
+
+	return auth, nil
+}
+
+// loadSecret reads the global secret from the configured source
+func (a *Authenticator) loadSecret() error {
+	cfg := a.config
+	if cfg.Auth.Backend == config.AuthBackendYubiKey {
+		// Only the challenge and password hash are stored; a missing
+		// enrollment is created by SetSecret
+		enrollment, err := yubikey.Load(cfg.Auth.YubiKeyFile)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read YubiKey enrollment: %w", err)
+		}
+		a.yubikey = enrollment
+	} else if cfg.Auth.SecretPath != "" {
 		// Read secret from file
 		data, err := os.ReadFile(cfg.Auth.SecretPath)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read secret file: %w", err)
+			return fmt.Errorf("failed to read secret file: %w", err)
 		}
 
-		auth.secretData = data
+		a.secretData = data
 	} else if cfg.KeychainService != "" && cfg.KeychainAccount != "" {
 		// Initialize keychain integration
 		kc, err := keychain.NewKeychainIntegration(cfg.KeychainService, cfg.KeychainAccount)
 		if err != nil {
-			return nil, fmt.Errorf("failed to initialize keychain: %w", err)
+			return fmt.Errorf("failed to initialize keychain: %w", err)
 		}
-		auth.keychainIntegration = kc
 
 		// Try to access the secret
 		exists, err := kc.SecretExists()
 		if err != nil {
-			return nil, fmt.Errorf("failed to check if secret exists: %w", err)
+			return fmt.Errorf("failed to check if secret exists: %w", err)
 		}
 
 		if !exists {
-			return nil, ErrSecretNotFound
+			return ErrSecretNotFound
 		}
+		a.keychainIntegration = kc
 	} else {
-		return nil, errors.New("no secret source configured")
+		return errors.New("no secret source configured")
 	}
+	return nil
+}
 
-	// Additional validation for traditional auth mode
-	if !cfg.Auth.UseZeroKnowledgeProof && cfg.Auth.HashAlgorithm == "" {
-		return nil, errors.New("hash algorithm must be specified when not using ZKP")
-	}
+// ReloadSecret re-reads the global secret after another process changed
+// it. Failed attempt counters and lockouts are kept.
+func (a *Authenticator) ReloadSecret() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.loadSecret()
+}
 
-	return auth, nil
+// HasSecret reports whether a global secret is set up
+func (a *Authenticator) HasSecret() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.yubikey != nil || len(a.secretData) > 0 || a.keychainIntegration != nil
 }
 
 // AuthenticateZKP authenticates a user using zero-knowledge proof with Themis
@@ -157,6 +219,11 @@ func NewAuthenticator(cfg *config.Config) (*Authenticator, error) {
 // The implementation handles multiple protocol iterations and properly cleans up memory
 // to ensure sensitive data doesn't remain in memory after authentication.
 func (a *Authenticator) AuthenticateZKP(userInput []byte) (bool, error) {
+	return a.authenticateZKP(userInput, secretSource{})
+}
+
+// authenticateZKP runs the ZKP protocol against the secret kept in src
+func (a *Authenticator) authenticateZKP(userInput []byte, src secretSource) (bool, error) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
@@ -190,7 +257,7 @@ func (a *Authenticator) AuthenticateZKP(userInput []byte) (bool, error) {
 	a.logger.Debugf("Starting ZKP protocol with context ID: %s", state.contextID)
 
 	// Get the stored secret with secure handling
-	secret, err := a.getSecret()
+	secret, err := a.getSecret(src)
 	if err != nil {
 		return false, fmt.Errorf("failed to get secret: %w", err)
 	}
@@ -510,34 +577,62 @@ func generateProtocolID() string {
 
 // Authenticate verifies if the provided user input matches the stored secret
 func (a *Authenticator) Authenticate(userInput []byte, appPath string) (bool, error) {
+	return a.AuthenticateUser(userInput, appPath, -1)
+}
+
+// AuthenticateUser verifies user input for a launch of appPath by uid: the
+// app's own secret when it names one, otherwise the user's secret when one
+// is set, then the secret in their desktop keyring when enabled, otherwise
+// the global secret. uid -1 skips user secrets.
+func (a *Authenticator) AuthenticateUser(userInput []byte, appPath string, uid int) (bool, error) {
 	// Add basic input validation
 	if len(userInput) == 0 {
 		return false, errors.New("empty authentication input")
 	}
 
+	// Apps sharing a secret share their attempt counter
+	src, err := a.secretSourceFor(appPath, uid)
+	if err != nil {
+		return false, err
+	}
+	defer src.desktop.wipe()
+	attemptKey := a.attemptKey(appPath)
+
 	// Check brute force protection
-	if err := a.bruteForceProtection.CheckAttempt(appPath); err != nil {
+	if err := a.bruteForceProtection.CheckAttempt(attemptKey); err != nil {
 		if errors.Is(err, ErrMaxAttemptsExceeded) || errors.Is(err, ErrTempLockout) {
 			// Get the lockout duration if applicable
-			lockoutDuration := a.bruteForceProtection.GetLockoutDuration(appPath)
+			lockoutDuration := a.bruteForceProtection.GetLockoutDuration(attemptKey)
 			if lockoutDuration > 0 {
 				return false, fmt.Errorf("%w: locked out for %s",
 					ErrTempLockout, lockoutDuration.Round(time.Second))
 			}
 			return false, err
 		}
+		if errors.Is(err, ErrBackoff) {
+			return false, fmt.Errorf("%w: retry in %s", ErrBackoff,
+				a.bruteForceProtection.GetLockoutDuration(attemptKey).Round(time.Second))
+		}
 		// Some other unexpected error
 		return false, fmt.Errorf("error checking brute force protection: %w", err)
 	}
 
+	// A recovery code unlocks when the password or second factor is lost
+	if a.useRecoveryCode(userInput) {
+		a.bruteForceProtection.RecordSuccess(attemptKey)
+		return true, nil
+	}
+
 	var authSuccess bool
 	var authErr error
 
-	if a.config.Auth.UseZeroKnowledgeProof {
-		authSuccess, authErr = a.AuthenticateZKP(userInput)
+	if a.config.Auth.Backend == config.AuthBackendYubiKey && src.global() {
+		authSuccess, authErr = a.AuthenticateYubiKey(userInput)
+	} else if a.config.Auth.UseZeroKnowledgeProof {
+		authSuccess, authErr = a.authenticateZKP(userInput, src)
 	} else {
 		// Fall back to traditional password hashing
-		authSuccess, authErr = a.AuthenticateTraditional(userInput)
+		authSuccess, authErr = a.authenticateTraditional(userInput, src)
 	}
 
 	// Record success or failure for brute force protection
@@ -545,9 +640,9 @@ func (a *Authenticator) Authenticate(userInput []byte, appPath string) (bool, er
 		// Don't count errors as failures
 		return false, authErr
 	} else if authSuccess {
-		a.bruteForceProtection.RecordSuccess(appPath)
+		a.bruteForceProtection.RecordSuccess(attemptKey)
 	} else {
-		a.bruteForceProtection.RecordFailure(appPath)
+		a.bruteForceProtection.RecordFailure(attemptKey)
 	}
 
 	return authSuccess, nil
@@ -555,32 +650,51 @@ func (a *Authenticator) Authenticate(userInput []byte, appPath string) (bool, er
 
 // AuthenticateTraditional authenticates a user using traditional password hashing
 func (a *Authenticator) AuthenticateTraditional(userInput []byte) (bool, error) {
+	return a.authenticateTraditional(userInput, secretSource{})
+}
+
+// authenticateTraditional compares against the hash kept in src
+func (a *Authenticator) authenticateTraditional(userInput []byte, src secretSource) (bool, error) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
 	// Get the stored hash
-	storedHash, err := a.getSecret()
+	storedHash, err := a.getSecret(src)
 	if err != nil {
 		return false, fmt.Errorf("failed to get stored hash: %w", err)
 	}
 
-	// Choose the appropriate hashing algorithm
-	switch a.config.Auth.HashAlgorithm {
-	case "bcrypt":
-		return compareBcrypt(userInput, storedHash)
-	case "argon2id":
-		return compareArgon2id(userInput, storedHash)
-	case "scrypt":
-		return compareScrypt(userInput, storedHash)
-	case "pbkdf2":
-		return comparePBKDF2(userInput, storedHash)
-	default:
-		return false, fmt.Errorf("unsupported hash algorithm: %s", a.config.Auth.HashAlgorithm)
+	// The algorithm is detected from the hash, so hashes stored before a
+	// change of algorithm still verify
+	ok, err := Compare(userInput, storedHash)
+	if err != nil || !ok {
+		return ok, err
+	}
+
+	// Upgrade weaker or outdated hashes while the password is at hand.
+	// Desktop keyring secrets are left alone, as that would break their pin.
+	params := a.argon2Params()
+	if src.desktop == nil && NeedsRehash(storedHash, params) {
+		if err := a.rehash(userInput, src, params); err != nil {
+			a.logger.Warnf("Failed to migrate stored hash to argon2id: %v", err)
+		} else {
+			a.logger.Infof("Migrated stored hash to argon2id (m=%d, t=%d, p=%d)",
+				params.Memory, params.Iterations, params.Parallelism)
+		}
 	}
+	return true, nil
 }
 
-// getSecret retrieves the secret/hash from the configured source
-func (a *Authenticator) getSecret() ([]byte, error) {
+// getSecret retrieves a copy of the secret/hash from src, or from the
+// configured source for the global secret. Callers may wipe the copy.
+func (a *Authenticator) getSecret(src secretSource) ([]byte, error) {
+	if src.file != "" {
+		return readSecretFile(src.file)
+	}
+	if src.desktop != nil {
+		return append([]byte(nil), src.desktop.secret...), nil
+	}
+
 	if a.keychainIntegration != nil {
 		// Get secret from keychain
 		return a.keychainIntegration.GetSecret()
@@ -588,7 +702,7 @@ func (a *Authenticator) getSecret() ([]byte, error) {
 
 	// Return the secret loaded from file
 	if a.secretData != nil {
-		return a.secretData, nil
+		return append([]byte(nil), a.secretData...), nil
 	}
 
 	return nil, ErrSecretNotFound
@@ -599,20 +713,14 @@ func (a *Authenticator) SetSecret(secret []byte) error {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	// For zero-knowledge proofs, we store the raw secret
-	// For traditional authentication, we need to hash it first
-	var dataToStore []byte
-	var err error
+	// The yubikey backend stores a hash bound to the key instead
+	if a.config.Auth.Backend == config.AuthBackendYubiKey {
+		return a.enrollYubiKey(secret)
+	}
 
-	if a.config.Auth.UseZeroKnowledgeProof {
-		// ZKP mode, store raw secret for Secure Comparator
-		dataToStore = secret
-	} else {
-		// Traditional mode, hash the password
-		dataToStore, err = GenerateHash(secret, a.config.Auth.HashAlgorithm)
-		if err != nil {
-			return fmt.Errorf("failed to hash secret: %w", err)
-		}
+	dataToStore, err := a.storedForm(secret)
+	if err != nil {
+		return err
 	}
 
 	// Save to appropriate storage
@@ -644,14 +752,80 @@ func (a *Authenticator) SetSecret(secret []byte) error {
 	return nil
 }
 
+// storedForm returns what is stored for a secret: for zero-knowledge
+// proofs the raw secret, for traditional authentication its argon2id hash
+func (a *Authenticator) storedForm(secret []byte) ([]byte, error) {
+	if a.config.Auth.UseZeroKnowledgeProof {
+		// ZKP mode, store raw secret for Secure Comparator
+		return secret, nil
+	}
+
+	// Traditional mode, hash the password
+	hash, err := GenerateArgon2idHash(secret, a.argon2Params())
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash secret: %w", err)
+	}
+	return hash, nil
+}
+
+// argon2Params returns the configured argon2id costs, using the default
+// for each one not set
+func (a *Authenticator) argon2Params() Argon2Params {
+	params := DefaultArgon2Params
+	if a.config.Auth.Argon2Memory > 0 {
+		params.Memory = uint32(a.config.Auth.Argon2Memory)
+	}
+	if a.config.Auth.Argon2Iterations > 0 {
+		params.Iterations = uint32(a.config.Auth.Argon2Iterations)
+	}
+	if a.config.Auth.Argon2Parallelism > 0 {
+		params.Parallelism = uint8(a.config.Auth.Argon2Parallelism)
+	}
+	return params
+}
+
+// rehash replaces the stored hash of a verified password with an argon2id
+// hash, where it was read from
+func (a *Authenticator) rehash(password []byte, src secretSource, params Argon2Params) error {
+	hash, err := GenerateArgon2idHash(password, params)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case src.file != "":
+		return os.WriteFile(src.file, hash, 0600)
+	case a.keychainIntegration != nil:
+		return a.keychainIntegration.SaveSecret(hash)
+	case a.config.Auth.SecretPath != "":
+		if err := os.WriteFile(a.config.Auth.SecretPath, hash, 0600); err != nil {
+			return err
+		}
+		a.secretData = hash
+		return nil
+	default:
+		return errors.New("no secret destination configured")
+	}
+}
+
 // GetRemainingAttempts returns the number of attempts remaining before lockout
 func (a *Authenticator) GetRemainingAttempts(appPath string) int {
-	return a.bruteForceProtection.GetRemainingAttempts(appPath)
+	return a.bruteForceProtection.GetRemainingAttempts(a.attemptKey(appPath))
+}
+
+// LockoutStatus reports whether authentication for appPath is currently
+// refused and, when a timed lockout is active, how long it has left
+func (a *Authenticator) LockoutStatus(appPath string) (bool, time.Duration) {
+	key := a.attemptKey(appPath)
+	if err := a.bruteForceProtection.CheckAttempt(key); err != nil {
+		return true, a.bruteForceProtection.GetLockoutDuration(key)
+	}
+	return false, 0
 }
 
 // ResetAttempts resets the brute force protection for a specific app
 func (a *Authenticator) ResetAttempts(appPath string) {
-	a.bruteForceProtection.ResetAttempts(appPath)
+	a.bruteForceProtection.ResetAttempts(a.attemptKey(appPath))
 }
 
 // ClearMemory securely wipes a byte slice
@@ -2,9 +2,11 @@ package auth_test
 
 import (
 	"os"
+	"strings"
 	"testing"
 
 	"wyrmlock/internal/auth"
+	"wyrmlock/internal/config"
 	"wyrmlock/internal/testutil"
 )
 
@@ -201,4 +203,153 @@ func TestAuthenticationWithBruteForceProtection(t *testing.T) {
 	if err == nil {
 		t.Errorf("Expected error for lockout, got nil")
 	}
-} 
\ No newline at end of file
+} 
+// TestLockoutStatus tests that exhausting attempts reports an active lockout
+func TestLockoutStatus(t *testing.T) {
+	authenticator, cleanup := testutil.SetupAuthenticatorWithPassword(t, "correct-password-123", "pbkdf2", false)
+	defer cleanup()
+
+	appPath := "/usr/bin/testapp"
+
+	if locked, _ := authenticator.LockoutStatus(appPath); locked {
+		t.Fatal("Expected app not to be locked out initially")
+	}
+
+	for i := 0; i < auth.DefaultMaxAuthAttempts; i++ {
+		if _, err := authenticator.Authenticate([]byte("wrong-password"), appPath); err != nil {
+			t.Fatalf("Unexpected error on attempt %d: %v", i+1, err)
+		}
+	}
+
+	locked, remaining := authenticator.LockoutStatus(appPath)
+	if !locked {
+		t.Fatal("Expected app to be locked out after exhausting attempts")
+	}
+	if remaining <= 0 || remaining > auth.DefaultLockoutDuration {
+		t.Errorf("Expected remaining lockout within (0, %s], got %s", auth.DefaultLockoutDuration, remaining)
+	}
+
+	// Other apps are unaffected
+	if locked, _ := authenticator.LockoutStatus("/usr/bin/otherapp"); locked {
+		t.Error("Expected other app not to be locked out")
+	}
+}
+
+// TestPerAppMaxAttempts tests that an app's own attempt limit overrides the
+// default
+func TestPerAppMaxAttempts(t *testing.T) {
+	hash, err := auth.GenerateHash([]byte("correct-password-123"), "pbkdf2")
+	if err != nil {
+		t.Fatalf("Failed to generate password hash: %v", err)
+	}
+	secretPath, cleanup := testutil.CreateTempFile(t, hash)
+	defer cleanup()
+
+	cfg := testutil.SetupTestConfig(t)
+	cfg.Auth.UseZeroKnowledgeProof = false
+	cfg.Auth.HashAlgorithm = "pbkdf2"
+	cfg.Auth.SecretPath = secretPath
+	cfg.BlockedApps = []config.BlockedApp{{Path: "/usr/bin/strictapp", MaxAttempts: 1}}
+
+	authenticator, err := auth.NewAuthenticator(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create authenticator: %v", err)
+	}
+
+	if remaining := authenticator.GetRemainingAttempts("/usr/bin/strictapp"); remaining != 1 {
+		t.Errorf("Expected 1 attempt for the app, got %d", remaining)
+	}
+	if remaining := authenticator.GetRemainingAttempts("/usr/bin/otherapp"); remaining != auth.DefaultMaxAuthAttempts {
+		t.Errorf("Expected %d attempts for other apps, got %d", auth.DefaultMaxAuthAttempts, remaining)
+	}
+
+	if _, err := authenticator.Authenticate([]byte("wrong-password"), "/usr/bin/strictapp"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if locked, _ := authenticator.LockoutStatus("/usr/bin/strictapp"); !locked {
+		t.Error("Expected the app to be locked out after one failed attempt")
+	}
+}
+
+// TestHashMigration tests that hashes of weaker algorithms are replaced by
+// argon2id hashes on the first successful authentication
+func TestHashMigration(t *testing.T) {
+	for _, algorithm := range []string{"bcrypt", "scrypt", "pbkdf2"} {
+		t.Run(algorithm, func(t *testing.T) {
+			authenticator, cleanup := testutil.SetupAuthenticatorWithPassword(
+				t, "test-password-123", algorithm, false)
+			defer cleanup()
+
+			// A wrong password leaves the hash alone
+			if ok, _ := authenticator.Authenticate([]byte("wrong-password"), "/usr/bin/testapp"); ok {
+				t.Fatal("Authentication with wrong password returned true")
+			}
+			stored, _ := os.ReadFile(authenticator.GetSecretPath())
+			if strings.HasPrefix(string(stored), "$argon2id$") {
+				t.Fatal("Expected a failed attempt not to migrate the hash")
+			}
+
+			ok, err := authenticator.Authenticate([]byte("test-password-123"), "/usr/bin/testapp")
+			if err != nil || !ok {
+				t.Fatalf("Authentication with correct password failed: %v", err)
+			}
+			stored, _ = os.ReadFile(authenticator.GetSecretPath())
+			if auth.NeedsRehash(stored, auth.DefaultArgon2Params) {
+				t.Errorf("Expected the hash to be migrated to argon2id, got %q", stored)
+			}
+
+			// The migrated hash still verifies
+			ok, err = authenticator.Authenticate([]byte("test-password-123"), "/usr/bin/testapp")
+			if err != nil || !ok {
+				t.Errorf("Authentication after migration failed: %v", err)
+			}
+		})
+	}
+}
+
+// TestReloadSecret tests that a secret changed by another authenticator is
+// picked up by a reload
+func TestReloadSecret(t *testing.T) {
+	secretPath, cleanup := testutil.CreateTempFile(t, mustHash(t, "old-password"))
+	defer cleanup()
+
+	cfg := testutil.SetupTestConfig(t)
+	cfg.Auth.UseZeroKnowledgeProof = false
+	cfg.Auth.SecretPath = secretPath
+	cfg.Auth.Argon2Memory = 1024
+	cfg.Auth.Argon2Iterations = 1
+	cfg.Auth.Argon2Parallelism = 1
+
+	running, err := auth.NewAuthenticator(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create authenticator: %v", err)
+	}
+	// Migrates the bcrypt hash, so the check below does not rewrite it
+	if ok, _ := running.AuthenticateUser([]byte("old-password"), "", -1); !ok {
+		t.Fatalf("Expected the old secret to be accepted")
+	}
+
+	passwd, err := auth.NewAuthenticator(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create authenticator: %v", err)
+	}
+	if !passwd.HasSecret() {
+		t.Fatalf("Expected the secret to be reported as set")
+	}
+	if err := passwd.SetSecret([]byte("new-password")); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+
+	if ok, _ := running.AuthenticateUser([]byte("old-password"), "", -1); !ok {
+		t.Fatalf("Expected the old secret to be used until reloaded")
+	}
+	if err := running.ReloadSecret(); err != nil {
+		t.Fatalf("ReloadSecret failed: %v", err)
+	}
+	if ok, err := running.AuthenticateUser([]byte("new-password"), "", -1); !ok {
+		t.Errorf("Expected the new secret to be accepted after reloading, got %v", err)
+	}
+	if ok, _ := running.AuthenticateUser([]byte("old-password"), "", -1); ok {
+		t.Errorf("Expected the old secret to be rejected after reloading")
+	}
+}
@@ -1,7 +1,10 @@
 package auth
 
 import (
+	"encoding/json"
 	"errors"
+	"os"
+	"path/filepath"
 	"sync"
 	"time"
 )
@@ -9,13 +12,17 @@ import (
 var (
 	ErrMaxAttemptsExceeded = errors.New("maximum authentication attempts exceeded")
 	ErrTempLockout         = errors.New("temporarily locked out")
+	ErrBackoff             = errors.New("too soon after a failed attempt")
 )
 
 // AuthAttempt tracks authentication attempts for an application
 type AuthAttempt struct {
-	FailedAttempts int
-	LastAttempt    time.Time
-	LockedUntil    time.Time
+	FailedAttempts int       `json:"failed_attempts"`
+	LastAttempt    time.Time `json:"last_attempt"`
+	LockedUntil    time.Time `json:"locked_until,omitempty"`
+
+	// NextAttempt is when the backoff after the last failure ends
+	NextAttempt time.Time `json:"next_attempt,omitempty"`
 }
 
 // BruteForceProtection manages authentication attempts and lockouts
@@ -24,6 +31,17 @@ type BruteForceProtection struct {
 	lockoutDuration time.Duration
 	attempts        map[string]*AuthAttempt
 	mu              sync.RWMutex
+
+	// appLimits override maxAttempts for individual apps
+	appLimits map[string]int
+
+	// backoffBase is the wait after the first failure, doubled for each
+	// further one up to backoffMax; 0 does not back off
+	backoffBase time.Duration
+	backoffMax  time.Duration
+
+	// stateFile keeps attempts across restarts; empty when not persisted
+	stateFile string
 }
 
 // NewBruteForceProtection creates a new brute force protection manager
@@ -32,27 +50,115 @@ func NewBruteForceProtection(maxAttempts int, lockoutDuration time.Duration) *Br
 		maxAttempts:     maxAttempts,
 		lockoutDuration: lockoutDuration,
 		attempts:        make(map[string]*AuthAttempt),
+		appLimits:       make(map[string]int),
+	}
+}
+
+// SetAppLimit overrides the number of failed attempts allowed for appPath
+func (b *BruteForceProtection) SetAppLimit(appPath string, maxAttempts int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.appLimits[appPath] = maxAttempts
+}
+
+// limit returns the number of failed attempts allowed for appPath. Caller
+// holds mu.
+func (b *BruteForceProtection) limit(appPath string) int {
+	if limit, ok := b.appLimits[appPath]; ok {
+		return limit
 	}
+	return b.maxAttempts
+}
+
+// SetBackoff makes each failed attempt refuse further ones for base,
+// doubling with every failure up to max
+func (b *BruteForceProtection) SetBackoff(base, max time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.backoffBase = base
+	b.backoffMax = max
+}
+
+// Persist loads the attempts kept in path and saves them there on every
+// change, so a restart does not reset counters or lift lockouts
+func (b *BruteForceProtection) Persist(path string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		attempts := make(map[string]*AuthAttempt)
+		if err := json.Unmarshal(data, &attempts); err != nil {
+			return err
+		}
+		for key, attempt := range attempts {
+			b.attempts[key] = attempt
+		}
+	}
+
+	// Fail now rather than on every attempt when path is not writable
+	b.stateFile = path
+	if err := b.save(); err != nil {
+		b.stateFile = ""
+		return err
+	}
+	return nil
+}
+
+// save writes the attempts to the state file. Caller holds mu.
+func (b *BruteForceProtection) save() error {
+	if b.stateFile == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(b.attempts)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(b.stateFile), 0700); err != nil {
+		return err
+	}
+	tmp := b.stateFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, b.stateFile)
 }
 
 // CheckAttempt verifies if an authentication attempt is allowed
 func (b *BruteForceProtection) CheckAttempt(appPath string) error {
-	b.mu.RLock()
-	attempt, exists := b.attempts[appPath]
-	b.mu.RUnlock()
+	b.mu.Lock()
+	defer b.mu.Unlock()
 
+	attempt, exists := b.attempts[appPath]
 	if !exists {
 		return nil
 	}
 
 	// Check if currently locked out
-	if time.Now().Before(attempt.LockedUntil) {
+	now := time.Now()
+	if now.Before(attempt.LockedUntil) {
 		return ErrTempLockout
 	}
+	if now.Before(attempt.NextAttempt) {
+		return ErrBackoff
+	}
 
 	// Check if max attempts exceeded
-	if attempt.FailedAttempts >= b.maxAttempts {
-		return ErrMaxAttemptsExceeded
+	if attempt.FailedAttempts >= b.limit(appPath) {
+		if attempt.LockedUntil.IsZero() {
+			return ErrMaxAttemptsExceeded
+		}
+
+		// The lockout is served, so counting starts over
+		attempt.FailedAttempts = 0
+		attempt.LockedUntil = time.Time{}
+		_ = b.save()
 	}
 
 	return nil
@@ -69,13 +175,27 @@ func (b *BruteForceProtection) RecordFailure(appPath string) {
 		b.attempts[appPath] = attempt
 	}
 
+	now := time.Now()
 	attempt.FailedAttempts++
-	attempt.LastAttempt = time.Now()
+	attempt.LastAttempt = now
+
+	// Each failure waits twice as long as the one before
+	if b.backoffBase > 0 {
+		wait := b.backoffBase
+		for i := 1; i < attempt.FailedAttempts && (b.backoffMax <= 0 || wait < b.backoffMax) && i < 32; i++ {
+			wait *= 2
+		}
+		if b.backoffMax > 0 && wait > b.backoffMax {
+			wait = b.backoffMax
+		}
+		attempt.NextAttempt = now.Add(wait)
+	}
 
 	// If max attempts reached, set lockout
-	if attempt.FailedAttempts >= b.maxAttempts {
-		attempt.LockedUntil = time.Now().Add(b.lockoutDuration)
+	if attempt.FailedAttempts >= b.limit(appPath) {
+		attempt.LockedUntil = now.Add(b.lockoutDuration)
 	}
+	_ = b.save()
 }
 
 // RecordSuccess resets the failed attempts counter
@@ -83,7 +203,10 @@ func (b *BruteForceProtection) RecordSuccess(appPath string) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	delete(b.attempts, appPath)
+	if _, exists := b.attempts[appPath]; exists {
+		delete(b.attempts, appPath)
+		_ = b.save()
+	}
 }
 
 // GetRemainingAttempts returns the number of attempts remaining
@@ -93,17 +216,18 @@ func (b *BruteForceProtection) GetRemainingAttempts(appPath string) int {
 
 	attempt, exists := b.attempts[appPath]
 	if !exists {
-		return b.maxAttempts
+		return b.limit(appPath)
 	}
 
-	remaining := b.maxAttempts - attempt.FailedAttempts
+	remaining := b.limit(appPath) - attempt.FailedAttempts
 	if remaining < 0 {
 		return 0
 	}
 	return remaining
 }
 
-// GetLockoutDuration returns the remaining lockout duration
+// GetLockoutDuration returns the remaining lockout duration, or of the
+// backoff when that ends later
 func (b *BruteForceProtection) GetLockoutDuration(appPath string) time.Duration {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
@@ -113,8 +237,12 @@ func (b *BruteForceProtection) GetLockoutDuration(appPath string) time.Duration
 		return 0
 	}
 
-	if time.Now().Before(attempt.LockedUntil) {
-		return time.Until(attempt.LockedUntil)
+	until := attempt.LockedUntil
+	if attempt.NextAttempt.After(until) {
+		until = attempt.NextAttempt
+	}
+	if time.Now().Before(until) {
+		return time.Until(until)
 	}
 	return 0
 }
@@ -125,4 +253,5 @@ func (b *BruteForceProtection) ResetAttempts(appPath string) {
 	defer b.mu.Unlock()
 
 	delete(b.attempts, appPath)
+	_ = b.save()
 }
@@ -0,0 +1,106 @@
+package auth_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"wyrmlock/internal/auth"
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/testutil"
+)
+
+// TestBruteForceBackoff tests that each failure refuses attempts for twice
+// as long as the one before, up to the maximum
+func TestBruteForceBackoff(t *testing.T) {
+	b := auth.NewBruteForceProtection(10, time.Minute)
+	b.SetBackoff(time.Second, 3*time.Second)
+	app := "/usr/bin/firefox"
+
+	for i, want := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second} {
+		b.RecordFailure(app)
+		if err := b.CheckAttempt(app); !errors.Is(err, auth.ErrBackoff) {
+			t.Fatalf("Expected a backoff after failure %d, got %v", i+1, err)
+		}
+		if got := b.GetLockoutDuration(app); got <= want-time.Second/2 || got > want {
+			t.Errorf("Expected a backoff of %s after failure %d, got %s", want, i+1, got)
+		}
+	}
+
+	b.RecordSuccess(app)
+	if err := b.CheckAttempt(app); err != nil {
+		t.Errorf("Expected a success to end the backoff, got %v", err)
+	}
+}
+
+// TestBruteForceLockoutExpiry tests that counting starts over once a
+// lockout is served
+func TestBruteForceLockoutExpiry(t *testing.T) {
+	b := auth.NewBruteForceProtection(2, 50*time.Millisecond)
+	app := "/usr/bin/firefox"
+
+	b.RecordFailure(app)
+	b.RecordFailure(app)
+	if err := b.CheckAttempt(app); !errors.Is(err, auth.ErrTempLockout) {
+		t.Fatalf("Expected a lockout, got %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if err := b.CheckAttempt(app); err != nil {
+		t.Fatalf("Expected attempts after the lockout, got %v", err)
+	}
+	if remaining := b.GetRemainingAttempts(app); remaining != 2 {
+		t.Errorf("Expected 2 attempts after the lockout, got %d", remaining)
+	}
+}
+
+// TestBruteForcePersist tests that failures and lockouts survive a restart
+func TestBruteForcePersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lockouts.json")
+	app := "/usr/bin/firefox"
+
+	b := auth.NewBruteForceProtection(2, time.Minute)
+	if err := b.Persist(path); err != nil {
+		t.Fatalf("Persist failed: %v", err)
+	}
+	b.RecordFailure(app)
+	b.RecordFailure(app)
+
+	restarted := auth.NewBruteForceProtection(2, time.Minute)
+	if err := restarted.Persist(path); err != nil {
+		t.Fatalf("Persist failed: %v", err)
+	}
+	if err := restarted.CheckAttempt(app); !errors.Is(err, auth.ErrTempLockout) {
+		t.Errorf("Expected the lockout to survive a restart, got %v", err)
+	}
+}
+
+// TestGlobalLockoutScope tests that a global scope counts failures for all
+// apps together
+func TestGlobalLockoutScope(t *testing.T) {
+	hash, err := auth.GenerateHash([]byte("correct-password-123"), "pbkdf2")
+	if err != nil {
+		t.Fatalf("Failed to generate password hash: %v", err)
+	}
+	secretPath, cleanup := testutil.CreateTempFile(t, hash)
+	defer cleanup()
+
+	cfg := testutil.SetupTestConfig(t)
+	cfg.Auth.UseZeroKnowledgeProof = false
+	cfg.Auth.HashAlgorithm = "pbkdf2"
+	cfg.Auth.SecretPath = secretPath
+	cfg.Auth.MaxAttempts = 2
+	cfg.Auth.LockoutScope = config.LockoutScopeGlobal
+
+	authenticator, err := auth.NewAuthenticator(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create authenticator: %v", err)
+	}
+
+	authenticator.AuthenticateUser([]byte("wrong-password"), "/usr/bin/firefox", -1)
+	authenticator.AuthenticateUser([]byte("wrong-password"), "/usr/bin/chromium", -1)
+	if locked, _ := authenticator.LockoutStatus("/usr/bin/thunderbird"); !locked {
+		t.Error("Expected failures on other apps to lock out every app")
+	}
+}
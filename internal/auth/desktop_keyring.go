@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/secretservice"
+)
+
+// desktopKeyringAccount is the account of the unlock secret in a user's
+// desktop keyring
+const desktopKeyringAccount = "unlock"
+
+// desktopKeyringLabel is the item label keyring managers show
+const desktopKeyringLabel = "wyrmlock unlock secret"
+
+// DesktopKeyringTimeout bounds a keyring access, which may wait for the
+// user to unlock their keyring
+const DesktopKeyringTimeout = 10 * time.Second
+
+// desktopSecret is a secret read from a user's desktop keyring
+type desktopSecret struct {
+	secret []byte
+}
+
+// wipe clears the secret once the attempt is over
+func (d *desktopSecret) wipe() {
+	if d == nil {
+		return
+	}
+	wipeBytes(d.secret)
+}
+
+// lookupDesktopSecret reads the secret uid keeps in their desktop keyring,
+// or returns nil when desktop keyrings are off or theirs has none or
+// cannot be reached, e.g. because they are not logged in. Users write
+// their own keyrings, so only a secret an administrator pinned is used;
+// anything else could be a secret the user replaced to get around the one
+// they were given.
+func (a *Authenticator) lookupDesktopSecret(uid int) *desktopSecret {
+	if !a.config.Auth.DesktopKeyring || uid < 0 {
+		return nil
+	}
+
+	u, err := user.LookupId(strconv.Itoa(uid))
+	if err != nil {
+		a.logger.Debugf("Desktop keyring of UID %d unavailable: %v", uid, err)
+		return nil
+	}
+	pins, err := loadDesktopPins(a.config.Auth.DesktopKeyringPinFile)
+	if err != nil {
+		a.logger.Warnf("Failed to read desktop keyring pins, using the global secret: %v", err)
+		return nil
+	}
+	pin, ok := pins[u.Username]
+	if !ok {
+		a.logger.Debugf("No desktop keyring secret pinned for %s", u.Username)
+		return nil
+	}
+
+	keyring, err := secretservice.ForUser(uid)
+	if err != nil {
+		a.logger.Debugf("Desktop keyring of UID %d unavailable: %v", uid, err)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DesktopKeyringTimeout)
+	defer cancel()
+	secret, err := keyring.Lookup(ctx, desktopKeyringAccount)
+	if err != nil {
+		if !errors.Is(err, secretservice.ErrNotFound) {
+			a.logger.Warnf("Failed to read the desktop keyring of UID %d, using the global secret: %v", uid, err)
+		}
+		return nil
+	}
+	if !matchesPin(secret, pin) {
+		a.logger.Warnf("Desktop keyring secret of %s does not match its pin, using the global secret", u.Username)
+		wipeBytes(secret)
+		return nil
+	}
+	return &desktopSecret{secret: secret}
+}
+
+// matchesPin reports whether secret hashes to pin
+func matchesPin(secret []byte, pin string) bool {
+	sum := sha256.Sum256(secret)
+	want, err := hex.DecodeString(pin)
+	return err == nil && subtle.ConstantTimeCompare(sum[:], want) == 1
+}
+
+// wipeBytes zeroes b
+func wipeBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// PinDesktopSecret trusts the secret username currently keeps in their
+// desktop keyring, recording its hash in the pin file. Users who change
+// the secret afterwards need it pinned again.
+func PinDesktopSecret(cfg *config.Config, username string) error {
+	u, err := user.Lookup(username)
+	if err != nil {
+		return err
+	}
+	uid, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		return fmt.Errorf("invalid UID of %s: %w", username, err)
+	}
+	keyring, err := secretservice.ForUser(uid)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DesktopKeyringTimeout)
+	defer cancel()
+	secret, err := keyring.Lookup(ctx, desktopKeyringAccount)
+	if errors.Is(err, secretservice.ErrNotFound) {
+		return ErrSecretNotFound
+	}
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(secret)
+	wipeBytes(secret)
+
+	pins, err := loadDesktopPins(cfg.Auth.DesktopKeyringPinFile)
+	if err != nil {
+		return err
+	}
+	pins[username] = hex.EncodeToString(sum[:])
+	return saveDesktopPins(cfg.Auth.DesktopKeyringPinFile, pins)
+}
+
+// UnpinDesktopSecret stops trusting the desktop keyring secret of
+// username
+func UnpinDesktopSecret(cfg *config.Config, username string) error {
+	pins, err := loadDesktopPins(cfg.Auth.DesktopKeyringPinFile)
+	if err != nil {
+		return err
+	}
+	if _, ok := pins[username]; !ok {
+		return ErrSecretNotFound
+	}
+	delete(pins, username)
+	return saveDesktopPins(cfg.Auth.DesktopKeyringPinFile, pins)
+}
+
+// loadDesktopPins reads the pinned hashes of desktop keyring secrets by
+// user name; a missing file pins none
+func loadDesktopPins(path string) (map[string]string, error) {
+	pins := make(map[string]string)
+	if path == "" {
+		return pins, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return pins, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &pins); err != nil {
+		return nil, fmt.Errorf("failed to parse desktop keyring pins: %w", err)
+	}
+	return pins, nil
+}
+
+// saveDesktopPins writes the pin file atomically, readable by root only
+func saveDesktopPins(path string, pins map[string]string) error {
+	if path == "" {
+		return errors.New("no desktop keyring pin file configured")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create desktop keyring pin directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(pins, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode desktop keyring pins: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write desktop keyring pins: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// SetDesktopSecret saves the calling user's secret in their own desktop
+// keyring, in the form SetSecret uses for the global one. It needs no
+// access to the global secret, so users can run it themselves; the daemon
+// uses it once an administrator pins it with PinDesktopSecret.
+func SetDesktopSecret(cfg *config.Config, secret []byte) error {
+	stored, err := (&Authenticator{config: cfg}).storedForm(secret)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DesktopKeyringTimeout)
+	defer cancel()
+	return secretservice.ForCurrentUser().Store(ctx, desktopKeyringAccount, desktopKeyringLabel, stored)
+}
+
+// RemoveDesktopSecret deletes the calling user's secret from their desktop
+// keyring
+func RemoveDesktopSecret() error {
+	ctx, cancel := context.WithTimeout(context.Background(), DesktopKeyringTimeout)
+	defer cancel()
+
+	err := secretservice.ForCurrentUser().Clear(ctx, desktopKeyringAccount)
+	if errors.Is(err, secretservice.ErrNotFound) {
+		return ErrSecretNotFound
+	}
+	return err
+}
+
+// HasDesktopSecret reports whether the calling user keeps a secret in their
+// desktop keyring
+func HasDesktopSecret() (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), DesktopKeyringTimeout)
+	defer cancel()
+
+	secret, err := secretservice.ForCurrentUser().Lookup(ctx, desktopKeyringAccount)
+	if errors.Is(err, secretservice.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	wipeBytes(secret)
+	return true, nil
+}
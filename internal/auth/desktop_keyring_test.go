@@ -0,0 +1,116 @@
+package auth_test
+
+import (
+	"errors"
+	"os"
+	"os/user"
+	"path/filepath"
+	"testing"
+
+	"wyrmlock/internal/auth"
+	"wyrmlock/internal/testutil"
+)
+
+// fakeSecretTool keeps one item in a file, like secret-tool does in the
+// keyring
+const fakeSecretTool = `#!/bin/sh
+store="$FAKE_KEYRING"
+case "$1" in
+lookup) [ -f "$store" ] || exit 1; cat "$store" ;;
+store) cat > "$store" ;;
+clear) [ -f "$store" ] || exit 1; rm "$store" ;;
+esac
+`
+
+// TestDesktopKeyring tests that a pinned secret in the launching user's
+// desktop keyring is used instead of the global one, that unpinned or
+// replaced secrets are not, and that users without one keep using the
+// global secret
+func TestDesktopKeyring(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "secret-tool"), []byte(fakeSecretTool), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("FAKE_KEYRING", filepath.Join(dir, "item"))
+
+	secretPath, cleanup := testutil.CreateTempFile(t, mustHash(t, "global-password"))
+	defer cleanup()
+
+	cfg := testutil.SetupTestConfig(t)
+	cfg.Auth.UseZeroKnowledgeProof = false
+	cfg.Auth.SecretPath = secretPath
+	cfg.Auth.UserSecretDir = t.TempDir()
+	cfg.Auth.DesktopKeyring = true
+	cfg.Auth.DesktopKeyringPinFile = filepath.Join(t.TempDir(), "pins.json")
+
+	a, err := auth.NewAuthenticator(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create authenticator: %v", err)
+	}
+	if err := auth.SetDesktopSecret(cfg, []byte("keyring-password")); err != nil {
+		t.Fatalf("SetDesktopSecret failed: %v", err)
+	}
+	if found, err := auth.HasDesktopSecret(); err != nil || !found {
+		t.Fatalf("Expected the keyring to hold a secret, got %v, %v", found, err)
+	}
+
+	uid := os.Getuid()
+	current, err := user.Current()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := a.AuthenticateUser([]byte("keyring-password"), "/usr/bin/firefox", uid); err != nil || ok {
+		t.Fatalf("Expected an unpinned keyring secret to be ignored, got %v, %v", ok, err)
+	}
+	if err := auth.PinDesktopSecret(cfg, current.Username); err != nil {
+		t.Fatalf("PinDesktopSecret failed: %v", err)
+	}
+
+	tests := []struct {
+		name, password string
+		uid            int
+		want           bool
+	}{
+		{"keyring secret", "keyring-password", uid, true},
+		{"global secret refused", "global-password", uid, false},
+		{"unknown user uses global", "global-password", -1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := a.AuthenticateUser([]byte(tt.password), "/usr/bin/firefox", tt.uid)
+			if err != nil {
+				t.Fatalf("AuthenticateUser failed: %v", err)
+			}
+			if ok != tt.want {
+				t.Errorf("AuthenticateUser = %v, want %v", ok, tt.want)
+			}
+		})
+	}
+
+	// A secret the user replaces after pinning is not trusted
+	if err := auth.SetDesktopSecret(cfg, []byte("replaced-password")); err != nil {
+		t.Fatalf("SetDesktopSecret failed: %v", err)
+	}
+	if ok, err := a.AuthenticateUser([]byte("replaced-password"), "/usr/bin/firefox", uid); err != nil || ok {
+		t.Errorf("Expected a replaced keyring secret to be refused, got %v, %v", ok, err)
+	}
+
+	if err := auth.UnpinDesktopSecret(cfg, current.Username); err != nil {
+		t.Fatalf("UnpinDesktopSecret failed: %v", err)
+	}
+	if err := auth.UnpinDesktopSecret(cfg, current.Username); !errors.Is(err, auth.ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound unpinning twice, got %v", err)
+	}
+
+	if err := auth.RemoveDesktopSecret(); err != nil {
+		t.Fatalf("RemoveDesktopSecret failed: %v", err)
+	}
+	if err := auth.RemoveDesktopSecret(); !errors.Is(err, auth.ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound removing twice, got %v", err)
+	}
+	ok, err := a.AuthenticateUser([]byte("global-password"), "/usr/bin/firefox", uid)
+	if err != nil || !ok {
+		t.Errorf("Expected the global secret once the keyring item is gone, got %v, %v", ok, err)
+	}
+}
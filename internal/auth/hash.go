@@ -216,32 +216,61 @@ func generateBcryptHash(password []byte) ([]byte, error) {
 	return hash, nil
 }
 
-// generateArgon2idHash creates an argon2id hash of a password
+// Argon2Params are the cost parameters of argon2id hashes
+type Argon2Params struct {
+	// Memory is the memory cost in KiB
+	Memory uint32
+
+	// Iterations is the number of passes over the memory
+	Iterations uint32
+
+	// Parallelism is the number of threads
+	Parallelism uint8
+}
+
+// DefaultArgon2Params are used when no parameters are configured
+var DefaultArgon2Params = Argon2Params{
+	Memory:      65536, // 64MB
+	Iterations:  3,
+	Parallelism: 2,
+}
+
+// argon2KeyLength is the length of the derived key
+const argon2KeyLength = 32
+
+// generateArgon2idHash creates an argon2id hash of a password with the
+// default parameters
 func generateArgon2idHash(password []byte) ([]byte, error) {
+	return GenerateArgon2idHash(password, DefaultArgon2Params)
+}
+
+// GenerateArgon2idHash creates an argon2id hash of a password
+func GenerateArgon2idHash(password []byte, params Argon2Params) ([]byte, error) {
 	// Generate a random salt
 	salt := make([]byte, 16)
 	if _, err := rand.Read(salt); err != nil {
 		return nil, fmt.Errorf("failed to generate salt: %w", err)
 	}
 
-	// Parameters for argon2id
-	const memory = 65536  // 64MB
-	const iterations = 3  // Number of iterations
-	const parallelism = 2 // Number of parallel threads
-	const keyLength = 32  // Length of the derived key
-
 	// Generate hash
-	hash := argon2.IDKey(password, salt, iterations, memory, parallelism, keyLength)
+	hash := argon2.IDKey(password, salt, params.Iterations, params.Memory, params.Parallelism, argon2KeyLength)
 
 	// Format the result as: $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
 	saltB64 := base64.RawStdEncoding.EncodeToString(salt)
 	hashB64 := base64.RawStdEncoding.EncodeToString(hash)
 	encodedHash := fmt.Sprintf("$argon2id$v=19$m=%d,t=%d,p=%d$%s$%s",
-		memory, iterations, parallelism, saltB64, hashB64)
+		params.Memory, params.Iterations, params.Parallelism, saltB64, hashB64)
 
 	return []byte(encodedHash), nil
 }
 
+// NeedsRehash reports whether a stored hash should be replaced by an
+// argon2id hash with params: it uses a weaker algorithm or other costs
+func NeedsRehash(hash []byte, params Argon2Params) bool {
+	want := fmt.Sprintf("$argon2id$v=19$m=%d,t=%d,p=%d$", params.Memory, params.Iterations, params.Parallelism)
+	return !strings.HasPrefix(string(hash), want)
+}
+
 // generateScryptHash creates a scrypt hash of a password
 func generateScryptHash(password []byte) ([]byte, error) {
 	// Generate a random salt
@@ -308,7 +337,7 @@ func Compare(password, hash []byte) (bool, error) {
 	
 	// Detect hash algorithm from prefix
 	switch {
-	case strings.HasPrefix(hashStr, "$2a$"):
+	case strings.HasPrefix(hashStr, "$2a$"), strings.HasPrefix(hashStr, "$2b$"), strings.HasPrefix(hashStr, "$2y$"):
 		return compareBcrypt(password, hash)
 	case strings.HasPrefix(hashStr, "$argon2id$"):
 		return compareArgon2id(password, hash)
@@ -317,6 +346,6 @@ func Compare(password, hash []byte) (bool, error) {
 	case strings.HasPrefix(hashStr, "$pbkdf2-sha256$"):
 		return comparePBKDF2(password, hash)
 	default:
-		return false, fmt.Errorf("unknown hash format: %s", hashStr[:10])
+		return false, errors.New("unknown hash format")
 	}
 }
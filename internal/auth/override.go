@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"wyrmlock/internal/config"
+)
+
+// overrideCodeLength is the number of base32 characters in the emergency
+// override code, 120 bits of entropy. It is longer than a recovery code so
+// the two cannot be confused.
+const overrideCodeLength = 24
+
+// overrideAttemptKey is the brute force counter of override attempts
+const overrideAttemptKey = "override"
+
+// IsOverrideCode reports whether input is shaped like the emergency
+// override code. Case, spaces and dashes do not matter.
+func IsOverrideCode(input []byte) bool {
+	_, ok := normalizeCode(input, overrideCodeLength)
+	return ok
+}
+
+// SetOverrideCode creates a new emergency override code, replacing the
+// old one, and returns it for the user to keep somewhere safe. Only its
+// hash is stored.
+func SetOverrideCode(cfg *config.Config) (string, error) {
+	if cfg.Auth.OverrideCodeFile == "" {
+		return "", fmt.Errorf("no override_code_file is configured")
+	}
+
+	code, hash, err := (&Authenticator{config: cfg}).generateCode(overrideCodeLength)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate override code: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cfg.Auth.OverrideCodeFile), 0700); err != nil {
+		return "", fmt.Errorf("failed to create override code directory: %w", err)
+	}
+	tmp := cfg.Auth.OverrideCodeFile + ".tmp"
+	if err := os.WriteFile(tmp, hash, 0600); err != nil {
+		return "", fmt.Errorf("failed to write override code: %w", err)
+	}
+	if err := os.Rename(tmp, cfg.Auth.OverrideCodeFile); err != nil {
+		return "", fmt.Errorf("failed to write override code: %w", err)
+	}
+	return code, nil
+}
+
+// CheckOverrideCode reports whether input is the emergency override code.
+// It is separate from the password and never accepted by AuthenticateUser;
+// attempts are rate limited like passwords.
+func (a *Authenticator) CheckOverrideCode(input []byte) (bool, error) {
+	code, ok := normalizeCode(input, overrideCodeLength)
+	if !ok || a.config.Auth.OverrideCodeFile == "" {
+		return false, nil
+	}
+
+	if err := a.bruteForceProtection.CheckAttempt(overrideAttemptKey); err != nil {
+		return false, err
+	}
+
+	hash, err := os.ReadFile(a.config.Auth.OverrideCodeFile)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read override code: %w", err)
+	}
+
+	match, err := Compare([]byte(code), hash)
+	if err != nil {
+		return false, err
+	}
+	if match {
+		a.bruteForceProtection.RecordSuccess(overrideAttemptKey)
+	} else {
+		a.bruteForceProtection.RecordFailure(overrideAttemptKey)
+	}
+	return match, nil
+}
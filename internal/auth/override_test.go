@@ -0,0 +1,53 @@
+package auth_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"wyrmlock/internal/auth"
+	"wyrmlock/internal/testutil"
+)
+
+// TestOverrideCode tests that the override code is recognized by its own
+// check only and never unlocks as a password
+func TestOverrideCode(t *testing.T) {
+	secretPath, cleanup := testutil.CreateTempFile(t, mustHash(t, "password"))
+	defer cleanup()
+
+	cfg := testutil.SetupTestConfig(t)
+	cfg.Auth.UseZeroKnowledgeProof = false
+	cfg.Auth.SecretPath = secretPath
+	cfg.Auth.OverrideCodeFile = filepath.Join(t.TempDir(), "override-code")
+	cfg.Auth.Argon2Memory = 1024
+	cfg.Auth.Argon2Iterations = 1
+	cfg.Auth.Argon2Parallelism = 1
+
+	a, err := auth.NewAuthenticator(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create authenticator: %v", err)
+	}
+
+	// Nothing is accepted before a code is set
+	if ok, err := a.CheckOverrideCode([]byte("aaaa-aaaa-aaaa-aaaa-aaaa-aaaa")); err != nil || ok {
+		t.Fatalf("Expected no override without a code, got %v, %v", ok, err)
+	}
+
+	code, err := auth.SetOverrideCode(cfg)
+	if err != nil {
+		t.Fatalf("SetOverrideCode failed: %v", err)
+	}
+	if !auth.IsOverrideCode([]byte(code)) || auth.IsRecoveryCode([]byte(code)) {
+		t.Fatalf("Expected %q to be shaped like an override code only", code)
+	}
+
+	if ok, err := a.CheckOverrideCode([]byte(strings.ToUpper(code))); err != nil || !ok {
+		t.Errorf("Expected the override code to be accepted, got %v, %v", ok, err)
+	}
+	if ok, _ := a.CheckOverrideCode([]byte("password")); ok {
+		t.Errorf("Expected the password not to be accepted as override code")
+	}
+	if ok, _ := a.AuthenticateUser([]byte(code), "/usr/bin/firefox", -1); ok {
+		t.Errorf("Expected the override code not to unlock as a password")
+	}
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"fmt"
+	"os"
+)
+
+// parentSecretFile returns the file holding the parent password
+func (a *Authenticator) parentSecretFile() (string, error) {
+	if a.config.Auth.ParentSecretFile == "" {
+		return "", fmt.Errorf("no parent_secret_file is configured")
+	}
+	return a.config.Auth.ParentSecretFile, nil
+}
+
+// SetParentSecret saves the parent password of the parental-control
+// profiles, in the form SetSecret uses for the global one
+func (a *Authenticator) SetParentSecret(secret []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	path, err := a.parentSecretFile()
+	if err != nil {
+		return err
+	}
+	return a.writeSecretFile(path, secret)
+}
+
+// HasParentSecret reports whether the parent password is set
+func (a *Authenticator) HasParentSecret() bool {
+	path, err := a.parentSecretFile()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// IsChild reports whether uid is a child account of a parental-control
+// profile
+func (a *Authenticator) IsChild(uid int) bool {
+	_, ok := a.config.ProfileFor(uid)
+	return ok
+}
@@ -0,0 +1,79 @@
+package auth_test
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"wyrmlock/internal/auth"
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/testutil"
+)
+
+// TestParentSecret tests that launches by a child account are only
+// unlocked by the parent password, whatever other secrets are set
+func TestParentSecret(t *testing.T) {
+	current, err := user.Current()
+	if err != nil {
+		t.Skipf("Cannot look up the current user: %v", err)
+	}
+
+	secretPath, cleanup := testutil.CreateTempFile(t, mustHash(t, "global-password"))
+	defer cleanup()
+
+	cfg := testutil.SetupTestConfig(t)
+	cfg.Auth.UseZeroKnowledgeProof = false
+	cfg.Auth.SecretPath = secretPath
+	cfg.Auth.AppSecretDir = t.TempDir()
+	cfg.Auth.UserSecretDir = t.TempDir()
+	cfg.Auth.ParentSecretFile = filepath.Join(t.TempDir(), "parent-secret")
+	cfg.BlockedApps = []config.BlockedApp{{Path: "/usr/bin/steam", Secret: "games"}}
+	cfg.Profiles = []config.Profile{{Name: "kids", Users: []string{strconv.Itoa(os.Getuid())}}}
+
+	a, err := auth.NewAuthenticator(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create authenticator: %v", err)
+	}
+	if !a.IsChild(os.Getuid()) || a.IsChild(-1) {
+		t.Fatal("Expected only the current user to be a child account")
+	}
+	if a.HasParentSecret() {
+		t.Fatal("Expected no parent secret before it is set")
+	}
+	if err := a.SetParentSecret([]byte("parent-password")); err != nil {
+		t.Fatalf("SetParentSecret failed: %v", err)
+	}
+	if err := a.SetUserSecret(current.Username, []byte("my-password")); err != nil {
+		t.Fatalf("SetUserSecret failed: %v", err)
+	}
+	if err := a.SetAppSecret("games", []byte("games-password")); err != nil {
+		t.Fatalf("SetAppSecret failed: %v", err)
+	}
+
+	uid := os.Getuid()
+	tests := []struct {
+		name, app, password string
+		uid                 int
+		want                bool
+	}{
+		{"parent password", "/usr/bin/firefox", "parent-password", uid, true},
+		{"own secret refused", "/usr/bin/firefox", "my-password", uid, false},
+		{"global secret refused", "/usr/bin/firefox", "global-password", uid, false},
+		{"parent password wins over app secret", "/usr/bin/steam", "parent-password", uid, true},
+		{"app secret refused", "/usr/bin/steam", "games-password", uid, false},
+		{"other users unaffected", "/usr/bin/firefox", "global-password", -1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := a.AuthenticateUser([]byte(tt.password), tt.app, tt.uid)
+			if err != nil {
+				t.Fatalf("AuthenticateUser failed: %v", err)
+			}
+			if ok != tt.want {
+				t.Errorf("AuthenticateUser = %v, want %v", ok, tt.want)
+			}
+		})
+	}
+}
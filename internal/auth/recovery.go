@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"wyrmlock/internal/config"
+)
+
+// RecoveryCodeCount is how many recovery codes are generated at a time
+const RecoveryCodeCount = 10
+
+// recoveryCodeLength is the number of base32 characters in a code, 80
+// bits of entropy
+const recoveryCodeLength = 16
+
+// recoveryEncoding spells generated codes without padding
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// recoveryCodes is the recovery code file
+type recoveryCodes struct {
+	Created time.Time      `json:"created"`
+	Codes   []recoveryCode `json:"codes"`
+}
+
+// recoveryCode is a hashed code and when it was used
+type recoveryCode struct {
+	Hash   string     `json:"hash"`
+	UsedAt *time.Time `json:"used_at,omitempty"`
+}
+
+// IsRecoveryCode reports whether input is shaped like a recovery code, so
+// a prompt can tell it from a password. Case, spaces and dashes do not
+// matter.
+func IsRecoveryCode(input []byte) bool {
+	_, ok := normalizeRecoveryCode(input)
+	return ok
+}
+
+// normalizeRecoveryCode returns the canonical form of a recovery code
+func normalizeRecoveryCode(input []byte) (string, bool) {
+	return normalizeCode(input, recoveryCodeLength)
+}
+
+// normalizeCode returns the canonical form of a generated code of length
+// base32 characters, ignoring case, spaces and dashes
+func normalizeCode(input []byte, length int) (string, bool) {
+	var b strings.Builder
+	for _, c := range string(input) {
+		switch {
+		case c == '-' || c == ' ':
+			continue
+		case c >= 'A' && c <= 'Z':
+			c += 'a' - 'A'
+		}
+		if !(c >= 'a' && c <= 'z') && !(c >= '2' && c <= '7') {
+			return "", false
+		}
+		b.WriteRune(c)
+	}
+	if b.Len() != length {
+		return "", false
+	}
+	return b.String(), true
+}
+
+// GenerateRecoveryCodes creates a new set of recovery codes, replacing any
+// generated before, and returns them for the user to write down. Only
+// their hashes are stored.
+func GenerateRecoveryCodes(cfg *config.Config) ([]string, error) {
+	if cfg.Auth.RecoveryCodeFile == "" {
+		return nil, fmt.Errorf("no recovery_code_file is configured")
+	}
+	a := &Authenticator{config: cfg}
+
+	file := recoveryCodes{Created: time.Now()}
+	codes := make([]string, 0, RecoveryCodeCount)
+	for i := 0; i < RecoveryCodeCount; i++ {
+		code, hash, err := a.generateCode(recoveryCodeLength)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		file.Codes = append(file.Codes, recoveryCode{Hash: string(hash)})
+		codes = append(codes, code)
+	}
+
+	if err := saveRecoveryCodes(cfg.Auth.RecoveryCodeFile, &file); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// generateCode returns a random code of length base32 characters, in
+// groups of four for writing down, and its argon2id hash
+func (a *Authenticator) generateCode(length int) (string, []byte, error) {
+	raw := make([]byte, length*5/8)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, err
+	}
+	code := strings.ToLower(recoveryEncoding.EncodeToString(raw))
+
+	hash, err := GenerateArgon2idHash([]byte(code), a.argon2Params())
+	if err != nil {
+		return "", nil, err
+	}
+
+	groups := make([]string, 0, length/4)
+	for i := 0; i < len(code); i += 4 {
+		groups = append(groups, code[i:min(i+4, len(code))])
+	}
+	return strings.Join(groups, "-"), hash, nil
+}
+
+// RecoveryCodesRemaining returns how many recovery codes are unused; 0
+// when none were generated
+func RecoveryCodesRemaining(cfg *config.Config) (int, error) {
+	file, err := loadRecoveryCodes(cfg.Auth.RecoveryCodeFile)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	remaining := 0
+	for _, code := range file.Codes {
+		if code.UsedAt == nil {
+			remaining++
+		}
+	}
+	return remaining, nil
+}
+
+// useRecoveryCode checks input against the unused recovery codes and
+// marks the matching one used, so each code unlocks only once
+func (a *Authenticator) useRecoveryCode(input []byte) bool {
+	code, ok := normalizeRecoveryCode(input)
+	if !ok || a.config.Auth.RecoveryCodeFile == "" {
+		return false
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	file, err := loadRecoveryCodes(a.config.Auth.RecoveryCodeFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			a.logger.Warnf("Failed to read recovery codes: %v", err)
+		}
+		return false
+	}
+
+	for i := range file.Codes {
+		if file.Codes[i].UsedAt != nil {
+			continue
+		}
+		if match, err := Compare([]byte(code), []byte(file.Codes[i].Hash)); err != nil || !match {
+			continue
+		}
+
+		// Refuse the code unless it can be marked used
+		now := time.Now()
+		file.Codes[i].UsedAt = &now
+		if err := saveRecoveryCodes(a.config.Auth.RecoveryCodeFile, file); err != nil {
+			a.logger.Errorf("Refusing recovery code that cannot be marked used: %v", err)
+			return false
+		}
+		a.logger.Warnf("Unlocked with a recovery code; generate new codes once the password or key is restored")
+		return true
+	}
+	return false
+}
+
+// loadRecoveryCodes reads the recovery code file
+func loadRecoveryCodes(path string) (*recoveryCodes, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file recoveryCodes
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse recovery codes: %w", err)
+	}
+	return &file, nil
+}
+
+// saveRecoveryCodes writes the recovery code file atomically, readable by
+// root only
+func saveRecoveryCodes(path string, file *recoveryCodes) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create recovery code directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode recovery codes: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write recovery codes: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
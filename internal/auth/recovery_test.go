@@ -0,0 +1,85 @@
+package auth_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"wyrmlock/internal/auth"
+	"wyrmlock/internal/testutil"
+)
+
+// TestRecoveryCodes tests that each recovery code unlocks exactly once and
+// that the password keeps working alongside them
+func TestRecoveryCodes(t *testing.T) {
+	secretPath, cleanup := testutil.CreateTempFile(t, mustHash(t, "password"))
+	defer cleanup()
+
+	cfg := testutil.SetupTestConfig(t)
+	cfg.Auth.UseZeroKnowledgeProof = false
+	cfg.Auth.SecretPath = secretPath
+	cfg.Auth.RecoveryCodeFile = filepath.Join(t.TempDir(), "recovery-codes.json")
+	cfg.Auth.Argon2Memory = 1024
+	cfg.Auth.Argon2Iterations = 1
+	cfg.Auth.Argon2Parallelism = 1
+
+	if remaining, err := auth.RecoveryCodesRemaining(cfg); err != nil || remaining != 0 {
+		t.Fatalf("Expected no codes before generating, got %d, %v", remaining, err)
+	}
+
+	codes, err := auth.GenerateRecoveryCodes(cfg)
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes failed: %v", err)
+	}
+	if len(codes) != auth.RecoveryCodeCount {
+		t.Fatalf("Expected %d codes, got %d", auth.RecoveryCodeCount, len(codes))
+	}
+
+	a, err := auth.NewAuthenticator(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create authenticator: %v", err)
+	}
+
+	// Codes may be typed in upper case and without dashes
+	typed := strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))
+	if !auth.IsRecoveryCode([]byte(typed)) {
+		t.Fatalf("Expected %q to look like a recovery code", typed)
+	}
+	if ok, err := a.AuthenticateUser([]byte(typed), "/usr/bin/firefox", -1); err != nil || !ok {
+		t.Fatalf("Expected the recovery code to unlock, got %v, %v", ok, err)
+	}
+	if ok, _ := a.AuthenticateUser([]byte(codes[0]), "/usr/bin/firefox", -1); ok {
+		t.Errorf("Expected a used recovery code to be refused")
+	}
+	if remaining, err := auth.RecoveryCodesRemaining(cfg); err != nil || remaining != auth.RecoveryCodeCount-1 {
+		t.Errorf("Expected %d unused codes, got %d, %v", auth.RecoveryCodeCount-1, remaining, err)
+	}
+
+	if ok, err := a.AuthenticateUser([]byte("password"), "/usr/bin/firefox", -1); err != nil || !ok {
+		t.Errorf("Expected the password to keep working, got %v, %v", ok, err)
+	}
+
+	// Regenerating invalidates the old codes
+	if _, err := auth.GenerateRecoveryCodes(cfg); err != nil {
+		t.Fatalf("GenerateRecoveryCodes failed: %v", err)
+	}
+	if ok, _ := a.AuthenticateUser([]byte(codes[1]), "/usr/bin/firefox", -1); ok {
+		t.Errorf("Expected a code from the old set to be refused")
+	}
+}
+
+func TestIsRecoveryCode(t *testing.T) {
+	tests := map[string]bool{
+		"abcd-efgh-ijkl-mnop": true,
+		"ABCD EFGH IJKL MNOP": true,
+		"abcdefghijklmnop":    true,
+		"abcd-efgh-ijkl-mno1": false,
+		"abcd-efgh-ijkl":      false,
+		"hunter2":             false,
+	}
+	for input, want := range tests {
+		if got := auth.IsRecoveryCode([]byte(input)); got != want {
+			t.Errorf("IsRecoveryCode(%q) = %v, want %v", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"wyrmlock/internal/config"
+)
+
+// WeakSecretError is returned for a new secret that breaks the configured
+// strength rules. Feedback lists what to change.
+type WeakSecretError struct {
+	Entropy  float64
+	Feedback []string
+}
+
+// Error implements the error interface
+func (e *WeakSecretError) Error() string {
+	return fmt.Sprintf("secret is too weak (about %.0f bits): %s", e.Entropy, strings.Join(e.Feedback, "; "))
+}
+
+// commonWords are passwords and words guessed first, checked after undoing
+// l33t substitutions
+var commonWords = []string{
+	"password", "passwort", "letmein", "welcome", "qwerty", "monkey", "dragon",
+	"iloveyou", "sunshine", "princess", "football", "baseball", "master",
+	"shadow", "login", "admin", "secret", "trustno1", "hello", "freedom",
+	"whatever", "starwars", "superman", "batman", "michael", "jennifer",
+	"charlie", "summer", "winter", "spring", "autumn", "changeme", "default",
+	"access", "unlock", "wyrmlock", "applock", "computer", "internet",
+}
+
+// leet undoes the common l33t substitutions
+var leet = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '@': 'a', '$': 's', '!': 'i',
+}
+
+// keyboardRows are the rows of a US keyboard, for spotting runs such as
+// "qwerty" or "asdf"
+var keyboardRows = []string{"`1234567890-=", "qwertyuiop[]\\", "asdfghjkl;'", "zxcvbnm,./"}
+
+// Relations between neighbouring characters that make a run
+const (
+	runNone = iota
+	runRepeat
+	runSequenceUp
+	runSequenceDown
+	runKeyboardRight
+	runKeyboardLeft
+)
+
+// CheckSecretStrength checks a new secret against the minimum length and
+// entropy in the configuration
+func CheckSecretStrength(cfg *config.Config, secret []byte) error {
+	entropy, weaknesses := EstimateEntropy(secret)
+
+	var feedback []string
+	if length := len([]rune(string(secret))); length < cfg.Auth.MinSecretLength {
+		feedback = append(feedback, fmt.Sprintf("use at least %d characters", cfg.Auth.MinSecretLength))
+	}
+	if entropy < float64(cfg.Auth.MinSecretEntropy) {
+		feedback = append(feedback, weaknesses...)
+		feedback = append(feedback, fmt.Sprintf("add characters or words until it reaches %d bits", cfg.Auth.MinSecretEntropy))
+	}
+	if len(feedback) == 0 {
+		return nil
+	}
+	return &WeakSecretError{Entropy: entropy, Feedback: feedback}
+}
+
+// EstimateEntropy estimates how many bits of entropy a secret has, in the
+// spirit of zxcvbn: characters count by the size of the alphabet they are
+// drawn from, but common passwords count as one guess out of a short list,
+// and characters continuing a repeat, a sequence or a keyboard run count
+// as one bit. It also returns the weaknesses found.
+func EstimateEntropy(secret []byte) (float64, []string) {
+	runes := []rune(string(secret))
+	if len(runes) == 0 {
+		return 0, nil
+	}
+
+	var weaknesses []string
+	var lower, upper, digit, symbol, other bool
+	for _, r := range runes {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < unicode.MaxASCII && unicode.IsPrint(r):
+			symbol = true
+		default:
+			other = true
+		}
+	}
+	pool, classes := 0, 0
+	for _, class := range []struct {
+		present bool
+		size    int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if class.present {
+			pool += class.size
+			classes++
+		}
+	}
+	charBits := math.Log2(float64(pool))
+
+	// The longest common word counts as a single guess from the list
+	start, end := commonWordSpan(runes)
+	entropy := 0.0
+	if end > start {
+		entropy += math.Log2(float64(len(commonWords)))
+		if strings.ToLower(string(runes[start:end])) != string(runes[start:end]) {
+			entropy++
+		}
+		weaknesses = append(weaknesses, fmt.Sprintf("avoid common passwords and words like %q", string(runes[start:end])))
+	}
+
+	found := map[int]bool{}
+	for i, r := range runes {
+		if i >= start && i < end {
+			continue
+		}
+		if i >= 2 {
+			kind := runKind(runes[i-1], r)
+			if kind != runNone && kind == runKind(runes[i-2], runes[i-1]) {
+				entropy++
+				found[kind] = true
+				continue
+			}
+		}
+		entropy += charBits
+	}
+
+	if found[runRepeat] {
+		weaknesses = append(weaknesses, "avoid repeated characters")
+	}
+	if found[runSequenceUp] || found[runSequenceDown] {
+		weaknesses = append(weaknesses, "avoid sequences like abc or 123")
+	}
+	if found[runKeyboardRight] || found[runKeyboardLeft] {
+		weaknesses = append(weaknesses, "avoid keyboard runs like qwerty")
+	}
+	if classes < 3 {
+		weaknesses = append(weaknesses, "mix in upper case letters, digits or symbols")
+	}
+	return entropy, weaknesses
+}
+
+// commonWordSpan returns where the longest common word in runes starts and
+// ends, or 0, 0 when there is none
+func commonWordSpan(runes []rune) (int, int) {
+	plain := make([]rune, len(runes))
+	for i, r := range runes {
+		r = unicode.ToLower(r)
+		if sub, ok := leet[r]; ok {
+			r = sub
+		}
+		plain[i] = r
+	}
+
+	bestStart, bestEnd := 0, 0
+	for _, word := range commonWords {
+		w := []rune(word)
+		if len(w) <= bestEnd-bestStart {
+			continue
+		}
+		for i := 0; i+len(w) <= len(plain); i++ {
+			if string(plain[i:i+len(w)]) == word {
+				bestStart, bestEnd = i, i+len(w)
+				break
+			}
+		}
+	}
+	return bestStart, bestEnd
+}
+
+// runKind returns how b follows a
+func runKind(a, b rune) int {
+	a, b = unicode.ToLower(a), unicode.ToLower(b)
+	switch {
+	case a == b:
+		return runRepeat
+	case b == a+1:
+		return runSequenceUp
+	case b == a-1:
+		return runSequenceDown
+	}
+	for _, row := range keyboardRows {
+		i, j := strings.IndexRune(row, a), strings.IndexRune(row, b)
+		if i < 0 || j < 0 {
+			continue
+		}
+		if j == i+1 {
+			return runKeyboardRight
+		}
+		if j == i-1 {
+			return runKeyboardLeft
+		}
+	}
+	return runNone
+}
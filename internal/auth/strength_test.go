@@ -0,0 +1,61 @@
+package auth_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"wyrmlock/internal/auth"
+	"wyrmlock/internal/testutil"
+)
+
+// TestCheckSecretStrength tests that weak secrets are rejected with
+// feedback naming the weakness
+func TestCheckSecretStrength(t *testing.T) {
+	cfg := testutil.SetupTestConfig(t)
+	cfg.Auth.MinSecretLength = 8
+	cfg.Auth.MinSecretEntropy = 35
+
+	tests := []struct {
+		secret   string
+		feedback string
+	}{
+		{"Tr0ub4dor&3x", ""},
+		{"correct horse battery staple", ""},
+		{"hunter2", "use at least 8 characters"},
+		{"P@ssw0rd2024", "common passwords"},
+		{"aaaaaaaaaaaaaaa", "repeated characters"},
+		{"abcdefghijklmn", "sequences"},
+		{"qwertyuiop1234", "keyboard runs"},
+	}
+
+	for _, tt := range tests {
+		err := auth.CheckSecretStrength(cfg, []byte(tt.secret))
+		if tt.feedback == "" {
+			if err != nil {
+				t.Errorf("Expected %q to be accepted, got %v", tt.secret, err)
+			}
+			continue
+		}
+
+		var weak *auth.WeakSecretError
+		if !errors.As(err, &weak) {
+			t.Errorf("Expected %q to be rejected, got %v", tt.secret, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.feedback) {
+			t.Errorf("Expected feedback on %q to mention %q, got %v", tt.secret, tt.feedback, err)
+		}
+	}
+}
+
+// TestCheckSecretStrengthDisabled tests that rules set to 0 are not checked
+func TestCheckSecretStrengthDisabled(t *testing.T) {
+	cfg := testutil.SetupTestConfig(t)
+	cfg.Auth.MinSecretLength = 0
+	cfg.Auth.MinSecretEntropy = 0
+
+	if err := auth.CheckSecretStrength(cfg, []byte("a")); err != nil {
+		t.Errorf("Expected no rules to be checked, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+
+	"wyrmlock/internal/config"
+)
+
+// userSecretPath returns the file holding the secret of username
+func (a *Authenticator) userSecretPath(username string) (string, error) {
+	if a.config.Auth.UserSecretDir == "" {
+		return "", fmt.Errorf("no user_secret_dir is configured")
+	}
+	if !config.IsValidSecretName(username) {
+		return "", fmt.Errorf("invalid user name: %q", username)
+	}
+	return filepath.Join(a.config.Auth.UserSecretDir, username), nil
+}
+
+// userSecretFile returns the secret file of uid when that user has one
+func (a *Authenticator) userSecretFile(uid int) string {
+	if uid < 0 || a.config.Auth.UserSecretDir == "" {
+		return ""
+	}
+	u, err := user.LookupId(strconv.Itoa(uid))
+	if err != nil {
+		return ""
+	}
+	path, err := a.userSecretPath(u.Username)
+	if err != nil {
+		return ""
+	}
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// SetUserSecret saves the secret that unlocks launches by username, in
+// the form SetSecret uses for the global one
+func (a *Authenticator) SetUserSecret(username string, secret []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	path, err := a.userSecretPath(username)
+	if err != nil {
+		return err
+	}
+	return a.writeSecretFile(path, secret)
+}
+
+// RemoveUserSecret deletes the secret of username, who then uses the
+// global secret again
+func (a *Authenticator) RemoveUserSecret(username string) error {
+	path, err := a.userSecretPath(username)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: %s has no secret", ErrSecretNotFound, username)
+		}
+		return err
+	}
+	return nil
+}
+
+// UserSecrets lists the users with their own secret
+func (a *Authenticator) UserSecrets() ([]string, error) {
+	entries, err := os.ReadDir(a.config.Auth.UserSecretDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var users []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && config.IsValidSecretName(entry.Name()) {
+			users = append(users, entry.Name())
+		}
+	}
+	return users, nil
+}
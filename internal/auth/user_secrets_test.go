@@ -0,0 +1,77 @@
+package auth_test
+
+import (
+	"os"
+	"os/user"
+	"testing"
+
+	"wyrmlock/internal/auth"
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/testutil"
+)
+
+// TestUserSecrets tests that launches by a user with their own secret are
+// checked against it, while other users and apps with their own secret are
+// not affected
+func TestUserSecrets(t *testing.T) {
+	current, err := user.Current()
+	if err != nil {
+		t.Skipf("Cannot look up the current user: %v", err)
+	}
+
+	secretPath, cleanup := testutil.CreateTempFile(t, mustHash(t, "global-password"))
+	defer cleanup()
+
+	cfg := testutil.SetupTestConfig(t)
+	cfg.Auth.UseZeroKnowledgeProof = false
+	cfg.Auth.SecretPath = secretPath
+	cfg.Auth.AppSecretDir = t.TempDir()
+	cfg.Auth.UserSecretDir = t.TempDir()
+	cfg.BlockedApps = []config.BlockedApp{{Path: "/usr/bin/steam", Secret: "games"}}
+
+	a, err := auth.NewAuthenticator(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create authenticator: %v", err)
+	}
+	if err := a.SetUserSecret(current.Username, []byte("my-password")); err != nil {
+		t.Fatalf("SetUserSecret failed: %v", err)
+	}
+	if err := a.SetAppSecret("games", []byte("games-password")); err != nil {
+		t.Fatalf("SetAppSecret failed: %v", err)
+	}
+
+	uid := os.Getuid()
+	tests := []struct {
+		name, app, password string
+		uid                 int
+		want                bool
+	}{
+		{"own secret", "/usr/bin/firefox", "my-password", uid, true},
+		{"global secret refused for user with own", "/usr/bin/firefox", "global-password", uid, false},
+		{"unknown user uses global", "/usr/bin/firefox", "global-password", -1, true},
+		{"app secret wins", "/usr/bin/steam", "games-password", uid, true},
+		{"user secret not used for app with own", "/usr/bin/steam", "my-password", uid, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := a.AuthenticateUser([]byte(tt.password), tt.app, tt.uid)
+			if err != nil {
+				t.Fatalf("AuthenticateUser failed: %v", err)
+			}
+			if ok != tt.want {
+				t.Errorf("AuthenticateUser = %v, want %v", ok, tt.want)
+			}
+		})
+	}
+
+	users, err := a.UserSecrets()
+	if err != nil || len(users) != 1 || users[0] != current.Username {
+		t.Errorf("Expected %s to be listed, got %v, %v", current.Username, users, err)
+	}
+	if err := a.RemoveUserSecret(current.Username); err != nil {
+		t.Fatalf("RemoveUserSecret failed: %v", err)
+	}
+	if ok, _ := a.AuthenticateUser([]byte("global-password"), "/usr/bin/firefox", uid); !ok {
+		t.Error("Expected the global secret after removing the user secret")
+	}
+}
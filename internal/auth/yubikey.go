@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"wyrmlock/internal/yubikey"
+)
+
+// YubiKeyTimeout bounds a challenge-response, including waiting for a
+// touch when the slot requires one
+const YubiKeyTimeout = 30 * time.Second
+
+// AuthenticateYubiKey checks the password together with the YubiKey's
+// response to the enrolled challenge
+func (a *Authenticator) AuthenticateYubiKey(userInput []byte) (bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.yubikey == nil {
+		return false, fmt.Errorf("%w: run wyrmlock yubikey setup", ErrSecretNotFound)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), YubiKeyTimeout)
+	defer cancel()
+	return a.yubikey.Verify(ctx, yubikey.Respond, userInput)
+}
+
+// enrollYubiKey binds a new password to the YubiKey and stores the
+// enrollment in place of a secret
+func (a *Authenticator) enrollYubiKey(secret []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), YubiKeyTimeout)
+	defer cancel()
+
+	enrollment, err := yubikey.Enroll(ctx, yubikey.Respond, a.config.Auth.YubiKeySlot, secret)
+	if err != nil {
+		return err
+	}
+	if err := enrollment.Save(a.config.Auth.YubiKeyFile); err != nil {
+		return err
+	}
+	a.yubikey = enrollment
+	return nil
+}
@@ -0,0 +1,163 @@
+// Package authcmd lets an external program decide unlocks, so a site can
+// plug in its own authentication system without patching wyrmlock. The
+// program is told about the launch on stdin and in its environment, and
+// answers with its exit status.
+package authcmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Exit statuses the program answers with. Any other status, or failing to
+// run at all, leaves the decision to the password prompt.
+const (
+	// ExitAllow unlocks the launch
+	ExitAllow = 0
+
+	// ExitDeny terminates the launch
+	ExitDeny = 1
+)
+
+// DefaultTimeout is used when no timeout is configured
+const DefaultTimeout = 30 * time.Second
+
+// maxOutput bounds what is kept of the program's output
+const maxOutput = 4096
+
+var (
+	// ErrUndecided is returned when the program neither allowed nor denied
+	// the launch
+	ErrUndecided = errors.New("auth command did not decide")
+
+	// ErrTimeout is returned when the program did not answer in time
+	ErrTimeout = errors.New("auth command timed out")
+)
+
+// Request describes the launch, written as JSON to the program's stdin
+type Request struct {
+	PID         int    `json:"pid"`
+	Executable  string `json:"executable"`
+	DisplayName string `json:"display_name"`
+	CmdLine     string `json:"cmdline,omitempty"`
+	UID         int    `json:"uid"`
+	User        string `json:"user,omitempty"`
+}
+
+// Env returns the request as WYRMLOCK_* environment variables
+func (r Request) Env() []string {
+	return []string{
+		"WYRMLOCK_PID=" + strconv.Itoa(r.PID),
+		"WYRMLOCK_APP=" + r.Executable,
+		"WYRMLOCK_APP_NAME=" + r.DisplayName,
+		"WYRMLOCK_CMDLINE=" + r.CmdLine,
+		"WYRMLOCK_UID=" + strconv.Itoa(r.UID),
+		"WYRMLOCK_USER=" + r.User,
+	}
+}
+
+// Result is the program's decision. Reason is the first line it printed,
+// if any.
+type Result struct {
+	Allowed bool
+	Reason  string
+}
+
+// Runner runs the configured program
+type Runner struct {
+	command []string
+	timeout time.Duration
+}
+
+// NewRunner returns a runner for command, the program followed by its
+// arguments, waiting up to timeout (DefaultTimeout when not positive)
+func NewRunner(command []string, timeout time.Duration) (*Runner, error) {
+	if len(command) == 0 || !filepath.IsAbs(command[0]) {
+		return nil, fmt.Errorf("auth command must be an absolute path")
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Runner{command: command, timeout: timeout}, nil
+}
+
+// Decide runs the program for a launch. It returns ErrTimeout when the
+// program is too slow and ErrUndecided, wrapped, when it exits with an
+// unknown status or cannot be run.
+func (r *Runner) Decide(ctx context.Context, req Request) (Result, error) {
+	if err := checkOwnership(r.command[0]); err != nil {
+		return Result{}, fmt.Errorf("%w: %v", ErrUndecided, err)
+	}
+
+	input, err := json.Marshal(req)
+	if err != nil {
+		return Result{}, fmt.Errorf("%w: %v", ErrUndecided, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// The program runs as root, so it gets a fixed environment rather
+	// than the daemon's
+	cmd := exec.CommandContext(ctx, r.command[0], r.command[1:]...)
+	cmd.Env = append([]string{"PATH=/usr/local/bin:/usr/bin:/bin"}, req.Env()...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	result := Result{Reason: firstLine(stdout.Bytes())}
+	if ctx.Err() == context.DeadlineExceeded {
+		return result, ErrTimeout
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		result.Allowed = true
+		return result, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == ExitDeny:
+		return result, nil
+	case errors.As(err, &exitErr):
+		return result, fmt.Errorf("%w: exit status %d: %s", ErrUndecided, exitErr.ExitCode(), firstLine(stderr.Bytes()))
+	default:
+		return result, fmt.Errorf("%w: %v", ErrUndecided, err)
+	}
+}
+
+// checkOwnership refuses a program that anyone other than root could replace, since
+// it decides for root
+func checkOwnership(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&0022 != 0 {
+		return fmt.Errorf("%s is writable by group or others", path)
+	}
+	// The daemon's own user stands in for root when it runs unprivileged
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && stat.Uid != 0 && int(stat.Uid) != os.Geteuid() {
+		return fmt.Errorf("%s is not owned by root (uid=%d)", path, stat.Uid)
+	}
+	return nil
+}
+
+// firstLine returns the first line of output, trimmed and bounded
+func firstLine(output []byte) string {
+	if len(output) > maxOutput {
+		output = output[:maxOutput]
+	}
+	line, _, _ := strings.Cut(string(output), "\n")
+	return strings.TrimSpace(line)
+}
@@ -0,0 +1,93 @@
+package authcmd_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"wyrmlock/internal/authcmd"
+)
+
+// writeScript writes an auth command running body
+func writeScript(t *testing.T, body string, mode os.FileMode) string {
+	path := filepath.Join(t.TempDir(), "decide")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+var request = authcmd.Request{PID: 42, Executable: "/usr/bin/firefox", DisplayName: "Firefox", UID: 1000, User: "alice"}
+
+func TestDecide(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		allowed   bool
+		reason    string
+		undecided bool
+	}{
+		{"allow", `echo "welcome $WYRMLOCK_USER"; exit 0`, true, "welcome alice", false},
+		{"deny", `echo "not $WYRMLOCK_APP"; exit 1`, false, "not /usr/bin/firefox", false},
+		{"stdin", `grep -q '"pid":42' && exit 0; exit 1`, true, "", false},
+		{"undecided", `exit 3`, false, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner, err := authcmd.NewRunner([]string{writeScript(t, tt.body, 0755)}, time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := runner.Decide(context.Background(), request)
+			if tt.undecided {
+				if !errors.Is(err, authcmd.ErrUndecided) {
+					t.Fatalf("Expected ErrUndecided, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Allowed != tt.allowed || result.Reason != tt.reason {
+				t.Errorf("Got %+v, want allowed=%v reason=%q", result, tt.allowed, tt.reason)
+			}
+		})
+	}
+}
+
+func TestDecideTimeout(t *testing.T) {
+	runner, err := authcmd.NewRunner([]string{writeScript(t, "exec sleep 5", 0755)}, 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runner.Decide(context.Background(), request); !errors.Is(err, authcmd.ErrTimeout) {
+		t.Errorf("Expected ErrTimeout, got %v", err)
+	}
+}
+
+func TestDecideRefusesWritableProgram(t *testing.T) {
+	runner, err := authcmd.NewRunner([]string{writeScript(t, "exit 0", 0777)}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = runner.Decide(context.Background(), request)
+	if !errors.Is(err, authcmd.ErrUndecided) || !strings.Contains(err.Error(), "writable") {
+		t.Errorf("Expected a writable program to be refused, got %v", err)
+	}
+}
+
+func TestNewRunnerRequiresAbsolutePath(t *testing.T) {
+	if _, err := authcmd.NewRunner([]string{"decide"}, 0); err == nil {
+		t.Error("Expected a relative path to be rejected")
+	}
+	if _, err := authcmd.NewRunner(nil, 0); err == nil {
+		t.Error("Expected an empty command to be rejected")
+	}
+}
@@ -0,0 +1,163 @@
+// Package authz delegates launch decisions to an external HTTP(S)
+// authorization service, letting an organisation-level policy decide
+// instead of the local password prompt.
+package authz
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/logging"
+)
+
+// Decision is the outcome returned by the authorization service
+type Decision string
+
+const (
+	// DecisionAllow resumes the process without prompting
+	DecisionAllow Decision = config.AuthzAllow
+
+	// DecisionDeny terminates the process without prompting
+	DecisionDeny Decision = config.AuthzDeny
+
+	// DecisionPrompt falls through to the local authentication dialog
+	DecisionPrompt Decision = config.AuthzPrompt
+)
+
+// DefaultTimeout is used when no timeout is configured
+const DefaultTimeout = 5 * time.Second
+
+// maxResponseSize bounds the response body read from the service
+const maxResponseSize = 64 * 1024
+
+// Request is the JSON body posted to the authorization service
+type Request struct {
+	PID         int       `json:"pid"`
+	Executable  string    `json:"executable"`
+	CmdLine     string    `json:"cmdline,omitempty"`
+	DisplayName string    `json:"display_name,omitempty"`
+	UID         int       `json:"uid"`
+	Hostname    string    `json:"hostname,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// Response is the JSON body returned by the authorization service
+type Response struct {
+	Decision Decision `json:"decision"`
+	Reason   string   `json:"reason,omitempty"`
+}
+
+// Client consults an external authorization service
+type Client struct {
+	url      string
+	token    string
+	fallback Decision
+	http     *http.Client
+	logger   *logging.Logger
+}
+
+// NewClient creates an authorization client from configuration
+func NewClient(cfg config.AuthorizationConfig, logger *logging.Logger) (*Client, error) {
+	timeout := DefaultTimeout
+	if cfg.Timeout > 0 {
+		timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+
+	fallback := Decision(cfg.FallbackAction)
+	if fallback == "" {
+		fallback = DecisionPrompt
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.CACertPath != "" {
+		pem, err := os.ReadFile(cfg.CACertPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read authorization CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CACertPath)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	var token string
+	if cfg.TokenFile != "" {
+		data, err := os.ReadFile(cfg.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read authorization token: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+
+	return &Client{
+		url:      cfg.URL,
+		token:    token,
+		fallback: fallback,
+		http:     &http.Client{Timeout: timeout, Transport: transport},
+		logger:   logger,
+	}, nil
+}
+
+// Decide asks the service for a decision. Any failure (network error, timeout,
+// non-2xx status, malformed or unknown decision) yields the fallback action,
+// with the reason describing the failure.
+func (c *Client) Decide(ctx context.Context, req Request) Response {
+	resp, err := c.query(ctx, req)
+	if err != nil {
+		c.logger.Warnf("Authorization service unavailable for %s (PID %d), using fallback %s: %v",
+			req.Executable, req.PID, c.fallback, err)
+		return Response{Decision: c.fallback, Reason: fmt.Sprintf("authorization service unavailable: %v", err)}
+	}
+	return resp
+}
+
+// query performs a single request to the service
+func (c *Client) query(ctx context.Context, req Request) (Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	httpResp, err := c.http.Do(httpReq)
+	if err != nil {
+		return Response{}, err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
+		return Response{}, fmt.Errorf("unexpected status %s", httpResp.Status)
+	}
+
+	var resp Response
+	if err := json.NewDecoder(io.LimitReader(httpResp.Body, maxResponseSize)).Decode(&resp); err != nil {
+		return Response{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	switch resp.Decision {
+	case DecisionAllow, DecisionDeny, DecisionPrompt:
+		return resp, nil
+	default:
+		return Response{}, fmt.Errorf("unknown decision %q", resp.Decision)
+	}
+}
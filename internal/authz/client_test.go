@@ -0,0 +1,102 @@
+package authz_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"wyrmlock/internal/authz"
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/logging"
+)
+
+func TestDecide(t *testing.T) {
+	logger := logging.NewLogger("[test]", false)
+
+	t.Run("ServiceDecision", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if got := r.Header.Get("Content-Type"); got != "application/json" {
+				t.Errorf("Expected JSON request, got content type %q", got)
+			}
+
+			var req authz.Request
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Errorf("Failed to decode request: %v", err)
+			}
+			if req.Executable != "/usr/bin/firefox" || req.PID != 42 {
+				t.Errorf("Unexpected request: %+v", req)
+			}
+
+			json.NewEncoder(w).Encode(authz.Response{Decision: authz.DecisionDeny, Reason: "outside work hours"})
+		}))
+		defer server.Close()
+
+		client, err := authz.NewClient(config.AuthorizationConfig{URL: server.URL}, logger)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+
+		resp := client.Decide(context.Background(), authz.Request{PID: 42, Executable: "/usr/bin/firefox"})
+		if resp.Decision != authz.DecisionDeny || resp.Reason != "outside work hours" {
+			t.Errorf("Expected deny decision from service, got %+v", resp)
+		}
+	})
+
+	t.Run("FallbackOnError", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		client, err := authz.NewClient(config.AuthorizationConfig{
+			URL:            server.URL,
+			FallbackAction: config.AuthzDeny,
+		}, logger)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+
+		if resp := client.Decide(context.Background(), authz.Request{PID: 1}); resp.Decision != authz.DecisionDeny {
+			t.Errorf("Expected fallback deny, got %s", resp.Decision)
+		}
+	})
+
+	t.Run("FallbackOnUnknownDecision", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"decision":"maybe"}`))
+		}))
+		defer server.Close()
+
+		client, err := authz.NewClient(config.AuthorizationConfig{URL: server.URL}, logger)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+
+		if resp := client.Decide(context.Background(), authz.Request{PID: 1}); resp.Decision != authz.DecisionPrompt {
+			t.Errorf("Expected default fallback prompt, got %s", resp.Decision)
+		}
+	})
+
+	t.Run("FallbackOnTimeout", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(2 * time.Second)
+		}))
+		defer server.Close()
+
+		client, err := authz.NewClient(config.AuthorizationConfig{
+			URL:            server.URL,
+			Timeout:        1,
+			FallbackAction: config.AuthzAllow,
+		}, logger)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+
+		if resp := client.Decide(context.Background(), authz.Request{PID: 1}); resp.Decision != authz.DecisionAllow {
+			t.Errorf("Expected fallback allow on timeout, got %s", resp.Decision)
+		}
+	})
+}
@@ -0,0 +1,139 @@
+// Package bluetooth tells whether a paired Bluetooth device, such as the
+// owner's phone, is in range. It drives BlueZ's bluetoothctl and, for
+// devices that are paired but not connected, l2ping.
+package bluetooth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrToolsMissing is returned when bluetoothctl is not installed
+	ErrToolsMissing = errors.New("bluetoothctl (bluez) is not installed")
+
+	// ErrNotPaired is returned when the device is not paired with this
+	// machine, so its presence proves nothing
+	ErrNotPaired = errors.New("device is not paired")
+)
+
+// DeviceInfo is what bluetoothctl reports about a device
+type DeviceInfo struct {
+	Name      string
+	Paired    bool
+	Bonded    bool
+	Connected bool
+}
+
+// ParseInfo parses the output of "bluetoothctl info"
+func ParseInfo(output string) DeviceInfo {
+	var info DeviceInfo
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "Name":
+			info.Name = value
+		case "Paired":
+			info.Paired = value == "yes"
+		case "Bonded":
+			info.Bonded = value == "yes"
+		case "Connected":
+			info.Connected = value == "yes"
+		}
+	}
+	return info
+}
+
+// Detector checks whether one paired device is in range. Results are
+// cached briefly, so a burst of launches does not page the device for each.
+type Detector struct {
+	address  string
+	timeout  time.Duration
+	cacheFor time.Duration
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	present   bool
+}
+
+// NewDetector returns a detector for the device at address, waiting up to
+// timeout (2 seconds when not positive) for it to answer
+func NewDetector(address string, timeout time.Duration) *Detector {
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	return &Detector{
+		address:  strings.ToUpper(address),
+		timeout:  timeout,
+		cacheFor: 10 * time.Second,
+	}
+}
+
+// Address returns the address of the device
+func (d *Detector) Address() string {
+	return d.address
+}
+
+// InRange reports whether the device is paired and either connected or
+// answering a ping
+func (d *Detector) InRange(ctx context.Context) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.checkedAt.IsZero() && time.Since(d.checkedAt) < d.cacheFor {
+		return d.present, nil
+	}
+
+	present, err := d.check(ctx)
+	if err != nil {
+		return false, err
+	}
+	d.present, d.checkedAt = present, time.Now()
+	return present, nil
+}
+
+// check asks BlueZ about the device, uncached
+func (d *Detector) check(ctx context.Context) (bool, error) {
+	ctl, err := exec.LookPath("bluetoothctl")
+	if err != nil {
+		return false, ErrToolsMissing
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, ctl, "info", d.address).Output()
+	if err != nil {
+		return false, fmt.Errorf("%w: %s", ErrNotPaired, d.address)
+	}
+	info := ParseInfo(string(output))
+	if !info.Paired && !info.Bonded {
+		return false, fmt.Errorf("%w: %s", ErrNotPaired, d.address)
+	}
+	if info.Connected {
+		return true, nil
+	}
+
+	// A paired phone is often not connected; one echo request shows
+	// whether it is in range
+	ping, err := exec.LookPath("l2ping")
+	if err != nil {
+		return false, nil
+	}
+	seconds := int(d.timeout.Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	cmd := exec.CommandContext(ctx, ping, "-c", "1", "-t", strconv.Itoa(seconds), d.address)
+	return cmd.Run() == nil, nil
+}
@@ -0,0 +1,78 @@
+package bluetooth_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"wyrmlock/internal/bluetooth"
+)
+
+const sampleInfo = `Device AA:BB:CC:DD:EE:FF (public)
+	Name: Pixel
+	Alias: Pixel
+	Paired: yes
+	Bonded: yes
+	Trusted: yes
+	Connected: no
+`
+
+func TestParseInfo(t *testing.T) {
+	info := bluetooth.ParseInfo(sampleInfo)
+	if info.Name != "Pixel" || !info.Paired || !info.Bonded || info.Connected {
+		t.Errorf("Unexpected device info: %+v", info)
+	}
+}
+
+// fakeTools replaces PATH with a bluetoothctl printing info and, when
+// pingExit is not empty, an l2ping exiting with it
+func fakeTools(t *testing.T, info string, pingExit string) {
+	dir := t.TempDir()
+	ctl := "#!/bin/sh\nprintf '%s' '" + info + "'\n"
+	if err := os.WriteFile(filepath.Join(dir, "bluetoothctl"), []byte(ctl), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if pingExit != "" {
+		if err := os.WriteFile(filepath.Join(dir, "l2ping"), []byte("#!/bin/sh\nexit "+pingExit+"\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir)
+}
+
+func TestInRange(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		info     string
+		pingExit string
+		present  bool
+		err      error
+	}{
+		{"connected", "Paired: yes\nConnected: yes\n", "", true, nil},
+		{"answers ping", "Paired: yes\nConnected: no\n", "0", true, nil},
+		{"out of range", "Paired: yes\nConnected: no\n", "1", false, nil},
+		{"no l2ping", "Paired: yes\nConnected: no\n", "", false, nil},
+		{"not paired", "Paired: no\nConnected: yes\n", "0", false, bluetooth.ErrNotPaired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeTools(t, tt.info, tt.pingExit)
+			present, err := bluetooth.NewDetector("aa:bb:cc:dd:ee:ff", time.Second).InRange(ctx)
+			if !errors.Is(err, tt.err) || present != tt.present {
+				t.Errorf("Expected %v, %v; got %v, %v", tt.present, tt.err, present, err)
+			}
+		})
+	}
+}
+
+func TestInRangeToolsMissing(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	if _, err := bluetooth.NewDetector("AA:BB:CC:DD:EE:FF", time.Second).InRange(context.Background()); !errors.Is(err, bluetooth.ErrToolsMissing) {
+		t.Errorf("Expected ErrToolsMissing, got %v", err)
+	}
+}
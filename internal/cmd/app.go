@@ -11,6 +11,7 @@ import (
 	"path/filepath"
 
 	"github.com/spf13/cobra"
+	"github.com/zeebo/blake3"
 
 	"wyrmlock/internal/config"
 )
@@ -18,7 +19,7 @@ import (
 // Add a new application to the protected list
 func newAppAddCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "add [path]",
+		Use:   "add [path|directory/|flatpak:<app-id>|snap:<name>]",
 		Short: "Add application to protected list",
 		Long:  `Add an application to the list of applications that require authentication.`,
 		Args:  cobra.MinimumNArgs(1),
@@ -28,6 +29,7 @@ func newAppAddCommand() *cobra.Command {
 			verifyHash, _ := cmd.Flags().GetBool("verify-hash")
 			exactPath, _ := cmd.Flags().GetBool("exact-path")
 			hashAlgorithm, _ := cmd.Flags().GetString("hash-algorithm")
+			matchHash, _ := cmd.Flags().GetBool("match-hash")
 			
 			// Load configuration
 			cfg, err := config.LoadConfig(configPath)
@@ -36,6 +38,38 @@ func newAppAddCommand() *cobra.Command {
 				return
 			}
 			
+			// New hashes use the configured algorithm unless one is given
+			if !cmd.Flags().Changed("hash-algorithm") && cfg.Monitor.HashAlgorithm != "" {
+				hashAlgorithm = cfg.Monitor.HashAlgorithm
+			}
+			
+			// Matching by hash uses the SHA-256 hash index
+			if matchHash {
+				verifyHash = true
+				hashAlgorithm = "sha256"
+			}
+			
+			// Sandboxed apps are protected by app ID rather than by path
+			if _, appID, ok := config.ParseSandboxEntry(args[0]); ok {
+				if appID == "" {
+					fmt.Printf("Missing application ID in %s\n", args[0])
+					return
+				}
+				for _, entry := range cfg.Monitor.ProtectedApps {
+					if entry == args[0] {
+						fmt.Printf("%s is already protected.\n", args[0])
+						return
+					}
+				}
+				cfg.Monitor.ProtectedApps = append(cfg.Monitor.ProtectedApps, args[0])
+				if err := config.SaveConfig(cfg, configPath); err != nil {
+					fmt.Printf("Error saving configuration: %v\n", err)
+					return
+				}
+				fmt.Printf("Added %s to protected applications.\n", args[0])
+				return
+			}
+			
 			// Get absolute path
 			appPath := args[0]
 			absPath, err := filepath.Abs(appPath)
@@ -45,17 +79,37 @@ func newAppAddCommand() *cobra.Command {
 			}
 			
 			// Check if file exists
-			if _, err := os.Stat(absPath); os.IsNotExist(err) {
+			info, err := os.Stat(absPath)
+			if os.IsNotExist(err) {
 				fmt.Printf("Application file does not exist: %s\n", absPath)
 				return
 			}
 			
+			// Directories protect every executable beneath them
+			if err == nil && info.IsDir() {
+				dirEntry := absPath + string(filepath.Separator)
+				for _, entry := range cfg.Monitor.ProtectedApps {
+					if entry == dirEntry {
+						fmt.Printf("%s is already protected.\n", dirEntry)
+						return
+					}
+				}
+				cfg.Monitor.ProtectedApps = append(cfg.Monitor.ProtectedApps, dirEntry)
+				if err := config.SaveConfig(cfg, configPath); err != nil {
+					fmt.Printf("Error saving configuration: %v\n", err)
+					return
+				}
+				fmt.Printf("Added directory %s to protected applications.\n", dirEntry)
+				return
+			}
+			
 			// Create new BlockedApp entry
 			blockedApp := config.BlockedApp{
 				Path:            absPath,
 				DisplayName:     displayName,
 				EnforcePathExact: exactPath,
 				EnforceFileHash: verifyHash,
+				MatchByHash:     matchHash,
 			}
 			
 			// If hash verification is enabled, compute hash
@@ -67,9 +121,12 @@ func newAppAddCommand() *cobra.Command {
 					hashFunc = sha256.New
 				case "sha512":
 					hashFunc = sha512.New
+				case "blake3":
+					hashFunc = newBlake3
 				default:
 					fmt.Printf("Unsupported hash algorithm: %s. Using SHA-256.\n", hashAlgorithm)
 					hashFunc = sha256.New
+					hashAlgorithm = "sha256"
 				}
 				
 				// Open the file
@@ -89,6 +146,7 @@ func newAppAddCommand() *cobra.Command {
 				
 				// Store hash
 				blockedApp.FileHash = hex.EncodeToString(h.Sum(nil))
+				blockedApp.FileHashAlgorithm = hashAlgorithm
 				fmt.Printf("Computed %s hash: %s\n", hashAlgorithm, blockedApp.FileHash)
 			}
 			
@@ -118,6 +176,9 @@ func newAppAddCommand() *cobra.Command {
 			if verifyHash {
 				fmt.Printf("Hash verification enabled for this application.\n")
 			}
+			if matchHash {
+				fmt.Printf("Copies of this binary will be protected regardless of path.\n")
+			}
 		},
 	}
 	
@@ -125,7 +186,8 @@ func newAppAddCommand() *cobra.Command {
 	cmd.Flags().StringP("name", "n", "", "Display name for the application")
 	cmd.Flags().BoolP("verify-hash", "v", false, "Verify executable hash")
 	cmd.Flags().BoolP("exact-path", "e", false, "Require exact path matching")
-	cmd.Flags().String("hash-algorithm", "sha256", "Hash algorithm to use (sha256 or sha512)")
+	cmd.Flags().String("hash-algorithm", "sha256", "Hash algorithm to use (sha256, sha512 or blake3)")
+	cmd.Flags().Bool("match-hash", false, "Also protect renamed or copied binaries with the same SHA-256 hash")
 	
 	return cmd
 }
@@ -236,7 +298,14 @@ func newAppListCommand() *cobra.Command {
 					fmt.Printf("   Exact Path Matching: %v\n", app.EnforcePathExact)
 					fmt.Printf("   Hash Verification: %v\n", app.EnforceFileHash)
 					if app.EnforceFileHash && app.FileHash != "" {
-						fmt.Printf("   Hash: %s\n", app.FileHash)
+						algorithm := app.FileHashAlgorithm
+						if algorithm == "" {
+							algorithm = "sha256"
+						}
+						fmt.Printf("   Hash (%s): %s\n", algorithm, app.FileHash)
+					}
+					if app.Secret != "" {
+						fmt.Printf("   Secret: %s\n", app.Secret)
 					}
 					fmt.Println()
 				}
@@ -289,6 +358,11 @@ func newAppVerifyCommand() *cobra.Command {
 			// Get flags
 			hashAlgorithm, _ := cmd.Flags().GetString("hash-algorithm")
 			
+			if hashAlgorithm != "" && !config.IsFileHashAlgorithm(hashAlgorithm) {
+				fmt.Printf("Unsupported hash algorithm: %s\n", hashAlgorithm)
+				return
+			}
+			
 			// Update configuration
 			cfg.Monitor.VerifyHashes = enable
 			if hashAlgorithm != "" {
@@ -310,7 +384,7 @@ func newAppVerifyCommand() *cobra.Command {
 	}
 	
 	// Add flags
-	cmd.Flags().String("hash-algorithm", "", "Hash algorithm to use (sha256 or sha512)")
+	cmd.Flags().String("hash-algorithm", "", "Algorithm for new hashes (sha256, sha512 or blake3)")
 	
 	return cmd
 }
@@ -351,6 +425,8 @@ func newAppUpdateHashCommand() *cobra.Command {
 				hashFunc = sha256.New
 			case "sha512":
 				hashFunc = sha512.New
+			case "blake3":
+				hashFunc = newBlake3
 			default:
 				fmt.Printf("Unsupported hash algorithm: %s. Using SHA-256.\n", hashAlgorithm)
 				hashFunc = sha256.New
@@ -383,7 +459,13 @@ func newAppUpdateHashCommand() *cobra.Command {
 			found := false
 			for i, app := range cfg.BlockedApps {
 				if app.Path == absPath {
+					// The hash index used to match copies is SHA-256 only
+					if app.MatchByHash && hashAlgorithm != "sha256" {
+						fmt.Printf("%s is matched by hash and needs a SHA-256 hash.\n", absPath)
+						return
+					}
 					app.FileHash = fileHash
+					app.FileHashAlgorithm = hashAlgorithm
 					app.EnforceFileHash = true
 					cfg.BlockedApps[i] = app
 					found = true
@@ -399,6 +481,7 @@ func newAppUpdateHashCommand() *cobra.Command {
 					EnforcePathExact: false,
 					EnforceFileHash: true,
 					FileHash:        fileHash,
+					FileHashAlgorithm: hashAlgorithm,
 				}
 				
 				cfg.BlockedApps = append(cfg.BlockedApps, blockedApp)
@@ -430,11 +513,16 @@ func newAppUpdateHashCommand() *cobra.Command {
 	}
 	
 	// Add flags
-	cmd.Flags().String("hash-algorithm", "", "Hash algorithm to use (sha256 or sha512)")
+	cmd.Flags().String("hash-algorithm", "", "Hash algorithm to use (sha256, sha512 or blake3)")
 	
 	return cmd
 }
 
+// newBlake3 returns a BLAKE3 hasher with a 256-bit output
+func newBlake3() hash.Hash {
+	return blake3.New()
+}
+
 // newAppCommand creates a new app command
 func newAppCommand() *cobra.Command {
 	cmd := &cobra.Command{
@@ -449,6 +537,7 @@ func newAppCommand() *cobra.Command {
 	cmd.AddCommand(newAppListCommand())
 	cmd.AddCommand(newAppVerifyCommand())
 	cmd.AddCommand(newAppUpdateHashCommand())
+	cmd.AddCommand(newAppSecretCommand())
 	
 	return cmd
 } 
\ No newline at end of file
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+
+	"wyrmlock/internal/auth"
+	"wyrmlock/internal/config"
+)
+
+// Manage per-app secrets
+func newAppSecretCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "secret",
+		Short: "Manage per-app passwords",
+		Long: `Set, list and remove the passwords of apps that do not use the global one.
+An app uses a per-app password when its entry in blocked_apps names it with
+secret = "<name>"; apps naming the same secret share the password and its
+failed attempt counter.`,
+	}
+
+	cmd.AddCommand(
+		newAppSecretSetCommand(),
+		newAppSecretListCommand(),
+		newAppSecretRemoveCommand(),
+	)
+
+	return cmd
+}
+
+func newAppSecretSetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <name>",
+		Short: "Set a per-app password",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !config.IsValidSecretName(args[0]) {
+				return fmt.Errorf("invalid secret name: %q", args[0])
+			}
+			if os.Geteuid() != 0 {
+				return fmt.Errorf("setting a secret requires root privileges")
+			}
+
+			model := initialSecretModel()
+			model.appSecret = args[0]
+			if _, err := tea.NewProgram(model).Run(); err != nil {
+				return fmt.Errorf("error setting secret: %w", err)
+			}
+			return nil
+		},
+	}
+}
+
+func newAppSecretListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List per-app passwords and the apps using them",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.LoadConfig(configPath)
+			if err != nil {
+				return fmt.Errorf("error loading configuration: %w", err)
+			}
+			a, err := auth.NewAuthenticator(cfg)
+			if err != nil {
+				return fmt.Errorf("error initializing authenticator: %w", err)
+			}
+
+			apps := make(map[string][]string)
+			for _, app := range cfg.BlockedApps {
+				if app.Secret != "" {
+					apps[app.Secret] = append(apps[app.Secret], app.Path)
+				}
+			}
+			// Secrets set but not used by any app
+			if entries, err := os.ReadDir(cfg.Auth.AppSecretDir); err == nil {
+				for _, entry := range entries {
+					if _, ok := apps[entry.Name()]; !ok && config.IsValidSecretName(entry.Name()) {
+						apps[entry.Name()] = nil
+					}
+				}
+			}
+			if len(apps) == 0 {
+				fmt.Println("No per-app passwords configured; all apps use the global password.")
+				return nil
+			}
+
+			names := make([]string, 0, len(apps))
+			for name := range apps {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				state := statusOkStyle.Render("set")
+				if !a.HasAppSecret(name) {
+					state = statusErrorStyle.Render("not set")
+				}
+				fmt.Printf("%s (%s)\n", name, state)
+				if len(apps[name]) == 0 {
+					fmt.Println("   not used by any app")
+				}
+				for _, path := range apps[name] {
+					fmt.Printf("   %s\n", path)
+				}
+			}
+			fmt.Printf("Secrets are stored in %s\n", filepath.Clean(cfg.Auth.AppSecretDir))
+			return nil
+		},
+	}
+}
+
+func newAppSecretRemoveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove <name>",
+		Short: "Remove a per-app password",
+		Long: `Remove a per-app password. Apps still naming it cannot be unlocked until it
+is set again or their secret entry is removed.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if os.Geteuid() != 0 {
+				return fmt.Errorf("removing a secret requires root privileges")
+			}
+			cfg, err := config.LoadConfig(configPath)
+			if err != nil {
+				return fmt.Errorf("error loading configuration: %w", err)
+			}
+			a, err := auth.NewAuthenticator(cfg)
+			if err != nil {
+				return fmt.Errorf("error initializing authenticator: %w", err)
+			}
+			if err := a.RemoveAppSecret(args[0]); err != nil {
+				return err
+			}
+
+			fmt.Printf("Removed secret %s\n", args[0])
+			for _, app := range cfg.BlockedApps {
+				if app.Secret == args[0] {
+					fmt.Printf("%s %s still names it\n", statusErrorStyle.Render("WARN"), app.Path)
+				}
+			}
+			return nil
+		},
+	}
+}
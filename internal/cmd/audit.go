@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"wyrmlock/internal/logging"
+)
+
+func newAuditCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Inspect the security audit log",
+	}
+
+	gapsCmd := &cobra.Command{
+		Use:   "gaps [log]",
+		Short: "Report missing events in the security log",
+		Long: `Every security log event carries a sequence number that increases by one
+per event, across restarts. Gaps mean events were lost or removed, e.g. by a
+crash or by tampering with the log; a sequence that goes backwards means the
+sequence state was reset.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := ""
+			if len(args) == 1 {
+				path = args[0]
+			} else {
+				home, err := os.UserHomeDir()
+				if err != nil {
+					return fmt.Errorf("failed to get home directory: %w", err)
+				}
+				path = filepath.Join(home, ".wyrmlock", "security.log")
+			}
+
+			file, err := os.Open(path)
+			if err != nil {
+				return fmt.Errorf("failed to open security log: %w", err)
+			}
+			defer file.Close()
+
+			gaps, resets, err := logging.FindSequenceGaps(file)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", path, err)
+			}
+
+			for _, gap := range gaps {
+				fmt.Printf("%s %d events missing after event %d\n", statusWarningStyle.Render("GAP"), gap.Missing, gap.After)
+			}
+			if resets > 0 {
+				fmt.Printf("%s sequence went backwards %d times\n", statusWarningStyle.Render("RESET"), resets)
+			}
+			if len(gaps) == 0 && resets == 0 {
+				fmt.Printf("%s no gaps in %s\n", statusOkStyle.Render("OK"), path)
+				return nil
+			}
+			return fmt.Errorf("security log has %d gaps and %d resets", len(gaps), resets)
+		},
+	}
+
+	cmd.AddCommand(gapsCmd)
+	return cmd
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"wyrmlock/internal/bluetooth"
+	"wyrmlock/internal/config"
+)
+
+// Check Bluetooth proximity unlocks
+func newBluetoothCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bluetooth",
+		Short: "Check the Bluetooth device used for proximity unlocks",
+		Long: `With bluetooth_device set to the address of a paired device, such as your
+phone, launches are unlocked without a prompt while it is in range and the
+password is asked for otherwise. Anyone able to pose as the device can
+unlock, so use it where that risk is acceptable.`,
+	}
+
+	cmd.AddCommand(newBluetoothCheckCommand())
+
+	return cmd
+}
+
+func newBluetoothCheckCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "check [address]",
+		Short: "Show whether the device is paired and in range",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.LoadConfig(configPath)
+			if err != nil {
+				return fmt.Errorf("error loading configuration: %w", err)
+			}
+
+			address := cfg.Auth.BluetoothDevice
+			if len(args) == 1 {
+				address = args[0]
+			}
+			if address == "" {
+				return fmt.Errorf("no device given and bluetooth_device is not set")
+			}
+			if !config.IsValidBluetoothAddress(address) {
+				return fmt.Errorf("invalid bluetooth device address: %s", address)
+			}
+
+			detector := bluetooth.NewDetector(address, time.Duration(cfg.Auth.BluetoothTimeout)*time.Second)
+			present, err := detector.InRange(context.Background())
+			if err != nil {
+				fmt.Println(statusErrorStyle.Render(err.Error()))
+				return err
+			}
+
+			fmt.Println(titleStyle.Render("Bluetooth proximity"))
+			fmt.Printf("Device: %s\n", strings.ToUpper(address))
+			if present {
+				fmt.Println(statusOkStyle.Render("In range: launches are unlocked without a prompt"))
+			} else {
+				fmt.Println(statusErrorStyle.Render("Not in range: the password is asked for"))
+			}
+			return nil
+		},
+	}
+}
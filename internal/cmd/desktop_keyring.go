@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+
+	"wyrmlock/internal/auth"
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/privilege"
+	"wyrmlock/internal/secretservice"
+)
+
+// Manage the password kept in the user's desktop keyring
+func newDesktopKeyringCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "desktop-keyring",
+		Short: "Manage your password in the desktop keyring",
+		Long: `Keep your wyrmlock password in your desktop keyring (GNOME Keyring, KWallet)
+through the secret service. Run set, status and remove as yourself, not with
+sudo. The daemon uses it while your login keyring is unlocked when
+desktop_keyring is enabled in the [auth] configuration and an administrator
+has pinned it with pin; a password changed later needs pinning again.`,
+	}
+
+	cmd.AddCommand(
+		newDesktopKeyringSetCommand(),
+		newDesktopKeyringStatusCommand(),
+		newDesktopKeyringRemoveCommand(),
+		newDesktopKeyringPinCommand(),
+		newDesktopKeyringUnpinCommand(),
+	)
+
+	return cmd
+}
+
+func newDesktopKeyringSetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set",
+		Short: "Store your password in the desktop keyring",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			model := initialSecretModel()
+			model.desktopKeyring = true
+			if _, err := tea.NewProgram(model).Run(); err != nil {
+				return fmt.Errorf("error setting secret: %w", err)
+			}
+			return nil
+		},
+	}
+}
+
+func newDesktopKeyringStatusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show whether your desktop keyring holds a password",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			found, err := auth.HasDesktopSecret()
+			if errors.Is(err, secretservice.ErrToolsMissing) {
+				fmt.Println(statusErrorStyle.Render("secret-tool is not installed; install libsecret-tools"))
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if !found {
+				fmt.Println("No password in the desktop keyring; the daemon uses your other secrets.")
+				return nil
+			}
+			fmt.Println(statusOkStyle.Render("Password stored in the desktop keyring"))
+			return nil
+		},
+	}
+}
+
+func newDesktopKeyringRemoveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove",
+		Short: "Remove your password from the desktop keyring",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := auth.RemoveDesktopSecret(); err != nil {
+				return err
+			}
+			fmt.Println("Removed the password from the desktop keyring")
+			return nil
+		},
+	}
+}
+
+func newDesktopKeyringPinCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "pin USER",
+		Short: "Trust the password USER keeps in their desktop keyring",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.LoadConfig(configPath)
+			if err != nil {
+				return err
+			}
+			if err := auth.PinDesktopSecret(cfg, args[0]); err != nil {
+				return err
+			}
+			fmt.Printf("Pinned the desktop keyring password of %s\n", args[0])
+			return nil
+		},
+	}
+}
+
+func newDesktopKeyringUnpinCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "unpin USER",
+		Short: "Stop trusting the password in USER's desktop keyring",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.LoadConfig(configPath)
+			if err != nil {
+				return err
+			}
+			if err := auth.UnpinDesktopSecret(cfg, args[0]); err != nil {
+				return err
+			}
+			fmt.Printf("Unpinned the desktop keyring password of %s\n", args[0])
+			return nil
+		},
+	}
+}
+
+// newKeyringHelperCommand is the helper the daemon starts to read users'
+// desktop keyrings after it has given up the capabilities to switch users
+func newKeyringHelperCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:    secretservice.HelperCommand,
+		Short:  "Desktop keyring helper for the daemon",
+		Hidden: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := privilege.LimitCapabilities(privilege.KeyringHelperCapabilities); err != nil {
+				return err
+			}
+			return secretservice.ServeHelper(os.Stdin, os.Stdout)
+		},
+	}
+}
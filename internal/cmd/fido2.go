@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/fido2"
+)
+
+// Create the fido2 command
+func newFido2Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fido2",
+		Short: "Manage FIDO2 security keys used to unlock apps",
+		Long: `Register, list and remove the FIDO2/U2F security keys that unlock protected
+apps when auth.fido2 is "primary" (a touch of the key replaces the password)
+or "second-factor" (a touch is required after the password). Requires the
+libfido2 tools (fido2-token, fido2-cred, fido2-assert).`,
+	}
+
+	cmd.AddCommand(
+		newFido2RegisterCommand(),
+		newFido2ListCommand(),
+		newFido2RemoveCommand(),
+	)
+
+	return cmd
+}
+
+// loadFido2Store loads the configured credential file
+func loadFido2Store() (*config.Config, *fido2.Store, error) {
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error loading configuration: %w", err)
+	}
+	if cfg.Auth.FIDO2CredentialFile == "" {
+		return nil, nil, fmt.Errorf("no fido2_credential_file is configured")
+	}
+	store, err := fido2.Load(cfg.Auth.FIDO2CredentialFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading credentials: %w", err)
+	}
+	return cfg, store, nil
+}
+
+func newFido2RegisterCommand() *cobra.Command {
+	var device string
+
+	cmd := &cobra.Command{
+		Use:   "register <name>",
+		Short: "Register a security key",
+		Long: `Make a new credential on a connected security key and add it to the
+credential file. Touch the key when it blinks.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if os.Geteuid() != 0 {
+				return fmt.Errorf("registering a security key requires root privileges")
+			}
+			cfg, store, err := loadFido2Store()
+			if err != nil {
+				return err
+			}
+			for _, cred := range store.Credentials {
+				if cred.Name == args[0] {
+					return fmt.Errorf("a key named %q is already registered", args[0])
+				}
+			}
+			if device == "" {
+				device = cfg.Auth.FIDO2Device
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), time.Minute)
+			defer cancel()
+
+			fmt.Println("Touch your security key...")
+			cred, err := fido2.Register(ctx, device, args[0])
+			if err != nil {
+				return err
+			}
+			store.Credentials = append(store.Credentials, cred)
+			if err := store.Save(cfg.Auth.FIDO2CredentialFile); err != nil {
+				return err
+			}
+
+			fmt.Printf("%s registered %s\n", statusOkStyle.Render("OK"), cred.Name)
+			if cfg.Auth.FIDO2 == "" || cfg.Auth.FIDO2 == config.FIDO2Off {
+				fmt.Println(`Set auth.fido2 to "primary" or "second-factor" to unlock with it.`)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&device, "device", "", "Security key device, e.g. /dev/hidraw3 (default: the configured or first key)")
+	return cmd
+}
+
+func newFido2ListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List registered security keys",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, store, err := loadFido2Store()
+			if err != nil {
+				return err
+			}
+			if len(store.Credentials) == 0 {
+				fmt.Println("No security keys registered")
+				return nil
+			}
+			for _, cred := range store.Credentials {
+				fmt.Printf("%-20s registered %s\n", cred.Name, cred.Registered.Format("2006-01-02 15:04"))
+			}
+			return nil
+		},
+	}
+}
+
+func newFido2RemoveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove <name>",
+		Short: "Remove a registered security key",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if os.Geteuid() != 0 {
+				return fmt.Errorf("removing a security key requires root privileges")
+			}
+			cfg, store, err := loadFido2Store()
+			if err != nil {
+				return err
+			}
+			if !store.Remove(args[0]) {
+				return fmt.Errorf("no key named %q is registered", args[0])
+			}
+			if err := store.Save(cfg.Auth.FIDO2CredentialFile); err != nil {
+				return err
+			}
+			fmt.Printf("Removed %s\n", args[0])
+			return nil
+		},
+	}
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/logging"
+	"wyrmlock/internal/monitor"
+)
+
+func newLearnCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "learn",
+		Short: "Record launches and suggest protection rules",
+		Long: `Learning mode records every program launched on the system (path, hash,
+frequency and user) without locking anything, then suggests protected apps
+and an allowlist based on what was actually run. Recordings can also be
+replayed against a candidate policy with "wyrmlock replay".`,
+	}
+
+	var duration time.Duration
+	var output string
+	recordCmd := &cobra.Command{
+		Use:   "record",
+		Short: "Record launches for a period",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return recordLaunches(duration, output)
+		},
+	}
+	recordCmd.Flags().DurationVar(&duration, "duration", time.Hour, "How long to record (interrupt to stop early)")
+	recordCmd.Flags().StringVarP(&output, "output", "o", "/var/lib/wyrmlock/learned.json", "File to write the recording to")
+
+	var maxPerDay int
+	var showAll bool
+	suggestCmd := &cobra.Command{
+		Use:   "suggest [recording]",
+		Short: "Print a configuration suggested by a recording",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			input := "/var/lib/wyrmlock/learned.json"
+			if len(args) == 1 {
+				input = args[0]
+			}
+			learner, err := monitor.LoadLearner(input)
+			if err != nil {
+				return err
+			}
+			printSuggestion(learner, maxPerDay, showAll)
+			return nil
+		},
+	}
+	suggestCmd.Flags().IntVar(&maxPerDay, "max-per-day", 20, "Launches per day above which a program counts as a tool rather than an app")
+	suggestCmd.Flags().BoolVar(&showAll, "show-all", false, "Also list every recorded executable with its launch count")
+
+	cmd.AddCommand(recordCmd, suggestCmd)
+	return cmd
+}
+
+// recordLaunches runs the process monitor in learning mode with no
+// protected apps, so nothing is suspended
+func recordLaunches(duration time.Duration, output string) error {
+	logger := logging.NewLogger("[learn]", verbose)
+
+	procMonitor, err := monitor.NewProcessMonitorDaemon(&config.Config{}, logger)
+	if err != nil {
+		return fmt.Errorf("failed to create process monitor: %w", err)
+	}
+
+	learner := monitor.NewLearner()
+	procMonitor.SetLearner(learner)
+
+	if err := procMonitor.Start(); err != nil {
+		return fmt.Errorf("failed to start process monitor: %w", err)
+	}
+
+	fmt.Printf("Recording launches for %s (Ctrl+C to stop early)...\n", duration)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	select {
+	case <-time.After(duration):
+	case <-sigCh:
+		fmt.Println("\nStopping early")
+	}
+
+	if err := procMonitor.Stop(); err != nil {
+		logger.Warnf("Failed to stop process monitor: %v", err)
+	}
+
+	if err := learner.Save(output); err != nil {
+		return err
+	}
+
+	fmt.Printf("Recorded %d executables to %s\n", len(learner.Summary()), output)
+	fmt.Printf("Run 'wyrmlock learn suggest %s' to see suggested rules\n", output)
+	fmt.Printf("Run 'wyrmlock replay %s --policy <file>' to test a policy against it\n", output)
+	return nil
+}
+
+// printSuggestion prints the suggested configuration as a TOML snippet
+func printSuggestion(learner *monitor.Learner, maxPerDay int, showAll bool) {
+	suggestion := learner.Suggest(maxPerDay)
+
+	if showAll {
+		fmt.Println("# Recorded executables (launches, users):")
+		for _, exec := range learner.Summary() {
+			users := make([]string, 0, len(exec.Users))
+			for name := range exec.Users {
+				users = append(users, name)
+			}
+			fmt.Printf("#   %5d  %s (%s)\n", exec.Count, exec.Path, strings.Join(users, ", "))
+		}
+		fmt.Println()
+	}
+
+	fmt.Println("# Suggested protected apps: programs started by regular users")
+	fmt.Println("[monitor]")
+	fmt.Println("protectedApps = [")
+	for _, app := range suggestion.ProtectedApps {
+		fmt.Printf("  %q,\n", app)
+	}
+	fmt.Println("]")
+	fmt.Println()
+
+	fmt.Println("# Allowlist: every executable observed while recording")
+	fmt.Println("allowlist = [")
+	for _, entry := range suggestion.Allowlist {
+		fmt.Printf("  %q,\n", entry)
+	}
+	fmt.Println("]")
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"wyrmlock/internal/auth"
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/logging"
+)
+
+// Manage the emergency override
+func newOverrideCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "override",
+		Short: "Manage the emergency override code",
+		Long: `The emergency override code is a break-glass code, separate from the
+password. Entered in any unlock dialog it unlocks every app for
+override_duration minutes. Every use is written to a tamper-evident journal
+and the security log, and announced to all connected clients.`,
+	}
+
+	cmd.AddCommand(
+		newOverrideSetCommand(),
+		newOverrideJournalCommand(),
+	)
+
+	return cmd
+}
+
+func newOverrideSetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set",
+		Short: "Generate a new override code, replacing the old one",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if os.Geteuid() != 0 {
+				return fmt.Errorf("setting the override code requires root privileges")
+			}
+			cfg, err := config.LoadConfig(configPath)
+			if err != nil {
+				return fmt.Errorf("error loading configuration: %w", err)
+			}
+
+			code, err := auth.SetOverrideCode(cfg)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(titleStyle.Render("Emergency override code"))
+			fmt.Println("Keep it sealed somewhere safe; it is not shown again.")
+			fmt.Println()
+			fmt.Println("  " + code)
+			if cfg.Auth.OverrideDuration <= 0 {
+				fmt.Println()
+				fmt.Println(statusErrorStyle.Render("override_duration is 0, so the code is not accepted until it is set"))
+			}
+			return nil
+		},
+	}
+}
+
+func newOverrideJournalCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "journal",
+		Short: "Verify and show the override journal",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.LoadConfig(configPath)
+			if err != nil {
+				return fmt.Errorf("error loading configuration: %w", err)
+			}
+
+			entries, err := logging.ReadJournal(cfg.Auth.OverrideJournal)
+			if os.IsNotExist(err) {
+				fmt.Println("No emergency overrides recorded.")
+				return nil
+			}
+			for _, entry := range entries {
+				keys := make([]string, 0, len(entry.Details))
+				for key := range entry.Details {
+					keys = append(keys, key)
+				}
+				sort.Strings(keys)
+				fields := make([]string, 0, len(keys))
+				for _, key := range keys {
+					fields = append(fields, key+"="+entry.Details[key])
+				}
+				fmt.Printf("%4d  %s  %-18s %s\n", entry.Seq, entry.Time.Local().Format("2006-01-02 15:04:05"),
+					entry.Event, strings.Join(fields, " "))
+			}
+
+			if errors.Is(err, logging.ErrJournalTampered) {
+				fmt.Println(statusErrorStyle.Render(err.Error()))
+				return err
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s %d entries, chain intact\n", statusOkStyle.Render("OK"), len(entries))
+			return nil
+		},
+	}
+}
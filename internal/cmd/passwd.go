@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+
+	"wyrmlock/internal/auth"
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/daemon"
+)
+
+func newPasswdCommand() *cobra.Command {
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "passwd",
+		Short: "Change the unlock secret and reload the daemon",
+		Long: `Set, change or rotate the global unlock secret. An existing secret, or one
+of the recovery codes, must be entered first. The new secret is stored with
+the current hashing parameters and the running daemon is told to reload it,
+so the change applies without a restart.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if os.Geteuid() != 0 {
+				return fmt.Errorf("changing the secret requires root privileges")
+			}
+			cfg, err := config.LoadConfig(configPath)
+			if err != nil {
+				return fmt.Errorf("error loading configuration: %w", err)
+			}
+
+			model := initialSecretModel()
+			current, err := auth.NewAuthenticator(cfg)
+			switch {
+			case err == nil && current.HasSecret():
+				model.verify = current
+				model.current = textinput.New()
+				model.current.Placeholder = "Current secret"
+				model.current.EchoMode = textinput.EchoPassword
+				model.current.Focus()
+				model.input.Blur()
+				model.inputMode = 2
+			case err == nil, errors.Is(err, os.ErrNotExist), errors.Is(err, auth.ErrSecretNotFound):
+				// Nothing to verify against yet
+			default:
+				return fmt.Errorf("error initializing authenticator: %w", err)
+			}
+
+			final, err := tea.NewProgram(model).Run()
+			if err != nil {
+				return fmt.Errorf("error setting secret: %w", err)
+			}
+			if m, ok := final.(secretModel); !ok || !m.success {
+				return nil
+			}
+
+			if err := daemon.ReloadSecret(daemonSocketPath(), timeout); err != nil {
+				fmt.Println(statusErrorStyle.Render(fmt.Sprintf("Secret changed, but the daemon was not reloaded: %v", err)))
+				fmt.Println("It uses the new secret once restarted.")
+			} else {
+				fmt.Println(statusOkStyle.Render("Daemon reloaded the secret"))
+			}
+			offerRecoveryCodes(final)
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVar(&timeout, "timeout", 5*time.Second, "Time to wait for the daemon")
+
+	return cmd
+}
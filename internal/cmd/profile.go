@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+
+	"wyrmlock/internal/auth"
+	"wyrmlock/internal/config"
+)
+
+// Manage parental-control profiles
+func newProfileCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Show parental-control profiles and set the parent password",
+		Long: `Profiles in the configuration name child accounts. Their launches of
+protected apps are denied during the profile's schedule, and otherwise only
+the parent password unlocks them: their own passwords and the emergency
+override are not accepted. Changing the configuration or the parent
+password requires root privileges.`,
+	}
+
+	cmd.AddCommand(
+		newProfileListCommand(),
+		newProfileParentSecretCommand(),
+	)
+
+	return cmd
+}
+
+func newProfileListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the profiles, their child accounts and whether they are blocked now",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.LoadConfig(configPath)
+			if err != nil {
+				return fmt.Errorf("error loading configuration: %w", err)
+			}
+			if len(cfg.Profiles) == 0 {
+				fmt.Println("No parental-control profiles configured.")
+				return nil
+			}
+			a, err := auth.NewAuthenticator(cfg)
+			if err != nil {
+				return fmt.Errorf("error initializing authenticator: %w", err)
+			}
+
+			fmt.Println(titleStyle.Render("Parental-control profiles"))
+			if !a.HasParentSecret() {
+				fmt.Println(statusErrorStyle.Render("The parent password is not set; run \"wyrmlock profile parent-secret\""))
+			}
+			now := time.Now()
+			for _, profile := range cfg.Profiles {
+				state := statusOkStyle.Render("outside its schedule")
+				if profile.BlockedAt(now) {
+					state = statusErrorStyle.Render("blocked by its schedule")
+				}
+				fmt.Printf("%s (%s): %s\n", profile.Name, strings.Join(profile.Users, ", "), state)
+			}
+			return nil
+		},
+	}
+}
+
+func newProfileParentSecretCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "parent-secret",
+		Short: "Set the parent password that unlocks apps for child accounts",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if os.Geteuid() != 0 {
+				return fmt.Errorf("setting a secret requires root privileges")
+			}
+
+			model := initialSecretModel()
+			model.parentSecret = true
+			if _, err := tea.NewProgram(model).Run(); err != nil {
+				return fmt.Errorf("error setting secret: %w", err)
+			}
+			return nil
+		},
+	}
+}
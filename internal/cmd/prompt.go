@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/gui"
+)
+
+// Answer unlock prompts on the terminal
+func newPromptCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "prompt",
+		Short: "Answer unlock prompts on this terminal",
+		Long: `Connect to the daemon as your agent and ask for passwords on this terminal
+instead of in dialogs, for headless servers and SSH sessions. The client
+started with "wyrmlock run --client" does the same by itself when no
+display is available. Press Ctrl+C to stop.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.LoadConfig(configPath)
+			if err != nil {
+				return fmt.Errorf("error loading configuration: %w", err)
+			}
+			cfg.Auth.GuiType = string(gui.GuiTypeTerminal)
+
+			// Echo stays off if Ctrl+C interrupts a prompt
+			defer gui.SaveTerminal("")()
+
+			c, err := initializeClient(cfg)
+			if err != nil {
+				return err
+			}
+			if err := c.Connect(); err != nil {
+				return fmt.Errorf("failed to connect to daemon: %w", err)
+			}
+			// Disconnect rather than Stop, which would ask the daemon to
+			// shut down
+			defer c.Disconnect()
+
+			fmt.Println(statusOkStyle.Render("Waiting for launches to unlock; press Ctrl+C to stop"))
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			<-ctx.Done()
+			return nil
+		},
+	}
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/daemon"
+)
+
+// daemonSocketPath returns the daemon socket from the configuration, falling
+// back to the default when it cannot be read
+func daemonSocketPath() string {
+	if cfg, err := config.LoadConfig(configPath); err == nil && cfg.SocketPath != "" {
+		return cfg.SocketPath
+	}
+	return defaultSocketPath
+}
+
+func newQuotaCommand() *cobra.Command {
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "quota",
+		Short: "Show or reset daily launch quotas",
+		Long: `Blocked apps with daily_launches set may be launched that many times per
+day without authentication. These commands query and reset today's counters
+in the running daemon.`,
+	}
+
+	showCmd := &cobra.Command{
+		Use:   "show",
+		Short: "Show today's launch quota usage",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			msg, err := daemon.QueryStatus(daemonSocketPath(), timeout)
+			if err != nil {
+				return err
+			}
+
+			if msg.Status == nil || len(msg.Status.Quotas) == 0 {
+				fmt.Println("No launch quotas configured")
+				return nil
+			}
+			for _, q := range msg.Status.Quotas {
+				fmt.Println(daemon.FormatQuota(q))
+			}
+			return nil
+		},
+	}
+
+	resetCmd := &cobra.Command{
+		Use:   "reset [path]",
+		Short: "Reset today's launch quota for an app, or all apps",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app := ""
+			if len(args) == 1 {
+				abs, err := filepath.Abs(args[0])
+				if err != nil {
+					return fmt.Errorf("failed to resolve path: %w", err)
+				}
+				app = abs
+			}
+
+			if err := daemon.ResetQuota(daemonSocketPath(), app, timeout); err != nil {
+				return err
+			}
+
+			if app == "" {
+				fmt.Println("Reset launch quotas for all apps")
+			} else {
+				fmt.Printf("Reset launch quota for %s\n", app)
+			}
+			return nil
+		},
+	}
+
+	cmd.PersistentFlags().DurationVar(&timeout, "timeout", 5*time.Second, "Time to wait for the daemon")
+	cmd.AddCommand(showCmd, resetCmd)
+
+	return cmd
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+
+	"wyrmlock/internal/auth"
+	"wyrmlock/internal/config"
+)
+
+// Manage the single-use recovery codes
+func newRecoveryCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "recovery",
+		Short: "Manage one-time recovery codes",
+		Long: `Recovery codes unlock an app once each when the password is forgotten or
+the security key is lost. Enter one in the password dialog; it also stands
+in for the second factor. Only their hashes are stored.`,
+	}
+
+	cmd.AddCommand(
+		newRecoveryGenerateCommand(),
+		newRecoveryStatusCommand(),
+	)
+
+	return cmd
+}
+
+func newRecoveryGenerateCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "generate",
+		Short: "Generate new recovery codes, invalidating the old ones",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if os.Geteuid() != 0 {
+				return fmt.Errorf("generating recovery codes requires root privileges")
+			}
+			cfg, err := config.LoadConfig(configPath)
+			if err != nil {
+				return fmt.Errorf("error loading configuration: %w", err)
+			}
+			return printRecoveryCodes(cfg)
+		},
+	}
+}
+
+func newRecoveryStatusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show how many recovery codes are left",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.LoadConfig(configPath)
+			if err != nil {
+				return fmt.Errorf("error loading configuration: %w", err)
+			}
+			remaining, err := auth.RecoveryCodesRemaining(cfg)
+			if err != nil {
+				return err
+			}
+			if remaining == 0 {
+				fmt.Println(statusErrorStyle.Render("No recovery codes left; run \"wyrmlock recovery generate\""))
+				return nil
+			}
+			fmt.Printf("%s %d of %d recovery codes unused\n", statusOkStyle.Render("OK"), remaining, auth.RecoveryCodeCount)
+			return nil
+		},
+	}
+}
+
+// printRecoveryCodes generates a new set of recovery codes and shows them
+// once
+func printRecoveryCodes(cfg *config.Config) error {
+	codes, err := auth.GenerateRecoveryCodes(cfg)
+	if err != nil {
+		return fmt.Errorf("error generating recovery codes: %w", err)
+	}
+
+	fmt.Println(titleStyle.Render("Recovery codes"))
+	fmt.Println("Each code unlocks once. Store them somewhere safe; they are not shown again.")
+	fmt.Println()
+	for _, code := range codes {
+		fmt.Println("  " + code)
+	}
+	return nil
+}
+
+// offerRecoveryCodes generates recovery codes after a secret is first set,
+// unless unused ones exist already
+func offerRecoveryCodes(final tea.Model) {
+	m, ok := final.(secretModel)
+	if !ok || !m.success || m.cfg == nil || m.cfg.Auth.RecoveryCodeFile == "" {
+		return
+	}
+	if remaining, err := auth.RecoveryCodesRemaining(m.cfg); err != nil || remaining > 0 {
+		return
+	}
+	if err := printRecoveryCodes(m.cfg); err != nil {
+		fmt.Println(statusErrorStyle.Render(err.Error()))
+	}
+}
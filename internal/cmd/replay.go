@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"wyrmlock/internal/monitor"
+	"wyrmlock/pkg/applock"
+)
+
+func newReplayCommand() *cobra.Command {
+	var (
+		policy      string
+		showAllowed bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "replay [recording]",
+		Short: "Evaluate a candidate policy against recorded launches",
+		Long: `Replay the launches captured by "wyrmlock learn record" against a policy
+and report which of them would have been locked or denied. The daemon's own
+policy engine decides each launch, with schedules and profiles evaluated at
+the time it was recorded, so a rule change can be validated against the
+actual workload before it is enforced.
+
+Launch quotas are counted over the recording, day by day. Recordings hold
+no running processes, ancestors or environments, so instance limits,
+screen-time budgets and trusted launchers are never reached, and apps
+locked only in certain environments are reported as locked.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			input := "/var/lib/wyrmlock/learned.json"
+			if len(args) == 1 {
+				input = args[0]
+			}
+			if policy == "" {
+				policy = configPath
+			}
+
+			learner, err := monitor.LoadLearner(input)
+			if err != nil {
+				return err
+			}
+			if len(learner.Launches) == 0 {
+				return fmt.Errorf("%s has no recorded launches; record again with 'wyrmlock learn record'", input)
+			}
+
+			cfg, err := applock.LoadConfig(policy)
+			if err != nil {
+				return fmt.Errorf("failed to load policy: %w", err)
+			}
+			engine, err := applock.NewEngine(cfg, nil)
+			if err != nil {
+				return fmt.Errorf("failed to prepare policy: %w", err)
+			}
+			engine.SetState(applock.NewMemoryState())
+
+			replayLaunches(learner, engine, showAllowed)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&policy, "policy", "p", "", "Policy file to evaluate (defaults to --config)")
+	cmd.Flags().BoolVar(&showAllowed, "show-allowed", false, "Also list launches the policy would allow")
+
+	return cmd
+}
+
+// unrecordedEnv reports that recordings do not hold the environment of
+// launches
+func unrecordedEnv() ([]string, error) {
+	return nil, errors.New("environment not recorded")
+}
+
+// replayLaunches prints the decision for each recorded launch and a summary
+func replayLaunches(learner *monitor.Learner, engine *applock.Engine, showAllowed bool) {
+	fmt.Println(titleStyle.Render("wyrmlock Policy Replay"))
+
+	counts := make(map[applock.Action]int)
+	for _, launch := range learner.Launches {
+		decision := engine.Decide(applock.Launch{
+			Executable: launch.Executable,
+			Script:     launch.Script,
+			CmdLine:    launch.CmdLine,
+			Hash:       launch.Hash,
+			Sandbox:    launch.Sandbox,
+			UID:        launch.UID,
+			GID:        launch.GID,
+			Groups:     launch.Groups,
+			Cwd:        launch.Cwd,
+			Removable:  launch.Removable,
+			Replaced:   launch.Replaced,
+			Time:       launch.Time,
+			LookupEnv:  unrecordedEnv,
+		})
+		counts[decision.Action]++
+
+		if decision.Action == applock.ActionAllow && !showAllowed {
+			continue
+		}
+
+		label := statusOkStyle.Render("ALLOW ")
+		switch decision.Action {
+		case applock.ActionPrompt:
+			label = statusWarningStyle.Render("LOCK  ")
+		case applock.ActionDeny:
+			label = statusErrorStyle.Render("DENY  ")
+		}
+
+		target := launch.Executable
+		if launch.Script != "" {
+			target = launch.Script
+		}
+		fmt.Printf("%s %s  %-10s %s (%s)\n", label, launch.Time.Format("2006-01-02 15:04:05"), launch.User, target, decision.Reason)
+	}
+
+	fmt.Printf("\n%d launches replayed: %d allowed, %d locked, %d denied\n", len(learner.Launches),
+		counts[applock.ActionAllow], counts[applock.ActionPrompt], counts[applock.ActionDeny])
+	if learner.Dropped > 0 {
+		fmt.Printf("%d further launches were not kept in the recording\n", learner.Dropped)
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"wyrmlock/internal/i18n"
 )
 
 var (
@@ -14,6 +17,10 @@ var (
 
 // NewRootCommand creates the root command for the wyrmlock CLI
 func NewRootCommand() *cobra.Command {
+	// Help follows the language of the environment; commands that load the
+	// configuration switch to the one set there
+	_ = i18n.Setup("", i18n.DefaultDir)
+
 	rootCmd := &cobra.Command{
 		Use:   "wyrmlock",
 		Short: "A security tool to control access to applications",
@@ -26,7 +33,8 @@ the user provides the correct authentication.`,
 		PersistentPreRun: func(cmd *cobra.Command, args []string) {
 			// Check if running as root for commands that require it
 			if cmd.Name() != "version" && cmd.Name() != "help" && cmd.Name() != "create-config" && 
-			   cmd.Name() != "keychain" && os.Geteuid() != 0 {
+			   cmd.Name() != "keychain" && cmd.Name() != "status" && cmd.Name() != "suggest" &&
+			   cmd.Name() != "replay" && os.Geteuid() != 0 {
 				fmt.Fprintln(os.Stderr, "This command requires root privileges to run")
 				os.Exit(1)
 			}
@@ -42,12 +50,48 @@ the user provides the correct authentication.`,
 		newAppCommand(),
 		newRunCommand(),
 		newSetSecretCommand(),
+		newPasswdCommand(),
 		newCreateConfigCommand(),
 		newListCommand(),
 		newVersionCommand(),
 		newConfigCommand(),
+		newStatusCommand(),
+		newQuotaCommand(),
+		newLearnCommand(),
+		newAuditCommand(),
+		newReplayCommand(),
+		newSelftestCommand(),
+		newSelftestTargetCommand(),
+		newSettingsCommand(),
+		newSealCommand(),
+		newFido2Command(),
+		newYubiKeyCommand(),
+		newUserSecretCommand(),
+		newProfileCommand(),
+		newPromptCommand(),
+		newDesktopKeyringCommand(),
+		newKeyringHelperCommand(),
+		newRecoveryCommand(),
+		newOverrideCommand(),
+		newBluetoothCommand(),
 		newKeychainCommand(), // Add the new keychain command
 	)
 
+	localizeCommand(rootCmd)
 	return rootCmd
 }
+
+// localizeCommand translates the help of cmd, its flags and its
+// subcommands
+func localizeCommand(cmd *cobra.Command) {
+	cmd.Short = i18n.T(cmd.Short)
+	cmd.Long = i18n.T(cmd.Long)
+	translate := func(flag *pflag.Flag) {
+		flag.Usage = i18n.T(flag.Usage)
+	}
+	cmd.Flags().VisitAll(translate)
+	cmd.PersistentFlags().VisitAll(translate)
+	for _, sub := range cmd.Commands() {
+		localizeCommand(sub)
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"wyrmlock/internal/auth"
 	"wyrmlock/internal/config"
 	"wyrmlock/internal/daemon"
+	"wyrmlock/internal/i18n"
 	"wyrmlock/internal/logging"
 	"wyrmlock/internal/monitor"
 )
@@ -35,6 +36,9 @@ var (
 	statusErrorStyle = lipgloss.NewStyle().
 				Foreground(lipgloss.Color("#FF5555"))
 
+	statusWarningStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#F1FA8C"))
+
 	tableStyle = lipgloss.NewStyle().
 			BorderStyle(lipgloss.NormalBorder()).
 			BorderForeground(lipgloss.Color("#7D56F4"))
@@ -207,6 +211,8 @@ var (
 
 // initializeMonitor creates and initializes the process monitor for legacy mode (no privilege separation)
 func initializeMonitor(cfg *config.Config) (*monitor.ProcessMonitor, error) {
+	setupLanguage(cfg)
+
 	// Create authenticator using cfg
 	authenticator, err := auth.NewAuthenticator(cfg)
 	if err != nil {
@@ -224,6 +230,8 @@ func initializeMonitor(cfg *config.Config) (*monitor.ProcessMonitor, error) {
 
 // initializeDaemon creates and initializes the privileged daemon
 func initializeDaemon(cfg *config.Config) (*daemon.Daemon, error) {
+	setupLanguage(cfg)
+
 	// Create the daemon instance
 	d, err := daemon.NewDaemon(cfg)
 	if err != nil {
@@ -233,8 +241,18 @@ func initializeDaemon(cfg *config.Config) (*daemon.Daemon, error) {
 	return d, nil
 }
 
+// setupLanguage translates prompts and notifications into the configured
+// language; a catalog that fails to load leaves the language as it was
+func setupLanguage(cfg *config.Config) {
+	if err := i18n.Setup(cfg.Locale, cfg.LocaleDir); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to load translations: %v\n", err)
+	}
+}
+
 // initializeClient creates and initializes the unprivileged client
 func initializeClient(cfg *config.Config) (*daemon.Client, error) {
+	setupLanguage(cfg)
+
 	// Create authenticator
 	authenticator, err := auth.NewAuthenticator(cfg)
 	if err != nil {
@@ -403,6 +421,12 @@ Legacy mode (--legacy) runs everything in a single process with root privileges.
 
 // runDaemonMode runs the application in daemon mode (privileged)
 func runDaemonMode(cfg *config.Config) {
+	// Confine filesystem access first; this re-executes the daemon
+	if err := daemon.ConfineFilesystem(cfg, logging.DefaultLogger); err != nil {
+		fmt.Printf("Failed to confine daemon: %v\n", err)
+		os.Exit(1)
+	}
+
 	fmt.Println("Starting wyrmlock in daemon mode (privileged)")
 
 	// Initialize daemon
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/integrity"
+	"wyrmlock/internal/privilege"
+)
+
+// Create the seal command
+func newSealCommand() *cobra.Command {
+	var verify bool
+
+	cmd := &cobra.Command{
+		Use:   "seal",
+		Short: "Seal the hashes of the wyrmlock binaries and configuration",
+		Long: `Record the SHA-256 of the wyrmlock binary, the privileged helper and the
+configuration file. The daemon checks them against the seal when it starts
+and logs, or with integrity_action = "refuse" refuses to start, when any of
+them has changed. Run it after installing, upgrading or editing the
+configuration. Sign the seal file (<seal_file>.sig) with a trusted signer to
+protect the seal itself.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.LoadConfig(configPath)
+			if err != nil {
+				return fmt.Errorf("error loading configuration: %w", err)
+			}
+			if cfg.Monitor.SealFile == "" {
+				return fmt.Errorf("no seal_file is configured")
+			}
+			targets := integrity.Targets(cfg.ConfigFile, privilege.DefaultHelperBinary)
+
+			if verify {
+				seal, err := integrity.Load(cfg.Monitor.SealFile)
+				if err != nil {
+					return fmt.Errorf("error reading seal: %w", err)
+				}
+				mismatches := seal.Verify(targets)
+				for _, mismatch := range mismatches {
+					fmt.Printf("%s %s\n", statusErrorStyle.Render("FAIL"), mismatch)
+				}
+				if len(mismatches) > 0 {
+					return fmt.Errorf("%d files do not match the seal", len(mismatches))
+				}
+				fmt.Printf("%s all files match the seal from %s\n", statusOkStyle.Render("OK"), seal.SealedAt.Format("2006-01-02 15:04"))
+				return nil
+			}
+
+			if os.Geteuid() != 0 {
+				return fmt.Errorf("sealing requires root privileges")
+			}
+			seal, err := integrity.Create(targets)
+			if err != nil {
+				return err
+			}
+			if err := seal.Save(cfg.Monitor.SealFile); err != nil {
+				return err
+			}
+			for path, hash := range seal.Files {
+				fmt.Printf("Sealed %s (%s)\n", path, hash)
+			}
+			fmt.Printf("Seal written to %s\n", cfg.Monitor.SealFile)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&verify, "verify", false, "Check the files against the existing seal instead of sealing")
+	return cmd
+}
@@ -0,0 +1,303 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/daemon"
+	"wyrmlock/internal/monitor"
+)
+
+// selftestTargetCommand is the hidden subcommand run by the self-test target
+const selftestTargetCommand = "selftest-target"
+
+// selftestResult is the outcome of one self-test step
+type selftestResult struct {
+	name string
+	err  error
+}
+
+func newSelftestCommand() *cobra.Command {
+	var timeout, promptTimeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "selftest",
+		Short: "Verify that launches are caught, suspended and controlled",
+		Long: `Run the full protection pipeline against a harmless test binary.
+
+The test loads your configuration, registers a temporary copy of wyrmlock as
+the only protected application, and runs a private daemon and agent with your
+authentication and prompt settings. It launches the copy and checks that the
+launch was detected and suspended, that your configured unlock method was
+asked and resumed it, and that terminate works. Unlock the test binary when
+prompted. Use it to confirm the kernel, backend and GUI combination on this
+machine actually functions; the running daemon is left alone.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			results := runSelftest(timeout, promptTimeout)
+
+			fmt.Println(titleStyle.Render("wyrmlock Self-Test"))
+			failed := 0
+			for _, r := range results {
+				if r.err != nil {
+					failed++
+					fmt.Printf("%s %s: %v\n", statusErrorStyle.Render("FAIL"), r.name, r.err)
+				} else {
+					fmt.Printf("%s %s\n", statusOkStyle.Render("PASS"), r.name)
+				}
+			}
+
+			fmt.Printf("\n%d of %d checks passed\n", len(results)-failed, len(results))
+			if failed > 0 {
+				return fmt.Errorf("self-test failed")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVar(&timeout, "timeout", 5*time.Second, "Time to wait for each step")
+	cmd.Flags().DurationVar(&promptTimeout, "prompt-timeout", 2*time.Minute, "Time to wait for the test binary to be unlocked")
+
+	return cmd
+}
+
+// newSelftestTargetCommand is the harmless process launched by the
+// self-test; it only sleeps
+func newSelftestTargetCommand() *cobra.Command {
+	var duration time.Duration
+
+	cmd := &cobra.Command{
+		Use:    selftestTargetCommand,
+		Short:  "Target process for selftest",
+		Hidden: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			time.Sleep(duration)
+		},
+	}
+
+	cmd.Flags().DurationVar(&duration, "duration", 30*time.Second, "How long to run")
+
+	return cmd
+}
+
+// runSelftest runs every self-test step, stopping at the first step whose
+// failure makes the rest meaningless
+func runSelftest(timeout, promptTimeout time.Duration) []selftestResult {
+	var results []selftestResult
+	record := func(name string, err error) bool {
+		results = append(results, selftestResult{name: name, err: err})
+		return err == nil
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if !record("load configuration", err) {
+		return results
+	}
+
+	tmpDir, err := os.MkdirTemp("", "wyrmlock-selftest-")
+	if !record("create test directory", err) {
+		return results
+	}
+	defer os.RemoveAll(tmpDir)
+
+	target, err := installSelftestTarget(tmpDir)
+	if !record("install test binary", err) {
+		return results
+	}
+
+	// Protect only the test binary, and keep the private daemon's records
+	// apart from the running daemon's
+	cfg.Monitor.ProtectedApps = []string{target}
+	cfg.Monitor.StateFile = ""
+	cfg.Monitor.QuotaFile = filepath.Join(tmpDir, "quota.json")
+	cfg.Monitor.UsageFile = filepath.Join(tmpDir, "usage.json")
+	cfg.Monitor.LedgerFile = filepath.Join(tmpDir, "ledger.json")
+	cfg.Monitor.SequenceFile = filepath.Join(tmpDir, "sequence")
+
+	d, err := daemon.NewDaemon(cfg)
+	if !record("create daemon", err) {
+		return results
+	}
+	if !record("start daemon", d.StartSelftest(filepath.Join(tmpDir, "daemon.sock"))) {
+		return results
+	}
+	defer d.StopSelftest()
+	procMonitor := d.Monitor()
+
+	// The agent prompts as it does for a real launch
+	agent, err := initializeClient(cfg)
+	if !record("create agent", err) {
+		return results
+	}
+	if !record("connect agent", agent.Connect()) {
+		return results
+	}
+	defer agent.Disconnect()
+
+	// First launch: detect, suspend, prompt and resume
+	first, err := launchSelftestTarget(target)
+	if !record("launch test binary", err) {
+		return results
+	}
+	defer stopSelftestTarget(first)
+
+	if !record("launch detected", waitForTracked(procMonitor, first.Process.Pid, timeout)) {
+		return results
+	}
+	record("process suspended", waitForProcessState(procMonitor, first.Process.Pid, monitor.ProcessStateSuspended, timeout))
+
+	fmt.Printf("Unlock %s when prompted (waiting up to %s)\n", filepath.Base(target), promptTimeout)
+	if !record("unlocked and resumed", waitForProcessState(procMonitor, first.Process.Pid, monitor.ProcessStateRunning, promptTimeout)) {
+		return results
+	}
+	record("grant recorded", waitForGrant(cfg.SocketPath, first.Process.Pid, timeout))
+
+	// Second launch: terminate
+	second, err := launchSelftestTarget(target)
+	if !record("launch test binary again", err) {
+		return results
+	}
+	defer stopSelftestTarget(second)
+
+	if !record("second launch detected", waitForTracked(procMonitor, second.Process.Pid, timeout)) {
+		return results
+	}
+
+	if err := procMonitor.TerminateProcess(second.Process.Pid); err != nil {
+		record("terminate", err)
+	} else {
+		record("terminate", waitForExit(second, timeout))
+	}
+
+	return results
+}
+
+// installSelftestTarget copies the running executable into dir so it can be
+// protected without affecting any real application
+func installSelftestTarget(dir string) (string, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate executable: %w", err)
+	}
+
+	src, err := os.Open(self)
+	if err != nil {
+		return "", fmt.Errorf("failed to open executable: %w", err)
+	}
+	defer src.Close()
+
+	// The monitor matches resolved paths, so resolve the directory as well
+	resolvedDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve test directory: %w", err)
+	}
+	target := filepath.Join(resolvedDir, "wyrmlock-selftest-target")
+
+	dst, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return "", fmt.Errorf("failed to create test binary: %w", err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return "", fmt.Errorf("failed to copy test binary: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		return "", fmt.Errorf("failed to write test binary: %w", err)
+	}
+
+	return target, nil
+}
+
+// launchSelftestTarget starts the test binary
+func launchSelftestTarget(target string) (*exec.Cmd, error) {
+	cmd := exec.Command(target, selftestTargetCommand, "--config", configPath)
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", target, err)
+	}
+	return cmd, nil
+}
+
+// stopSelftestTarget makes sure a test process does not outlive the test
+func stopSelftestTarget(cmd *exec.Cmd) {
+	if cmd.ProcessState != nil {
+		return
+	}
+	_ = cmd.Process.Kill()
+	_ = cmd.Wait()
+}
+
+// waitForTracked waits for the monitor to pick up the launch of pid
+func waitForTracked(procMonitor *monitor.ProcessMonitor, pid int, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if _, ok := procMonitor.GetProcess(pid); ok {
+			return nil
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return fmt.Errorf("no launch of PID %d detected within %s", pid, timeout)
+}
+
+// waitForGrant waits for the daemon to report the unlock of pid as a grant
+func waitForGrant(socketPath string, pid int, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		msg, err := daemon.QueryStatus(socketPath, timeout)
+		if err != nil {
+			return err
+		}
+		if msg.Status != nil {
+			for _, grant := range msg.Status.Grants {
+				if grant.PID == pid {
+					return nil
+				}
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return fmt.Errorf("no grant for PID %d reported within %s", pid, timeout)
+}
+
+// waitForProcessState polls until the process reaches the wanted state
+func waitForProcessState(procMonitor *monitor.ProcessMonitor, pid int, want string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	state := ""
+	for time.Now().Before(deadline) {
+		var err error
+		state, err = procMonitor.GetProcessState(pid)
+		if err != nil {
+			return err
+		}
+		if state == want {
+			return nil
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return fmt.Errorf("process %d is %s, expected %s", pid, state, want)
+}
+
+// waitForExit waits for a test process to exit
+func waitForExit(cmd *exec.Cmd, timeout time.Duration) error {
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	select {
+	case err := <-done:
+		var exitErr *exec.ExitError
+		if err != nil && !errors.As(err, &exitErr) {
+			return err
+		}
+		return nil
+	case <-time.After(timeout):
+		_ = cmd.Process.Kill()
+		<-done
+		return fmt.Errorf("process %d still running after %s", cmd.Process.Pid, timeout)
+	}
+}
@@ -19,8 +19,26 @@ type secretModel struct {
 	confirm   textinput.Model
 	cfg       *config.Config
 	err       error
-	inputMode int // 0: password, 1: confirm
+	inputMode int // 0: password, 1: confirm, 2: current secret
 	success   bool
+
+	// appSecret names the per-app secret and userSecret the user whose
+	// secret to set; both empty sets the global one
+	appSecret  string
+	userSecret string
+
+	// parentSecret sets the parent password of the parental-control
+	// profiles instead
+	parentSecret bool
+
+	// desktopKeyring stores the calling user's secret in their desktop
+	// keyring instead
+	desktopKeyring bool
+
+	// verify, set by passwd, checks the current secret entered in
+	// current before a new one is accepted
+	verify  *auth.Authenticator
+	current textinput.Model
 }
 
 func initialSecretModel() secretModel {
@@ -55,7 +73,29 @@ func (m secretModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 
 		case "enter":
-			if m.inputMode == 0 {
+			// Enter on an error goes back to the form
+			if m.err != nil && m.cfg != nil {
+				m.err = nil
+				return m, nil
+			}
+
+			if m.inputMode == 2 {
+				// The current secret, or a recovery code, must be known
+				// to replace it
+				ok, err := m.verify.AuthenticateUser([]byte(m.current.Value()), "", -1)
+				m.current.SetValue("")
+				if err == nil && !ok {
+					err = fmt.Errorf("wrong secret")
+				}
+				if err != nil {
+					m.err = fmt.Errorf("current secret not accepted: %w", err)
+					return m, nil
+				}
+				m.err = nil
+				m.inputMode = 0
+				m.current.Blur()
+				return m, m.input.Focus()
+			} else if m.inputMode == 0 {
 				// Switch to confirm password
 				m.inputMode = 1
 				m.input.Blur()
@@ -71,13 +111,42 @@ func (m secretModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return m, nil
 				}
 
-				// Save secret using authenticator
-				a, err := auth.NewAuthenticator(m.cfg)
-				if err != nil {
-					m.err = fmt.Errorf("failed to initialize authenticator: %w", err)
+				// Weak secrets are refused with what to change
+				if err := auth.CheckSecretStrength(m.cfg, []byte(m.input.Value())); err != nil {
+					m.err = err
+					m.inputMode = 0
+					m.input.SetValue("")
+					m.confirm.SetValue("")
+					m.input.Focus()
+					m.confirm.Blur()
 					return m, nil
 				}
-				if err := a.SetSecret([]byte(m.input.Value())); err != nil {
+
+				// Save secret using authenticator; the desktop keyring is
+				// set by users who cannot read the global secret
+				var setSecret func([]byte) error
+				if m.desktopKeyring {
+					setSecret = func(secret []byte) error { return auth.SetDesktopSecret(m.cfg, secret) }
+				} else {
+					a := m.verify
+					if a == nil {
+						var err error
+						a, err = auth.NewAuthenticator(m.cfg)
+						if err != nil {
+							m.err = fmt.Errorf("failed to initialize authenticator: %w", err)
+							return m, nil
+						}
+					}
+					setSecret = a.SetSecret
+					if m.appSecret != "" {
+						setSecret = func(secret []byte) error { return a.SetAppSecret(m.appSecret, secret) }
+					} else if m.userSecret != "" {
+						setSecret = func(secret []byte) error { return a.SetUserSecret(m.userSecret, secret) }
+					} else if m.parentSecret {
+						setSecret = a.SetParentSecret
+					}
+				}
+				if err := setSecret([]byte(m.input.Value())); err != nil {
 					m.err = fmt.Errorf("failed to set secret: %w", err)
 					return m, nil
 				}
@@ -96,7 +165,11 @@ func (m secretModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	// Handle input changes
-	if m.inputMode == 0 {
+	if m.inputMode == 2 {
+		var cmd tea.Cmd
+		m.current, cmd = m.current.Update(msg)
+		return m, cmd
+	} else if m.inputMode == 0 {
 		var cmd tea.Cmd
 		m.input, cmd = m.input.Update(msg)
 		return m, cmd
@@ -129,7 +202,9 @@ func (m secretModel) View() string {
 	var screen string
 	screen += titleStyle.Render("Set Authentication Secret") + "\n\n"
 
-	if m.inputMode == 0 {
+	if m.inputMode == 2 {
+		screen += "Current secret: " + m.current.View() + "\n\n"
+	} else if m.inputMode == 0 {
 		screen += "Enter your secret: " + m.input.View() + "\n\n"
 	} else {
 		screen += "Enter your secret: " + strings.Repeat("•", len(m.input.Value())) + "\n"
@@ -147,9 +222,12 @@ func newSetSecretCommand() *cobra.Command {
 		Long:  `Set or change the authentication secret used to unlock applications.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			p := tea.NewProgram(initialSecretModel())
-			if _, err := p.Run(); err != nil {
+			final, err := p.Run()
+			if err != nil {
 				fmt.Printf("Error setting secret: %v\n", err)
+				return
 			}
+			offerRecoveryCodes(final)
 		},
 	}
 
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/desktop"
+	"wyrmlock/internal/gui"
+)
+
+// Create the settings command
+func newSettingsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "settings",
+		Short: "Choose protected applications in a graphical picker",
+		Long: `Show installed applications and running programs in a checklist and
+save the ones selected as protected applications.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := config.LoadConfig(configPath)
+			if err != nil {
+				fmt.Printf("Error loading configuration: %v\n", err)
+				return
+			}
+
+			apps := pickerApps(cfg)
+			changes, ok, err := gui.ShowAppPicker(apps)
+			if err != nil {
+				fmt.Printf("Error showing app picker: %v\n", err)
+				return
+			}
+			if !ok || changes.Empty() {
+				fmt.Println("No changes made.")
+				return
+			}
+
+			applyPickerChanges(cfg, changes)
+
+			// Never write a configuration the daemon would refuse to load
+			if err := config.Validate(cfg); err != nil {
+				fmt.Printf("Invalid configuration, not saved: %v\n", err)
+				return
+			}
+			if err := config.SaveConfig(cfg, configPath); err != nil {
+				fmt.Printf("Error saving configuration: %v\n", err)
+				return
+			}
+
+			for _, app := range changes.Protect {
+				fmt.Printf("Added %s to protected applications.\n", app.Path)
+			}
+			for _, app := range changes.Unprotect {
+				fmt.Printf("Removed %s from protected applications.\n", app.Path)
+			}
+		},
+	}
+
+	return cmd
+}
+
+// pickerApps lists the protected apps, installed applications and running
+// programs, one row per executable
+func pickerApps(cfg *config.Config) []gui.PickerApp {
+	rows := make(map[string]*gui.PickerApp)
+	var order []string
+	add := func(app gui.PickerApp) {
+		if _, ok := rows[app.Path]; ok {
+			return
+		}
+		rows[app.Path] = &app
+		order = append(order, app.Path)
+	}
+
+	for _, entry := range desktop.Load(desktop.Dirs()) {
+		if !entry.Hidden && entry.Path != "" {
+			add(gui.PickerApp{Name: entry.Name, Path: entry.Path, Source: gui.PickerSourceDesktop})
+		}
+	}
+	for _, exe := range runningExecutables() {
+		add(gui.PickerApp{Name: filepath.Base(exe), Path: exe, Source: gui.PickerSourceRunning})
+	}
+
+	// Protected apps are always listed so they can be switched off
+	for _, path := range protectedPaths(cfg) {
+		add(gui.PickerApp{Name: filepath.Base(path), Path: path, Source: gui.PickerSourceConfig})
+		rows[path].Protected = true
+	}
+	for _, app := range cfg.BlockedApps {
+		if row, ok := rows[app.Path]; ok && app.DisplayName != "" && row.Source == gui.PickerSourceConfig {
+			row.Name = app.DisplayName
+		}
+	}
+
+	apps := make([]gui.PickerApp, 0, len(order))
+	for _, path := range order {
+		apps = append(apps, *rows[path])
+	}
+	gui.SortPickerApps(apps)
+	return apps
+}
+
+// protectedPaths returns the executables protected by path. Directory,
+// hash and sandbox entries are left to the other app commands.
+func protectedPaths(cfg *config.Config) []string {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, entry := range cfg.Monitor.ProtectedApps {
+		if config.IsPathEntry(entry) && !strings.HasSuffix(entry, "/") && !seen[entry] {
+			seen[entry] = true
+			paths = append(paths, entry)
+		}
+	}
+	for _, app := range cfg.BlockedApps {
+		if !seen[app.Path] {
+			seen[app.Path] = true
+			paths = append(paths, app.Path)
+		}
+	}
+	return paths
+}
+
+// runningExecutables returns the executables of running user programs,
+// leaving out system helpers
+func runningExecutables() []string {
+	procDirs, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var exes []string
+	for _, dir := range procDirs {
+		if _, err := strconv.Atoi(dir.Name()); err != nil {
+			continue
+		}
+		exe, err := os.Readlink(filepath.Join("/proc", dir.Name(), "exe"))
+		if err != nil || seen[exe] || strings.HasSuffix(exe, " (deleted)") {
+			continue
+		}
+		seen[exe] = true
+
+		if strings.HasPrefix(exe, "/usr/lib/") || strings.HasPrefix(exe, "/usr/libexec/") ||
+			strings.HasPrefix(exe, "/usr/sbin/") || strings.HasPrefix(exe, "/sbin/") {
+			continue
+		}
+		exes = append(exes, exe)
+	}
+	return exes
+}
+
+// applyPickerChanges adds and removes the apps toggled in the picker
+func applyPickerChanges(cfg *config.Config, changes gui.PickerChanges) {
+	for _, app := range changes.Protect {
+		if !slices.Contains(cfg.Monitor.ProtectedApps, app.Path) {
+			cfg.Monitor.ProtectedApps = append(cfg.Monitor.ProtectedApps, app.Path)
+		}
+		if !slices.ContainsFunc(cfg.BlockedApps, func(b config.BlockedApp) bool { return b.Path == app.Path }) {
+			cfg.BlockedApps = append(cfg.BlockedApps, config.BlockedApp{Path: app.Path, DisplayName: app.Name})
+		}
+	}
+
+	for _, app := range changes.Unprotect {
+		var protected []string
+		for _, path := range cfg.Monitor.ProtectedApps {
+			if path != app.Path {
+				protected = append(protected, path)
+			}
+		}
+		cfg.Monitor.ProtectedApps = protected
+
+		var blocked []config.BlockedApp
+		for _, b := range cfg.BlockedApps {
+			if b.Path != app.Path {
+				blocked = append(blocked, b)
+			}
+		}
+		cfg.BlockedApps = blocked
+	}
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"wyrmlock/internal/daemon"
+	"wyrmlock/internal/ipc"
+)
+
+// defaultSocketPath is used when the configuration cannot be read, e.g. when
+// running status as an unprivileged user
+const defaultSocketPath = "/var/run/wyrmlock-daemon.sock"
+
+func newStatusCommand() *cobra.Command {
+	var (
+		jsonOutput bool
+		timeout    time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show active grants, quota usage, lockouts and kernel features",
+		Long: `Query the running daemon and list active grants (which app, for whom, and
+until when), today's quota consumption per app, and active authentication
+lockouts with the time left before another attempt is allowed. Also shows
+the kernel features detected at startup and warns when the daemon runs in a
+degraded mode. Users other than root see only their own processes and
+grants.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			msg, err := daemon.QueryStatus(daemonSocketPath(), timeout)
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(msg)
+			}
+
+			printStatus(msg, time.Now())
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Print the raw status response as JSON")
+	cmd.Flags().DurationVar(&timeout, "timeout", 5*time.Second, "Time to wait for the daemon")
+
+	return cmd
+}
+
+// printStatus renders a status response as plain text
+func printStatus(msg *ipc.Message, now time.Time) {
+	report := msg.Status
+	if report == nil {
+		report = &ipc.StatusReport{}
+	}
+
+	fmt.Println(titleStyle.Render("wyrmlock Status"))
+	fmt.Printf("Protected apps: %d\n", len(msg.ProtectedApps))
+	fmt.Printf("Monitored processes: %d\n\n", len(msg.ProcessList))
+
+	fmt.Println("Active grants:")
+	if len(report.Grants) == 0 {
+		fmt.Println("  none")
+	}
+	for _, g := range report.Grants {
+		fmt.Printf("  %s\n", daemon.FormatGrant(g, now))
+	}
+
+	fmt.Println("\nQuota usage today:")
+	if len(report.Quotas) == 0 {
+		fmt.Println("  none")
+	}
+	for _, q := range report.Quotas {
+		fmt.Printf("  %s\n", daemon.FormatQuota(q))
+	}
+
+	fmt.Println("\nActive lockouts:")
+	if len(report.Lockouts) == 0 {
+		fmt.Println("  none")
+	}
+	for _, l := range report.Lockouts {
+		fmt.Printf("  %s\n", daemon.FormatLockout(l, now))
+	}
+
+	if report.Kernel != nil {
+		fmt.Println("\nKernel features:")
+		for _, line := range daemon.FormatKernel(report.Kernel) {
+			fmt.Printf("  %s\n", line)
+		}
+		for _, warning := range report.Kernel.Warnings {
+			fmt.Printf("  %s %s\n", statusWarningStyle.Render("WARN"), warning)
+		}
+	}
+
+	if report.Events != nil {
+		fmt.Println("\nExec events:")
+		fmt.Printf("  %s\n", daemon.FormatEventQueue(report.Events))
+	}
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+
+	"wyrmlock/internal/auth"
+	"wyrmlock/internal/config"
+)
+
+// Manage per-user secrets
+func newUserSecretCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "user-secret",
+		Short: "Manage per-user passwords",
+		Long: `Set, list and remove the passwords of users who do not use the global one.
+The daemon checks the password for a launch against the secret of the user
+who started it; apps naming their own secret still use that.`,
+	}
+
+	cmd.AddCommand(
+		newUserSecretSetCommand(),
+		newUserSecretListCommand(),
+		newUserSecretRemoveCommand(),
+	)
+
+	return cmd
+}
+
+func newUserSecretSetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <user>",
+		Short: "Set a user's password",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := user.Lookup(args[0]); err != nil {
+				return fmt.Errorf("unknown user %s: %w", args[0], err)
+			}
+			if os.Geteuid() != 0 {
+				return fmt.Errorf("setting a secret requires root privileges")
+			}
+
+			model := initialSecretModel()
+			model.userSecret = args[0]
+			if _, err := tea.NewProgram(model).Run(); err != nil {
+				return fmt.Errorf("error setting secret: %w", err)
+			}
+			return nil
+		},
+	}
+}
+
+// loadAuthenticator loads the configuration and creates an authenticator
+func loadAuthenticator() (*auth.Authenticator, error) {
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading configuration: %w", err)
+	}
+	a, err := auth.NewAuthenticator(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing authenticator: %w", err)
+	}
+	return a, nil
+}
+
+func newUserSecretListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List users with their own password",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			a, err := loadAuthenticator()
+			if err != nil {
+				return err
+			}
+			users, err := a.UserSecrets()
+			if err != nil {
+				return err
+			}
+			if len(users) == 0 {
+				fmt.Println("No per-user passwords set; all users use the global password.")
+				return nil
+			}
+			for _, name := range users {
+				if _, err := user.Lookup(name); err != nil {
+					fmt.Printf("%s %s\n", name, statusErrorStyle.Render("(no such user)"))
+					continue
+				}
+				fmt.Println(name)
+			}
+			return nil
+		},
+	}
+}
+
+func newUserSecretRemoveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove <user>",
+		Short: "Remove a user's password; the user uses the global one again",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if os.Geteuid() != 0 {
+				return fmt.Errorf("removing a secret requires root privileges")
+			}
+			a, err := loadAuthenticator()
+			if err != nil {
+				return err
+			}
+			if err := a.RemoveUserSecret(args[0]); err != nil {
+				return err
+			}
+			fmt.Printf("Removed the secret of %s\n", args[0])
+			return nil
+		},
+	}
+}
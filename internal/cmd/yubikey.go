@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+
+	"wyrmlock/internal/auth"
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/yubikey"
+)
+
+// Create the yubikey command
+func newYubiKeyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "yubikey",
+		Short: "Set up the YubiKey challenge-response backend",
+		Long: `Provision a YubiKey for backend = "yubikey", which checks the unlock password
+together with the key's HMAC-SHA1 challenge-response so the unlock secret is
+never stored. Requires the ykpers tools (ykchalresp, ykpersonalize).`,
+	}
+
+	cmd.AddCommand(
+		newYubiKeySetupCommand(),
+		newYubiKeyCheckCommand(),
+	)
+
+	return cmd
+}
+
+// loadYubiKeyConfig loads the configuration and checks it uses the
+// yubikey backend
+func loadYubiKeyConfig() (*config.Config, error) {
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading configuration: %w", err)
+	}
+	if cfg.Auth.Backend != config.AuthBackendYubiKey {
+		return nil, fmt.Errorf(`set backend = "yubikey" in the [auth] section first`)
+	}
+	return cfg, nil
+}
+
+func newYubiKeySetupCommand() *cobra.Command {
+	var program, touch bool
+
+	cmd := &cobra.Command{
+		Use:   "setup",
+		Short: "Bind the unlock password to the YubiKey",
+		Long: `Set the unlock password for the yubikey backend. With --program the configured
+slot is first programmed with a new random HMAC-SHA1 key, replacing what the
+slot held; without it the slot must already be set up for challenge-response.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if os.Geteuid() != 0 {
+				return fmt.Errorf("YubiKey setup requires root privileges")
+			}
+			cfg, err := loadYubiKeyConfig()
+			if err != nil {
+				return err
+			}
+
+			if program {
+				ctx, cancel := context.WithTimeout(cmd.Context(), auth.YubiKeyTimeout)
+				defer cancel()
+				if err := yubikey.Program(ctx, cfg.Auth.YubiKeySlot, touch); err != nil {
+					return fmt.Errorf("error programming slot %d: %w", cfg.Auth.YubiKeySlot, err)
+				}
+				fmt.Printf("%s programmed slot %d for challenge-response\n", statusOkStyle.Render("OK"), cfg.Auth.YubiKeySlot)
+			}
+
+			fmt.Println("Touch the YubiKey if it blinks after entering the password.")
+			p := tea.NewProgram(initialSecretModel())
+			final, err := p.Run()
+			if err != nil {
+				return fmt.Errorf("error setting secret: %w", err)
+			}
+			offerRecoveryCodes(final)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&program, "program", false, "Program the slot with a new random key (replaces its current configuration)")
+	cmd.Flags().BoolVar(&touch, "touch", false, "With --program, require a touch for every challenge")
+	return cmd
+}
+
+func newYubiKeyCheckCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "check",
+		Short: "Check that the YubiKey answers challenges",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadYubiKeyConfig()
+			if err != nil {
+				return err
+			}
+
+			challenge := make([]byte, 32)
+			if _, err := rand.Read(challenge); err != nil {
+				return err
+			}
+			ctx, cancel := context.WithTimeout(cmd.Context(), auth.YubiKeyTimeout)
+			defer cancel()
+			if _, err := yubikey.Respond(ctx, cfg.Auth.YubiKeySlot, challenge); err != nil {
+				fmt.Printf("%s slot %d: %v\n", statusErrorStyle.Render("FAIL"), cfg.Auth.YubiKeySlot, err)
+				return err
+			}
+
+			fmt.Printf("%s slot %d answers challenges\n", statusOkStyle.Render("OK"), cfg.Auth.YubiKeySlot)
+			if _, err := os.Stat(cfg.Auth.YubiKeyFile); err != nil {
+				fmt.Println("No password is bound yet; run wyrmlock yubikey setup.")
+			}
+			return nil
+		},
+	}
+}
@@ -1,9 +1,11 @@
 package config
 
 import (
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/spf13/viper"
@@ -26,11 +28,33 @@ type Config struct {
 	// BlockedApps is a list of applications that require authentication
 	BlockedApps []BlockedApp `json:"blocked_apps"`
 
+	// Profiles are parental-control profiles for child accounts
+	Profiles []Profile `json:"profiles,omitempty"`
+
+	// Authorization configures delegation of launch decisions to an
+	// external policy service
+	Authorization AuthorizationConfig `json:"authorization,omitempty"`
+
+	// Notifications controls how the client batches and rate-limits
+	// desktop notifications
+	Notifications NotificationConfig `json:"notifications,omitempty"`
+
 	// KeychainService is the name of the keychain service
 	KeychainService string `json:"keychain_service,omitempty"`
 
 	// KeychainAccount is the name of the keychain account
 	KeychainAccount string `json:"keychain_account,omitempty"`
+
+	// Locale is the language of prompts and notifications, such as de or
+	// pt_BR; empty follows LANGUAGE, LC_ALL, LC_MESSAGES and LANG
+	Locale string `json:"locale,omitempty"`
+
+	// LocaleDir holds message catalogs that add to or override the
+	// built-in translations, named after their locale, e.g. de.json
+	LocaleDir string `json:"locale_dir,omitempty"`
+
+	// ConfigFile is the file the configuration was loaded from
+	ConfigFile string `json:"-" mapstructure:"-"`
 }
 
 // AuthConfig contains authentication-related configuration
@@ -38,9 +62,18 @@ type AuthConfig struct {
 	// GuiType specifies the type of GUI to use for authentication dialogs
 	GuiType string `json:"gui_type"`
 
-	// HashAlgorithm specifies the password hashing algorithm
+	// HashAlgorithm specifies the password hashing algorithm. New hashes
+	// are always argon2id; hashes stored with another algorithm still
+	// verify and are migrated on the next successful unlock.
 	HashAlgorithm string `json:"hash_algorithm"`
 
+	// Argon2Memory (KiB), Argon2Iterations and Argon2Parallelism are the
+	// argon2id costs; 0 uses 65536, 3 and 2. Hashes with other costs are
+	// rehashed on the next successful unlock.
+	Argon2Memory      int `json:"argon2_memory,omitempty"`
+	Argon2Iterations  int `json:"argon2_iterations,omitempty"`
+	Argon2Parallelism int `json:"argon2_parallelism,omitempty"`
+
 	// Salt is used for password hashing
 	Salt string `json:"salt"`
 
@@ -50,6 +83,177 @@ type AuthConfig struct {
 	// LockoutDuration is the duration of lockout after max attempts in seconds
 	LockoutDuration int `json:"lockout_duration"`
 
+	// BackoffBase is how many seconds a further attempt is refused after
+	// the first failed one, doubling with each failure up to BackoffMax
+	// seconds; 0 allows attempts right away until the lockout
+	BackoffBase int `json:"backoff_base,omitempty"`
+	BackoffMax  int `json:"backoff_max,omitempty"`
+
+	// LockoutScope is what failed attempts are counted for: app (default)
+	// counts each app, or each group of apps sharing a secret, separately,
+	// global counts them together so guesses cannot be spread over apps
+	LockoutScope string `json:"lockout_scope,omitempty"`
+
+	// LockoutStateFile keeps failed attempts and lockouts across daemon
+	// restarts; empty keeps them in memory only
+	LockoutStateFile string `json:"lockout_state_file,omitempty"`
+
+	// DialogTimeout is how many seconds an authentication dialog stays open
+	// before it is treated as cancelled; 0 uses the default of 60. Dialogs
+	// for concurrent launches are queued and shown one at a time.
+	DialogTimeout int `json:"dialog_timeout,omitempty"`
+
+	// DialogTitle is the title of authentication dialogs; {app} and
+	// {path} are replaced with the application's name and executable
+	DialogTitle string `json:"dialog_title,omitempty"`
+
+	// DialogMessage is an optional notice shown with the prompt, such as
+	// an acceptable-use warning; it takes the same placeholders
+	DialogMessage string `json:"dialog_message,omitempty"`
+
+	// DialogLogo is an image, such as an organization's logo, shown in
+	// dialogs in place of the padlock
+	DialogLogo string `json:"dialog_logo,omitempty"`
+
+	// DialogPrimaryColor and DialogBackgroundColor replace the accent and
+	// background colors of the dialog theme, as #rrggbb
+	DialogPrimaryColor    string `json:"dialog_primary_color,omitempty"`
+	DialogBackgroundColor string `json:"dialog_background_color,omitempty"`
+
+	// GracePeriod is how many minutes after a successful unlock the same
+	// executable (by hash) may be launched again without prompting; 0
+	// prompts for every launch
+	GracePeriod int `json:"grace_period,omitempty"`
+
+	// SessionTokens stores a token in the user's kernel session keyring
+	// after an unlock, accepted for launches within the grace period even
+	// after the client or daemon restarts; tokens do not survive a reboot
+	SessionTokens bool `json:"session_tokens,omitempty"`
+
+	// TokenKeyFile holds the key session tokens are signed with; keep it
+	// on tmpfs so a reboot revokes every token
+	TokenKeyFile string `json:"token_key_file,omitempty"`
+
+	// Mode selects who authenticates unlocks: password (default) prompts
+	// in the wyrmlock client, polkit asks polkit for the org.applock.unlock
+	// action so the desktop's polkit agent prompts and polkit rules apply
+	Mode string `json:"mode,omitempty"`
+
+	// FIDO2 unlocks with a registered FIDO2 security key: primary replaces
+	// the password with a touch of the key, second-factor requires both,
+	// off (default) does not use keys
+	FIDO2 string `json:"fido2,omitempty"`
+
+	// FIDO2CredentialFile holds the keys registered with "wyrmlock fido2
+	// register"
+	FIDO2CredentialFile string `json:"fido2_credential_file,omitempty"`
+
+	// FIDO2Device is the hidraw device of the key; empty uses the first
+	// key connected
+	FIDO2Device string `json:"fido2_device,omitempty"`
+
+	// Fingerprint unlocks with a finger enrolled in fprintd, prompting for
+	// the password when no reader is available or the finger is rejected
+	Fingerprint bool `json:"fingerprint,omitempty"`
+
+	// Backend selects where the password is checked against: secret
+	// (default) uses the secret file or keychain, yubikey binds it to a
+	// YubiKey's HMAC-SHA1 challenge-response slot so the unlock secret is
+	// never stored
+	Backend string `json:"backend,omitempty"`
+
+	// YubiKeySlot is the challenge-response slot (1 or 2) of the yubikey
+	// backend
+	YubiKeySlot int `json:"yubikey_slot,omitempty"`
+
+	// YubiKeyFile holds the challenge and password hash of the yubikey
+	// backend, written by "wyrmlock yubikey setup"
+	YubiKeyFile string `json:"yubikey_file,omitempty"`
+
+	// AppSecretDir holds the per-app secrets named by BlockedApp.Secret,
+	// one file per name
+	AppSecretDir string `json:"app_secret_dir,omitempty"`
+
+	// UserSecretDir holds per-user secrets, one file per user name. The
+	// daemon checks passwords for launches by a user with a file there
+	// against it instead of the global secret; app secrets still win.
+	UserSecretDir string `json:"user_secret_dir,omitempty"`
+
+	// DesktopKeyring checks the password for a launch against the secret
+	// the launching user keeps in their desktop keyring (GNOME Keyring,
+	// KWallet) via the secret service, set with "wyrmlock desktop-keyring
+	// set"; it is only readable while their login keyring is unlocked.
+	// Per-app and per-user secret files still win, and users without one
+	// use the global secret.
+	DesktopKeyring bool `json:"desktop_keyring,omitempty"`
+
+	// DesktopKeyringPinFile holds the hash of the keyring secret an
+	// administrator pinned for each user with "wyrmlock desktop-keyring
+	// pin"; keyring secrets that do not match their pin are ignored, so
+	// users cannot replace the secret they are checked against
+	DesktopKeyringPinFile string `json:"desktop_keyring_pin_file,omitempty"`
+
+	// RecoveryCodeFile holds the hashes of the single-use recovery codes
+	// generated with "wyrmlock recovery generate", which unlock when the
+	// password is forgotten or the security key is lost
+	RecoveryCodeFile string `json:"recovery_code_file,omitempty"`
+
+	// OverrideCodeFile holds the hash of the emergency override code set
+	// with "wyrmlock override set". Entered in an unlock dialog, the code
+	// unlocks every app for OverrideDuration minutes; every use is written
+	// to OverrideJournal and announced to all clients.
+	OverrideCodeFile string `json:"override_code_file,omitempty"`
+
+	// OverrideDuration is how many minutes an emergency override lasts; 0
+	// disables the override
+	OverrideDuration int `json:"override_duration,omitempty"`
+
+	// OverrideJournal is the tamper-evident, hash-chained record of
+	// emergency overrides. An override is refused when it cannot be
+	// recorded.
+	OverrideJournal string `json:"override_journal,omitempty"`
+
+	// BluetoothDevice is the address of a paired Bluetooth device, such as
+	// the owner's phone, whose presence unlocks launches without a prompt;
+	// when it is out of range the password is asked for. Empty disables
+	// proximity unlocks.
+	BluetoothDevice string `json:"bluetooth_device,omitempty"`
+
+	// BluetoothTimeout is how many seconds the device is given to answer
+	BluetoothTimeout int `json:"bluetooth_timeout,omitempty"`
+
+	// AuthCommand is the program, and its arguments, that decides unlocks
+	// in the command mode. It is given the launch as JSON on stdin and in
+	// WYRMLOCK_* environment variables; exit status 0 allows, 1 denies and
+	// anything else falls back to the password prompt.
+	AuthCommand []string `json:"auth_command,omitempty"`
+
+	// AuthCommandTimeout is how many seconds the auth command may take
+	// before the launch is denied
+	AuthCommandTimeout int `json:"auth_command_timeout,omitempty"`
+
+	// ApprovalAdmins are the users whose agents are asked to approve
+	// unlocks in the approval mode
+	ApprovalAdmins []string `json:"approval_admins,omitempty"`
+
+	// ApprovalTimeout is how many seconds a launch stays suspended waiting
+	// for an administrator before it is denied
+	ApprovalTimeout int `json:"approval_timeout,omitempty"`
+
+	// MinSecretLength is the fewest characters a new secret may have; 0
+	// does not check the length
+	MinSecretLength int `json:"min_secret_length,omitempty"`
+
+	// MinSecretEntropy is the fewest bits of estimated entropy a new
+	// secret must have. The estimate discounts repeats, sequences, keyboard
+	// runs and common passwords; 0 does not check it.
+	MinSecretEntropy int `json:"min_secret_entropy,omitempty"`
+
+	// ParentSecretFile holds the parent password, set with "wyrmlock
+	// profile parent-secret". It is the only password that unlocks
+	// launches by the child accounts of a profile.
+	ParentSecretFile string `json:"parent_secret_file,omitempty"`
+
 	// UseZeroKnowledgeProof enables zero-knowledge proof authentication
 	UseZeroKnowledgeProof bool `json:"use_zero_knowledge_proof"`
 
@@ -62,16 +266,571 @@ type MonitorConfig struct {
 	// ScanInterval is the interval between process scans in seconds
 	ScanInterval int `json:"scan_interval"`
 
-	// ProtectedApps is a list of applications that require authentication
+	// ProtectedApps is a list of applications that require authentication.
+	// Entries are executable paths, "sha256:<hex>" to match any binary
+	// with that content regardless of its path, or "flatpak:<app-id>" /
+	// "snap:<name>" to match sandboxed applications. A directory (ending in
+	// "/") protects every executable beneath it.
 	ProtectedApps []string `json:"protected_apps"`
 
 	// VerifyHashes enables verification of executable hashes
 	VerifyHashes bool `json:"verify_hashes"`
 
-	// HashAlgorithm specifies which hash algorithm to use for verification
+	// HashAlgorithm is the algorithm new executable hashes are stored with:
+	// sha256, sha512 or blake3 (fastest on large binaries). Stored hashes
+	// keep the algorithm they were made with.
 	HashAlgorithm string `json:"hash_algorithm"`
+
+	// EnvCheck controls inspection of loader environment variables such as
+	// LD_PRELOAD before resuming an authenticated process: off, warn or strict
+	EnvCheck string `json:"env_check,omitempty"`
+
+	// RegexRules match executables by regular expression, evaluated in order
+	// before the exact-path ProtectedApps list
+	RegexRules []RegexRule `json:"regex_rules,omitempty"`
+
+	// StartupAction is applied to protected apps already running when the
+	// monitor starts: register (default) tracks them as unlocked, suspend
+	// stops them until the app is next unlocked, prompt locks them like a
+	// new launch
+	StartupAction string `json:"startup_action,omitempty"`
+
+	// ReplacedExecAction is applied to launches whose executable was deleted
+	// or replaced on disk: warn (default) reports them, lock prompts for
+	// them like a protected app, deny terminates them
+	ReplacedExecAction string `json:"replaced_exec_action,omitempty"`
+
+	// AnonymousExecAction is applied to launches with no file on disk, such
+	// as memfd executables, which path rules cannot match: warn (default)
+	// reports them, prompt locks them with a warning in the dialog, deny
+	// terminates them
+	AnonymousExecAction string `json:"anonymous_exec_action,omitempty"`
+
+	// RemovableMediaAction is applied to programs launched from removable
+	// media such as USB drives: off (default), warn reports them, deny
+	// terminates them
+	RemovableMediaAction string `json:"removable_media_action,omitempty"`
+
+	// ContainerAction is applied to launches inside containers (another
+	// mount namespace): enforce (default) applies the rules to the paths
+	// seen inside the container, ignore leaves containers alone
+	ContainerAction string `json:"container_action,omitempty"`
+
+	// PackageVerifyAction checks protected executables against the dpkg or
+	// rpm database in addition to stored hashes: off (default), warn shows
+	// a mismatch in the dialog, deny terminates the launch
+	PackageVerifyAction string `json:"package_verify_action,omitempty"`
+
+	// IMAMeasurements selects where executable hashes come from: auto
+	// (default) uses the kernel IMA measurement of a binary when one is
+	// available and hashes it in userspace otherwise, off always hashes
+	IMAMeasurements string `json:"ima_measurements,omitempty"`
+
+	// UntrustedDirAction is applied to programs launched from UntrustedDirs
+	// or any world-writable directory: off (default), prompt locks them
+	// with a warning in the dialog, deny terminates them
+	UntrustedDirAction string `json:"untrusted_dir_action,omitempty"`
+
+	// UntrustedDirs lists directories programs should not run from; "~/"
+	// is the home of the launching user
+	UntrustedDirs []string `json:"untrusted_dirs,omitempty"`
+
+	// SuspendMethod selects how locked processes are stopped: auto (default)
+	// uses the cgroup v2 freezer when available, freezer requires it, signal
+	// always uses SIGSTOP
+	SuspendMethod string `json:"suspend_method,omitempty"`
+
+	// TerminateTimeout is how many seconds a terminated process has to exit
+	// after SIGTERM before it is killed with SIGKILL (default 5)
+	TerminateTimeout int `json:"terminate_timeout,omitempty"`
+
+	// SuspendTimeout is how many seconds a launch may wait suspended for
+	// authentication before SuspendTimeoutAction is applied; 0 waits forever
+	SuspendTimeout int `json:"suspend_timeout,omitempty"`
+
+	// SuspendTimeoutAction is applied to launches not unlocked within
+	// SuspendTimeout: terminate (default) kills them, keep leaves them
+	// suspended until the app is next unlocked
+	SuspendTimeoutAction string `json:"suspend_timeout_action,omitempty"`
+
+	// SessionLockAction is applied when logind reports the desktop session
+	// locked or switched to another user: revoke (default) ends grace
+	// periods, relock also suspends running protected apps until they are
+	// unlocked again, off ignores the session
+	SessionLockAction string `json:"session_lock_action,omitempty"`
+
+	// IdleRelock is how many minutes a session may stay idle, as reported
+	// by logind's IdleHint, before grace periods end and running protected
+	// apps are re-locked; 0 disables idle re-locking
+	IdleRelock int `json:"idle_relock,omitempty"`
+
+	// TrustedParents lists launcher executables, such as a backup agent,
+	// whose launches of protected apps are not prompted for. Every ancestor
+	// of the launch is checked, not only its direct parent.
+	TrustedParents []string `json:"trusted_parents,omitempty"`
+
+	// TrustedSigners may sign the executables of apps that require a
+	// signature: "gpg:<fingerprint>" keys are checked with gpgv against
+	// SignatureKeyring, "x509:<certificate.pem>" certificates verify raw
+	// signatures made with their key
+	TrustedSigners []string `json:"trusted_signers,omitempty"`
+
+	// SignatureKeyring is the GPG keyring holding the trusted signers' keys
+	SignatureKeyring string `json:"signature_keyring,omitempty"`
+
+	// ShutdownAction is applied to running protected apps when the daemon
+	// stops: none (default) leaves them running, suspend stops them and
+	// terminate kills them, so protection fails closed
+	ShutdownAction string `json:"shutdown_action,omitempty"`
+
+	// StateFile records daemon starts and stops so a protection gap left by
+	// a stop or crash can be reported on the next start
+	StateFile string `json:"state_file,omitempty"`
+
+	// QuotaFile persists per-day launch quota counters
+	QuotaFile string `json:"quota_file,omitempty"`
+
+	// UsageFile persists per-day screen-time usage
+	UsageFile string `json:"usage_file,omitempty"`
+
+	// PinFile persists the approved hashes of apps with PinHash
+	PinFile string `json:"pin_file,omitempty"`
+
+	// SealFile holds the hashes of the daemon binary, the privileged helper
+	// and the configuration sealed with "wyrmlock seal"
+	SealFile string `json:"seal_file,omitempty"`
+
+	// IntegrityAction is applied at daemon startup when a sealed file has
+	// changed: warn (default) logs it loudly, refuse stops the daemon from
+	// starting, off skips the check
+	IntegrityAction string `json:"integrity_action,omitempty"`
+
+	// Seccomp restricts the daemon to the system calls it needs once it
+	// has started: enforce (default) refuses the others, log only records
+	// them in the audit log, off installs no filter
+	Seccomp string `json:"seccomp,omitempty"`
+
+	// Landlock confines the daemon's filesystem writes to /proc, cgroups,
+	// its socket, state and log paths: auto (default) when the kernel
+	// supports Landlock, off never
+	Landlock string `json:"landlock,omitempty"`
+
+	// LedgerFile records launches suspended awaiting authentication, so a
+	// daemon restarted after a crash locks them again instead of leaving
+	// them frozen
+	LedgerFile string `json:"ledger_file,omitempty"`
+
+	// SequenceFile persists the sequence number stamped on broadcast events
+	SequenceFile string `json:"sequence_file,omitempty"`
+
+	// Backend selects how launches are detected: auto (default) uses the
+	// proc connector when available and otherwise polls /proc;
+	// proc-connector or poll force one
+	Backend string `json:"backend,omitempty"`
+
+	// PollInterval is how often the polling backend scans /proc, in
+	// milliseconds. Launches run unsuspended for up to this long.
+	PollInterval int `json:"poll_interval,omitempty"`
+
+	// HashConcurrency is how many executables are hashed at once, so a
+	// burst of launches does not saturate disk IO. Suspended processes are
+	// hashed before others waiting.
+	HashConcurrency int `json:"hash_concurrency,omitempty"`
+
+	// ExecWorkers is how many exec events are evaluated concurrently
+	ExecWorkers int `json:"exec_workers,omitempty"`
+
+	// ExecQueueSize bounds the exec events waiting for a worker. Events
+	// beyond it are dropped and the processes found by a rescan once the
+	// queue drains, so a fork bomb cannot exhaust memory.
+	ExecQueueSize int `json:"exec_queue_size,omitempty"`
+
+	// UsageWarning is how many minutes before a daily time budget runs out
+	// the user is warned
+	UsageWarning int `json:"usage_warning,omitempty"`
+
+	// DefaultAction applies to protected launches of apps without their own
+	// action: lock (default) prompts for authentication, deny terminates
+	// the process without any dialog, e.g. for kiosks
+	DefaultAction string `json:"default_action,omitempty"`
+
+	// AllowlistMode enables deny-by-default operation: executables that are
+	// not allowlisted and match no rule are locked (lock) or terminated
+	// (deny). off (default) protects only the configured apps.
+	AllowlistMode string `json:"allowlist_mode,omitempty"`
+
+	// Allowlist holds the executables that may run in allowlist mode, as
+	// paths, directories (trailing "/") or "sha256:<hex>" hash entries.
+	// "wyrmlock learn suggest" proposes one from a recording.
+	Allowlist []string `json:"allowlist,omitempty"`
+
+	// AllowlistSystemDirs are always allowed in allowlist mode so helpers
+	// and services keep working. Processes run by root are exempt as well.
+	AllowlistSystemDirs []string `json:"allowlist_system_dirs,omitempty"`
+
+	// AuditOnly logs and broadcasts protected launches without suspending,
+	// prompting or terminating anything, for rollout and reporting before
+	// enforcement
+	AuditOnly bool `json:"audit_only,omitempty"`
+
+	// RedactArgs lists executables (full paths or names) whose arguments
+	// are replaced by a placeholder in events and audit records, e.g.
+	// browsers whose command lines carry URLs
+	RedactArgs []string `json:"redact_args,omitempty"`
+}
+
+// Allowlist modes
+const (
+	// AllowlistModeOff protects only the configured apps
+	AllowlistModeOff = "off"
+
+	// AllowlistModeLock requires authentication for unlisted executables
+	AllowlistModeLock = RuleActionLock
+
+	// AllowlistModeDeny terminates unlisted executables
+	AllowlistModeDeny = RuleActionDeny
+)
+
+// DefaultAllowlistSystemDirs hold libraries, helpers and system daemons that
+// users do not launch directly
+var DefaultAllowlistSystemDirs = []string{"/usr/lib/", "/usr/lib64/", "/usr/libexec/", "/lib/", "/lib64/", "/usr/sbin/", "/sbin/"}
+
+// AllowlistEnabled reports whether deny-by-default mode is on
+func (m MonitorConfig) AllowlistEnabled() bool {
+	return m.AllowlistMode == AllowlistModeLock || m.AllowlistMode == AllowlistModeDeny
+}
+
+// Process event backends
+const (
+	// BackendAuto picks the best backend the kernel supports
+	BackendAuto = "auto"
+
+	// BackendProcConnector receives events from the netlink proc connector
+	BackendProcConnector = "proc-connector"
+
+	// BackendPoll scans /proc at an interval
+	BackendPoll = "poll"
+)
+
+// Startup actions for protected apps already running
+const (
+	// StartupActionRegister tracks running protected apps as unlocked
+	StartupActionRegister = "register"
+
+	// StartupActionSuspend stops running protected apps without a dialog
+	// until the app is next unlocked
+	StartupActionSuspend = "suspend"
+
+	// StartupActionPrompt locks running protected apps like new launches
+	StartupActionPrompt = "prompt"
+)
+
+// Actions for launches of deleted or replaced executables
+const (
+	// ReplacedExecActionWarn reports the launch and handles it normally
+	ReplacedExecActionWarn = "warn"
+
+	// ReplacedExecActionLock locks the launch like a protected app
+	ReplacedExecActionLock = "lock"
+
+	// ReplacedExecActionDeny terminates the launch
+	ReplacedExecActionDeny = "deny"
+)
+
+// Actions for launches with no executable on disk
+const (
+	// AnonymousExecActionWarn reports the launch and handles it normally
+	AnonymousExecActionWarn = "warn"
+
+	// AnonymousExecActionPrompt locks the launch and warns in the dialog
+	AnonymousExecActionPrompt = "prompt"
+
+	// AnonymousExecActionDeny terminates the launch
+	AnonymousExecActionDeny = "deny"
+)
+
+// Actions for launches from removable media
+const (
+	// RemovableMediaActionOff ignores where executables live
+	RemovableMediaActionOff = "off"
+
+	// RemovableMediaActionWarn reports the launch and handles it normally
+	RemovableMediaActionWarn = "warn"
+
+	// RemovableMediaActionDeny terminates the launch
+	RemovableMediaActionDeny = "deny"
+)
+
+// Actions for launches inside containers
+const (
+	// ContainerActionEnforce applies the rules inside containers too
+	ContainerActionEnforce = "enforce"
+
+	// ContainerActionIgnore leaves launches inside containers alone
+	ContainerActionIgnore = "ignore"
+)
+
+// Actions for protected executables that differ from their package
+const (
+	// PackageVerifyActionOff skips package verification
+	PackageVerifyActionOff = "off"
+
+	// PackageVerifyActionWarn reports the mismatch and prompts with a warning
+	PackageVerifyActionWarn = "warn"
+
+	// PackageVerifyActionDeny terminates the launch
+	PackageVerifyActionDeny = "deny"
+)
+
+// Sources of executable hashes
+const (
+	// IMAMeasurementsAuto uses IMA measurements when the kernel provides them
+	IMAMeasurementsAuto = "auto"
+
+	// IMAMeasurementsOff always hashes executables in userspace
+	IMAMeasurementsOff = "off"
+)
+
+// Actions for a daemon whose own files no longer match their seal
+const (
+	// IntegrityActionOff skips the self-integrity check
+	IntegrityActionOff = "off"
+
+	// IntegrityActionWarn logs mismatches and starts anyway
+	IntegrityActionWarn = "warn"
+
+	// IntegrityActionRefuse refuses to start
+	IntegrityActionRefuse = "refuse"
+)
+
+// Modes of the daemon's seccomp filter
+const (
+	// SeccompOff installs no filter
+	SeccompOff = "off"
+
+	// SeccompLog allows every call but logs those outside the allow list
+	SeccompLog = "log"
+
+	// SeccompEnforce fails calls outside the allow list with EPERM
+	SeccompEnforce = "enforce"
+)
+
+// Modes of the daemon's Landlock confinement
+const (
+	// LandlockAuto confines the daemon when the kernel supports Landlock
+	LandlockAuto = "auto"
+
+	// LandlockOff leaves filesystem access unrestricted
+	LandlockOff = "off"
+)
+
+// Modes of authenticating unlocks
+const (
+	// AuthModePassword prompts for the wyrmlock password in the client
+	AuthModePassword = "password"
+
+	// AuthModePolkit delegates the decision to polkit
+	AuthModePolkit = "polkit"
+
+	// AuthModeCommand delegates the decision to the AuthCommand program
+	AuthModeCommand = "command"
+
+	// AuthModeApproval asks an administrator to approve each unlock
+	AuthModeApproval = "approval"
+)
+
+// Scopes of counting failed attempts
+const (
+	// LockoutScopeApp counts failed attempts per app
+	LockoutScopeApp = "app"
+
+	// LockoutScopeGlobal counts failed attempts across all apps
+	LockoutScopeGlobal = "global"
+)
+
+// Uses of a FIDO2 security key
+const (
+	// FIDO2Off does not use security keys
+	FIDO2Off = "off"
+
+	// FIDO2Primary unlocks with a touch of the key instead of the password
+	FIDO2Primary = "primary"
+
+	// FIDO2SecondFactor requires a touch of the key after the password
+	FIDO2SecondFactor = "second-factor"
+)
+
+// Backends checking the password
+const (
+	// AuthBackendSecret checks against the secret file or keychain
+	AuthBackendSecret = "secret"
+
+	// AuthBackendYubiKey checks with a YubiKey challenge-response slot
+	AuthBackendYubiKey = "yubikey"
+)
+
+// Actions for launches from untrusted directories
+const (
+	// UntrustedDirActionOff ignores where executables live
+	UntrustedDirActionOff = "off"
+
+	// UntrustedDirActionPrompt locks the launch and warns in the dialog
+	UntrustedDirActionPrompt = "prompt"
+
+	// UntrustedDirActionDeny terminates the launch
+	UntrustedDirActionDeny = "deny"
+)
+
+// Trusted signer entry prefixes
+const (
+	SignerPrefixGPG  = "gpg:"
+	SignerPrefixX509 = "x509:"
+)
+
+// DefaultSignatureKeyring holds the GPG keys of trusted signers
+const DefaultSignatureKeyring = "/etc/wyrmlock/trustedkeys.gpg"
+
+// DefaultUntrustedDirs are the directories downloaded and dropped programs
+// usually run from
+var DefaultUntrustedDirs = []string{"/tmp", "/var/tmp", "/dev/shm", "~/Downloads"}
+
+// Ways to suspend locked processes
+const (
+	// SuspendMethodAuto uses the cgroup freezer when the kernel supports it
+	SuspendMethodAuto = "auto"
+
+	// SuspendMethodFreezer requires the cgroup v2 freezer
+	SuspendMethodFreezer = "freezer"
+
+	// SuspendMethodSignal always uses SIGSTOP and SIGCONT
+	SuspendMethodSignal = "signal"
+)
+
+// Actions for launches not unlocked within the suspend timeout
+const (
+	// SuspendTimeoutActionTerminate kills the launch
+	SuspendTimeoutActionTerminate = "terminate"
+
+	// SuspendTimeoutActionKeep keeps the launch suspended until the app is
+	// next unlocked
+	SuspendTimeoutActionKeep = "keep"
+)
+
+// Actions on a desktop session lock or user switch
+const (
+	// SessionLockActionOff ignores the session
+	SessionLockActionOff = "off"
+
+	// SessionLockActionRevoke ends grace periods
+	SessionLockActionRevoke = "revoke"
+
+	// SessionLockActionRelock ends grace periods and re-locks running
+	// protected apps
+	SessionLockActionRelock = "relock"
+)
+
+// Daemon shutdown actions
+const (
+	// ShutdownActionNone leaves protected apps running when the daemon stops
+	ShutdownActionNone = "none"
+
+	// ShutdownActionSuspend stops protected apps when the daemon stops
+	ShutdownActionSuspend = "suspend"
+
+	// ShutdownActionTerminate terminates protected apps when the daemon stops
+	ShutdownActionTerminate = "terminate"
+)
+
+// RegexRule matches executables by regular expression against the executable
+// path and/or the process command line. When both patterns are set, both must match.
+type RegexRule struct {
+	// Name identifies the rule in logs
+	Name string `json:"name,omitempty"`
+
+	// PathPattern is matched against the resolved executable path
+	PathPattern string `json:"path_pattern,omitempty"`
+
+	// CmdlinePattern is matched against /proc/<pid>/cmdline (arguments joined by spaces)
+	CmdlinePattern string `json:"cmdline_pattern,omitempty"`
+
+	// Action is taken when the rule matches: lock (default), deny or allow
+	Action string `json:"action,omitempty"`
+
+	// Users and Groups restrict the rule to processes launched by these
+	// users or members of these groups (names or numeric IDs)
+	Users  []string `json:"users,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// Rule actions
+const (
+	// RuleActionLock requires authentication before the process may continue
+	RuleActionLock = "lock"
+
+	// RuleActionDeny terminates the process without prompting
+	RuleActionDeny = "deny"
+
+	// RuleActionAllow exempts the process from all further checks
+	RuleActionAllow = "allow"
+
+	// RuleActionLog lets a protected app run without prompting and records
+	// each launch in the security log
+	RuleActionLog = "log"
+)
+
+// AuthorizationConfig configures an external HTTP(S) authorization service
+// consulted by the daemon for each blocked launch
+type AuthorizationConfig struct {
+	// Enabled turns on delegation to the authorization service
+	Enabled bool `json:"enabled"`
+
+	// URL is the endpoint receiving a JSON POST per blocked launch
+	URL string `json:"url"`
+
+	// Timeout is the request timeout in seconds
+	Timeout int `json:"timeout"`
+
+	// FallbackAction is used when the service is unreachable, times out or
+	// returns an invalid answer: prompt, deny or allow
+	FallbackAction string `json:"fallback_action"`
+
+	// TokenFile optionally holds a bearer token sent with each request
+	TokenFile string `json:"token_file,omitempty"`
+
+	// CACertPath optionally pins the CA bundle used to verify the service
+	CACertPath string `json:"ca_cert_path,omitempty"`
+}
+
+// NotificationConfig controls client-side notification aggregation
+type NotificationConfig struct {
+	// Window is how long in seconds events of one class are collected
+	// into a single summarized notification
+	Window int `json:"window"`
+
+	// RateLimits caps the notifications shown per minute for each event
+	// class (denied, lockout, usage). 0 mutes the class; classes not listed use
+	// DefaultNotificationRateLimit.
+	RateLimits map[string]int `json:"rate_limits,omitempty"`
 }
 
+// Notification event classes
+const (
+	NotifyClassDenied  = "denied"
+	NotifyClassLockout = "lockout"
+	NotifyClassUsage   = "usage"
+)
+
+// DefaultNotificationRateLimit is the per-minute limit for classes without
+// a configured rate limit
+const DefaultNotificationRateLimit = 4
+
+// Authorization decisions and fallback actions
+const (
+	AuthzPrompt = "prompt"
+	AuthzDeny   = "deny"
+	AuthzAllow  = "allow"
+)
+
 // BlockedApp represents an application that requires authentication
 type BlockedApp struct {
 	// Path is the path to the executable
@@ -86,8 +845,194 @@ type BlockedApp struct {
 	// EnforceFileHash enables executable hash verification
 	EnforceFileHash bool `json:"enforce_file_hash,omitempty"`
 
-	// FileHash is the SHA-256 hash of the executable
+	// FileHash is the hash of the executable
 	FileHash string `json:"file_hash,omitempty"`
+
+	// FileHashAlgorithm is the algorithm FileHash was computed with; empty
+	// means sha256
+	FileHashAlgorithm string `json:"file_hash_algorithm,omitempty"`
+
+	// PinHash pins the app's SHA-256 (FileHash until a version is
+	// approved): a launch of a different binary asks the user to approve
+	// the new version, which is then pinned. Without FileHash the first
+	// approved launch is pinned.
+	PinHash bool `json:"pin_hash,omitempty"`
+
+	// StrictEnv refuses to resume this app when it was started with a
+	// dangerous loader environment, regardless of Monitor.EnvCheck
+	StrictEnv bool `json:"strict_env,omitempty"`
+
+	// RequireSignature refuses to resume this app unless a detached
+	// signature by one of Monitor.TrustedSigners verifies
+	RequireSignature bool `json:"require_signature,omitempty"`
+
+	// SignaturePath is the detached signature; empty uses Path + ".sig"
+	SignaturePath string `json:"signature_path,omitempty"`
+
+	// MaxInstances limits how many unlocked instances may run at once;
+	// excess launches are denied without prompting. 0 means unlimited.
+	MaxInstances int `json:"max_instances,omitempty"`
+
+	// MatchByHash also protects any executable whose SHA-256 equals FileHash,
+	// so renamed or copied binaries are still caught
+	MatchByHash bool `json:"match_by_hash,omitempty"`
+
+	// Users and Groups lock the app only when launched by these users or
+	// members of these groups (names or numeric IDs). Empty means everyone.
+	Users  []string `json:"users,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+
+	// Schedule lists windows during which launches are denied
+	Schedule []ScheduleBlock `json:"schedule,omitempty"`
+
+	// OutsideSchedule is the action outside the schedule windows: allow
+	// (default), prompt or deny
+	OutsideSchedule string `json:"outside_schedule,omitempty"`
+
+	// DailyLaunches lets the app be launched this many times per day
+	// without authentication before the lock engages. 0 disables the quota.
+	DailyLaunches int `json:"daily_launches,omitempty"`
+
+	// DailyMinutes is the app's daily screen-time budget. Once running
+	// instances have used it up they are handled by LimitAction and new
+	// launches are denied until midnight. 0 disables the budget.
+	DailyMinutes int `json:"daily_minutes,omitempty"`
+
+	// LimitAction is applied to running instances when the budget is used
+	// up: suspend (default, resumed the next day) or terminate
+	LimitAction string `json:"limit_action,omitempty"`
+
+	// Action overrides Monitor.DefaultAction for this app: lock prompts,
+	// deny terminates without a dialog, log allows the launch and records it
+	// in the security log
+	Action string `json:"action,omitempty"`
+
+	// MaxAttempts overrides the number of failed authentication attempts
+	// before this app is locked out; 0 uses the global limit
+	MaxAttempts int `json:"max_attempts,omitempty"`
+
+	// Secret names the password that unlocks this app instead of the global
+	// one, set with "wyrmlock app secret set". Apps naming the same secret
+	// form a group sharing the password and its failed attempt counter.
+	Secret string `json:"secret,omitempty"`
+
+	// GracePeriod overrides Auth.GracePeriod for this app, in minutes; 0 uses
+	// the global grace period
+	GracePeriod int `json:"grace_period,omitempty"`
+
+	// TrustedParents adds launchers to Monitor.TrustedParents for this app
+	TrustedParents []string `json:"trusted_parents,omitempty"`
+
+	// WorkingDirs lock the app only when it is started in one of these
+	// directories or below them. Empty means anywhere.
+	WorkingDirs []string `json:"working_dirs,omitempty"`
+
+	// Env locks the app only when every entry matches the environment it was
+	// started with: "NAME=value" requires that value, a bare "NAME" only
+	// requires the variable to be set. Empty means any environment.
+	Env []string `json:"env,omitempty"`
+
+	// AuditOnly monitors this app without enforcing, as Monitor.AuditOnly
+	// does for every app
+	AuditOnly bool `json:"audit_only,omitempty"`
+}
+
+// Screen-time limit actions
+const (
+	// LimitActionSuspend stops running instances until the next day
+	LimitActionSuspend = "suspend"
+
+	// LimitActionTerminate kills running instances
+	LimitActionTerminate = "terminate"
+)
+
+// HashEntryPrefix marks a ProtectedApps entry that matches by SHA-256 hash
+const HashEntryPrefix = "sha256:"
+
+// ParseHashEntry returns the normalised hash of a "sha256:<hex>" entry
+func ParseHashEntry(entry string) (string, bool) {
+	if !strings.HasPrefix(entry, HashEntryPrefix) {
+		return "", false
+	}
+	return strings.ToLower(strings.TrimPrefix(entry, HashEntryPrefix)), true
+}
+
+// bluetoothAddressPattern matches a Bluetooth address such as
+// AA:BB:CC:DD:EE:FF
+var bluetoothAddressPattern = regexp.MustCompile(`^[0-9A-Fa-f]{2}(:[0-9A-Fa-f]{2}){5}$`)
+
+// localePattern matches a locale such as de, pt_BR or de_DE.UTF-8
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(_[A-Z]{2})?(\.[A-Za-z0-9-]+)?(@[a-z]+)?$`)
+
+// colorPattern matches a color such as #1976d2 or #fff
+var colorPattern = regexp.MustCompile(`^#([0-9A-Fa-f]{3}|[0-9A-Fa-f]{6})$`)
+
+// IsValidBluetoothAddress reports whether addr is a Bluetooth device address
+func IsValidBluetoothAddress(addr string) bool {
+	return bluetoothAddressPattern.MatchString(addr)
+}
+
+// IsValidSecretName reports whether name can name a per-app secret: letters,
+// digits, '.', '_' and '-', not starting with '.'
+func IsValidSecretName(name string) bool {
+	if name == "" || name[0] == '.' {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// IsFileHashAlgorithm reports whether name is a supported executable hash
+// algorithm
+func IsFileHashAlgorithm(name string) bool {
+	switch name {
+	case "sha256", "sha512", "blake3":
+		return true
+	}
+	return false
+}
+
+// Sandbox entry prefixes for ProtectedApps
+const (
+	FlatpakEntryPrefix = "flatpak:"
+	SnapEntryPrefix    = "snap:"
+)
+
+// ParseSandboxEntry splits a "flatpak:<app-id>" or "snap:<name>" entry
+func ParseSandboxEntry(entry string) (kind string, appID string, ok bool) {
+	switch {
+	case strings.HasPrefix(entry, FlatpakEntryPrefix):
+		return "flatpak", strings.TrimPrefix(entry, FlatpakEntryPrefix), true
+	case strings.HasPrefix(entry, SnapEntryPrefix):
+		return "snap", strings.TrimPrefix(entry, SnapEntryPrefix), true
+	}
+	return "", "", false
+}
+
+// IsPathEntry reports whether a ProtectedApps entry is a plain executable path
+func IsPathEntry(entry string) bool {
+	if _, ok := ParseHashEntry(entry); ok {
+		return false
+	}
+	if _, _, ok := ParseSandboxEntry(entry); ok {
+		return false
+	}
+	return true
+}
+
+// isSHA256Hex reports whether s is a hex-encoded SHA-256 digest
+func isSHA256Hex(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
 }
 
 // LoadConfig loads the configuration from the specified file
@@ -128,6 +1073,7 @@ func LoadConfig(configPath string) (*Config, error) {
 	if err := v.Unmarshal(&cfg); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %v", err)
 	}
+	cfg.ConfigFile = v.ConfigFileUsed()
 
 	// Validate the configuration
 	if err := validateConfig(&cfg); err != nil {
@@ -137,6 +1083,12 @@ func LoadConfig(configPath string) (*Config, error) {
 	return &cfg, nil
 }
 
+// Validate checks a configuration before it is saved, with the same rules
+// LoadConfig applies
+func Validate(cfg *Config) error {
+	return validateConfig(cfg)
+}
+
 // setConfigDefaults sets default values for the configuration
 func setConfigDefaults(v *viper.Viper) {
 	// Default GUI type
@@ -148,12 +1100,95 @@ func setConfigDefaults(v *viper.Viper) {
 	// Default hash algorithm
 	v.SetDefault("auth.hash_algorithm", "argon2id")
 
+	// Default argon2id costs (64MB, 3 passes, 2 threads)
+	v.SetDefault("auth.argon2_memory", 65536)
+	v.SetDefault("auth.argon2_iterations", 3)
+	v.SetDefault("auth.argon2_parallelism", 2)
+
 	// Default max attempts
 	v.SetDefault("auth.max_attempts", 3)
 
 	// Default lockout duration (5 minutes)
 	v.SetDefault("auth.lockout_duration", 300)
 
+	// Failed attempts back off from 1 second to a minute, per app, and
+	// are kept across restarts
+	v.SetDefault("auth.backoff_base", 1)
+	v.SetDefault("auth.backoff_max", 60)
+	v.SetDefault("auth.lockout_scope", LockoutScopeApp)
+	v.SetDefault("auth.lockout_state_file", "/var/lib/wyrmlock/lockouts.json")
+
+	// Default auth dialog timeout (1 minute)
+	v.SetDefault("auth.dialog_timeout", 60)
+
+	// Default dialog branding: the usual title, no notice, logo or colors
+	v.SetDefault("auth.dialog_title", "Authentication Required - {app}")
+	v.SetDefault("auth.dialog_message", "")
+	v.SetDefault("auth.dialog_logo", "")
+	v.SetDefault("auth.dialog_primary_color", "")
+	v.SetDefault("auth.dialog_background_color", "")
+
+	// No grace period after an unlock by default
+	v.SetDefault("auth.grace_period", 0)
+
+	// Session tokens are off by default and only issued with a grace period
+	v.SetDefault("auth.session_tokens", false)
+	v.SetDefault("auth.token_key_file", "/var/run/wyrmlock-token.key")
+
+	// Prompt for the wyrmlock password by default
+	v.SetDefault("auth.mode", AuthModePassword)
+
+	// No FIDO2 security key by default
+	v.SetDefault("auth.fido2", FIDO2Off)
+	v.SetDefault("auth.fido2_credential_file", "/etc/wyrmlock/fido2.json")
+
+	// No fingerprint unlock by default
+	v.SetDefault("auth.fingerprint", false)
+
+	// Check passwords against the secret file by default
+	v.SetDefault("auth.backend", AuthBackendSecret)
+	v.SetDefault("auth.yubikey_slot", 2)
+	v.SetDefault("auth.yubikey_file", "/etc/wyrmlock/yubikey.json")
+
+	// Default per-app secret directory
+	v.SetDefault("auth.app_secret_dir", "/etc/wyrmlock/app-secrets")
+
+	// Default per-user secret directory
+	v.SetDefault("auth.user_secret_dir", "/etc/wyrmlock/users")
+
+	// Desktop keyrings are not consulted by default
+	v.SetDefault("auth.desktop_keyring", false)
+
+	// Default pins of desktop keyring secrets
+	v.SetDefault("auth.desktop_keyring_pin_file", "/etc/wyrmlock/desktop-keyring-pins.json")
+
+	// Default recovery code file
+	v.SetDefault("auth.recovery_code_file", "/etc/wyrmlock/recovery-codes.json")
+
+	// Emergency overrides last 15 minutes
+	v.SetDefault("auth.override_code_file", "/etc/wyrmlock/override-code")
+	v.SetDefault("auth.override_duration", 15)
+	v.SetDefault("auth.override_journal", "/var/lib/wyrmlock/override.journal")
+
+	// Proximity unlocks are off; a paired device gets 2 seconds to answer
+	v.SetDefault("auth.bluetooth_device", "")
+	v.SetDefault("auth.bluetooth_timeout", 2)
+
+	// No auth command by default; it gets 30 seconds to decide
+	v.SetDefault("auth.auth_command", []string{})
+	v.SetDefault("auth.auth_command_timeout", 30)
+
+	// Administrators have two minutes to approve an unlock
+	v.SetDefault("auth.approval_admins", []string{})
+	v.SetDefault("auth.approval_timeout", 120)
+
+	// Default parent secret for parental-control profiles
+	v.SetDefault("auth.parent_secret_file", "/etc/wyrmlock/parent-secret")
+
+	// New secrets need 8 characters and about 35 bits of entropy
+	v.SetDefault("auth.min_secret_length", 8)
+	v.SetDefault("auth.min_secret_entropy", 35)
+
 	// Default scan interval (1 second)
 	v.SetDefault("monitor.scan_interval", 1)
 	
@@ -163,9 +1198,63 @@ func setConfigDefaults(v *viper.Viper) {
 	// Default hash algorithm for verification
 	v.SetDefault("monitor.hash_algorithm", "sha256")
 
+	// Leave protected apps running on daemon stop unless configured otherwise
+	v.SetDefault("monitor.startup_action", StartupActionRegister)
+	v.SetDefault("monitor.replaced_exec_action", ReplacedExecActionWarn)
+	v.SetDefault("monitor.anonymous_exec_action", AnonymousExecActionWarn)
+	v.SetDefault("monitor.removable_media_action", RemovableMediaActionOff)
+	v.SetDefault("monitor.container_action", ContainerActionEnforce)
+	v.SetDefault("monitor.package_verify_action", PackageVerifyActionOff)
+	v.SetDefault("monitor.ima_measurements", IMAMeasurementsAuto)
+	v.SetDefault("monitor.untrusted_dir_action", UntrustedDirActionOff)
+	v.SetDefault("monitor.untrusted_dirs", DefaultUntrustedDirs)
+	v.SetDefault("monitor.suspend_method", SuspendMethodAuto)
+	v.SetDefault("monitor.terminate_timeout", 5)
+	v.SetDefault("monitor.suspend_timeout", 300)
+	v.SetDefault("monitor.suspend_timeout_action", SuspendTimeoutActionTerminate)
+	v.SetDefault("monitor.session_lock_action", SessionLockActionRevoke)
+	v.SetDefault("monitor.idle_relock", 0)
+	v.SetDefault("monitor.trusted_parents", []string{})
+	v.SetDefault("monitor.trusted_signers", []string{})
+	v.SetDefault("monitor.signature_keyring", DefaultSignatureKeyring)
+	v.SetDefault("monitor.shutdown_action", ShutdownActionNone)
+	v.SetDefault("monitor.state_file", "/var/lib/wyrmlock/daemon.state")
+	v.SetDefault("monitor.quota_file", "/var/lib/wyrmlock/quotas.json")
+	v.SetDefault("monitor.usage_file", "/var/lib/wyrmlock/usage.json")
+	v.SetDefault("monitor.pin_file", "/var/lib/wyrmlock/pins.json")
+	v.SetDefault("monitor.ledger_file", "/var/lib/wyrmlock/suspended.json")
+	v.SetDefault("monitor.seal_file", "/etc/wyrmlock/integrity.json")
+	v.SetDefault("monitor.integrity_action", IntegrityActionWarn)
+	v.SetDefault("monitor.seccomp", SeccompEnforce)
+	v.SetDefault("monitor.landlock", LandlockAuto)
+	v.SetDefault("monitor.sequence_file", "/var/lib/wyrmlock/events.seq")
+	v.SetDefault("monitor.backend", BackendAuto)
+	v.SetDefault("monitor.poll_interval", 250)
+	v.SetDefault("monitor.hash_concurrency", 2)
+	v.SetDefault("monitor.exec_workers", 8)
+	v.SetDefault("monitor.exec_queue_size", 1024)
+	v.SetDefault("monitor.usage_warning", 5)
+	v.SetDefault("monitor.audit_only", false)
+	v.SetDefault("monitor.default_action", RuleActionLock)
+	v.SetDefault("monitor.allowlist_mode", AllowlistModeOff)
+	v.SetDefault("monitor.allowlist_system_dirs", DefaultAllowlistSystemDirs)
+
+	// External authorization service is disabled by default and falls back
+	// to the local prompt
+	v.SetDefault("authorization.enabled", false)
+	v.SetDefault("authorization.timeout", 5)
+	v.SetDefault("authorization.fallback_action", AuthzPrompt)
+
+	// Notifications of one class arriving within a few seconds are merged
+	v.SetDefault("notifications.window", 3)
+
 	// Default socket path
 	v.SetDefault("socket_path", "/var/run/wyrmlock-daemon.sock")
 
+	// Default to the language of the environment and the installed catalogs
+	v.SetDefault("locale", "")
+	v.SetDefault("locale_dir", "/usr/share/wyrmlock/locale")
+
 	// Default to non-verbose logging
 	v.SetDefault("verbose", false)
 }
@@ -173,20 +1262,129 @@ func setConfigDefaults(v *viper.Viper) {
 // validateConfig checks if the loaded configuration is valid
 func validateConfig(cfg *Config) error {
 	// Check if there are any protected applications
-	if len(cfg.Monitor.ProtectedApps) == 0 {
+	if len(cfg.Monitor.ProtectedApps) == 0 && len(cfg.Monitor.RegexRules) == 0 && !cfg.Monitor.AllowlistEnabled() {
 		return fmt.Errorf("no protected applications specified")
 	}
 
 	// Check GUI type
 	switch cfg.Auth.GuiType {
-	case "gtk", "webkit2gtk", "indicator":
+	case "gtk", "gtk4", "overlay", "helper", "terminal", "webkit2gtk", "indicator":
 		// Valid GUI types
 	default:
 		return fmt.Errorf("invalid GUI type: %s", cfg.Auth.GuiType)
 	}
+	if cfg.Auth.DialogTimeout < 0 {
+		return fmt.Errorf("dialog timeout must not be negative")
+	}
+	if cfg.Locale != "" && !localePattern.MatchString(cfg.Locale) {
+		return fmt.Errorf("invalid locale %q: use a name such as de or pt_BR", cfg.Locale)
+	}
+	if cfg.LocaleDir != "" && !filepath.IsAbs(cfg.LocaleDir) {
+		return fmt.Errorf("locale directory must be an absolute path: %s", cfg.LocaleDir)
+	}
+	if cfg.Auth.DialogLogo != "" && !filepath.IsAbs(cfg.Auth.DialogLogo) {
+		return fmt.Errorf("dialog logo must be an absolute path: %s", cfg.Auth.DialogLogo)
+	}
+	for _, color := range []string{cfg.Auth.DialogPrimaryColor, cfg.Auth.DialogBackgroundColor} {
+		if color != "" && !colorPattern.MatchString(color) {
+			return fmt.Errorf("invalid dialog color %q: use #rrggbb", color)
+		}
+	}
+	if cfg.Auth.GracePeriod < 0 {
+		return fmt.Errorf("grace period must not be negative")
+	}
+	if cfg.Auth.SessionTokens && cfg.Auth.TokenKeyFile == "" {
+		return fmt.Errorf("session tokens require a token key file")
+	}
+	if cfg.Auth.OverrideDuration < 0 {
+		return fmt.Errorf("override duration must not be negative")
+	}
+	if cfg.Auth.OverrideDuration > 0 && cfg.Auth.OverrideCodeFile != "" && cfg.Auth.OverrideJournal == "" {
+		return fmt.Errorf("emergency override requires an override journal")
+	}
+	if cfg.Auth.BluetoothDevice != "" && !IsValidBluetoothAddress(cfg.Auth.BluetoothDevice) {
+		return fmt.Errorf("invalid bluetooth device address: %s", cfg.Auth.BluetoothDevice)
+	}
+	if cfg.Auth.BluetoothTimeout < 0 {
+		return fmt.Errorf("bluetooth timeout must not be negative")
+	}
+	if cfg.Auth.MinSecretLength < 0 || cfg.Auth.MinSecretEntropy < 0 {
+		return fmt.Errorf("secret strength rules must not be negative")
+	}
+	if cfg.Auth.MaxAttempts < 0 || cfg.Auth.LockoutDuration < 0 || cfg.Auth.BackoffBase < 0 || cfg.Auth.BackoffMax < 0 {
+		return fmt.Errorf("lockout policy values must not be negative")
+	}
+	switch cfg.Auth.LockoutScope {
+	case "", LockoutScopeApp, LockoutScopeGlobal:
+		// Valid scopes
+	default:
+		return fmt.Errorf("invalid lockout scope: %s", cfg.Auth.LockoutScope)
+	}
+
+	// Check the auth mode
+	switch cfg.Auth.Mode {
+	case "", AuthModePassword, AuthModePolkit, AuthModeApproval:
+		// Valid modes
+	case AuthModeCommand:
+		if len(cfg.Auth.AuthCommand) == 0 || !filepath.IsAbs(cfg.Auth.AuthCommand[0]) {
+			return fmt.Errorf("command auth mode requires an auth command with an absolute path")
+		}
+	default:
+		return fmt.Errorf("invalid auth mode: %s", cfg.Auth.Mode)
+	}
+	if cfg.Auth.AuthCommandTimeout < 0 {
+		return fmt.Errorf("auth command timeout must not be negative")
+	}
+	if cfg.Auth.Mode == AuthModeApproval && len(cfg.Auth.ApprovalAdmins) == 0 {
+		return fmt.Errorf("approval auth mode requires at least one approval admin")
+	}
+	if cfg.Auth.ApprovalTimeout < 0 {
+		return fmt.Errorf("approval timeout must not be negative")
+	}
+
+	// Check the FIDO2 mode
+	switch cfg.Auth.FIDO2 {
+	case "", FIDO2Off, FIDO2Primary, FIDO2SecondFactor:
+		// Valid modes
+	default:
+		return fmt.Errorf("invalid fido2 mode: %s", cfg.Auth.FIDO2)
+	}
+	if cfg.Auth.FIDO2 != "" && cfg.Auth.FIDO2 != FIDO2Off && cfg.Auth.FIDO2CredentialFile == "" {
+		return fmt.Errorf("fido2 mode %s requires a credential file", cfg.Auth.FIDO2)
+	}
+	if cfg.Auth.DesktopKeyring && cfg.Auth.DesktopKeyringPinFile == "" {
+		return fmt.Errorf("desktop_keyring requires a pin file")
+	}
+
+	// Check the argon2id costs
+	if cfg.Auth.Argon2Memory < 0 || cfg.Auth.Argon2Iterations < 0 || cfg.Auth.Argon2Parallelism < 0 {
+		return fmt.Errorf("argon2 parameters must not be negative")
+	}
+	if cfg.Auth.Argon2Parallelism > 255 {
+		return fmt.Errorf("argon2 parallelism must be at most 255")
+	}
+	if cfg.Auth.Argon2Memory > 0 && cfg.Auth.Argon2Memory < 8*max(cfg.Auth.Argon2Parallelism, 1) {
+		return fmt.Errorf("argon2 memory must be at least 8 KiB per thread")
+	}
+
+	// Check the password backend
+	switch cfg.Auth.Backend {
+	case "", AuthBackendSecret, AuthBackendYubiKey:
+		// Valid backends
+	default:
+		return fmt.Errorf("invalid auth backend: %s", cfg.Auth.Backend)
+	}
 
 	// Check ZKP configuration
-	if cfg.Auth.UseZeroKnowledgeProof {
+	if cfg.Auth.Backend == AuthBackendYubiKey {
+		// The YubiKey backend needs no secret file
+		if cfg.Auth.YubiKeySlot != 1 && cfg.Auth.YubiKeySlot != 2 {
+			return fmt.Errorf("yubikey slot must be 1 or 2")
+		}
+		if cfg.Auth.YubiKeyFile == "" {
+			return fmt.Errorf("yubikey backend requires a yubikey file")
+		}
+	} else if cfg.Auth.UseZeroKnowledgeProof {
 		if cfg.Auth.SecretPath == "" {
 			return fmt.Errorf("when using ZKP, secret file must be specified")
 		}
@@ -206,15 +1404,403 @@ func validateConfig(cfg *Config) error {
 	}
 	
 	// Check hash algorithm for process verification
-	if cfg.Monitor.VerifyHashes {
-		switch cfg.Monitor.HashAlgorithm {
-		case "sha256", "sha512":
-			// Valid hash algorithms
+	if cfg.Monitor.VerifyHashes && !IsFileHashAlgorithm(cfg.Monitor.HashAlgorithm) {
+		return fmt.Errorf("invalid process verification hash algorithm: %s", cfg.Monitor.HashAlgorithm)
+	}
+
+	// Check environment check mode
+	switch cfg.Monitor.EnvCheck {
+	case "", "off", "warn", "strict":
+		// Valid modes
+	default:
+		return fmt.Errorf("invalid environment check mode: %s", cfg.Monitor.EnvCheck)
+	}
+
+	// Check startup action
+	switch cfg.Monitor.StartupAction {
+	case "", StartupActionRegister, StartupActionSuspend, StartupActionPrompt:
+		// Valid actions
+	default:
+		return fmt.Errorf("invalid startup action: %s", cfg.Monitor.StartupAction)
+	}
+
+	// Check the action for replaced executables
+	switch cfg.Monitor.ReplacedExecAction {
+	case "", ReplacedExecActionWarn, ReplacedExecActionLock, ReplacedExecActionDeny:
+		// Valid actions
+	default:
+		return fmt.Errorf("invalid replaced executable action: %s", cfg.Monitor.ReplacedExecAction)
+	}
+
+	// Check the action for anonymous executions
+	switch cfg.Monitor.AnonymousExecAction {
+	case "", AnonymousExecActionWarn, AnonymousExecActionPrompt, AnonymousExecActionDeny:
+		// Valid actions
+	default:
+		return fmt.Errorf("invalid anonymous execution action: %s", cfg.Monitor.AnonymousExecAction)
+	}
+
+	// Check the removable media policy
+	switch cfg.Monitor.RemovableMediaAction {
+	case "", RemovableMediaActionOff, RemovableMediaActionWarn, RemovableMediaActionDeny:
+		// Valid actions
+	default:
+		return fmt.Errorf("invalid removable media action: %s", cfg.Monitor.RemovableMediaAction)
+	}
+
+	// Check the container policy
+	switch cfg.Monitor.ContainerAction {
+	case "", ContainerActionEnforce, ContainerActionIgnore:
+		// Valid actions
+	default:
+		return fmt.Errorf("invalid container action: %s", cfg.Monitor.ContainerAction)
+	}
+
+	// Check the package verification policy
+	switch cfg.Monitor.PackageVerifyAction {
+	case "", PackageVerifyActionOff, PackageVerifyActionWarn, PackageVerifyActionDeny:
+		// Valid actions
+	default:
+		return fmt.Errorf("invalid package verify action: %s", cfg.Monitor.PackageVerifyAction)
+	}
+
+	// Check the IMA measurement mode
+	switch cfg.Monitor.IMAMeasurements {
+	case "", IMAMeasurementsAuto, IMAMeasurementsOff:
+		// Valid modes
+	default:
+		return fmt.Errorf("invalid IMA measurements mode: %s", cfg.Monitor.IMAMeasurements)
+	}
+
+	// Check the self-integrity policy
+	switch cfg.Monitor.IntegrityAction {
+	case "", IntegrityActionOff, IntegrityActionWarn, IntegrityActionRefuse:
+		// Valid actions
+	default:
+		return fmt.Errorf("invalid integrity action: %s", cfg.Monitor.IntegrityAction)
+	}
+
+	// Check the seccomp mode
+	switch cfg.Monitor.Seccomp {
+	case "", SeccompOff, SeccompLog, SeccompEnforce:
+		// Valid modes
+	default:
+		return fmt.Errorf("invalid seccomp mode: %s", cfg.Monitor.Seccomp)
+	}
+
+	// Check the Landlock mode
+	switch cfg.Monitor.Landlock {
+	case "", LandlockAuto, LandlockOff:
+		// Valid modes
+	default:
+		return fmt.Errorf("invalid landlock mode: %s", cfg.Monitor.Landlock)
+	}
+
+	// Check the untrusted directory policy
+	switch cfg.Monitor.UntrustedDirAction {
+	case "", UntrustedDirActionOff, UntrustedDirActionPrompt, UntrustedDirActionDeny:
+		// Valid actions
+	default:
+		return fmt.Errorf("invalid untrusted directory action: %s", cfg.Monitor.UntrustedDirAction)
+	}
+	for _, dir := range cfg.Monitor.UntrustedDirs {
+		if !filepath.IsAbs(dir) && !strings.HasPrefix(dir, "~/") {
+			return fmt.Errorf("untrusted directory must be absolute or start with ~/: %s", dir)
+		}
+	}
+
+	// Check the suspend method
+	switch cfg.Monitor.SuspendMethod {
+	case "", SuspendMethodAuto, SuspendMethodFreezer, SuspendMethodSignal:
+		// Valid methods
+	default:
+		return fmt.Errorf("invalid suspend method: %s", cfg.Monitor.SuspendMethod)
+	}
+
+	if cfg.Monitor.TerminateTimeout < 0 {
+		return fmt.Errorf("terminate timeout must not be negative")
+	}
+
+	// Check the suspend timeout
+	if cfg.Monitor.SuspendTimeout < 0 {
+		return fmt.Errorf("suspend timeout must not be negative")
+	}
+	switch cfg.Monitor.SuspendTimeoutAction {
+	case "", SuspendTimeoutActionTerminate, SuspendTimeoutActionKeep:
+		// Valid actions
+	default:
+		return fmt.Errorf("invalid suspend timeout action: %s", cfg.Monitor.SuspendTimeoutAction)
+	}
+
+	// Check session lock action
+	switch cfg.Monitor.SessionLockAction {
+	case "", SessionLockActionOff, SessionLockActionRevoke, SessionLockActionRelock:
+		// Valid actions
+	default:
+		return fmt.Errorf("invalid session lock action: %s", cfg.Monitor.SessionLockAction)
+	}
+	if cfg.Monitor.IdleRelock < 0 {
+		return fmt.Errorf("idle relock must not be negative")
+	}
+	for _, parent := range cfg.Monitor.TrustedParents {
+		if !filepath.IsAbs(parent) {
+			return fmt.Errorf("trusted parent must be an absolute path: %s", parent)
+		}
+	}
+	for _, signer := range cfg.Monitor.TrustedSigners {
+		if err := validateSigner(signer); err != nil {
+			return err
+		}
+	}
+
+	// Check daemon shutdown action
+	switch cfg.Monitor.ShutdownAction {
+	case "", ShutdownActionNone, ShutdownActionSuspend, ShutdownActionTerminate:
+		// Valid actions
+	default:
+		return fmt.Errorf("invalid shutdown action: %s", cfg.Monitor.ShutdownAction)
+	}
+
+	// Check allowlist mode
+	switch cfg.Monitor.AllowlistMode {
+	case "", AllowlistModeOff, AllowlistModeLock, AllowlistModeDeny:
+		// Valid modes
+	default:
+		return fmt.Errorf("invalid allowlist mode: %s", cfg.Monitor.AllowlistMode)
+	}
+	if cfg.Monitor.AllowlistEnabled() && len(cfg.Monitor.Allowlist) == 0 {
+		return fmt.Errorf("allowlist mode %s requires allowlist entries", cfg.Monitor.AllowlistMode)
+	}
+	for _, entry := range cfg.Monitor.Allowlist {
+		if hash, ok := ParseHashEntry(entry); ok && !isSHA256Hex(hash) {
+			return fmt.Errorf("invalid allowlist hash entry: %s", entry)
+		}
+	}
+
+	// Check the default action for protected apps
+	switch cfg.Monitor.DefaultAction {
+	case "", RuleActionLock, RuleActionDeny:
+		// Valid actions
+	default:
+		return fmt.Errorf("invalid default action: %s", cfg.Monitor.DefaultAction)
+	}
+
+	// Check hash-based protection entries
+	for _, entry := range cfg.Monitor.ProtectedApps {
+		if hash, ok := ParseHashEntry(entry); ok && !isSHA256Hex(hash) {
+			return fmt.Errorf("invalid hash entry in protected apps: %s", entry)
+		}
+		if _, appID, ok := ParseSandboxEntry(entry); ok && appID == "" {
+			return fmt.Errorf("missing application ID in protected apps entry: %s", entry)
+		}
+	}
+	if cfg.Monitor.UsageWarning < 0 {
+		return fmt.Errorf("usage warning must not be negative")
+	}
+	if cfg.Monitor.ExecWorkers < 0 || cfg.Monitor.ExecQueueSize < 0 {
+		return fmt.Errorf("exec workers and queue size must not be negative")
+	}
+	if cfg.Monitor.HashConcurrency < 0 {
+		return fmt.Errorf("hash concurrency must not be negative")
+	}
+
+	// Check the event backend
+	switch cfg.Monitor.Backend {
+	case "", BackendAuto, BackendProcConnector, BackendPoll:
+		// Valid backends
+	default:
+		return fmt.Errorf("invalid monitor backend: %s", cfg.Monitor.Backend)
+	}
+	if cfg.Monitor.PollInterval < 0 {
+		return fmt.Errorf("poll interval must not be negative")
+	}
+	for _, entry := range cfg.Monitor.RedactArgs {
+		if strings.TrimSpace(entry) == "" {
+			return fmt.Errorf("empty entry in redact_args")
+		}
+	}
+	for _, app := range cfg.BlockedApps {
+		if app.MaxInstances < 0 {
+			return fmt.Errorf("blocked app %s has a negative instance limit", app.Path)
+		}
+		if app.DailyLaunches < 0 {
+			return fmt.Errorf("blocked app %s has a negative daily launch quota", app.Path)
+		}
+		if app.DailyMinutes < 0 {
+			return fmt.Errorf("blocked app %s has a negative daily time budget", app.Path)
+		}
+		switch app.LimitAction {
+		case "", LimitActionSuspend, LimitActionTerminate:
+			// Valid actions
+		default:
+			return fmt.Errorf("blocked app %s: invalid limit_action: %s", app.Path, app.LimitAction)
+		}
+		switch app.Action {
+		case "", RuleActionLock, RuleActionDeny, RuleActionLog:
+			// Valid actions
+		default:
+			return fmt.Errorf("blocked app %s: invalid action: %s", app.Path, app.Action)
+		}
+		if app.MaxAttempts < 0 {
+			return fmt.Errorf("blocked app %s has a negative attempt limit", app.Path)
+		}
+		if app.Secret != "" && !IsValidSecretName(app.Secret) {
+			return fmt.Errorf("blocked app %s: invalid secret name: %q", app.Path, app.Secret)
+		}
+		if app.Secret != "" && cfg.Auth.AppSecretDir == "" {
+			return fmt.Errorf("blocked app %s names a secret but no app_secret_dir is configured", app.Path)
+		}
+		if app.RequireSignature && len(cfg.Monitor.TrustedSigners) == 0 {
+			return fmt.Errorf("blocked app %s requires a signature but no trusted signers are configured", app.Path)
+		}
+		if app.GracePeriod < 0 {
+			return fmt.Errorf("blocked app %s has a negative grace period", app.Path)
+		}
+		for _, parent := range app.TrustedParents {
+			if !filepath.IsAbs(parent) {
+				return fmt.Errorf("blocked app %s: trusted parent must be an absolute path: %s", app.Path, parent)
+			}
+		}
+		for _, dir := range app.WorkingDirs {
+			if !filepath.IsAbs(dir) {
+				return fmt.Errorf("blocked app %s: working directory must be an absolute path: %s", app.Path, dir)
+			}
+		}
+		for _, entry := range app.Env {
+			if name, _, _ := strings.Cut(entry, "="); name == "" {
+				return fmt.Errorf("blocked app %s: invalid environment condition: %q", app.Path, entry)
+			}
+		}
+		if app.FileHashAlgorithm != "" && !IsFileHashAlgorithm(app.FileHashAlgorithm) {
+			return fmt.Errorf("blocked app %s: invalid file hash algorithm: %s", app.Path, app.FileHashAlgorithm)
+		}
+		if app.MatchByHash && (!isSHA256Hex(strings.ToLower(app.FileHash)) || (app.FileHashAlgorithm != "" && app.FileHashAlgorithm != "sha256")) {
+			return fmt.Errorf("blocked app %s matches by hash but has no valid SHA-256 file hash", app.Path)
+		}
+		if app.PinHash && app.FileHash != "" && (!isSHA256Hex(strings.ToLower(app.FileHash)) || (app.FileHashAlgorithm != "" && app.FileHashAlgorithm != "sha256")) {
+			return fmt.Errorf("blocked app %s pins its hash but its file hash is not a valid SHA-256", app.Path)
+		}
+		if err := validateCredentialNames(app.Users, app.Groups); err != nil {
+			return fmt.Errorf("blocked app %s: %v", app.Path, err)
+		}
+		for i, block := range app.Schedule {
+			if err := block.Validate(); err != nil {
+				return fmt.Errorf("blocked app %s: schedule block %d: %v", app.Path, i, err)
+			}
+		}
+		switch app.OutsideSchedule {
+		case "", ScheduleActionAllow, ScheduleActionPrompt, ScheduleActionDeny:
+			// Valid actions
 		default:
-			return fmt.Errorf("invalid process verification hash algorithm: %s", cfg.Monitor.HashAlgorithm)
+			return fmt.Errorf("blocked app %s: invalid outside_schedule action: %s", app.Path, app.OutsideSchedule)
 		}
 	}
 
+	// Check external authorization settings
+	if cfg.Authorization.Enabled {
+		if !strings.HasPrefix(cfg.Authorization.URL, "https://") && !strings.HasPrefix(cfg.Authorization.URL, "http://") {
+			return fmt.Errorf("authorization URL must be http(s): %q", cfg.Authorization.URL)
+		}
+		switch cfg.Authorization.FallbackAction {
+		case "", AuthzPrompt, AuthzDeny, AuthzAllow:
+			// Valid fallback actions
+		default:
+			return fmt.Errorf("invalid authorization fallback action: %s", cfg.Authorization.FallbackAction)
+		}
+	}
+
+	// Check notification settings
+	if cfg.Notifications.Window < 0 {
+		return fmt.Errorf("notification window must not be negative")
+	}
+	for class, limit := range cfg.Notifications.RateLimits {
+		switch class {
+		case NotifyClassDenied, NotifyClassLockout, NotifyClassUsage:
+			// Known event classes
+		default:
+			return fmt.Errorf("unknown notification class: %s", class)
+		}
+		if limit < 0 {
+			return fmt.Errorf("notification rate limit for %s must not be negative", class)
+		}
+	}
+
+	// Check regex rules compile and use known actions
+	for i, rule := range cfg.Monitor.RegexRules {
+		if err := validateRegexRule(rule); err != nil {
+			return fmt.Errorf("invalid regex rule %d (%s): %v", i, rule.Name, err)
+		}
+	}
+
+	// Check parental-control profiles
+	if err := validateProfiles(cfg.Profiles); err != nil {
+		return err
+	}
+	if len(cfg.Profiles) > 0 && cfg.Auth.ParentSecretFile == "" {
+		return fmt.Errorf("parental-control profiles require a parent secret file")
+	}
+
+	return nil
+}
+
+// validateRegexRule checks a single regex rule
+func validateRegexRule(rule RegexRule) error {
+	if rule.PathPattern == "" && rule.CmdlinePattern == "" {
+		return fmt.Errorf("at least one of path_pattern or cmdline_pattern is required")
+	}
+
+	if rule.PathPattern != "" {
+		if _, err := regexp.Compile(rule.PathPattern); err != nil {
+			return fmt.Errorf("invalid path_pattern: %v", err)
+		}
+	}
+
+	if rule.CmdlinePattern != "" {
+		if _, err := regexp.Compile(rule.CmdlinePattern); err != nil {
+			return fmt.Errorf("invalid cmdline_pattern: %v", err)
+		}
+	}
+
+	switch rule.Action {
+	case "", RuleActionLock, RuleActionDeny, RuleActionAllow:
+		// Valid actions
+	default:
+		return fmt.Errorf("invalid action: %s", rule.Action)
+	}
+
+	return validateCredentialNames(rule.Users, rule.Groups)
+}
+
+// validateSigner checks a trusted signer entry
+func validateSigner(signer string) error {
+	if fpr, ok := strings.CutPrefix(signer, SignerPrefixGPG); ok {
+		fpr = strings.ReplaceAll(fpr, " ", "")
+		if _, err := hex.DecodeString(fpr); err != nil || len(fpr) < 16 {
+			return fmt.Errorf("invalid GPG signer fingerprint: %s", signer)
+		}
+		return nil
+	}
+	if cert, ok := strings.CutPrefix(signer, SignerPrefixX509); ok {
+		if !filepath.IsAbs(cert) {
+			return fmt.Errorf("x509 signer certificate must be an absolute path: %s", signer)
+		}
+		return nil
+	}
+	return fmt.Errorf("trusted signer must start with %s or %s: %s", SignerPrefixGPG, SignerPrefixX509, signer)
+}
+
+// validateCredentialNames checks the user and group names of a rule
+func validateCredentialNames(users, groups []string) error {
+	for _, name := range users {
+		if name == "" || strings.ContainsAny(name, " \t:") {
+			return fmt.Errorf("invalid user name: %q", name)
+		}
+	}
+	for _, name := range groups {
+		if name == "" || strings.ContainsAny(name, " \t:") {
+			return fmt.Errorf("invalid group name: %q", name)
+		}
+	}
 	return nil
 }
 
@@ -277,6 +1863,49 @@ func SaveConfig(cfg *Config, configPath string) error {
 	v.Set("monitor.scan_interval", cfg.Monitor.ScanInterval)
 	v.Set("monitor.verify_hashes", cfg.Monitor.VerifyHashes)
 	v.Set("monitor.hash_algorithm", cfg.Monitor.HashAlgorithm)
+	v.Set("monitor.regex_rules", cfg.Monitor.RegexRules)
+	v.Set("monitor.env_check", cfg.Monitor.EnvCheck)
+	v.Set("monitor.startup_action", cfg.Monitor.StartupAction)
+	v.Set("monitor.replaced_exec_action", cfg.Monitor.ReplacedExecAction)
+	v.Set("monitor.anonymous_exec_action", cfg.Monitor.AnonymousExecAction)
+	v.Set("monitor.removable_media_action", cfg.Monitor.RemovableMediaAction)
+	v.Set("monitor.container_action", cfg.Monitor.ContainerAction)
+	v.Set("monitor.package_verify_action", cfg.Monitor.PackageVerifyAction)
+	v.Set("monitor.ima_measurements", cfg.Monitor.IMAMeasurements)
+	v.Set("monitor.untrusted_dir_action", cfg.Monitor.UntrustedDirAction)
+	v.Set("monitor.untrusted_dirs", cfg.Monitor.UntrustedDirs)
+	v.Set("monitor.suspend_method", cfg.Monitor.SuspendMethod)
+	v.Set("monitor.terminate_timeout", cfg.Monitor.TerminateTimeout)
+	v.Set("monitor.suspend_timeout", cfg.Monitor.SuspendTimeout)
+	v.Set("monitor.suspend_timeout_action", cfg.Monitor.SuspendTimeoutAction)
+	v.Set("monitor.session_lock_action", cfg.Monitor.SessionLockAction)
+	v.Set("monitor.idle_relock", cfg.Monitor.IdleRelock)
+	v.Set("monitor.trusted_parents", cfg.Monitor.TrustedParents)
+	v.Set("monitor.trusted_signers", cfg.Monitor.TrustedSigners)
+	v.Set("monitor.signature_keyring", cfg.Monitor.SignatureKeyring)
+	v.Set("monitor.shutdown_action", cfg.Monitor.ShutdownAction)
+	v.Set("monitor.state_file", cfg.Monitor.StateFile)
+	v.Set("monitor.quota_file", cfg.Monitor.QuotaFile)
+	v.Set("monitor.usage_file", cfg.Monitor.UsageFile)
+	v.Set("monitor.pin_file", cfg.Monitor.PinFile)
+	v.Set("monitor.ledger_file", cfg.Monitor.LedgerFile)
+	v.Set("monitor.seal_file", cfg.Monitor.SealFile)
+	v.Set("monitor.integrity_action", cfg.Monitor.IntegrityAction)
+	v.Set("monitor.seccomp", cfg.Monitor.Seccomp)
+	v.Set("monitor.landlock", cfg.Monitor.Landlock)
+	v.Set("monitor.sequence_file", cfg.Monitor.SequenceFile)
+	v.Set("monitor.backend", cfg.Monitor.Backend)
+	v.Set("monitor.poll_interval", cfg.Monitor.PollInterval)
+	v.Set("monitor.hash_concurrency", cfg.Monitor.HashConcurrency)
+	v.Set("monitor.exec_workers", cfg.Monitor.ExecWorkers)
+	v.Set("monitor.exec_queue_size", cfg.Monitor.ExecQueueSize)
+	v.Set("monitor.usage_warning", cfg.Monitor.UsageWarning)
+	v.Set("monitor.redact_args", cfg.Monitor.RedactArgs)
+	v.Set("monitor.audit_only", cfg.Monitor.AuditOnly)
+	v.Set("monitor.default_action", cfg.Monitor.DefaultAction)
+	v.Set("monitor.allowlist_mode", cfg.Monitor.AllowlistMode)
+	v.Set("monitor.allowlist", cfg.Monitor.Allowlist)
+	v.Set("monitor.allowlist_system_dirs", cfg.Monitor.AllowlistSystemDirs)
 
 	// Auth settings
 	v.Set("auth.use_zero_knowledge_proof", cfg.Auth.UseZeroKnowledgeProof)
@@ -285,10 +1914,67 @@ func SaveConfig(cfg *Config, configPath string) error {
 	v.Set("auth.gui_type", cfg.Auth.GuiType)
 	v.Set("auth.max_attempts", cfg.Auth.MaxAttempts)
 	v.Set("auth.lockout_duration", cfg.Auth.LockoutDuration)
+	v.Set("auth.backoff_base", cfg.Auth.BackoffBase)
+	v.Set("auth.backoff_max", cfg.Auth.BackoffMax)
+	v.Set("auth.lockout_scope", cfg.Auth.LockoutScope)
+	v.Set("auth.lockout_state_file", cfg.Auth.LockoutStateFile)
+	v.Set("auth.dialog_timeout", cfg.Auth.DialogTimeout)
+	v.Set("auth.dialog_title", cfg.Auth.DialogTitle)
+	v.Set("auth.dialog_message", cfg.Auth.DialogMessage)
+	v.Set("auth.dialog_logo", cfg.Auth.DialogLogo)
+	v.Set("auth.dialog_primary_color", cfg.Auth.DialogPrimaryColor)
+	v.Set("auth.dialog_background_color", cfg.Auth.DialogBackgroundColor)
+	v.Set("auth.grace_period", cfg.Auth.GracePeriod)
+	v.Set("auth.session_tokens", cfg.Auth.SessionTokens)
+	v.Set("auth.token_key_file", cfg.Auth.TokenKeyFile)
+	v.Set("auth.mode", cfg.Auth.Mode)
+	v.Set("auth.fido2", cfg.Auth.FIDO2)
+	v.Set("auth.fido2_credential_file", cfg.Auth.FIDO2CredentialFile)
+	v.Set("auth.fido2_device", cfg.Auth.FIDO2Device)
+	v.Set("auth.fingerprint", cfg.Auth.Fingerprint)
+	v.Set("auth.backend", cfg.Auth.Backend)
+	v.Set("auth.yubikey_slot", cfg.Auth.YubiKeySlot)
+	v.Set("auth.yubikey_file", cfg.Auth.YubiKeyFile)
+	v.Set("auth.app_secret_dir", cfg.Auth.AppSecretDir)
+	v.Set("auth.user_secret_dir", cfg.Auth.UserSecretDir)
+	v.Set("auth.desktop_keyring", cfg.Auth.DesktopKeyring)
+	v.Set("auth.desktop_keyring_pin_file", cfg.Auth.DesktopKeyringPinFile)
+	v.Set("auth.recovery_code_file", cfg.Auth.RecoveryCodeFile)
+	v.Set("auth.override_code_file", cfg.Auth.OverrideCodeFile)
+	v.Set("auth.override_duration", cfg.Auth.OverrideDuration)
+	v.Set("auth.override_journal", cfg.Auth.OverrideJournal)
+	v.Set("auth.bluetooth_device", cfg.Auth.BluetoothDevice)
+	v.Set("auth.bluetooth_timeout", cfg.Auth.BluetoothTimeout)
+	v.Set("auth.auth_command", cfg.Auth.AuthCommand)
+	v.Set("auth.auth_command_timeout", cfg.Auth.AuthCommandTimeout)
+	v.Set("auth.approval_admins", cfg.Auth.ApprovalAdmins)
+	v.Set("auth.approval_timeout", cfg.Auth.ApprovalTimeout)
+	v.Set("auth.parent_secret_file", cfg.Auth.ParentSecretFile)
+	v.Set("auth.min_secret_length", cfg.Auth.MinSecretLength)
+	v.Set("auth.min_secret_entropy", cfg.Auth.MinSecretEntropy)
+	v.Set("auth.argon2_memory", cfg.Auth.Argon2Memory)
+	v.Set("auth.argon2_iterations", cfg.Auth.Argon2Iterations)
+	v.Set("auth.argon2_parallelism", cfg.Auth.Argon2Parallelism)
+
+	// External authorization
+	v.Set("authorization.enabled", cfg.Authorization.Enabled)
+	v.Set("authorization.url", cfg.Authorization.URL)
+	v.Set("authorization.timeout", cfg.Authorization.Timeout)
+	v.Set("authorization.fallback_action", cfg.Authorization.FallbackAction)
+	v.Set("authorization.token_file", cfg.Authorization.TokenFile)
+	v.Set("authorization.ca_cert_path", cfg.Authorization.CACertPath)
+
+	// Notifications
+	v.Set("notifications.window", cfg.Notifications.Window)
+	v.Set("notifications.rate_limits", cfg.Notifications.RateLimits)
 
 	// Socket path
 	v.Set("socket_path", cfg.SocketPath)
 
+	// Language
+	v.Set("locale", cfg.Locale)
+	v.Set("locale_dir", cfg.LocaleDir)
+
 	// Other settings
 	v.Set("verbose", cfg.Verbose)
 
@@ -318,19 +2004,88 @@ func DefaultConfig() *Config {
 	cfg := &Config{
 		SocketPath: "/var/run/wyrmlock-daemon.sock",
 		Verbose:    false,
+		LocaleDir:  "/usr/share/wyrmlock/locale",
 		Auth: AuthConfig{
 			GuiType:               "gtk",
 			HashAlgorithm:         "argon2id",
 			MaxAttempts:           3,
 			LockoutDuration:       300, // 5 minutes
+			BackoffBase:           1,
+			BackoffMax:            60,
+			LockoutScope:          LockoutScopeApp,
+			LockoutStateFile:      "/var/lib/wyrmlock/lockouts.json",
+			DialogTimeout:         60,
+			DialogTitle:           "Authentication Required - {app}",
+			TokenKeyFile:          "/var/run/wyrmlock-token.key",
+			Mode:                  AuthModePassword,
+			FIDO2:                 FIDO2Off,
+			FIDO2CredentialFile:   "/etc/wyrmlock/fido2.json",
+			Fingerprint:           false,
+			Backend:               AuthBackendSecret,
+			YubiKeySlot:           2,
+			YubiKeyFile:           "/etc/wyrmlock/yubikey.json",
+			AppSecretDir:          "/etc/wyrmlock/app-secrets",
+			UserSecretDir:         "/etc/wyrmlock/users",
+			DesktopKeyringPinFile: "/etc/wyrmlock/desktop-keyring-pins.json",
+			RecoveryCodeFile:      "/etc/wyrmlock/recovery-codes.json",
+			OverrideCodeFile:      "/etc/wyrmlock/override-code",
+			OverrideDuration:      15,
+			OverrideJournal:       "/var/lib/wyrmlock/override.journal",
+			BluetoothTimeout:      2,
+			AuthCommandTimeout:    30,
+			ApprovalTimeout:       120,
+			ParentSecretFile:      "/etc/wyrmlock/parent-secret",
+			MinSecretLength:       8,
+			MinSecretEntropy:      35,
+			Argon2Memory:          65536,
+			Argon2Iterations:      3,
+			Argon2Parallelism:     2,
 			UseZeroKnowledgeProof: true,
 			SecretPath:            "/etc/wyrmlock/secret",
 		},
 		Monitor: MonitorConfig{
-			ScanInterval:  1,
-			ProtectedApps: []string{},
-			VerifyHashes:  false,
-			HashAlgorithm: "sha256",
+			ScanInterval:         1,
+			ProtectedApps:        []string{},
+			VerifyHashes:         false,
+			HashAlgorithm:        "sha256",
+			StartupAction:        StartupActionRegister,
+			ReplacedExecAction:   ReplacedExecActionWarn,
+			AnonymousExecAction:  AnonymousExecActionWarn,
+			RemovableMediaAction: RemovableMediaActionOff,
+			ContainerAction:      ContainerActionEnforce,
+			PackageVerifyAction:  PackageVerifyActionOff,
+			IMAMeasurements:      IMAMeasurementsAuto,
+			UntrustedDirAction:   UntrustedDirActionOff,
+			UntrustedDirs:        append([]string(nil), DefaultUntrustedDirs...),
+			SuspendMethod:        SuspendMethodAuto,
+			TerminateTimeout:     5,
+			SuspendTimeout:       300,
+			SuspendTimeoutAction: SuspendTimeoutActionTerminate,
+			SessionLockAction:    SessionLockActionRevoke,
+			SignatureKeyring:     DefaultSignatureKeyring,
+			ShutdownAction:       ShutdownActionNone,
+			StateFile:            "/var/lib/wyrmlock/daemon.state",
+			QuotaFile:            "/var/lib/wyrmlock/quotas.json",
+			UsageFile:            "/var/lib/wyrmlock/usage.json",
+			PinFile:              "/var/lib/wyrmlock/pins.json",
+			LedgerFile:           "/var/lib/wyrmlock/suspended.json",
+			SealFile:             "/etc/wyrmlock/integrity.json",
+			IntegrityAction:      IntegrityActionWarn,
+			Seccomp:              SeccompEnforce,
+			Landlock:             LandlockAuto,
+			SequenceFile:         "/var/lib/wyrmlock/events.seq",
+			UsageWarning:         5,
+			Backend:              BackendAuto,
+			PollInterval:         250,
+			HashConcurrency:      2,
+			ExecWorkers:          8,
+			ExecQueueSize:        1024,
+
+			AllowlistMode:       AllowlistModeOff,
+			AllowlistSystemDirs: append([]string(nil), DefaultAllowlistSystemDirs...),
+		},
+		Notifications: NotificationConfig{
+			Window: 3,
 		},
 	}
 
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"os/user"
+	"strconv"
+	"time"
+)
+
+// Profile is a parental-control profile. Its child accounts cannot unlock
+// protected apps with their own password: only the parent password does,
+// and the emergency override is refused for them. Launches during the
+// profile's schedule are denied outright.
+type Profile struct {
+	// Name identifies the profile in logs and denials
+	Name string `json:"name"`
+
+	// Users are the child accounts, by name or numeric ID
+	Users []string `json:"users"`
+
+	// Schedule lists windows during which every protected app is denied
+	// to the child accounts, on top of each app's own schedule
+	Schedule []ScheduleBlock `json:"schedule,omitempty"`
+}
+
+// HasUser reports whether the profile lists the user with username or uid
+func (p Profile) HasUser(username string, uid int) bool {
+	for _, u := range p.Users {
+		if u == username || (uid >= 0 && u == strconv.Itoa(uid)) {
+			return true
+		}
+	}
+	return false
+}
+
+// BlockedAt reports whether t falls within one of the profile's windows
+func (p Profile) BlockedAt(t time.Time) bool {
+	for _, block := range p.Schedule {
+		if block.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// ProfileFor returns the profile listing the user with uid, if any
+func (c *Config) ProfileFor(uid int) (Profile, bool) {
+	if uid < 0 || len(c.Profiles) == 0 {
+		return Profile{}, false
+	}
+	username := ""
+	if u, err := user.LookupId(strconv.Itoa(uid)); err == nil {
+		username = u.Username
+	}
+	for _, profile := range c.Profiles {
+		if profile.HasUser(username, uid) {
+			return profile, true
+		}
+	}
+	return Profile{}, false
+}
+
+// validateProfiles checks the profiles' names, accounts and schedules. A
+// child account in two profiles would make it unclear which applies.
+func validateProfiles(profiles []Profile) error {
+	names := make(map[string]bool)
+	users := make(map[string]string)
+	for _, profile := range profiles {
+		if profile.Name == "" {
+			return fmt.Errorf("profile without a name")
+		}
+		if names[profile.Name] {
+			return fmt.Errorf("duplicate profile: %s", profile.Name)
+		}
+		names[profile.Name] = true
+
+		if len(profile.Users) == 0 {
+			return fmt.Errorf("profile %s: no users", profile.Name)
+		}
+		if err := validateCredentialNames(profile.Users, nil); err != nil {
+			return fmt.Errorf("profile %s: %v", profile.Name, err)
+		}
+		for _, u := range profile.Users {
+			if other, ok := users[u]; ok {
+				return fmt.Errorf("profile %s: user %s is already in profile %s", profile.Name, u, other)
+			}
+			users[u] = profile.Name
+		}
+
+		for i, block := range profile.Schedule {
+			if err := block.Validate(); err != nil {
+				return fmt.Errorf("profile %s: schedule block %d: %v", profile.Name, i, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"wyrmlock/internal/config"
+)
+
+// TestProfileBlockedAt tests that a profile blocks launches only within
+// its schedule windows
+func TestProfileBlockedAt(t *testing.T) {
+	profile := config.Profile{
+		Name:  "kids",
+		Users: []string{"alice", "1001"},
+		Schedule: []config.ScheduleBlock{
+			{Start: "21:00", End: "07:00"},
+			{Days: []string{"weekdays"}, Start: "09:00", End: "15:00"},
+		},
+	}
+
+	// 2026-10-12 is a Monday
+	tests := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		{"bedtime", time.Date(2026, 10, 12, 22, 0, 0, 0, time.Local), true},
+		{"school", time.Date(2026, 10, 12, 10, 0, 0, 0, time.Local), true},
+		{"afternoon", time.Date(2026, 10, 12, 16, 0, 0, 0, time.Local), false},
+		{"weekend morning", time.Date(2026, 10, 17, 10, 0, 0, 0, time.Local), false},
+	}
+	for _, tt := range tests {
+		if got := profile.BlockedAt(tt.t); got != tt.want {
+			t.Errorf("%s: BlockedAt = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if !profile.HasUser("alice", -1) || !profile.HasUser("", 1001) || profile.HasUser("bob", 1000) {
+		t.Error("HasUser matched the wrong accounts")
+	}
+}
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule actions applied to a blocked app outside its schedule blocks
+const (
+	// ScheduleActionAllow lets the app run without prompting
+	ScheduleActionAllow = "allow"
+
+	// ScheduleActionPrompt requires authentication as usual
+	ScheduleActionPrompt = "prompt"
+
+	// ScheduleActionDeny terminates the app without prompting
+	ScheduleActionDeny = "deny"
+)
+
+// ScheduleBlock is a window during which a blocked app is denied, e.g.
+// 22:00-07:00 every day or all day on Sunday. A window that ends before it
+// starts runs past midnight and belongs to the day it starts on.
+type ScheduleBlock struct {
+	// Days the window starts on: mon..sun, weekdays, weekend. Empty means every day.
+	Days []string `json:"days,omitempty"`
+
+	// Start and End are "HH:MM" in local time. Both empty means all day.
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+var weekdayNames = map[string][]time.Weekday{
+	"sun":      {time.Sunday},
+	"mon":      {time.Monday},
+	"tue":      {time.Tuesday},
+	"wed":      {time.Wednesday},
+	"thu":      {time.Thursday},
+	"fri":      {time.Friday},
+	"sat":      {time.Saturday},
+	"weekdays": {time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	"weekend":  {time.Saturday, time.Sunday},
+}
+
+// parseDays returns the set of weekdays named by days
+func parseDays(days []string) ([7]bool, error) {
+	var set [7]bool
+	if len(days) == 0 {
+		for i := range set {
+			set[i] = true
+		}
+		return set, nil
+	}
+
+	for _, day := range days {
+		name := strings.ToLower(strings.TrimSpace(day))
+		if len(name) > 3 && name != "weekdays" && name != "weekend" {
+			// Accept full names such as "sunday"
+			name = name[:3]
+		}
+		weekdays, ok := weekdayNames[name]
+		if !ok {
+			return set, fmt.Errorf("invalid day: %s", day)
+		}
+		for _, wd := range weekdays {
+			set[wd] = true
+		}
+	}
+	return set, nil
+}
+
+// parseClock converts "HH:MM" to minutes after midnight
+func parseClock(s string) (int, error) {
+	hh, mm, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	h, err := strconv.Atoi(hh)
+	if err != nil || h < 0 || h > 24 {
+		return 0, fmt.Errorf("invalid hour in %q", s)
+	}
+	m, err := strconv.Atoi(mm)
+	if err != nil || m < 0 || m > 59 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("invalid minute in %q", s)
+	}
+	return h*60 + m, nil
+}
+
+// Validate checks the days and times of the block
+func (b ScheduleBlock) Validate() error {
+	if _, err := parseDays(b.Days); err != nil {
+		return err
+	}
+	if (b.Start == "") != (b.End == "") {
+		return fmt.Errorf("start and end must both be set or both be empty")
+	}
+	if b.Start == "" {
+		return nil
+	}
+	start, err := parseClock(b.Start)
+	if err != nil {
+		return err
+	}
+	end, err := parseClock(b.End)
+	if err != nil {
+		return err
+	}
+	if start == end {
+		return fmt.Errorf("start and end are equal; leave both empty for all day")
+	}
+	return nil
+}
+
+// Contains reports whether t falls within the block
+func (b ScheduleBlock) Contains(t time.Time) bool {
+	days, err := parseDays(b.Days)
+	if err != nil {
+		return false
+	}
+
+	if b.Start == "" && b.End == "" {
+		return days[t.Weekday()]
+	}
+
+	start, err := parseClock(b.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(b.End)
+	if err != nil {
+		return false
+	}
+
+	now := t.Hour()*60 + t.Minute()
+	if start < end {
+		return days[t.Weekday()] && now >= start && now < end
+	}
+
+	// Overnight window: the evening part belongs to today, the morning
+	// part to the window that started yesterday
+	yesterday := (t.Weekday() + 6) % 7
+	return (days[t.Weekday()] && now >= start) || (days[yesterday] && now < end)
+}
+
+// ScheduleActionAt returns how a launch of the app at t is handled: deny
+// inside a schedule block, otherwise the app's outside-schedule action.
+// Apps without a schedule always prompt.
+func (a BlockedApp) ScheduleActionAt(t time.Time) string {
+	if len(a.Schedule) == 0 {
+		return ScheduleActionPrompt
+	}
+
+	for _, block := range a.Schedule {
+		if block.Contains(t) {
+			return ScheduleActionDeny
+		}
+	}
+
+	if a.OutsideSchedule == "" {
+		return ScheduleActionAllow
+	}
+	return a.OutsideSchedule
+}
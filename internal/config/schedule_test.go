@@ -0,0 +1,88 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"wyrmlock/internal/config"
+)
+
+// TestScheduleBlockContains tests same-day, overnight and all-day windows
+func TestScheduleBlockContains(t *testing.T) {
+	// 2026-10-11 is a Sunday
+	at := func(day int, hour, minute int) time.Time {
+		return time.Date(2026, 10, day, hour, minute, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		name  string
+		block config.ScheduleBlock
+		t     time.Time
+		want  bool
+	}{
+		{"overnight evening", config.ScheduleBlock{Start: "22:00", End: "07:00"}, at(12, 23, 0), true},
+		{"overnight morning", config.ScheduleBlock{Start: "22:00", End: "07:00"}, at(12, 6, 59), true},
+		{"overnight outside", config.ScheduleBlock{Start: "22:00", End: "07:00"}, at(12, 7, 0), false},
+		{"overnight from friday only", config.ScheduleBlock{Days: []string{"fri"}, Start: "22:00", End: "07:00"}, at(17, 3, 0), true},
+		{"overnight not from saturday", config.ScheduleBlock{Days: []string{"fri"}, Start: "22:00", End: "07:00"}, at(18, 3, 0), false},
+		{"all day sunday", config.ScheduleBlock{Days: []string{"sunday"}}, at(11, 12, 0), true},
+		{"all day sunday on monday", config.ScheduleBlock{Days: []string{"sun"}}, at(12, 12, 0), false},
+		{"weekdays daytime", config.ScheduleBlock{Days: []string{"weekdays"}, Start: "09:00", End: "17:00"}, at(14, 10, 30), true},
+		{"weekdays on weekend", config.ScheduleBlock{Days: []string{"weekdays"}, Start: "09:00", End: "17:00"}, at(11, 10, 30), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.block.Contains(tt.t); got != tt.want {
+				t.Errorf("Contains(%s) = %v, want %v", tt.t.Format("Mon 15:04"), got, tt.want)
+			}
+		})
+	}
+}
+
+// TestScheduleActionAt tests the action inside and outside schedule blocks
+func TestScheduleActionAt(t *testing.T) {
+	app := config.BlockedApp{
+		Path:     "/usr/bin/firefox",
+		Schedule: []config.ScheduleBlock{{Start: "22:00", End: "07:00"}},
+	}
+
+	night := time.Date(2026, 10, 12, 23, 0, 0, 0, time.Local)
+	day := time.Date(2026, 10, 12, 12, 0, 0, 0, time.Local)
+
+	if got := app.ScheduleActionAt(night); got != config.ScheduleActionDeny {
+		t.Errorf("Expected deny inside schedule, got %s", got)
+	}
+	if got := app.ScheduleActionAt(day); got != config.ScheduleActionAllow {
+		t.Errorf("Expected allow outside schedule by default, got %s", got)
+	}
+
+	app.OutsideSchedule = config.ScheduleActionPrompt
+	if got := app.ScheduleActionAt(day); got != config.ScheduleActionPrompt {
+		t.Errorf("Expected prompt outside schedule, got %s", got)
+	}
+
+	if got := (config.BlockedApp{}).ScheduleActionAt(night); got != config.ScheduleActionPrompt {
+		t.Errorf("Expected prompt without schedule, got %s", got)
+	}
+}
+
+// TestScheduleBlockValidate tests rejection of malformed blocks
+func TestScheduleBlockValidate(t *testing.T) {
+	invalid := []config.ScheduleBlock{
+		{Days: []string{"someday"}},
+		{Start: "22:00"},
+		{Start: "25:00", End: "07:00"},
+		{Start: "10:00", End: "10:00"},
+		{Start: "9am", End: "5pm"},
+	}
+	for _, block := range invalid {
+		if err := block.Validate(); err == nil {
+			t.Errorf("Expected %+v to be invalid", block)
+		}
+	}
+
+	if err := (config.ScheduleBlock{Days: []string{"weekend"}, Start: "00:00", End: "24:00"}).Validate(); err != nil {
+		t.Errorf("Expected valid block, got %v", err)
+	}
+}
@@ -0,0 +1,234 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"os/user"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"wyrmlock/internal/gui"
+	"wyrmlock/internal/ipc"
+	"wyrmlock/internal/monitor"
+)
+
+// approvalDecision is an administrator's answer to an approval request
+type approvalDecision struct {
+	approved bool
+	admin    string
+}
+
+// approvalStore holds the launches waiting for an administrator
+type approvalStore struct {
+	mu      sync.Mutex
+	pending map[int]chan approvalDecision
+}
+
+// newApprovalStore creates an empty approval store
+func newApprovalStore() *approvalStore {
+	return &approvalStore{pending: make(map[int]chan approvalDecision)}
+}
+
+// add registers a launch as waiting and returns where its decision arrives
+func (s *approvalStore) add(pid int) <-chan approvalDecision {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ch := make(chan approvalDecision, 1)
+	s.pending[pid] = ch
+	return ch
+}
+
+// remove forgets a launch once it is decided
+func (s *approvalStore) remove(pid int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pending, pid)
+}
+
+// resolve delivers a decision for a waiting launch; only the first answer
+// counts, and false is returned when nothing is waiting
+func (s *approvalStore) resolve(pid int, decision approvalDecision) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ch, ok := s.pending[pid]
+	if !ok {
+		return false
+	}
+	delete(s.pending, pid)
+	ch <- decision
+	return true
+}
+
+// approvalAdmin returns the name of the user with uid when they may
+// approve unlocks
+func (d *Daemon) approvalAdmin(uid int) (string, bool) {
+	if uid < 0 {
+		return "", false
+	}
+	u, err := user.LookupId(strconv.Itoa(uid))
+	if err != nil {
+		return "", false
+	}
+	return u.Username, slices.Contains(d.config.Auth.ApprovalAdmins, u.Username)
+}
+
+// approvalClients returns the connected agents of approval admins
+func (d *Daemon) approvalClients() []*clientConn {
+	var admins []*clientConn
+	for _, client := range d.snapshotConnections() {
+		if _, ok := d.approvalAdmin(client.uid); ok {
+			admins = append(admins, client)
+		}
+	}
+	return admins
+}
+
+// approvalUnlock asks the administrators' agents to approve a locked
+// launch, holding it suspended until one answers. Without an answer in
+// time, or without an administrator connected, it is denied.
+func (d *Daemon) approvalUnlock(pid int, execPath string, displayName string) {
+	cmdLine := d.monitor.EventCmdLine(pid, execPath)
+	deny := func(reason string) {
+		d.logger.Infof("Denying %s (PID %d): %s", displayName, pid, reason)
+		if err := d.monitor.KillProcessTree(pid); err != nil {
+			d.logger.Errorf("Failed to terminate process %d: %v", pid, err)
+		}
+		d.broadcastDenied(pid, execPath, displayName, reason, cmdLine)
+	}
+
+	admins := d.approvalClients()
+	if len(admins) == 0 {
+		deny("no administrator is available to approve it")
+		return
+	}
+
+	timeout := time.Duration(d.config.Auth.ApprovalTimeout) * time.Second
+	if timeout <= 0 {
+		timeout = 120 * time.Second
+	}
+
+	requester := "unknown user"
+	if uid := processUID(pid); uid >= 0 {
+		requester = strconv.Itoa(uid)
+		if u, err := user.LookupId(requester); err == nil {
+			requester = u.Username
+		}
+	}
+
+	decision := d.approvals.add(pid)
+	defer d.approvals.remove(pid)
+
+	info := &monitor.ProcessInfo{
+		PID:     pid,
+		Command: execPath,
+		CmdLine: cmdLine,
+	}
+	attributeSession(info)
+	d.sendToApprovers(admins, ipc.Message{
+		Type:    ipc.MsgApprovalRequest,
+		Process: info,
+		AppName: displayName,
+		Data: map[string]interface{}{
+			"user":            requester,
+			"timeout_seconds": int(timeout.Seconds()),
+		},
+	})
+	d.logger.Infof("Asked %d administrator agent(s) to approve %s (PID %d) for %s", len(admins), displayName, pid, requester)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case answer := <-decision:
+		if !answer.approved {
+			deny(fmt.Sprintf("not approved by %s", answer.admin))
+			return
+		}
+		d.logger.Infof("%s approved %s (PID %d)", answer.admin, displayName, pid)
+		if err := d.monitor.ResumeProcess(pid); err != nil {
+			d.logger.Errorf("Failed to resume process %d: %v", pid, err)
+			return
+		}
+		d.status.recordGrant(d.grantFor(pid, "approval by "+answer.admin))
+		d.startGrace(pid)
+	case <-timer.C:
+		deny("no administrator approved it in time")
+	case <-d.stopCh:
+	}
+}
+
+// sendToApprovers sends an approval request to the administrators' agents,
+// numbered in the same sequence as broadcasts
+func (d *Daemon) sendToApprovers(admins []*clientConn, msg ipc.Message) {
+	d.broadcastMu.Lock()
+	defer d.broadcastMu.Unlock()
+
+	msg.Seq = d.seq.Next()
+	for _, client := range admins {
+		if err := client.send(msg); err != nil {
+			d.logger.Debugf("Failed to send message to client: %v", err)
+			client.conn.Close()
+			d.removeConnection(client.conn)
+		}
+	}
+}
+
+// handleApprovalResponse applies an administrator's answer to a launch
+// waiting for approval
+func (d *Daemon) handleApprovalResponse(client *clientConn, msg ipc.Message) {
+	admin, ok := d.approvalAdmin(client.uid)
+	if !ok {
+		d.replyError(client, msg.Type, ipc.NewErrorDetail(ipc.ErrCodeNotAuthorized,
+			"only approval admins may answer approval requests"))
+		return
+	}
+
+	if !d.approvals.resolve(msg.PID, approvalDecision{approved: msg.Success, admin: admin}) {
+		d.replyError(client, msg.Type, ipc.NewErrorDetail(ipc.ErrCodeUnknownPID,
+			fmt.Sprintf("no launch is waiting for approval with PID %d", msg.PID)))
+	}
+}
+
+// handleApprovalRequest asks the administrator at this agent to approve a
+// launch and sends the answer back; no answer before the daemon's timeout
+// leaves the launch denied
+func (c *Client) handleApprovalRequest(msg ipc.Message) {
+	if msg.Process == nil {
+		c.logger.Error("Received approval request with nil process info")
+		return
+	}
+
+	requester, _ := msg.Data["user"].(string)
+	timeout := 120 * time.Second
+	if seconds, ok := msg.Data["timeout_seconds"].(float64); ok && seconds > 0 {
+		timeout = time.Duration(seconds) * time.Second
+	}
+	displayName := msg.AppName
+	if displayName == "" {
+		displayName = msg.Process.Command
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		approved, err := gui.ConfirmApproval(ctx, displayName, requester)
+		if err != nil {
+			c.logger.Errorf("Failed to ask for approval of %s: %v", displayName, err)
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		c.logger.Infof("Approval of %s (PID %d) for %s: %v", displayName, msg.Process.PID, requester, approved)
+		if err := c.sendMessage(ipc.Message{
+			Type:    ipc.MsgApprovalResponse,
+			PID:     msg.Process.PID,
+			Success: approved,
+		}); err != nil {
+			c.logger.Errorf("Failed to send approval: %v", err)
+		}
+	}()
+}
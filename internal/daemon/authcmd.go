@@ -0,0 +1,62 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"os/user"
+	"strconv"
+
+	"wyrmlock/internal/authcmd"
+	"wyrmlock/internal/config"
+)
+
+// commandUnlock has the configured auth command decide a locked launch. A
+// command that times out counts as denied; when it cannot decide the
+// client prompts instead.
+func (d *Daemon) commandUnlock(pid int, execPath string, displayName string) {
+	req := authcmd.Request{
+		PID:         pid,
+		Executable:  execPath,
+		DisplayName: displayName,
+		CmdLine:     d.monitor.EventCmdLine(pid, execPath),
+		UID:         processUID(pid),
+	}
+	if req.UID >= 0 {
+		if u, err := user.LookupId(strconv.Itoa(req.UID)); err == nil {
+			req.User = u.Username
+		}
+	}
+
+	result, err := d.authCommand.Decide(context.Background(), req)
+	if errors.Is(err, authcmd.ErrUndecided) {
+		d.logger.Warnf("Auth command could not decide %s (PID %d), prompting in the client: %v", displayName, pid, err)
+		d.promptClients(pid, execPath, displayName)
+		return
+	}
+
+	if err == nil && result.Allowed {
+		d.logger.Infof("Auth command allowed %s (PID %d): %s", displayName, pid, result.Reason)
+		if err := d.monitor.ResumeProcess(pid); err != nil {
+			d.logger.Errorf("Failed to resume process %d: %v", pid, err)
+			return
+		}
+		d.status.recordGrant(d.grantFor(pid, config.AuthModeCommand))
+		d.startGrace(pid)
+		return
+	}
+
+	reason := result.Reason
+	switch {
+	case err != nil:
+		reason = "auth command timed out"
+	case reason == "":
+		reason = "denied by auth command"
+	}
+	d.logger.Infof("Denying %s (PID %d): %s", displayName, pid, reason)
+
+	cmdLine := d.monitor.EventCmdLine(pid, execPath)
+	if err := d.monitor.KillProcessTree(pid); err != nil {
+		d.logger.Errorf("Failed to terminate process %d: %v", pid, err)
+	}
+	d.broadcastDenied(pid, execPath, displayName, reason, cmdLine)
+}
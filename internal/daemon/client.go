@@ -9,10 +9,13 @@ import (
 
 	"wyrmlock/internal/auth"
 	"wyrmlock/internal/config"
+	"wyrmlock/internal/fido2"
 	"wyrmlock/internal/gui"
+	"wyrmlock/internal/i18n"
 	"wyrmlock/internal/ipc"
 	"wyrmlock/internal/logging"
 	"wyrmlock/internal/monitor"
+	"wyrmlock/internal/secure"
 	"wyrmlock/internal/util"
 )
 
@@ -28,6 +31,13 @@ type Client struct {
 	stopCh          chan struct{}
 	mu              sync.Mutex
 	shutdownHandler *util.ShutdownHandler
+	statusHandler   func(ipc.Message)
+	notifications   *gui.NotificationCenter
+	sequence        logging.SequenceChecker
+
+	// countdowns holds the apps whose lockout countdown is shown
+	countdowns  map[string]bool
+	countdownMu sync.Mutex
 }
 
 // NewClient creates a new client instance
@@ -48,7 +58,11 @@ func NewClient(config *config.Config, authenticator *auth.Authenticator) (*Clien
 		gui:           gui,
 		logger:        logger,
 		stopCh:        make(chan struct{}),
+		countdowns:    make(map[string]bool),
 	}
+
+	// Merge bursts of denials into summaries instead of one popup each
+	client.notifications = newNotificationCenter(config, logger)
 	
 	// Initialize shutdown handler
 	client.shutdownHandler = util.NewShutdownHandler(logger, 5*time.Second)
@@ -61,6 +75,21 @@ func NewClient(config *config.Config, authenticator *auth.Authenticator) (*Clien
 	return client, nil
 }
 
+// newNotificationCenter creates the client's notification center from the
+// notification settings
+func newNotificationCenter(cfg *config.Config, logger *logging.Logger) *gui.NotificationCenter {
+	center := gui.NewNotificationCenter(
+		gui.SendNotification,
+		time.Duration(cfg.Notifications.Window)*time.Second,
+		cfg.Notifications.RateLimits,
+		config.DefaultNotificationRateLimit,
+	)
+	center.SetErrorHandler(func(err error) {
+		logger.Debugf("Failed to show notification: %v", err)
+	})
+	return center
+}
+
 // Connect establishes a connection to the daemon
 func (c *Client) Connect() error {
 	c.mu.Lock()
@@ -104,13 +133,43 @@ func (c *Client) handleMessages() {
 				return
 			}
 
+			c.checkSequence(msg)
+
 			switch msg.Type {
 			case ipc.MsgProcessEvent:
 				c.handleProcessEvent(msg)
 			case ipc.MsgAuthRequest:
 				c.handleAuthRequest(msg)
+			case ipc.MsgProcessDenied:
+				c.handleProcessDenied(msg)
+			case ipc.MsgUsageWarning:
+				c.handleUsageWarning(msg)
+			case ipc.MsgProcessAudit:
+				c.handleProcessAudit(msg)
+			case ipc.MsgAuthResult:
+				c.storeToken(msg)
+			case ipc.MsgOverride:
+				c.handleOverride(msg)
+			case ipc.MsgLockout:
+				c.handleLockout(msg)
+			case ipc.MsgApprovalRequest:
+				c.handleApprovalRequest(msg)
+			case ipc.MsgFingerprintPrompt:
+				c.handleFingerprintPrompt(msg)
+			case ipc.MsgError:
+				c.handleErrorReply(msg)
+			case ipc.MsgStatusResponse:
+				c.mu.Lock()
+				handler := c.statusHandler
+				c.mu.Unlock()
+				if handler != nil {
+					handler(msg)
+				}
 			case ipc.MsgPing:
-				c.encoder.Encode(ipc.Message{Type: ipc.MsgPong})
+				// Answer daemon heartbeats so idle clients stay connected
+				if err := c.sendMessage(ipc.Message{Type: ipc.MsgPong}); err != nil {
+					c.logger.Debugf("Failed to answer heartbeat: %v", err)
+				}
 			}
 		}
 	}
@@ -123,9 +182,11 @@ func (c *Client) handleProcessEvent(msg ipc.Message) {
 		return
 	}
 
-	c.gui.ShowAuthDialog(msg.Process.Command, func(password string) {
-		c.sendAuthResponse(msg.Process.PID, password)
-	})
+	if c.denyIfLockedOut(msg) || c.denyIfNotApproved(msg) {
+		return
+	}
+
+	c.promptUnlock(msg)
 }
 
 // handleAuthRequest processes an authentication request
@@ -135,26 +196,210 @@ func (c *Client) handleAuthRequest(msg ipc.Message) {
 		return
 	}
 
-	c.gui.ShowAuthDialog(msg.Process.Command, func(password string) {
-		c.sendAuthResponse(msg.Process.PID, password)
+	if c.denyIfLockedOut(msg) || c.denyIfNotApproved(msg) {
+		return
+	}
+
+	c.promptUnlock(msg)
+}
+
+// promptUnlock asks the user to unlock a launch with the password, the
+// security key or both, as configured; fingerprints are verified by the
+// daemon before it prompts
+func (c *Client) promptUnlock(msg ipc.Message) {
+	// Launches within the grace period of an earlier unlock need no prompt
+	if c.unlockWithToken(msg) {
+		return
+	}
+
+	displayName := msg.Process.PromptName(msg.Process.Command)
+	path := msg.Process.Target()
+	switch {
+	case c.config.Auth.FIDO2 == config.FIDO2Primary:
+		go c.unlockWithSecurityKey(msg.Process.PID, displayName, path, msg.Challenge)
+	case msg.Fingerprint:
+		go c.completeFingerprint(msg.Process.PID, displayName, msg.Challenge)
+	default:
+		c.promptPassword(msg.Process.PID, displayName, path, msg.Challenge)
+	}
+}
+
+// promptPassword shows the password dialog, followed by the security key
+// when it is the second factor unless a recovery or override code was
+// entered
+func (c *Client) promptPassword(pid int, displayName, path string, challenge []byte) {
+	c.gui.ShowAuthDialog(displayName, path, func(password *secure.Buffer) {
+		// Recovery and override codes stand in for a lost security key
+		var assertion *fido2.Assertion
+		if !auth.IsRecoveryCode(password.Bytes()) && !auth.IsOverrideCode(password.Bytes()) {
+			var err error
+			if assertion, err = c.securityKeyAssertion(displayName, challenge); err != nil {
+				password.Destroy()
+				c.sendSecurityKeyDenial(pid, err)
+				return
+			}
+		}
+		c.sendAuthResponse(pid, password, assertion)
 	})
 }
 
-// sendAuthResponse sends an authentication response to the daemon
-func (c *Client) sendAuthResponse(pid int, password string) {
+// denyIfLockedOut refuses a launch without prompting while the app is in
+// auth lockout, notifying the user how long the lockout has left
+func (c *Client) denyIfLockedOut(msg ipc.Message) bool {
+	if c.authenticator == nil {
+		return false
+	}
+
+	locked, remaining := c.authenticator.LockoutStatus(msg.Process.Command)
+	if !locked {
+		return false
+	}
+
+	displayName := msg.AppName
+	if displayName == "" {
+		displayName = msg.Process.Command
+	}
+	c.logger.Infof("Denying %s (PID %d): locked out", displayName, msg.Process.PID)
+
+	if err := c.sendMessage(ipc.Message{
+		Type:    ipc.MsgAuthResponse,
+		PID:     msg.Process.PID,
+		Success: false,
+		ErrorDetail: &ipc.ErrorDetail{
+			Code:       ipc.ErrCodeLockedOut,
+			Message:    monitor.LockoutMessage(displayName, remaining),
+			Retryable:  true,
+			RetryAfter: int(remaining.Seconds()),
+		},
+	}); err != nil {
+		c.logger.Errorf("Failed to send lockout denial: %v", err)
+	}
+
+	c.showLockout(displayName, time.Now().Add(remaining))
+	return true
+}
+
+// denyIfNotApproved asks the user to approve a changed executable before
+// prompting for authentication, refusing the launch when they do not
+func (c *Client) denyIfNotApproved(msg ipc.Message) bool {
+	if !msg.Process.NewBinary {
+		return false
+	}
+
+	displayName := msg.Process.PromptName(msg.Process.Command)
+	approved, err := gui.ConfirmBinaryChange(displayName, msg.Process.PinHash)
+	if err != nil {
+		c.logger.Errorf("Failed to confirm new version of %s: %v", displayName, err)
+	}
+	if approved {
+		return false
+	}
+
+	c.logger.Infof("Denying %s (PID %d): new version not approved", displayName, msg.Process.PID)
+	if err := c.sendMessage(ipc.Message{
+		Type:    ipc.MsgAuthResponse,
+		PID:     msg.Process.PID,
+		Success: false,
+		ErrorDetail: &ipc.ErrorDetail{
+			Code:    ipc.ErrCodeBinaryRejected,
+			Message: fmt.Sprintf("new version of %s was not approved", displayName),
+		},
+	}); err != nil {
+		c.logger.Errorf("Failed to send denial: %v", err)
+	}
+	return true
+}
+
+// handleProcessDenied notifies the user about a launch denied by policy
+func (c *Client) handleProcessDenied(msg ipc.Message) {
+	displayName := msg.AppName
+	if displayName == "" && msg.Process != nil {
+		displayName = msg.Process.Command
+	}
+
+	c.logger.Infof("Launch of %s denied: %s", displayName, msg.Error)
+	c.notifications.Notify(config.NotifyClassDenied, i18n.T("Access denied"), fmt.Sprintf("%s: %s", displayName, msg.Error))
+}
+
+// handleUsageWarning warns the user that an app is about to reach its daily
+// time budget
+func (c *Client) handleUsageWarning(msg ipc.Message) {
+	remaining := 0
+	if seconds, ok := msg.Data["remaining_seconds"].(float64); ok {
+		remaining = int(seconds)
+	}
+
+	c.logger.Infof("%s has %ds of its daily time left", msg.AppName, remaining)
+	c.notifications.Notify(config.NotifyClassUsage, i18n.T("Time almost up"),
+		monitor.UsageWarningMessage(msg.AppName, time.Duration(remaining)*time.Second))
+}
+
+// checkSequence warns when broadcast events were missed, e.g. while the
+// client was disconnected, or when the daemon's sequence went backwards
+func (c *Client) checkSequence(msg ipc.Message) {
+	gap, found, reset := c.sequence.Observe(msg.Seq)
+	if found {
+		c.logger.Warnf("Missed %d daemon events after event %d", gap.Missing, gap.After)
+	}
+	if reset {
+		c.logger.Warnf("Daemon event sequence went backwards to %d; its sequence state may have been lost", msg.Seq)
+	}
+}
+
+// handleProcessAudit logs a launch of an audit-only app; nothing was enforced
+func (c *Client) handleProcessAudit(msg ipc.Message) {
+	action, _ := msg.Data["action"].(string)
+	reason, _ := msg.Data["reason"].(string)
+	c.logger.Infof("Audit only: %s would be %s (%s)", msg.AppName, action, reason)
+}
+
+// handleErrorReply reports a structured error returned by the daemon
+func (c *Client) handleErrorReply(msg ipc.Message) {
+	err := msg.Err()
+	if err == nil {
+		return
+	}
+
+	request, _ := msg.Data["request"].(string)
+	if msg.ErrorDetail != nil && msg.ErrorDetail.Retryable {
+		c.logger.Warnf("Daemon rejected %s (retryable): %v", request, err)
+		return
+	}
+	c.logger.Errorf("Daemon rejected %s: %v", request, err)
+}
+
+// sendAuthResponse sends an authentication response to the daemon, with
+// the security key's assertion when it is the second factor
+func (c *Client) sendAuthResponse(pid int, password *secure.Buffer, assertion *fido2.Assertion) {
+	// The JSON protocol needs the password as a string; this is the only
+	// copy outside locked memory and lives only until the message is sent
 	msg := ipc.Message{
-		Type:     ipc.MsgAuthResponse,
-		Password: password,
+		Type:      ipc.MsgAuthResponse,
+		Password:  string(password.Bytes()),
+		Assertion: assertion,
 		Process: &monitor.ProcessInfo{
 			PID:     pid,
 			Command: "", // We don't need to send this back
 		},
 	}
-	if err := c.encoder.Encode(msg); err != nil {
+	if err := c.sendMessage(msg); err != nil {
 		c.logger.Errorf("Failed to send auth response: %v", err)
 	}
 }
 
+// SetStatusHandler registers a callback for status responses from the daemon
+func (c *Client) SetStatusHandler(handler func(ipc.Message)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.statusHandler = handler
+}
+
+// RecentNotifications returns the events behind recent notifications,
+// including those merged into summaries, for a details view
+func (c *Client) RecentNotifications() []gui.NotificationEvent {
+	return c.notifications.Recent()
+}
+
 // sendMessage sends a message to the daemon
 func (c *Client) sendMessage(msg ipc.Message) error {
 	c.mu.Lock()
@@ -192,6 +437,9 @@ func (c *Client) Stop() error {
 		close(c.stopCh)
 	}
 
+	// Deliver summaries still waiting for their window to close
+	c.notifications.Flush()
+
 	if c.conn != nil {
 		// Best effort to notify daemon, ignore errors
 		_ = c.encoder.Encode(ipc.Message{Type: ipc.MsgShutdown})
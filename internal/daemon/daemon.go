@@ -1,17 +1,29 @@
 package daemon
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"os"
+	"sync"
+	"syscall"
 	"time"
 
+	"wyrmlock/internal/auth"
+	"wyrmlock/internal/authcmd"
+	"wyrmlock/internal/authz"
+	"wyrmlock/internal/bluetooth"
 	"wyrmlock/internal/config"
 	"wyrmlock/internal/ipc"
 	"wyrmlock/internal/logging"
 	"wyrmlock/internal/monitor"
+	"wyrmlock/internal/polkit"
 	"wyrmlock/internal/privilege"
+	"wyrmlock/internal/secretservice"
+	"wyrmlock/internal/session"
+	"wyrmlock/internal/token"
 	"wyrmlock/internal/util"
 )
 
@@ -21,12 +33,69 @@ type Daemon struct {
 	monitor         *monitor.ProcessMonitor
 	socket          net.Listener
 	logger          *logging.Logger
-	connections     map[net.Conn]struct{}
+	connections     map[net.Conn]*clientConn
+	connMu          sync.Mutex
 	stopCh          chan struct{}
 	shutdownHandler *util.ShutdownHandler
 	privManager     *privilege.PrivilegeManager
 	helperClient    *privilege.HelperClient
 	opHandler       *privilege.OperationHandler
+	authz           *authz.Client
+	status          *statusTracker
+	grace           *graceStore
+	session         *session.Watcher
+	polkit          *polkit.Authority
+
+	// authenticator checks passwords sent by clients against the secret
+	// of the launching user; nil when no secret is readable. authMu
+	// guards it, as a reload may replace it.
+	authenticator *auth.Authenticator
+	authMu        sync.RWMutex
+
+	// tokens signs the session tokens clients keep in the kernel keyring;
+	// nil when session tokens are off
+	tokens *token.Issuer
+
+	// overrideJournal records emergency overrides; nil when the override
+	// is disabled
+	overrideJournal *logging.Journal
+	override        overrideWindow
+
+	// proximity finds the owner's Bluetooth device, whose presence
+	// unlocks launches; nil when proximity unlocks are off
+	proximity *bluetooth.Detector
+
+	// authCommand runs the site's program that decides unlocks in the
+	// command mode; nil in other modes
+	authCommand *authcmd.Runner
+
+	// approvals holds launches waiting for an administrator in the
+	// approval mode
+	approvals *approvalStore
+
+	// challenges holds the security key challenge sent with each prompt
+	challenges *challengeStore
+
+	// fingerprints holds launches whose finger matched while they wait
+	// for the security key
+	fingerprints *fingerprintStore
+
+	// keyringHelper reads users' desktop keyrings once the daemon no
+	// longer may switch users; nil when desktop keyrings are off
+	keyringHelper *secretservice.Helper
+
+	// idleTimers re-lock apps once a session stays idle
+	idleTimers map[string]*time.Timer
+	idleMu     sync.Mutex
+
+	// startedAt is when this run started, recorded in the state file so
+	// the next start can tell a clean stop from a crash
+	startedAt time.Time
+
+	// Sequence numbers stamped on broadcast events; broadcastMu keeps them
+	// in order on the wire
+	seq         *logging.Sequence
+	broadcastMu sync.Mutex
 }
 
 // NewDaemon creates a new privileged daemon
@@ -55,15 +124,96 @@ func NewDaemon(cfg *config.Config) (*Daemon, error) {
 		return nil, fmt.Errorf("failed to create process monitor: %w", err)
 	}
 
+	// Optional external authorization service
+	var authzClient *authz.Client
+	if cfg.Authorization.Enabled {
+		authzClient, err = authz.NewClient(cfg.Authorization, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create authorization client: %w", err)
+		}
+	}
+
+	// Let polkit decide unlocks when configured; without polkit the
+	// client prompts as usual
+	var authority *polkit.Authority
+	if cfg.Auth.Mode == config.AuthModePolkit {
+		authority, err = polkit.NewAuthority()
+		if err != nil {
+			logger.Warnf("polkit unavailable, prompting in the client instead: %v", err)
+		}
+	}
+
+	// Passwords are checked here rather than trusted from the client, so
+	// each launching user's own secret can be selected
+	authenticator, err := auth.NewAuthenticator(cfg)
+	if err != nil {
+		logger.Warnf("Passwords cannot be verified, password unlocks will be denied: %v", err)
+		authenticator = nil
+	}
+
+	// Session tokens let an unlock outlive client and daemon restarts
+	var tokens *token.Issuer
+	if cfg.Auth.SessionTokens {
+		tokens, err = token.Open(cfg.Auth.TokenKeyFile)
+		if err != nil {
+			logger.Warnf("Session tokens disabled: %v", err)
+			tokens = nil
+		}
+	}
+
+	// The emergency override is only available while it can be recorded
+	var overrideJournal *logging.Journal
+	if cfg.Auth.OverrideDuration > 0 && cfg.Auth.OverrideCodeFile != "" {
+		overrideJournal, err = logging.OpenJournal(cfg.Auth.OverrideJournal)
+		if err != nil {
+			logger.Warnf("Emergency override disabled: %v", err)
+			overrideJournal = nil
+		}
+	}
+
+	var proximity *bluetooth.Detector
+	if cfg.Auth.BluetoothDevice != "" {
+		proximity = bluetooth.NewDetector(cfg.Auth.BluetoothDevice, time.Duration(cfg.Auth.BluetoothTimeout)*time.Second)
+	}
+
+	var authCommand *authcmd.Runner
+	if cfg.Auth.Mode == config.AuthModeCommand {
+		authCommand, err = authcmd.NewRunner(cfg.Auth.AuthCommand, time.Duration(cfg.Auth.AuthCommandTimeout)*time.Second)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up auth command: %w", err)
+		}
+	}
+
+	// Broadcast events are numbered across restarts so clients can detect
+	// missed events
+	seq, err := logging.OpenSequence(cfg.Monitor.SequenceFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event sequence: %w", err)
+	}
+
 	daemon := &Daemon{
-		config:       cfg,
-		monitor:      monitor,
-		logger:       logger,
-		connections:  make(map[net.Conn]struct{}),
-		stopCh:       make(chan struct{}),
-		privManager:  privManager,
-		helperClient: helperClient,
-		opHandler:    opHandler,
+		config:          cfg,
+		monitor:         monitor,
+		logger:          logger,
+		connections:     make(map[net.Conn]*clientConn),
+		stopCh:          make(chan struct{}),
+		privManager:     privManager,
+		helperClient:    helperClient,
+		opHandler:       opHandler,
+		authz:           authzClient,
+		polkit:          authority,
+		authenticator:   authenticator,
+		tokens:          tokens,
+		overrideJournal: overrideJournal,
+		proximity:       proximity,
+		authCommand:     authCommand,
+		approvals:       newApprovalStore(),
+		challenges:      newChallengeStore(),
+		fingerprints:    newFingerprintStore(),
+		status:          newStatusTracker(),
+		idleTimers:      make(map[string]*time.Timer),
+		grace:           newGraceStore(cfg),
+		seq:             seq,
 	}
 
 	// Create shutdown handler
@@ -74,14 +224,22 @@ func NewDaemon(cfg *config.Config) (*Daemon, error) {
 		return daemon.Stop()
 	})
 
-	// Register process cleanup function that ensures no suspended processes remain
-	daemon.shutdownHandler.RegisterShutdownFunc(util.CheckProcessesBeforeExit)
+	// Register process cleanup function that ensures no suspended processes
+	// remain, unless protection is configured to fail closed
+	if cfg.Monitor.ShutdownAction == "" || cfg.Monitor.ShutdownAction == config.ShutdownActionNone {
+		daemon.shutdownHandler.RegisterShutdownFunc(util.CheckProcessesBeforeExit)
+	}
 
 	return daemon, nil
 }
 
 // Start begins the daemon and listens for client connections
 func (d *Daemon) Start() error {
+	// Refuse to run (or complain loudly) when our own files were tampered with
+	if err := d.checkIntegrity(); err != nil {
+		return err
+	}
+
 	// Check if socket creation requires privileges
 	requiresPrivilege, _ := d.privManager.IsOperationPrivileged(string(privilege.OpSocketCreation))
 	
@@ -137,6 +295,9 @@ func (d *Daemon) Start() error {
 	d.config.SocketPath = socketPath
 	d.logger.Debugf("Daemon socket created at %s", socketPath)
 	
+	// Report any protection gap left by the previous run
+	d.recordStart()
+
 	// Start process monitor
 	monitorResp, err := d.opHandler.ExecuteOperation(privilege.OperationRequest{
 		Type: privilege.OpMonitoring,
@@ -155,17 +316,34 @@ func (d *Daemon) Start() error {
 		return fmt.Errorf("failed to start monitor: %s", errMsg)
 	}
 
+	// Users' desktop keyrings are read by a helper that keeps the
+	// capabilities to switch to them, which the daemon gives up next
+	d.startKeyringHelper()
+
 	// Drop privileges while maintaining required capabilities
 	if err := d.privManager.DropPrivileges(); err != nil {
 		return fmt.Errorf("failed to drop privileges: %w", err)
 	}
 
+	// Without CAP_DAC_OVERRIDE the freezer may no longer reach the
+	// cgroups of user sessions
+	d.monitor.RecheckFreezer()
+
+	// Restrict the daemon to the system calls it needs from here on
+	d.installSeccomp()
+
+	// End grace periods when the desktop session locks
+	d.watchSession()
+
 	// Begin handling shutdown signals
 	d.shutdownHandler.HandleShutdown()
 
 	// Accept and handle client connections
 	go d.acceptConnections()
 
+	// Let systemd supervise the daemon
+	d.startWatchdog()
+
 	d.logger.Info("Daemon started successfully")
 	return nil
 }
@@ -185,25 +363,32 @@ func (d *Daemon) acceptConnections() {
 		}
 
 		// Register connection
-		d.connections[conn] = struct{}{}
+		client := newClientConn(conn)
+		d.identifyClient(client)
+		d.connMu.Lock()
+		d.connections[conn] = client
+		d.connMu.Unlock()
 
 		// Handle client in a goroutine
-		go d.handleClient(conn)
+		go d.handleClient(client)
 	}
 }
 
-// handleClient processes messages from a connected client
-func (d *Daemon) handleClient(conn net.Conn) {
+// handleClient processes messages from a connected client.
+// Liveness is tracked by the heartbeat rather than a read deadline, so a
+// client that is idle but still answering pings stays connected.
+func (d *Daemon) handleClient(client *clientConn) {
+	conn := client.conn
+	done := make(chan struct{})
 	defer func() {
+		close(done)
 		conn.Close()
-		delete(d.connections, conn)
+		d.removeConnection(conn)
 	}()
 
-	decoder := json.NewDecoder(conn)
-	encoder := json.NewEncoder(conn)
+	go d.heartbeat(client, done)
 
-	// Set a read deadline to prevent hanging
-	conn.SetReadDeadline(time.Now().Add(30 * time.Second))
+	decoder := json.NewDecoder(conn)
 
 	for {
 		var msg ipc.Message
@@ -212,74 +397,460 @@ func (d *Daemon) handleClient(conn net.Conn) {
 			return
 		}
 
-		// Reset read deadline for active connections
-		conn.SetReadDeadline(time.Now().Add(30 * time.Second))
+		client.markSeen()
 
 		switch msg.Type {
 		case ipc.MsgPing:
 			// Respond to ping
-			encoder.Encode(ipc.Message{
+			client.send(ipc.Message{
 				Type: ipc.MsgPong,
 			})
 
+		case ipc.MsgPong:
+			// Client answered a heartbeat
+			client.markPong()
+
 		case ipc.MsgAuthResponse:
 			// Client is responding to an auth request
-			if msg.Success {
-				// Auth successful, resume the process
-				if err := d.monitor.ResumeProcess(msg.PID); err != nil {
-					d.logger.Errorf("Failed to resume process %d: %v", msg.PID, err)
-				}
-			} else {
-				// Auth failed, terminate the process
-				if err := d.monitor.TerminateProcess(msg.PID); err != nil {
-					d.logger.Errorf("Failed to terminate process %d: %v", msg.PID, err)
-				}
-			}
+			d.handleAuthResponse(client, msg)
+
+		case ipc.MsgStatusRequest:
+			// Report grants, quotas and lockouts
+			d.handleStatusRequest(client)
+
+		case ipc.MsgQuotaReset:
+			// Administrator resets launch quotas
+			d.handleQuotaReset(client, msg)
+
+		case ipc.MsgReloadSecret:
+			// Administrator changed the unlock secret
+			d.handleReloadSecret(client, msg)
+
+		case ipc.MsgApprovalResponse:
+			// Administrator answered an approval request
+			d.handleApprovalResponse(client, msg)
 
 		case ipc.MsgShutdown:
-			// Client requested shutdown
-			d.logger.Info("Shutdown requested by client")
-			d.Stop()
+			// Administrator stops the daemon
+			if d.handleShutdown(client, msg) {
+				return
+			}
+
+		default:
+			d.replyError(client, msg.Type, ipc.NewErrorDetail(ipc.ErrCodeUnknownCommand,
+				fmt.Sprintf("unsupported message type: %s", msg.Type)))
+		}
+	}
+}
+
+// handleShutdown stops the daemon on behalf of root and reports whether it
+// did. The socket is world-connectable, so the peer's credentials are
+// checked.
+func (d *Daemon) handleShutdown(client *clientConn, msg ipc.Message) bool {
+	uid, err := peerUID(client.conn)
+	if err != nil {
+		d.logger.Warnf("Rejecting shutdown request: failed to read peer credentials: %v", err)
+	} else if uid != 0 {
+		d.logger.Warnf("Rejecting shutdown request from UID %d: not root", uid)
+	}
+	if err != nil || uid != 0 {
+		d.replyError(client, msg.Type, ipc.NewErrorDetail(ipc.ErrCodeNotAuthorized,
+			"shutting down the daemon requires root"))
+		return false
+	}
+
+	d.logger.Info("Shutdown requested by root")
+	d.Stop()
+	return true
+}
+
+// handleAuthResponse applies a client's answer to a prompt to a suspended
+// process. Only credentials verified here unlock it; a client can deny a
+// launch but not vouch for one.
+func (d *Daemon) handleAuthResponse(client *clientConn, msg ipc.Message) {
+	pid := msg.PID
+	if pid == 0 && msg.Process != nil {
+		pid = msg.Process.PID
+	}
+
+	if pid <= 0 {
+		d.replyError(client, msg.Type, ipc.NewErrorDetail(ipc.ErrCodeInvalidRequest, "missing PID"))
+		return
+	}
+
+	if !d.monitor.IsMonitored(pid) {
+		d.replyError(client, msg.Type, ipc.NewErrorDetail(ipc.ErrCodeUnknownPID,
+			fmt.Sprintf("process %d is not awaiting authentication", pid)))
+		return
+	}
+
+	// Other users' agents must not answer for, or deny, someone's launch
+	if !d.answersFor(client, pid) {
+		d.logger.Warnf("Rejecting auth response for PID %d from UID %d: not its agent", pid, client.uid)
+		d.replyError(client, msg.Type, ipc.NewErrorDetail(ipc.ErrCodeNotAuthorized,
+			fmt.Sprintf("not allowed to answer for process %d", pid)))
+		return
+	}
+
+	// Every response uses up the security key challenge of its prompt and
+	// the finger matched before it
+	keyVerified := d.checkSecurityKey(pid, msg.Assertion)
+	fingerprint := d.fingerprints.take(pid)
+
+	// A session token stands in for the password within the grace
+	// period; a rejected one is not a failed unlock, the user is asked
+	grantedBy := "password"
+	if msg.Token != "" {
+		if !d.verifyToken(pid, msg.Token) {
+			d.promptAfterToken(pid)
 			return
 		}
+		msg.Success = true
+		grantedBy = "session token"
+	} else if d.tryOverride(pid, msg.Password) {
+		msg.Success = true
+		grantedBy = "emergency override"
+	} else if msg.Password != "" {
+		// A password is verified against the launching user's secret
+		msg.Success = d.passwordAccepted(pid, msg.Password, keyVerified)
+	} else if msg.Assertion != nil {
+		// The key alone unlocks as the primary factor; as the second it
+		// completes the finger the daemon matched
+		primary := d.config.Auth.FIDO2 == config.FIDO2Primary
+		msg.Success = keyVerified && (primary || fingerprint)
+		grantedBy = "security key"
+		if fingerprint {
+			grantedBy = "fingerprint and security key"
+		}
+	} else {
+		// Without a credential the response is a denial, whatever the
+		// client claims
+		msg.Success = false
+	}
+
+	// A failure that locked the app out tells the client for how long
+	if !msg.Success {
+		if lockout, locked := d.lockoutFor(pid); locked {
+			d.status.recordLockout(lockout.App, lockout.Until)
+			d.sendToOwner(d.lockoutMessage(pid, lockout))
+		}
+	}
+
+	result := ipc.Message{
+		Type:    ipc.MsgAuthResult,
+		PID:     pid,
+		Success: true,
+	}
+
+	if msg.Success {
+		// Auth successful, resume the process
+		if err := d.monitor.ResumeProcess(pid); err != nil {
+			d.logger.Errorf("Failed to resume process %d: %v", pid, err)
+			code := ipc.ErrCodeProcessControl
+			if errors.Is(err, monitor.ErrUnsafeEnvironment) {
+				code = ipc.ErrCodeUnsafeEnvironment
+			} else if errors.Is(err, monitor.ErrSignatureInvalid) {
+				code = ipc.ErrCodeSignatureInvalid
+			} else if errors.Is(err, monitor.ErrExecutableChanged) {
+				code = ipc.ErrCodeExecutableChanged
+			}
+			d.replyError(client, msg.Type, ipc.NewErrorDetail(code, err.Error()))
+			return
+		}
+		d.status.recordGrant(d.grantFor(pid, grantedBy))
+		d.startGrace(pid)
+
+		// Hand the client a token to keep for the grace period; a token
+		// that was accepted is handed back so the client keeps it
+		result.Token = msg.Token
+		if result.Token == "" {
+			result.Token = d.issueToken(pid)
+		}
+		if result.Token != "" {
+			result.Process = d.tokenProcess(pid)
+		}
+	} else {
+		// Auth failed, kill the process and anything it already forked
+		if err := d.monitor.KillProcessTree(pid); err != nil {
+			d.logger.Errorf("Failed to terminate process %d: %v", pid, err)
+			d.replyError(client, msg.Type, ipc.NewErrorDetail(ipc.ErrCodeProcessControl, err.Error()))
+			return
+		}
+	}
+
+	client.send(result)
+}
+
+// replyError sends a structured error reply to a client
+func (d *Daemon) replyError(client *clientConn, request ipc.MessageType, detail *ipc.ErrorDetail) {
+	d.logger.Debugf("Replying to %s with error: %v", request, detail)
+	if err := client.send(ipc.NewErrorReply(request, detail)); err != nil {
+		d.logger.Debugf("Failed to send error reply: %v", err)
 	}
 }
 
 // RegisterProcessEventHandler registers a callback for process events
 func (d *Daemon) RegisterProcessEventHandler() {
 	d.monitor.RegisterEventHandler(func(pid int, execPath string, displayName string) {
-		// Create process event message
-		msg := ipc.Message{
-			Type: ipc.MsgProcessEvent,
-			Process: &monitor.ProcessInfo{
-				PID:     pid,
-				Command: execPath,
-				Allowed: false,
-			},
-			AppName: displayName,
-		}
-
-		// Broadcast to all clients
-		d.broadcastMessage(msg)
+		// The emergency override lets everything through, recorded
+		if d.resumeInOverride(pid, displayName) {
+			return
+		}
+
+		// A changed binary is approved by the user, not by a grace period
+		// or policy
+		if tracked, _ := d.monitor.GetProcess(pid); tracked.NewBinary {
+			d.promptClients(pid, execPath, displayName)
+			return
+		}
+
+		// Apps unlocked a moment ago run again without a prompt
+		if d.resumeInGrace(pid, displayName) {
+			return
+		}
+
+		// Delegate to the external authorization service when configured;
+		// run it asynchronously so the monitor is not blocked on the network
+		if d.authz != nil {
+			go d.authorizeLaunch(pid, execPath, displayName)
+			return
+		}
+
+		// Launches of a locked out app are denied without a prompt
+		if d.denyLockedOut(pid, displayName) {
+			return
+		}
+
+		d.requestUnlock(pid, execPath, displayName)
+	})
+
+	// Tell clients about launches denied by policy so they can notify the user
+	d.monitor.RegisterDeniedHandler(d.broadcastDenied)
+
+	// Warn clients before a screen-time budget runs out
+	d.monitor.RegisterUsageWarningHandler(d.broadcastUsageWarning)
+
+	// Report launches of audit-only apps
+	d.monitor.RegisterAuditHandler(d.broadcastAudit)
+}
+
+// promptClients asks connected clients to authenticate a launch
+func (d *Daemon) promptClients(pid int, execPath string, displayName string) {
+	tracked, _ := d.monitor.GetProcess(pid)
+
+	info := &monitor.ProcessInfo{
+		PID:     pid,
+		Command: execPath,
+		Allowed: false,
+		CmdLine: d.monitor.EventCmdLine(pid, execPath),
+		Warning: tracked.Warning,
+		Session: tracked.Session,
+		Seat:    tracked.Seat,
+		AppName: tracked.AppName,
+		Icon:    tracked.Icon,
+
+		PinHash:   tracked.PinHash,
+		NewBinary: tracked.NewBinary,
+	}
+	attributeSession(info)
+
+	// Create process event message
+	msg := ipc.Message{
+		Type:    ipc.MsgProcessEvent,
+		Process: info,
+		AppName: displayName,
+	}
+
+	// The security key signs a challenge only the daemon knows, so the
+	// client cannot vouch for a touch that did not happen
+	if d.usesSecurityKey() {
+		challenge, err := d.challenges.issue(pid, d.monitor.IsMonitored)
+		if err != nil {
+			d.logger.Errorf("Failed to create security key challenge for PID %d: %v", pid, err)
+		}
+		msg.Challenge = challenge
+		msg.Fingerprint = d.fingerprints.has(pid)
+	}
+
+	// Only the launching user's agent is asked
+	d.sendToOwner(msg)
+}
+
+// broadcastDenied tells clients a launch was denied without prompting
+func (d *Daemon) broadcastDenied(pid int, execPath string, displayName string, reason string, cmdLine string) {
+	info := &monitor.ProcessInfo{
+		PID:     pid,
+		Command: execPath,
+		Allowed: false,
+		CmdLine: cmdLine,
+	}
+	attributeSession(info)
+
+	d.broadcastMessage(ipc.Message{
+		Type:    ipc.MsgProcessDenied,
+		Process: info,
+		AppName: displayName,
+		Error:   reason,
 	})
 }
 
+// broadcastAudit tells clients about a launch that was only audited
+func (d *Daemon) broadcastAudit(pid int, execPath string, displayName string, action string, reason string, cmdLine string) {
+	info := &monitor.ProcessInfo{
+		PID:     pid,
+		Command: execPath,
+		Allowed: true,
+		CmdLine: cmdLine,
+	}
+	attributeSession(info)
+
+	d.broadcastMessage(ipc.Message{
+		Type:    ipc.MsgProcessAudit,
+		Process: info,
+		AppName: displayName,
+		Data: map[string]interface{}{
+			"action": action,
+			"reason": reason,
+		},
+	})
+}
+
+// broadcastUsageWarning tells clients an app will soon be stopped by its
+// daily time budget
+func (d *Daemon) broadcastUsageWarning(execPath string, displayName string, remaining time.Duration) {
+	d.broadcastMessage(ipc.Message{
+		Type:    ipc.MsgUsageWarning,
+		AppName: displayName,
+		Data: map[string]interface{}{
+			"app":               execPath,
+			"remaining_seconds": int(remaining.Seconds()),
+		},
+	})
+}
+
+// authorizeLaunch consults the external authorization service for a blocked launch
+func (d *Daemon) authorizeLaunch(pid int, execPath string, displayName string) {
+	req := authz.Request{
+		PID:         pid,
+		Executable:  execPath,
+		CmdLine:     d.monitor.EventCmdLine(pid, execPath),
+		DisplayName: displayName,
+		UID:         processUID(pid),
+		Timestamp:   time.Now(),
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		req.Hostname = hostname
+	}
+
+	resp := d.authz.Decide(context.Background(), req)
+	d.logger.Infof("Authorization service decided %s for %s (PID %d): %s", resp.Decision, execPath, pid, resp.Reason)
+
+	switch resp.Decision {
+	case authz.DecisionAllow:
+		if err := d.monitor.ResumeProcess(pid); err != nil {
+			d.logger.Errorf("Failed to resume process %d: %v", pid, err)
+			return
+		}
+		d.status.recordGrant(d.grantFor(pid, "authorization service"))
+	case authz.DecisionDeny:
+		cmdLine := d.monitor.EventCmdLine(pid, execPath)
+		if err := d.monitor.KillProcessTree(pid); err != nil {
+			d.logger.Errorf("Failed to terminate process %d: %v", pid, err)
+		}
+		reason := resp.Reason
+		if reason == "" {
+			reason = "denied by authorization service"
+		}
+		d.broadcastDenied(pid, execPath, displayName, reason, cmdLine)
+	default:
+		d.requestUnlock(pid, execPath, displayName)
+	}
+}
+
+// processUID returns the effective UID owning a process, or -1 if unknown
+func processUID(pid int) int {
+	info, err := os.Stat(fmt.Sprintf("/proc/%d", pid))
+	if err != nil {
+		return -1
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return int(stat.Uid)
+	}
+	return -1
+}
+
+// closeConnections closes all client connections and the socket
+func (d *Daemon) closeConnections() {
+	d.connMu.Lock()
+	for conn := range d.connections {
+		if err := conn.Close(); err != nil {
+			d.logger.Errorf("Error closing client connection: %v", err)
+		}
+	}
+	d.connections = make(map[net.Conn]*clientConn)
+	d.connMu.Unlock()
+
+	if d.socket != nil {
+		if err := d.socket.Close(); err != nil {
+			d.logger.Errorf("Error closing socket: %v", err)
+		}
+	}
+}
+
 // broadcastMessage sends a message to all connected clients
 func (d *Daemon) broadcastMessage(msg ipc.Message) {
-	for conn := range d.connections {
-		encoder := json.NewEncoder(conn)
-		if err := encoder.Encode(msg); err != nil {
+	d.broadcastMu.Lock()
+	defer d.broadcastMu.Unlock()
+
+	// Numbered even without clients, so a client that reconnects sees what
+	// it missed
+	msg.Seq = d.seq.Next()
+
+	for _, client := range d.snapshotConnections() {
+		if err := client.send(msg); err != nil {
 			d.logger.Debugf("Failed to send message to client: %v", err)
 			// Remove failed connection
-			conn.Close()
-			delete(d.connections, conn)
+			client.conn.Close()
+			d.removeConnection(client.conn)
 		}
 	}
 }
 
+// snapshotConnections returns the currently connected clients
+func (d *Daemon) snapshotConnections() []*clientConn {
+	d.connMu.Lock()
+	defer d.connMu.Unlock()
+
+	clients := make([]*clientConn, 0, len(d.connections))
+	for _, client := range d.connections {
+		clients = append(clients, client)
+	}
+	return clients
+}
+
+// removeConnection forgets a client connection
+func (d *Daemon) removeConnection(conn net.Conn) {
+	d.connMu.Lock()
+	delete(d.connections, conn)
+	d.connMu.Unlock()
+}
+
 // Stop gracefully shuts down the daemon
 func (d *Daemon) Stop() error {
 	close(d.stopCh)
+	_ = notifySystemd("STOPPING=1")
+
+	if d.session != nil {
+		if err := d.session.Close(); err != nil {
+			d.logger.Debugf("Error closing session watcher: %v", err)
+		}
+	}
+	if d.polkit != nil {
+		if err := d.polkit.Close(); err != nil {
+			d.logger.Debugf("Error closing polkit connection: %v", err)
+		}
+	}
+	d.stopIdleTimers()
 
 	// Restore privileges for cleanup operations that require it
 	restoreResp, err := d.opHandler.ExecuteOperation(privilege.OperationRequest{
@@ -293,25 +864,24 @@ func (d *Daemon) Stop() error {
 		d.logger.Errorf("Error restoring privileges: %v", err)
 	}
 
-	// Stop the monitor
-	if err := d.monitor.Stop(); err != nil {
-		d.logger.Errorf("Error stopping monitor: %v", err)
+	// Fail closed: suspend or terminate protected apps that would otherwise
+	// keep running unprotected
+	if action := d.config.Monitor.ShutdownAction; action == config.ShutdownActionSuspend || action == config.ShutdownActionTerminate {
+		count := d.monitor.EnforceShutdown(action)
+		d.logger.Infof("Applied shutdown action %s to %d protected processes", action, count)
 	}
+	d.recordStop()
 
-	// Close all client connections
-	for conn := range d.connections {
-		if err := conn.Close(); err != nil {
-			d.logger.Errorf("Error closing client connection: %v", err)
-		}
+	if err := d.seq.Close(); err != nil {
+		d.logger.Errorf("Error saving event sequence: %v", err)
 	}
-	d.connections = make(map[net.Conn]struct{})
 
-	// Close the socket
-	if d.socket != nil {
-		if err := d.socket.Close(); err != nil {
-			d.logger.Errorf("Error closing socket: %v", err)
-		}
+	// Stop the monitor
+	if err := d.monitor.Stop(); err != nil {
+		d.logger.Errorf("Error stopping monitor: %v", err)
 	}
+
+	d.closeConnections()
 	
 	// Disconnect from helper if connected
 	if d.helperClient != nil {
@@ -320,6 +890,13 @@ func (d *Daemon) Stop() error {
 		}
 	}
 	
+	if d.keyringHelper != nil {
+		secretservice.UseHelper(nil)
+		if err := d.keyringHelper.Close(); err != nil {
+			d.logger.Debugf("Error stopping keyring helper: %v", err)
+		}
+	}
+
 	// Stop the operation handler's helper process if it was started
 	if d.opHandler != nil {
 		if err := d.opHandler.StopHelperProcess(); err != nil {
@@ -0,0 +1,192 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"wyrmlock/internal/auth"
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/fido2"
+	"wyrmlock/internal/gui"
+	"wyrmlock/internal/i18n"
+	"wyrmlock/internal/ipc"
+	"wyrmlock/internal/secure"
+)
+
+// securityKeyTimeout bounds the wait for a touch when no dialog timeout is
+// configured
+const securityKeyTimeout = 60 * time.Second
+
+// challengeStore holds the security key challenge sent with each prompt;
+// a challenge is good for one response
+type challengeStore struct {
+	mu      sync.Mutex
+	pending map[int][]byte
+}
+
+// newChallengeStore creates an empty challenge store
+func newChallengeStore() *challengeStore {
+	return &challengeStore{pending: make(map[int][]byte)}
+}
+
+// issue creates the challenge for a prompt for pid, dropping those of
+// launches no longer waiting
+func (s *challengeStore) issue(pid int, waiting func(int) bool) ([]byte, error) {
+	challenge, err := fido2.NewChallenge()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for other := range s.pending {
+		if !waiting(other) {
+			delete(s.pending, other)
+		}
+	}
+	s.pending[pid] = challenge
+	return challenge, nil
+}
+
+// take returns and forgets the challenge issued for pid
+func (s *challengeStore) take(pid int) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	challenge := s.pending[pid]
+	delete(s.pending, pid)
+	return challenge
+}
+
+// usesSecurityKey reports whether unlocks involve a FIDO2 key
+func (d *Daemon) usesSecurityKey() bool {
+	return d.config.Auth.FIDO2 != "" && d.config.Auth.FIDO2 != config.FIDO2Off
+}
+
+// checkSecurityKey verifies the assertion a client sent for pid against
+// the challenge sent with its prompt and the registered credentials. The
+// challenge is used up either way.
+func (d *Daemon) checkSecurityKey(pid int, assertion *fido2.Assertion) bool {
+	challenge := d.challenges.take(pid)
+	if assertion == nil || !d.usesSecurityKey() {
+		return false
+	}
+	if challenge == nil {
+		d.logger.Warnf("Rejecting security key for PID %d: no challenge was issued", pid)
+		return false
+	}
+	if err := fido2.CheckRegistered(d.config.Auth.FIDO2CredentialFile, challenge, *assertion); err != nil {
+		d.logger.Warnf("Rejecting security key for PID %d: %v", pid, err)
+		return false
+	}
+	return true
+}
+
+// passwordAccepted checks a password sent for pid under the security key
+// setting: as the second factor the key must have been verified too, and
+// as the primary factor only a recovery code is taken in its place.
+// Recovery codes stand in for a lost key.
+func (d *Daemon) passwordAccepted(pid int, password string, keyVerified bool) bool {
+	recovery := auth.IsRecoveryCode([]byte(password))
+	switch d.config.Auth.FIDO2 {
+	case config.FIDO2Primary:
+		if !recovery {
+			d.logger.Infof("Denying PID %d: the security key unlocks, not the password", pid)
+			return false
+		}
+	case config.FIDO2SecondFactor:
+		if !recovery && !keyVerified {
+			d.logger.Infof("Denying PID %d: password sent without the security key", pid)
+			return false
+		}
+	}
+	return d.verifyPassword(pid, password)
+}
+
+// securityKeyAssertion asks the user to touch a registered FIDO2 key and
+// returns its signature over the daemon's challenge. It returns nil when
+// security keys are not used.
+func (c *Client) securityKeyAssertion(displayName string, challenge []byte) (*fido2.Assertion, error) {
+	authCfg := c.config.Auth
+	if authCfg.FIDO2 == "" || authCfg.FIDO2 == config.FIDO2Off {
+		return nil, nil
+	}
+	if len(challenge) == 0 {
+		return nil, fmt.Errorf("the daemon sent no security key challenge")
+	}
+
+	store, err := fido2.Load(authCfg.FIDO2CredentialFile)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := securityKeyTimeout
+	if authCfg.DialogTimeout > 0 {
+		timeout = time.Duration(authCfg.DialogTimeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// The prompt is not rate limited like event notifications
+	if err := gui.SendNotification("wyrmlock",
+		i18n.T("Touch your security key to unlock %s", displayName)); err != nil {
+		c.logger.Debugf("Failed to send security key notification: %v", err)
+	}
+
+	c.logger.Infof("Waiting for security key to unlock %s", displayName)
+	assertion, err := fido2.Assert(ctx, authCfg.FIDO2Device, store.Credentials, challenge)
+	if err != nil {
+		return nil, err
+	}
+	return &assertion, nil
+}
+
+// unlockWithSecurityKey unlocks a launch with a touch of the security key
+// alone, offering a recovery code when the key fails
+func (c *Client) unlockWithSecurityKey(pid int, displayName, path string, challenge []byte) {
+	assertion, err := c.securityKeyAssertion(displayName, challenge)
+	if err != nil {
+		c.promptRecoveryCode(pid, displayName, path, err)
+		return
+	}
+
+	c.logger.Infof("Security key touched, unlocking %s (PID %d)", displayName, pid)
+	if err := c.sendMessage(ipc.Message{
+		Type:      ipc.MsgAuthResponse,
+		PID:       pid,
+		Assertion: assertion,
+	}); err != nil {
+		c.logger.Errorf("Failed to send auth response: %v", err)
+	}
+}
+
+// promptRecoveryCode asks for a recovery or emergency override code in
+// place of a security key that replaces the password but failed; anything
+// else is refused
+func (c *Client) promptRecoveryCode(pid int, displayName, path string, keyErr error) {
+	c.logger.Infof("Security key check for PID %d failed, offering a recovery code: %v", pid, keyErr)
+	c.gui.ShowAuthDialog(displayName+" (recovery code)", path, func(code *secure.Buffer) {
+		if !auth.IsRecoveryCode(code.Bytes()) && !auth.IsOverrideCode(code.Bytes()) {
+			c.sendSecurityKeyDenial(pid, keyErr)
+			return
+		}
+		c.sendAuthResponse(pid, code, nil)
+	})
+}
+
+// sendSecurityKeyDenial refuses a launch whose security key check failed
+func (c *Client) sendSecurityKeyDenial(pid int, err error) {
+	c.logger.Warnf("Security key check for PID %d failed: %v", pid, err)
+	if sendErr := c.sendMessage(ipc.Message{
+		Type:    ipc.MsgAuthResponse,
+		PID:     pid,
+		Success: false,
+		ErrorDetail: &ipc.ErrorDetail{
+			Code:    ipc.ErrCodeAuthFailed,
+			Message: fmt.Sprintf("security key not verified: %v", err),
+		},
+	}); sendErr != nil {
+		c.logger.Errorf("Failed to send denial: %v", sendErr)
+	}
+}
@@ -0,0 +1,157 @@
+package daemon
+
+import (
+	"context"
+	"os/user"
+	"strconv"
+	"sync"
+	"time"
+
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/fprintd"
+	"wyrmlock/internal/gui"
+	"wyrmlock/internal/i18n"
+	"wyrmlock/internal/ipc"
+	"wyrmlock/internal/monitor"
+)
+
+// fingerprintTimeout bounds the wait for a finger when no dialog timeout
+// is configured
+const fingerprintTimeout = 60 * time.Second
+
+// fingerprintStore remembers the launches whose finger matched while they
+// wait for the security key as the second factor
+type fingerprintStore struct {
+	mu      sync.Mutex
+	matched map[int]bool
+}
+
+// newFingerprintStore creates an empty fingerprint store
+func newFingerprintStore() *fingerprintStore {
+	return &fingerprintStore{matched: make(map[int]bool)}
+}
+
+// add records a matched finger for pid, dropping those of launches no
+// longer waiting
+func (s *fingerprintStore) add(pid int, waiting func(int) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for other := range s.matched {
+		if !waiting(other) {
+			delete(s.matched, other)
+		}
+	}
+	s.matched[pid] = true
+}
+
+// has reports whether a finger matched for pid
+func (s *fingerprintStore) has(pid int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.matched[pid]
+}
+
+// take reports and forgets whether a finger matched for pid
+func (s *fingerprintStore) take(pid int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	matched := s.matched[pid]
+	delete(s.matched, pid)
+	return matched
+}
+
+// usesFingerprint reports whether launches are unlocked with a finger; a
+// security key that replaces the password takes precedence
+func (d *Daemon) usesFingerprint() bool {
+	return d.config.Auth.Fingerprint && d.config.Auth.FIDO2 != config.FIDO2Primary
+}
+
+// fingerprintUnlock has fprintd verify a finger of the user who launched
+// pid, so a client cannot claim a match that did not happen. A match
+// resumes the launch, or asks for the security key when it is the second
+// factor; without a reader or on a mismatch the client prompts for the
+// password.
+func (d *Daemon) fingerprintUnlock(pid int, execPath string, displayName string) {
+	info, ok := d.monitor.GetProcess(pid)
+	if !ok {
+		return
+	}
+	uid := processUID(pid)
+	u, err := user.LookupId(strconv.Itoa(uid))
+	if err != nil {
+		d.logger.Infof("Cannot look up the user of PID %d, prompting for the password: %v", pid, err)
+		d.promptClients(pid, execPath, displayName)
+		return
+	}
+
+	timeout := fingerprintTimeout
+	if d.config.Auth.DialogTimeout > 0 {
+		timeout = time.Duration(d.config.Auth.DialogTimeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// The launching user's agent tells them to touch the reader
+	process := &monitor.ProcessInfo{PID: pid, Command: info.Command, Session: info.Session, Seat: info.Seat}
+	attributeSession(process)
+	d.sendToOwner(ipc.Message{
+		Type:    ipc.MsgFingerprintPrompt,
+		Process: process,
+		AppName: displayName,
+	})
+
+	matched, err := fprintd.Verify(ctx, u.Username)
+	if err != nil || !matched {
+		if err != nil {
+			d.logger.Infof("Fingerprint unlock of %s unavailable, prompting for the password: %v", displayName, err)
+		} else {
+			d.logger.Infof("Fingerprint not recognized for %s, prompting for the password", displayName)
+		}
+		d.promptClients(pid, execPath, displayName)
+		return
+	}
+
+	if d.config.Auth.FIDO2 == config.FIDO2SecondFactor {
+		d.logger.Infof("Fingerprint verified for %s (PID %d), waiting for the security key", displayName, pid)
+		d.fingerprints.add(pid, d.monitor.IsMonitored)
+		d.promptClients(pid, execPath, displayName)
+		return
+	}
+
+	d.logger.Infof("Fingerprint verified, unlocking %s (PID %d)", displayName, pid)
+	if err := d.monitor.ResumeProcess(pid); err != nil {
+		d.logger.Errorf("Failed to resume process %d: %v", pid, err)
+		return
+	}
+	d.status.recordGrant(d.grantFor(pid, "fingerprint"))
+	d.startGrace(pid)
+}
+
+// handleFingerprintPrompt tells the user to touch the fingerprint reader
+// for a launch the daemon is verifying
+func (c *Client) handleFingerprintPrompt(msg ipc.Message) {
+	// The prompt is not rate limited like event notifications
+	if err := gui.SendNotification("wyrmlock",
+		i18n.T("Touch the fingerprint reader to unlock %s", msg.AppName)); err != nil {
+		c.logger.Debugf("Failed to send fingerprint notification: %v", err)
+	}
+}
+
+// completeFingerprint answers a prompt whose finger the daemon already
+// verified with the security key, the second factor
+func (c *Client) completeFingerprint(pid int, displayName string, challenge []byte) {
+	assertion, err := c.securityKeyAssertion(displayName, challenge)
+	if err != nil {
+		c.sendSecurityKeyDenial(pid, err)
+		return
+	}
+
+	c.logger.Infof("Security key touched, unlocking %s (PID %d)", displayName, pid)
+	if err := c.sendMessage(ipc.Message{
+		Type:      ipc.MsgAuthResponse,
+		PID:       pid,
+		Assertion: assertion,
+	}); err != nil {
+		c.logger.Errorf("Failed to send auth response: %v", err)
+	}
+}
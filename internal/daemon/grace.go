@@ -0,0 +1,136 @@
+package daemon
+
+import (
+	"sync"
+	"time"
+
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/monitor"
+)
+
+// graceKey identifies an unlocked executable and who unlocked it; a changed
+// binary at the same path must be unlocked again, and an unlock by one user
+// or in one session does not carry over to others
+type graceKey struct {
+	app     string
+	hash    string
+	uid     int
+	session string
+}
+
+// graceStore remembers recent unlocks so an app can be launched again
+// without prompting until its grace period expires
+type graceStore struct {
+	mu      sync.Mutex
+	period  time.Duration
+	periods map[string]time.Duration
+	unlocks map[graceKey]time.Time
+}
+
+// newGraceStore creates a grace store from the global and per-app grace
+// periods; a period of 0 disables it
+func newGraceStore(cfg *config.Config) *graceStore {
+	s := &graceStore{
+		period:  time.Duration(cfg.Auth.GracePeriod) * time.Minute,
+		periods: make(map[string]time.Duration),
+		unlocks: make(map[graceKey]time.Time),
+	}
+	for _, app := range cfg.BlockedApps {
+		if app.GracePeriod > 0 {
+			s.periods[app.Path] = time.Duration(app.GracePeriod) * time.Minute
+		}
+	}
+	return s
+}
+
+// periodFor returns the grace period of app
+func (s *graceStore) periodFor(app string) time.Duration {
+	if period, ok := s.periods[app]; ok {
+		return period
+	}
+	return s.period
+}
+
+// grant starts the grace period for an executable unlocked at now
+func (s *graceStore) grant(key graceKey, now time.Time) {
+	period := s.periodFor(key.app)
+	if period <= 0 || key.hash == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.unlocks[key] = now.Add(period)
+}
+
+// active returns when the grace period of an executable ends, if it is
+// still running at now
+func (s *graceStore) active(key graceKey, now time.Time) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expires, ok := s.unlocks[key]
+	if !ok {
+		return time.Time{}, false
+	}
+	if !now.Before(expires) {
+		delete(s.unlocks, key)
+		return time.Time{}, false
+	}
+	return expires, true
+}
+
+// revokeAll ends every grace period and returns how many were running
+func (s *graceStore) revokeAll() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := len(s.unlocks)
+	s.unlocks = make(map[graceKey]time.Time)
+	return count
+}
+
+// graceKeyFor returns the grace key of a launch, bound to the launching
+// user and their session like session tokens are bound to the user
+func (d *Daemon) graceKeyFor(pid int) (graceKey, bool) {
+	info, ok := d.monitor.GetProcess(pid)
+	if !ok {
+		return graceKey{}, false
+	}
+	uid := processUID(pid)
+	if uid < 0 {
+		return graceKey{}, false
+	}
+	process := &monitor.ProcessInfo{PID: pid, Session: info.Session}
+	attributeSession(process)
+	return graceKey{app: info.Target(), hash: info.ExecHash, uid: uid, session: process.Session}, true
+}
+
+// startGrace starts the grace period of the app a process was unlocked for
+func (d *Daemon) startGrace(pid int) {
+	if key, ok := d.graceKeyFor(pid); ok {
+		d.grace.grant(key, time.Now())
+	}
+}
+
+// resumeInGrace resumes a launch of an app unlocked within its grace period
+// instead of prompting, and reports whether it did
+func (d *Daemon) resumeInGrace(pid int, displayName string) bool {
+	key, ok := d.graceKeyFor(pid)
+	if !ok {
+		return false
+	}
+	expires, ok := d.grace.active(key, time.Now())
+	if !ok {
+		return false
+	}
+
+	d.logger.Infof("%s was unlocked recently, resuming PID %d without prompting (grace period ends at %s)",
+		displayName, pid, expires.Format("15:04:05"))
+	if err := d.monitor.ResumeProcess(pid); err != nil {
+		d.logger.Errorf("Failed to resume process %d: %v", pid, err)
+		return true
+	}
+	d.status.recordGrant(d.grantFor(pid, "grace period"))
+	return true
+}
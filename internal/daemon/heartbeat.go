@@ -0,0 +1,138 @@
+package daemon
+
+import (
+	"encoding/json"
+	"net"
+	"sync"
+	"time"
+
+	"wyrmlock/internal/ipc"
+)
+
+const (
+	// HeartbeatInterval is how often the daemon pings each connected client
+	HeartbeatInterval = 10 * time.Second
+
+	// HeartbeatMaxMissed is the number of consecutive unanswered pings after
+	// which a client is considered dead and disconnected
+	HeartbeatMaxMissed = 3
+
+	// clientWriteTimeout bounds a single write to a client so a stuck peer
+	// cannot block the daemon
+	clientWriteTimeout = 5 * time.Second
+)
+
+// clientConn tracks a connected client and its liveness state.
+// A client that is quiet but still answers pings is kept; only a client that
+// misses HeartbeatMaxMissed consecutive pings is treated as dead. Any message
+// received after a ping counts as an answer, so a busy client whose pong is
+// queued behind other traffic is not dropped.
+type clientConn struct {
+	conn    net.Conn
+	encoder *json.Encoder
+	writeMu sync.Mutex
+
+	// uid and session identify the user agent on the other end, so prompts
+	// reach only the launching user; uid is -1 when unknown
+	uid     int
+	session string
+
+	stateMu     sync.Mutex
+	lastSeen    time.Time
+	lastPing    time.Time
+	lastPong    time.Time
+	pingPending bool
+	missedPings int
+}
+
+// newClientConn wraps a client connection
+func newClientConn(conn net.Conn) *clientConn {
+	now := time.Now()
+	return &clientConn{
+		conn:     conn,
+		encoder:  json.NewEncoder(conn),
+		uid:      -1,
+		lastSeen: now,
+		lastPong: now,
+	}
+}
+
+// send writes a message to the client, serialising concurrent writers
+func (c *clientConn) send(msg ipc.Message) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.conn.SetWriteDeadline(time.Now().Add(clientWriteTimeout))
+	return c.encoder.Encode(msg)
+}
+
+// markSeen records that data was received from the client
+func (c *clientConn) markSeen() {
+	c.stateMu.Lock()
+	c.lastSeen = time.Now()
+	c.stateMu.Unlock()
+}
+
+// markPong records a pong reply and clears the missed ping counter
+func (c *clientConn) markPong() {
+	c.stateMu.Lock()
+	now := time.Now()
+	c.lastSeen = now
+	c.lastPong = now
+	c.pingPending = false
+	c.missedPings = 0
+	c.stateMu.Unlock()
+}
+
+// nextPing accounts for the previous ping and reports whether the client is
+// still considered alive
+func (c *clientConn) nextPing() bool {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+
+	if c.pingPending {
+		if c.lastSeen.Before(c.lastPing) {
+			c.missedPings++
+		} else {
+			c.missedPings = 0
+		}
+	}
+	if c.missedPings >= HeartbeatMaxMissed {
+		return false
+	}
+	c.pingPending = true
+	c.lastPing = time.Now()
+	return true
+}
+
+// heartbeat pings the client until it disconnects, the daemon stops, or the
+// client misses too many pings in a row
+func (d *Daemon) heartbeat(client *clientConn, done <-chan struct{}) {
+	ticker := time.NewTicker(HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.stopCh:
+			return
+		case <-done:
+			return
+		case <-ticker.C:
+			if !client.nextPing() {
+				client.stateMu.Lock()
+				lastPong := client.lastPong
+				client.stateMu.Unlock()
+				d.logger.Infof("Client missed %d heartbeats (last pong %s ago), disconnecting",
+					HeartbeatMaxMissed, time.Since(lastPong).Round(time.Second))
+				client.conn.Close()
+				return
+			}
+
+			if err := client.send(ipc.Message{Type: ipc.MsgPing}); err != nil {
+				d.logger.Debugf("Failed to send heartbeat to client: %v", err)
+				client.conn.Close()
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/integrity"
+	"wyrmlock/internal/logging"
+	"wyrmlock/internal/monitor"
+	"wyrmlock/internal/privilege"
+)
+
+// checkIntegrity compares the daemon binary, the privileged helper and the
+// configuration with the hashes sealed at install time. Mismatches are
+// logged as security events and, with the refuse action, stop the daemon
+// from starting.
+func (d *Daemon) checkIntegrity() error {
+	action := d.config.Monitor.IntegrityAction
+	if action == config.IntegrityActionOff || d.config.Monitor.SealFile == "" {
+		return nil
+	}
+	sealFile := d.config.Monitor.SealFile
+
+	var problems []string
+	seal, err := integrity.Load(sealFile)
+	if errors.Is(err, os.ErrNotExist) {
+		if action != config.IntegrityActionRefuse {
+			d.logger.Warnf("No integrity seal at %s; run \"wyrmlock seal\" after installing", sealFile)
+			return nil
+		}
+		problems = append(problems, fmt.Sprintf("no integrity seal at %s", sealFile))
+	} else if err != nil {
+		problems = append(problems, fmt.Sprintf("failed to read integrity seal: %v", err))
+	} else {
+		// A signed seal must carry a valid signature
+		if sigPath := sealFile + ".sig"; fileExists(sigPath) {
+			if _, err := monitor.VerifySignature(sealFile, sigPath, d.config.Monitor.TrustedSigners, d.config.Monitor.SignatureKeyring); err != nil {
+				problems = append(problems, fmt.Sprintf("integrity seal signature: %v", err))
+			}
+		}
+		for _, mismatch := range seal.Verify(integrity.Targets(d.config.ConfigFile, privilege.DefaultHelperBinary)) {
+			problems = append(problems, mismatch.String())
+		}
+	}
+
+	if len(problems) == 0 {
+		d.logger.Debugf("Daemon files match the integrity seal %s", sealFile)
+		return nil
+	}
+
+	for _, problem := range problems {
+		d.logger.Errorf("INTEGRITY CHECK FAILED: %s", problem)
+	}
+	if logging.SecurityLog != nil {
+		logging.SecurityLog.LogEvent(logging.EventIntegrityMismatch, "Daemon files do not match their integrity seal", map[string]interface{}{
+			"seal_file": sealFile,
+			"problems":  problems,
+			"action":    action,
+		})
+	}
+
+	if action == config.IntegrityActionRefuse {
+		return fmt.Errorf("integrity check failed: %s", problems[0])
+	}
+	return nil
+}
+
+// fileExists reports whether path exists
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
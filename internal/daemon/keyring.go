@@ -0,0 +1,29 @@
+package daemon
+
+import (
+	"os"
+
+	"wyrmlock/internal/secretservice"
+)
+
+// startKeyringHelper starts the helper that reads users' desktop keyrings
+// when they are enabled. Without it, the secrets in desktop keyrings are
+// ignored and users fall back to their other secrets.
+func (d *Daemon) startKeyringHelper() {
+	if !d.config.Auth.DesktopKeyring || os.Geteuid() != 0 {
+		return
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		d.logger.Warnf("Desktop keyrings unavailable: cannot find the wyrmlock binary: %v", err)
+		return
+	}
+	helper, err := secretservice.StartHelper(exe)
+	if err != nil {
+		d.logger.Warnf("Desktop keyrings unavailable: %v", err)
+		return
+	}
+	d.keyringHelper = helper
+	secretservice.UseHelper(helper)
+}
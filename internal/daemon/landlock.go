@@ -0,0 +1,122 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"golang.org/x/sys/unix"
+
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/landlock"
+	"wyrmlock/internal/logging"
+	"wyrmlock/internal/privilege"
+)
+
+// landlockEnv marks a daemon that was re-executed inside its Landlock domain
+const landlockEnv = "WYRMLOCK_LANDLOCKED"
+
+// ConfineFilesystem restricts the daemon with Landlock before it starts.
+// Landlock applies to a single thread, so the calling thread is confined and
+// then replaced with a fresh copy of the daemon, which inherits the domain
+// in every thread it creates; it returns in that copy. Reads stay open, as
+// the monitor hashes executables wherever they are launched from, but
+// writes are limited to /proc, cgroups, the socket, state and log paths and
+// execution to system directories. Kernels without Landlock fall back to
+// running unconfined with a warning.
+func ConfineFilesystem(cfg *config.Config, logger *logging.Logger) error {
+	if cfg.Monitor.Landlock == config.LandlockOff {
+		return nil
+	}
+	if os.Getenv(landlockEnv) == "1" {
+		os.Unsetenv(landlockEnv)
+		logger.Info("Filesystem access confined by Landlock")
+		return nil
+	}
+
+	abi := landlock.ABI()
+	if abi == 0 {
+		logger.Warn("Landlock unavailable: the daemon cannot restrict its own filesystem access")
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		logger.Warnf("Not confining the daemon with Landlock: %v", err)
+		return nil
+	}
+	rules := landlockRules(cfg, exe)
+
+	// The thread stays locked: it is either replaced by exec or confined
+	// while the rest of the process is not, and must not run other code
+	runtime.LockOSThread()
+	skipped, err := landlock.RestrictThread(rules)
+	if err != nil {
+		logger.Warnf("Failed to confine the daemon with Landlock, running unconfined: %v", err)
+		return nil
+	}
+	for _, path := range skipped {
+		logger.Debugf("Landlock rule skipped for missing %s", path)
+	}
+
+	logger.Debugf("Re-executing inside Landlock domain (ABI %d)", abi)
+	env := append(os.Environ(), landlockEnv+"=1")
+	if err := unix.Exec("/proc/self/exe", os.Args, env); err != nil {
+		return fmt.Errorf("failed to re-execute inside Landlock domain: %w", err)
+	}
+	return nil
+}
+
+// landlockRules lists what the daemon may access. Writable directories are
+// created first, since a rule can only cover a path that exists.
+func landlockRules(cfg *config.Config, exe string) []landlock.Rule {
+	readWrite := uint64(landlock.AccessRead | landlock.AccessWrite)
+	rules := []landlock.Rule{
+		{Path: "/", Access: landlock.AccessRead},
+		{Path: "/proc", Access: readWrite},
+		{Path: "/sys/fs/cgroup", Access: readWrite},
+		{Path: "/dev/null", Access: readWrite},
+		// The client socket lives in /var/run
+		{Path: "/var/run", Access: readWrite},
+		{Path: exe, Access: landlock.AccessRead | landlock.AccessExecute},
+		{Path: filepath.Dir(privilege.DefaultHelperBinary), Access: landlock.AccessRead | landlock.AccessExecute},
+	}
+
+	// gpgv, dpkg-query, rpm and their libraries
+	for _, dir := range []string{"/usr", "/bin", "/sbin", "/lib", "/lib64"} {
+		rules = append(rules, landlock.Rule{Path: dir, Access: landlock.AccessRead | landlock.AccessExecute})
+	}
+
+	// The auth command may live outside the system directories
+	if len(cfg.Auth.AuthCommand) > 0 && filepath.IsAbs(cfg.Auth.AuthCommand[0]) {
+		rules = append(rules, landlock.Rule{Path: filepath.Dir(cfg.Auth.AuthCommand[0]), Access: landlock.AccessRead | landlock.AccessExecute})
+	}
+
+	dirs := make(map[string]bool)
+	for _, file := range []string{
+		cfg.Monitor.StateFile,
+		cfg.Monitor.QuotaFile,
+		cfg.Monitor.UsageFile,
+		cfg.Monitor.PinFile,
+		cfg.Monitor.LedgerFile,
+		cfg.Monitor.SequenceFile,
+		// Used recovery codes are marked in place
+		cfg.Auth.RecoveryCodeFile,
+		cfg.Auth.OverrideJournal,
+		cfg.Auth.LockoutStateFile,
+	} {
+		if file != "" {
+			dirs[filepath.Dir(file)] = true
+		}
+	}
+	// The security log defaults to ~/.wyrmlock
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs[filepath.Join(home, ".wyrmlock")] = true
+	}
+	for dir := range dirs {
+		_ = os.MkdirAll(dir, 0755)
+		rules = append(rules, landlock.Rule{Path: dir, Access: readWrite})
+	}
+	return rules
+}
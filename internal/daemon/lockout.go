@@ -0,0 +1,133 @@
+package daemon
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/gui"
+	"wyrmlock/internal/i18n"
+	"wyrmlock/internal/ipc"
+	"wyrmlock/internal/logging"
+	"wyrmlock/internal/monitor"
+)
+
+// lockoutFor reports whether the app pid launched is locked out after
+// failed passwords, and until when
+func (d *Daemon) lockoutFor(pid int) (ipc.Lockout, bool) {
+	authenticator := d.currentAuthenticator()
+	if authenticator == nil {
+		return ipc.Lockout{}, false
+	}
+	info, ok := d.monitor.GetProcess(pid)
+	if !ok {
+		return ipc.Lockout{}, false
+	}
+
+	locked, remaining := authenticator.LockoutStatus(info.Target())
+	if !locked {
+		return ipc.Lockout{}, false
+	}
+	return ipc.Lockout{App: info.Target(), Until: time.Now().Add(remaining)}, true
+}
+
+// lockoutMessage builds the message telling a client that a launch was
+// denied by a lockout
+func (d *Daemon) lockoutMessage(pid int, lockout ipc.Lockout) ipc.Message {
+	displayName := filepath.Base(lockout.App)
+	info, ok := d.monitor.GetProcess(pid)
+	if ok && info.AppName != "" {
+		displayName = info.AppName
+	}
+	remaining := lockout.Remaining(time.Now())
+
+	process := &monitor.ProcessInfo{PID: pid, Command: info.Command, Session: info.Session, Seat: info.Seat}
+	attributeSession(process)
+
+	return ipc.Message{
+		Type:    ipc.MsgLockout,
+		PID:     pid,
+		Process: process,
+		AppName: displayName,
+		Lockout: &lockout,
+		ErrorDetail: &ipc.ErrorDetail{
+			Code:       ipc.ErrCodeLockedOut,
+			Message:    monitor.LockoutMessage(displayName, remaining),
+			Retryable:  true,
+			RetryAfter: int(remaining.Round(time.Second).Seconds()),
+		},
+	}
+}
+
+// denyLockedOut refuses a launch while its app is locked out, without
+// prompting, and tells the launching user's client how long is left
+func (d *Daemon) denyLockedOut(pid int, displayName string) bool {
+	lockout, locked := d.lockoutFor(pid)
+	if !locked {
+		return false
+	}
+
+	msg := d.lockoutMessage(pid, lockout)
+	d.logger.Infof("Denying %s (PID %d): %s", displayName, pid, msg.ErrorDetail.Message)
+	if logging.SecurityLog != nil {
+		logging.SecurityLog.LogProcessEvent(logging.EventProcessBlocked, lockout.App, pid, map[string]interface{}{
+			"reason":                    "locked after too many failed attempts",
+			"lockout_remaining_seconds": msg.ErrorDetail.RetryAfter,
+			"cmdline":                   d.monitor.EventCmdLine(pid, lockout.App),
+		})
+	}
+	d.status.recordLockout(lockout.App, lockout.Until)
+
+	// The owner is found through the process, so tell it before the kill
+	d.sendToOwner(msg)
+	if err := d.monitor.KillProcessTree(pid); err != nil {
+		d.logger.Errorf("Failed to terminate process %d: %v", pid, err)
+	}
+	return true
+}
+
+// handleLockout shows how long a lockout reported by the daemon has left,
+// counting down to its end
+func (c *Client) handleLockout(msg ipc.Message) {
+	if msg.Lockout == nil {
+		return
+	}
+	c.showLockout(msg.AppName, msg.Lockout.Until)
+}
+
+// showLockout counts down to the end of a lockout in a window, one per
+// app, falling back to a notification
+func (c *Client) showLockout(displayName string, until time.Time) {
+	c.countdownMu.Lock()
+	if c.countdowns[displayName] {
+		c.countdownMu.Unlock()
+		return
+	}
+	c.countdowns[displayName] = true
+	c.countdownMu.Unlock()
+
+	go func() {
+		defer func() {
+			c.countdownMu.Lock()
+			delete(c.countdowns, displayName)
+			c.countdownMu.Unlock()
+		}()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			select {
+			case <-c.stopCh:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		if err := gui.ShowLockoutCountdown(ctx, displayName, until); err != nil {
+			c.logger.Debugf("Failed to show lockout countdown: %v", err)
+			c.notifications.Notify(config.NotifyClassLockout, i18n.T("Access denied"),
+				monitor.LockoutMessage(displayName, time.Until(until)))
+		}
+	}()
+}
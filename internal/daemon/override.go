@@ -0,0 +1,210 @@
+package daemon
+
+import (
+	"fmt"
+	"os/user"
+	"strconv"
+	"sync"
+	"time"
+
+	"wyrmlock/internal/auth"
+	"wyrmlock/internal/gui"
+	"wyrmlock/internal/i18n"
+	"wyrmlock/internal/ipc"
+	"wyrmlock/internal/logging"
+	"wyrmlock/internal/monitor"
+)
+
+// overrideWindow is the emergency override currently running, if any
+type overrideWindow struct {
+	mu    sync.Mutex
+	until time.Time
+	timer *time.Timer
+}
+
+// active reports whether the override runs at now and until when
+func (w *overrideWindow) active(now time.Time) (time.Time, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.until, now.Before(w.until)
+}
+
+// tryOverride checks whether a password sent for a suspended launch is the
+// emergency override code and, when it is, unlocks every app until the
+// override ends. Every attempt is recorded; an override that cannot be
+// recorded is refused.
+func (d *Daemon) tryOverride(pid int, password string) bool {
+	authenticator := d.currentAuthenticator()
+	if d.overrideJournal == nil || authenticator == nil || !auth.IsOverrideCode([]byte(password)) {
+		return false
+	}
+
+	details := d.overrideDetails(pid)
+
+	// Child accounts of a parental-control profile cannot override
+	if authenticator.IsChild(processUID(pid)) {
+		if _, err := d.recordOverride("override_refused", "Emergency override refused for a child account", details); err != nil {
+			d.logger.Errorf("Failed to record refused emergency override: %v", err)
+		}
+		return false
+	}
+
+	ok, err := authenticator.CheckOverrideCode([]byte(password))
+	if err != nil || !ok {
+		if err != nil {
+			details["error"] = err.Error()
+		}
+		if _, err := d.recordOverride("override_refused", "Emergency override code rejected", details); err != nil {
+			d.logger.Errorf("Failed to record refused emergency override: %v", err)
+		}
+		return false
+	}
+
+	until := time.Now().Add(time.Duration(d.config.Auth.OverrideDuration) * time.Minute)
+	details["until"] = until.Format(time.RFC3339)
+	if _, err := d.recordOverride("override_activated",
+		fmt.Sprintf("Emergency override by %s, all apps unlocked until %s", details["user"], until.Format("15:04:05")),
+		details); err != nil {
+		d.logger.Errorf("Refusing emergency override that cannot be recorded: %v", err)
+		return false
+	}
+
+	d.override.mu.Lock()
+	d.override.until = until
+	if d.override.timer != nil {
+		d.override.timer.Stop()
+	}
+	d.override.timer = time.AfterFunc(time.Until(until), d.endOverride)
+	d.override.mu.Unlock()
+
+	d.broadcastOverride(details["user"], until)
+
+	// Everything else waiting for a prompt is let through too
+	go d.resumeAllSuspended(pid)
+	return true
+}
+
+// endOverride re-locks every app once the override has run out
+func (d *Daemon) endOverride() {
+	d.override.mu.Lock()
+	d.override.until = time.Time{}
+	d.override.timer = nil
+	d.override.mu.Unlock()
+
+	count := d.monitor.RelockAll()
+	if _, err := d.recordOverride("override_expired",
+		fmt.Sprintf("Emergency override ended, re-locked %d apps", count),
+		map[string]string{"relocked": strconv.Itoa(count)}); err != nil {
+		d.logger.Errorf("Failed to record the end of the emergency override: %v", err)
+	}
+	d.broadcastOverride("", time.Time{})
+}
+
+// resumeInOverride resumes a launch while the emergency override runs,
+// recording it, and reports whether it did
+func (d *Daemon) resumeInOverride(pid int, displayName string) bool {
+	if _, ok := d.override.active(time.Now()); !ok {
+		return false
+	}
+
+	if _, err := d.recordOverride("override_launch",
+		fmt.Sprintf("%s (PID %d) launched without authentication during the emergency override", displayName, pid),
+		d.overrideDetails(pid)); err != nil {
+		// Without a record the launch is prompted for as usual
+		d.logger.Errorf("Failed to record launch during emergency override: %v", err)
+		return false
+	}
+	if err := d.monitor.ResumeProcess(pid); err != nil {
+		d.logger.Errorf("Failed to resume process %d: %v", pid, err)
+		return true
+	}
+	d.status.recordGrant(d.grantFor(pid, "emergency override"))
+	return true
+}
+
+// resumeAllSuspended resumes every launch still waiting for a prompt,
+// except the one the override was entered for
+func (d *Daemon) resumeAllSuspended(except int) {
+	processes, err := d.monitor.PollProcesses()
+	if err != nil {
+		return
+	}
+	for _, process := range processes {
+		if process.PID == except || process.Allowed {
+			continue
+		}
+		if state, err := d.monitor.GetProcessState(process.PID); err != nil || state != monitor.ProcessStateSuspended {
+			continue
+		}
+		d.resumeInOverride(process.PID, process.PromptName(process.Command))
+	}
+}
+
+// recordOverride writes an override event to the journal and the security
+// log
+func (d *Daemon) recordOverride(event, message string, details map[string]string) (logging.JournalEntry, error) {
+	entry, err := d.overrideJournal.Append(event, details)
+
+	if logging.SecurityLog != nil {
+		logDetails := map[string]interface{}{"event": event}
+		for key, value := range details {
+			logDetails[key] = value
+		}
+		if err == nil {
+			// Ties the security log to the journal, so neither can be
+			// rewritten alone without it showing
+			logDetails["journal_seq"] = entry.Seq
+			logDetails["journal_hash"] = entry.Hash
+		}
+		logging.SecurityLog.LogEvent(logging.EventEmergencyOverride, message, logDetails)
+	}
+	return entry, err
+}
+
+// overrideDetails describes the launch an override event is about
+func (d *Daemon) overrideDetails(pid int) map[string]string {
+	details := map[string]string{"pid": strconv.Itoa(pid)}
+	if info, ok := d.monitor.GetProcess(pid); ok {
+		details["app"] = info.Target()
+	}
+	if uid := processUID(pid); uid >= 0 {
+		details["uid"] = strconv.Itoa(uid)
+		details["user"] = strconv.Itoa(uid)
+		if u, err := user.LookupId(details["uid"]); err == nil {
+			details["user"] = u.Username
+		}
+	}
+	return details
+}
+
+// broadcastOverride tells every client that an emergency override started
+// or, with a zero until, ended
+func (d *Daemon) broadcastOverride(username string, until time.Time) {
+	msg := ipc.Message{
+		Type: ipc.MsgOverride,
+		Data: map[string]interface{}{"user": username},
+	}
+	if !until.IsZero() {
+		msg.Data["until"] = until.Unix()
+	}
+	d.broadcastMessage(msg)
+}
+
+// handleOverride notifies the user of an emergency override. It bypasses
+// the notification rate limits so it is never suppressed.
+func (c *Client) handleOverride(msg ipc.Message) {
+	username, _ := msg.Data["user"].(string)
+	until, active := msg.Data["until"].(float64)
+
+	title := i18n.T("Emergency override ended")
+	body := i18n.T("All protected apps are locked again.")
+	if active {
+		title = i18n.T("Emergency override active")
+		body = i18n.T("%s unlocked all protected apps until %s. This has been recorded.",
+			username, time.Unix(int64(until), 0).Format("15:04"))
+		c.logger.Warnf("Emergency override by %s until %s", username, time.Unix(int64(until), 0).Format("15:04:05"))
+	}
+	if err := gui.SendNotification(title, body); err != nil {
+		c.logger.Debugf("Failed to send override notification: %v", err)
+	}
+}
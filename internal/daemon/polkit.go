@@ -0,0 +1,103 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/polkit"
+)
+
+// requestUnlock asks for a locked launch to be authenticated: by the
+// owner's Bluetooth device being in range when configured, then by polkit
+// the auth command or an administrator in those modes, otherwise by the
+// launching user's client
+func (d *Daemon) requestUnlock(pid int, execPath string, displayName string) {
+	// Paging the device takes a moment, so the monitor is not kept waiting
+	if d.proximity != nil {
+		go func() {
+			if !d.unlockByProximity(pid, displayName) {
+				d.askToUnlock(pid, execPath, displayName)
+			}
+		}()
+		return
+	}
+	d.askToUnlock(pid, execPath, displayName)
+}
+
+// askToUnlock has polkit, the auth command, an administrator, fprintd or
+// the launching user's client authenticate a locked launch
+func (d *Daemon) askToUnlock(pid int, execPath string, displayName string) {
+	if d.config.Auth.Mode == config.AuthModeApproval {
+		go d.approvalUnlock(pid, execPath, displayName)
+		return
+	}
+	if d.authCommand != nil {
+		go d.commandUnlock(pid, execPath, displayName)
+		return
+	}
+	if d.polkit != nil {
+		go d.polkitUnlock(pid, execPath, displayName)
+		return
+	}
+	if d.usesFingerprint() {
+		go d.fingerprintUnlock(pid, execPath, displayName)
+		return
+	}
+	d.promptClients(pid, execPath, displayName)
+}
+
+// polkitUnlock checks the unlock action for the launch itself, so the
+// polkit agent of the user's session prompts. An unanswered prompt counts
+// as denied; when polkit cannot be asked the client prompts instead.
+func (d *Daemon) polkitUnlock(pid int, execPath string, displayName string) {
+	subject, err := polkit.NewProcessSubject(pid)
+	if err != nil {
+		d.logger.Debugf("Process %d gone before the polkit check: %v", pid, err)
+		return
+	}
+
+	timeout := time.Duration(d.config.Auth.DialogTimeout) * time.Second
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	result, err := d.polkit.CheckAuthorization(ctx, subject, polkit.UnlockAction, map[string]string{
+		"app":        displayName,
+		"executable": execPath,
+	})
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		d.logger.Warnf("polkit check for %s (PID %d) failed, prompting in the client: %v", displayName, pid, err)
+		d.promptClients(pid, execPath, displayName)
+		return
+	}
+
+	if result.Authorized {
+		d.logger.Infof("polkit authorized %s (PID %d)", displayName, pid)
+		if err := d.monitor.ResumeProcess(pid); err != nil {
+			d.logger.Errorf("Failed to resume process %d: %v", pid, err)
+			return
+		}
+		d.status.recordGrant(d.grantFor(pid, config.AuthModePolkit))
+		d.startGrace(pid)
+		return
+	}
+
+	reason := "not authorized by polkit"
+	switch {
+	case err != nil:
+		reason = "polkit authentication timed out"
+	case result.Dismissed():
+		reason = "polkit authentication dismissed"
+	}
+	d.logger.Infof("Denying %s (PID %d): %s", displayName, pid, reason)
+
+	cmdLine := d.monitor.EventCmdLine(pid, execPath)
+	if err := d.monitor.KillProcessTree(pid); err != nil {
+		d.logger.Errorf("Failed to terminate process %d: %v", pid, err)
+	}
+	d.broadcastDenied(pid, execPath, displayName, reason, cmdLine)
+}
@@ -0,0 +1,47 @@
+package daemon
+
+import (
+	"context"
+
+	"wyrmlock/internal/logging"
+)
+
+// unlockByProximity resumes a launch without prompting when the owner's
+// paired Bluetooth device is in range. It reports false, so the password
+// is asked for, when the device is away or cannot be checked, or when a
+// child account launched it.
+func (d *Daemon) unlockByProximity(pid int, displayName string) bool {
+	// The device stands in for its owner, not for a parent
+	if _, child := d.config.ProfileFor(processUID(pid)); child {
+		return false
+	}
+
+	present, err := d.proximity.InRange(context.Background())
+	if err != nil {
+		d.logger.Warnf("Cannot check for Bluetooth device %s, prompting instead: %v", d.proximity.Address(), err)
+		return false
+	}
+	if !present {
+		d.logger.Debugf("Bluetooth device %s is not in range, prompting for %s", d.proximity.Address(), displayName)
+		return false
+	}
+
+	info, ok := d.monitor.GetProcess(pid)
+	if !ok {
+		return true
+	}
+	d.logger.Infof("Bluetooth device %s is in range, resuming %s (PID %d) without prompting",
+		d.proximity.Address(), displayName, pid)
+	if logging.SecurityLog != nil {
+		logging.SecurityLog.LogProcessEvent(logging.EventProcessAllowed, info.Target(), pid, map[string]interface{}{
+			"granted_by": "bluetooth proximity",
+			"device":     d.proximity.Address(),
+		})
+	}
+	if err := d.monitor.ResumeProcess(pid); err != nil {
+		d.logger.Errorf("Failed to resume process %d: %v", pid, err)
+		return true
+	}
+	d.status.recordGrant(d.grantFor(pid, "bluetooth proximity"))
+	return true
+}
@@ -0,0 +1,77 @@
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+
+	"wyrmlock/internal/ipc"
+)
+
+// handleQuotaReset resets launch quotas on behalf of root. The socket is
+// world-connectable, so the peer's credentials are checked.
+func (d *Daemon) handleQuotaReset(client *clientConn, msg ipc.Message) {
+	uid, err := peerUID(client.conn)
+	if err != nil || uid != 0 {
+		d.replyError(client, msg.Type, ipc.NewErrorDetail(ipc.ErrCodeNotAuthorized,
+			"resetting quotas requires root"))
+		return
+	}
+
+	if err := d.monitor.ResetLaunchQuota(msg.AppName); err != nil {
+		d.replyError(client, msg.Type, ipc.NewErrorDetail(ipc.ErrCodeInvalidRequest, err.Error()))
+		return
+	}
+
+	if err := client.send(ipc.Message{
+		Type:    ipc.MsgQuotaResetAck,
+		AppName: msg.AppName,
+		Success: true,
+	}); err != nil {
+		d.logger.Debugf("Failed to acknowledge quota reset: %v", err)
+	}
+}
+
+// peerUID returns the UID of the process on the other end of a unix socket
+func peerUID(conn net.Conn) (int, error) {
+	cred, err := peerCredentials(conn)
+	if err != nil {
+		return -1, err
+	}
+	return int(cred.Uid), nil
+}
+
+// peerCredentials returns the credentials of the process on the other end
+// of a unix socket, as recorded by the kernel when it connected
+func peerCredentials(conn net.Conn) (*syscall.Ucred, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return nil, fmt.Errorf("not a unix socket connection")
+	}
+
+	raw, err := unixConn.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+
+	var cred *syscall.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil {
+		return nil, err
+	}
+	if credErr != nil {
+		return nil, credErr
+	}
+
+	return cred, nil
+}
+
+// ResetQuota asks the daemon to reset today's launch quota for app, or for
+// every app when app is empty
+func ResetQuota(socketPath string, app string, timeout time.Duration) error {
+	_, err := roundTrip(socketPath, ipc.Message{Type: ipc.MsgQuotaReset, AppName: app}, ipc.MsgQuotaResetAck, timeout)
+	return err
+}
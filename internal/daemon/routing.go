@@ -0,0 +1,87 @@
+package daemon
+
+import (
+	"slices"
+
+	"wyrmlock/internal/ipc"
+	"wyrmlock/internal/monitor"
+	"wyrmlock/internal/session"
+)
+
+// identifyClient records the user and login session of a connecting agent
+// from its socket credentials, which the client cannot forge
+func (d *Daemon) identifyClient(client *clientConn) {
+	cred, err := peerCredentials(client.conn)
+	if err != nil {
+		d.logger.Debugf("Failed to identify client: %v", err)
+		return
+	}
+
+	client.uid = int(cred.Uid)
+	if s, err := session.Resolve(int(cred.Pid)); err == nil {
+		client.session = s.ID
+	}
+	d.logger.Debugf("Client connected for UID %d (session %q)", client.uid, client.session)
+}
+
+// ownerClients selects the agents that should prompt for a process: those in
+// its login session, otherwise those of its user. Root agents act for users
+// without an agent of their own.
+func (d *Daemon) ownerClients(info *monitor.ProcessInfo) []*clientConn {
+	uid := processUID(info.PID)
+
+	var bySession, byUser, admins []*clientConn
+	for _, client := range d.snapshotConnections() {
+		switch {
+		case info.Session != "" && client.session == info.Session:
+			bySession = append(bySession, client)
+		case uid >= 0 && client.uid == uid:
+			byUser = append(byUser, client)
+		case client.uid == 0:
+			admins = append(admins, client)
+		}
+	}
+
+	if len(bySession) > 0 {
+		return bySession
+	}
+	if len(byUser) > 0 {
+		return byUser
+	}
+	return admins
+}
+
+// answersFor reports whether client is one of the agents prompted for
+// pid, the only ones that may answer for it
+func (d *Daemon) answersFor(client *clientConn, pid int) bool {
+	info, ok := d.monitor.GetProcess(pid)
+	if !ok {
+		return false
+	}
+	process := &monitor.ProcessInfo{PID: pid, Session: info.Session}
+	attributeSession(process)
+	return slices.Contains(d.ownerClients(process), client)
+}
+
+// sendToOwner sends a process event to the launching user's agents only,
+// numbered in the same sequence as broadcasts
+func (d *Daemon) sendToOwner(msg ipc.Message) {
+	d.broadcastMu.Lock()
+	defer d.broadcastMu.Unlock()
+
+	msg.Seq = d.seq.Next()
+
+	clients := d.ownerClients(msg.Process)
+	if len(clients) == 0 {
+		d.logger.Warnf("No agent connected for PID %d (%s), it stays locked", msg.Process.PID, msg.AppName)
+		return
+	}
+
+	for _, client := range clients {
+		if err := client.send(msg); err != nil {
+			d.logger.Debugf("Failed to send message to client: %v", err)
+			client.conn.Close()
+			d.removeConnection(client.conn)
+		}
+	}
+}
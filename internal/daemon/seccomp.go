@@ -0,0 +1,23 @@
+package daemon
+
+import (
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/seccomp"
+)
+
+// installSeccomp confines the daemon to the system calls it needs. It runs
+// once initialization is done, as sockets, the monitor and privileges are
+// set up with calls the filter would refuse. A kernel without seccomp
+// leaves the daemon running unconfined, with a warning.
+func (d *Daemon) installSeccomp() {
+	mode := d.config.Monitor.Seccomp
+	if mode == "" || mode == config.SeccompOff {
+		return
+	}
+
+	if err := seccomp.Install(mode == config.SeccompEnforce); err != nil {
+		d.logger.Warnf("Failed to install seccomp filter, running unconfined: %v", err)
+		return
+	}
+	d.logger.Infof("Seccomp filter installed (%s mode)", mode)
+}
@@ -0,0 +1,65 @@
+package daemon
+
+import (
+	"time"
+
+	"wyrmlock/internal/auth"
+	"wyrmlock/internal/ipc"
+)
+
+// currentAuthenticator returns the authenticator passwords are checked
+// with, or nil when no secret is readable
+func (d *Daemon) currentAuthenticator() *auth.Authenticator {
+	d.authMu.RLock()
+	defer d.authMu.RUnlock()
+	return d.authenticator
+}
+
+// handleReloadSecret re-reads the unlock secret on behalf of root after it
+// was changed, so the change applies without restarting the daemon
+func (d *Daemon) handleReloadSecret(client *clientConn, msg ipc.Message) {
+	uid, err := peerUID(client.conn)
+	if err != nil || uid != 0 {
+		d.replyError(client, msg.Type, ipc.NewErrorDetail(ipc.ErrCodeNotAuthorized,
+			"reloading the secret requires root"))
+		return
+	}
+
+	if err := d.reloadSecret(); err != nil {
+		d.logger.Warnf("Failed to reload the unlock secret: %v", err)
+		d.replyError(client, msg.Type, ipc.NewErrorDetail(ipc.ErrCodeInternal, err.Error()))
+		return
+	}
+	d.logger.Info("Unlock secret reloaded")
+
+	if err := client.send(ipc.Message{
+		Type:    ipc.MsgReloadSecretAck,
+		Success: true,
+	}); err != nil {
+		d.logger.Debugf("Failed to acknowledge secret reload: %v", err)
+	}
+}
+
+// reloadSecret re-reads the secret into the running authenticator, keeping
+// its lockouts, or creates one when the daemon started without a secret
+func (d *Daemon) reloadSecret() error {
+	d.authMu.Lock()
+	defer d.authMu.Unlock()
+
+	if d.authenticator != nil {
+		return d.authenticator.ReloadSecret()
+	}
+
+	authenticator, err := auth.NewAuthenticator(d.config)
+	if err != nil {
+		return err
+	}
+	d.authenticator = authenticator
+	return nil
+}
+
+// ReloadSecret asks the daemon to re-read the unlock secret
+func ReloadSecret(socketPath string, timeout time.Duration) error {
+	_, err := roundTrip(socketPath, ipc.Message{Type: ipc.MsgReloadSecret}, ipc.MsgReloadSecretAck, timeout)
+	return err
+}
@@ -0,0 +1,53 @@
+package daemon
+
+import (
+	"fmt"
+	"net"
+
+	"wyrmlock/internal/monitor"
+)
+
+// StartSelftest starts the daemon for the self-test. Launches are handled
+// and clients served as by Start, but on socketPath so the running daemon
+// is left alone, and without giving up privileges or recording the run.
+func (d *Daemon) StartSelftest(socketPath string) error {
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to create socket: %w", err)
+	}
+	d.socket = listener
+	d.config.SocketPath = socketPath
+
+	d.RegisterProcessEventHandler()
+	if err := d.monitor.Start(); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to start monitor: %w", err)
+	}
+
+	go d.acceptConnections()
+	return nil
+}
+
+// StopSelftest stops a daemon started by StartSelftest
+func (d *Daemon) StopSelftest() error {
+	close(d.stopCh)
+	d.stopIdleTimers()
+	if d.polkit != nil {
+		if err := d.polkit.Close(); err != nil {
+			d.logger.Debugf("Error closing polkit connection: %v", err)
+		}
+	}
+
+	err := d.monitor.Stop()
+	d.closeConnections()
+	if closeErr := d.seq.Close(); closeErr != nil {
+		d.logger.Debugf("Error saving event sequence: %v", closeErr)
+	}
+	return err
+}
+
+// Monitor returns the daemon's process monitor, through which the self-test
+// observes the launches it handles
+func (d *Daemon) Monitor() *monitor.ProcessMonitor {
+	return d.monitor
+}
@@ -0,0 +1,113 @@
+package daemon
+
+import (
+	"time"
+
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/monitor"
+	"wyrmlock/internal/session"
+)
+
+// watchSession follows the desktop session through logind so unlocks do not
+// outlive the user stepping away. The daemon runs without it when logind is
+// not reachable.
+func (d *Daemon) watchSession() {
+	if d.config.Monitor.SessionLockAction == config.SessionLockActionOff && d.config.Monitor.IdleRelock <= 0 {
+		return
+	}
+
+	watcher, err := session.NewWatcher(d.handleSessionEvent)
+	if err != nil {
+		d.logger.Warnf("Not following session locks: %v", err)
+		return
+	}
+	d.session = watcher
+	watcher.Start()
+}
+
+// handleSessionEvent ends grace periods when the session locks or switches
+// user, re-locking running protected apps when configured to, and times
+// idle sessions
+func (d *Daemon) handleSessionEvent(event session.Event) {
+	switch event.Kind {
+	case session.EventIdle:
+		d.startIdleTimer(string(event.Path))
+		return
+	case session.EventActive:
+		d.stopIdleTimer(string(event.Path))
+		return
+	}
+
+	action := d.config.Monitor.SessionLockAction
+	if action == config.SessionLockActionOff {
+		return
+	}
+
+	revoked := d.grace.revokeAll()
+	d.revokeTokens()
+	d.logger.Infof("Session %s on %s, ended %d grace periods", event.Kind, event.Path, revoked)
+
+	if action == config.SessionLockActionRelock {
+		count := d.monitor.RelockAll()
+		d.logger.Infof("Re-locked %d protected processes", count)
+	}
+}
+
+// startIdleTimer re-locks once a session has stayed idle for IdleRelock
+// minutes
+func (d *Daemon) startIdleTimer(path string) {
+	if d.config.Monitor.IdleRelock <= 0 {
+		return
+	}
+
+	d.idleMu.Lock()
+	defer d.idleMu.Unlock()
+
+	if _, running := d.idleTimers[path]; running {
+		return
+	}
+	d.idleTimers[path] = time.AfterFunc(time.Duration(d.config.Monitor.IdleRelock)*time.Minute, func() {
+		d.idleMu.Lock()
+		delete(d.idleTimers, path)
+		d.idleMu.Unlock()
+
+		revoked := d.grace.revokeAll()
+		d.revokeTokens()
+		count := d.monitor.RelockAll()
+		d.logger.Infof("Session %s idle for %d minutes, ended %d grace periods and re-locked %d protected processes",
+			path, d.config.Monitor.IdleRelock, revoked, count)
+	})
+}
+
+// stopIdleTimer cancels the idle timer of a session that is used again
+func (d *Daemon) stopIdleTimer(path string) {
+	d.idleMu.Lock()
+	defer d.idleMu.Unlock()
+
+	if timer, ok := d.idleTimers[path]; ok {
+		timer.Stop()
+		delete(d.idleTimers, path)
+	}
+}
+
+// stopIdleTimers cancels all idle timers
+func (d *Daemon) stopIdleTimers() {
+	d.idleMu.Lock()
+	defer d.idleMu.Unlock()
+
+	for path, timer := range d.idleTimers {
+		timer.Stop()
+		delete(d.idleTimers, path)
+	}
+}
+
+// attributeSession fills in the login session and seat of an event's
+// process when the monitor has not already
+func attributeSession(info *monitor.ProcessInfo) {
+	if info.Session != "" {
+		return
+	}
+	if s, err := session.Resolve(info.PID); err == nil {
+		info.Session, info.Seat = s.ID, s.Seat
+	}
+}
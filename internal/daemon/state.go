@@ -0,0 +1,110 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"wyrmlock/internal/logging"
+)
+
+// daemonState is persisted across daemon runs to detect protection gaps
+type daemonState struct {
+	PID       int       `json:"pid"`
+	StartedAt time.Time `json:"started_at"`
+	StoppedAt time.Time `json:"stopped_at,omitempty"`
+	CleanStop bool      `json:"clean_stop"`
+}
+
+// readDaemonState loads the state left by the previous run
+func readDaemonState(path string) (*daemonState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var state daemonState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse daemon state: %w", err)
+	}
+	return &state, nil
+}
+
+// writeDaemonState atomically replaces the state file
+func writeDaemonState(path string, state *daemonState) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode daemon state: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write daemon state: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// recordStart reports the protection gap left by the previous run, if any,
+// and records this run as started
+func (d *Daemon) recordStart() {
+	path := d.config.Monitor.StateFile
+	if path == "" {
+		return
+	}
+
+	now := time.Now()
+	if prev, err := readDaemonState(path); err == nil {
+		details := map[string]interface{}{
+			"previous_pid":        prev.PID,
+			"previous_started_at": prev.StartedAt,
+			"crashed":             !prev.CleanStop,
+		}
+
+		var message string
+		if prev.CleanStop {
+			gap := now.Sub(prev.StoppedAt).Round(time.Second)
+			details["stopped_at"] = prev.StoppedAt
+			details["gap_seconds"] = int(gap.Seconds())
+			message = fmt.Sprintf("Protected apps were unprotected for %s while the daemon was stopped", gap)
+		} else {
+			message = fmt.Sprintf("Daemon (PID %d, started %s) did not stop cleanly; protected apps were unprotected until now",
+				prev.PID, prev.StartedAt.Format(time.RFC3339))
+		}
+
+		d.logger.Warn(message)
+		if logging.SecurityLog != nil {
+			logging.SecurityLog.LogEvent(logging.EventProtectionGap, message, details)
+		}
+	} else if !os.IsNotExist(err) {
+		d.logger.Warnf("Failed to read daemon state: %v", err)
+	}
+
+	d.startedAt = now
+	if err := writeDaemonState(path, &daemonState{PID: os.Getpid(), StartedAt: now}); err != nil {
+		d.logger.Warnf("Failed to record daemon start: %v", err)
+	}
+}
+
+// recordStop marks this run as cleanly stopped
+func (d *Daemon) recordStop() {
+	path := d.config.Monitor.StateFile
+	if path == "" {
+		return
+	}
+
+	state := &daemonState{
+		PID:       os.Getpid(),
+		StartedAt: d.startedAt,
+		StoppedAt: time.Now(),
+		CleanStop: true,
+	}
+	if err := writeDaemonState(path, state); err != nil {
+		d.logger.Warnf("Failed to record daemon stop: %v", err)
+	}
+}
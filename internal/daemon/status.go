@@ -0,0 +1,276 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os/user"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"wyrmlock/internal/ipc"
+	"wyrmlock/internal/monitor"
+)
+
+// statusTracker records the grants and lockouts the daemon has observed so
+// they can be reported by the status command
+type statusTracker struct {
+	mu       sync.Mutex
+	grants   map[int]ipc.Grant
+	lockouts map[string]time.Time
+}
+
+// newStatusTracker creates an empty status tracker
+func newStatusTracker() *statusTracker {
+	return &statusTracker{
+		grants:   make(map[int]ipc.Grant),
+		lockouts: make(map[string]time.Time),
+	}
+}
+
+// recordGrant remembers that a process was allowed to run
+func (t *statusTracker) recordGrant(grant ipc.Grant) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.grants[grant.PID] = grant
+}
+
+// recordLockout remembers an authentication lockout reported by a client
+func (t *statusTracker) recordLockout(app string, until time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lockouts[app] = until
+}
+
+// report builds a status report, dropping grants for processes that are no
+// longer tracked and lockouts that have expired
+func (t *statusTracker) report(isActive func(pid int) bool, now time.Time) *ipc.StatusReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	report := &ipc.StatusReport{GeneratedAt: now}
+
+	for pid, grant := range t.grants {
+		expired := !grant.ExpiresAt.IsZero() && now.After(grant.ExpiresAt)
+		if expired || !isActive(pid) {
+			delete(t.grants, pid)
+			continue
+		}
+		report.Grants = append(report.Grants, grant)
+	}
+
+	for app, until := range t.lockouts {
+		if !now.Before(until) {
+			delete(t.lockouts, app)
+			continue
+		}
+		report.Lockouts = append(report.Lockouts, ipc.Lockout{App: app, Until: until})
+	}
+
+	sort.Slice(report.Grants, func(i, j int) bool { return report.Grants[i].GrantedAt.Before(report.Grants[j].GrantedAt) })
+	sort.Slice(report.Lockouts, func(i, j int) bool { return report.Lockouts[i].App < report.Lockouts[j].App })
+
+	return report
+}
+
+// grantFor builds a grant for a process the daemon has just resumed
+func (d *Daemon) grantFor(pid int, grantedBy string) ipc.Grant {
+	grant := ipc.Grant{
+		PID:       pid,
+		GrantedBy: grantedBy,
+		GrantedAt: time.Now(),
+	}
+
+	if info, ok := d.monitor.GetProcess(pid); ok {
+		grant.App = info.Target()
+	}
+
+	if uid := processUID(pid); uid >= 0 {
+		grant.User = strconv.Itoa(uid)
+		if u, err := user.LookupId(grant.User); err == nil {
+			grant.User = u.Username
+		}
+	}
+
+	return grant
+}
+
+// handleStatusRequest answers a status request from a client. Anyone may
+// ask, so processes and grants of other users are only reported to root.
+func (d *Daemon) handleStatusRequest(client *clientConn) {
+	processes, err := d.monitor.PollProcesses()
+	if err != nil {
+		d.replyError(client, ipc.MsgStatusRequest, ipc.NewErrorDetail(ipc.ErrCodeInternal, err.Error()))
+		return
+	}
+
+	report := d.status.report(d.monitor.IsMonitored, time.Now())
+	if uid, err := peerUID(client.conn); err != nil || uid != 0 {
+		processes, report.Grants = ownStatus(processes, report.Grants, uid)
+	}
+	for _, q := range d.monitor.LaunchQuotas() {
+		report.Quotas = append(report.Quotas, ipc.QuotaUsage{
+			App:   q.App,
+			Kind:  ipc.QuotaLaunches,
+			Used:  q.Used,
+			Limit: q.Limit,
+		})
+	}
+	for _, q := range d.monitor.UsageQuotas() {
+		report.Quotas = append(report.Quotas, ipc.QuotaUsage{
+			App:   q.App,
+			Kind:  ipc.QuotaMinutes,
+			Used:  q.Used,
+			Limit: q.Limit,
+		})
+	}
+
+	caps := d.monitor.Capabilities()
+	report.Kernel = &ipc.KernelInfo{
+		Release:  caps.Kernel,
+		Backend:  caps.Backend,
+		Features: caps.Features,
+		Warnings: caps.Warnings(),
+	}
+
+	queue := d.monitor.EventQueueStats()
+	report.Events = &ipc.EventQueue{
+		Workers:   queue.Workers,
+		Capacity:  queue.Capacity,
+		Depth:     queue.Depth,
+		Processed: queue.Processed,
+		Dropped:   queue.Dropped,
+	}
+
+	if err := client.send(ipc.Message{
+		Type:          ipc.MsgStatusResponse,
+		ProcessList:   processes,
+		ProtectedApps: d.config.Monitor.ProtectedApps,
+		Status:        report,
+	}); err != nil {
+		d.logger.Debugf("Failed to send status response: %v", err)
+	}
+}
+
+// ownStatus keeps the processes and grants that belong to uid, dropping all
+// of them when uid is unknown
+func ownStatus(processes []monitor.ProcessInfo, grants []ipc.Grant, uid int) ([]monitor.ProcessInfo, []ipc.Grant) {
+	var ownProcesses []monitor.ProcessInfo
+	var ownGrants []ipc.Grant
+	if uid < 0 {
+		return ownProcesses, ownGrants
+	}
+
+	for _, p := range processes {
+		if processUID(p.PID) == uid {
+			ownProcesses = append(ownProcesses, p)
+		}
+	}
+	for _, g := range grants {
+		if processUID(g.PID) == uid {
+			ownGrants = append(ownGrants, g)
+		}
+	}
+	return ownProcesses, ownGrants
+}
+
+// QueryStatus connects to the daemon socket and requests its status
+func QueryStatus(socketPath string, timeout time.Duration) (*ipc.Message, error) {
+	return roundTrip(socketPath, ipc.Message{Type: ipc.MsgStatusRequest}, ipc.MsgStatusResponse, timeout)
+}
+
+// roundTrip sends a single request on a new connection and waits for the
+// reply of the given type or an error reply to the request
+func roundTrip(socketPath string, request ipc.Message, reply ipc.MessageType, timeout time.Duration) (*ipc.Message, error) {
+	conn, err := net.DialTimeout("unix", socketPath, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	if err := json.NewEncoder(conn).Encode(request); err != nil {
+		return nil, fmt.Errorf("failed to send %s: %w", request.Type, err)
+	}
+
+	// Skip unrelated broadcasts (heartbeats, process events) until the reply
+	decoder := json.NewDecoder(conn)
+	for {
+		var msg ipc.Message
+		if err := decoder.Decode(&msg); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", reply, err)
+		}
+
+		switch msg.Type {
+		case reply:
+			return &msg, nil
+		case ipc.MsgError:
+			if req, _ := msg.Data["request"].(string); req == string(request.Type) {
+				return nil, msg.Err()
+			}
+		}
+	}
+}
+
+// FormatGrant describes a grant for display
+func FormatGrant(g ipc.Grant, now time.Time) string {
+	s := fmt.Sprintf("%s (PID %d)", g.App, g.PID)
+	if g.User != "" {
+		s += " for " + g.User
+	}
+	if g.GrantedBy != "" {
+		s += " by " + g.GrantedBy
+	}
+	if g.ExpiresAt.IsZero() {
+		return s + ", until exit"
+	}
+	return fmt.Sprintf("%s, expires in %s", s, g.ExpiresAt.Sub(now).Round(time.Second))
+}
+
+// FormatQuota describes a quota's usage for display
+func FormatQuota(q ipc.QuotaUsage) string {
+	remaining := q.Limit - q.Used
+	if remaining < 0 {
+		remaining = 0
+	}
+	if q.Kind == ipc.QuotaMinutes {
+		return fmt.Sprintf("%s: %d of %d minutes of screen time used, %d left", q.App, q.Used, q.Limit, remaining)
+	}
+	return fmt.Sprintf("%s: %d of %d free launches used, %d left", q.App, q.Used, q.Limit, remaining)
+}
+
+// FormatKernel describes the detected kernel features for display, one line
+// per feature
+func FormatKernel(k *ipc.KernelInfo) []string {
+	lines := []string{fmt.Sprintf("Kernel %s, %s backend", k.Release, k.Backend)}
+
+	names := make([]string, 0, len(k.Features))
+	for name := range k.Features {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		state := "missing"
+		if k.Features[name] {
+			state = "available"
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", name, state))
+	}
+	return lines
+}
+
+// FormatEventQueue describes the exec event queue for display
+func FormatEventQueue(q *ipc.EventQueue) string {
+	return fmt.Sprintf("%d of %d queued, %d workers, %d processed, %d dropped",
+		q.Depth, q.Capacity, q.Workers, q.Processed, q.Dropped)
+}
+
+// FormatLockout describes a lockout for display
+func FormatLockout(l ipc.Lockout, now time.Time) string {
+	return fmt.Sprintf("%s: locked out, retry in %s (at %s)", l.App,
+		l.Remaining(now).Round(time.Second), l.Until.Format("15:04:05"))
+}
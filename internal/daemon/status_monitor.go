@@ -22,6 +22,9 @@ type DaemonStatus struct {
 	Version          string                `json:"version"`
 	SystemErrors     []string              `json:"system_errors,omitempty"`
 	BruteForceEvents int                   `json:"brute_force_events"`
+	Grants           []ipc.Grant           `json:"grants,omitempty"`
+	Quotas           []ipc.QuotaUsage      `json:"quotas,omitempty"`
+	Lockouts         []ipc.Lockout         `json:"lockouts,omitempty"`
 }
 
 // StatusMonitor monitors and reports on daemon status
@@ -45,7 +48,7 @@ func NewStatusMonitor(client *Client) *StatusMonitor {
 		logger = logging.NewLogger("[status]", false)
 	}
 
-	return &StatusMonitor{
+	m := &StatusMonitor{
 		client:    client,
 		logger:    logger,
 		stopCh:    make(chan struct{}),
@@ -57,6 +60,11 @@ func NewStatusMonitor(client *Client) *StatusMonitor {
 			BruteForceEvents: 0,
 		},
 	}
+
+	// Receive status responses from the client's message loop
+	client.SetStatusHandler(m.HandleStatusResponse)
+
+	return m
 }
 
 // Start begins monitoring the daemon status
@@ -185,6 +193,12 @@ func (m *StatusMonitor) HandleStatusResponse(msg ipc.Message) {
 		m.status.Version = msg.Version
 	}
 
+	if msg.Status != nil {
+		m.status.Grants = msg.Status.Grants
+		m.status.Quotas = msg.Status.Quotas
+		m.status.Lockouts = msg.Status.Lockouts
+	}
+
 	// Notify status listeners
 	m.notifyListeners()
 }
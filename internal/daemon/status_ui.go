@@ -215,6 +215,35 @@ func (ui *StatusUI) View() string {
 		view.WriteString("\n")
 	}
 
+	// Grants, quotas and lockouts explain why a launch did or didn't prompt
+	if len(ui.status.Grants) > 0 {
+		view.WriteString("Active Grants:\n")
+		for _, g := range ui.status.Grants {
+			view.WriteString(statusValueStyle.Render("• " + FormatGrant(g, time.Now())))
+			view.WriteString("\n")
+		}
+		view.WriteString("\n")
+	}
+
+	if len(ui.status.Quotas) > 0 {
+		view.WriteString("Quota Usage Today:\n")
+		for _, q := range ui.status.Quotas {
+			view.WriteString(statusValueStyle.Render("• " + FormatQuota(q)))
+			view.WriteString("\n")
+		}
+		view.WriteString("\n")
+	}
+
+	if len(ui.status.Lockouts) > 0 {
+		view.WriteString(statusWarningStyle.Render("Active Lockouts:"))
+		view.WriteString("\n")
+		for _, l := range ui.status.Lockouts {
+			view.WriteString(statusWarningStyle.Render("• " + FormatLockout(l, time.Now())))
+			view.WriteString("\n")
+		}
+		view.WriteString("\n")
+	}
+
 	// Active processes section
 	if len(ui.status.ActiveProcesses) > 0 {
 		view.WriteString("Active Monitored Processes:\n")
@@ -0,0 +1,147 @@
+package daemon
+
+import (
+	"errors"
+	"path/filepath"
+	"time"
+
+	"wyrmlock/internal/ipc"
+	"wyrmlock/internal/keyring"
+	"wyrmlock/internal/monitor"
+	"wyrmlock/internal/token"
+)
+
+// issueToken returns a session token for the app a process was just
+// unlocked for, valid for its grace period; empty when tokens are off or
+// the app has no grace period
+func (d *Daemon) issueToken(pid int) string {
+	if d.tokens == nil {
+		return ""
+	}
+	info, ok := d.monitor.GetProcess(pid)
+	if !ok || info.ExecHash == "" {
+		return ""
+	}
+	period := d.grace.periodFor(info.Target())
+	if period <= 0 {
+		return ""
+	}
+	return d.tokens.Issue(processUID(pid), info.Target(), info.ExecHash, period, time.Now())
+}
+
+// verifyToken checks a session token a client sent for a suspended launch
+// against the launching user and the executable
+func (d *Daemon) verifyToken(pid int, tok string) bool {
+	if d.tokens == nil {
+		return false
+	}
+	info, ok := d.monitor.GetProcess(pid)
+	if !ok || info.ExecHash == "" {
+		return false
+	}
+
+	err := d.tokens.Verify(tok, processUID(pid), info.Target(), info.ExecHash, time.Now())
+	if err != nil {
+		if errors.Is(err, token.ErrExpired) {
+			d.logger.Debugf("Session token for %s (PID %d) expired", info.Target(), pid)
+		} else {
+			d.logger.Warnf("Rejected session token for %s (PID %d): %v", info.Target(), pid, err)
+		}
+		return false
+	}
+	return true
+}
+
+// promptAfterToken asks the user to authenticate a launch whose session
+// token was rejected; the client has already dropped the token
+func (d *Daemon) promptAfterToken(pid int) {
+	info, ok := d.monitor.GetProcess(pid)
+	if !ok {
+		return
+	}
+	displayName := info.AppName
+	if displayName == "" {
+		displayName = filepath.Base(info.Command)
+	}
+	d.promptClients(pid, info.Command, displayName)
+}
+
+// tokenProcess identifies the app a token is stored for in the client
+func (d *Daemon) tokenProcess(pid int) *monitor.ProcessInfo {
+	info, ok := d.monitor.GetProcess(pid)
+	if !ok {
+		return nil
+	}
+	return &monitor.ProcessInfo{PID: pid, Command: info.Command}
+}
+
+// revokeTokens rotates the signing key so no token issued so far is
+// accepted again
+func (d *Daemon) revokeTokens() {
+	if d.tokens == nil {
+		return
+	}
+	if err := d.tokens.Rotate(); err != nil {
+		d.logger.Errorf("Failed to revoke session tokens: %v", err)
+	}
+}
+
+// tokenDescription names the keyring key holding the session token of an
+// app
+func tokenDescription(app string) string {
+	return "wyrmlock:" + app
+}
+
+// unlockWithToken answers a prompt with the app's session token from the
+// kernel keyring, and reports whether it did. Changed binaries are always
+// shown to the user.
+func (c *Client) unlockWithToken(msg ipc.Message) bool {
+	if !c.config.Auth.SessionTokens || msg.Process.NewBinary {
+		return false
+	}
+
+	description := tokenDescription(msg.Process.Command)
+	tok, err := keyring.Load(description)
+	if err != nil {
+		if !errors.Is(err, keyring.ErrNotFound) {
+			c.logger.Debugf("Failed to read session token: %v", err)
+		}
+		return false
+	}
+
+	// A token is sent once: the daemon hands it back when it is accepted
+	// and prompts again when it is not
+	if err := keyring.Remove(description); err != nil {
+		c.logger.Debugf("Failed to drop session token: %v", err)
+	}
+	if err := c.sendMessage(ipc.Message{
+		Type:  ipc.MsgAuthResponse,
+		PID:   msg.Process.PID,
+		Token: string(tok),
+	}); err != nil {
+		c.logger.Errorf("Failed to send session token: %v", err)
+		return false
+	}
+	return true
+}
+
+// storeToken keeps the session token the daemon sent with an unlock until
+// it expires
+func (c *Client) storeToken(msg ipc.Message) {
+	if msg.Token == "" || msg.Process == nil || !c.config.Auth.SessionTokens {
+		return
+	}
+
+	expires, err := token.Expiry(msg.Token)
+	if err != nil {
+		c.logger.Debugf("Ignoring malformed session token: %v", err)
+		return
+	}
+	ttl := time.Until(expires)
+	if ttl <= 0 {
+		return
+	}
+	if err := keyring.Store(tokenDescription(msg.Process.Command), []byte(msg.Token), ttl); err != nil {
+		c.logger.Warnf("Failed to store session token: %v", err)
+	}
+}
@@ -0,0 +1,28 @@
+package daemon
+
+// verifyPassword checks a password a client sent for a suspended launch.
+// The secret is chosen by the UID that launched it, so users sharing the
+// machine can each have their own.
+func (d *Daemon) verifyPassword(pid int, password string) bool {
+	authenticator := d.currentAuthenticator()
+	if authenticator == nil {
+		d.logger.Warnf("Denying PID %d: no secret to verify the password against", pid)
+		return false
+	}
+
+	info, ok := d.monitor.GetProcess(pid)
+	if !ok {
+		return false
+	}
+	uid := processUID(pid)
+
+	authenticated, err := authenticator.AuthenticateUser([]byte(password), info.Target(), uid)
+	if err != nil {
+		d.logger.Warnf("Password check for %s (PID %d, UID %d) failed: %v", info.Target(), pid, uid, err)
+		return false
+	}
+	if !authenticated {
+		d.logger.Infof("Wrong password for %s (PID %d, UID %d)", info.Target(), pid, uid)
+	}
+	return authenticated
+}
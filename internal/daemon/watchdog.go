@@ -0,0 +1,81 @@
+package daemon
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// notifySystemd sends a state update to systemd when the daemon runs as a
+// Type=notify service; it does nothing otherwise
+func notifySystemd(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	// Abstract sockets are given with a leading @
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// watchdogInterval returns how often systemd expects a watchdog ping, or 0
+// when the service has no WatchdogSec= or the watchdog is meant for
+// another process
+func watchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
+
+// startWatchdog tells systemd the daemon is ready and pings its watchdog
+// while the monitor is running. If the daemon hangs or is killed, systemd
+// restarts it (Restart=always) and the new run recovers the launches that
+// were left suspended.
+func (d *Daemon) startWatchdog() {
+	if err := notifySystemd("READY=1"); err != nil {
+		d.logger.Warnf("Failed to notify systemd: %v", err)
+	}
+
+	interval := watchdogInterval()
+	if interval == 0 {
+		return
+	}
+	d.logger.Debugf("Pinging the systemd watchdog every %s", interval/2)
+
+	go func() {
+		// Ping twice per interval so one late ping does not trip it
+		ticker := time.NewTicker(interval / 2)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-d.stopCh:
+				return
+			case <-ticker.C:
+				if !d.monitor.Running() {
+					d.logger.Error("Process monitor is not running, withholding watchdog ping")
+					continue
+				}
+				if err := notifySystemd("WATCHDOG=1"); err != nil {
+					d.logger.Debugf("Failed to ping systemd watchdog: %v", err)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,238 @@
+// Package desktop reads freedesktop.org .desktop entries so executables can
+// be shown by their application name and icon
+package desktop
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// refreshInterval is how long a loaded index is used before the desktop
+// entries are read again, so newly installed apps are picked up
+const refreshInterval = 5 * time.Minute
+
+// execSearchPath is used to resolve Exec lines that name a bare command
+var execSearchPath = []string{"/usr/local/bin", "/usr/bin", "/bin", "/usr/games", "/usr/local/sbin", "/usr/sbin", "/sbin"}
+
+// Entry is an application described by a .desktop file
+type Entry struct {
+	// ID is the desktop file name, e.g. "steam.desktop"
+	ID string
+
+	// File is the path of the desktop file
+	File string
+
+	Name string
+	Icon string
+	Exec string
+
+	// Path is the executable the Exec line starts, resolved to an absolute
+	// path; empty when it cannot be found
+	Path string
+
+	// Hidden is set for entries not shown in menus (NoDisplay or Hidden)
+	Hidden bool
+}
+
+// Dirs returns the application directories in XDG precedence order
+func Dirs() []string {
+	var dirs []string
+
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			dataHome = filepath.Join(home, ".local", "share")
+		}
+	}
+	if dataHome != "" {
+		dirs = append(dirs, filepath.Join(dataHome, "applications"))
+	}
+
+	dataDirs := os.Getenv("XDG_DATA_DIRS")
+	if dataDirs == "" {
+		dataDirs = "/usr/local/share:/usr/share"
+	}
+	for _, dir := range strings.Split(dataDirs, ":") {
+		if dir != "" {
+			dirs = append(dirs, filepath.Join(dir, "applications"))
+		}
+	}
+
+	// Flatpak and snap exports are not always in XDG_DATA_DIRS for the daemon
+	return append(dirs, "/var/lib/flatpak/exports/share/applications", "/var/lib/snapd/desktop/applications")
+}
+
+// Parse reads the [Desktop Entry] group of a desktop file. Only
+// applications are returned; ok is false for links, directories and
+// malformed files.
+func Parse(r io.Reader) (Entry, bool) {
+	var entry Entry
+	isApp := false
+	inEntry := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			inEntry = line == "[Desktop Entry]"
+			continue
+		}
+		if !inEntry {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch strings.TrimSpace(key) {
+		case "Type":
+			isApp = value == "Application"
+		case "Name":
+			entry.Name = value
+		case "Icon":
+			entry.Icon = value
+		case "Exec":
+			entry.Exec = value
+		case "NoDisplay", "Hidden":
+			if value == "true" {
+				entry.Hidden = true
+			}
+		}
+	}
+
+	if scanner.Err() != nil || !isApp || entry.Name == "" {
+		return Entry{}, false
+	}
+	entry.Path = ResolveExec(entry.Exec)
+	return entry, true
+}
+
+// ResolveExec returns the absolute path of the program an Exec line starts,
+// skipping an "env VAR=value" prefix, or "" when it cannot be found
+func ResolveExec(execLine string) string {
+	fields := strings.Fields(execLine)
+	for len(fields) > 0 {
+		field := strings.Trim(fields[0], `"'`)
+		if field == "env" || field == "/usr/bin/env" || strings.Contains(field, "=") {
+			fields = fields[1:]
+			continue
+		}
+
+		if !filepath.IsAbs(field) {
+			found := ""
+			for _, dir := range execSearchPath {
+				candidate := filepath.Join(dir, field)
+				if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+					found = candidate
+					break
+				}
+			}
+			if found == "" {
+				return ""
+			}
+			field = found
+		}
+
+		if resolved, err := filepath.EvalSymlinks(field); err == nil {
+			return resolved
+		}
+		return field
+	}
+	return ""
+}
+
+// Load reads the desktop entries in dirs. An entry in an earlier directory
+// hides one with the same ID in a later directory.
+func Load(dirs []string) []Entry {
+	seen := make(map[string]bool)
+	var entries []Entry
+
+	for _, dir := range dirs {
+		files, err := filepath.Glob(filepath.Join(dir, "*.desktop"))
+		if err != nil {
+			continue
+		}
+		for _, file := range files {
+			id := filepath.Base(file)
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			f, err := os.Open(file)
+			if err != nil {
+				continue
+			}
+			entry, ok := Parse(f)
+			f.Close()
+			if !ok {
+				continue
+			}
+			entry.ID, entry.File = id, file
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries
+}
+
+// Index maps executables to their desktop entries. It is safe for
+// concurrent use and reloads the entries periodically.
+type Index struct {
+	dirs []string
+
+	mu       sync.Mutex
+	byPath   map[string]Entry
+	loadedAt time.Time
+}
+
+// NewIndex creates an index over dirs; nil uses Dirs()
+func NewIndex(dirs []string) *Index {
+	if dirs == nil {
+		dirs = Dirs()
+	}
+	return &Index{dirs: dirs}
+}
+
+// Lookup returns the desktop entry that starts execPath. Entries shown in
+// menus win over hidden ones for the same executable.
+func (x *Index) Lookup(execPath string) (Entry, bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if x.byPath == nil || time.Since(x.loadedAt) > refreshInterval {
+		x.byPath = make(map[string]Entry)
+		for _, entry := range Load(x.dirs) {
+			if entry.Path == "" {
+				continue
+			}
+			if existing, ok := x.byPath[entry.Path]; ok && !existing.Hidden {
+				continue
+			}
+			x.byPath[entry.Path] = entry
+		}
+		x.loadedAt = time.Now()
+	}
+
+	if entry, ok := x.byPath[execPath]; ok {
+		return entry, true
+	}
+	if resolved, err := filepath.EvalSymlinks(execPath); err == nil {
+		entry, ok := x.byPath[resolved]
+		return entry, ok
+	}
+	return Entry{}, false
+}
@@ -0,0 +1,70 @@
+package desktop_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"wyrmlock/internal/desktop"
+)
+
+func TestParse(t *testing.T) {
+	data := `[Desktop Entry]
+Type=Application
+Name=Steam
+Name[de]=Steam
+Icon=steam
+Exec=env GDK_SCALE=2 /usr/games/steam %U
+
+[Desktop Action Library]
+Name=Library
+Exec=/usr/games/steam steam://open/games
+`
+	entry, ok := desktop.Parse(strings.NewReader(data))
+	if !ok {
+		t.Fatal("Expected the application entry to parse")
+	}
+	if entry.Name != "Steam" || entry.Icon != "steam" {
+		t.Errorf("Parse() = %+v, want Steam with icon steam", entry)
+	}
+
+	if _, ok := desktop.Parse(strings.NewReader("[Desktop Entry]\nType=Link\nName=Docs\nURL=https://example.com\n")); ok {
+		t.Error("Expected a link entry to be skipped")
+	}
+}
+
+func TestIndexLookup(t *testing.T) {
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "tool")
+	if err := os.WriteFile(exe, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("Failed to write executable: %v", err)
+	}
+
+	apps := filepath.Join(dir, "applications")
+	if err := os.Mkdir(apps, 0755); err != nil {
+		t.Fatalf("Failed to create applications dir: %v", err)
+	}
+	entries := map[string]string{
+		"tool-helper.desktop": "[Desktop Entry]\nType=Application\nName=Tool Helper\nNoDisplay=true\nExec=" + exe + " --helper\n",
+		"tool.desktop":        "[Desktop Entry]\nType=Application\nName=Tool\nIcon=tool-icon\nExec=\"" + exe + "\" %F\n",
+	}
+	for name, data := range entries {
+		if err := os.WriteFile(filepath.Join(apps, name), []byte(data), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	index := desktop.NewIndex([]string{apps})
+	entry, ok := index.Lookup(exe)
+	if !ok {
+		t.Fatalf("Expected %s to resolve to a desktop entry", exe)
+	}
+	if entry.Name != "Tool" || entry.Icon != "tool-icon" || entry.ID != "tool.desktop" {
+		t.Errorf("Lookup() = %+v, want the visible Tool entry", entry)
+	}
+
+	if _, ok := index.Lookup(filepath.Join(dir, "other")); ok {
+		t.Error("Expected no entry for an unknown executable")
+	}
+}
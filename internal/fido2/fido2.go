@@ -0,0 +1,419 @@
+// Package fido2 unlocks with a FIDO2/U2F security key. It drives the
+// libfido2 command line tools (fido2-token, fido2-cred and fido2-assert)
+// and verifies assertions itself against the registered public keys.
+package fido2
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// RelyingParty is the FIDO2 relying party ID credentials are made for
+const RelyingParty = "wyrmlock"
+
+// flagUserPresent is the authenticator data flag set when the key was
+// touched
+const flagUserPresent = 0x01
+
+var (
+	// ErrToolsMissing is returned when the libfido2 tools are not installed
+	ErrToolsMissing = errors.New("libfido2 tools (fido2-token, fido2-cred, fido2-assert) are not installed")
+
+	// ErrNoDevice is returned when no security key is connected
+	ErrNoDevice = errors.New("no FIDO2 security key found")
+
+	// ErrNoCredentials is returned when no key has been registered
+	ErrNoCredentials = errors.New("no FIDO2 security key registered")
+
+	// ErrNotVerified is returned when no registered key produced a valid
+	// assertion
+	ErrNotVerified = errors.New("security key not verified")
+)
+
+// Credential is a registered security key
+type Credential struct {
+	// Name tells keys apart in listings
+	Name string `json:"name"`
+
+	// ID is the base64 credential ID the key returned
+	ID string `json:"id"`
+
+	// PublicKey is the ES256 public key in PEM form
+	PublicKey string `json:"public_key"`
+
+	Registered time.Time `json:"registered"`
+}
+
+// Store is the credential file
+type Store struct {
+	Credentials []Credential `json:"credentials"`
+}
+
+// Load reads a credential file; a missing file is an empty store
+func Load(path string) (*Store, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Store{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var store Store
+	if err := json.Unmarshal(data, &store); err != nil {
+		return nil, fmt.Errorf("failed to parse FIDO2 credentials: %w", err)
+	}
+	return &store, nil
+}
+
+// Save writes the credential file atomically. It holds only public keys
+// but decides who may unlock, so it is kept root-owned.
+func (s *Store) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create credential directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode FIDO2 credentials: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write FIDO2 credentials: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// Remove drops the credential with the given name, reporting whether one
+// was found
+func (s *Store) Remove(name string) bool {
+	for i, cred := range s.Credentials {
+		if cred.Name == name {
+			s.Credentials = append(s.Credentials[:i], s.Credentials[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Devices lists the connected security keys, e.g. /dev/hidraw3
+func Devices(ctx context.Context) ([]string, error) {
+	out, err := runTool(ctx, "fido2-token", nil, "-L")
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []string
+	for _, line := range strings.Split(string(out), "\n") {
+		// "/dev/hidraw3: vendor=0x1050, product=0x0407 (Yubico YubiKey OTP+FIDO+CCID)"
+		if path, _, ok := strings.Cut(line, ": "); ok && path != "" {
+			devices = append(devices, path)
+		}
+	}
+	return devices, nil
+}
+
+// device returns the configured device, or the first key connected
+func device(ctx context.Context, configured string) (string, error) {
+	if configured != "" {
+		return configured, nil
+	}
+	devices, err := Devices(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(devices) == 0 {
+		return "", ErrNoDevice
+	}
+	return devices[0], nil
+}
+
+// Register makes a new credential on the key, which has to be touched
+func Register(ctx context.Context, dev, name string) (Credential, error) {
+	dev, err := device(ctx, dev)
+	if err != nil {
+		return Credential{}, err
+	}
+
+	clientDataHash, err := randomBytes(32)
+	if err != nil {
+		return Credential{}, err
+	}
+	userID, err := randomBytes(32)
+	if err != nil {
+		return Credential{}, err
+	}
+
+	input := strings.Join([]string{
+		encode(clientDataHash), RelyingParty, name, encode(userID),
+	}, "\n") + "\n"
+	made, err := runTool(ctx, "fido2-cred", []byte(input), "-M", dev)
+	if err != nil {
+		return Credential{}, fmt.Errorf("failed to make credential: %w", err)
+	}
+
+	// Verifying the attestation prints the credential ID and public key
+	verified, err := runTool(ctx, "fido2-cred", made, "-V")
+	if err != nil {
+		return Credential{}, fmt.Errorf("failed to verify new credential: %w", err)
+	}
+	id, publicKey, ok := strings.Cut(string(verified), "\n")
+	if !ok || id == "" {
+		return Credential{}, fmt.Errorf("unexpected fido2-cred output")
+	}
+	if _, err := parsePublicKey(publicKey); err != nil {
+		return Credential{}, err
+	}
+
+	return Credential{
+		Name:       name,
+		ID:         strings.TrimSpace(id),
+		PublicKey:  publicKey,
+		Registered: time.Now(),
+	}, nil
+}
+
+// Assertion is a key's signature over a challenge, made where the key is
+// plugged in and checked by whoever issued the challenge
+type Assertion struct {
+	// CredentialID names the registered credential that signed
+	CredentialID string `json:"credential_id"`
+
+	AuthData  []byte `json:"auth_data"`
+	Signature []byte `json:"signature"`
+}
+
+// NewChallenge returns a fresh client data hash to be signed
+func NewChallenge() ([]byte, error) {
+	return randomBytes(32)
+}
+
+// Verify asks the key to sign a fresh challenge, which needs a touch, and
+// checks the signature against each registered credential in turn
+func Verify(ctx context.Context, dev string, credentials []Credential) error {
+	challenge, err := NewChallenge()
+	if err != nil {
+		return err
+	}
+	_, err = Assert(ctx, dev, credentials, challenge)
+	return err
+}
+
+// Assert asks the key to sign challenge, which needs a touch, trying each
+// registered credential in turn, and returns the first signature that
+// verifies
+func Assert(ctx context.Context, dev string, credentials []Credential, challenge []byte) (Assertion, error) {
+	if len(credentials) == 0 {
+		return Assertion{}, ErrNoCredentials
+	}
+	dev, err := device(ctx, dev)
+	if err != nil {
+		return Assertion{}, err
+	}
+
+	var lastErr error
+	for _, cred := range credentials {
+		assertion, err := assertCredential(ctx, dev, cred, challenge)
+		if err == nil {
+			return assertion, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return Assertion{}, fmt.Errorf("%w: %v", ErrNotVerified, lastErr)
+}
+
+// VerifyRegistered verifies the key against the credentials registered in
+// credentialFile
+func VerifyRegistered(ctx context.Context, credentialFile, dev string) error {
+	store, err := Load(credentialFile)
+	if err != nil {
+		return err
+	}
+	return Verify(ctx, dev, store.Credentials)
+}
+
+// CheckRegistered verifies an assertion made for challenge against the
+// credential it names in credentialFile
+func CheckRegistered(credentialFile string, challenge []byte, assertion Assertion) error {
+	store, err := Load(credentialFile)
+	if err != nil {
+		return err
+	}
+	for _, cred := range store.Credentials {
+		if cred.ID == assertion.CredentialID {
+			if err := CheckAssertion(cred.PublicKey, challenge, assertion.AuthData, assertion.Signature); err != nil {
+				return fmt.Errorf("%w: %v", ErrNotVerified, err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: credential is not registered", ErrNotVerified)
+}
+
+// assertCredential gets an assertion of challenge for one credential and
+// checks it
+func assertCredential(ctx context.Context, dev string, cred Credential, challenge []byte) (Assertion, error) {
+	input := strings.Join([]string{encode(challenge), RelyingParty, cred.ID}, "\n") + "\n"
+	out, err := runTool(ctx, "fido2-assert", []byte(input), "-G", "-p", dev)
+	if err != nil {
+		return Assertion{}, err
+	}
+
+	// client data hash, relying party, authenticator data, signature
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) < 4 {
+		return Assertion{}, fmt.Errorf("unexpected fido2-assert output")
+	}
+	if echoed, err := decode(lines[0]); err != nil || !bytes.Equal(echoed, challenge) {
+		return Assertion{}, fmt.Errorf("assertion is for another challenge")
+	}
+	authData, err := decode(lines[2])
+	if err != nil {
+		return Assertion{}, fmt.Errorf("invalid authenticator data: %w", err)
+	}
+	signature, err := decode(lines[3])
+	if err != nil {
+		return Assertion{}, fmt.Errorf("invalid signature: %w", err)
+	}
+
+	// fido2-assert prints the authenticator data as a CBOR byte string
+	authData, err = unwrapCBORBytes(authData)
+	if err != nil {
+		return Assertion{}, err
+	}
+	if err := CheckAssertion(cred.PublicKey, challenge, authData, signature); err != nil {
+		return Assertion{}, err
+	}
+	return Assertion{CredentialID: cred.ID, AuthData: authData, Signature: signature}, nil
+}
+
+// CheckAssertion verifies an ES256 assertion: the authenticator data must
+// be for RelyingParty with the user present, and the signature over it and
+// the client data hash must match the public key
+func CheckAssertion(publicKeyPEM string, clientDataHash, authData, signature []byte) error {
+	pub, err := parsePublicKey(publicKeyPEM)
+	if err != nil {
+		return err
+	}
+
+	// rpIdHash (32) | flags (1) | signCount (4)
+	if len(authData) < 37 {
+		return fmt.Errorf("authenticator data too short")
+	}
+	rpHash := sha256.Sum256([]byte(RelyingParty))
+	if !bytes.Equal(authData[:32], rpHash[:]) {
+		return fmt.Errorf("assertion is for another relying party")
+	}
+	if authData[32]&flagUserPresent == 0 {
+		return fmt.Errorf("security key was not touched")
+	}
+
+	signed := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash...))
+	if !ecdsa.VerifyASN1(pub, signed[:], signature) {
+		return fmt.Errorf("signature does not match the registered key")
+	}
+	return nil
+}
+
+// parsePublicKey decodes a PEM ES256 public key
+func parsePublicKey(publicKeyPEM string) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil {
+		return nil, fmt.Errorf("invalid public key")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	pub, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("only ES256 keys are supported")
+	}
+	return pub, nil
+}
+
+// unwrapCBORBytes returns the content of a CBOR byte string
+func unwrapCBORBytes(data []byte) ([]byte, error) {
+	if len(data) == 0 || data[0]>>5 != 2 {
+		return nil, fmt.Errorf("authenticator data is not a CBOR byte string")
+	}
+
+	length, header := int(data[0]&0x1f), 1
+	switch length {
+	case 24:
+		if len(data) < 2 {
+			return nil, fmt.Errorf("truncated CBOR byte string")
+		}
+		length, header = int(data[1]), 2
+	case 25:
+		if len(data) < 3 {
+			return nil, fmt.Errorf("truncated CBOR byte string")
+		}
+		length, header = int(data[1])<<8|int(data[2]), 3
+	default:
+		if length > 23 {
+			return nil, fmt.Errorf("unsupported CBOR byte string length")
+		}
+	}
+	if len(data) != header+length {
+		return nil, fmt.Errorf("truncated CBOR byte string")
+	}
+	return data[header:], nil
+}
+
+// runTool runs a libfido2 tool with input on stdin
+func runTool(ctx context.Context, tool string, input []byte, args ...string) ([]byte, error) {
+	path, err := exec.LookPath(tool)
+	if err != nil {
+		return nil, ErrToolsMissing
+	}
+
+	cmd := exec.CommandContext(ctx, path, args...)
+	if input != nil {
+		cmd.Stdin = bytes.NewReader(input)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %s", tool, msg)
+		}
+		return nil, fmt.Errorf("%s: %w", tool, err)
+	}
+	return out, nil
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("failed to generate challenge: %w", err)
+	}
+	return b, nil
+}
+
+func encode(b []byte) string {
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func decode(s string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+}
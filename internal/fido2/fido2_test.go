@@ -0,0 +1,130 @@
+package fido2_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"wyrmlock/internal/fido2"
+)
+
+func TestCheckAssertion(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("Failed to encode key: %v", err)
+	}
+	publicKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	rpHash := sha256.Sum256([]byte(fido2.RelyingParty))
+	authData := append(rpHash[:], 0x01, 0, 0, 0, 7)
+	clientDataHash := sha256.Sum256([]byte("challenge"))
+	sign := func(authData []byte) []byte {
+		digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+		sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+		if err != nil {
+			t.Fatalf("Failed to sign: %v", err)
+		}
+		return sig
+	}
+
+	if err := fido2.CheckAssertion(publicKey, clientDataHash[:], authData, sign(authData)); err != nil {
+		t.Errorf("Expected a valid assertion to verify, got %v", err)
+	}
+
+	other := sha256.Sum256([]byte("challenge 2"))
+	if err := fido2.CheckAssertion(publicKey, other[:], authData, sign(authData)); err == nil {
+		t.Error("Expected an assertion for another challenge to fail")
+	}
+
+	notTouched := append(rpHash[:], 0x00, 0, 0, 0, 7)
+	if err := fido2.CheckAssertion(publicKey, clientDataHash[:], notTouched, sign(notTouched)); err == nil {
+		t.Error("Expected an assertion without user presence to fail")
+	}
+
+	otherRP := sha256.Sum256([]byte("example.com"))
+	foreign := append(otherRP[:], 0x01, 0, 0, 0, 7)
+	if err := fido2.CheckAssertion(publicKey, clientDataHash[:], foreign, sign(foreign)); err == nil {
+		t.Error("Expected an assertion for another relying party to fail")
+	}
+}
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fido2.json")
+
+	store, err := fido2.Load(path)
+	if err != nil || len(store.Credentials) != 0 {
+		t.Fatalf("Expected an empty store for a missing file, got %v, %v", store, err)
+	}
+
+	store.Credentials = append(store.Credentials,
+		fido2.Credential{Name: "blue", ID: "aWQx"},
+		fido2.Credential{Name: "black", ID: "aWQy"})
+	if err := store.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := fido2.Load(path)
+	if err != nil || len(loaded.Credentials) != 2 {
+		t.Fatalf("Expected 2 credentials, got %v, %v", loaded, err)
+	}
+	if !loaded.Remove("blue") || loaded.Remove("blue") || len(loaded.Credentials) != 1 {
+		t.Errorf("Expected blue to be removed once, got %v", loaded.Credentials)
+	}
+}
+
+func TestCheckRegistered(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("Failed to encode key: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "fido2.json")
+	store := &fido2.Store{Credentials: []fido2.Credential{{
+		Name:      "blue",
+		ID:        "aWQx",
+		PublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+	}}}
+	if err := store.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	challenge, err := fido2.NewChallenge()
+	if err != nil {
+		t.Fatalf("NewChallenge failed: %v", err)
+	}
+	rpHash := sha256.Sum256([]byte(fido2.RelyingParty))
+	authData := append(rpHash[:], 0x01, 0, 0, 0, 7)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), challenge...))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+
+	assertion := fido2.Assertion{CredentialID: "aWQx", AuthData: authData, Signature: sig}
+	if err := fido2.CheckRegistered(path, challenge, assertion); err != nil {
+		t.Errorf("Expected the assertion to verify, got %v", err)
+	}
+
+	stale, _ := fido2.NewChallenge()
+	if err := fido2.CheckRegistered(path, stale, assertion); !errors.Is(err, fido2.ErrNotVerified) {
+		t.Errorf("Expected an assertion for another challenge to be rejected, got %v", err)
+	}
+
+	assertion.CredentialID = "aWQy"
+	if err := fido2.CheckRegistered(path, challenge, assertion); !errors.Is(err, fido2.ErrNotVerified) {
+		t.Errorf("Expected an unregistered credential to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+// Package fprintd verifies fingerprints through the fprintd daemon on the
+// system bus, so users with enrolled fingers can unlock with a touch
+package fprintd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	service          = "net.reactivated.Fprint"
+	managerPath      = "/net/reactivated/Fprint/Manager"
+	managerInterface = "net.reactivated.Fprint.Manager"
+	deviceInterface  = "net.reactivated.Fprint.Device"
+
+	// anyFinger verifies against all enrolled fingers
+	anyFinger = "any"
+)
+
+var (
+	// ErrNoDevice is returned when fprintd is not running or no reader is
+	// connected
+	ErrNoDevice = errors.New("no fingerprint reader available")
+
+	// ErrNotEnrolled is returned when the user has no enrolled fingers
+	ErrNotEnrolled = errors.New("no fingerprints enrolled")
+)
+
+// Status is the outcome of one VerifyStatus signal
+type Status int
+
+const (
+	// StatusMatch means the finger matched
+	StatusMatch Status = iota
+
+	// StatusNoMatch means the finger did not match
+	StatusNoMatch
+
+	// StatusRetry means the scan was unusable and the user should try
+	// again, e.g. a swipe that was too short
+	StatusRetry
+
+	// StatusFailed means the reader failed or was disconnected
+	StatusFailed
+)
+
+// ParseStatus classifies a VerifyStatus result string
+func ParseStatus(result string) Status {
+	switch result {
+	case "verify-match":
+		return StatusMatch
+	case "verify-no-match":
+		return StatusNoMatch
+	case "verify-retry-scan", "verify-swipe-too-short",
+		"verify-finger-not-centered", "verify-remove-and-retry":
+		return StatusRetry
+	default:
+		// verify-disconnected, verify-unknown-error
+		return StatusFailed
+	}
+}
+
+// Verify claims the default reader for username ("" for the caller) and
+// waits until a finger matches or is rejected, or ctx ends. Unusable
+// scans are retried until fprintd gives up.
+func Verify(ctx context.Context, username string) (bool, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to the system bus: %w", err)
+	}
+	defer conn.Close()
+
+	var devicePath dbus.ObjectPath
+	manager := conn.Object(service, managerPath)
+	if err := manager.CallWithContext(ctx, managerInterface+".GetDefaultDevice", 0).Store(&devicePath); err != nil {
+		return false, fmt.Errorf("%w: %v", ErrNoDevice, err)
+	}
+	device := conn.Object(service, devicePath)
+
+	if err := device.CallWithContext(ctx, deviceInterface+".Claim", 0, username).Err; err != nil {
+		return false, fmt.Errorf("failed to claim fingerprint reader: %w", err)
+	}
+	defer device.Call(deviceInterface+".Release", 0)
+
+	var fingers []string
+	if err := device.CallWithContext(ctx, deviceInterface+".ListEnrolledFingers", 0, username).Store(&fingers); err != nil {
+		if isNoEnrolledPrints(err) {
+			return false, ErrNotEnrolled
+		}
+		return false, fmt.Errorf("failed to list enrolled fingers: %w", err)
+	}
+	if len(fingers) == 0 {
+		return false, ErrNotEnrolled
+	}
+
+	if err := conn.AddMatchSignal(
+		dbus.WithMatchObjectPath(devicePath),
+		dbus.WithMatchInterface(deviceInterface),
+		dbus.WithMatchMember("VerifyStatus"),
+	); err != nil {
+		return false, fmt.Errorf("failed to subscribe to fingerprint results: %w", err)
+	}
+	signals := make(chan *dbus.Signal, 8)
+	conn.Signal(signals)
+
+	if err := device.CallWithContext(ctx, deviceInterface+".VerifyStart", 0, anyFinger).Err; err != nil {
+		return false, fmt.Errorf("failed to start fingerprint verification: %w", err)
+	}
+	defer device.Call(deviceInterface+".VerifyStop", 0)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case signal, ok := <-signals:
+			if !ok {
+				return false, fmt.Errorf("system bus connection closed")
+			}
+			if signal.Name != deviceInterface+".VerifyStatus" || len(signal.Body) < 2 {
+				continue
+			}
+			result, _ := signal.Body[0].(string)
+			done, _ := signal.Body[1].(bool)
+
+			switch ParseStatus(result) {
+			case StatusMatch:
+				return true, nil
+			case StatusNoMatch:
+				if done {
+					return false, nil
+				}
+			case StatusFailed:
+				return false, fmt.Errorf("fingerprint reader failed: %s", result)
+			}
+			if done {
+				// fprintd stopped after a retry status
+				return false, nil
+			}
+		}
+	}
+}
+
+// isNoEnrolledPrints reports whether err is fprintd's NoEnrolledPrints
+func isNoEnrolledPrints(err error) bool {
+	var dbusErr dbus.Error
+	if errors.As(err, &dbusErr) {
+		return strings.HasSuffix(dbusErr.Name, ".NoEnrolledPrints")
+	}
+	return false
+}
@@ -0,0 +1,26 @@
+package fprintd_test
+
+import (
+	"testing"
+
+	"wyrmlock/internal/fprintd"
+)
+
+func TestParseStatus(t *testing.T) {
+	tests := map[string]fprintd.Status{
+		"verify-match":               fprintd.StatusMatch,
+		"verify-no-match":            fprintd.StatusNoMatch,
+		"verify-retry-scan":          fprintd.StatusRetry,
+		"verify-swipe-too-short":     fprintd.StatusRetry,
+		"verify-finger-not-centered": fprintd.StatusRetry,
+		"verify-remove-and-retry":    fprintd.StatusRetry,
+		"verify-disconnected":        fprintd.StatusFailed,
+		"verify-unknown-error":       fprintd.StatusFailed,
+		"something-new":              fprintd.StatusFailed,
+	}
+	for result, want := range tests {
+		if got := fprintd.ParseStatus(result); got != want {
+			t.Errorf("ParseStatus(%q) = %v, want %v", result, got, want)
+		}
+	}
+}
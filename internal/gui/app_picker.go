@@ -0,0 +1,114 @@
+package gui
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// Sources of the apps offered by the picker
+const (
+	// PickerSourceDesktop is an installed application with a .desktop entry
+	PickerSourceDesktop = "installed"
+
+	// PickerSourceRunning is an executable seen running
+	PickerSourceRunning = "running"
+
+	// PickerSourceConfig is an app protected by the configuration only
+	PickerSourceConfig = "configured"
+)
+
+// PickerApp is a row of the protected-app picker
+type PickerApp struct {
+	Name      string
+	Path      string
+	Source    string
+	Protected bool
+}
+
+// PickerChanges are the apps the user switched protection on or off
+type PickerChanges struct {
+	Protect   []PickerApp
+	Unprotect []PickerApp
+}
+
+// Empty reports whether the user changed nothing
+func (c PickerChanges) Empty() bool {
+	return len(c.Protect) == 0 && len(c.Unprotect) == 0
+}
+
+// SortPickerApps orders rows with protected apps first, then by name
+func SortPickerApps(apps []PickerApp) {
+	sort.SliceStable(apps, func(i, j int) bool {
+		if apps[i].Protected != apps[j].Protected {
+			return apps[i].Protected
+		}
+		return strings.ToLower(apps[i].Name) < strings.ToLower(apps[j].Name)
+	})
+}
+
+// ShowAppPicker lets the user toggle protection for apps in a zenity
+// checklist and returns what changed. ok is false when the user cancelled.
+func ShowAppPicker(apps []PickerApp) (PickerChanges, bool, error) {
+	if _, err := exec.LookPath("zenity"); err != nil {
+		return PickerChanges{}, false, fmt.Errorf("zenity command not found; please install zenity package: %w", err)
+	}
+
+	output, err := exec.Command("zenity", pickerArgs(apps)...).Output()
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		return PickerChanges{}, false, nil // User cancelled
+	} else if err != nil {
+		return PickerChanges{}, false, fmt.Errorf("error showing app picker: %w", err)
+	}
+
+	return DiffPicks(apps, ParsePickerOutput(string(output))), true, nil
+}
+
+// pickerArgs builds the zenity checklist, printing the selected paths
+func pickerArgs(apps []PickerApp) []string {
+	args := []string{
+		"--list", "--checklist",
+		"--title", "Protected Applications",
+		"--text", "Choose the applications that require authentication to start:",
+		"--width=720", "--height=520",
+		"--column", "Protect", "--column", "Application", "--column", "Path", "--column", "Source",
+		"--print-column=3", "--separator=\n",
+		"--ok-label=Save",
+	}
+	for _, app := range apps {
+		args = append(args, strings.ToUpper(fmt.Sprint(app.Protected)), app.Name, app.Path, app.Source)
+	}
+	return args
+}
+
+// ParsePickerOutput returns the paths selected in the checklist
+func ParsePickerOutput(output string) []string {
+	var paths []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			paths = append(paths, line)
+		}
+	}
+	return paths
+}
+
+// DiffPicks compares the selected paths with the rows shown. Apps that were
+// not shown are never changed.
+func DiffPicks(apps []PickerApp, selected []string) PickerChanges {
+	chosen := make(map[string]bool, len(selected))
+	for _, path := range selected {
+		chosen[path] = true
+	}
+
+	var changes PickerChanges
+	for _, app := range apps {
+		switch {
+		case chosen[app.Path] && !app.Protected:
+			changes.Protect = append(changes.Protect, app)
+		case !chosen[app.Path] && app.Protected:
+			changes.Unprotect = append(changes.Unprotect, app)
+		}
+	}
+	return changes
+}
@@ -0,0 +1,30 @@
+package gui_test
+
+import (
+	"testing"
+
+	"wyrmlock/internal/gui"
+)
+
+func TestDiffPicks(t *testing.T) {
+	apps := []gui.PickerApp{
+		{Name: "Steam", Path: "/usr/games/steam", Source: gui.PickerSourceDesktop, Protected: true},
+		{Name: "Firefox", Path: "/usr/lib/firefox/firefox", Source: gui.PickerSourceDesktop},
+		{Name: "GIMP", Path: "/usr/bin/gimp", Source: gui.PickerSourceDesktop, Protected: true},
+		{Name: "vim", Path: "/usr/bin/vim", Source: gui.PickerSourceRunning},
+	}
+
+	selected := gui.ParsePickerOutput("/usr/games/steam\n/usr/lib/firefox/firefox\n")
+	changes := gui.DiffPicks(apps, selected)
+
+	if len(changes.Protect) != 1 || changes.Protect[0].Path != "/usr/lib/firefox/firefox" {
+		t.Errorf("Expected Firefox to be protected, got %+v", changes.Protect)
+	}
+	if len(changes.Unprotect) != 1 || changes.Unprotect[0].Path != "/usr/bin/gimp" {
+		t.Errorf("Expected GIMP to be unprotected, got %+v", changes.Unprotect)
+	}
+
+	if !gui.DiffPicks(apps, gui.ParsePickerOutput("/usr/games/steam\n/usr/bin/gimp\n")).Empty() {
+		t.Error("Expected no changes when the selection matches the protected apps")
+	}
+}
@@ -0,0 +1,35 @@
+package gui
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+
+	"wyrmlock/internal/i18n"
+)
+
+// ApprovalText asks an administrator to approve a user's launch
+func ApprovalText(appName, user string) string {
+	return i18n.T("%s wants to open %s.\n\nApprove this launch?", user, appName)
+}
+
+// ConfirmApproval asks an administrator to approve a user's launch,
+// returning false when they deny it or ctx is done first
+func ConfirmApproval(ctx context.Context, appName, user string) (bool, error) {
+	if _, err := exec.LookPath("zenity"); err != nil {
+		return false, fmt.Errorf("zenity command not found; please install zenity package: %w", err)
+	}
+
+	err := exec.CommandContext(ctx, "zenity", "--question", "--icon-name=dialog-password",
+		"--title", i18n.T("Approval requested"), "--text", ApprovalText(appName, user),
+		"--ok-label="+i18n.T("Approve"), "--cancel-label="+i18n.T("Deny"), "--width=420").Run()
+	if ctx.Err() != nil {
+		return false, nil
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		return false, nil // Administrator declined
+	} else if err != nil {
+		return false, fmt.Errorf("error showing approval dialog: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,28 @@
+package gui
+
+import (
+	"fmt"
+	"os/exec"
+
+	"wyrmlock/internal/i18n"
+)
+
+// ConfirmBinaryChange asks whether to approve a new version of a protected
+// app whose executable no longer matches its pinned hash. It returns false
+// when the user declines or closes the dialog.
+func ConfirmBinaryChange(appName, hash string) (bool, error) {
+	if _, err := exec.LookPath("zenity"); err != nil {
+		return false, fmt.Errorf("zenity command not found; please install zenity package: %w", err)
+	}
+
+	text := i18n.T("The program %s has changed since it was last approved.\n\nNew SHA-256: %s\n\nApprove the new version?", appName, hash)
+	err := exec.Command("zenity", "--question", "--icon-name=dialog-warning",
+		"--title", i18n.T("Binary changed"), "--text", text,
+		"--ok-label="+i18n.T("Approve"), "--cancel-label="+i18n.T("Deny"), "--width=480").Run()
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		return false, nil // User declined
+	} else if err != nil {
+		return false, fmt.Errorf("error showing binary change dialog: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,78 @@
+package gui
+
+import (
+	"strings"
+
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/i18n"
+)
+
+// DefaultDialogTitle is the title of authentication dialogs unless a
+// deployment sets its own
+const DefaultDialogTitle = "Authentication Required - {app}"
+
+// Branding customises authentication dialogs for a deployment. Title and
+// Message are templates in which {app} is replaced with the application's
+// name and {path} with its executable.
+type Branding struct {
+	Title   string
+	Message string
+
+	// Logo is an image shown in place of the padlock icon
+	Logo string
+
+	// Primary and Background replace the theme's colors when set
+	Primary    string
+	Background string
+}
+
+// BrandingFromConfig returns the dialog branding set in cfg
+func BrandingFromConfig(cfg *config.Config) Branding {
+	return Branding{
+		Title:      cfg.Auth.DialogTitle,
+		Message:    cfg.Auth.DialogMessage,
+		Logo:       cfg.Auth.DialogLogo,
+		Primary:    cfg.Auth.DialogPrimaryColor,
+		Background: cfg.Auth.DialogBackgroundColor,
+	}
+}
+
+// TitleFor returns the dialog title for an application. Titles and
+// notices found in the message catalog are translated too.
+func (b Branding) TitleFor(appName, path string) string {
+	title := b.Title
+	if title == "" {
+		title = DefaultDialogTitle
+	}
+	return expandPlaceholders(i18n.T(title), appName, path)
+}
+
+// MessageFor returns the notice shown with the prompt for an application,
+// or "" when there is none
+func (b Branding) MessageFor(appName, path string) string {
+	if b.Message == "" {
+		return ""
+	}
+	return expandPlaceholders(i18n.T(b.Message), appName, path)
+}
+
+// Apply returns theme with the branding's colors in place of its own
+func (b Branding) Apply(theme DialogTheme) DialogTheme {
+	if b.Primary != "" {
+		theme.Primary = b.Primary
+	}
+	if b.Background != "" {
+		theme.Background = b.Background
+		theme.Surface = b.Background
+	}
+	return theme
+}
+
+// expandPlaceholders fills in {app} and {path}; the path falls back to the
+// name when it is not known
+func expandPlaceholders(text, appName, path string) string {
+	if path == "" {
+		path = appName
+	}
+	return strings.NewReplacer("{app}", appName, "{path}", path).Replace(text)
+}
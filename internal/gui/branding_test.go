@@ -0,0 +1,50 @@
+package gui_test
+
+import (
+	"testing"
+
+	"wyrmlock/internal/gui"
+)
+
+func TestBrandingText(t *testing.T) {
+	var plain gui.Branding
+	if got := plain.TitleFor("Firefox", "/usr/bin/firefox"); got != "Authentication Required - Firefox" {
+		t.Errorf("Expected the default title, got %q", got)
+	}
+	if got := plain.MessageFor("Firefox", "/usr/bin/firefox"); got != "" {
+		t.Errorf("Expected no notice, got %q", got)
+	}
+
+	branding := gui.Branding{
+		Title:   "Acme IT - {app}",
+		Message: "{app} ({path}) is restricted; access is logged.",
+	}
+	if got := branding.TitleFor("Firefox", "/usr/bin/firefox"); got != "Acme IT - Firefox" {
+		t.Errorf("Unexpected title %q", got)
+	}
+	if got := branding.MessageFor("Firefox", "/usr/bin/firefox"); got != "Firefox (/usr/bin/firefox) is restricted; access is logged." {
+		t.Errorf("Unexpected notice %q", got)
+	}
+	if got := branding.MessageFor("Firefox", ""); got != "Firefox (Firefox) is restricted; access is logged." {
+		t.Errorf("Expected the name in place of an unknown path, got %q", got)
+	}
+}
+
+func TestBrandingApply(t *testing.T) {
+	branding := gui.Branding{Primary: "#ff6600", Background: "#202020"}
+
+	theme := branding.Apply(gui.DarkTheme)
+	if theme.Primary != "#ff6600" || theme.Background != "#202020" || theme.Surface != "#202020" {
+		t.Errorf("Branded colors not applied: %+v", theme)
+	}
+	if theme.OnPrimary != gui.DarkTheme.OnPrimary {
+		t.Errorf("Expected other colors to be kept, got %+v", theme)
+	}
+	if !theme.IsDark() {
+		t.Error("Expected the branded dark theme to stay dark")
+	}
+
+	if theme := (gui.Branding{}).Apply(gui.LightTheme); theme != gui.LightTheme {
+		t.Errorf("Expected no branding to leave the theme alone, got %+v", theme)
+	}
+}
@@ -0,0 +1,137 @@
+package gui
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+
+	"wyrmlock/internal/i18n"
+	"wyrmlock/internal/secure"
+)
+
+// DefaultDialogTimeout is how long an authentication dialog stays open
+// before it is treated as unanswered
+const DefaultDialogTimeout = 60 * time.Second
+
+// maxSecretLength bounds what a dialog tool may print as the password
+const maxSecretLength = 4096
+
+// ErrDialogTimeout is returned for a dialog the user did not answer in time
+var ErrDialogTimeout = errors.New("authentication dialog timed out")
+
+// DialogFunc shows an authentication dialog for the application named
+// appName, running path, until it is answered or ctx ends. The password is
+// returned in locked memory the caller destroys.
+type DialogFunc func(ctx context.Context, appName, path string) (*secure.Buffer, bool, error)
+
+// DialogQueue shows authentication dialogs one at a time, in the order they
+// were requested, instead of stacking them on top of each other. Each dialog
+// is closed when its timeout expires and names how many are waiting behind it.
+type DialogQueue struct {
+	show DialogFunc
+
+	mu      sync.Mutex
+	turn    *sync.Cond
+	timeout time.Duration
+	next    uint64
+	serving uint64
+}
+
+// NewDialogQueue creates a queue showing dialogs with show. A timeout of 0
+// uses DefaultDialogTimeout.
+func NewDialogQueue(show DialogFunc, timeout time.Duration) *DialogQueue {
+	q := &DialogQueue{show: show}
+	q.turn = sync.NewCond(&q.mu)
+	q.SetTimeout(timeout)
+	return q
+}
+
+// SetTimeout changes how long later dialogs stay open. A timeout of 0 uses
+// DefaultDialogTimeout.
+func (q *DialogQueue) SetTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultDialogTimeout
+	}
+	q.mu.Lock()
+	q.timeout = timeout
+	q.mu.Unlock()
+}
+
+// Show waits for the dialogs queued before it, then shows one for appName,
+// whose executable is path. It returns ErrDialogTimeout when the dialog was
+// not answered in time.
+func (q *DialogQueue) Show(appName, path string) (*secure.Buffer, bool, error) {
+	q.mu.Lock()
+	ticket := q.next
+	q.next++
+	for ticket != q.serving {
+		q.turn.Wait()
+	}
+	waiting := int(q.next - q.serving - 1)
+	timeout := q.timeout
+	q.mu.Unlock()
+
+	defer func() {
+		q.mu.Lock()
+		q.serving++
+		q.turn.Broadcast()
+		q.mu.Unlock()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	password, ok, err := q.show(ctx, QueueLabel(appName, waiting), path)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		password.Destroy()
+		return nil, false, ErrDialogTimeout
+	}
+	return password, ok, err
+}
+
+// Waiting returns how many dialogs are queued behind the one showing
+func (q *DialogQueue) Waiting() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.next == q.serving {
+		return 0
+	}
+	return int(q.next - q.serving - 1)
+}
+
+// QueueLabel is the name shown in a dialog with waiting more queued behind it
+func QueueLabel(appName string, waiting int) string {
+	if waiting <= 0 {
+		return appName
+	}
+	return i18n.T("%s (%d more waiting)", appName, waiting)
+}
+
+// runSecret runs a dialog tool and reads what it prints straight into
+// locked memory, rather than through the copies cmd.Output makes
+func runSecret(cmd *exec.Cmd) (*secure.Buffer, error) {
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	output, readErr := secure.Read(stdout, maxSecretLength)
+	if readErr != nil {
+		_, _ = io.Copy(io.Discard, stdout)
+	}
+	if err := cmd.Wait(); err != nil {
+		output.Destroy()
+		return nil, err
+	}
+	if readErr != nil {
+		return nil, readErr
+	}
+	return output, nil
+}
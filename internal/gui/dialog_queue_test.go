@@ -0,0 +1,93 @@
+package gui_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"wyrmlock/internal/gui"
+	"wyrmlock/internal/secure"
+)
+
+func TestDialogQueueShowsOneAtATime(t *testing.T) {
+	var mu sync.Mutex
+	open, maxOpen := 0, 0
+	var labels []string
+	release := make(chan struct{})
+
+	queue := gui.NewDialogQueue(func(ctx context.Context, appName, path string) (*secure.Buffer, bool, error) {
+		mu.Lock()
+		open++
+		if open > maxOpen {
+			maxOpen = open
+		}
+		labels = append(labels, appName)
+		mu.Unlock()
+
+		<-release
+
+		mu.Lock()
+		open--
+		mu.Unlock()
+		password, err := secure.FromBytes([]byte("secret"))
+		return password, true, err
+	}, time.Minute)
+
+	shown := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(labels)
+	}
+
+	var wg sync.WaitGroup
+	for i, app := range []string{"firefox", "steam", "thunderbird"} {
+		wg.Add(1)
+		go func(app string) {
+			defer wg.Done()
+			password, ok, err := queue.Show(app, "/usr/bin/"+app)
+			if !ok || err != nil || string(password.Bytes()) != "secret" {
+				t.Errorf("Expected %s to be answered, got ok=%v err=%v", app, ok, err)
+			}
+			password.Destroy()
+		}(app)
+
+		// Queue in a known order
+		for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+			if shown() == 1 && queue.Waiting() == i {
+				break
+			}
+		}
+	}
+
+	if waiting := queue.Waiting(); waiting != 2 {
+		t.Errorf("Expected 2 dialogs waiting, got %d", waiting)
+	}
+	close(release)
+	wg.Wait()
+
+	if maxOpen != 1 {
+		t.Errorf("Expected one dialog open at a time, got %d", maxOpen)
+	}
+	if len(labels) != 3 || labels[0] != "firefox" {
+		t.Fatalf("Expected dialogs in request order, got %v", labels)
+	}
+	if labels[1] != "steam (1 more waiting)" || labels[2] != "thunderbird" {
+		t.Errorf("Expected queue indicators in labels, got %v", labels)
+	}
+}
+
+func TestDialogQueueTimeout(t *testing.T) {
+	queue := gui.NewDialogQueue(func(ctx context.Context, appName, path string) (*secure.Buffer, bool, error) {
+		<-ctx.Done()
+		return nil, false, ctx.Err()
+	}, 20*time.Millisecond)
+
+	if _, ok, err := queue.Show("firefox", "/usr/bin/firefox"); ok || !errors.Is(err, gui.ErrDialogTimeout) {
+		t.Errorf("Expected the dialog to time out, got ok=%v err=%v", ok, err)
+	}
+	if waiting := queue.Waiting(); waiting != 0 {
+		t.Errorf("Expected the queue to be empty, got %d waiting", waiting)
+	}
+}
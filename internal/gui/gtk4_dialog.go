@@ -0,0 +1,411 @@
+//go:build gtk4 && cgo
+
+package gui
+
+/*
+#cgo pkg-config: gtk4
+#include <stdlib.h>
+#include <string.h>
+#include <gtk/gtk.h>
+#ifdef WL_LAYER_SHELL
+#include <gtk4-layer-shell.h>
+#endif
+
+typedef struct {
+	GtkWidget *window;
+	GtkWidget *entry;
+	volatile gint done;
+	volatile gint cancelled;
+	int accepted;
+	char *password;
+	size_t length;
+} wl_dialog;
+
+static int wl_gtk4_init(void) {
+	return gtk_init_check() ? 0 : -1;
+}
+
+static void wl_finish(wl_dialog *d, int accepted) {
+	if (g_atomic_int_get(&d->done)) {
+		return;
+	}
+	if (accepted) {
+		const char *text = gtk_editable_get_text(GTK_EDITABLE(d->entry));
+		d->length = strlen(text);
+		d->password = malloc(d->length + 1);
+		if (d->password != NULL) {
+			memcpy(d->password, text, d->length + 1);
+		}
+	}
+	d->accepted = accepted;
+	gtk_editable_set_text(GTK_EDITABLE(d->entry), "");
+	g_atomic_int_set(&d->done, 1);
+	gtk_window_destroy(GTK_WINDOW(d->window));
+}
+
+static void wl_on_unlock(GtkWidget *widget, gpointer data) {
+	wl_finish((wl_dialog *)data, 1);
+}
+
+static void wl_on_cancel(GtkWidget *widget, gpointer data) {
+	wl_finish((wl_dialog *)data, 0);
+}
+
+static gboolean wl_on_close(GtkWindow *window, gpointer data) {
+	wl_finish((wl_dialog *)data, 0);
+	return TRUE;
+}
+
+static gboolean wl_on_key(GtkEventControllerKey *controller, guint keyval, guint keycode,
+		GdkModifierType state, gpointer data) {
+	if (keyval == GDK_KEY_Escape) {
+		wl_finish((wl_dialog *)data, 0);
+		return TRUE;
+	}
+	return FALSE;
+}
+
+static int wl_layer_shell_supported(void) {
+#ifdef WL_LAYER_SHELL
+	return gtk_layer_is_supported() ? 1 : 0;
+#else
+	return 0;
+#endif
+}
+
+// wl_make_overlay turns window into a layer surface covering every edge of
+// the output above all other windows, holding the keyboard while it is open.
+// It must run before the window is shown.
+static void wl_make_overlay(GtkWindow *window) {
+#ifdef WL_LAYER_SHELL
+	gtk_layer_init_for_window(window);
+	gtk_layer_set_namespace(window, "wyrmlock");
+	gtk_layer_set_layer(window, GTK_LAYER_SHELL_LAYER_OVERLAY);
+	gtk_layer_set_anchor(window, GTK_LAYER_SHELL_EDGE_TOP, TRUE);
+	gtk_layer_set_anchor(window, GTK_LAYER_SHELL_EDGE_BOTTOM, TRUE);
+	gtk_layer_set_anchor(window, GTK_LAYER_SHELL_EDGE_LEFT, TRUE);
+	gtk_layer_set_anchor(window, GTK_LAYER_SHELL_EDGE_RIGHT, TRUE);
+	gtk_layer_set_exclusive_zone(window, -1);
+	gtk_layer_set_keyboard_mode(window, GTK_LAYER_SHELL_KEYBOARD_MODE_EXCLUSIVE);
+#endif
+}
+
+static void wl_apply_theme(const char *css, int dark) {
+	g_object_set(gtk_settings_get_default(), "gtk-application-prefer-dark-theme", dark ? TRUE : FALSE, NULL);
+
+	static GtkCssProvider *provider = NULL;
+	if (provider == NULL) {
+		provider = gtk_css_provider_new();
+		gtk_style_context_add_provider_for_display(gdk_display_get_default(),
+			GTK_STYLE_PROVIDER(provider), GTK_STYLE_PROVIDER_PRIORITY_APPLICATION);
+	}
+	gtk_css_provider_load_from_data(provider, css, -1);
+}
+
+// wl_labels holds the translated text of the dialog
+typedef struct {
+	const char *prompt;
+	const char *cancel;
+	const char *unlock;
+} wl_labels;
+
+static wl_dialog *wl_dialog_new(const char *title, const char *app_name, const char *message,
+		const char *logo, const wl_labels *labels, const char *css, int dark, int overlay) {
+	wl_apply_theme(css, dark);
+
+	wl_dialog *d = calloc(1, sizeof(wl_dialog));
+	if (d == NULL) {
+		return NULL;
+	}
+
+	d->window = gtk_window_new();
+	gtk_window_set_title(GTK_WINDOW(d->window), title);
+	gtk_window_set_modal(GTK_WINDOW(d->window), TRUE);
+	gtk_window_set_resizable(GTK_WINDOW(d->window), FALSE);
+	gtk_widget_add_css_class(d->window, "wyrmlock-auth");
+	g_signal_connect(d->window, "close-request", G_CALLBACK(wl_on_close), d);
+
+	GtkWidget *box = gtk_box_new(GTK_ORIENTATION_VERTICAL, 12);
+	gtk_widget_set_margin_top(box, 20);
+	gtk_widget_set_margin_bottom(box, 20);
+	gtk_widget_set_margin_start(box, 20);
+	gtk_widget_set_margin_end(box, 20);
+	if (overlay) {
+		// The prompt sits in the middle of a dimmed, full-screen surface
+		wl_make_overlay(GTK_WINDOW(d->window));
+		gtk_widget_add_css_class(d->window, "overlay");
+		GtkWidget *card = gtk_box_new(GTK_ORIENTATION_VERTICAL, 0);
+		gtk_widget_add_css_class(card, "prompt");
+		gtk_widget_set_halign(card, GTK_ALIGN_CENTER);
+		gtk_widget_set_valign(card, GTK_ALIGN_CENTER);
+		gtk_widget_set_size_request(card, 400, -1);
+		gtk_box_append(GTK_BOX(card), box);
+		gtk_window_set_child(GTK_WINDOW(d->window), card);
+	} else {
+		gtk_window_set_default_size(GTK_WINDOW(d->window), 400, -1);
+		gtk_window_set_child(GTK_WINDOW(d->window), box);
+	}
+
+	if (logo[0] != '\0') {
+		GtkWidget *picture = gtk_picture_new_for_filename(logo);
+		gtk_picture_set_content_fit(GTK_PICTURE(picture), GTK_CONTENT_FIT_CONTAIN);
+		gtk_widget_set_size_request(picture, -1, 64);
+		gtk_box_append(GTK_BOX(box), picture);
+	}
+
+	GtkWidget *name = gtk_label_new(app_name);
+	gtk_widget_add_css_class(name, "app-name");
+	gtk_label_set_wrap(GTK_LABEL(name), TRUE);
+	gtk_box_append(GTK_BOX(box), name);
+	if (message[0] != '\0') {
+		GtkWidget *notice = gtk_label_new(message);
+		gtk_widget_add_css_class(notice, "notice");
+		gtk_label_set_wrap(GTK_LABEL(notice), TRUE);
+		gtk_label_set_xalign(GTK_LABEL(notice), 0);
+		gtk_box_append(GTK_BOX(box), notice);
+	}
+	gtk_box_append(GTK_BOX(box), gtk_label_new(labels->prompt));
+
+	d->entry = gtk_password_entry_new();
+	gtk_password_entry_set_show_peek_icon(GTK_PASSWORD_ENTRY(d->entry), TRUE);
+	g_signal_connect(d->entry, "activate", G_CALLBACK(wl_on_unlock), d);
+	gtk_box_append(GTK_BOX(box), d->entry);
+
+	GtkWidget *buttons = gtk_box_new(GTK_ORIENTATION_HORIZONTAL, 8);
+	gtk_widget_set_halign(buttons, GTK_ALIGN_END);
+	GtkWidget *cancel = gtk_button_new_with_mnemonic(labels->cancel);
+	g_signal_connect(cancel, "clicked", G_CALLBACK(wl_on_cancel), d);
+	GtkWidget *unlock = gtk_button_new_with_mnemonic(labels->unlock);
+	gtk_widget_add_css_class(unlock, "suggested-action");
+	g_signal_connect(unlock, "clicked", G_CALLBACK(wl_on_unlock), d);
+	gtk_box_append(GTK_BOX(buttons), cancel);
+	gtk_box_append(GTK_BOX(buttons), unlock);
+	gtk_box_append(GTK_BOX(box), buttons);
+
+	GtkEventController *keys = gtk_event_controller_key_new();
+	gtk_event_controller_set_propagation_phase(keys, GTK_PHASE_CAPTURE);
+	g_signal_connect(keys, "key-pressed", G_CALLBACK(wl_on_key), d);
+	gtk_widget_add_controller(d->window, keys);
+
+	gtk_window_set_default_widget(GTK_WINDOW(d->window), unlock);
+	gtk_window_set_focus(GTK_WINDOW(d->window), d->entry);
+	gtk_window_present(GTK_WINDOW(d->window));
+	gtk_widget_grab_focus(d->entry);
+	return d;
+}
+
+// wl_dialog_run iterates the main context until the dialog is answered or
+// cancelled from another thread
+static void wl_dialog_run(wl_dialog *d) {
+	while (!g_atomic_int_get(&d->done)) {
+		if (g_atomic_int_get(&d->cancelled)) {
+			wl_finish(d, 0);
+			break;
+		}
+		g_main_context_iteration(NULL, TRUE);
+	}
+	// Let the window finish closing
+	while (g_main_context_pending(NULL)) {
+		g_main_context_iteration(NULL, FALSE);
+	}
+}
+
+// wl_dialog_cancel is safe to call from any thread while the dialog runs
+static void wl_dialog_cancel(wl_dialog *d) {
+	g_atomic_int_set(&d->cancelled, 1);
+	g_main_context_wakeup(NULL);
+}
+
+static void wl_dialog_free(wl_dialog *d) {
+	if (d->password != NULL) {
+		free(d->password);
+	}
+	free(d);
+}
+*/
+import "C"
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"unsafe"
+
+	"wyrmlock/internal/i18n"
+	"wyrmlock/internal/secure"
+)
+
+// GTK4DialogImpl is a native GTK 4 implementation of the dialog interface.
+// The dialog is modal, takes keyboard focus when it opens, cancels on
+// Escape and follows the light or dark theme. GTK 4 has no pointer or
+// keyboard grabs; the window manager decides whether the dialog stays on
+// top, unless it is shown as a layer-shell overlay.
+type GTK4DialogImpl struct {
+	mu       sync.Mutex
+	theme    DialogTheme
+	branding Branding
+	overlay  bool
+}
+
+// gtk4Calls runs functions on the one OS thread GTK was initialised on
+var (
+	gtk4Once    sync.Once
+	gtk4InitErr error
+	gtk4Calls   chan func()
+)
+
+// startGTK4 initialises GTK on a dedicated, locked OS thread, which then
+// runs every GTK call
+func startGTK4() error {
+	gtk4Once.Do(func() {
+		gtk4Calls = make(chan func())
+		ready := make(chan error)
+		go func() {
+			runtime.LockOSThread()
+			if C.wl_gtk4_init() != 0 {
+				ready <- fmt.Errorf("%w: cannot open a display", ErrGTK4Unavailable)
+				return
+			}
+			ready <- nil
+			for call := range gtk4Calls {
+				call()
+			}
+		}()
+		gtk4InitErr = <-ready
+	})
+	return gtk4InitErr
+}
+
+// NewGTK4DialogImpl creates a new GTK 4 dialog implementation
+func NewGTK4DialogImpl() (*GTK4DialogImpl, error) {
+	if err := startGTK4(); err != nil {
+		return nil, err
+	}
+	return &GTK4DialogImpl{theme: LightTheme}, nil
+}
+
+// NewGTK4OverlayDialogImpl creates a GTK 4 dialog shown as a full-screen
+// overlay through the wlr-layer-shell protocol. The overlay sits above
+// every window and keeps the keyboard until it is answered, so the blocked
+// application cannot cover it or take focus.
+func NewGTK4OverlayDialogImpl() (*GTK4DialogImpl, error) {
+	if err := startGTK4(); err != nil {
+		return nil, err
+	}
+	supported := make(chan bool)
+	gtk4Calls <- func() {
+		supported <- C.wl_layer_shell_supported() != 0
+	}
+	if !<-supported {
+		return nil, fmt.Errorf("%w: needs a build with -tags \"gtk4 layershell\" and a compositor with wlr-layer-shell", ErrLayerShellUnavailable)
+	}
+	return &GTK4DialogImpl{theme: LightTheme, overlay: true}, nil
+}
+
+// SetTheme sets the dialog theme
+func (g *GTK4DialogImpl) SetTheme(theme DialogTheme) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.theme = theme
+}
+
+// SetBranding sets the title, notice and logo of the dialog
+func (g *GTK4DialogImpl) SetBranding(branding Branding) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.branding = branding
+}
+
+// ShowAuthDialog shows an authentication dialog
+func (g *GTK4DialogImpl) ShowAuthDialog(appName string) (*secure.Buffer, bool, error) {
+	return g.ShowAuthDialogContext(context.Background(), appName, "")
+}
+
+// ShowAuthDialogContext shows an authentication dialog for appName, running
+// path, closing it when ctx ends
+func (g *GTK4DialogImpl) ShowAuthDialogContext(ctx context.Context, appName, path string) (*secure.Buffer, bool, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	title := C.CString(g.branding.TitleFor(appName, path))
+	defer C.free(unsafe.Pointer(title))
+	name := C.CString(appName)
+	defer C.free(unsafe.Pointer(name))
+	message := C.CString(g.branding.MessageFor(appName, path))
+	defer C.free(unsafe.Pointer(message))
+	logo := C.CString(g.branding.Logo)
+	defer C.free(unsafe.Pointer(logo))
+	labels := C.wl_labels{
+		prompt: C.CString(i18n.T("Enter password to unlock:")),
+		cancel: C.CString(i18n.T("_Cancel")),
+		unlock: C.CString(i18n.T("_Unlock")),
+	}
+	defer C.free(unsafe.Pointer(labels.prompt))
+	defer C.free(unsafe.Pointer(labels.cancel))
+	defer C.free(unsafe.Pointer(labels.unlock))
+	css := C.CString(GTK4CSS(g.theme))
+	defer C.free(unsafe.Pointer(css))
+	dark := C.int(0)
+	if g.theme.IsDark() {
+		dark = 1
+	}
+	overlay := C.int(0)
+	if g.overlay {
+		overlay = 1
+	}
+
+	var (
+		password *secure.Buffer
+		accepted bool
+		err      error
+	)
+	finished := make(chan struct{})
+	gtk4Calls <- func() {
+		defer close(finished)
+
+		d := C.wl_dialog_new(title, name, message, logo, &labels, css, dark, overlay)
+		if d == nil {
+			err = fmt.Errorf("failed to create GTK 4 dialog")
+			return
+		}
+		defer C.wl_dialog_free(d)
+
+		// Cancellation comes from another goroutine; it must be done
+		// before the dialog is freed
+		var watcher sync.WaitGroup
+		answered := make(chan struct{})
+		watcher.Add(1)
+		go func() {
+			defer watcher.Done()
+			select {
+			case <-ctx.Done():
+				C.wl_dialog_cancel(d)
+			case <-answered:
+			}
+		}()
+		C.wl_dialog_run(d)
+		close(answered)
+		watcher.Wait()
+
+		if d.accepted == 0 {
+			return
+		}
+		accepted = true
+		if d.password == nil {
+			err = fmt.Errorf("failed to read password")
+			return
+		}
+		// FromBytes wipes the C copy once it is in locked memory
+		password, err = secure.FromBytes(unsafe.Slice((*byte)(unsafe.Pointer(d.password)), int(d.length)))
+	}
+	<-finished
+
+	if err != nil {
+		return nil, false, err
+	}
+	if ctx.Err() != nil && !accepted {
+		return nil, false, ctx.Err()
+	}
+	return password, accepted, nil
+}
@@ -0,0 +1,40 @@
+//go:build !gtk4 || !cgo
+
+package gui
+
+import (
+	"context"
+	"fmt"
+
+	"wyrmlock/internal/secure"
+)
+
+// GTK4DialogImpl stands in for the GTK 4 dialog in builds without the
+// gtk4 build tag
+type GTK4DialogImpl struct{}
+
+// NewGTK4DialogImpl reports that this build has no GTK 4 support
+func NewGTK4DialogImpl() (*GTK4DialogImpl, error) {
+	return nil, fmt.Errorf("%w: rebuild with -tags gtk4", ErrGTK4Unavailable)
+}
+
+// NewGTK4OverlayDialogImpl reports that this build has no GTK 4 support
+func NewGTK4OverlayDialogImpl() (*GTK4DialogImpl, error) {
+	return nil, fmt.Errorf("%w: rebuild with -tags \"gtk4 layershell\"", ErrLayerShellUnavailable)
+}
+
+// SetTheme does nothing
+func (g *GTK4DialogImpl) SetTheme(theme DialogTheme) {}
+
+// SetBranding does nothing
+func (g *GTK4DialogImpl) SetBranding(branding Branding) {}
+
+// ShowAuthDialog reports that this build has no GTK 4 support
+func (g *GTK4DialogImpl) ShowAuthDialog(appName string) (*secure.Buffer, bool, error) {
+	return nil, false, ErrGTK4Unavailable
+}
+
+// ShowAuthDialogContext reports that this build has no GTK 4 support
+func (g *GTK4DialogImpl) ShowAuthDialogContext(ctx context.Context, appName, path string) (*secure.Buffer, bool, error) {
+	return nil, false, ErrGTK4Unavailable
+}
@@ -0,0 +1,12 @@
+//go:build gtk4 && layershell && cgo
+
+package gui
+
+// Linking gtk4-layer-shell defines WL_LAYER_SHELL for the C code of the
+// GTK 4 dialog, which then offers the full-screen overlay
+
+/*
+#cgo pkg-config: gtk4-layer-shell-0
+#cgo CFLAGS: -DWL_LAYER_SHELL
+*/
+import "C"
@@ -0,0 +1,63 @@
+package gui
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrGTK4Unavailable is returned when wyrmlock was built without the gtk4
+// build tag or GTK 4 cannot open a display
+var ErrGTK4Unavailable = errors.New("GTK 4 dialogs are unavailable")
+
+// ErrLayerShellUnavailable is returned when wyrmlock was built without the
+// layershell build tag or the compositor does not offer wlr-layer-shell,
+// as on X11 and GNOME
+var ErrLayerShellUnavailable = errors.New("layer-shell overlays are unavailable")
+
+// GTK4CSS returns the style sheet of the GTK 4 dialog for theme
+func GTK4CSS(theme DialogTheme) string {
+	return fmt.Sprintf(`
+window.wyrmlock-auth {
+	background-color: %s;
+	color: %s;
+}
+window.wyrmlock-auth .app-name {
+	font-weight: bold;
+	font-size: larger;
+	color: %s;
+}
+window.wyrmlock-auth .notice {
+	border-left: 4px solid %s;
+	padding-left: 8px;
+}
+window.wyrmlock-auth passwordentry {
+	background-color: %s;
+	color: %s;
+	border-radius: 4px;
+}
+window.wyrmlock-auth button.suggested-action {
+	background-image: none;
+	background-color: %s;
+	color: %s;
+	font-weight: bold;
+}
+window.wyrmlock-auth.overlay {
+	background-color: rgba(0, 0, 0, 0.6);
+}
+window.wyrmlock-auth.overlay .prompt {
+	background-color: %s;
+	border-radius: 12px;
+}
+`, theme.Background, theme.OnBackground,
+		theme.Primary,
+		theme.Error,
+		theme.Surface, theme.OnSurface,
+		theme.Primary, theme.OnPrimary,
+		theme.Background)
+}
+
+// IsDark reports whether theme is the dark theme, with or without branded
+// colors, which also asks GTK for its dark variant
+func (t DialogTheme) IsDark() bool {
+	return t.OnBackground == DarkTheme.OnBackground
+}
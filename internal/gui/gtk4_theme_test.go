@@ -0,0 +1,29 @@
+package gui_test
+
+import (
+	"strings"
+	"testing"
+
+	"wyrmlock/internal/gui"
+)
+
+func TestGTK4CSS(t *testing.T) {
+	for _, theme := range []gui.DialogTheme{gui.LightTheme, gui.DarkTheme} {
+		css := gui.GTK4CSS(theme)
+		for _, color := range []string{theme.Background, theme.OnBackground, theme.Primary, theme.Surface, theme.OnPrimary} {
+			if !strings.Contains(css, color) {
+				t.Errorf("Style sheet is missing %s:\n%s", color, css)
+			}
+		}
+		if !strings.Contains(css, "window.wyrmlock-auth.overlay") {
+			t.Errorf("Style sheet has no overlay rules:\n%s", css)
+		}
+		if strings.Contains(css, "%!") {
+			t.Errorf("Style sheet has a formatting error:\n%s", css)
+		}
+	}
+
+	if gui.LightTheme.IsDark() || !gui.DarkTheme.IsDark() {
+		t.Error("IsDark does not tell the themes apart")
+	}
+}
@@ -1,17 +1,21 @@
 package gui
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
-	"strings"
 	"sync"
+
+	"wyrmlock/internal/i18n"
+	"wyrmlock/internal/secure"
 )
 
 // GTKDialogImpl is a GTK implementation of the dialog interface
 type GTKDialogImpl struct {
-	mu    sync.Mutex
-	theme DialogTheme
+	mu       sync.Mutex
+	theme    DialogTheme
+	branding Branding
 }
 
 // NewGTKDialogImpl creates a new GTK dialog implementation
@@ -33,8 +37,21 @@ func (g *GTKDialogImpl) SetTheme(theme DialogTheme) {
 	g.theme = theme
 }
 
+// SetBranding sets the title, notice and logo of the dialog
+func (g *GTKDialogImpl) SetBranding(branding Branding) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.branding = branding
+}
+
 // ShowAuthDialog shows an authentication dialog using zenity
-func (g *GTKDialogImpl) ShowAuthDialog(appName string) (string, bool, error) {
+func (g *GTKDialogImpl) ShowAuthDialog(appName string) (*secure.Buffer, bool, error) {
+	return g.ShowAuthDialogContext(context.Background(), appName, "")
+}
+
+// ShowAuthDialogContext shows an authentication dialog for appName, running
+// path, using zenity, closing it when ctx ends
+func (g *GTKDialogImpl) ShowAuthDialogContext(ctx context.Context, appName, path string) (*secure.Buffer, bool, error) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
@@ -86,39 +103,48 @@ func (g *GTKDialogImpl) ShowAuthDialog(appName string) (string, bool, error) {
 	// Create a temporary CSS file
 	cssFile, err := os.CreateTemp("", "wyrmlock-gtk-*.css")
 	if err != nil {
-		return "", false, fmt.Errorf("failed to create temporary CSS file: %w", err)
+		return nil, false, fmt.Errorf("failed to create temporary CSS file: %w", err)
 	}
 	defer os.Remove(cssFile.Name())
 
 	if _, err := cssFile.WriteString(css); err != nil {
-		return "", false, fmt.Errorf("failed to write CSS file: %w", err)
+		return nil, false, fmt.Errorf("failed to write CSS file: %w", err)
 	}
 	cssFile.Close()
 
+	text := fmt.Sprintf("<span class='app-name'>%s</span>\n%s", appName, i18n.T("Enter password to unlock:"))
+	if message := g.branding.MessageFor(appName, path); message != "" {
+		text = fmt.Sprintf("%s\n\n%s", message, text)
+	}
+
 	// Use zenity to display the GTK dialog
-	cmd := exec.Command("zenity",
+	args := []string{
 		"--password",
-		"--title", fmt.Sprintf("Authentication Required - %s", appName),
-		"--text", fmt.Sprintf("<span class='app-name'>%s</span>\nEnter password to unlock:", appName),
+		"--title", g.branding.TitleFor(appName, path),
+		"--text", text,
 		"--width=400",
 		"--height=200",
 		"--class=auth-dialog",
-		"--ok-label=Unlock",
-		"--cancel-label=Cancel",
+		"--ok-label=" + i18n.T("Unlock"),
+		"--cancel-label=" + i18n.T("Cancel"),
 		fmt.Sprintf("--gtk-style=%s", cssFile.Name()),
-	)
+	}
+	if g.branding.Logo != "" {
+		args = append(args, "--window-icon="+g.branding.Logo)
+	}
+	cmd := exec.CommandContext(ctx, "zenity", args...)
 
-	// Capture the output
-	output, err := cmd.Output()
+	// Capture the password in locked memory
+	password, err := runSecret(cmd)
 
 	// Check if the user clicked Cancel
 	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
-		return "", false, nil // User cancelled
+		return nil, false, nil // User cancelled
 	} else if err != nil {
-		return "", false, fmt.Errorf("error showing authentication dialog: %w", err)
+		return nil, false, fmt.Errorf("error showing authentication dialog: %w", err)
 	}
 
-	// Parse the output to get the password
-	password := strings.TrimSpace(string(output))
+	// zenity prints a newline after the password
+	password.TrimSpace()
 	return password, true, nil
 }
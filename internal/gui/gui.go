@@ -1,24 +1,86 @@
 package gui
 
 import (
+	"context"
+	"time"
+
 	"wyrmlock/internal/config"
+	"wyrmlock/internal/secure"
 )
 
 // GUI handles the graphical user interface for authentication
 type GUI struct {
-	config *config.Config
+	config  *config.Config
+	dialogs *DialogQueue
+
+	// helper runs zenity or kdialog for the dialogs; nil when neither is
+	// installed
+	helper *HelperDialogImpl
+
+	// terminal prompts on the controlling terminal instead, without a
+	// display or when the terminal GUI type is configured
+	terminal *TerminalDialogImpl
 }
 
 // NewGUI creates a new GUI instance
 func NewGUI(config *config.Config) (*GUI, error) {
-	return &GUI{
+	g := &GUI{
 		config: config,
-	}, nil
+	}
+	if config.Auth.GuiType == string(GuiTypeTerminal) || !HasDisplay() {
+		if terminal, err := NewTerminalDialogImpl(""); err == nil {
+			g.terminal = terminal
+		} else if config.Auth.GuiType == string(GuiTypeTerminal) {
+			return nil, err
+		}
+	}
+	if g.terminal == nil {
+		if helper, err := NewHelperDialogImpl(); err == nil {
+			g.helper = helper
+		}
+	}
+
+	branding := BrandingFromConfig(config)
+	if g.terminal != nil {
+		g.terminal.SetBranding(branding)
+	}
+	if g.helper != nil {
+		g.helper.SetBranding(branding)
+	}
+	g.dialogs = NewDialogQueue(g.showAuthDialog, time.Duration(config.Auth.DialogTimeout)*time.Second)
+	return g, nil
 }
 
-// ShowAuthDialog displays an authentication dialog for the given application
-func (g *GUI) ShowAuthDialog(appName string, callback func(password string)) {
-	// TODO: Implement actual GUI dialog
-	// For now, just call the callback with an empty password
-	callback("")
-} 
\ No newline at end of file
+// ShowAuthDialog queues an authentication dialog for the given application,
+// running path, and calls callback with the password once it has been answered. Dialogs
+// are shown one at a time; an unanswered dialog yields an empty password.
+// The password is destroyed once callback returns.
+func (g *GUI) ShowAuthDialog(appName, path string, callback func(password *secure.Buffer)) {
+	go func() {
+		password, ok, err := g.dialogs.Show(appName, path)
+		if err != nil || !ok {
+			password.Destroy()
+			password = nil
+		}
+		defer password.Destroy()
+		callback(password)
+	}()
+}
+
+// showAuthDialog displays a single dialog
+func (g *GUI) showAuthDialog(ctx context.Context, appName, path string) (*secure.Buffer, bool, error) {
+	if g.terminal != nil {
+		return g.terminal.ShowAuthDialogContext(ctx, appName, path)
+	}
+	if g.helper != nil {
+		return g.helper.ShowAuthDialogContext(ctx, appName, path)
+	}
+	// TODO: Implement a built-in dialog for installs without a helper
+	// For now, just answer with an empty password
+	return nil, true, nil
+}
+
+// ShowNotification displays a desktop notification
+func (g *GUI) ShowNotification(title, message string) error {
+	return SendNotification(title, message)
+}
@@ -0,0 +1,122 @@
+package gui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"wyrmlock/internal/i18n"
+	"wyrmlock/internal/secure"
+)
+
+// Dialog helper programs driven as subprocesses
+const (
+	HelperZenity  = "zenity"
+	HelperKDialog = "kdialog"
+)
+
+// DetectHelper picks the dialog helper for desktop, the value of
+// XDG_CURRENT_DESKTOP: kdialog on KDE when installed, otherwise zenity,
+// otherwise kdialog. It returns the helper's name and path.
+func DetectHelper(desktop string, lookPath func(string) (string, error)) (string, string, error) {
+	order := []string{HelperZenity, HelperKDialog}
+	if strings.Contains(strings.ToUpper(desktop), "KDE") {
+		order = []string{HelperKDialog, HelperZenity}
+	}
+	for _, tool := range order {
+		if path, err := lookPath(tool); err == nil {
+			return tool, path, nil
+		}
+	}
+	return "", "", fmt.Errorf("no dialog helper found; please install zenity or kdialog")
+}
+
+// HelperArgs returns the arguments that make tool ask for the password to
+// unlock appName, running path, with branding's title, notice and logo.
+// zenity's password dialog has no text, so a hidden entry carries the
+// notice instead.
+func HelperArgs(tool, appName, path string, branding Branding) []string {
+	title := branding.TitleFor(appName, path)
+	text := i18n.T("Enter password to unlock %s:", appName)
+	if message := branding.MessageFor(appName, path); message != "" {
+		text = message + "\n\n" + text
+	}
+
+	if tool == HelperKDialog {
+		args := []string{"--title", title, "--password", text}
+		if branding.Logo != "" {
+			args = append(args, "--icon", branding.Logo)
+		}
+		return args
+	}
+
+	args := []string{"--password", "--title", title}
+	if branding.Message != "" {
+		args = []string{"--entry", "--hide-text", "--title", title, "--text", text}
+	}
+	if branding.Logo != "" {
+		args = append(args, "--window-icon="+branding.Logo)
+	}
+	return append(args, "--ok-label="+i18n.T("Unlock"), "--cancel-label="+i18n.T("Cancel"))
+}
+
+// HelperDialogImpl asks for passwords by running zenity or kdialog, so
+// installs without a built-in toolkit still get a graphical prompt
+type HelperDialogImpl struct {
+	tool string
+	path string
+
+	mu       sync.Mutex
+	branding Branding
+}
+
+// NewHelperDialogImpl detects the dialog helper to use
+func NewHelperDialogImpl() (*HelperDialogImpl, error) {
+	tool, path, err := DetectHelper(os.Getenv("XDG_CURRENT_DESKTOP"), exec.LookPath)
+	if err != nil {
+		return nil, err
+	}
+	return &HelperDialogImpl{tool: tool, path: path}, nil
+}
+
+// Tool returns the name of the helper in use
+func (h *HelperDialogImpl) Tool() string {
+	return h.tool
+}
+
+// SetBranding sets the title, notice and logo of the dialogs
+func (h *HelperDialogImpl) SetBranding(branding Branding) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.branding = branding
+}
+
+// ShowAuthDialog shows an authentication dialog
+func (h *HelperDialogImpl) ShowAuthDialog(appName string) (*secure.Buffer, bool, error) {
+	return h.ShowAuthDialogContext(context.Background(), appName, "")
+}
+
+// ShowAuthDialogContext shows an authentication dialog for appName,
+// running path, closing it when ctx ends
+func (h *HelperDialogImpl) ShowAuthDialogContext(ctx context.Context, appName, path string) (*secure.Buffer, bool, error) {
+	h.mu.Lock()
+	branding := h.branding
+	h.mu.Unlock()
+
+	cmd := exec.CommandContext(ctx, h.path, HelperArgs(h.tool, appName, path, branding)...)
+
+	// Both helpers exit with 1 when cancelled
+	password, err := runSecret(cmd)
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, fmt.Errorf("error showing %s dialog: %w", h.tool, err)
+	}
+
+	// Both print a newline after the password
+	password.TrimSpace()
+	return password, true, nil
+}
@@ -0,0 +1,80 @@
+package gui_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"wyrmlock/internal/gui"
+)
+
+func TestDetectHelper(t *testing.T) {
+	lookPath := func(installed ...string) func(string) (string, error) {
+		return func(name string) (string, error) {
+			if slices.Contains(installed, name) {
+				return "/usr/bin/" + name, nil
+			}
+			return "", errors.New("not found")
+		}
+	}
+
+	tests := []struct {
+		name      string
+		desktop   string
+		installed []string
+		want      string
+	}{
+		{"zenity on GNOME", "GNOME", []string{"zenity", "kdialog"}, gui.HelperZenity},
+		{"kdialog on KDE", "KDE", []string{"zenity", "kdialog"}, gui.HelperKDialog},
+		{"zenity on KDE without kdialog", "KDE", []string{"zenity"}, gui.HelperZenity},
+		{"kdialog elsewhere without zenity", "XFCE", []string{"kdialog"}, gui.HelperKDialog},
+		{"none", "", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool, path, err := gui.DetectHelper(tt.desktop, lookPath(tt.installed...))
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("Expected no helper, got %s", tool)
+				}
+				return
+			}
+			if err != nil || tool != tt.want || path != "/usr/bin/"+tt.want {
+				t.Errorf("DetectHelper = %s, %s, %v; want %s", tool, path, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestHelperArgs(t *testing.T) {
+	if args := gui.HelperArgs(gui.HelperKDialog, "Firefox", "/usr/bin/firefox", gui.Branding{}); !slices.Contains(args, "--password") || !slices.Contains(args, "--title") {
+		t.Errorf("Unexpected kdialog arguments: %v", args)
+	}
+	if args := gui.HelperArgs(gui.HelperZenity, "Firefox", "/usr/bin/firefox", gui.Branding{}); args[0] != "--password" {
+		t.Errorf("Unexpected zenity arguments: %v", args)
+	}
+}
+
+func TestHelperArgsBranding(t *testing.T) {
+	branding := gui.Branding{
+		Title:   "Acme - {app}",
+		Message: "Use of {path} is monitored",
+		Logo:    "/usr/share/acme/logo.png",
+	}
+
+	args := gui.HelperArgs(gui.HelperZenity, "Firefox", "/usr/bin/firefox", branding)
+	for _, want := range []string{"--entry", "--hide-text", "Acme - Firefox", "--window-icon=/usr/share/acme/logo.png"} {
+		if !slices.Contains(args, want) {
+			t.Errorf("zenity arguments %v are missing %q", args, want)
+		}
+	}
+	if i := slices.Index(args, "--text"); i < 0 || !strings.HasPrefix(args[i+1], "Use of /usr/bin/firefox is monitored") {
+		t.Errorf("zenity arguments %v do not carry the notice", args)
+	}
+
+	args = gui.HelperArgs(gui.HelperKDialog, "Firefox", "/usr/bin/firefox", branding)
+	if i := slices.Index(args, "--icon"); i < 0 || args[i+1] != branding.Logo {
+		t.Errorf("kdialog arguments %v do not carry the logo", args)
+	}
+}
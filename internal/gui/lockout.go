@@ -0,0 +1,90 @@
+package gui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"time"
+
+	"wyrmlock/internal/i18n"
+)
+
+// FormatCountdown formats the time left as m:ss, or h:mm:ss from an hour
+func FormatCountdown(remaining time.Duration) string {
+	if remaining < 0 {
+		remaining = 0
+	}
+	seconds := int(remaining.Round(time.Second).Seconds())
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// LockoutCountdownText describes a lockout with the time it has left, on
+// one line as zenity reads updates line by line
+func LockoutCountdownText(appName string, remaining time.Duration) string {
+	return i18n.T("%s is locked after too many failed attempts. Try again in %s.",
+		appName, FormatCountdown(remaining))
+}
+
+// ShowLockoutCountdown shows a window counting down to the end of a
+// lockout, closing when it ends, when the user closes it or when ctx is
+// done. It needs zenity; callers fall back to a notification on error.
+func ShowLockoutCountdown(ctx context.Context, appName string, until time.Time) error {
+	path, err := exec.LookPath("zenity")
+	if err != nil {
+		return fmt.Errorf("zenity command not found: %w", err)
+	}
+
+	total := time.Until(until)
+	if total <= 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithDeadline(ctx, until.Add(time.Second))
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path, "--progress", "--title=wyrmlock", "--auto-close",
+		"--percentage=0", "--text="+LockoutCountdownText(appName, total))
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to show lockout countdown: %w", err)
+	}
+
+	// The window closing ends the countdown early
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(exited)
+	}()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		remaining := time.Until(until)
+		done := int(100 * (total - remaining) / total)
+		if remaining <= 0 {
+			done = 100
+		}
+		if _, err := io.WriteString(stdin, fmt.Sprintf("# %s\n%d\n",
+			LockoutCountdownText(appName, remaining), done)); err != nil || done == 100 {
+			stdin.Close()
+			<-exited
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-exited:
+			return nil
+		case <-ctx.Done():
+			<-exited
+			return nil
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package gui_test
+
+import (
+	"testing"
+	"time"
+
+	"wyrmlock/internal/gui"
+)
+
+func TestFormatCountdown(t *testing.T) {
+	tests := []struct {
+		remaining time.Duration
+		want      string
+	}{
+		{-time.Second, "0:00"},
+		{9 * time.Second, "0:09"},
+		{4*time.Minute + 32*time.Second, "4:32"},
+		{time.Hour + 2*time.Minute + 3*time.Second, "1:02:03"},
+		{1500 * time.Millisecond, "0:02"},
+	}
+
+	for _, tt := range tests {
+		if got := gui.FormatCountdown(tt.remaining); got != tt.want {
+			t.Errorf("FormatCountdown(%s) = %q, want %q", tt.remaining, got, tt.want)
+		}
+	}
+}
@@ -1,14 +1,17 @@
 package gui
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"strings"
 	"sync"
+	"time"
 
 	"wyrmlock/internal/logging"
+	"wyrmlock/internal/secure"
 )
 
 // Common errors
@@ -23,6 +26,16 @@ type GuiType string
 const (
 	GuiTypeWebKit GuiType = "webkit"
 	GuiTypeGTK    GuiType = "gtk"
+	GuiTypeGTK4   GuiType = "gtk4"
+	GuiTypeHelper GuiType = "helper"
+
+	// GuiTypeOverlay shows the GTK 4 dialog as a full-screen Wayland
+	// layer-shell overlay, falling back to the ordinary GTK 4 window
+	GuiTypeOverlay GuiType = "overlay"
+
+	// GuiTypeTerminal prompts on the controlling terminal; it is also
+	// used when no display is available
+	GuiTypeTerminal GuiType = "terminal"
 )
 
 // Manager manages GUI interactions for the application
@@ -30,9 +43,14 @@ type Manager struct {
 	mu            sync.Mutex
 	guiType       GuiType
 	theme         DialogTheme
+	branding      Branding
 	webkitDialog  *WebKitDialogImpl
 	gtkDialog     *GTKDialogImpl
+	gtk4Dialog    *GTK4DialogImpl
+	helperDialog  *HelperDialogImpl
+	terminal      *TerminalDialogImpl
 	appIndicator  *AppIndicatorImpl
+	dialogs       *DialogQueue
 	isSystemDark  bool
 	themeCallback func(DialogTheme)
 	logger        *logging.Logger
@@ -53,27 +71,72 @@ func NewManager(guiType GuiType) (*Manager, error) {
 		logger:  logger,
 	}
 
+	// Headless servers and SSH sessions are prompted on the terminal
+	if guiType == GuiTypeTerminal || !HasDisplay() {
+		terminal, err := NewTerminalDialogImpl("")
+		if err == nil {
+			m.terminal = terminal
+			m.dialogs = NewDialogQueue(m.showAuthDialog, DefaultDialogTimeout)
+			logger.Debug("GUI manager prompting on the terminal")
+			return m, nil
+		}
+		if guiType == GuiTypeTerminal {
+			return nil, err
+		}
+	}
+
 	// Initialize dialog implementations
 	var err error
 	switch guiType {
 	case GuiTypeWebKit:
 		m.webkitDialog, err = NewWebKitDialogImpl()
 		if err != nil {
-			return nil, fmt.Errorf("failed to create WebKit dialog: %w", err)
+			err = fmt.Errorf("failed to create WebKit dialog: %w", err)
 		}
 	case GuiTypeGTK:
 		m.gtkDialog, err = NewGTKDialogImpl()
 		if err != nil {
-			return nil, fmt.Errorf("failed to create GTK dialog: %w", err)
+			err = fmt.Errorf("failed to create GTK dialog: %w", err)
+		}
+	case GuiTypeGTK4:
+		m.gtk4Dialog, err = NewGTK4DialogImpl()
+		if err != nil {
+			err = fmt.Errorf("failed to create GTK 4 dialog: %w", err)
+		}
+	case GuiTypeOverlay:
+		m.gtk4Dialog, err = NewGTK4OverlayDialogImpl()
+		if err != nil {
+			logger.Warnf("No overlay: %v", err)
+			m.gtk4Dialog, err = NewGTK4DialogImpl()
 		}
+		if err != nil {
+			err = fmt.Errorf("failed to create GTK 4 dialog: %w", err)
+		}
+	case GuiTypeHelper:
+		err = errors.New("dialog helper requested")
 	default:
 		return nil, fmt.Errorf("unsupported GUI type: %s", guiType)
 	}
 
-	// Initialize app indicator
+	// Without the configured toolkit, zenity or kdialog still prompt
+	if err != nil {
+		helper, helperErr := NewHelperDialogImpl()
+		if helperErr != nil {
+			return nil, fmt.Errorf("%w; %v", err, helperErr)
+		}
+		if guiType != GuiTypeHelper {
+			logger.Warnf("%v; falling back to %s", err, helper.Tool())
+		}
+		m.helperDialog = helper
+	}
+
+	// Dialogs for concurrent launches are shown one after another
+	m.dialogs = NewDialogQueue(m.showAuthDialog, DefaultDialogTimeout)
+
+	// The tray icon is optional, so minimal installs still prompt
 	m.appIndicator, err = NewAppIndicatorImpl()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create app indicator: %w", err)
+		logger.Warnf("No tray icon: %v", err)
 	}
 
 	// Initialize theme
@@ -89,22 +152,55 @@ func (m *Manager) SetTheme(theme DialogTheme) {
 	defer m.mu.Unlock()
 
 	m.theme = theme
+	m.applyTheme()
 
-	// Update dialog implementations
+	// Notify callback if registered
+	if m.themeCallback != nil {
+		m.themeCallback(theme)
+	}
+}
+
+// SetBranding sets the title, notice, logo and colors of authentication
+// dialogs
+func (m *Manager) SetBranding(branding Branding) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.branding = branding
+	if m.webkitDialog != nil {
+		m.webkitDialog.SetBranding(branding)
+	}
+	if m.gtkDialog != nil {
+		m.gtkDialog.SetBranding(branding)
+	}
+	if m.gtk4Dialog != nil {
+		m.gtk4Dialog.SetBranding(branding)
+	}
+	if m.helperDialog != nil {
+		m.helperDialog.SetBranding(branding)
+	}
+	if m.terminal != nil {
+		m.terminal.SetBranding(branding)
+	}
+	m.applyTheme()
+}
+
+// applyTheme passes the theme, with the branding's colors, to the dialog
+// implementations
+func (m *Manager) applyTheme() {
+	theme := m.branding.Apply(m.theme)
 	if m.webkitDialog != nil {
 		m.webkitDialog.SetTheme(theme)
 	}
 	if m.gtkDialog != nil {
 		m.gtkDialog.SetTheme(theme)
 	}
+	if m.gtk4Dialog != nil {
+		m.gtk4Dialog.SetTheme(theme)
+	}
 	if m.appIndicator != nil {
 		m.appIndicator.SetTheme(theme)
 	}
-
-	// Notify callback if registered
-	if m.themeCallback != nil {
-		m.themeCallback(theme)
-	}
 }
 
 // OnThemeChange registers a callback for theme changes
@@ -150,42 +246,63 @@ func (m *Manager) detectSystemTheme() {
 	}
 }
 
-// ShowAuthDialog shows an authentication dialog
-func (m *Manager) ShowAuthDialog(appName string) (string, bool, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// SetDialogTimeout sets how long an authentication dialog stays open before
+// it is treated as unanswered; 0 uses DefaultDialogTimeout
+func (m *Manager) SetDialogTimeout(timeout time.Duration) {
+	m.dialogs.SetTimeout(timeout)
+}
 
-	var password string
-	var ok bool
-	var err error
+// ShowAuthDialog shows an authentication dialog for appName, running path,
+// once the dialogs requested before it have been answered
+func (m *Manager) ShowAuthDialog(appName, path string) (*secure.Buffer, bool, error) {
+	password, ok, err := m.dialogs.Show(appName, path)
+	if errors.Is(err, ErrDialogTimeout) {
+		m.logger.Debug("Auth dialog timed out")
+		return nil, false, err
+	}
+	if err != nil {
+		m.logger.Errorf("Failed to show auth dialog: %v", err)
+		return nil, false, fmt.Errorf("failed to show auth dialog: %w", err)
+	}
+
+	if !ok {
+		password.Destroy()
+		m.logger.Debug("User cancelled auth dialog")
+		return nil, false, nil
+	}
+
+	m.logger.Debug("Auth dialog completed successfully")
+	return password, true, nil
+}
 
+// showAuthDialog shows a dialog with the configured implementation
+func (m *Manager) showAuthDialog(ctx context.Context, appName, path string) (*secure.Buffer, bool, error) {
 	m.logger.Debugf("Showing auth dialog for app: %s", appName)
 
+	if m.terminal != nil {
+		return m.terminal.ShowAuthDialogContext(ctx, appName, path)
+	}
+	if m.helperDialog != nil {
+		return m.helperDialog.ShowAuthDialogContext(ctx, appName, path)
+	}
+
 	switch m.guiType {
 	case GuiTypeWebKit:
 		if m.webkitDialog != nil {
-			password, ok, err = m.webkitDialog.ShowAuthDialog(appName)
+			return m.webkitDialog.ShowAuthDialogContext(ctx, appName, path)
 		}
 	case GuiTypeGTK:
 		if m.gtkDialog != nil {
-			password, ok, err = m.gtkDialog.ShowAuthDialog(appName)
+			return m.gtkDialog.ShowAuthDialogContext(ctx, appName, path)
+		}
+	case GuiTypeGTK4, GuiTypeOverlay:
+		if m.gtk4Dialog != nil {
+			return m.gtk4Dialog.ShowAuthDialogContext(ctx, appName, path)
 		}
 	default:
-		return "", false, fmt.Errorf("%w: %s", ErrUnsupportedGUI, m.guiType)
-	}
-
-	if err != nil {
-		m.logger.Errorf("Failed to show auth dialog: %v", err)
-		return "", false, fmt.Errorf("failed to show auth dialog: %w", err)
+		return nil, false, fmt.Errorf("%w: %s", ErrUnsupportedGUI, m.guiType)
 	}
-
-	if !ok {
-		m.logger.Debug("User cancelled auth dialog")
-		return "", false, nil
-	}
-
-	m.logger.Debug("Auth dialog completed successfully")
-	return password, true, nil
+	return nil, false, nil
 }
 
 // ShowSystemTrayIcon shows the system tray icon
@@ -215,6 +332,6 @@ func (m *Manager) HideSystemTrayIcon() error {
 // DialogImpl is the interface that all dialog implementations must satisfy
 type DialogImpl interface {
 	// ShowAuthDialog shows an authentication dialog
-	// Returns the entered password, a boolean indicating if authentication was attempted, and an error
-	ShowAuthDialog(appName string) (string, bool, error)
+	// Returns the entered password in locked memory the caller destroys, a boolean indicating if authentication was attempted, and an error
+	ShowAuthDialog(appName string) (*secure.Buffer, bool, error)
 }
@@ -0,0 +1,34 @@
+package gui
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// SendNotification shows a desktop notification using notify-send, falling
+// back to zenity when notify-send is not installed
+func SendNotification(title, message string) error {
+	if path, err := exec.LookPath("notify-send"); err == nil {
+		cmd := exec.Command(path, "--app-name=wyrmlock", "--icon=dialog-password", title, message)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to send notification: %w", err)
+		}
+		return nil
+	}
+
+	if path, err := exec.LookPath("zenity"); err == nil {
+		cmd := exec.Command(path, "--notification", fmt.Sprintf("--text=%s\n%s", title, message))
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to send notification: %w", err)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("no notification tool found; please install libnotify (notify-send) or zenity")
+}
+
+// ShowNotification shows a desktop notification
+func (m *Manager) ShowNotification(title, message string) error {
+	m.logger.Debugf("Showing notification: %s", title)
+	return SendNotification(title, message)
+}
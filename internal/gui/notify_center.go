@@ -0,0 +1,227 @@
+package gui
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"wyrmlock/internal/i18n"
+)
+
+// maxRecentNotifications is how many events the notification center keeps
+// for its details view
+const maxRecentNotifications = 100
+
+// maxSummaryLines is how many distinct events a summary lists before
+// collapsing the rest into a count
+const maxSummaryLines = 5
+
+// NotificationEvent is a single event passed to the notification center
+type NotificationEvent struct {
+	Class   string
+	Title   string
+	Message string
+	Time    time.Time
+}
+
+// notificationBatch collects the events of one class until it is flushed
+type notificationBatch struct {
+	title  string
+	order  []string
+	counts map[string]int
+	total  int
+	timer  *time.Timer
+}
+
+// NotificationCenter merges bursts of events into one notification per
+// class and caps how many notifications each class may show per minute.
+// Events held back by the rate limit are merged into the next summary.
+type NotificationCenter struct {
+	mu       sync.Mutex
+	send     func(title, message string) error
+	window   time.Duration
+	limits   map[string]int
+	fallback int
+	batches  map[string]*notificationBatch
+	sent     map[string][]time.Time
+	recent   []NotificationEvent
+	onError  func(error)
+}
+
+// NewNotificationCenter creates a notification center delivering through
+// send. limits maps event classes to notifications per minute; a class not
+// in limits uses defaultLimit, and a limit of 0 mutes the class.
+func NewNotificationCenter(send func(title, message string) error, window time.Duration, limits map[string]int, defaultLimit int) *NotificationCenter {
+	return &NotificationCenter{
+		send:     send,
+		window:   window,
+		limits:   limits,
+		fallback: defaultLimit,
+		batches:  make(map[string]*notificationBatch),
+		sent:     make(map[string][]time.Time),
+	}
+}
+
+// SetErrorHandler sets a callback for notifications that fail to deliver
+func (c *NotificationCenter) SetErrorHandler(handler func(error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onError = handler
+}
+
+// limit returns the per-minute limit for class
+func (c *NotificationCenter) limit(class string) int {
+	if limit, ok := c.limits[class]; ok {
+		return limit
+	}
+	return c.fallback
+}
+
+// Notify queues an event. The first event of a class starts a window; every
+// event of that class arriving within the window ends up in one summary.
+func (c *NotificationCenter) Notify(class, title, message string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	c.recent = append(c.recent, NotificationEvent{Class: class, Title: title, Message: message, Time: now})
+	if len(c.recent) > maxRecentNotifications {
+		c.recent = c.recent[len(c.recent)-maxRecentNotifications:]
+	}
+
+	if c.limit(class) == 0 {
+		return
+	}
+
+	batch := c.batches[class]
+	if batch == nil {
+		batch = &notificationBatch{title: title, counts: make(map[string]int)}
+		c.batches[class] = batch
+		batch.timer = time.AfterFunc(c.window, func() { c.flushClass(class) })
+	}
+
+	if _, seen := batch.counts[message]; !seen {
+		batch.order = append(batch.order, message)
+	}
+	batch.counts[message]++
+	batch.total++
+}
+
+// Flush delivers every pending summary that the rate limits allow now
+func (c *NotificationCenter) Flush() {
+	c.mu.Lock()
+	classes := make([]string, 0, len(c.batches))
+	for class := range c.batches {
+		classes = append(classes, class)
+	}
+	c.mu.Unlock()
+
+	for _, class := range classes {
+		c.flushClass(class)
+	}
+}
+
+// Pending returns the number of events waiting to be summarized for class
+func (c *NotificationCenter) Pending(class string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if batch := c.batches[class]; batch != nil {
+		return batch.total
+	}
+	return 0
+}
+
+// Recent returns the most recent events, oldest first, including those that
+// were merged into summaries or muted
+func (c *NotificationCenter) Recent() []NotificationEvent {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]NotificationEvent(nil), c.recent...)
+}
+
+// Stop cancels pending summaries without delivering them
+func (c *NotificationCenter) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for class, batch := range c.batches {
+		batch.timer.Stop()
+		delete(c.batches, class)
+	}
+}
+
+// flushClass delivers the summary for class, or postpones it until the
+// class is back under its rate limit
+func (c *NotificationCenter) flushClass(class string) {
+	c.mu.Lock()
+
+	batch := c.batches[class]
+	if batch == nil {
+		c.mu.Unlock()
+		return
+	}
+
+	now := time.Now()
+	if wait := c.rateLimitWait(class, now); wait > 0 {
+		batch.timer.Stop()
+		batch.timer = time.AfterFunc(wait, func() { c.flushClass(class) })
+		c.mu.Unlock()
+		return
+	}
+
+	batch.timer.Stop()
+	delete(c.batches, class)
+	c.sent[class] = append(c.sent[class], now)
+
+	title, message := batch.summary()
+	send, onError := c.send, c.onError
+	c.mu.Unlock()
+
+	if err := send(title, message); err != nil && onError != nil {
+		onError(err)
+	}
+}
+
+// rateLimitWait returns how long class must wait before another
+// notification may be shown. Caller holds mu.
+func (c *NotificationCenter) rateLimitWait(class string, now time.Time) time.Duration {
+	cutoff := now.Add(-time.Minute)
+	sent := c.sent[class]
+	for len(sent) > 0 && !sent[0].After(cutoff) {
+		sent = sent[1:]
+	}
+	c.sent[class] = sent
+
+	if len(sent) < c.limit(class) {
+		return 0
+	}
+	return sent[0].Sub(cutoff)
+}
+
+// summary renders the batch as one notification. A single event is shown
+// as is; a burst lists each distinct message with its count.
+func (b *notificationBatch) summary() (string, string) {
+	if b.total == 1 {
+		return b.title, b.order[0]
+	}
+
+	title := fmt.Sprintf("%s (%d)", b.title, b.total)
+
+	var lines []string
+	for i, message := range b.order {
+		if i == maxSummaryLines {
+			lines = append(lines, i18n.T("and %d more", len(b.order)-maxSummaryLines))
+			break
+		}
+		if count := b.counts[message]; count > 1 {
+			lines = append(lines, fmt.Sprintf("%s (×%d)", message, count))
+		} else {
+			lines = append(lines, message)
+		}
+	}
+
+	return title, strings.Join(lines, "\n")
+}
@@ -0,0 +1,117 @@
+package gui_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"wyrmlock/internal/gui"
+)
+
+// recordingSender collects delivered notifications
+type recordingSender struct {
+	mu       sync.Mutex
+	sent     []string
+	messages []string
+}
+
+func (r *recordingSender) send(title, message string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, title)
+	r.messages = append(r.messages, message)
+	return nil
+}
+
+func (r *recordingSender) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.sent)
+}
+
+func TestNotificationCenterAggregatesBurst(t *testing.T) {
+	sender := &recordingSender{}
+	center := gui.NewNotificationCenter(sender.send, time.Hour, nil, 4)
+	defer center.Stop()
+
+	for i := 0; i < 10; i++ {
+		center.Notify("denied", "Access denied", "steam: blocked by schedule")
+	}
+	center.Notify("denied", "Access denied", "firefox: blocked by schedule")
+
+	if got := center.Pending("denied"); got != 11 {
+		t.Fatalf("Expected 11 pending events, got %d", got)
+	}
+
+	center.Flush()
+
+	if sender.count() != 1 {
+		t.Fatalf("Expected one summarized notification, got %d", sender.count())
+	}
+	if sender.sent[0] != "Access denied (11)" {
+		t.Errorf("Unexpected summary title %q", sender.sent[0])
+	}
+	if !strings.Contains(sender.messages[0], "steam: blocked by schedule (×10)") ||
+		!strings.Contains(sender.messages[0], "firefox: blocked by schedule") {
+		t.Errorf("Summary does not list the events: %q", sender.messages[0])
+	}
+	if len(center.Recent()) != 11 {
+		t.Errorf("Expected 11 recent events, got %d", len(center.Recent()))
+	}
+}
+
+func TestNotificationCenterSingleEvent(t *testing.T) {
+	sender := &recordingSender{}
+	center := gui.NewNotificationCenter(sender.send, time.Hour, nil, 4)
+	defer center.Stop()
+
+	center.Notify("lockout", "Access denied", "steam is locked")
+	center.Flush()
+
+	if sender.count() != 1 || sender.sent[0] != "Access denied" || sender.messages[0] != "steam is locked" {
+		t.Errorf("Single event should be shown unchanged, got %v %v", sender.sent, sender.messages)
+	}
+}
+
+func TestNotificationCenterRateLimit(t *testing.T) {
+	sender := &recordingSender{}
+	center := gui.NewNotificationCenter(sender.send, time.Hour, map[string]int{"denied": 1, "lockout": 0}, 4)
+	defer center.Stop()
+
+	center.Notify("denied", "Access denied", "first")
+	center.Flush()
+	center.Notify("denied", "Access denied", "second")
+	center.Flush()
+
+	if sender.count() != 1 {
+		t.Fatalf("Expected rate limit to hold back the second notification, got %d", sender.count())
+	}
+	if center.Pending("denied") != 1 {
+		t.Errorf("Held back event should stay pending")
+	}
+
+	center.Notify("lockout", "Access denied", "muted")
+	center.Flush()
+	if sender.count() != 1 || center.Pending("lockout") != 0 {
+		t.Errorf("Muted class should not be delivered or queued")
+	}
+}
+
+func TestNotificationCenterWindow(t *testing.T) {
+	sender := &recordingSender{}
+	center := gui.NewNotificationCenter(sender.send, 20*time.Millisecond, nil, 4)
+	defer center.Stop()
+
+	center.Notify("denied", "Access denied", "a")
+	center.Notify("denied", "Access denied", "b")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for sender.count() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if sender.count() != 1 {
+		t.Fatalf("Expected the window to deliver one summary, got %d", sender.count())
+	}
+}
@@ -0,0 +1,171 @@
+package gui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"wyrmlock/internal/i18n"
+	"wyrmlock/internal/secure"
+)
+
+// DefaultTerminal is the controlling terminal the terminal prompt uses
+const DefaultTerminal = "/dev/tty"
+
+// HasDisplay reports whether an X11 or Wayland display is available for
+// graphical dialogs
+func HasDisplay() bool {
+	return os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != ""
+}
+
+// SaveTerminal returns a function that restores the settings the terminal
+// at path (the controlling terminal when empty) has now, for programs that
+// may be interrupted while a prompt has echo turned off
+func SaveTerminal(path string) func() {
+	if path == "" {
+		path = DefaultTerminal
+	}
+	tty, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return func() {}
+	}
+	state, err := unix.IoctlGetTermios(int(tty.Fd()), unix.TCGETS)
+	if err != nil {
+		tty.Close()
+		return func() {}
+	}
+	return func() {
+		_ = unix.IoctlSetTermios(int(tty.Fd()), unix.TCSETS, state)
+		tty.Close()
+	}
+}
+
+// TerminalDialogImpl asks for passwords on a terminal, for headless
+// servers and SSH sessions
+type TerminalDialogImpl struct {
+	path string
+
+	mu       sync.Mutex
+	branding Branding
+}
+
+// NewTerminalDialogImpl returns a prompt on the terminal at path, the
+// controlling terminal when empty. It fails when the terminal cannot be
+// opened, e.g. for a process without one.
+func NewTerminalDialogImpl(path string) (*TerminalDialogImpl, error) {
+	if path == "" {
+		path = DefaultTerminal
+	}
+	tty, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("no terminal to prompt on: %w", err)
+	}
+	tty.Close()
+	return &TerminalDialogImpl{path: path}, nil
+}
+
+// SetBranding sets the notice printed with the prompt
+func (t *TerminalDialogImpl) SetBranding(branding Branding) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.branding = branding
+}
+
+// ShowAuthDialog asks for a password on the terminal
+func (t *TerminalDialogImpl) ShowAuthDialog(appName string) (*secure.Buffer, bool, error) {
+	return t.ShowAuthDialogContext(context.Background(), appName, "")
+}
+
+// ShowAuthDialogContext asks for a password on the terminal without
+// echoing it, giving up when ctx ends. An empty answer cancels.
+func (t *TerminalDialogImpl) ShowAuthDialogContext(ctx context.Context, appName, path string) (*secure.Buffer, bool, error) {
+	t.mu.Lock()
+	message := t.branding.MessageFor(appName, path)
+	t.mu.Unlock()
+
+	tty, err := os.OpenFile(t.path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, false, fmt.Errorf("no terminal to prompt on: %w", err)
+	}
+	defer tty.Close()
+
+	fd := int(tty.Fd())
+	state, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s is not a terminal: %w", t.path, err)
+	}
+	noEcho := *state
+	noEcho.Lflag &^= unix.ECHO
+	if err := unix.IoctlSetTermios(fd, unix.TCSETS, &noEcho); err != nil {
+		return nil, false, fmt.Errorf("failed to turn off echo: %w", err)
+	}
+	defer func() {
+		_ = unix.IoctlSetTermios(fd, unix.TCSETS, state)
+		fmt.Fprintln(tty)
+	}()
+
+	fmt.Fprintf(tty, "\nwyrmlock: %s\n", i18n.T("%s is locked.", appName))
+	if message != "" {
+		fmt.Fprintln(tty, message)
+	}
+	fmt.Fprint(tty, i18n.T("Password to unlock (empty to cancel): "))
+
+	// A read deadline interrupts the read when ctx ends
+	stop := context.AfterFunc(ctx, func() { _ = tty.SetReadDeadline(time.Now()) })
+	defer stop()
+
+	password, err := ReadSecretLine(tty)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, false, ctx.Err()
+		}
+		return nil, false, fmt.Errorf("failed to read password: %w", err)
+	}
+	if password.Len() == 0 {
+		password.Destroy()
+		return nil, false, nil
+	}
+	return password, true, nil
+}
+
+// ReadSecretLine reads one line from r into locked memory, without the
+// line ending. Reading a byte at a time leaves the rest of the input
+// unread.
+func ReadSecretLine(r io.Reader) (*secure.Buffer, error) {
+	var line [maxSecretLength]byte
+	n := 0
+	var b [1]byte
+	for {
+		read, err := r.Read(b[:])
+		if read == 1 {
+			if b[0] == '\n' {
+				break
+			}
+			if n == len(line) {
+				secure.Wipe(line[:n])
+				return nil, secure.ErrTooLong
+			}
+			line[n] = b[0]
+			n++
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			secure.Wipe(line[:n])
+			return nil, err
+		}
+	}
+	if n > 0 && line[n-1] == '\r' {
+		n--
+	}
+	password, err := secure.FromBytes(line[:n])
+	secure.Wipe(line[:])
+	return password, err
+}
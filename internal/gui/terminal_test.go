@@ -0,0 +1,58 @@
+package gui_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"wyrmlock/internal/gui"
+	"wyrmlock/internal/secure"
+)
+
+func TestReadSecretLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"line", "hunter2\n", "hunter2"},
+		{"crlf", "hunter2\r\n", "hunter2"},
+		{"eof", "hunter2", "hunter2"},
+		{"empty", "\n", ""},
+		{"spaces kept", " hunter 2 \n", " hunter 2 "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			password, err := gui.ReadSecretLine(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("ReadSecretLine failed: %v", err)
+			}
+			defer password.Destroy()
+			if got := string(password.Bytes()); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestReadSecretLineLeavesRestUnread(t *testing.T) {
+	r := strings.NewReader("first\nsecond\n")
+	password, err := gui.ReadSecretLine(r)
+	if err != nil {
+		t.Fatalf("ReadSecretLine failed: %v", err)
+	}
+	password.Destroy()
+
+	rest, _ := io.ReadAll(r)
+	if string(rest) != "second\n" {
+		t.Errorf("Expected the second line to be left unread, got %q", rest)
+	}
+}
+
+func TestReadSecretLineTooLong(t *testing.T) {
+	input := strings.Repeat("a", 5000) + "\n"
+	if _, err := gui.ReadSecretLine(strings.NewReader(input)); !errors.Is(err, secure.ErrTooLong) {
+		t.Errorf("Expected ErrTooLong, got %v", err)
+	}
+}
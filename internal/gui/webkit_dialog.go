@@ -1,13 +1,16 @@
 package gui
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strings"
 	"sync"
 	"text/template"
+
+	"wyrmlock/internal/i18n"
+	"wyrmlock/internal/secure"
 )
 
 // DialogTheme defines the color scheme for dialogs
@@ -57,6 +60,7 @@ var (
 type WebKitDialogImpl struct {
 	mu          sync.Mutex
 	theme       DialogTheme
+	branding    Branding
 	assetsDir   string
 	templateDir string
 }
@@ -106,6 +110,13 @@ func (w *WebKitDialogImpl) SetTheme(theme DialogTheme) {
 	w.theme = theme
 }
 
+// SetBranding sets the title, notice, logo and colors of the dialog
+func (w *WebKitDialogImpl) SetBranding(branding Branding) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.branding = branding
+}
+
 // initializeTemplates creates the HTML templates
 func (w *WebKitDialogImpl) initializeTemplates() error {
 	authTemplate := `
@@ -114,7 +125,7 @@ func (w *WebKitDialogImpl) initializeTemplates() error {
 <head>
     <meta charset="utf-8">
     <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>Authentication Required</title>
+    <title>{{html .Title}}</title>
     <style>
         :root {
             --primary: {{.Theme.Primary}};
@@ -254,6 +265,19 @@ func (w *WebKitDialogImpl) initializeTemplates() error {
             fill: currentColor;
         }
 
+        .app-logo {
+            width: 48px;
+            height: 48px;
+            object-fit: contain;
+        }
+
+        .notice {
+            margin-bottom: 1.5rem;
+            padding: 0.75rem 1rem;
+            border-left: 4px solid var(--error);
+            color: var(--on-surface);
+        }
+
         .error-message {
             color: var(--error);
             font-size: 0.875rem;
@@ -344,21 +368,28 @@ func (w *WebKitDialogImpl) initializeTemplates() error {
 </head>
 <body>
     <main class="container" role="main">
-        <h2 id="dialog-title">Authentication Required</h2>
+        <h2 id="dialog-title">{{html .Title}}</h2>
         <div class="app-info">
+            {{if .Logo}}
+            <img class="app-logo" src="file://{{html .Logo}}" alt="">
+            {{else}}
             <div class="app-icon" role="img" aria-label="Application icon">
                 <svg viewBox="0 0 24 24" aria-hidden="true">
                     <path d="M18 8h-1V6c0-2.76-2.24-5-5-5S7 3.24 7 6v2H6c-1.1 0-2 .9-2 2v10c0 1.1.9 2 2 2h12c1.1 0 2-.9 2-2V10c0-1.1-.9-2-2-2zM9 6c0-1.66 1.34-3 3-3s3 1.34 3 3v2H9V6zm9 14H6V10h12v10zm-6-3c1.1 0 2-.9 2-2s-.9-2-2-2-2 .9-2 2 .9 2 2 2z"/>
                 </svg>
             </div>
+            {{end}}
             <div>
-                <p>Enter password to unlock:</p>
-                <p class="app-name">{{.AppName}}</p>
+                <p>{{T "Enter password to unlock:" | html}}</p>
+                <p class="app-name">{{html .AppName}}</p>
             </div>
         </div>
+        {{if .Message}}
+        <p class="notice" role="note">{{html .Message}}</p>
+        {{end}}
         <form id="auth-form" aria-labelledby="dialog-title">
             <div class="form-group">
-                <label for="password">Password:</label>
+                <label for="password">{{T "Password:" | html}}</label>
                 <div class="input-wrapper">
                     <input type="password" 
                            id="password" 
@@ -369,7 +400,7 @@ func (w *WebKitDialogImpl) initializeTemplates() error {
                            required>
                     <button type="button" 
                             class="toggle-password" 
-                            aria-label="Toggle password visibility">
+                            aria-label="{{T "Toggle password visibility" | html}}">
                         <svg class="show-password" viewBox="0 0 24 24" aria-hidden="true">
                             <path d="M12 4.5C7 4.5 2.73 7.61 1 12c1.73 4.39 6 7.5 11 7.5s9.27-3.11 11-7.5c-1.73-4.39-6-7.5-11-7.5zM12 17c-2.76 0-5-2.24-5-5s2.24-5 5-5 5 2.24 5 5-2.24 5-5 5zm0-8c-1.66 0-3 1.34-3 3s1.34 3 3 3 3-1.34 3-3-1.34-3-3-3z"/>
                         </svg>
@@ -381,8 +412,8 @@ func (w *WebKitDialogImpl) initializeTemplates() error {
                 <div id="password-error" class="error-message" role="alert"></div>
             </div>
             <div class="buttons">
-                <button type="button" class="btn-secondary" data-action="cancel">Cancel</button>
-                <button type="submit" class="btn-primary">Unlock</button>
+                <button type="button" class="btn-secondary" data-action="cancel">{{T "Cancel" | html}}</button>
+                <button type="submit" class="btn-primary">{{T "Unlock" | html}}</button>
             </div>
         </form>
     </main>
@@ -399,7 +430,7 @@ func (w *WebKitDialogImpl) initializeTemplates() error {
             form.addEventListener('submit', function(e) {
                 e.preventDefault();
                 if (!passwordInput.value) {
-                    showError('Please enter your password');
+                    showError('{{js (T "Please enter your password")}}');
                     return;
                 }
                 // Submit the form
@@ -445,15 +476,24 @@ func (w *WebKitDialogImpl) initializeTemplates() error {
 }
 
 // ShowAuthDialog shows an authentication dialog using yad with HTML form
-func (w *WebKitDialogImpl) ShowAuthDialog(appName string) (string, bool, error) {
+func (w *WebKitDialogImpl) ShowAuthDialog(appName string) (*secure.Buffer, bool, error) {
+	return w.ShowAuthDialogContext(context.Background(), appName, "")
+}
+
+// ShowAuthDialogContext shows an authentication dialog for appName, running
+// path, using yad with HTML form, closing it when ctx ends
+func (w *WebKitDialogImpl) ShowAuthDialogContext(ctx context.Context, appName, path string) (*secure.Buffer, bool, error) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
 	// Read the template
 	templatePath := filepath.Join(w.templateDir, "auth.html")
-	tmpl, err := template.ParseFiles(templatePath)
+	// T translates the dialog's text
+	tmpl, err := template.New(filepath.Base(templatePath)).Funcs(template.FuncMap{
+		"T": func(message string) string { return i18n.T(message) },
+	}).ParseFiles(templatePath)
 	if err != nil {
-		return "", false, fmt.Errorf("failed to parse template: %w", err)
+		return nil, false, fmt.Errorf("failed to parse template: %w", err)
 	}
 
 	// Create a temporary file for the rendered HTML
@@ -463,32 +503,39 @@ func (w *WebKitDialogImpl) ShowAuthDialog(appName string) (string, bool, error)
 	// Create the file
 	htmlFile, err := os.Create(htmlPath)
 	if err != nil {
-		return "", false, fmt.Errorf("failed to create temporary HTML file: %w", err)
+		return nil, false, fmt.Errorf("failed to create temporary HTML file: %w", err)
 	}
 	defer os.Remove(htmlPath) // Clean up the file when done
 
 	// Render the template
+	title := w.branding.TitleFor(appName, path)
 	data := struct {
 		AppName   string
+		Title     string
+		Message   string
+		Logo      string
 		Theme     DialogTheme
 		DarkTheme DialogTheme
 	}{
 		AppName:   appName,
+		Title:     title,
+		Message:   w.branding.MessageFor(appName, path),
+		Logo:      w.branding.Logo,
 		Theme:     w.theme,
-		DarkTheme: DarkTheme,
+		DarkTheme: w.branding.Apply(DarkTheme),
 	}
 
 	if err := tmpl.Execute(htmlFile, data); err != nil {
 		htmlFile.Close()
-		return "", false, fmt.Errorf("failed to render template: %w", err)
+		return nil, false, fmt.Errorf("failed to render template: %w", err)
 	}
 	htmlFile.Close()
 
 	// Use yad to display the WebKit2GTK dialog
-	cmd := exec.Command("yad",
+	cmd := exec.CommandContext(ctx, "yad",
 		"--html",
 		"--filename="+htmlPath,
-		"--title", fmt.Sprintf("Authentication Required - %s", appName),
+		"--title", title,
 		"--width=400",
 		"--height=500",
 		"--center",
@@ -499,23 +546,22 @@ func (w *WebKitDialogImpl) ShowAuthDialog(appName string) (string, bool, error)
 		"--print-uri",
 	)
 
-	// Capture the output
-	output, err := cmd.Output()
+	// Capture the output in locked memory
+	output, err := runSecret(cmd)
 
 	// Check if the user clicked Cancel
 	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
-		return "", false, nil // User cancelled
+		return nil, false, nil // User cancelled
 	} else if err != nil {
-		return "", false, fmt.Errorf("error showing authentication dialog: %w", err)
+		return nil, false, fmt.Errorf("error showing authentication dialog: %w", err)
 	}
 
-	// Parse the output to get the password
-	outputStr := strings.TrimSpace(string(output))
-	if strings.HasPrefix(outputStr, "file://") {
-		return "", false, nil // User closed the dialog
+	output.TrimSpace()
+	if output.HasPrefix("file://") {
+		output.Destroy()
+		return nil, false, nil // User closed the dialog
 	}
 
 	// The password will be in the form data
-	password := strings.TrimSpace(outputStr)
-	return password, true, nil
+	return output, true, nil
 }
@@ -0,0 +1,213 @@
+// Package i18n translates user-facing text. Messages are looked up by their
+// English text, gettext style, in the catalog for the user's locale; text
+// without a translation stays in English. Catalogs ship inside the binary
+// and may be added to or overridden by files in a catalog directory, in
+// any format registered with RegisterFormat.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+// DefaultDir is where catalogs installed alongside wyrmlock are read from
+const DefaultDir = "/usr/share/wyrmlock/locale"
+
+// Catalog maps English messages to their translations. Translations of
+// format strings keep the verbs of the original, in the same order.
+type Catalog map[string]string
+
+// Format parses a catalog file
+type Format func(data []byte) (Catalog, error)
+
+//go:embed catalogs/*.json
+var builtin embed.FS
+
+var (
+	formatsMu sync.RWMutex
+	formats   = map[string]Format{".json": ParseJSON}
+)
+
+// RegisterFormat makes catalog files with extension ext, such as ".po",
+// readable with format
+func RegisterFormat(ext string, format Format) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	formats[strings.ToLower(ext)] = format
+}
+
+// ParseJSON parses a catalog written as one JSON object of English
+// messages and their translations
+func ParseJSON(data []byte) (Catalog, error) {
+	var catalog Catalog
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return nil, fmt.Errorf("invalid JSON catalog: %w", err)
+	}
+	return catalog, nil
+}
+
+// DetectLocale returns the locale messages should be shown in, from
+// LANGUAGE, LC_ALL, LC_MESSAGES and LANG in that order, like gettext, as
+// language_TERRITORY without encoding or modifier. It returns "" for the C
+// and POSIX locales and when none is set, which means English.
+func DetectLocale(getenv func(string) string) string {
+	for _, name := range []string{"LANGUAGE", "LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := getenv(name)
+		if name == "LANGUAGE" {
+			// A list of preferences; the first is used
+			value, _, _ = strings.Cut(value, ":")
+		}
+		if value != "" {
+			return normalize(value)
+		}
+	}
+	return ""
+}
+
+// normalize turns de_DE.UTF-8@euro into de_DE
+func normalize(locale string) string {
+	locale, _, _ = strings.Cut(locale, ".")
+	locale, _, _ = strings.Cut(locale, "@")
+	if locale == "C" || locale == "POSIX" {
+		return ""
+	}
+	return locale
+}
+
+// candidates returns the catalog names tried for locale, most specific
+// first: de_AT, then de
+func candidates(locale string) []string {
+	if locale == "" {
+		return nil
+	}
+	names := []string{locale}
+	if language, _, ok := strings.Cut(locale, "_"); ok {
+		names = append(names, language)
+	}
+	return names
+}
+
+// Translator translates messages into one locale
+type Translator struct {
+	locale  string
+	catalog Catalog
+}
+
+// NewTranslator returns a translator for locale using the built-in
+// catalogs and, overriding them, the catalogs found in dir, if any. For
+// de_AT, entries for de_AT take precedence over those for de. An empty
+// locale, or one without catalogs, leaves messages in English.
+func NewTranslator(locale, dir string) (*Translator, error) {
+	t := &Translator{locale: locale, catalog: Catalog{}}
+
+	// Least specific first, so more specific entries overwrite them
+	names := candidates(locale)
+	for i := len(names) - 1; i >= 0; i-- {
+		if err := t.merge(builtin, "catalogs", names[i]); err != nil {
+			return nil, err
+		}
+		if dir != "" {
+			if err := t.merge(os.DirFS(dir), ".", names[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return t, nil
+}
+
+// merge adds the catalogs named name in dir of fsys, in every registered
+// format
+func (t *Translator) merge(fsys fs.FS, dir, name string) error {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	for ext, format := range formats {
+		file := path.Join(dir, name+ext)
+		data, err := fs.ReadFile(fsys, file)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read catalog %s: %w", file, err)
+		}
+		catalog, err := format(data)
+		if err != nil {
+			return fmt.Errorf("failed to parse catalog %s: %w", file, err)
+		}
+		for message, translation := range catalog {
+			if translation != "" {
+				t.catalog[message] = translation
+			}
+		}
+	}
+	return nil
+}
+
+// Locale returns the locale of the translator
+func (t *Translator) Locale() string {
+	return t.locale
+}
+
+// Text returns the translation of message, or message itself when the
+// catalog has none
+func (t *Translator) Text(message string) string {
+	if translation, ok := t.catalog[message]; ok {
+		return translation
+	}
+	return message
+}
+
+// Sprintf formats the translation of format with args
+func (t *Translator) Sprintf(format string, args ...any) string {
+	return fmt.Sprintf(t.Text(format), args...)
+}
+
+var (
+	mu      sync.RWMutex
+	current = &Translator{catalog: Catalog{}}
+)
+
+// Setup translates messages for locale from now on, detecting the locale
+// from the environment when it is empty, with catalogs from dir in
+// addition to the built-in ones. Until it is called, messages stay in
+// English.
+func Setup(locale, dir string) error {
+	if locale == "" {
+		locale = DetectLocale(os.Getenv)
+	}
+	t, err := NewTranslator(normalize(locale), dir)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	current = t
+	mu.Unlock()
+	return nil
+}
+
+// Locale returns the locale messages are translated into
+func Locale() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current.locale
+}
+
+// T translates message, formatting it with args like fmt.Sprintf when
+// there are any
+func T(message string, args ...any) string {
+	mu.RLock()
+	t := current
+	mu.RUnlock()
+
+	if len(args) == 0 {
+		return t.Text(message)
+	}
+	return t.Sprintf(message, args...)
+}
@@ -0,0 +1,165 @@
+package i18n_test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"wyrmlock/internal/i18n"
+)
+
+func TestDetectLocale(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"unset", nil, ""},
+		{"lang", map[string]string{"LANG": "de_DE.UTF-8"}, "de_DE"},
+		{"modifier", map[string]string{"LANG": "de_DE.UTF-8@euro"}, "de_DE"},
+		{"lc_messages over lang", map[string]string{"LC_MESSAGES": "fr_FR.UTF-8", "LANG": "de_DE.UTF-8"}, "fr_FR"},
+		{"lc_all over lc_messages", map[string]string{"LC_ALL": "fr_CA", "LC_MESSAGES": "de_DE"}, "fr_CA"},
+		{"language list first", map[string]string{"LANGUAGE": "pt_BR:pt:en", "LANG": "de_DE"}, "pt_BR"},
+		{"c locale", map[string]string{"LC_ALL": "C.UTF-8", "LANG": "de_DE"}, ""},
+		{"posix", map[string]string{"LANG": "POSIX"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(name string) string { return tt.env[name] }
+			if got := i18n.DetectLocale(getenv); got != tt.want {
+				t.Errorf("DetectLocale = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranslatorBuiltin(t *testing.T) {
+	de, err := i18n.NewTranslator("de_AT", "")
+	if err != nil {
+		t.Fatalf("NewTranslator failed: %v", err)
+	}
+	if got := de.Text("Unlock"); got != "Entsperren" {
+		t.Errorf("Expected de_AT to fall back to the de catalog, got %q", got)
+	}
+	if got := de.Sprintf("Touch your security key to unlock %s", "Firefox"); got != "Berühren Sie Ihren Sicherheitsschlüssel, um Firefox zu entsperren" {
+		t.Errorf("Unexpected formatted translation %q", got)
+	}
+	if got := de.Text("Not in any catalog"); got != "Not in any catalog" {
+		t.Errorf("Expected untranslated text to stay in English, got %q", got)
+	}
+
+	en, err := i18n.NewTranslator("", "")
+	if err != nil {
+		t.Fatalf("NewTranslator failed: %v", err)
+	}
+	if got := en.Text("Unlock"); got != "Unlock" {
+		t.Errorf("Expected English without a locale, got %q", got)
+	}
+}
+
+func TestTranslatorDirectory(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("de.json", `{"Unlock": "Aufsperren", "Access denied": ""}`)
+	write("de_AT.json", `{"Cancel": "Abbruch"}`)
+	write("nl.json", `{"Unlock": "Ontgrendelen"}`)
+
+	tr, err := i18n.NewTranslator("de_AT", dir)
+	if err != nil {
+		t.Fatalf("NewTranslator failed: %v", err)
+	}
+	if got := tr.Text("Unlock"); got != "Aufsperren" {
+		t.Errorf("Expected the installed catalog to override the built-in one, got %q", got)
+	}
+	if got := tr.Text("Cancel"); got != "Abbruch" {
+		t.Errorf("Expected the de_AT catalog to take precedence, got %q", got)
+	}
+	if got := tr.Text("Access denied"); got != "Zugriff verweigert" {
+		t.Errorf("Expected an empty translation to be ignored, got %q", got)
+	}
+
+	nl, err := i18n.NewTranslator("nl_NL", dir)
+	if err != nil {
+		t.Fatalf("NewTranslator failed: %v", err)
+	}
+	if got := nl.Text("Unlock"); got != "Ontgrendelen" {
+		t.Errorf("Expected a language without a built-in catalog to load, got %q", got)
+	}
+
+	write("sv.json", `{"Unlock": `)
+	if _, err := i18n.NewTranslator("sv", dir); err == nil {
+		t.Error("Expected a broken catalog to be reported")
+	}
+}
+
+func TestRegisterFormat(t *testing.T) {
+	i18n.RegisterFormat(".txt", func(data []byte) (i18n.Catalog, error) {
+		catalog := i18n.Catalog{}
+		for _, line := range strings.Split(string(data), "\n") {
+			if message, translation, ok := strings.Cut(line, "="); ok {
+				catalog[message] = translation
+			}
+		}
+		return catalog, nil
+	})
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "eo.txt"), []byte("Unlock=Malŝlosi\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tr, err := i18n.NewTranslator("eo", dir)
+	if err != nil {
+		t.Fatalf("NewTranslator failed: %v", err)
+	}
+	if got := tr.Text("Unlock"); got != "Malŝlosi" {
+		t.Errorf("Expected the registered format to be read, got %q", got)
+	}
+}
+
+func TestSetup(t *testing.T) {
+	t.Cleanup(func() { _ = i18n.Setup("C", "") })
+
+	if err := i18n.Setup("fr_FR.UTF-8", ""); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if i18n.Locale() != "fr_FR" {
+		t.Errorf("Expected locale fr_FR, got %q", i18n.Locale())
+	}
+	if got := i18n.T("%s (%d more waiting)", "Firefox", 2); got != "Firefox (2 autres en attente)" {
+		t.Errorf("Unexpected translation %q", got)
+	}
+}
+
+// verbPattern matches fmt verbs and dialog placeholders
+var verbPattern = regexp.MustCompile(`%[-+# 0]*[0-9]*(\.[0-9]+)?[a-zA-Z%]|\{app\}|\{path\}`)
+
+func TestBuiltinCatalogsKeepVerbs(t *testing.T) {
+	files, err := filepath.Glob("catalogs/*.json")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("No built-in catalogs found: %v", err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		catalog, err := i18n.ParseJSON(data)
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		for message, translation := range catalog {
+			want := verbPattern.FindAllString(message, -1)
+			got := verbPattern.FindAllString(translation, -1)
+			if !slices.Equal(want, got) {
+				t.Errorf("%s: %q has verbs %v, its translation %q has %v", file, message, want, translation, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// Package integrity records the hashes of wyrmlock's own files at install
+// time so the daemon can notice when they are changed afterwards
+package integrity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Seal holds the SHA-256 of each sealed file, keyed by path
+type Seal struct {
+	SealedAt time.Time         `json:"sealed_at"`
+	Files    map[string]string `json:"files"`
+}
+
+// Target is a file to seal or verify. Source is read instead of Path when
+// set, e.g. /proc/self/exe for the running binary.
+type Target struct {
+	Path   string
+	Source string
+}
+
+// source returns the file read for the target
+func (t Target) source() string {
+	if t.Source != "" {
+		return t.Source
+	}
+	return t.Path
+}
+
+// Mismatch is a target that does not match the seal
+type Mismatch struct {
+	Path string
+
+	// Want is the sealed hash; empty when the path was never sealed
+	Want string
+
+	// Got is the current hash; empty when the file could not be read
+	Got string
+	Err error
+}
+
+// String describes the mismatch for logs
+func (m Mismatch) String() string {
+	switch {
+	case m.Want == "":
+		return fmt.Sprintf("%s is not sealed", m.Path)
+	case m.Err != nil:
+		return fmt.Sprintf("%s cannot be verified: %v", m.Path, m.Err)
+	default:
+		return fmt.Sprintf("%s has changed (sealed %s, now %s)", m.Path, m.Want, m.Got)
+	}
+}
+
+// HashFile returns the hex SHA-256 of a file
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Create seals the current content of targets
+func Create(targets []Target) (*Seal, error) {
+	seal := &Seal{SealedAt: time.Now(), Files: make(map[string]string, len(targets))}
+	for _, target := range targets {
+		hash, err := HashFile(target.source())
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash %s: %w", target.Path, err)
+		}
+		seal.Files[target.Path] = hash
+	}
+	return seal, nil
+}
+
+// Load reads a seal written by Save
+func Load(path string) (*Seal, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var seal Seal
+	if err := json.Unmarshal(data, &seal); err != nil {
+		return nil, fmt.Errorf("failed to parse seal: %w", err)
+	}
+	if seal.Files == nil {
+		seal.Files = make(map[string]string)
+	}
+	return &seal, nil
+}
+
+// Save writes the seal atomically
+func (s *Seal) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create seal directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode seal: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write seal: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// Verify compares targets with the seal and returns those that differ,
+// cannot be read or were never sealed, ordered by path
+func (s *Seal) Verify(targets []Target) []Mismatch {
+	var mismatches []Mismatch
+	for _, target := range targets {
+		want, ok := s.Files[target.Path]
+		if !ok {
+			mismatches = append(mismatches, Mismatch{Path: target.Path})
+			continue
+		}
+
+		got, err := HashFile(target.source())
+		if err != nil || got != want {
+			mismatches = append(mismatches, Mismatch{Path: target.Path, Want: want, Got: got, Err: err})
+		}
+	}
+
+	sort.Slice(mismatches, func(i, j int) bool { return mismatches[i].Path < mismatches[j].Path })
+	return mismatches
+}
+
+// Targets returns the files the daemon seals: the running binary, the
+// privileged helper when it is installed and the configuration file
+func Targets(configFile, helperBinary string) []Target {
+	var targets []Target
+	if exe, err := os.Executable(); err == nil {
+		if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+			exe = resolved
+		}
+		// Hash what is running, even if the file was replaced since
+		targets = append(targets, Target{Path: exe, Source: "/proc/self/exe"})
+	}
+	if _, err := os.Stat(helperBinary); err == nil {
+		targets = append(targets, Target{Path: helperBinary})
+	}
+	if configFile != "" {
+		targets = append(targets, Target{Path: configFile})
+	}
+	return targets
+}
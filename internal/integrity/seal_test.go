@@ -0,0 +1,60 @@
+package integrity_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"wyrmlock/internal/integrity"
+)
+
+func TestSealVerify(t *testing.T) {
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "wyrmlock")
+	configFile := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(binary, []byte("binary"), 0755); err != nil {
+		t.Fatalf("Failed to write binary: %v", err)
+	}
+	if err := os.WriteFile(configFile, []byte("verbose = false\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	targets := []integrity.Target{{Path: binary}, {Path: configFile}}
+	seal, err := integrity.Create(targets)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	sealPath := filepath.Join(dir, "integrity.json")
+	if err := seal.Save(sealPath); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, err := integrity.Load(sealPath)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if mismatches := loaded.Verify(targets); len(mismatches) != 0 {
+		t.Fatalf("Expected sealed files to verify, got %v", mismatches)
+	}
+
+	if err := os.WriteFile(configFile, []byte("verbose = true\n"), 0644); err != nil {
+		t.Fatalf("Failed to modify config: %v", err)
+	}
+	helper := filepath.Join(dir, "wyrmlock-helper")
+	mismatches := loaded.Verify(append(targets, integrity.Target{Path: helper}))
+	if len(mismatches) != 2 {
+		t.Fatalf("Expected 2 mismatches, got %v", mismatches)
+	}
+	if mismatches[0].Path != configFile || mismatches[0].Got == "" || mismatches[0].Got == mismatches[0].Want {
+		t.Errorf("Expected the modified config to mismatch, got %+v", mismatches[0])
+	}
+	if mismatches[1].Path != helper || mismatches[1].Want != "" {
+		t.Errorf("Expected the unsealed helper to be reported, got %+v", mismatches[1])
+	}
+
+	// The running binary is read through another path
+	alias := integrity.Target{Path: binary, Source: filepath.Join(dir, "missing")}
+	if mismatches := loaded.Verify([]integrity.Target{alias}); len(mismatches) != 1 || mismatches[0].Err == nil {
+		t.Errorf("Expected an unreadable source to be reported, got %v", mismatches)
+	}
+}
@@ -0,0 +1,111 @@
+package ipc
+
+import (
+	"fmt"
+	"time"
+)
+
+// ErrorCode identifies the class of failure reported in an IPC reply
+type ErrorCode string
+
+const (
+	// ErrCodeInvalidRequest means the request was malformed or missing fields
+	ErrCodeInvalidRequest ErrorCode = "invalid_request"
+
+	// ErrCodeUnknownCommand means the message type is not handled by the peer
+	ErrCodeUnknownCommand ErrorCode = "unknown_command"
+
+	// ErrCodeUnknownPID means the referenced process is not tracked
+	ErrCodeUnknownPID ErrorCode = "unknown_pid"
+
+	// ErrCodeNotAuthorized means the caller is not allowed to perform the request
+	ErrCodeNotAuthorized ErrorCode = "not_authorized"
+
+	// ErrCodeLockedOut means authentication is temporarily locked out
+	ErrCodeLockedOut ErrorCode = "locked_out"
+
+	// ErrCodeAuthFailed means the supplied credentials were rejected
+	ErrCodeAuthFailed ErrorCode = "auth_failed"
+
+	// ErrCodeProcessControl means a signal or state change on the process failed
+	ErrCodeProcessControl ErrorCode = "process_control_failed"
+
+	// ErrCodeUnsafeEnvironment means a resume was refused because the process
+	// was started with a dangerous loader environment
+	ErrCodeUnsafeEnvironment ErrorCode = "unsafe_environment"
+
+	// ErrCodeSignatureInvalid means a resume was refused because the
+	// executable has no valid signature by a trusted signer
+	ErrCodeSignatureInvalid ErrorCode = "signature_invalid"
+
+	// ErrCodeBinaryRejected means the user did not approve a new version of
+	// an app whose executable no longer matches its pinned hash
+	ErrCodeBinaryRejected ErrorCode = "binary_rejected"
+
+	// ErrCodeExecutableChanged means a resume was refused because the
+	// executable was swapped while the user was authenticating
+	ErrCodeExecutableChanged ErrorCode = "executable_changed"
+
+	// ErrCodeInternal is an unexpected daemon-side failure
+	ErrCodeInternal ErrorCode = "internal"
+)
+
+// ErrorDetail is the structured error carried in an IPC reply
+type ErrorDetail struct {
+	Code      ErrorCode `json:"code"`
+	Message   string    `json:"message"`
+	Retryable bool      `json:"retryable"`
+
+	// RetryAfter is the number of seconds after which a retry may succeed
+	RetryAfter int `json:"retry_after,omitempty"`
+}
+
+// Error implements the error interface
+func (e *ErrorDetail) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%s: %s (retry in %s)", e.Code, e.Message,
+			(time.Duration(e.RetryAfter) * time.Second).String())
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// retryableCodes lists codes where the same request may succeed later
+var retryableCodes = map[ErrorCode]bool{
+	ErrCodeLockedOut:      true,
+	ErrCodeAuthFailed:     true,
+	ErrCodeProcessControl: true,
+	ErrCodeInternal:       true,
+}
+
+// NewErrorDetail creates an error detail with the default retryable flag for the code
+func NewErrorDetail(code ErrorCode, message string) *ErrorDetail {
+	return &ErrorDetail{
+		Code:      code,
+		Message:   message,
+		Retryable: retryableCodes[code],
+	}
+}
+
+// NewErrorReply builds an error reply for a request of the given type.
+// The legacy Error string is filled in as well for older clients.
+func NewErrorReply(request MessageType, detail *ErrorDetail) Message {
+	return Message{
+		Type:        MsgError,
+		Error:       detail.Message,
+		ErrorDetail: detail,
+		Data: map[string]interface{}{
+			"request": string(request),
+		},
+	}
+}
+
+// Err returns the structured error carried by the message, if any
+func (m Message) Err() error {
+	if m.ErrorDetail != nil {
+		return m.ErrorDetail
+	}
+	if m.Error != "" {
+		return NewErrorDetail(ErrCodeInternal, m.Error)
+	}
+	return nil
+}
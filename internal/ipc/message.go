@@ -1,6 +1,7 @@
 package ipc
 
 import (
+	"wyrmlock/internal/fido2"
 	"wyrmlock/internal/monitor"
 )
 
@@ -9,34 +10,66 @@ type MessageType string
 
 const (
 	// Message types for IPC
-	MsgProcessEvent     MessageType = "process_event"
-	MsgAuthRequest      MessageType = "auth_request"
-	MsgAuthResponse     MessageType = "auth_response"
-	MsgTerminateProcess MessageType = "terminate_process"
-	MsgResumeProcess    MessageType = "resume_process"
-	MsgShutdown         MessageType = "shutdown"
-	MsgPing             MessageType = "ping"
-	MsgPong             MessageType = "pong"
-	MsgList             MessageType = "list"
-	MsgListResponse     MessageType = "list_response"
-	MsgUnlock           MessageType = "unlock"
-	MsgUnlockResponse   MessageType = "unlock_response"
-	MsgStatusRequest    MessageType = "status_request"
-	MsgStatusResponse   MessageType = "status_response"
-	MsgShutdownAck      MessageType = "shutdown_ack"
+	MsgProcessEvent      MessageType = "process_event"
+	MsgAuthRequest       MessageType = "auth_request"
+	MsgAuthResponse      MessageType = "auth_response"
+	MsgTerminateProcess  MessageType = "terminate_process"
+	MsgResumeProcess     MessageType = "resume_process"
+	MsgShutdown          MessageType = "shutdown"
+	MsgPing              MessageType = "ping"
+	MsgPong              MessageType = "pong"
+	MsgList              MessageType = "list"
+	MsgListResponse      MessageType = "list_response"
+	MsgUnlock            MessageType = "unlock"
+	MsgUnlockResponse    MessageType = "unlock_response"
+	MsgStatusRequest     MessageType = "status_request"
+	MsgStatusResponse    MessageType = "status_response"
+	MsgShutdownAck       MessageType = "shutdown_ack"
+	MsgAuthResult        MessageType = "auth_result"
+	MsgError             MessageType = "error"
+	MsgProcessDenied     MessageType = "process_denied"
+	MsgQuotaReset        MessageType = "quota_reset"
+	MsgQuotaResetAck     MessageType = "quota_reset_ack"
+	MsgUsageWarning      MessageType = "usage_warning"
+	MsgProcessAudit      MessageType = "process_audit"
+	MsgOverride          MessageType = "emergency_override"
+	MsgReloadSecret      MessageType = "reload_secret"
+	MsgReloadSecretAck   MessageType = "reload_secret_ack"
+	MsgLockout           MessageType = "lockout"
+	MsgApprovalRequest   MessageType = "approval_request"
+	MsgApprovalResponse  MessageType = "approval_response"
+	MsgFingerprintPrompt MessageType = "fingerprint_prompt"
 )
 
 // Message is the structure used for IPC between daemon and client
 type Message struct {
 	Type          MessageType            `json:"type"`
+	Seq           uint64                 `json:"seq,omitempty"`
 	Process       *monitor.ProcessInfo   `json:"process,omitempty"`
 	AppName       string                 `json:"app_name,omitempty"`
 	PID           int                    `json:"pid,omitempty"`
 	Password      string                 `json:"password,omitempty"`
+	Token         string                 `json:"token,omitempty"`
 	Success       bool                   `json:"success,omitempty"`
 	Error         string                 `json:"error,omitempty"`
+	ErrorDetail   *ErrorDetail           `json:"error_detail,omitempty"`
 	Data          map[string]interface{} `json:"data,omitempty"`
 	ProcessList   []monitor.ProcessInfo  `json:"process_list,omitempty"`
 	ProtectedApps []string               `json:"protected_apps,omitempty"`
 	Version       string                 `json:"version,omitempty"`
+	Status        *StatusReport          `json:"status,omitempty"`
+
+	// Lockout is set when a launch was denied because its app is locked
+	// out, so clients can count down to its end
+	Lockout *Lockout `json:"lockout,omitempty"`
+
+	// Challenge is sent with a prompt for the security key to sign, and
+	// Assertion carries the signature back with the response, so the
+	// daemon checks the key itself
+	Challenge []byte           `json:"challenge,omitempty"`
+	Assertion *fido2.Assertion `json:"assertion,omitempty"`
+
+	// Fingerprint is set on a prompt whose finger the daemon already
+	// verified, so only the security key is left to ask for
+	Fingerprint bool `json:"fingerprint,omitempty"`
 }
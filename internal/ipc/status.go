@@ -0,0 +1,81 @@
+package ipc
+
+import "time"
+
+// StatusReport answers "why didn't it prompt me?" and "how long until I can
+// try again?" without reading the logs
+type StatusReport struct {
+	Grants      []Grant      `json:"grants,omitempty"`
+	Quotas      []QuotaUsage `json:"quotas,omitempty"`
+	Lockouts    []Lockout    `json:"lockouts,omitempty"`
+	Kernel      *KernelInfo  `json:"kernel,omitempty"`
+	Events      *EventQueue  `json:"events,omitempty"`
+	GeneratedAt time.Time    `json:"generated_at"`
+}
+
+// KernelInfo is the kernel feature set the daemon detected at startup and
+// the event backend it selected
+type KernelInfo struct {
+	Release  string          `json:"release"`
+	Backend  string          `json:"backend"`
+	Features map[string]bool `json:"features"`
+
+	// Warnings explain the consequences of missing features; empty when the
+	// daemon is not running in a degraded mode
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// EventQueue is the state of the daemon's exec event queue
+type EventQueue struct {
+	Workers   int    `json:"workers"`
+	Capacity  int    `json:"capacity"`
+	Depth     int    `json:"depth"`
+	Processed uint64 `json:"processed"`
+
+	// Dropped counts events lost to a full queue; the processes they
+	// started are found by a rescan
+	Dropped uint64 `json:"dropped"`
+}
+
+// Grant is an active permission for an app to run without prompting
+type Grant struct {
+	App       string    `json:"app"`
+	PID       int       `json:"pid,omitempty"`
+	User      string    `json:"user,omitempty"`
+	GrantedBy string    `json:"granted_by,omitempty"`
+	GrantedAt time.Time `json:"granted_at"`
+
+	// ExpiresAt is zero when the grant lasts until the process exits
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// Quota kinds
+const (
+	// QuotaLaunches counts launches allowed without authentication
+	QuotaLaunches = "launches"
+
+	// QuotaMinutes counts minutes of screen time
+	QuotaMinutes = "minutes"
+)
+
+// QuotaUsage is today's consumption of an app's quota
+type QuotaUsage struct {
+	App   string `json:"app"`
+	Kind  string `json:"kind"`
+	Used  int    `json:"used"`
+	Limit int    `json:"limit"`
+}
+
+// Lockout is an active authentication lockout
+type Lockout struct {
+	App   string    `json:"app"`
+	Until time.Time `json:"until"`
+}
+
+// Remaining returns how long the lockout has left at the given time
+func (l Lockout) Remaining(now time.Time) time.Duration {
+	if now.After(l.Until) {
+		return 0
+	}
+	return l.Until.Sub(now)
+}
@@ -0,0 +1,78 @@
+// Package keyring keeps small secrets in the kernel session keyring of the
+// calling process, where they expire on their own and are gone after the
+// session ends or the machine reboots
+package keyring
+
+import (
+	"errors"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// keyType is the kernel key type used for stored payloads
+const keyType = "user"
+
+// ErrNotFound is returned when no key has the description
+var ErrNotFound = errors.New("key not found")
+
+// Store adds or replaces a key in the session keyring that the kernel
+// removes after ttl
+func Store(description string, payload []byte, ttl time.Duration) error {
+	id, err := unix.AddKey(keyType, description, payload, unix.KEY_SPEC_SESSION_KEYRING)
+	if err != nil {
+		return err
+	}
+
+	seconds := int(ttl.Round(time.Second) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	if _, err := unix.KeyctlInt(unix.KEYCTL_SET_TIMEOUT, id, seconds, 0, 0); err != nil {
+		// Never leave a key behind without its expiry
+		_, _ = unix.KeyctlInt(unix.KEYCTL_INVALIDATE, id, 0, 0, 0)
+		return err
+	}
+	return nil
+}
+
+// Load returns the payload of a key in the session keyring
+func Load(description string) ([]byte, error) {
+	id, err := search(description)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := unix.KeyctlBuffer(unix.KEYCTL_READ, id, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	payload := make([]byte, size)
+	n, err := unix.KeyctlBuffer(unix.KEYCTL_READ, id, payload, 0)
+	if err != nil {
+		return nil, err
+	}
+	if n < len(payload) {
+		payload = payload[:n]
+	}
+	return payload, nil
+}
+
+// Remove invalidates a key in the session keyring
+func Remove(description string) error {
+	id, err := search(description)
+	if err != nil {
+		return err
+	}
+	_, err = unix.KeyctlInt(unix.KEYCTL_INVALIDATE, id, 0, 0, 0)
+	return err
+}
+
+// search finds a key in the session keyring
+func search(description string) (int, error) {
+	id, err := unix.KeyctlSearch(unix.KEY_SPEC_SESSION_KEYRING, keyType, description, 0)
+	if errors.Is(err, unix.ENOKEY) || errors.Is(err, unix.EKEYEXPIRED) || errors.Is(err, unix.EKEYREVOKED) {
+		return 0, ErrNotFound
+	}
+	return id, err
+}
@@ -0,0 +1,39 @@
+package keyring_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"wyrmlock/internal/keyring"
+)
+
+func TestStoreLoadRemove(t *testing.T) {
+	description := fmt.Sprintf("wyrmlock-test:%d", os.Getpid())
+
+	if err := keyring.Store(description, []byte("payload"), time.Minute); err != nil {
+		if errors.Is(err, unix.ENOSYS) || errors.Is(err, unix.EPERM) || errors.Is(err, unix.EACCES) {
+			t.Skipf("Kernel keyring unavailable: %v", err)
+		}
+		t.Fatalf("Store failed: %v", err)
+	}
+
+	payload, err := keyring.Load(description)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if string(payload) != "payload" {
+		t.Errorf("Expected the stored payload, got %q", payload)
+	}
+
+	if err := keyring.Remove(description); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := keyring.Load(description); !errors.Is(err, keyring.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after Remove, got %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+// Package landlock restricts the filesystem access of the daemon with the
+// Landlock LSM
+package landlock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Access rights granted by rules
+const (
+	// AccessRead reads files and lists directories
+	AccessRead = unix.LANDLOCK_ACCESS_FS_READ_FILE | unix.LANDLOCK_ACCESS_FS_READ_DIR
+
+	// AccessExecute runs files
+	AccessExecute = unix.LANDLOCK_ACCESS_FS_EXECUTE
+
+	// AccessWrite creates, modifies, renames and removes files and
+	// directories
+	AccessWrite = unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_DIR |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_FILE |
+		unix.LANDLOCK_ACCESS_FS_MAKE_DIR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_REG |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_FIFO |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SYM |
+		unix.LANDLOCK_ACCESS_FS_REFER |
+		unix.LANDLOCK_ACCESS_FS_TRUNCATE
+)
+
+// fileAccess are the rights that apply to a file rather than a directory
+const fileAccess = unix.LANDLOCK_ACCESS_FS_EXECUTE |
+	unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+	unix.LANDLOCK_ACCESS_FS_READ_FILE |
+	unix.LANDLOCK_ACCESS_FS_TRUNCATE |
+	unix.LANDLOCK_ACCESS_FS_IOCTL_DEV
+
+// ErrUnavailable is returned when the kernel does not support Landlock
+var ErrUnavailable = errors.New("landlock is not supported by the kernel")
+
+// Rule grants Access beneath Path
+type Rule struct {
+	Path   string
+	Access uint64
+}
+
+// ABI returns the Landlock ABI version of the kernel, 0 when unsupported
+func ABI() int {
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno != 0 {
+		return 0
+	}
+	return int(abi)
+}
+
+// handledAccess returns every filesystem right the ABI knows, all of which
+// are denied unless a rule grants them
+func handledAccess(abi int) uint64 {
+	handled := uint64(unix.LANDLOCK_ACCESS_FS_MAKE_SYM<<1 - 1)
+	if abi >= 2 {
+		handled |= unix.LANDLOCK_ACCESS_FS_REFER
+	}
+	if abi >= 3 {
+		handled |= unix.LANDLOCK_ACCESS_FS_TRUNCATE
+	}
+	if abi >= 5 {
+		handled |= unix.LANDLOCK_ACCESS_FS_IOCTL_DEV
+	}
+	return handled
+}
+
+// RestrictThread confines the calling OS thread to rules; rights the
+// kernel does not know are dropped. Landlock applies per thread and is
+// inherited across exec, so the caller locks the goroutine to its thread
+// and execs to carry the restriction to a whole new process. Rules for
+// paths that do not exist are skipped and returned.
+func RestrictThread(rules []Rule) ([]string, error) {
+	abi := ABI()
+	if abi == 0 {
+		return nil, ErrUnavailable
+	}
+	handled := handledAccess(abi)
+
+	attr := unix.LandlockRulesetAttr{Access_fs: handled}
+	fd, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET,
+		uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return nil, fmt.Errorf("failed to create ruleset: %w", errno)
+	}
+	ruleset := int(fd)
+	defer unix.Close(ruleset)
+
+	var skipped []string
+	for _, rule := range rules {
+		if err := addRule(ruleset, rule, handled); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				skipped = append(skipped, rule.Path)
+				continue
+			}
+			return skipped, err
+		}
+	}
+
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return skipped, fmt.Errorf("failed to set no_new_privs: %w", err)
+	}
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, uintptr(ruleset), 0, 0); errno != 0 {
+		return skipped, fmt.Errorf("failed to enforce ruleset: %w", errno)
+	}
+	return skipped, nil
+}
+
+// addRule adds a path-beneath rule, keeping to the rights a file can carry
+// when the path is not a directory
+func addRule(ruleset int, rule Rule, handled uint64) error {
+	fd, err := unix.Open(rule.Path, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return &os.PathError{Op: "open", Path: rule.Path, Err: err}
+	}
+	defer unix.Close(fd)
+
+	var st unix.Stat_t
+	if err := unix.Fstat(fd, &st); err != nil {
+		return &os.PathError{Op: "stat", Path: rule.Path, Err: err}
+	}
+	access := rule.Access & handled
+	if st.Mode&unix.S_IFMT != unix.S_IFDIR {
+		access &= fileAccess
+	}
+	if access == 0 {
+		return nil
+	}
+
+	attr := unix.LandlockPathBeneathAttr{Allowed_access: access, Parent_fd: int32(fd)}
+	if _, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, uintptr(ruleset),
+		unix.LANDLOCK_RULE_PATH_BENEATH, uintptr(unsafe.Pointer(&attr)), 0, 0, 0); errno != 0 {
+		return fmt.Errorf("failed to add rule for %s: %w", rule.Path, errno)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package landlock_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"wyrmlock/internal/landlock"
+)
+
+func TestRestrictThread(t *testing.T) {
+	if landlock.ABI() == 0 {
+		t.Skip("Landlock is not supported by this kernel")
+	}
+
+	allowed := t.TempDir()
+	denied := t.TempDir()
+	missing := filepath.Join(allowed, "missing")
+
+	errc := make(chan error, 1)
+	go func() {
+		// The restricted thread is discarded when the goroutine exits
+		// without unlocking it
+		runtime.LockOSThread()
+
+		skipped, err := landlock.RestrictThread([]landlock.Rule{
+			{Path: "/", Access: landlock.AccessRead},
+			{Path: allowed, Access: landlock.AccessRead | landlock.AccessWrite},
+			{Path: missing, Access: landlock.AccessWrite},
+		})
+		if err != nil {
+			errc <- err
+			return
+		}
+		if len(skipped) != 1 || skipped[0] != missing {
+			errc <- errors.New("expected the missing path to be skipped")
+			return
+		}
+		if err := os.WriteFile(filepath.Join(allowed, "ok"), nil, 0644); err != nil {
+			errc <- err
+			return
+		}
+		if err := os.WriteFile(filepath.Join(denied, "blocked"), nil, 0644); !errors.Is(err, os.ErrPermission) {
+			errc <- errors.New("expected a write outside the rules to be denied")
+			return
+		}
+		errc <- nil
+	}()
+
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,141 @@
+package logging
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrJournalTampered is returned when a journal entry does not chain to
+// the one before it or does not match its hash
+var ErrJournalTampered = errors.New("journal has been tampered with")
+
+// JournalEntry is one record of a Journal. Hash covers the entry with Hash
+// empty, including the hash of the previous entry, so changing, removing
+// or reordering any entry breaks every hash after it.
+type JournalEntry struct {
+	Seq     uint64            `json:"seq"`
+	Time    time.Time         `json:"time"`
+	Event   string            `json:"event"`
+	Details map[string]string `json:"details,omitempty"`
+	Prev    string            `json:"prev"`
+	Hash    string            `json:"hash"`
+}
+
+// computeHash returns the hash of the entry
+func (e JournalEntry) computeHash() (string, error) {
+	e.Hash = ""
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Journal is an append-only, hash-chained log for records that must be
+// tamper-evident, such as emergency overrides
+type Journal struct {
+	mu   sync.Mutex
+	path string
+	seq  uint64
+	last string
+}
+
+// OpenJournal opens the journal at path, creating it when missing, and
+// continues its chain
+func OpenJournal(path string) (*Journal, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create journal directory: %w", err)
+	}
+
+	j := &Journal{path: path}
+	entries, err := ReadJournal(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if n := len(entries); n > 0 {
+		j.seq, j.last = entries[n-1].Seq, entries[n-1].Hash
+	}
+	return j, nil
+}
+
+// Append adds an entry and syncs it to disk before returning, so an
+// action is never taken without its record
+func (j *Journal) Append(event string, details map[string]string) (JournalEntry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entry := JournalEntry{
+		Seq:     j.seq + 1,
+		Time:    time.Now().UTC(),
+		Event:   event,
+		Details: details,
+		Prev:    j.last,
+	}
+	hash, err := entry.computeHash()
+	if err != nil {
+		return JournalEntry{}, fmt.Errorf("failed to hash journal entry: %w", err)
+	}
+	entry.Hash = hash
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return JournalEntry{}, fmt.Errorf("failed to encode journal entry: %w", err)
+	}
+
+	file, err := os.OpenFile(j.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return JournalEntry{}, fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return JournalEntry{}, fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return JournalEntry{}, fmt.Errorf("failed to sync journal: %w", err)
+	}
+
+	j.seq, j.last = entry.Seq, entry.Hash
+	return entry, nil
+}
+
+// ReadJournal reads and verifies the journal at path. On a broken chain it
+// returns the entries up to the break with ErrJournalTampered.
+func ReadJournal(path string) ([]JournalEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []JournalEntry
+	prev := ""
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		var entry JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return entries, fmt.Errorf("%w: line %d cannot be parsed", ErrJournalTampered, line)
+		}
+		hash, err := entry.computeHash()
+		if err != nil {
+			return entries, err
+		}
+		if entry.Hash != hash {
+			return entries, fmt.Errorf("%w: entry %d does not match its hash", ErrJournalTampered, entry.Seq)
+		}
+		if entry.Prev != prev || entry.Seq != uint64(len(entries)+1) {
+			return entries, fmt.Errorf("%w: entry %d does not follow entry %d", ErrJournalTampered, entry.Seq, len(entries))
+		}
+		entries = append(entries, entry)
+		prev = entry.Hash
+	}
+	return entries, scanner.Err()
+}
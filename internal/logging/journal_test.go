@@ -0,0 +1,61 @@
+package logging_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"wyrmlock/internal/logging"
+)
+
+func TestJournalChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "override.journal")
+
+	j, err := logging.OpenJournal(path)
+	if err != nil {
+		t.Fatalf("OpenJournal failed: %v", err)
+	}
+	if _, err := j.Append("activated", map[string]string{"user": "alice"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	// A reopened journal continues the chain
+	j, err = logging.OpenJournal(path)
+	if err != nil {
+		t.Fatalf("OpenJournal failed: %v", err)
+	}
+	if _, err := j.Append("expired", nil); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	entries, err := logging.ReadJournal(path)
+	if err != nil {
+		t.Fatalf("ReadJournal failed: %v", err)
+	}
+	if len(entries) != 2 || entries[1].Seq != 2 || entries[1].Prev != entries[0].Hash {
+		t.Fatalf("Expected two chained entries, got %+v", entries)
+	}
+
+	// Editing an entry breaks the chain
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Replace(string(data), "alice", "mallory", 1)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := logging.ReadJournal(path); !errors.Is(err, logging.ErrJournalTampered) {
+		t.Errorf("Expected an edited entry to be detected, got %v", err)
+	}
+
+	// So does removing one
+	lines := strings.SplitAfter(string(data), "\n")
+	if err := os.WriteFile(path, []byte(lines[1]), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := logging.ReadJournal(path); !errors.Is(err, logging.ErrJournalTampered) {
+		t.Errorf("Expected a removed entry to be detected, got %v", err)
+	}
+}
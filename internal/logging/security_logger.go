@@ -24,10 +24,47 @@ const (
 	EventSecurityViolation = "SECURITY_VIOLATION"
 	EventProcessBlocked    = "PROCESS_BLOCKED"
 	EventProcessAllowed    = "PROCESS_ALLOWED"
+	EventProcessAudited    = "PROCESS_AUDITED"
+	EventProtectionGap     = "PROTECTION_GAP"
+
+	// EventExecutableReplaced reports a process whose executable was
+	// deleted or replaced on disk after it was launched
+	EventExecutableReplaced = "EXECUTABLE_REPLACED"
+
+	// EventAnonymousExec reports a process executed from memory, such as a
+	// memfd, rather than a file on disk
+	EventAnonymousExec = "ANONYMOUS_EXEC"
+
+	// EventRemovableMediaExec reports a program launched from removable
+	// media
+	EventRemovableMediaExec = "REMOVABLE_MEDIA_EXEC"
+
+	// EventUntrustedDirExec reports a program launched from a temporary,
+	// download or world-writable directory
+	EventUntrustedDirExec = "UNTRUSTED_DIR_EXEC"
+
+	// EventPackageMismatch reports a protected executable whose content
+	// differs from what its distribution package shipped
+	EventPackageMismatch = "PACKAGE_MISMATCH"
+
+	// EventBinaryChanged reports a protected executable that no longer
+	// matches its pinned hash
+	EventBinaryChanged = "BINARY_CHANGED"
+
+	// EventIntegrityMismatch reports a daemon file that no longer matches
+	// the hash sealed at install time
+	EventIntegrityMismatch = "INTEGRITY_MISMATCH"
+
+	// EventEmergencyOverride is logged when the emergency override code is
+	// used, when the override ends and for every launch it lets through
+	EventEmergencyOverride = "EMERGENCY_OVERRIDE"
 )
 
 // SecurityEvent represents a security-related event
 type SecurityEvent struct {
+	// Seq increases by one per event, across restarts; a jump means events
+	// were lost
+	Seq         uint64                 `json:"seq,omitempty"`
 	Timestamp   time.Time              `json:"timestamp"`
 	EventType   string                 `json:"event_type"`
 	ProcessPath string                 `json:"process_path,omitempty"`
@@ -43,6 +80,7 @@ type SecurityLogger struct {
 	logger         *Logger
 	logFile        *os.File
 	logPath        string
+	seq            *Sequence
 	mu             sync.Mutex
 	eventListeners []EventListener
 }
@@ -73,6 +111,13 @@ func NewSecurityLogger(logPath string, stdLogger *Logger) (*SecurityLogger, erro
 		return nil, fmt.Errorf("failed to open security log file: %w", err)
 	}
 
+	// Event sequence numbers continue where the previous run stopped
+	seq, err := OpenSequence(logPath + ".seq")
+	if err != nil {
+		logFile.Close()
+		return nil, err
+	}
+
 	// Use default logger if none provided
 	if stdLogger == nil {
 		stdLogger = DefaultLogger
@@ -85,12 +130,18 @@ func NewSecurityLogger(logPath string, stdLogger *Logger) (*SecurityLogger, erro
 		logger:         stdLogger,
 		logFile:        logFile,
 		logPath:        logPath,
+		seq:            seq,
 		eventListeners: make([]EventListener, 0),
 	}, nil
 }
 
 // Close closes the security logger
 func (sl *SecurityLogger) Close() error {
+	if sl.seq != nil {
+		if err := sl.seq.Close(); err != nil {
+			sl.logger.Warnf("Failed to save event sequence: %v", err)
+		}
+	}
 	if sl.logFile != nil {
 		return sl.logFile.Close()
 	}
@@ -178,6 +229,10 @@ func (sl *SecurityLogger) logEventObject(event SecurityEvent) {
 	sl.mu.Lock()
 	defer sl.mu.Unlock()
 
+	if sl.seq != nil {
+		event.Seq = sl.seq.Next()
+	}
+
 	// Convert event to JSON
 	eventJSON, err := json.Marshal(event)
 	if err != nil {
@@ -206,8 +261,20 @@ func (sl *SecurityLogger) logEventObject(event SecurityEvent) {
 		sl.logger.Infof("Process blocked: %s (PID: %d)", event.ProcessPath, event.ProcessID)
 	case EventProcessAllowed:
 		sl.logger.Infof("Process allowed: %s (PID: %d)", event.ProcessPath, event.ProcessID)
+	case EventProcessAudited:
+		sl.logger.Infof("Process audited: %s (PID: %d)", event.ProcessPath, event.ProcessID)
 	case EventSecurityViolation:
 		sl.logger.Errorf("Security violation: %s", event.Message)
+	case EventEmergencyOverride:
+		sl.logger.Errorf("EMERGENCY OVERRIDE: %s", event.Message)
+	case EventExecutableReplaced:
+		sl.logger.Warnf("Executable replaced: %s (PID: %d)", event.ProcessPath, event.ProcessID)
+	case EventAnonymousExec:
+		sl.logger.Warnf("Anonymous execution: %s (PID: %d)", event.ProcessPath, event.ProcessID)
+	case EventRemovableMediaExec:
+		sl.logger.Warnf("Launch from removable media: %s (PID: %d)", event.ProcessPath, event.ProcessID)
+	case EventUntrustedDirExec:
+		sl.logger.Warnf("Launch from untrusted directory: %s (PID: %d)", event.ProcessPath, event.ProcessID)
 	default:
 		sl.logger.Debugf("Security event %s: %s", event.EventType, event.Message)
 	}
@@ -127,3 +127,44 @@ func TestSecurityLoggerGlobalInstance(t *testing.T) {
 		nil,
 	)
 }
+
+func TestEventSequence(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "security.log")
+
+	securityLogger, err := logging.NewSecurityLogger(logPath, logging.NewLogger("[test]", false))
+	if err != nil {
+		t.Fatalf("Failed to create security logger: %v", err)
+	}
+	securityLogger.LogEvent(logging.EventServiceStart, "first", nil)
+	securityLogger.LogEvent(logging.EventServiceStop, "second", nil)
+	securityLogger.Close()
+
+	// A clean restart continues the sequence without a gap
+	securityLogger, err = logging.NewSecurityLogger(logPath, logging.NewLogger("[test]", false))
+	if err != nil {
+		t.Fatalf("Failed to reopen security logger: %v", err)
+	}
+	securityLogger.LogEvent(logging.EventServiceStart, "third", nil)
+	securityLogger.Close()
+
+	file, err := os.Open(logPath)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	defer file.Close()
+
+	gaps, resets, err := logging.FindSequenceGaps(file)
+	if err != nil || len(gaps) != 0 || resets != 0 {
+		t.Errorf("Expected a contiguous sequence, got gaps %v, resets %d, err %v", gaps, resets, err)
+	}
+
+	var checker logging.SequenceChecker
+	checker.Observe(3)
+	if gap, found, _ := checker.Observe(7); !found || gap.After != 3 || gap.Missing != 3 {
+		t.Errorf("Expected 3 missing events after 3, got %+v", gap)
+	}
+	if _, _, reset := checker.Observe(2); !reset {
+		t.Error("Expected a backwards sequence to be reported as a reset")
+	}
+}
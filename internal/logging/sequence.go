@@ -0,0 +1,162 @@
+package logging
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// sequenceBlock is how many sequence numbers are reserved on disk at once
+const sequenceBlock = 64
+
+// Sequence hands out monotonically increasing event sequence numbers that
+// survive restarts, so readers can tell missed or suppressed events from
+// silence. Numbers are reserved on disk in blocks; after a crash the unused
+// rest of the block is skipped, which readers see as a gap. That is
+// intended: events may have been lost.
+type Sequence struct {
+	mu       sync.Mutex
+	path     string
+	next     uint64
+	reserved uint64
+}
+
+// OpenSequence resumes the sequence persisted at path, starting at 1 when
+// the file does not exist. An empty path keeps the sequence in memory.
+func OpenSequence(path string) (*Sequence, error) {
+	s := &Sequence{path: path, next: 1}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read sequence file: %w", err)
+	default:
+		next, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse sequence file: %w", err)
+		}
+		if next > 0 {
+			s.next = next
+		}
+	}
+
+	s.reserved = s.next
+	return s, nil
+}
+
+// Next returns the next sequence number
+func (s *Sequence) Next() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.path != "" && s.next >= s.reserved {
+		if err := s.persist(s.next + sequenceBlock); err == nil {
+			s.reserved = s.next + sequenceBlock
+		} else if DefaultLogger != nil {
+			DefaultLogger.Warnf("Failed to persist event sequence: %v", err)
+		}
+	}
+
+	seq := s.next
+	s.next++
+	return seq
+}
+
+// Close persists the exact next number so a clean restart leaves no gap
+func (s *Sequence) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.path == "" {
+		return nil
+	}
+	if err := s.persist(s.next); err != nil {
+		return err
+	}
+	s.reserved = s.next
+	return nil
+}
+
+// persist atomically writes next to the sequence file. Caller holds mu.
+func (s *Sequence) persist(next uint64) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create sequence directory: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.FormatUint(next, 10)+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write sequence file: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// SequenceGap is a run of sequence numbers missing from an event stream
+type SequenceGap struct {
+	// After is the last sequence number seen before the gap
+	After uint64
+
+	// Missing is how many numbers were skipped
+	Missing uint64
+}
+
+// SequenceChecker detects gaps in a stream of sequence numbers
+type SequenceChecker struct {
+	last uint64
+}
+
+// Observe records seq and returns the gap before it, if any. A number at or
+// below the last one seen (a replay or a sequence reset) is reported with
+// reset set and restarts tracking from it. Zero means unsequenced and is
+// ignored.
+func (c *SequenceChecker) Observe(seq uint64) (gap SequenceGap, found bool, reset bool) {
+	if seq == 0 {
+		return SequenceGap{}, false, false
+	}
+
+	last := c.last
+	c.last = seq
+
+	switch {
+	case last == 0:
+		return SequenceGap{}, false, false
+	case seq <= last:
+		return SequenceGap{}, false, true
+	case seq > last+1:
+		return SequenceGap{After: last, Missing: seq - last - 1}, true, false
+	}
+	return SequenceGap{}, false, false
+}
+
+// FindSequenceGaps reads a security log and returns the gaps in its event
+// sequence numbers, counting resets as well
+func FindSequenceGaps(r io.Reader) (gaps []SequenceGap, resets int, err error) {
+	var checker SequenceChecker
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var event SecurityEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, 0, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		gap, found, reset := checker.Observe(event.Seq)
+		if found {
+			gaps = append(gaps, gap)
+		}
+		if reset {
+			resets++
+		}
+	}
+	return gaps, resets, scanner.Err()
+}
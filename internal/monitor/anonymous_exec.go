@@ -0,0 +1,48 @@
+package monitor
+
+import (
+	"fmt"
+	"os"
+
+	"wyrmlock/internal/policy"
+)
+
+// Kinds of anonymous execution
+const (
+	// AnonymousMemfd is an executable run from a memfd_create file
+	AnonymousMemfd = policy.AnonymousMemfd
+
+	// AnonymousTmpfile is an executable run from an O_TMPFILE file that was
+	// never linked into the filesystem
+	AnonymousTmpfile = policy.AnonymousTmpfile
+
+	// AnonymousFd is an executable run through a /dev/fd or /proc fd path
+	AnonymousFd = policy.AnonymousFd
+)
+
+// AnonymousExecutable reports how a process was executed without an
+// on-disk file, or "" when its executable is a regular file. Processes
+// started with fexecve on such a file show up here too, since their exe link
+// names the anonymous file; fexecve of a regular file is not reported.
+func AnonymousExecutable(pid int) string {
+	exePath, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+	if err != nil {
+		return ""
+	}
+	return policy.AnonymousKind(exePath)
+}
+
+// promptName returns the name to show when prompting for a tracked process
+func (m *ProcessMonitor) promptName(pid int, displayName string) string {
+	info, _ := m.GetProcess(pid)
+	return info.PromptName(displayName)
+}
+
+// PromptName returns the name to show when prompting for the process, with
+// its warning if it has one
+func (p ProcessInfo) PromptName(name string) string {
+	if p.Warning == "" {
+		return name
+	}
+	return fmt.Sprintf("%s (warning: %s)", name, p.Warning)
+}
@@ -0,0 +1,26 @@
+package monitor
+
+import "path/filepath"
+
+// displayName returns how an app is shown to the user: its configured
+// display name, the name from its .desktop entry, or the file name
+func (m *ProcessMonitor) displayName(app string) string {
+	for _, blocked := range m.config.BlockedApps {
+		if blocked.Path == app && blocked.DisplayName != "" {
+			return blocked.DisplayName
+		}
+	}
+	if entry, ok := m.desktopApps.Lookup(app); ok {
+		return entry.Name
+	}
+	return filepath.Base(app)
+}
+
+// describeApp fills in the application name and icon of a launch from the
+// app's .desktop entry
+func (m *ProcessMonitor) describeApp(procInfo *ProcessInfo, app string) {
+	procInfo.AppName = m.displayName(app)
+	if entry, ok := m.desktopApps.Lookup(app); ok {
+		procInfo.Icon = entry.Icon
+	}
+}
@@ -0,0 +1,76 @@
+package monitor
+
+import (
+	"wyrmlock/internal/logging"
+)
+
+// Actions reported for launches of apps in audit-only mode
+const (
+	// AuditActionLock means the launch would have required authentication
+	AuditActionLock = "lock"
+
+	// AuditActionDeny means the launch would have been terminated
+	AuditActionDeny = "deny"
+)
+
+// ProcessAuditHandler is a callback for protected launches that were only
+// audited. action is what enforcement would have done.
+type ProcessAuditHandler func(pid int, execPath string, displayName string, action string, reason string, cmdLine string)
+
+// RegisterAuditHandler registers a callback for audited launches
+func (m *ProcessMonitor) RegisterAuditHandler(handler ProcessAuditHandler) {
+	m.eventHandlerMu.Lock()
+	m.auditHandler = handler
+	m.eventHandlerMu.Unlock()
+}
+
+// isAuditOnly reports whether execPath is monitored without enforcement,
+// globally or per app
+func (m *ProcessMonitor) isAuditOnly(execPath string) bool {
+	if m.config.Monitor.AuditOnly {
+		return true
+	}
+	for _, app := range m.config.BlockedApps {
+		if app.Path == execPath && app.AuditOnly {
+			return true
+		}
+	}
+	return false
+}
+
+// auditLaunch records what enforcement would have done to a launch of an
+// audit-only app and reports whether the app is audit-only. The caller
+// skips enforcement when it returns true.
+func (m *ProcessMonitor) auditLaunch(pid int, execPath, displayName, action, reason string) bool {
+	if !m.isAuditOnly(execPath) {
+		return false
+	}
+
+	m.logger.Infof("Audit only: %s (PID %d) would be %s: %s", displayName, pid, auditVerb(action), reason)
+	cmdLine := m.eventCmdLine(pid, execPath)
+
+	if logging.SecurityLog != nil {
+		logging.SecurityLog.LogProcessEvent(logging.EventProcessAudited, execPath, pid, map[string]interface{}{
+			"action":  action,
+			"reason":  reason,
+			"cmdline": cmdLine,
+		})
+	}
+
+	m.eventHandlerMu.RLock()
+	handler := m.auditHandler
+	m.eventHandlerMu.RUnlock()
+
+	if handler != nil {
+		go handler(pid, execPath, displayName, action, reason, cmdLine)
+	}
+	return true
+}
+
+// auditVerb describes an audit action for logs
+func auditVerb(action string) string {
+	if action == AuditActionDeny {
+		return "denied"
+	}
+	return "locked"
+}
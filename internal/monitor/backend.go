@@ -0,0 +1,124 @@
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+
+	"wyrmlock/internal/config"
+)
+
+// MonitorBackend delivers process events to the monitor. Backends report
+// events through the monitor's handlers: forks, execs (through the exec
+// queue), credential changes and exits.
+type MonitorBackend interface {
+	// Name identifies the backend in logs and status reports
+	Name() string
+
+	// Open acquires the backend's resources; an error means the backend
+	// cannot run on this system
+	Open() error
+
+	// Run delivers events until the monitor's stop channel is closed
+	Run()
+
+	// Wake interrupts a blocked Run so it notices the monitor stopping
+	Wake()
+
+	// Close releases what Open acquired
+	Close() error
+}
+
+// selectBackend returns the configured backend, or with auto the best one
+// the probed capabilities allow
+func (m *ProcessMonitor) selectBackend(name string) (MonitorBackend, error) {
+	if name == "" || name == config.BackendAuto {
+		name = m.capabilities.Backend
+	}
+
+	switch name {
+	case BackendProcConnector:
+		return &procConnectorBackend{m: m}, nil
+	case BackendPolling:
+		return newPollBackend(m, m.config.Monitor.PollInterval), nil
+	case BackendNone:
+		return nil, fmt.Errorf("no process event backend available on this system")
+	}
+	return nil, fmt.Errorf("unknown monitor backend: %s", name)
+}
+
+// procConnectorBackend receives events from the netlink proc connector
+type procConnectorBackend struct {
+	m *ProcessMonitor
+}
+
+// Name implements MonitorBackend
+func (b *procConnectorBackend) Name() string {
+	return BackendProcConnector
+}
+
+// Open binds and subscribes the netlink socket
+func (b *procConnectorBackend) Open() error {
+	m := b.m
+
+	// Open netlink socket
+	sock, err := syscall.Socket(
+		syscall.AF_NETLINK,
+		syscall.SOCK_DGRAM,
+		NETLINK_CONNECTOR,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create netlink socket: %w", err)
+	}
+	m.sock = sock
+
+	// Bind to the socket
+	addr := &syscall.SockaddrNetlink{
+		Family: syscall.AF_NETLINK,
+		Pid:    uint32(os.Getpid()),
+		Groups: CN_IDX_PROC,
+	}
+	if err := syscall.Bind(sock, addr); err != nil {
+		syscall.Close(sock)
+		return fmt.Errorf("failed to bind to netlink socket: %w", err)
+	}
+
+	// Only wake up for the events we handle
+	if err := m.attachEventFilter(); err != nil {
+		m.logger.Warnf("Failed to attach proc event filter, all events will be read: %v", err)
+	}
+
+	// Subscribe to proc connector
+	if err := m.subscribe(); err != nil {
+		syscall.Close(sock)
+		return fmt.Errorf("failed to subscribe to proc connector: %w", err)
+	}
+
+	// Stop wakes the event loop through this descriptor
+	wakeFd, err := unix.Eventfd(0, unix.EFD_CLOEXEC|unix.EFD_NONBLOCK)
+	if err != nil {
+		syscall.Close(sock)
+		return fmt.Errorf("failed to create wakeup descriptor: %w", err)
+	}
+	m.wakeFd = wakeFd
+
+	return nil
+}
+
+// Run implements MonitorBackend
+func (b *procConnectorBackend) Run() {
+	b.m.monitor()
+}
+
+// Wake implements MonitorBackend
+func (b *procConnectorBackend) Wake() {
+	b.m.wake()
+}
+
+// Close closes the socket and wakeup descriptor
+func (b *procConnectorBackend) Close() error {
+	syscall.Close(b.m.wakeFd)
+	return syscall.Close(b.m.sock)
+}
@@ -0,0 +1,225 @@
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// Kernel features probed at startup
+const (
+	FeatureProcConnector = "proc_connector"
+	FeatureFanotifyPerm  = "fanotify_perm"
+	FeatureCgroupFreezer = "cgroup2_freezer"
+	FeatureLandlock      = "landlock"
+	FeaturePidfd         = "pidfd"
+)
+
+// Event backends the monitor can run on
+const (
+	// BackendProcConnector receives exec events from the netlink proc
+	// connector
+	BackendProcConnector = "proc-connector"
+
+	// BackendPolling finds launches by scanning /proc at an interval, when
+	// the proc connector is unavailable
+	BackendPolling = "poll"
+
+	// BackendNone means no usable backend was found
+	BackendNone = "none"
+)
+
+// featureWarnings explains what is lost when a feature is missing
+var featureWarnings = map[string]string{
+	FeatureProcConnector: "proc connector unavailable: launches are found by polling /proc and run briefly before being suspended (needs CAP_NET_ADMIN and CONFIG_PROC_EVENTS)",
+	FeatureFanotifyPerm:  "fanotify permission events unavailable: launches can only be suspended after exec, not blocked before it",
+	FeatureCgroupFreezer: "cgroup v2 freezer unavailable: suspended processes can be resumed by anyone able to send them SIGCONT",
+	FeatureLandlock:      "Landlock unavailable: the daemon cannot restrict its own filesystem access",
+	FeaturePidfd:         "pidfd unavailable: signals are sent by PID and may race with PID reuse",
+}
+
+// Capabilities is the kernel feature set detected at startup and the
+// backend chosen from it
+type Capabilities struct {
+	Kernel   string
+	Backend  string
+	Features map[string]bool
+}
+
+// ProbeCapabilities detects which kernel features are usable by this process
+func ProbeCapabilities() Capabilities {
+	caps := Capabilities{
+		Kernel: kernelRelease(),
+		Features: map[string]bool{
+			FeatureProcConnector: probeProcConnector(),
+			FeatureFanotifyPerm:  probeFanotifyPerm(),
+			FeatureCgroupFreezer: probeCgroupFreezer(),
+			FeatureLandlock:      probeLandlock(),
+			FeaturePidfd:         probePidfd(),
+		},
+	}
+
+	switch {
+	case caps.Features[FeatureProcConnector]:
+		caps.Backend = BackendProcConnector
+	case probeProcfs():
+		caps.Backend = BackendPolling
+	default:
+		caps.Backend = BackendNone
+	}
+	return caps
+}
+
+// Has reports whether a feature is available
+func (c Capabilities) Has(feature string) bool {
+	return c.Features[feature]
+}
+
+// Warnings describes each missing feature, sorted by feature name. An empty
+// result means the monitor is not running in a degraded mode.
+func (c Capabilities) Warnings() []string {
+	names := make([]string, 0, len(c.Features))
+	for name, ok := range c.Features {
+		if !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	warnings := make([]string, 0, len(names))
+	for _, name := range names {
+		warnings = append(warnings, featureWarnings[name])
+	}
+	return warnings
+}
+
+// RecheckFreezer probes the cgroup freezer again once the daemon has given
+// up privileges, which may leave it unable to create freezer cgroups.
+// Suspensions then fall back to SIGSTOP and the feature is reported
+// missing.
+func (m *ProcessMonitor) RecheckFreezer() {
+	if m.freezer == nil || probeCgroupFreezer() {
+		return
+	}
+	m.logger.Warnf("Degraded mode: %s", featureWarnings[FeatureCgroupFreezer])
+	m.disableFeature(FeatureCgroupFreezer)
+}
+
+// disableFeature reports feature missing from now on. The map is copied,
+// as earlier results of Capabilities share it.
+func (m *ProcessMonitor) disableFeature(feature string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	features := make(map[string]bool, len(m.capabilities.Features))
+	for name, ok := range m.capabilities.Features {
+		features[name] = ok
+	}
+	features[feature] = false
+	m.capabilities.Features = features
+}
+
+// Capabilities returns the feature set detected when the monitor started
+func (m *ProcessMonitor) Capabilities() Capabilities {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.capabilities
+}
+
+// kernelRelease returns the running kernel release, e.g. "6.8.0-45-generic"
+func kernelRelease() string {
+	var uts unix.Utsname
+	if err := unix.Uname(&uts); err != nil {
+		return "unknown"
+	}
+	return unix.ByteSliceToString(uts.Release[:])
+}
+
+// probeProcConnector checks that a proc connector socket can be bound. The
+// kernel assigns the port so the probe does not clash with the monitor's
+// own socket.
+func probeProcConnector() bool {
+	sock, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_DGRAM, NETLINK_CONNECTOR)
+	if err != nil {
+		return false
+	}
+	defer syscall.Close(sock)
+
+	return syscall.Bind(sock, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: CN_IDX_PROC}) == nil
+}
+
+// probeProcfs checks that /proc can be listed, which the polling backend
+// needs
+func probeProcfs() bool {
+	f, err := os.Open("/proc")
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	_, err = f.Readdirnames(1)
+	return err == nil
+}
+
+// probeFanotifyPerm checks that exec permission events can be requested on
+// the root mount; the mark is dropped when the descriptor is closed
+func probeFanotifyPerm() bool {
+	fd, err := unix.FanotifyInit(unix.FAN_CLASS_CONTENT|unix.FAN_CLOEXEC, unix.O_RDONLY)
+	if err != nil {
+		return false
+	}
+	defer unix.Close(fd)
+
+	return unix.FanotifyMark(fd, unix.FAN_MARK_ADD|unix.FAN_MARK_MOUNT, unix.FAN_OPEN_EXEC_PERM, unix.AT_FDCWD, "/") == nil
+}
+
+// probeCgroupFreezer checks that a cgroup can actually be created and
+// frozen on the cgroup v2 hierarchy, on the unified mount of hybrid systems
+// too. It tries where locked processes are frozen: inside a user's systemd
+// manager when one runs, since those cgroups belong to the user and need
+// CAP_DAC_OVERRIDE, otherwise in this process's cgroup or the root.
+func probeCgroupFreezer() bool {
+	root, err := cgroup2Mount()
+	if err != nil {
+		return false
+	}
+
+	var fs unix.Statfs_t
+	if err := unix.Statfs(root, &fs); err != nil || fs.Type != unix.CGROUP2_SUPER_MAGIC {
+		return false
+	}
+
+	parent := root
+	if path, err := processCgroup("self"); err == nil {
+		parent = filepath.Join(root, path)
+	}
+	if managers, _ := filepath.Glob(filepath.Join(root, "user.slice", "user-*.slice", "user@*.service")); len(managers) > 0 {
+		parent = managers[0]
+	}
+
+	probe := filepath.Join(parent, fmt.Sprintf("wyrmlock-probe-%d", os.Getpid()))
+	if err := os.Mkdir(probe, 0755); err != nil {
+		return false
+	}
+	defer os.Remove(probe)
+	return writeCgroupFile(probe, "cgroup.freeze", "1") == nil
+}
+
+// probeLandlock checks that the Landlock ABI is available
+func probeLandlock() bool {
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	return errno == 0 && abi > 0
+}
+
+// probePidfd checks that process file descriptors can be opened
+func probePidfd() bool {
+	fd, err := unix.PidfdOpen(os.Getpid(), 0)
+	if err != nil {
+		return false
+	}
+	unix.Close(fd)
+	return true
+}
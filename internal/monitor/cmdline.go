@@ -0,0 +1,60 @@
+package monitor
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// RedactedArgs replaces the arguments of apps whose command lines must not
+// appear in events or audit records
+const RedactedArgs = "[arguments redacted]"
+
+// redactCmdLine keeps the program name of a command line and replaces its
+// arguments with RedactedArgs
+func redactCmdLine(cmdLine string) string {
+	program, args, _ := strings.Cut(cmdLine, " ")
+	if args == "" {
+		return program
+	}
+	return program + " " + RedactedArgs
+}
+
+// shouldRedactArgs reports whether the arguments of execPath are redacted.
+// Monitor.RedactArgs entries match the full path or the executable name.
+func (m *ProcessMonitor) shouldRedactArgs(execPath string) bool {
+	name := filepath.Base(execPath)
+	for _, entry := range m.config.Monitor.RedactArgs {
+		if entry == execPath || entry == name {
+			return true
+		}
+	}
+	return false
+}
+
+// eventCmdLine returns the command line of a process as it may be reported in
+// events and audit records. The command line captured at exec time is
+// preferred; for processes not tracked yet it is read from /proc.
+func (m *ProcessMonitor) eventCmdLine(pid int, execPath string) string {
+	m.monitoredMu.RLock()
+	info, exists := m.monitoredProcesses[pid]
+	m.monitoredMu.RUnlock()
+
+	cmdLine := info.CmdLine
+	if !exists || cmdLine == "" {
+		var err error
+		if cmdLine, err = m.getProcessCmdLine(pid); err != nil {
+			return ""
+		}
+	}
+
+	if m.shouldRedactArgs(execPath) || (exists && m.shouldRedactArgs(info.Command)) {
+		return redactCmdLine(cmdLine)
+	}
+	return cmdLine
+}
+
+// EventCmdLine returns the command line of a process for events, with the
+// arguments redacted for apps listed in Monitor.RedactArgs
+func (m *ProcessMonitor) EventCmdLine(pid int, execPath string) string {
+	return m.eventCmdLine(pid, execPath)
+}
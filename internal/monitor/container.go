@@ -0,0 +1,92 @@
+package monitor
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"wyrmlock/internal/config"
+)
+
+// containerCgroupMarkers map cgroup path elements to the runtime that
+// created them, most specific first
+var containerCgroupMarkers = []struct {
+	marker  string
+	runtime string
+}{
+	{"kubepods", "kubernetes"},
+	{"libpod", "podman"},
+	{"docker", "docker"},
+	{"containerd", "containerd"},
+	{"lxc.payload", "lxc"},
+	{"machine.slice", "systemd-nspawn"},
+}
+
+// foreignMountNamespace reports whether a process runs in a different mount
+// namespace than the monitor, so its paths do not name the monitor's files
+func foreignMountNamespace(pid int) bool {
+	own, err := os.Readlink("/proc/self/ns/mnt")
+	if err != nil {
+		return false
+	}
+	ns, err := os.Readlink(fmt.Sprintf("/proc/%d/ns/mnt", pid))
+	return err == nil && ns != own
+}
+
+// detectContainer returns the runtime of the container a process runs in,
+// or "" when it shares the monitor's mount namespace. Flatpak and snap
+// sandboxes use their own mount namespaces too but are handled as sandboxes.
+func detectContainer(pid int, execPath string) string {
+	if !foreignMountNamespace(pid) {
+		return ""
+	}
+	if sandbox, _ := detectSandbox(pid, execPath); sandbox != nil {
+		return ""
+	}
+
+	if runtime := containerFromCgroup(pid); runtime != "" {
+		return runtime
+	}
+	return "container"
+}
+
+// containerFromCgroup names the container runtime from /proc/<pid>/cgroup
+func containerFromCgroup(pid int) string {
+	file, err := os.Open(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		for _, m := range containerCgroupMarkers {
+			if strings.Contains(fields[2], m.marker) {
+				return m.runtime
+			}
+		}
+	}
+	return ""
+}
+
+// hostPath translates a path inside a process's mount namespace to one the
+// monitor can open, through the process's /proc root. Paths of processes
+// sharing the monitor's namespace are returned unchanged.
+func hostPath(pid int, path string) string {
+	if !foreignMountNamespace(pid) {
+		return path
+	}
+	return filepath.Join(fmt.Sprintf("/proc/%d/root", pid), path)
+}
+
+// ignoresContainers reports whether launches inside containers are left alone
+func (m *ProcessMonitor) ignoresContainers() bool {
+	return m.config.Monitor.ContainerAction == config.ContainerActionIgnore
+}
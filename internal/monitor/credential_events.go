@@ -0,0 +1,160 @@
+package monitor
+
+import (
+	"path/filepath"
+)
+
+// UID and GID change event structure. The first ID is the real one, the
+// second the effective one.
+type idProcEvent struct {
+	ProcessPid  uint32
+	ProcessTgid uint32
+	RealID      uint32
+	EffectiveID uint32
+}
+
+// credentialWatch is a running process of an app with per-user rules, kept
+// so a later credential change can be re-evaluated
+type credentialWatch struct {
+	app string
+
+	// uid is the real UID the launch was evaluated for
+	uid int
+
+	// exempt is set when the rules did not apply at launch, so the process
+	// runs without having authenticated
+	exempt bool
+}
+
+// watchCredentials remembers a launch of an app with per-user rules
+func (m *ProcessMonitor) watchCredentials(pid int, app string, exempt bool) {
+	if !m.policy.RestrictsUsersOf(app) {
+		return
+	}
+
+	uid := -1
+	if creds, err := readProcessCredentials(pid); err == nil {
+		uid = creds.UID
+	}
+
+	m.credWatchMu.Lock()
+	m.credWatches[pid] = credentialWatch{app: app, uid: uid, exempt: exempt}
+	m.credWatchMu.Unlock()
+}
+
+// forgetCredentials drops an exited process
+func (m *ProcessMonitor) forgetCredentials(pid int) {
+	m.credWatchMu.Lock()
+	delete(m.credWatches, pid)
+	m.credWatchMu.Unlock()
+}
+
+// handleCredentialEvent re-evaluates a watched process whose user or group
+// IDs changed. It runs on the event loop and only does a map lookup for
+// processes that are not watched.
+func (m *ProcessMonitor) handleCredentialEvent(pid int) {
+	m.credWatchMu.Lock()
+	watch, ok := m.credWatches[pid]
+	m.credWatchMu.Unlock()
+
+	if ok {
+		go m.recheckCredentials(pid, watch)
+	}
+}
+
+// recheckCredentials re-locks a process when the app's rules now cover it:
+// either it was exempt at launch, or it was unlocked for one user and now
+// runs as another
+func (m *ProcessMonitor) recheckCredentials(pid int, watch credentialWatch) {
+	creds, err := readProcessCredentials(pid)
+	if err != nil {
+		return
+	}
+
+	if !m.policy.LockedFor(watch.app, creds.UID, creds.GID, creds.Groups) {
+		// Dropping out of the rules keeps the process in its current state
+		return
+	}
+	if !watch.exempt && creds.UID == watch.uid {
+		return
+	}
+
+	m.forgetCredentials(pid)
+	if m.auditLaunch(pid, watch.app, filepath.Base(watch.app), AuditActionLock, "credentials changed") {
+		return
+	}
+
+	m.logger.Infof("%s (PID %d) changed credentials to UID %d and is locked for it, re-locking", watch.app, pid, creds.UID)
+	m.relock(pid, watch.app)
+}
+
+// relock suspends a running process and routes it to authentication again,
+// as handleExecEvent does for a new launch, and reports whether it did. Like
+// handleExecEvent it holds handledMu only to claim the PID.
+func (m *ProcessMonitor) relock(pid int, app string) bool {
+	m.handledMu.Lock()
+	previous, handled := m.handledPids[pid]
+	if handled && (previous == "" || !m.isUnlocked(pid)) {
+		// The process is still being evaluated or authenticated
+		m.handledMu.Unlock()
+		return false
+	}
+	m.handledPids[pid] = ""
+	m.handledMu.Unlock()
+
+	// On failure an unlocked process keeps its entry, unless it exited
+	abandon := func() {
+		m.handledMu.Lock()
+		defer m.handledMu.Unlock()
+		if _, ok := m.handledPids[pid]; !ok {
+			return
+		}
+		if handled {
+			m.handledPids[pid] = previous
+		} else {
+			delete(m.handledPids, pid)
+		}
+	}
+
+	procInfo, err := m.getProcessInfo(pid)
+	if err != nil {
+		abandon()
+		m.logger.Debugf("Failed to get process info for re-locked PID %d: %v", pid, err)
+		return false
+	}
+
+	// Stop the process straight away; handleBlockedApp repeats this and
+	// notifies the user
+	if err := m.capturePidfd(pid, procInfo.StartTime); err != nil {
+		abandon()
+		return false
+	}
+	if err := m.suspendProcess(pid); err != nil {
+		abandon()
+		m.logger.Errorf("Failed to stop process %d: %v", pid, err)
+		return false
+	}
+
+	if !m.keepHandledPid(pid, app) {
+		m.logger.Debugf("PID %d exited before it could be re-locked", pid)
+		return false
+	}
+
+	procInfo.Allowed = false
+	m.monitoredMu.Lock()
+	m.monitoredProcesses[pid] = *procInfo
+	m.monitoredMu.Unlock()
+
+	go m.handleBlockedApp(pid, app)
+	return true
+}
+
+// isUnlocked reports whether a tracked process was allowed to run. In daemon
+// mode an unlocked process keeps its handled entry until it exits.
+func (m *ProcessMonitor) isUnlocked(pid int) bool {
+	m.monitoredMu.RLock()
+	defer m.monitoredMu.RUnlock()
+
+	info, ok := m.monitoredProcesses[pid]
+	return ok && info.Allowed
+}
@@ -0,0 +1,63 @@
+package monitor
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// processCredentials are the real user and group identities of a process
+type processCredentials struct {
+	UID    int
+	GID    int
+	Groups []int
+}
+
+// readProcessCredentials reads the real UID, real GID and supplementary
+// groups from /proc/<pid>/status. The real IDs identify the launching user
+// even for setuid executables.
+func readProcessCredentials(pid int) (*processCredentials, error) {
+	file, err := os.Open(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read process status: %w", err)
+	}
+	defer file.Close()
+
+	creds := &processCredentials{UID: -1, GID: -1}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+
+		switch key {
+		case "Uid":
+			// real, effective, saved set, filesystem
+			if len(fields) > 0 {
+				creds.UID, _ = strconv.Atoi(fields[0])
+			}
+		case "Gid":
+			if len(fields) > 0 {
+				creds.GID, _ = strconv.Atoi(fields[0])
+			}
+		case "Groups":
+			for _, f := range fields {
+				if gid, err := strconv.Atoi(f); err == nil {
+					creds.Groups = append(creds.Groups, gid)
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to parse process status: %w", err)
+	}
+
+	if creds.UID < 0 || creds.GID < 0 {
+		return nil, fmt.Errorf("no credentials in process status")
+	}
+	return creds, nil
+}
@@ -0,0 +1,241 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"wyrmlock/internal/logging"
+)
+
+// Environment check modes
+const (
+	// EnvCheckOff disables environment inspection
+	EnvCheckOff = "off"
+
+	// EnvCheckWarn reports dangerous variables but still resumes the process
+	EnvCheckWarn = "warn"
+
+	// EnvCheckStrict refuses to resume a process with dangerous variables
+	EnvCheckStrict = "strict"
+)
+
+// ErrUnsafeEnvironment is returned when a resume is refused because the
+// process was started with a dangerous loader environment
+var ErrUnsafeEnvironment = errors.New("process environment is unsafe")
+
+// loaderEnvVars are dynamic loader variables that can inject code into a process
+var loaderEnvVars = map[string]bool{
+	"LD_PRELOAD":      true,
+	"LD_LIBRARY_PATH": true,
+	"LD_AUDIT":        true,
+}
+
+// EnvFinding describes a dangerous environment variable found on a process
+type EnvFinding struct {
+	Variable string
+	Value    string
+	Reason   string
+}
+
+// String returns a human readable description of the finding
+func (f EnvFinding) String() string {
+	return fmt.Sprintf("%s=%s (%s)", f.Variable, f.Value, f.Reason)
+}
+
+// readProcessEnviron reads the environment of a process from /proc
+func readProcessEnviron(pid int) ([]string, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/environ", pid))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read process environ: %w", err)
+	}
+
+	var env []string
+	for _, entry := range strings.Split(string(data), "\x00") {
+		if entry != "" {
+			env = append(env, entry)
+		}
+	}
+	return env, nil
+}
+
+// checkLoaderEnv inspects an environment for loader variables that point
+// into locations an unprivileged user could have written
+func checkLoaderEnv(env []string) []EnvFinding {
+	var findings []EnvFinding
+
+	for _, entry := range env {
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok || !loaderEnvVars[name] {
+			continue
+		}
+
+		// LD_PRELOAD accepts space or colon separated lists
+		separators := ":"
+		if name == "LD_PRELOAD" {
+			separators = ": "
+		}
+		parts := strings.FieldsFunc(value, func(r rune) bool {
+			return strings.ContainsRune(separators, r)
+		})
+
+		// An empty element in LD_LIBRARY_PATH means the current directory
+		if name == "LD_LIBRARY_PATH" && hasEmptyPathElement(value) {
+			findings = append(findings, EnvFinding{name, value, "empty element searches the current directory"})
+			continue
+		}
+
+		for _, part := range parts {
+			if reason := unsafeLoaderPath(name, part); reason != "" {
+				findings = append(findings, EnvFinding{name, value, reason})
+				break
+			}
+		}
+	}
+
+	return findings
+}
+
+// hasEmptyPathElement reports whether a colon separated list has an empty element
+func hasEmptyPathElement(value string) bool {
+	return value == "" || strings.HasPrefix(value, ":") || strings.HasSuffix(value, ":") ||
+		strings.Contains(value, "::")
+}
+
+// unsafeLoaderPath returns why a loader path is unsafe, or "" if it is not
+func unsafeLoaderPath(name, path string) string {
+	if !filepath.IsAbs(path) {
+		// A bare library name in LD_PRELOAD is resolved via the trusted search path
+		if name == "LD_PRELOAD" && !strings.Contains(path, "/") {
+			return ""
+		}
+		return fmt.Sprintf("relative path %s", path)
+	}
+
+	// Check the path itself and every parent directory. A sticky parent such
+	// as /tmp cannot have root-owned entries replaced, but a sticky directory
+	// used directly as a library path still accepts new files from anyone.
+	leaf := filepath.Clean(path)
+	for p := leaf; ; p = filepath.Dir(p) {
+		if writable, err := isUserWritable(p, p != leaf); err == nil && writable {
+			return fmt.Sprintf("%s is writable by unprivileged users", p)
+		}
+		if p == "/" {
+			break
+		}
+	}
+
+	return ""
+}
+
+// isUserWritable reports whether path is owned by or writable for non-root
+// users. When honourSticky is set, a world-writable sticky directory is not
+// treated as writable.
+func isUserWritable(path string, honourSticky bool) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return false, fmt.Errorf("unsupported file info for %s", path)
+	}
+
+	mode := info.Mode().Perm()
+	sticky := info.Mode()&os.ModeSticky != 0
+
+	switch {
+	case stat.Uid != 0:
+		return true, nil
+	case mode&0002 != 0 && !(honourSticky && info.IsDir() && sticky):
+		return true, nil
+	case mode&0020 != 0 && stat.Gid != 0:
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// envCheckMode returns the environment check mode for an executable
+func (m *ProcessMonitor) envCheckMode(execPath string) string {
+	for _, app := range m.config.BlockedApps {
+		if app.StrictEnv && app.Path == execPath {
+			return EnvCheckStrict
+		}
+	}
+
+	switch m.config.Monitor.EnvCheck {
+	case EnvCheckWarn, EnvCheckStrict:
+		return m.config.Monitor.EnvCheck
+	default:
+		return EnvCheckOff
+	}
+}
+
+// captureEnvironment records dangerous environment variables at exec time
+func (m *ProcessMonitor) captureEnvironment(pid int, execPath string) {
+	if m.envCheckMode(execPath) == EnvCheckOff {
+		return
+	}
+
+	env, err := readProcessEnviron(pid)
+	if err != nil {
+		m.logger.Debugf("Failed to capture environment for PID %d: %v", pid, err)
+		return
+	}
+
+	findings := checkLoaderEnv(env)
+	if len(findings) == 0 {
+		return
+	}
+
+	m.envMu.Lock()
+	m.envFindings[pid] = findings
+	m.envMu.Unlock()
+}
+
+// checkResumeEnvironment reports dangerous environment variables captured at
+// exec time and, in strict mode, refuses the resume
+func (m *ProcessMonitor) checkResumeEnvironment(pid int, execPath string) error {
+	m.envMu.Lock()
+	findings := m.envFindings[pid]
+	m.envMu.Unlock()
+
+	if len(findings) == 0 {
+		return nil
+	}
+
+	mode := m.envCheckMode(execPath)
+	descriptions := make([]string, 0, len(findings))
+	for _, f := range findings {
+		descriptions = append(descriptions, f.String())
+	}
+
+	if logging.SecurityLog != nil {
+		logging.SecurityLog.LogProcessEvent(logging.EventSecurityViolation, execPath, pid, map[string]interface{}{
+			"reason":   "dangerous loader environment",
+			"findings": descriptions,
+			"mode":     mode,
+			"cmdline":  m.eventCmdLine(pid, execPath),
+		})
+	}
+
+	if mode == EnvCheckStrict {
+		m.logger.Warnf("Refusing to resume %s (PID %d): %s", execPath, pid, strings.Join(descriptions, "; "))
+		return fmt.Errorf("%w: %s", ErrUnsafeEnvironment, strings.Join(descriptions, "; "))
+	}
+
+	m.logger.Warnf("Resuming %s (PID %d) with dangerous environment: %s", execPath, pid, strings.Join(descriptions, "; "))
+	return nil
+}
+
+// clearEnvironment forgets captured environment findings for a PID
+func (m *ProcessMonitor) clearEnvironment(pid int) {
+	m.envMu.Lock()
+	delete(m.envFindings, pid)
+	m.envMu.Unlock()
+}
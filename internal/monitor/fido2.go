@@ -0,0 +1,42 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/fido2"
+	"wyrmlock/internal/i18n"
+)
+
+// securityKeyTimeout bounds the wait for a touch when no dialog timeout is
+// configured
+const securityKeyTimeout = 60 * time.Second
+
+// verifySecurityKey asks the user to touch a registered FIDO2 key and
+// waits for it. It is a no-op when security keys are not used.
+func (m *ProcessMonitor) verifySecurityKey(displayName string) error {
+	authCfg := m.config.Auth
+	if authCfg.FIDO2 == "" || authCfg.FIDO2 == config.FIDO2Off {
+		return nil
+	}
+
+	timeout := securityKeyTimeout
+	if authCfg.DialogTimeout > 0 {
+		timeout = time.Duration(authCfg.DialogTimeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := m.guiManager.ShowNotification("wyrmlock",
+		i18n.T("Touch your security key to unlock %s", displayName)); err != nil {
+		m.logger.Debugf("Failed to send security key notification: %v", err)
+	}
+
+	m.logger.Infof("Waiting for security key to unlock %s", displayName)
+	if err := fido2.VerifyRegistered(ctx, authCfg.FIDO2CredentialFile, authCfg.FIDO2Device); err != nil {
+		return fmt.Errorf("security key verification failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package monitor
+
+import (
+	"encoding/binary"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// eventTypeOffset is where the proc event type sits in a connector datagram
+var eventTypeOffset = uint32(unsafe.Sizeof(nlMsgHdr{}) + unsafe.Sizeof(cnMsgHdr{}))
+
+// subscribedEvents returns the proc event types the monitor acts on. UID
+// and GID changes only matter when some app has per-user rules.
+func (m *ProcessMonitor) subscribedEvents() uint32 {
+	events := uint32(PROC_EVENT_EXEC | PROC_EVENT_FORK | PROC_EVENT_EXIT)
+	if m.policy.RestrictsUsers() {
+		events |= PROC_EVENT_UID | PROC_EVENT_GID
+	}
+	return events
+}
+
+// attachEventFilter installs a classic BPF filter on the netlink socket so
+// the kernel drops proc events the monitor ignores (sid, ptrace, comm,
+// coredump and the like) instead of waking the event loop for each one.
+// The proc connector sends one event per datagram, so checking the event
+// type at a fixed offset is enough.
+func (m *ProcessMonitor) attachEventFilter() error {
+	filter := []unix.SockFilter{
+		// A = event type
+		{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: eventTypeOffset},
+		// Keep the datagram when the type is one of the subscribed events
+		{Code: unix.BPF_JMP | unix.BPF_JSET | unix.BPF_K, Jt: 0, Jf: 1, K: bpfWord(m.subscribedEvents())},
+		{Code: unix.BPF_RET | unix.BPF_K, K: 0xffffffff},
+		{Code: unix.BPF_RET | unix.BPF_K, K: 0},
+	}
+
+	prog := unix.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+	return unix.SetsockoptSockFprog(m.sock, unix.SOL_SOCKET, unix.SO_ATTACH_FILTER, &prog)
+}
+
+// bpfWord converts a host-order value to what a BPF word load of the same
+// bytes yields. BPF loads are big-endian while netlink messages use host
+// byte order.
+func bpfWord(v uint32) uint32 {
+	var b [4]byte
+	binary.NativeEndian.PutUint32(b[:], v)
+	return binary.BigEndian.Uint32(b[:])
+}
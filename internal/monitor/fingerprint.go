@@ -0,0 +1,57 @@
+package monitor
+
+import (
+	"context"
+	"os/user"
+	"strconv"
+	"time"
+
+	"wyrmlock/internal/fprintd"
+	"wyrmlock/internal/i18n"
+)
+
+// fingerprintTimeout bounds the wait for a finger when no dialog timeout
+// is configured
+const fingerprintTimeout = 60 * time.Second
+
+// verifyFingerprint asks the user running pid to touch the fingerprint
+// reader and reports whether an enrolled finger matched. It returns false
+// when fingerprint unlock is off or unavailable, so the caller prompts for
+// the password instead.
+func (m *ProcessMonitor) verifyFingerprint(pid int, displayName string) bool {
+	if !m.config.Auth.Fingerprint {
+		return false
+	}
+
+	creds, err := readProcessCredentials(pid)
+	if err != nil {
+		m.logger.Debugf("Failed to read credentials of %d: %v", pid, err)
+		return false
+	}
+	u, err := user.LookupId(strconv.Itoa(creds.UID))
+	if err != nil {
+		m.logger.Debugf("Failed to look up user %d: %v", creds.UID, err)
+		return false
+	}
+
+	timeout := fingerprintTimeout
+	if m.config.Auth.DialogTimeout > 0 {
+		timeout = time.Duration(m.config.Auth.DialogTimeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := m.guiManager.ShowNotification("wyrmlock",
+		i18n.T("Touch the fingerprint reader to unlock %s", displayName)); err != nil {
+		m.logger.Debugf("Failed to send fingerprint notification: %v", err)
+	}
+
+	matched, err := fprintd.Verify(ctx, u.Username)
+	switch {
+	case err != nil:
+		m.logger.Infof("Fingerprint unlock of %s unavailable, prompting for the password: %v", displayName, err)
+	case !matched:
+		m.logger.Infof("Fingerprint not recognized for %s, prompting for the password", displayName)
+	}
+	return err == nil && matched
+}
@@ -0,0 +1,322 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"wyrmlock/internal/config"
+)
+
+// cgroupFreezer suspends a locked process by moving it into a frozen child
+// of its own cgroup. Unlike SIGSTOP the target cannot observe or undo the
+// stop, and everything it forks lands in the same frozen cgroup. The child
+// is created next to the process so its systemd scope is never emptied.
+type cgroupFreezer struct {
+	// mount is where the cgroup v2 hierarchy is mounted
+	mount string
+
+	mu     sync.Mutex
+	groups map[int]*freezeGroup
+}
+
+// freezeGroup is the frozen cgroup holding a locked root and its descendants
+type freezeGroup struct {
+	path string
+
+	// origins is the cgroup each moved process came from
+	origins map[int]string
+}
+
+// newCgroupFreezer locates the cgroup v2 hierarchy
+func newCgroupFreezer() (*cgroupFreezer, error) {
+	mount, err := cgroup2Mount()
+	if err != nil {
+		return nil, err
+	}
+	return &cgroupFreezer{mount: mount, groups: make(map[int]*freezeGroup)}, nil
+}
+
+// cgroup2Mount returns the cgroup v2 mount point, which is /sys/fs/cgroup
+// on unified systems and usually /sys/fs/cgroup/unified on hybrid ones
+func cgroup2Mount() (string, error) {
+	mounts, err := readMountInfo()
+	if err != nil {
+		return "", err
+	}
+
+	found := ""
+	for _, mount := range mounts {
+		if mount.fsType != "cgroup2" {
+			continue
+		}
+		if mount.mountPoint == "/sys/fs/cgroup" {
+			return mount.mountPoint, nil
+		}
+		if found == "" {
+			found = mount.mountPoint
+		}
+	}
+	if found == "" {
+		return "", errors.New("no cgroup v2 hierarchy mounted")
+	}
+	return found, nil
+}
+
+// processCgroup returns the cgroup v2 path of a process, relative to the
+// hierarchy root
+func processCgroup(pid string) (string, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%s/cgroup", pid))
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if path, ok := strings.CutPrefix(line, "0::"); ok {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no cgroup v2 entry")
+}
+
+// cgroupDir returns the absolute directory of a process's cgroup
+func (f *cgroupFreezer) cgroupDir(pid int) (string, error) {
+	path, err := processCgroup(strconv.Itoa(pid))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(f.mount, path), nil
+}
+
+// freeze moves pid into the frozen cgroup of root, creating it for the
+// root itself. Freezing a process already in the group does nothing.
+func (f *cgroupFreezer) freeze(root, pid int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	current, err := f.cgroupDir(pid)
+	if err != nil {
+		return fmt.Errorf("failed to read cgroup of %d: %w", pid, err)
+	}
+
+	group := f.groups[root]
+	created := false
+	if group == nil {
+		if pid != root {
+			return fmt.Errorf("process %d is not frozen", root)
+		}
+		path := filepath.Join(current, fmt.Sprintf("wyrmlock-%d", root))
+		if err := os.Mkdir(path, 0755); err != nil && !os.IsExist(err) {
+			return fmt.Errorf("failed to create freezer cgroup: %w", err)
+		}
+		// Freeze the empty group so processes stop as they are moved in
+		if err := writeCgroupFile(path, "cgroup.freeze", "1"); err != nil {
+			os.Remove(path)
+			return fmt.Errorf("failed to freeze cgroup: %w", err)
+		}
+		group = &freezeGroup{path: path, origins: make(map[int]string)}
+		created = true
+	}
+
+	if current == group.path {
+		return nil
+	}
+	if err := writeCgroupFile(group.path, "cgroup.procs", strconv.Itoa(pid)); err != nil {
+		if created {
+			os.Remove(group.path)
+		}
+		return fmt.Errorf("failed to move %d into freezer cgroup: %w", pid, err)
+	}
+
+	group.origins[pid] = current
+	f.groups[root] = group
+	return nil
+}
+
+// frozen reports whether pid sits in one of the frozen cgroups
+func (f *cgroupFreezer) frozen(pid int) bool {
+	current, err := f.cgroupDir(pid)
+	if err != nil {
+		return false
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, group := range f.groups {
+		if group.path == current {
+			return true
+		}
+	}
+	return false
+}
+
+// has reports whether root is frozen with the cgroup freezer
+func (f *cgroupFreezer) has(root int) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.groups[root]
+	return ok
+}
+
+// thaw resumes the group of root, returns its processes to the cgroups they
+// came from and removes it. Processes forked inside the group go to the
+// root's original cgroup. stop sends SIGSTOP to each process before it is
+// thawed, handing the suspension back to signals.
+func (f *cgroupFreezer) thaw(root int, stop bool) error {
+	f.mu.Lock()
+	group := f.groups[root]
+	delete(f.groups, root)
+	f.mu.Unlock()
+
+	if group == nil {
+		return nil
+	}
+
+	parent := filepath.Dir(group.path)
+	for _, pid := range cgroupProcs(group.path) {
+		if stop {
+			_ = syscall.Kill(pid, syscall.SIGSTOP)
+		}
+		origin, ok := group.origins[pid]
+		if !ok {
+			origin = parent
+		}
+		if err := writeCgroupFile(origin, "cgroup.procs", strconv.Itoa(pid)); err != nil && origin != parent {
+			_ = writeCgroupFile(parent, "cgroup.procs", strconv.Itoa(pid))
+		}
+	}
+
+	if err := writeCgroupFile(group.path, "cgroup.freeze", "0"); err != nil {
+		return fmt.Errorf("failed to thaw cgroup: %w", err)
+	}
+	if err := os.Remove(group.path); err != nil {
+		return fmt.Errorf("failed to remove freezer cgroup: %w", err)
+	}
+	return nil
+}
+
+// roots returns the root PIDs that have a frozen group
+func (f *cgroupFreezer) roots() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	roots := make([]int, 0, len(f.groups))
+	for root := range f.groups {
+		roots = append(roots, root)
+	}
+	return roots
+}
+
+// cgroupProcs lists the processes in a cgroup
+func cgroupProcs(dir string) []int {
+	data, err := os.ReadFile(filepath.Join(dir, "cgroup.procs"))
+	if err != nil {
+		return nil
+	}
+
+	var pids []int
+	for _, field := range strings.Fields(string(data)) {
+		if pid, err := strconv.Atoi(field); err == nil {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+// writeCgroupFile writes a value to a cgroup control file
+func writeCgroupFile(dir, name, value string) error {
+	return os.WriteFile(filepath.Join(dir, name), []byte(value), 0644)
+}
+
+// setupFreezer picks how locked processes are suspended: with the cgroup
+// freezer when the config and kernel allow it, otherwise with SIGSTOP
+func (m *ProcessMonitor) setupFreezer() error {
+	method := m.config.Monitor.SuspendMethod
+	if method == config.SuspendMethodSignal {
+		return nil
+	}
+
+	if !m.capabilities.Has(FeatureCgroupFreezer) {
+		if method == config.SuspendMethodFreezer {
+			return errors.New("suspend method freezer needs the cgroup v2 freezer")
+		}
+		return nil
+	}
+
+	freezer, err := newCgroupFreezer()
+	if err != nil {
+		if method == config.SuspendMethodFreezer {
+			return err
+		}
+		m.logger.Warnf("Cgroup freezer unavailable, suspending with SIGSTOP: %v", err)
+		return nil
+	}
+	m.freezer = freezer
+	m.logger.Debugf("Suspending locked processes with the cgroup freezer at %s", freezer.mount)
+	return nil
+}
+
+// suspendProcess stops a locked process, freezing it with everything it
+// forks when the cgroup freezer is in use and sending SIGSTOP otherwise
+func (m *ProcessMonitor) suspendProcess(pid int) error {
+	if m.freezer != nil {
+		err := m.freezer.freeze(pid, pid)
+		if err == nil {
+			return nil
+		}
+		m.logger.Warnf("Failed to freeze process %d, using SIGSTOP: %v", pid, err)
+	}
+	return m.signalProcess(pid, syscall.SIGSTOP)
+}
+
+// suspendDescendant stops a descendant of a locked root, in the root's
+// frozen cgroup when it has one
+func (m *ProcessMonitor) suspendDescendant(root, pid int) error {
+	if m.freezer != nil && m.freezer.has(root) {
+		err := m.freezer.freeze(root, pid)
+		if err == nil {
+			return nil
+		}
+		m.logger.Warnf("Failed to freeze descendant %d of %d, using SIGSTOP: %v", pid, root, err)
+	}
+	return m.signalProcess(pid, syscall.SIGSTOP)
+}
+
+// resumeProcess continues a suspended process. SIGCONT is sent even after
+// a thaw, since part of the tree may have fallen back to SIGSTOP.
+func (m *ProcessMonitor) resumeProcess(pid int) error {
+	m.clearPending(pid)
+	if m.freezer != nil {
+		if err := m.freezer.thaw(pid, false); err != nil {
+			m.logger.Warnf("Failed to thaw process %d: %v", pid, err)
+		}
+	}
+	return m.signalProcess(pid, syscall.SIGCONT)
+}
+
+// releaseFrozen removes the frozen cgroup of an exited root
+func (m *ProcessMonitor) releaseFrozen(pid int) {
+	if m.freezer == nil {
+		return
+	}
+	if err := m.freezer.thaw(pid, false); err != nil {
+		m.logger.Debugf("Failed to release freezer cgroup of %d: %v", pid, err)
+	}
+}
+
+// releaseAllFrozen hands every frozen process back to SIGSTOP and removes
+// the freezer cgroups, so none are left behind when the monitor stops
+func (m *ProcessMonitor) releaseAllFrozen() {
+	if m.freezer == nil {
+		return
+	}
+	for _, root := range m.freezer.roots() {
+		if err := m.freezer.thaw(root, true); err != nil {
+			m.logger.Warnf("Failed to release freezer cgroup of %d: %v", root, err)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"wyrmlock/internal/logging"
+)
+
+// HashPin is the approved SHA-256 of a protected executable
+type HashPin struct {
+	Hash     string    `json:"hash"`
+	PinnedAt time.Time `json:"pinned_at"`
+}
+
+// hashPins keeps approved executable hashes on disk so an updated binary
+// is noticed across daemon restarts
+type hashPins struct {
+	mu   sync.Mutex
+	path string
+	Pins map[string]HashPin `json:"pins"`
+}
+
+// loadHashPins reads the pins from path; a missing or unreadable file
+// starts with none
+func loadHashPins(path string) *hashPins {
+	p := &hashPins{path: path, Pins: make(map[string]HashPin)}
+	if path == "" {
+		return p
+	}
+
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, p); err != nil || p.Pins == nil {
+			p.Pins = make(map[string]HashPin)
+		}
+	}
+	return p
+}
+
+// save writes the pins to disk atomically. Caller holds mu.
+func (p *hashPins) save() error {
+	if p.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(p.path), 0755); err != nil {
+		return fmt.Errorf("failed to create pin directory: %w", err)
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed to encode pins: %w", err)
+	}
+
+	tmp := p.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write pins: %w", err)
+	}
+	return os.Rename(tmp, p.path)
+}
+
+// get returns the pinned hash of app
+func (p *hashPins) get(app string) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pin, ok := p.Pins[app]
+	return pin.Hash, ok
+}
+
+// set pins app to hash
+func (p *hashPins) set(app, hash string, now time.Time) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.Pins[app] = HashPin{Hash: hash, PinnedAt: now}
+	return p.save()
+}
+
+// pinsHash reports whether app is configured with hash pinning and returns
+// its configured SHA-256, used until a pin is stored
+func (m *ProcessMonitor) pinsHash(app string) (bool, string) {
+	for _, blocked := range m.config.BlockedApps {
+		if blocked.Path != app || !blocked.PinHash {
+			continue
+		}
+		if blocked.FileHashAlgorithm == "" || blocked.FileHashAlgorithm == "sha256" {
+			return true, strings.ToLower(blocked.FileHash)
+		}
+		return true, ""
+	}
+	return false, ""
+}
+
+// checkHashPin compares a launch of a pinning app with its pin. The launch
+// is recorded as changed when the executable differs, and the hash is
+// pinned once the launch is approved; the first approved launch of an app
+// without a pin pins it.
+func (m *ProcessMonitor) checkHashPin(pid int, procInfo *ProcessInfo, app string) {
+	pinning, configured := m.pinsHash(app)
+	if !pinning {
+		return
+	}
+
+	// Scripts are pinned by the script, binaries through the exe link so
+	// the hash is of what is running
+	var hash string
+	if procInfo.Script != "" {
+		h, err := m.getFileHash(procInfo.Script, pid)
+		if err != nil {
+			m.logger.Warnf("Failed to hash %s for its pin: %v", app, err)
+			return
+		}
+		hash = h
+	} else if hash = m.execHash(procInfo.Command, pid); hash == "" {
+		return
+	}
+
+	pinned, ok := m.pins.get(app)
+	if !ok {
+		pinned = configured
+	}
+	procInfo.PinHash = hash
+	if pinned == "" || pinned == hash {
+		return
+	}
+
+	procInfo.NewBinary = true
+	m.logger.Warnf("%s (PID %d) no longer matches its pinned hash", app, pid)
+	if logging.SecurityLog != nil {
+		logging.SecurityLog.LogProcessEvent(logging.EventBinaryChanged, app, pid, map[string]interface{}{
+			"pinned_hash": pinned,
+			"hash":        hash,
+			"cmdline":     m.eventCmdLine(pid, app),
+		})
+	}
+}
+
+// approveHashPin stores the hash of an approved launch as the app's pin
+func (m *ProcessMonitor) approveHashPin(info ProcessInfo) {
+	if info.PinHash == "" {
+		return
+	}
+	app := info.Target()
+	if pinned, ok := m.pins.get(app); ok && pinned == info.PinHash {
+		return
+	}
+
+	if err := m.pins.set(app, info.PinHash, time.Now()); err != nil {
+		m.logger.Errorf("Failed to save hash pin of %s: %v", app, err)
+		return
+	}
+	if info.NewBinary {
+		m.logger.Infof("Approved new version of %s, pinned %s", app, info.PinHash)
+	} else {
+		m.logger.Infof("Pinned %s to %s", app, info.PinHash)
+	}
+}
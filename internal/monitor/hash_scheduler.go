@@ -0,0 +1,77 @@
+package monitor
+
+import "sync"
+
+// HashPriority orders hash computations waiting for a slot
+type HashPriority int
+
+const (
+	// HashPriorityNormal is for processes that keep running meanwhile
+	HashPriorityNormal HashPriority = iota
+
+	// HashPrioritySuspended is for processes the monitor has suspended,
+	// whose user is waiting on the result
+	HashPrioritySuspended
+)
+
+// DefaultHashConcurrency is how many files are hashed at once when the
+// config leaves it unset
+const DefaultHashConcurrency = 2
+
+// hashScheduler limits how many files are hashed at once so a burst of
+// launches (e.g. at login) does not saturate disk IO. Suspended processes
+// go ahead of waiting normal ones.
+type hashScheduler struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	limit  int
+	active int
+
+	// urgent counts suspended-priority callers waiting for a slot
+	urgent int
+}
+
+// newHashScheduler creates a scheduler allowing limit concurrent hashes
+func newHashScheduler(limit int) *hashScheduler {
+	s := &hashScheduler{}
+	s.cond = sync.NewCond(&s.mu)
+	s.setLimit(limit)
+	return s
+}
+
+// setLimit changes the number of concurrent hashes
+func (s *hashScheduler) setLimit(limit int) {
+	if limit <= 0 {
+		limit = DefaultHashConcurrency
+	}
+
+	s.mu.Lock()
+	s.limit = limit
+	s.mu.Unlock()
+	s.cond.Broadcast()
+}
+
+// acquire waits for a hashing slot. Normal callers also wait while any
+// suspended-priority caller is queued.
+func (s *hashScheduler) acquire(priority HashPriority) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if priority == HashPrioritySuspended {
+		s.urgent++
+		defer func() { s.urgent-- }()
+	}
+	for s.active >= s.limit || (priority == HashPriorityNormal && s.urgent > 0) {
+		s.cond.Wait()
+	}
+	s.active++
+}
+
+// release frees a slot taken by acquire
+func (s *hashScheduler) release() {
+	s.mu.Lock()
+	s.active--
+	s.mu.Unlock()
+	s.cond.Broadcast()
+}
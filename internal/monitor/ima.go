@@ -0,0 +1,103 @@
+package monitor
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"wyrmlock/internal/config"
+)
+
+// imaMeasurementsPath is the kernel's IMA measurement list
+const imaMeasurementsPath = "/sys/kernel/security/ima/ascii_runtime_measurements"
+
+// IMAMeasurement is an entry of the IMA measurement list
+type IMAMeasurement struct {
+	Path      string
+	Algorithm string
+	Digest    string
+}
+
+// ParseIMAMeasurement parses a line of ascii_runtime_measurements:
+// "PCR template-hash template-name [algo:]file-hash path [...]". The
+// original "ima" template carries a bare SHA-1 digest.
+func ParseIMAMeasurement(line string) (IMAMeasurement, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 5 || !filepath.IsAbs(fields[4]) {
+		return IMAMeasurement{}, false
+	}
+
+	algorithm, digest, ok := strings.Cut(fields[3], ":")
+	if !ok {
+		algorithm, digest = "sha1", fields[3]
+	}
+	return IMAMeasurement{Path: fields[4], Algorithm: algorithm, Digest: strings.ToLower(digest)}, true
+}
+
+// imaLog follows the IMA measurement list. The kernel measures an
+// executable when it is executed and again whenever its content changes,
+// so the latest measurement of a path describes what ran without reading
+// the file again.
+type imaLog struct {
+	mu      sync.Mutex
+	file    *os.File
+	reader  *bufio.Reader
+	partial string
+	digests map[string]IMAMeasurement
+}
+
+// openIMALog opens the measurement list when IMA is active and readable
+func openIMALog(cfg *config.Config) *imaLog {
+	if cfg.Monitor.IMAMeasurements == config.IMAMeasurementsOff {
+		return nil
+	}
+
+	file, err := os.Open(imaMeasurementsPath)
+	if err != nil {
+		return nil
+	}
+	return &imaLog{
+		file:    file,
+		reader:  bufio.NewReader(file),
+		digests: make(map[string]IMAMeasurement),
+	}
+}
+
+// refresh reads measurements appended since the last call. Caller holds mu.
+func (l *imaLog) refresh() {
+	for {
+		line, err := l.reader.ReadString('\n')
+		if err != nil {
+			// Keep a partial line until the rest of it is appended; the
+			// next read continues where this one stopped
+			l.partial += line
+			return
+		}
+
+		line, l.partial = l.partial+line, ""
+		if m, ok := ParseIMAMeasurement(line); ok {
+			l.digests[m.Path] = m
+		}
+	}
+}
+
+// lookup returns the measured SHA-256 digest of path. A nil log, or a
+// path IMA has not measured with SHA-256, reports false so the caller
+// hashes the file itself.
+func (l *imaLog) lookup(path string) (string, bool) {
+	if l == nil {
+		return "", false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refresh()
+	m, ok := l.digests[path]
+	if !ok || m.Algorithm != "sha256" {
+		return "", false
+	}
+	return m.Digest, true
+}
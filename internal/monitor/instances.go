@@ -0,0 +1,129 @@
+package monitor
+
+import (
+	"fmt"
+
+	"wyrmlock/internal/i18n"
+	"wyrmlock/internal/logging"
+)
+
+// ProcessDeniedHandler is a callback for launches refused without prompting.
+// cmdLine is the command line captured before the process was terminated.
+type ProcessDeniedHandler func(pid int, execPath string, displayName string, reason string, cmdLine string)
+
+// Exit event structure
+type exitProcEvent struct {
+	ProcessPid  uint32
+	ProcessTgid uint32
+	ExitCode    uint32
+	ExitSignal  uint32
+}
+
+// RegisterDeniedHandler registers a callback for launches denied by policy
+func (m *ProcessMonitor) RegisterDeniedHandler(handler ProcessDeniedHandler) {
+	m.eventHandlerMu.Lock()
+	m.deniedHandler = handler
+	m.eventHandlerMu.Unlock()
+}
+
+// handleExitEvent forgets a process that has exited so it no longer counts
+// towards instance limits and the tracking maps do not grow over the
+// daemon's uptime. It runs on the event loop for every exit on the system.
+func (m *ProcessMonitor) handleExitEvent(pid int) {
+	m.forgetTreeMember(pid)
+	m.forgetCredentials(pid)
+	m.forgetStartupHeld(pid)
+	m.releaseFrozen(pid)
+	m.pidfds.exit(pid)
+	m.clearPending(pid)
+	m.forgetSnapshot(pid)
+	m.leavePrompt(pid)
+
+	m.monitoredMu.RLock()
+	_, exists := m.monitoredProcesses[pid]
+	m.monitoredMu.RUnlock()
+
+	if exists {
+		m.logger.Debugf("Monitored process %d exited", pid)
+		m.removeMonitoredProcess(pid)
+	}
+
+	// handledMu is only held to claim or release a PID, so this does not
+	// stall the event loop
+	m.releaseHandledPid(pid)
+}
+
+// claimHandledPid claims a PID for evaluation, reporting false when it is
+// already being evaluated or locked
+func (m *ProcessMonitor) claimHandledPid(pid int) bool {
+	m.handledMu.Lock()
+	defer m.handledMu.Unlock()
+	if _, ok := m.handledPids[pid]; ok {
+		return false
+	}
+	// The app is filled in once the launch is locked
+	m.handledPids[pid] = ""
+	return true
+}
+
+// keepHandledPid records the app a claimed PID was locked for, reporting
+// false when the process exited and dropped the claim meanwhile
+func (m *ProcessMonitor) keepHandledPid(pid int, app string) bool {
+	m.handledMu.Lock()
+	defer m.handledMu.Unlock()
+	if _, ok := m.handledPids[pid]; !ok {
+		return false
+	}
+	m.handledPids[pid] = app
+	return true
+}
+
+// releaseHandledPid forgets a PID claimed by handleExecEvent
+func (m *ProcessMonitor) releaseHandledPid(pid int) {
+	m.handledMu.Lock()
+	delete(m.handledPids, pid)
+	m.handledMu.Unlock()
+}
+
+// isAllowedInstanceChild reports whether parentPID is an allowed instance of
+// execPath, making the new process part of that instance
+func (m *ProcessMonitor) isAllowedInstanceChild(parentPID int, execPath string) bool {
+	m.monitoredMu.RLock()
+	defer m.monitoredMu.RUnlock()
+
+	parent, ok := m.monitoredProcesses[parentPID]
+	return ok && parent.Allowed && parent.Target() == execPath
+}
+
+// reportDenial logs a launch refused by policy and notifies the user or the
+// registered denied handler
+func (m *ProcessMonitor) reportDenial(pid int, execPath, displayName, reason string, details map[string]interface{}) {
+	m.logger.Infof("Denying %s (PID %d): %s", displayName, pid, reason)
+
+	// Capture the command line now; the process is terminated right after
+	cmdLine := m.eventCmdLine(pid, execPath)
+
+	if logging.SecurityLog != nil {
+		if details == nil {
+			details = make(map[string]interface{})
+		}
+		details["reason"] = reason
+		details["cmdline"] = cmdLine
+		logging.SecurityLog.LogProcessEvent(logging.EventProcessBlocked, execPath, pid, details)
+	}
+
+	m.eventHandlerMu.RLock()
+	handler := m.deniedHandler
+	m.eventHandlerMu.RUnlock()
+
+	if handler != nil {
+		go handler(pid, execPath, displayName, reason, cmdLine)
+		return
+	}
+
+	if m.guiManager != nil {
+		if err := m.guiManager.ShowNotification(i18n.T("Access denied"), fmt.Sprintf("%s: %s", displayName, reason)); err != nil {
+			m.logger.Debugf("Failed to show denial notification: %v", err)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// interpreterSpec describes how to find the script argument of an interpreter
+type interpreterSpec struct {
+	// noScriptFlags mean the program text comes from the command line or a
+	// module name, not a file (python -c, bash -c, node -e)
+	noScriptFlags map[string]bool
+
+	// argFlags consume the following argument (python -W arg, bash -o opt)
+	argFlags map[string]bool
+
+	// jarFlag is the option whose value is the program (java -jar app.jar)
+	jarFlag string
+}
+
+var (
+	pythonSpec = &interpreterSpec{
+		noScriptFlags: map[string]bool{"-c": true, "-m": true, "-": true},
+		argFlags:      map[string]bool{"-W": true, "-X": true, "--check-hash-based-pycs": true},
+	}
+	shellSpec = &interpreterSpec{
+		noScriptFlags: map[string]bool{"-c": true, "-s": true, "-": true},
+		argFlags:      map[string]bool{"-o": true, "-O": true, "+O": true, "--rcfile": true, "--init-file": true},
+	}
+	nodeSpec = &interpreterSpec{
+		noScriptFlags: map[string]bool{"-e": true, "--eval": true, "-p": true, "--print": true, "-": true},
+		argFlags:      map[string]bool{"-r": true, "--require": true, "--import": true, "--loader": true, "--input-type": true},
+	}
+	perlSpec = &interpreterSpec{
+		noScriptFlags: map[string]bool{"-e": true, "-E": true, "-": true},
+		argFlags:      map[string]bool{"-I": true, "-M": true, "-m": true},
+	}
+	rubySpec = &interpreterSpec{
+		noScriptFlags: map[string]bool{"-e": true, "-": true},
+		argFlags:      map[string]bool{"-I": true, "-r": true, "-C": true},
+	}
+	javaSpec = &interpreterSpec{
+		jarFlag: "-jar",
+	}
+
+	// Versioned names such as python3.12 or perl5.36
+	pythonNameRe = regexp.MustCompile(`^python[0-9.]*$`)
+	perlNameRe   = regexp.MustCompile(`^perl[0-9.]*$`)
+	rubyNameRe   = regexp.MustCompile(`^ruby[0-9.]*$`)
+)
+
+// interpreterFor returns the spec for a known interpreter executable, or nil
+func interpreterFor(execPath string) *interpreterSpec {
+	name := filepath.Base(execPath)
+
+	switch {
+	case pythonNameRe.MatchString(name):
+		return pythonSpec
+	case perlNameRe.MatchString(name):
+		return perlSpec
+	case rubyNameRe.MatchString(name):
+		return rubySpec
+	}
+
+	switch name {
+	case "bash", "sh", "dash", "zsh", "ksh", "mksh", "fish":
+		return shellSpec
+	case "node", "nodejs":
+		return nodeSpec
+	case "java":
+		return javaSpec
+	}
+
+	return nil
+}
+
+// scriptArgument returns the script path from an interpreter's argv, or ""
+// when the interpreter is not running a script file
+func (s *interpreterSpec) scriptArgument(argv []string) string {
+	if len(argv) < 2 {
+		return ""
+	}
+
+	args := argv[1:]
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if s.jarFlag != "" {
+			if arg == s.jarFlag && i+1 < len(args) {
+				return args[i+1]
+			}
+			continue
+		}
+
+		if arg == "--" {
+			if i+1 < len(args) {
+				return args[i+1]
+			}
+			return ""
+		}
+
+		if s.noScriptFlags[arg] {
+			return ""
+		}
+
+		if s.argFlags[arg] {
+			i++
+			continue
+		}
+
+		if strings.HasPrefix(arg, "-") || strings.HasPrefix(arg, "+") {
+			continue
+		}
+
+		return arg
+	}
+
+	return ""
+}
+
+// readProcessArgv reads the raw argument vector of a process
+func readProcessArgv(pid int) ([]string, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read process cmdline: %w", err)
+	}
+
+	argv := strings.Split(strings.TrimRight(string(data), "\x00"), "\x00")
+	if len(argv) == 1 && argv[0] == "" {
+		return nil, nil
+	}
+	return argv, nil
+}
+
+// resolveScriptPath returns the absolute path of the script run by an
+// interpreter process, or "" if execPath is not a known interpreter or no
+// script file is being run. Relative script paths are resolved against the
+// process working directory.
+func resolveScriptPath(pid int, execPath string) string {
+	spec := interpreterFor(execPath)
+	if spec == nil {
+		return ""
+	}
+
+	argv, err := readProcessArgv(pid)
+	if err != nil {
+		return ""
+	}
+
+	script := spec.scriptArgument(argv)
+	if script == "" {
+		return ""
+	}
+
+	if !filepath.IsAbs(script) {
+		cwd, err := os.Readlink(fmt.Sprintf("/proc/%d/cwd", pid))
+		if err != nil {
+			return ""
+		}
+		script = filepath.Join(cwd, script)
+	}
+
+	script = filepath.Clean(script)
+	if resolved, err := filepath.EvalSymlinks(script); err == nil {
+		script = resolved
+	}
+
+	// Only report regular files; "bash ./missing" and similar are not scripts
+	if info, err := os.Stat(script); err != nil || !info.Mode().IsRegular() {
+		return ""
+	}
+
+	return script
+}
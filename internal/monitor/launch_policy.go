@@ -0,0 +1,216 @@
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/logging"
+	"wyrmlock/internal/policy"
+)
+
+// launchFacts describes a launch to the policy engine. Credentials, the
+// working directory and what the executable runs from are read now; the
+// hash of other files, the sandbox, ancestors and environment only when a
+// policy asks for them.
+func (m *ProcessMonitor) launchFacts(pid int, procInfo *ProcessInfo) policy.Launch {
+	l := policy.Launch{
+		Executable: procInfo.Command,
+		Script:     procInfo.Script,
+		CmdLine:    procInfo.CmdLine,
+		Hash:       procInfo.ExecHash,
+		UID:        -1,
+		GID:        -1,
+		PID:        pid,
+		ParentPID:  procInfo.ParentPID,
+		Time:       time.Now(),
+		HashPath: func(path string) string {
+			return m.execHash(path, pid)
+		},
+		LookupSandbox: func() string {
+			if sandbox, _ := detectSandbox(pid, procInfo.Command); sandbox != nil {
+				return sandbox.Key()
+			}
+			return ""
+		},
+		LookupAncestors: func() []string {
+			return m.ancestorExecutables(pid)
+		},
+		LookupEnv: func() ([]string, error) {
+			return readProcessEnviron(pid)
+		},
+	}
+
+	if creds, err := readProcessCredentials(pid); err == nil {
+		l.UID, l.GID, l.Groups = creds.UID, creds.GID, creds.Groups
+	} else {
+		m.logger.Debugf("Failed to read credentials for PID %d, applying user rules: %v", pid, err)
+	}
+	if cwd, err := os.Readlink(fmt.Sprintf("/proc/%d/cwd", pid)); err == nil {
+		l.Cwd = cwd
+	}
+
+	if policy.AnonymousKind(procInfo.Command) == "" {
+		l.Replaced = ReplacedExecutable(pid)
+	}
+	if m.policy.RemovableMediaAction() != "" {
+		l.Removable = removableMount(pid, procInfo)
+	}
+	return l
+}
+
+// reportSuspiciousLaunch writes launches of anonymous, replaced or removable
+// executables to the security log, whatever is then decided for them
+func (m *ProcessMonitor) reportSuspiciousLaunch(pid int, procInfo *ProcessInfo, l *policy.Launch) {
+	execPath := procInfo.Command
+
+	if kind := policy.AnonymousKind(execPath); kind != "" {
+		action := m.policy.AnonymousExecAction()
+		m.logger.Warnf("Anonymous execution of %s (PID %d) from %s", execPath, pid, kind)
+		if logging.SecurityLog != nil {
+			logging.SecurityLog.LogProcessEvent(logging.EventAnonymousExec, execPath, pid, map[string]interface{}{
+				"kind":    kind,
+				"action":  action,
+				"cmdline": m.eventCmdLine(pid, execPath),
+			})
+		}
+	}
+
+	if l.Replaced != "" {
+		action := m.policy.ReplacedExecAction()
+		m.logger.Warnf("Suspicious launch of %s (PID %d): %s", execPath, pid, l.Replaced)
+		if logging.SecurityLog != nil {
+			logging.SecurityLog.LogProcessEvent(logging.EventExecutableReplaced, execPath, pid, map[string]interface{}{
+				"reason":  l.Replaced,
+				"action":  action,
+				"cmdline": m.eventCmdLine(pid, execPath),
+			})
+		}
+	}
+
+	if l.Removable != "" {
+		target := procInfo.Target()
+		m.logger.Warnf("Launch of %s (PID %d) from removable media at %s", target, pid, l.Removable)
+		if logging.SecurityLog != nil {
+			logging.SecurityLog.LogProcessEvent(logging.EventRemovableMediaExec, target, pid, map[string]interface{}{
+				"mount":   l.Removable,
+				"action":  m.policy.RemovableMediaAction(),
+				"cmdline": m.eventCmdLine(pid, execPath),
+			})
+		}
+	}
+}
+
+// reportUntrustedDir writes a launch from an untrusted directory to the
+// security log
+func (m *ProcessMonitor) reportUntrustedDir(pid int, procInfo *ProcessInfo, dir string) {
+	target := procInfo.Target()
+	m.logger.Warnf("Launch of %s (PID %d) from untrusted directory %s", target, pid, dir)
+	if logging.SecurityLog != nil {
+		logging.SecurityLog.LogProcessEvent(logging.EventUntrustedDirExec, target, pid, map[string]interface{}{
+			"directory": dir,
+			"action":    m.policy.UntrustedDirAction(),
+			"cmdline":   m.eventCmdLine(pid, procInfo.Command),
+		})
+	}
+}
+
+// policyState answers the policy engine from the monitor's tracking of
+// running instances, screen time and launch quotas
+type policyState struct {
+	m *ProcessMonitor
+}
+
+// RunningInstances counts the allowed instances of app other than the launch
+func (s policyState) RunningInstances(l *policy.Launch, app string) int {
+	s.m.monitoredMu.RLock()
+	defer s.m.monitoredMu.RUnlock()
+
+	running := 0
+	for pid, info := range s.m.monitoredProcesses {
+		if pid != l.PID && info.Allowed && info.Target() == app {
+			running++
+		}
+	}
+	return running
+}
+
+// InstanceChild reports whether the launch was started by an allowed
+// instance of app
+func (s policyState) InstanceChild(l *policy.Launch, app string) bool {
+	return s.m.isAllowedInstanceChild(l.ParentPID, app)
+}
+
+// UsageExhausted reports whether app has used up today's time budget
+func (s policyState) UsageExhausted(app string, t time.Time) bool {
+	return s.m.usageExhausted(app, t)
+}
+
+// ConsumeLaunch uses one of today's free launches of app. Audit-only apps
+// are reported instead, leaving their quota untouched.
+func (s policyState) ConsumeLaunch(app string, limit int, t time.Time) bool {
+	if s.m.isAuditOnly(app) {
+		return false
+	}
+
+	ok, err := s.m.quotas.consume(app, limit, t)
+	if err != nil {
+		s.m.logger.Warnf("Failed to persist launch quota: %v", err)
+	}
+	if !ok {
+		s.m.logger.Debugf("Launch quota for %s used up (%d per day), locking", app, limit)
+		return false
+	}
+	s.m.logger.Infof("Allowing %s from launch quota (%d of %d used today)", app, s.m.quotas.used(app, t), limit)
+	return true
+}
+
+// checkProtectedLaunch applies the checks of a protected launch that need
+// the running process rather than the policy: package integrity, hash pins,
+// hash verification and membership of an unlocked instance. It reports
+// whether the launch was settled, and the error of terminating it.
+func (m *ProcessMonitor) checkProtectedLaunch(pid int, procInfo *ProcessInfo, appPath string) (bool, error) {
+	// Protected executables that differ from their distribution package
+	switch action, reason := m.checkPackageIntegrity(pid, procInfo, appPath); action {
+	case config.PackageVerifyActionDeny:
+		displayName := m.displayName(appPath)
+		if m.auditLaunch(pid, appPath, displayName, AuditActionDeny, reason) {
+			return true, nil
+		}
+		m.reportDenial(pid, appPath, displayName, reason, nil)
+		return true, m.KillProcessTree(pid)
+	case config.PackageVerifyActionWarn:
+		procInfo.Warning = reason
+	}
+
+	// Updated binaries of pinned apps need their new version approved
+	m.checkHashPin(pid, procInfo, appPath)
+
+	if m.verifyHashes && m.verifier != nil {
+		appName := filepath.Base(appPath)
+
+		// Check if we have any known hashes for this app in the blocked apps list
+		for _, blockedApp := range m.config.BlockedApps {
+			if blockedApp.Path == appPath && blockedApp.EnforceFileHash && blockedApp.FileHash != "" {
+				m.verifier.AddKnownHash(appName, appPath, blockedApp.FileHash, HashAlgorithm(blockedApp.FileHashAlgorithm))
+				break
+			}
+		}
+
+		// Log the verification error but continue with simple name matching
+		if err := m.verifier.VerifyProcess(pid, appName); err != nil {
+			m.logger.Warnf("Error during process verification for %s (pid %d): %v, continuing with simple name matching",
+				appName, pid, err)
+		}
+	}
+
+	// Processes forked by an unlocked instance that re-execute the same app
+	// belong to that instance
+	if m.inheritsAllowed(pid, appPath) {
+		m.logger.Debugf("%s (PID %d) descends from an unlocked instance, allowing", appPath, pid)
+		return true, nil
+	}
+	return false, nil
+}
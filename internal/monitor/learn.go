@@ -0,0 +1,281 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LearnedExec summarizes the launches of one executable seen in learning mode
+type LearnedExec struct {
+	Path      string         `json:"path"`
+	Hash      string         `json:"hash,omitempty"`
+	Count     int            `json:"count"`
+	Users     map[string]int `json:"users"`
+	FirstSeen time.Time      `json:"first_seen"`
+	LastSeen  time.Time      `json:"last_seen"`
+}
+
+// RecordedLaunch is a single exec event captured in learning mode, with what
+// a policy needs to evaluate it again later
+type RecordedLaunch struct {
+	Time       time.Time `json:"time"`
+	Executable string    `json:"executable"`
+	Script     string    `json:"script,omitempty"`
+	CmdLine    string    `json:"cmdline,omitempty"`
+	Hash       string    `json:"hash,omitempty"`
+	Sandbox    string    `json:"sandbox,omitempty"`
+	User       string    `json:"user"`
+	UID        int       `json:"uid"`
+	GID        int       `json:"gid"`
+	Groups     []int     `json:"groups,omitempty"`
+	Cwd        string    `json:"cwd,omitempty"`
+	Removable  string    `json:"removable,omitempty"`
+	Replaced   string    `json:"replaced,omitempty"`
+}
+
+// MaxRecordedLaunches bounds the individual events kept in a recording;
+// later launches are still counted in the summary
+const MaxRecordedLaunches = 100000
+
+// Learner records every exec event so rules can be suggested from real use
+// and candidate policies replayed against it
+type Learner struct {
+	mu        sync.Mutex
+	StartedAt time.Time               `json:"started_at"`
+	StoppedAt time.Time               `json:"stopped_at,omitempty"`
+	Execs     map[string]*LearnedExec `json:"execs"`
+	Launches  []RecordedLaunch        `json:"launches,omitempty"`
+
+	// Dropped counts launches beyond MaxRecordedLaunches
+	Dropped int `json:"dropped,omitempty"`
+}
+
+// NewLearner creates an empty learner
+func NewLearner() *Learner {
+	return &Learner{StartedAt: time.Now(), Execs: make(map[string]*LearnedExec)}
+}
+
+// LoadLearner reads a recording written by Save
+func LoadLearner(path string) (*Learner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+
+	l := NewLearner()
+	if err := json.Unmarshal(data, l); err != nil {
+		return nil, fmt.Errorf("failed to parse recording: %w", err)
+	}
+	if l.Execs == nil {
+		l.Execs = make(map[string]*LearnedExec)
+	}
+	return l, nil
+}
+
+// Save writes the recording to path
+func (l *Learner) Save(path string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.StoppedAt.IsZero() {
+		l.StoppedAt = time.Now()
+	}
+
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode recording: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create recording directory: %w", err)
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// Record adds an exec event to the summary
+func (l *Learner) Record(execPath, hash, username string, at time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.record(execPath, hash, username, at)
+}
+
+// RecordLaunch adds an exec event to the summary and keeps it for replay
+func (l *Learner) RecordLaunch(launch RecordedLaunch) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// The executable hash does not describe a script
+	target, hash := launch.Executable, launch.Hash
+	if launch.Script != "" {
+		target, hash = launch.Script, ""
+	}
+	l.record(target, hash, launch.User, launch.Time)
+
+	if len(l.Launches) >= MaxRecordedLaunches {
+		l.Dropped++
+		return
+	}
+	l.Launches = append(l.Launches, launch)
+}
+
+// record updates the summary. Caller holds mu.
+func (l *Learner) record(execPath, hash, username string, at time.Time) {
+	exec := l.Execs[execPath]
+	if exec == nil {
+		exec = &LearnedExec{Path: execPath, Users: make(map[string]int), FirstSeen: at}
+		l.Execs[execPath] = exec
+	}
+	exec.Count++
+	exec.Users[username]++
+	exec.LastSeen = at
+	if hash != "" {
+		exec.Hash = hash
+	}
+}
+
+// Suggestion is the configuration proposed from a recording
+type Suggestion struct {
+	// ProtectedApps are executables started by regular users that are worth
+	// locking
+	ProtectedApps []string
+
+	// Allowlist holds every executable observed, as hash entries when the
+	// hash is known
+	Allowlist []string
+}
+
+// systemExecDirs hold helpers and libraries users rarely launch themselves
+var systemExecDirs = []string{"/usr/lib/", "/usr/libexec/", "/lib/", "/lib64/", "/usr/sbin/", "/sbin/"}
+
+// Suggest proposes protected apps and an allowlist. An executable is
+// suggested for protection when a regular (non-root) user started it, it
+// does not live in a system helper directory, and it averaged at most
+// maxPerDay launches per day, which excludes shells and tools run in scripts.
+func (l *Learner) Suggest(maxPerDay int) Suggestion {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stopped := l.StoppedAt
+	if stopped.IsZero() {
+		stopped = time.Now()
+	}
+	days := stopped.Sub(l.StartedAt).Hours() / 24
+	if days < 1 {
+		days = 1
+	}
+
+	var s Suggestion
+	for path, exec := range l.Execs {
+		if exec.Hash != "" {
+			s.Allowlist = append(s.Allowlist, "sha256:"+exec.Hash)
+		} else {
+			s.Allowlist = append(s.Allowlist, path)
+		}
+
+		if float64(exec.Count)/days > float64(maxPerDay) || !startedByRegularUser(exec) || inSystemExecDir(path) {
+			continue
+		}
+		s.ProtectedApps = append(s.ProtectedApps, path)
+	}
+
+	sort.Strings(s.ProtectedApps)
+	sort.Strings(s.Allowlist)
+	return s
+}
+
+// Summary returns the recorded executables, most frequently launched first
+func (l *Learner) Summary() []LearnedExec {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	execs := make([]LearnedExec, 0, len(l.Execs))
+	for _, exec := range l.Execs {
+		execs = append(execs, *exec)
+	}
+	sort.Slice(execs, func(i, j int) bool {
+		if execs[i].Count != execs[j].Count {
+			return execs[i].Count > execs[j].Count
+		}
+		return execs[i].Path < execs[j].Path
+	})
+	return execs
+}
+
+// startedByRegularUser reports whether anyone but root launched the executable
+func startedByRegularUser(exec *LearnedExec) bool {
+	for name := range exec.Users {
+		if name != "root" && name != "0" {
+			return true
+		}
+	}
+	return false
+}
+
+// inSystemExecDir reports whether path lives in a system helper directory
+func inSystemExecDir(path string) bool {
+	for _, dir := range systemExecDirs {
+		if strings.HasPrefix(path, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetLearner enables learning mode: every exec event is recorded in l
+// before protection rules are applied. Pass nil to stop recording.
+func (m *ProcessMonitor) SetLearner(l *Learner) {
+	m.learnerMu.Lock()
+	m.learner = l
+	m.learnerMu.Unlock()
+}
+
+// learn records an exec event when learning mode is enabled
+func (m *ProcessMonitor) learn(procInfo *ProcessInfo) {
+	m.learnerMu.Lock()
+	l := m.learner
+	m.learnerMu.Unlock()
+
+	if l == nil {
+		return
+	}
+
+	launch := RecordedLaunch{
+		Time:       time.Now(),
+		Executable: procInfo.Command,
+		Script:     procInfo.Script,
+		CmdLine:    procInfo.CmdLine,
+		Hash:       procInfo.ExecHash,
+		User:       "unknown",
+		UID:        -1,
+		GID:        -1,
+	}
+
+	if creds, err := readProcessCredentials(procInfo.PID); err == nil {
+		launch.UID, launch.GID, launch.Groups = creds.UID, creds.GID, creds.Groups
+		launch.User = strconv.Itoa(creds.UID)
+		if u, err := user.LookupId(launch.User); err == nil {
+			launch.User = u.Username
+		}
+	}
+	if sandbox, _ := detectSandbox(procInfo.PID, procInfo.Command); sandbox != nil {
+		launch.Sandbox = sandbox.Key()
+	}
+	if cwd, err := os.Readlink(fmt.Sprintf("/proc/%d/cwd", procInfo.PID)); err == nil {
+		launch.Cwd = cwd
+	}
+	launch.Removable = removableMount(procInfo.PID, procInfo)
+	launch.Replaced = ReplacedExecutable(procInfo.PID)
+	if m.shouldRedactArgs(procInfo.Command) {
+		launch.CmdLine = redactCmdLine(launch.CmdLine)
+	}
+
+	l.RecordLaunch(launch)
+}
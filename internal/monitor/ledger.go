@@ -0,0 +1,201 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// LedgerEntry is a launch suspended awaiting authentication
+type LedgerEntry struct {
+	App       string    `json:"app"`
+	StartTime int64     `json:"start_time"`
+	Since     time.Time `json:"since"`
+
+	// Cgroup is the frozen cgroup holding the launch; empty when it was
+	// stopped with SIGSTOP
+	Cgroup string `json:"cgroup,omitempty"`
+}
+
+// suspendLedger keeps the suspended launches on disk so a daemon that was
+// killed and restarted can find them again rather than leave them frozen
+type suspendLedger struct {
+	mu      sync.Mutex
+	path    string
+	Entries map[int]LedgerEntry `json:"entries"`
+}
+
+// loadSuspendLedger reads the ledger from path; a missing or unreadable
+// file starts empty
+func loadSuspendLedger(path string) *suspendLedger {
+	l := &suspendLedger{path: path, Entries: make(map[int]LedgerEntry)}
+	if path == "" {
+		return l
+	}
+
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, l); err != nil || l.Entries == nil {
+			l.Entries = make(map[int]LedgerEntry)
+		}
+	}
+	return l
+}
+
+// save writes the ledger to disk atomically. Caller holds mu.
+func (l *suspendLedger) save() error {
+	if l.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create ledger directory: %w", err)
+	}
+
+	data, err := json.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("failed to encode ledger: %w", err)
+	}
+
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write ledger: %w", err)
+	}
+	return os.Rename(tmp, l.path)
+}
+
+// add records a suspended launch
+func (l *suspendLedger) add(pid int, entry LedgerEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.Entries[pid] = entry
+	return l.save()
+}
+
+// remove forgets pid. It runs for every exit on the system, so the file is
+// only written when pid was recorded.
+func (l *suspendLedger) remove(pid int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.Entries[pid]; !ok {
+		return nil
+	}
+	delete(l.Entries, pid)
+	return l.save()
+}
+
+// take returns the recorded launches and empties the ledger
+func (l *suspendLedger) take() map[int]LedgerEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := l.Entries
+	l.Entries = make(map[int]LedgerEntry)
+	if len(entries) > 0 {
+		_ = l.save()
+	}
+	return entries
+}
+
+// groupPath returns the frozen cgroup of root, or "" when it has none
+func (f *cgroupFreezer) groupPath(root int) string {
+	if f == nil {
+		return ""
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if group := f.groups[root]; group != nil {
+		return group.path
+	}
+	return ""
+}
+
+// recordSuspended adds a launch suspended for authentication to the ledger
+func (m *ProcessMonitor) recordSuspended(pid int, app string, since time.Time) {
+	startTime, err := m.getProcessStartTime(pid)
+	if err != nil {
+		return
+	}
+
+	entry := LedgerEntry{App: app, StartTime: startTime, Since: since, Cgroup: m.freezer.groupPath(pid)}
+	if err := m.ledger.add(pid, entry); err != nil {
+		m.logger.Warnf("Failed to record suspended process %d: %v", pid, err)
+	}
+}
+
+// forgetSuspended drops pid from the ledger
+func (m *ProcessMonitor) forgetSuspended(pid int) {
+	if err := m.ledger.remove(pid); err != nil {
+		m.logger.Warnf("Failed to update suspended process ledger: %v", err)
+	}
+}
+
+// recoverSuspended takes over the launches a previous run left suspended.
+// Each is handled again like a new launch, so it is prompted for, denied or
+// allowed under the current configuration; launches that are no longer
+// locked are resumed.
+func (m *ProcessMonitor) recoverSuspended() {
+	for pid, entry := range m.ledger.take() {
+		// The PID may have been reused since
+		startTime, err := m.getProcessStartTime(pid)
+		if err != nil || startTime != entry.StartTime {
+			continue
+		}
+
+		// Hand a frozen cgroup left behind back to SIGSTOP so the launch can
+		// be frozen again by this run
+		stopped := []int{pid}
+		if entry.Cgroup != "" && strings.HasPrefix(filepath.Base(entry.Cgroup), "wyrmlock-") {
+			if procs := releaseStaleCgroup(entry.Cgroup); len(procs) > 0 {
+				stopped = procs
+			}
+		}
+
+		if state, err := m.getProcessState(pid); err != nil || state != ProcessStateSuspended {
+			// Resumed by someone else meanwhile; the startup scan covers it
+			continue
+		}
+
+		m.logger.Infof("Recovering %s (PID %d), suspended by a previous run since %s",
+			entry.App, pid, entry.Since.Format(time.RFC3339))
+		if err := m.handleExecEvent(pid); err != nil {
+			m.logger.Warnf("Failed to recover suspended process %d: %v", pid, err)
+		}
+
+		if !m.isPending(pid) {
+			for _, stoppedPid := range stopped {
+				_ = syscall.Kill(stoppedPid, syscall.SIGCONT)
+			}
+		}
+	}
+}
+
+// isPending reports whether pid is suspended awaiting authentication
+func (m *ProcessMonitor) isPending(pid int) bool {
+	m.pendingMu.Lock()
+	defer m.pendingMu.Unlock()
+	_, ok := m.pending[pid]
+	return ok
+}
+
+// releaseStaleCgroup moves the processes of a frozen cgroup left by a
+// previous run to its parent, stopped with SIGSTOP, removes it and returns
+// the processes it held
+func releaseStaleCgroup(path string) []int {
+	parent := filepath.Dir(path)
+	procs := cgroupProcs(path)
+	for _, pid := range procs {
+		_ = syscall.Kill(pid, syscall.SIGSTOP)
+		_ = writeCgroupFile(parent, "cgroup.procs", strconv.Itoa(pid))
+	}
+	_ = writeCgroupFile(path, "cgroup.freeze", "0")
+	_ = os.Remove(path)
+	return procs
+}
@@ -0,0 +1,135 @@
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"wyrmlock/internal/logging"
+)
+
+const (
+	// userHZ is the clock tick rate of start times in /proc/<pid>/stat,
+	// fixed at 100 for userspace on Linux
+	userHZ = 100
+
+	// maxRecvBuffer caps how far the socket buffer grows after overflows
+	maxRecvBuffer = 8 << 20
+
+	// rescanGrace widens the rescan window to cover clock granularity
+	rescanGrace = time.Second
+)
+
+// recoverFromOverflow handles ENOBUFS: the kernel dropped process events
+// because the socket buffer was full. The buffer is enlarged, the
+// subscription renewed, and processes started since the last successful
+// read are evaluated as if their exec had been seen. A process forked
+// before that read that only exec'd during the gap is not found.
+func (m *ProcessMonitor) recoverFromOverflow(lastRecv time.Time) {
+	m.logger.Warnf("Kernel dropped process events (socket buffer overflow); rescanning processes started since %s",
+		lastRecv.Format("15:04:05.000"))
+
+	if logging.SecurityLog != nil {
+		logging.SecurityLog.LogEvent(logging.EventSystemError, "Process events were dropped by the kernel", map[string]interface{}{
+			"last_received": lastRecv,
+		})
+	}
+
+	if size, err := m.growRecvBuffer(); err != nil {
+		m.logger.Warnf("Failed to enlarge netlink socket buffer: %v", err)
+	} else {
+		m.logger.Infof("Netlink socket buffer is now %d bytes", size)
+	}
+
+	if err := m.subscribe(); err != nil {
+		m.logger.Errorf("Failed to resubscribe to proc connector: %v", err)
+	}
+
+	go m.rescanProcesses(lastRecv.Add(-rescanGrace))
+}
+
+// growRecvBuffer doubles the socket receive buffer up to maxRecvBuffer.
+// SO_RCVBUFFORCE ignores the rmem_max sysctl but needs CAP_NET_ADMIN, which
+// the monitor has since it reads the proc connector.
+func (m *ProcessMonitor) growRecvBuffer() (int, error) {
+	current, err := syscall.GetsockoptInt(m.sock, syscall.SOL_SOCKET, syscall.SO_RCVBUF)
+	if err != nil {
+		return 0, err
+	}
+
+	// The kernel reports twice the requested size to account for overhead
+	size := current
+	if size < maxRecvBuffer {
+		size = min(size*2, maxRecvBuffer)
+	}
+
+	if err := syscall.SetsockoptInt(m.sock, syscall.SOL_SOCKET, syscall.SO_RCVBUFFORCE, size); err != nil {
+		if err := syscall.SetsockoptInt(m.sock, syscall.SOL_SOCKET, syscall.SO_RCVBUF, size); err != nil {
+			return 0, err
+		}
+	}
+	return syscall.GetsockoptInt(m.sock, syscall.SOL_SOCKET, syscall.SO_RCVBUF)
+}
+
+// rescanProcesses evaluates every process started at or after since that
+// is not already being handled
+func (m *ProcessMonitor) rescanProcesses(since time.Time) {
+	sinceTicks, err := bootTicksAt(since)
+	if err != nil {
+		m.logger.Errorf("Failed to rescan processes: %v", err)
+		return
+	}
+
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		m.logger.Errorf("Failed to rescan processes: %v", err)
+		return
+	}
+
+	scanned := 0
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == os.Getpid() {
+			continue
+		}
+
+		start, err := m.getProcessStartTime(pid)
+		if err != nil || start < sinceTicks {
+			continue
+		}
+
+		scanned++
+		if err := m.handleExecEvent(pid); err != nil {
+			m.logger.Debugf("Rescan of PID %d failed: %v", pid, err)
+		}
+	}
+
+	m.logger.Infof("Rescanned %d processes started during the event gap", scanned)
+}
+
+// bootTicksAt converts a wall-clock time to clock ticks since boot, the unit
+// of process start times
+func bootTicksAt(t time.Time) (int64, error) {
+	data, err := os.ReadFile("/proc/uptime")
+	if err != nil {
+		return 0, fmt.Errorf("failed to read uptime: %w", err)
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("invalid uptime format")
+	}
+	uptime, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse uptime: %w", err)
+	}
+
+	seconds := uptime - time.Since(t).Seconds()
+	if seconds < 0 {
+		seconds = 0
+	}
+	return int64(seconds * userHZ), nil
+}
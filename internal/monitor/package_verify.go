@@ -0,0 +1,241 @@
+package monitor
+
+import (
+	"bufio"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/logging"
+)
+
+// dpkgInfoDir holds the per-package file lists and md5sums of dpkg
+const dpkgInfoDir = "/var/lib/dpkg/info"
+
+// PackageVerification is the result of checking a file against the package
+// manager's record of it
+type PackageVerification struct {
+	// Package owns the file; empty when no package does
+	Package string
+
+	// Matches is set when the file's content is what the package shipped
+	Matches bool
+}
+
+// Owned reports whether a package ships the file
+func (v PackageVerification) Owned() bool {
+	return v.Package != ""
+}
+
+// VerifyPackageFile checks a file against the dpkg md5sums or the rpm
+// database. Files no package owns, and systems with neither package
+// manager, verify as unowned.
+func VerifyPackageFile(path string) (PackageVerification, error) {
+	if _, err := exec.LookPath("dpkg-query"); err == nil {
+		return verifyDpkgFile(path)
+	}
+	if _, err := exec.LookPath("rpm"); err == nil {
+		return verifyRPMFile(path)
+	}
+	return PackageVerification{}, nil
+}
+
+// verifyDpkgFile compares a file with the md5sum its Debian package recorded
+func verifyDpkgFile(path string) (PackageVerification, error) {
+	// usrmerge systems may list /usr/bin/foo as /bin/foo
+	candidates := []string{path}
+	if rest, ok := strings.CutPrefix(path, "/usr"); ok {
+		candidates = append(candidates, rest)
+	}
+
+	for _, candidate := range candidates {
+		out, err := exec.Command("dpkg-query", "-S", candidate).Output()
+		if err != nil {
+			continue
+		}
+
+		// "pkg[:arch][, pkg2]: /path"; diversions start with "diversion by"
+		for _, line := range strings.Split(string(out), "\n") {
+			owners, owned, ok := strings.Cut(line, ": ")
+			if !ok || owned != candidate || strings.HasPrefix(owners, "diversion ") {
+				continue
+			}
+			pkg := strings.TrimSpace(strings.Split(owners, ",")[0])
+
+			want, err := dpkgMD5Sum(pkg, strings.TrimPrefix(candidate, "/"))
+			if err != nil {
+				return PackageVerification{}, err
+			}
+			got, err := fileDigest(path, md5.New())
+			if err != nil {
+				return PackageVerification{}, err
+			}
+			return PackageVerification{Package: pkg, Matches: got == want}, nil
+		}
+	}
+	return PackageVerification{}, nil
+}
+
+// dpkgMD5Sum reads the recorded md5sum of relPath from a package's
+// md5sums file
+func dpkgMD5Sum(pkg, relPath string) (string, error) {
+	names := []string{pkg + ".md5sums"}
+	if name, _, ok := strings.Cut(pkg, ":"); ok {
+		names = append(names, name+".md5sums")
+	}
+
+	for _, name := range names {
+		f, err := os.Open(filepath.Join(dpkgInfoDir, name))
+		if err != nil {
+			continue
+		}
+		defer f.Close()
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			sum, file, ok := strings.Cut(scanner.Text(), "  ")
+			if ok && file == relPath {
+				return sum, nil
+			}
+		}
+		return "", fmt.Errorf("package %s records no md5sum for /%s", pkg, relPath)
+	}
+	return "", fmt.Errorf("package %s has no md5sums", pkg)
+}
+
+// verifyRPMFile compares a file with the digest in the rpm database
+func verifyRPMFile(path string) (PackageVerification, error) {
+	pkgOut, err := exec.Command("rpm", "-qf", "--queryformat", "%{NAME}\n", path).Output()
+	if err != nil {
+		// Not owned by any package
+		return PackageVerification{}, nil
+	}
+	pkg := strings.TrimSpace(strings.Split(string(pkgOut), "\n")[0])
+
+	// path size mtime digest mode owner group isconfig isdoc rdev symlink
+	dump, err := exec.Command("rpm", "-qf", "--dump", path).Output()
+	if err != nil {
+		return PackageVerification{}, fmt.Errorf("failed to query rpm database: %w", err)
+	}
+	for _, line := range strings.Split(string(dump), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[0] != path {
+			continue
+		}
+
+		want := fields[3]
+		var h hash.Hash
+		switch len(want) {
+		case md5.Size * 2:
+			h = md5.New()
+		case sha256.Size * 2:
+			h = sha256.New()
+		default:
+			return PackageVerification{}, fmt.Errorf("unsupported rpm file digest for %s", path)
+		}
+		got, err := fileDigest(path, h)
+		if err != nil {
+			return PackageVerification{}, err
+		}
+		return PackageVerification{Package: pkg, Matches: got == want}, nil
+	}
+	return PackageVerification{}, fmt.Errorf("package %s records no digest for %s", pkg, path)
+}
+
+// fileDigest hashes a file with h
+func fileDigest(path string, h hash.Hash) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// packageCache remembers verifications by file version so the package
+// manager is queried again only when the file changes
+type packageCache struct {
+	mu      sync.Mutex
+	results map[fileIdentity]PackageVerification
+}
+
+// verify returns the cached verification of path, checking it on a miss
+func (c *packageCache) verify(path string) (PackageVerification, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return PackageVerification{}, err
+	}
+	id, ok := identifyFile(file)
+	file.Close()
+	if !ok {
+		return VerifyPackageFile(path)
+	}
+
+	c.mu.Lock()
+	result, cached := c.results[id]
+	c.mu.Unlock()
+	if cached {
+		return result, nil
+	}
+
+	result, err = VerifyPackageFile(path)
+	if err != nil {
+		return result, err
+	}
+
+	c.mu.Lock()
+	if c.results == nil {
+		c.results = make(map[fileIdentity]PackageVerification)
+	}
+	c.results[id] = result
+	c.mu.Unlock()
+	return result, nil
+}
+
+// checkPackageIntegrity verifies a protected app against its package and
+// returns the configured action and the reason when the file differs from
+// what the package shipped, or "" when it matches or no package owns it
+func (m *ProcessMonitor) checkPackageIntegrity(pid int, procInfo *ProcessInfo, app string) (string, string) {
+	action := m.config.Monitor.PackageVerifyAction
+	if action == "" || action == config.PackageVerifyActionOff {
+		return "", ""
+	}
+
+	// The host's package database does not describe container files
+	if procInfo.Container != "" {
+		return "", ""
+	}
+
+	result, err := m.packages.verify(app)
+	if err != nil {
+		m.logger.Debugf("Failed to verify %s against its package: %v", app, err)
+		return "", ""
+	}
+	if !result.Owned() || result.Matches {
+		return "", ""
+	}
+
+	reason := fmt.Sprintf("executable does not match package %s", result.Package)
+	m.logger.Warnf("Suspicious launch of %s (PID %d): %s", app, pid, reason)
+	if logging.SecurityLog != nil {
+		logging.SecurityLog.LogProcessEvent(logging.EventPackageMismatch, app, pid, map[string]interface{}{
+			"package": result.Package,
+			"action":  action,
+			"cmdline": m.eventCmdLine(pid, app),
+		})
+	}
+	return action, reason
+}
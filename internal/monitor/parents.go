@@ -0,0 +1,29 @@
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// maxAncestorDepth bounds the walk up the parent chain
+const maxAncestorDepth = 64
+
+// ancestorExecutables returns the executables of pid's ancestors, nearest
+// first. Ancestors whose executable was deleted are skipped so a removed
+// launcher cannot be impersonated by its path.
+func (m *ProcessMonitor) ancestorExecutables(pid int) []string {
+	var exes []string
+	for depth := 0; depth < maxAncestorDepth; depth++ {
+		ppid, err := m.getProcessParentPID(pid)
+		if err != nil || ppid <= 0 {
+			break
+		}
+
+		if exe, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", ppid)); err == nil && !strings.HasSuffix(exe, deletedSuffix) {
+			exes = append(exes, exe)
+		}
+		pid = ppid
+	}
+	return exes
+}
@@ -0,0 +1,169 @@
+package monitor
+
+import (
+	"errors"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// pidTombstoneAge is how long the PID of an exited locked process refuses
+// signals, comfortably longer than any authentication dialog
+const pidTombstoneAge = time.Hour
+
+// ErrProcessExited is returned when signalling a locked process that has
+// exited, whose PID may already belong to another process
+var ErrProcessExited = errors.New("process has exited")
+
+// pidfdTable holds process file descriptors captured when a process is
+// locked. Signals sent through them reach the process that was locked even
+// if it exits during a long dialog and its PID is reused.
+type pidfdTable struct {
+	mu  sync.Mutex
+	fds map[int]int
+
+	// exited records locked processes that exited; their PIDs are not
+	// signalled until captured again
+	exited map[int]time.Time
+}
+
+// newPidfdTable creates an empty table
+func newPidfdTable() *pidfdTable {
+	return &pidfdTable{fds: make(map[int]int), exited: make(map[int]time.Time)}
+}
+
+// capture opens a pidfd for pid, replacing an earlier one. startTime is the
+// start time read with the exec event; a different start time once the
+// pidfd is open means the PID was already reused and nothing is captured.
+// Kernels without pidfd fall back to signalling by PID.
+func (t *pidfdTable) capture(pid int, startTime int64, readStart func(int) (int64, error)) error {
+	fd, err := unix.PidfdOpen(pid, 0)
+	if err != nil {
+		if err == unix.ESRCH {
+			return ErrProcessExited
+		}
+		t.forget(pid)
+		return nil
+	}
+
+	if startTime != 0 {
+		if current, err := readStart(pid); err != nil || current != startTime {
+			unix.Close(fd)
+			return ErrProcessExited
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if old, ok := t.fds[pid]; ok {
+		unix.Close(old)
+	}
+	t.fds[pid] = fd
+	delete(t.exited, pid)
+	return nil
+}
+
+// signal sends sig through the captured pidfd, or by PID when none was
+// captured. The PID of a locked process that exited is never signalled.
+func (t *pidfdTable) signal(pid int, sig syscall.Signal) error {
+	t.mu.Lock()
+	fd, ok := t.fds[pid]
+	_, exited := t.exited[pid]
+	t.mu.Unlock()
+
+	if exited {
+		return ErrProcessExited
+	}
+	if !ok {
+		return syscall.Kill(pid, sig)
+	}
+
+	err := unix.PidfdSendSignal(fd, unix.Signal(sig), nil, 0)
+	if err == unix.ESRCH {
+		return ErrProcessExited
+	}
+	return err
+}
+
+// exit closes the pidfd of an exited process and keeps its PID from being
+// signalled. Old entries are pruned here.
+func (t *pidfdTable) exit(pid int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	for old, at := range t.exited {
+		if now.Sub(at) > pidTombstoneAge {
+			delete(t.exited, old)
+		}
+	}
+
+	fd, ok := t.fds[pid]
+	if !ok {
+		return
+	}
+	unix.Close(fd)
+	delete(t.fds, pid)
+	t.exited[pid] = now
+}
+
+// dup returns a duplicate of the pidfd held for pid, or -1 when none is
+func (t *pidfdTable) dup(pid int) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	fd, ok := t.fds[pid]
+	if !ok {
+		return -1
+	}
+	dup, err := unix.FcntlInt(uintptr(fd), unix.F_DUPFD_CLOEXEC, 0)
+	if err != nil {
+		return -1
+	}
+	return dup
+}
+
+// pinned reports whether a pidfd is held for pid
+func (t *pidfdTable) pinned(pid int) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.fds[pid]
+	return ok
+}
+
+// forget drops pid without marking it exited
+func (t *pidfdTable) forget(pid int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if fd, ok := t.fds[pid]; ok {
+		unix.Close(fd)
+		delete(t.fds, pid)
+	}
+	delete(t.exited, pid)
+}
+
+// closeAll closes every captured pidfd
+func (t *pidfdTable) closeAll() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for pid, fd := range t.fds {
+		unix.Close(fd)
+		delete(t.fds, pid)
+	}
+	t.exited = make(map[int]time.Time)
+}
+
+// capturePidfd pins a process about to be locked, so later signals cannot
+// reach another process that reuses its PID. startTime is 0 when unknown.
+func (m *ProcessMonitor) capturePidfd(pid int, startTime int64) error {
+	return m.pidfds.capture(pid, startTime, m.getProcessStartTime)
+}
+
+// signalProcess sends sig to pid through its pidfd when one was captured
+func (m *ProcessMonitor) signalProcess(pid int, sig syscall.Signal) error {
+	return m.pidfds.signal(pid, sig)
+}
@@ -0,0 +1,39 @@
+package monitor
+
+import (
+	"wyrmlock/internal/logging"
+)
+
+// denySilently terminates a protected launch without prompting or notifying
+// clients, for kiosk deployments. The denial is still written to the
+// security log.
+func (m *ProcessMonitor) denySilently(pid int, execPath string) {
+	m.logger.Infof("Silently denying %s (PID %d) by policy", execPath, pid)
+
+	if logging.SecurityLog != nil {
+		logging.SecurityLog.LogProcessEvent(logging.EventProcessBlocked, execPath, pid, map[string]interface{}{
+			"reason":  "denied by policy",
+			"silent":  true,
+			"cmdline": m.eventCmdLine(pid, execPath),
+		})
+	}
+
+	if err := m.KillProcessTree(pid); err != nil {
+		m.logger.Errorf("Failed to terminate process %d: %v", pid, err)
+	}
+
+	m.releaseHandledPid(pid)
+}
+
+// logLaunch lets a protected launch run without prompting and records it in
+// the security log
+func (m *ProcessMonitor) logLaunch(pid int, execPath, displayName string) {
+	m.logger.Infof("Allowing %s (PID %d) by policy, launch logged", displayName, pid)
+
+	if logging.SecurityLog != nil {
+		logging.SecurityLog.LogProcessEvent(logging.EventProcessAllowed, execPath, pid, map[string]interface{}{
+			"reason":  "allowed by policy",
+			"cmdline": m.eventCmdLine(pid, execPath),
+		})
+	}
+}
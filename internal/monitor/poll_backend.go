@@ -0,0 +1,144 @@
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// DefaultPollInterval is how often the polling backend scans /proc when
+// the config leaves it unset
+const DefaultPollInterval = 250 * time.Millisecond
+
+// pollBackend finds launches by scanning /proc, for kernels without the proc
+// connector and containers that may not bind its socket. Processes run
+// unsuspended for up to one interval, a process that execs twice within an
+// interval is only seen once, and credential changes are not reported.
+type pollBackend struct {
+	m        *ProcessMonitor
+	interval time.Duration
+
+	// known maps each PID seen in the last scan to what it was running
+	known map[int]polledProcess
+}
+
+// polledProcess identifies what a PID was running when last scanned. The
+// start time tells a reused PID apart from the process seen before.
+type polledProcess struct {
+	start int64
+	exe   string
+}
+
+// newPollBackend creates a polling backend scanning every intervalMs
+// milliseconds
+func newPollBackend(m *ProcessMonitor, intervalMs int) *pollBackend {
+	interval := time.Duration(intervalMs) * time.Millisecond
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	return &pollBackend{m: m, interval: interval}
+}
+
+// Name implements MonitorBackend
+func (b *pollBackend) Name() string {
+	return BackendPolling
+}
+
+// Open records the processes already running; the startup scan handles
+// those, so they are not reported as launches
+func (b *pollBackend) Open() error {
+	known, err := b.scan()
+	if err != nil {
+		return err
+	}
+	b.known = known
+	return nil
+}
+
+// Run scans /proc every interval until the monitor stops
+func (b *pollBackend) Run() {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.m.stopCh:
+			return
+		case <-ticker.C:
+			b.poll()
+		}
+	}
+}
+
+// Wake implements MonitorBackend; Run already waits on the stop channel
+func (b *pollBackend) Wake() {}
+
+// Close implements MonitorBackend
+func (b *pollBackend) Close() error {
+	return nil
+}
+
+// poll compares /proc with the last scan and reports the differences as
+// fork, exec and exit events
+func (b *pollBackend) poll() {
+	current, err := b.scan()
+	if err != nil {
+		b.m.logger.Errorf("Failed to poll processes: %v", err)
+		return
+	}
+
+	for pid, proc := range current {
+		prev, seen := b.known[pid]
+		switch {
+		case !seen || prev.start != proc.start:
+			// A new process, possibly on a reused PID
+			if seen {
+				b.m.handleExitEvent(pid)
+			}
+			if ppid, err := b.m.getProcessParentPID(pid); err == nil {
+				b.m.handleForkEvent(ppid, pid)
+			}
+			if proc.exe != "" {
+				b.m.enqueueExec(pid)
+			}
+		case proc.exe != prev.exe && proc.exe != "":
+			// The process executed another program
+			b.m.enqueueExec(pid)
+		}
+	}
+
+	for pid := range b.known {
+		if _, ok := current[pid]; !ok {
+			b.m.handleExitEvent(pid)
+		}
+	}
+
+	b.known = current
+}
+
+// scan lists the running processes. Kernel threads have no executable and
+// are recorded with an empty one.
+func (b *pollBackend) scan() (map[int]polledProcess, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read /proc: %w", err)
+	}
+
+	procs := make(map[int]polledProcess, len(entries))
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		start, err := b.m.getProcessStartTime(pid)
+		if err != nil {
+			// Exited while scanning
+			continue
+		}
+		exe, _ := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+		procs[pid] = polledProcess{start: start, exe: exe}
+	}
+	return procs, nil
+}
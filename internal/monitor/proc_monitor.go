@@ -1,23 +1,27 @@
 package monitor
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
+	"encoding/binary"
 	"errors"
 	"fmt"
-	"io"
 	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 	"unsafe"
 
 	"wyrmlock/internal/auth"
 	"wyrmlock/internal/config"
+	"wyrmlock/internal/desktop"
 	"wyrmlock/internal/gui"
+	"wyrmlock/internal/i18n"
 	"wyrmlock/internal/logging"
+	"wyrmlock/internal/policy"
+	"wyrmlock/internal/session"
+
+	"golang.org/x/sys/unix"
 )
 
 const (
@@ -49,6 +53,24 @@ type ProcessInfo struct {
 	StartTime int64  // Process start time for race condition prevention
 	CmdLine   string // Full command line for verification
 	State     string // Current process state
+	Script    string // Script path when Command is a known interpreter
+	Warning   string // Caution shown with the prompt, e.g. for anonymous executables
+	Container string // Container runtime when launched inside a container
+	Session   string // logind session the process belongs to
+	Seat      string // Seat of that session, e.g. seat0
+	AppName   string // Application name from the app's .desktop entry
+	Icon      string // Icon name or path from the app's .desktop entry
+	PinHash   string // Hash pinned for the app when this launch is approved
+	NewBinary bool   // The executable differs from the app's pinned hash
+}
+
+// Target returns the program the process is running: the script for
+// interpreted programs, otherwise the executable
+func (p ProcessInfo) Target() string {
+	if p.Script != "" {
+		return p.Script
+	}
+	return p.Command
 }
 
 // ProcessState represents the current state of a process
@@ -77,9 +99,13 @@ type ProcessMonitor struct {
 	config        *config.Config
 	authenticator *auth.Authenticator
 	guiManager    *gui.Manager
+	backend       MonitorBackend
 	sock          int
+	wakeFd        int
 	running       bool
 	mu            sync.Mutex
+	capabilities  Capabilities
+	freezer       *cgroupFreezer
 	wg            sync.WaitGroup
 	stopCh        chan struct{}
 
@@ -99,9 +125,80 @@ type ProcessMonitor struct {
 	monitoredProcesses map[int]ProcessInfo
 	monitoredMu        sync.RWMutex
 	
+	// Callback for launches denied by policy without prompting
+	deniedHandler ProcessDeniedHandler
+
+	// Callback for launches of audit-only apps
+	auditHandler ProcessAuditHandler
+	
+	// Exec events waiting for a worker
+	execQueue *execQueue
+
+	// pidfds pin locked processes against PID reuse
+	pidfds *pidfdTable
+
+	// pending holds launches suspended awaiting authentication
+	pending   map[int]pendingLaunch
+	pendingMu sync.Mutex
+
+	// ledger persists pending launches for the next run
+	ledger *suspendLedger
+
+	// snapshots hold what each suspended launch ran when it was locked
+	snapshots  map[int]launchSnapshot
+	snapshotMu sync.Mutex
+
+	// prompts holds the open authentication prompt of each app
+	prompts   map[string]*sharedPrompt
+	promptsMu sync.Mutex
+
+	// Processes suspended by the startup scan, mapped to their app
+	startupHeld map[int]string
+	startupMu   sync.Mutex
+
 	// Process verification
 	verifier      *ProcessVerifier
 	verifyHashes  bool // Whether to verify executable hashes
+
+	// Launch policy compiled from the config
+	policy *policy.Engine
+
+	// Desktop entries naming protected executables
+	desktopApps *desktop.Index
+
+	// Package manager verifications of protected executables
+	packages packageCache
+
+	// Approved hashes of apps with hash pinning
+	pins *hashPins
+
+	// Kernel IMA measurements of executed binaries; nil without IMA
+	ima *imaLog
+
+	// Per-day launch counters for apps with a launch quota
+	quotas *launchQuota
+
+	// Per-day running time for apps with a screen-time budget
+	usage               *usageTracker
+	usageWarningHandler UsageWarningHandler
+
+	// Learning mode recorder, nil when disabled
+	learner   *Learner
+	learnerMu sync.Mutex
+
+	// Dangerous environment variables captured at exec time
+	envFindings map[int][]EnvFinding
+	envMu       sync.Mutex
+
+	// Descendants of monitored processes, sharing their locked or allowed
+	// state
+	treeMembers map[int]*treeMember
+	treeMu      sync.Mutex
+
+	// Running processes of apps with per-user rules, re-evaluated when their
+	// credentials change
+	credWatches map[int]credentialWatch
+	credWatchMu sync.Mutex
 }
 
 // Netlink message header
@@ -142,6 +239,8 @@ func NewProcessMonitor(cfg *config.Config, authenticator *auth.Authenticator) (*
 	if err != nil {
 		return nil, fmt.Errorf("failed to create GUI manager: %w", err)
 	}
+	guiManager.SetDialogTimeout(time.Duration(cfg.Auth.DialogTimeout) * time.Second)
+	guiManager.SetBranding(gui.BrandingFromConfig(cfg))
 
 	// Get logger
 	logger := logging.DefaultLogger
@@ -152,6 +251,13 @@ func NewProcessMonitor(cfg *config.Config, authenticator *auth.Authenticator) (*
 	
 	// Create process verifier with default settings
 	verifier := NewProcessVerifier(logger)
+	verifier.hashCache.SetConcurrency(cfg.Monitor.HashConcurrency)
+
+	// Compile the launch policy
+	engine, err := policy.NewEngine(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile launch policy: %w", err)
+	}
 
 	return &ProcessMonitor{
 		config:             cfg,
@@ -160,10 +266,26 @@ func NewProcessMonitor(cfg *config.Config, authenticator *auth.Authenticator) (*
 		handledPids:        make(map[int]string),
 		monitoredProcesses: make(map[int]ProcessInfo),
 		stopCh:             make(chan struct{}),
+		execQueue:          newExecQueue(cfg.Monitor.ExecWorkers, cfg.Monitor.ExecQueueSize),
+		startupHeld:        make(map[int]string),
+		pidfds:             newPidfdTable(),
+		pending:            make(map[int]pendingLaunch),
+		ledger:             loadSuspendLedger(cfg.Monitor.LedgerFile),
+		snapshots:          make(map[int]launchSnapshot),
+		prompts:            make(map[string]*sharedPrompt),
 		logger:             logger,
 		daemonMode:         false,
 		verifier:           verifier,
 		verifyHashes:       cfg.Monitor.VerifyHashes,
+		policy:             engine,
+		desktopApps:        desktop.NewIndex(nil),
+		ima:                openIMALog(cfg),
+		pins:               loadHashPins(cfg.Monitor.PinFile),
+		quotas:             loadLaunchQuota(cfg.Monitor.QuotaFile),
+		usage:              loadUsageTracker(cfg.Monitor.UsageFile),
+		envFindings:        make(map[int][]EnvFinding),
+		treeMembers:        make(map[int]*treeMember),
+		credWatches:        make(map[int]credentialWatch),
 	}, nil
 }
 
@@ -171,16 +293,39 @@ func NewProcessMonitor(cfg *config.Config, authenticator *auth.Authenticator) (*
 func NewProcessMonitorDaemon(cfg *config.Config, logger *logging.Logger) (*ProcessMonitor, error) {
 	// Create process verifier
 	verifier := NewProcessVerifier(logger)
+	verifier.hashCache.SetConcurrency(cfg.Monitor.HashConcurrency)
+
+	// Compile the launch policy
+	engine, err := policy.NewEngine(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile launch policy: %w", err)
+	}
 	
 	return &ProcessMonitor{
 		config:             cfg,
 		handledPids:        make(map[int]string),
 		monitoredProcesses: make(map[int]ProcessInfo),
 		stopCh:             make(chan struct{}),
+		execQueue:          newExecQueue(cfg.Monitor.ExecWorkers, cfg.Monitor.ExecQueueSize),
+		startupHeld:        make(map[int]string),
+		pidfds:             newPidfdTable(),
+		pending:            make(map[int]pendingLaunch),
+		ledger:             loadSuspendLedger(cfg.Monitor.LedgerFile),
+		snapshots:          make(map[int]launchSnapshot),
+		prompts:            make(map[string]*sharedPrompt),
 		logger:             logger,
 		daemonMode:         true,
 		verifier:           verifier,
 		verifyHashes:       cfg.Monitor.VerifyHashes,
+		policy:             engine,
+		desktopApps:        desktop.NewIndex(nil),
+		ima:                openIMALog(cfg),
+		pins:               loadHashPins(cfg.Monitor.PinFile),
+		quotas:             loadLaunchQuota(cfg.Monitor.QuotaFile),
+		usage:              loadUsageTracker(cfg.Monitor.UsageFile),
+		envFindings:        make(map[int][]EnvFinding),
+		treeMembers:        make(map[int]*treeMember),
+		credWatches:        make(map[int]credentialWatch),
 	}, nil
 }
 
@@ -202,40 +347,59 @@ func (m *ProcessMonitor) Start() error {
 
 	m.logger.Info("Starting process monitor")
 
-	// Open netlink socket
-	sock, err := syscall.Socket(
-		syscall.AF_NETLINK,
-		syscall.SOCK_DGRAM,
-		NETLINK_CONNECTOR,
-	)
+	// Pick the event backend from what this kernel supports
+	m.capabilities = ProbeCapabilities()
+	for _, warning := range m.capabilities.Warnings() {
+		m.logger.Warnf("Degraded mode: %s", warning)
+	}
+	backend, err := m.selectBackend(m.config.Monitor.Backend)
 	if err != nil {
-		return fmt.Errorf("failed to create netlink socket: %w", err)
+		return err
 	}
-	m.sock = sock
-
-	// Bind to the socket
-	addr := &syscall.SockaddrNetlink{
-		Family: syscall.AF_NETLINK,
-		Pid:    uint32(os.Getpid()),
-		Groups: CN_IDX_PROC,
+	m.logger.Infof("Kernel %s, using %s backend", m.capabilities.Kernel, backend.Name())
+	if err := backend.Open(); err != nil {
+		return fmt.Errorf("failed to open %s backend: %w", backend.Name(), err)
 	}
-	if err := syscall.Bind(sock, addr); err != nil {
-		syscall.Close(sock)
-		return fmt.Errorf("failed to bind to netlink socket: %w", err)
+	m.backend = backend
+	m.capabilities.Backend = backend.Name()
+	if m.ima != nil {
+		m.logger.Info("Using kernel IMA measurements for executable hashes")
 	}
 
-	// Subscribe to proc connector
-	if err := m.subscribe(); err != nil {
-		syscall.Close(sock)
-		return fmt.Errorf("failed to subscribe to proc connector: %w", err)
+	// Suspend with the cgroup freezer when it is available
+	if err := m.setupFreezer(); err != nil {
+		m.backend.Close()
+		return err
 	}
 
 	m.running = true
 	m.logger.Debug("Process monitor initialized successfully")
 
 	// Start monitoring in a separate goroutine
+	m.startExecWorkers()
 	m.wg.Add(1)
-	go m.monitor()
+	go func() {
+		defer m.wg.Done()
+		m.backend.Run()
+	}()
+
+	// Launches a killed daemon left suspended are locked again
+	m.recoverSuspended()
+
+	// Exec events only report new launches; find apps already running
+	m.scanRunning(m.config.Monitor.StartupAction)
+
+	// Charge running apps against their daily time budgets
+	if m.hasUsageLimits() {
+		m.wg.Add(1)
+		go m.trackUsage()
+	}
+
+	// Give up on prompts nobody answers
+	if m.config.Monitor.SuspendTimeout > 0 {
+		m.wg.Add(1)
+		go m.watchSuspendTimeout()
+	}
 
 	return nil
 }
@@ -291,14 +455,21 @@ func (m *ProcessMonitor) Stop() error {
 
 	m.logger.Info("Stopping process monitor")
 
-	// Signal the monitoring goroutine to stop
+	// Signal the monitoring goroutine to stop and interrupt its wait
 	close(m.stopCh)
+	m.backend.Wake()
 
 	// Wait for it to exit
 	m.wg.Wait()
 
-	// Close the socket
-	syscall.Close(m.sock)
+	// Release the backend's descriptors
+	if err := m.backend.Close(); err != nil {
+		m.logger.Debugf("Failed to close %s backend: %v", m.backend.Name(), err)
+	}
+
+	// Nothing can thaw the freezer cgroups once the monitor is gone
+	m.releaseAllFrozen()
+	m.pidfds.closeAll()
 
 	m.running = false
 	m.logger.Debug("Process monitor stopped")
@@ -306,29 +477,58 @@ func (m *ProcessMonitor) Stop() error {
 	return nil
 }
 
-// monitor handles process events
+// monitor handles process events from the proc connector
 func (m *ProcessMonitor) monitor() {
-	defer m.wg.Done()
-
 	buf := make([]byte, 4096)
+	lastRecv := time.Now()
+	fds := []unix.PollFd{
+		{Fd: int32(m.sock), Events: unix.POLLIN},
+		{Fd: int32(m.wakeFd), Events: unix.POLLIN},
+	}
 
 	for {
+		// Block until events arrive or Stop wakes us
+		if _, err := unix.Poll(fds, -1); err != nil {
+			if errors.Is(err, unix.EINTR) {
+				continue
+			}
+			m.logger.Errorf("Error polling netlink socket: %v", err)
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+
 		select {
 		case <-m.stopCh:
 			return
 		default:
-			// Read from socket
-			n, _, err := syscall.Recvfrom(m.sock, buf, 0)
+		}
+
+		if fds[0].Revents == 0 {
+			continue
+		}
+
+		// Drain everything queued; an overflow shows up as POLLERR and is
+		// reported by the read
+		for {
+			n, _, err := syscall.Recvfrom(m.sock, buf, syscall.MSG_DONTWAIT)
 			if err != nil {
-				// Check if we're shutting down
-				select {
-				case <-m.stopCh:
-					return
-				default:
-					m.logger.Errorf("Error reading from netlink: %v", err)
+				if errors.Is(err, syscall.EAGAIN) {
+					break
+				}
+
+				// Events were dropped; find what was missed
+				if errors.Is(err, syscall.ENOBUFS) {
+					m.recoverFromOverflow(lastRecv)
+					lastRecv = time.Now()
 					continue
 				}
+
+				if !errors.Is(err, syscall.EINTR) {
+					m.logger.Errorf("Error reading from netlink: %v", err)
+				}
+				break
 			}
+			lastRecv = time.Now()
 
 			// Process the message
 			if err := m.processNetlinkMessage(buf[:n]); err != nil {
@@ -338,16 +538,93 @@ func (m *ProcessMonitor) monitor() {
 	}
 }
 
-// processNetlinkMessage handles a netlink message containing process events
+// wake interrupts the event loop's poll
+func (m *ProcessMonitor) wake() {
+	var one [8]byte
+	binary.NativeEndian.PutUint64(one[:], 1)
+	if _, err := unix.Write(m.wakeFd, one[:]); err != nil {
+		m.logger.Debugf("Failed to wake event loop: %v", err)
+	}
+}
+
+// processNetlinkMessage handles a received datagram, which may carry several
+// netlink messages back to back. Each message is processed in turn, so a
+// malformed message only loses itself.
 func (m *ProcessMonitor) processNetlinkMessage(buf []byte) error {
-	// Parse netlink header
-	if len(buf) < int(unsafe.Sizeof(nlMsgHdr{})) {
-		return errors.New("message too short for netlink header")
+	payloads, err := NetlinkPayloads(buf)
+
+	errs := []error{err}
+	for _, payload := range payloads {
+		if err := m.processConnectorMessage(payload); err != nil {
+			errs = append(errs, err)
+		}
 	}
+	return errors.Join(errs...)
+}
 
-	// Skip netlink header
-	buf = buf[unsafe.Sizeof(nlMsgHdr{}):]
+// NetlinkPayloads walks the netlink messages of a datagram and returns the
+// payloads of those carrying data. No-ops and acknowledgements (error
+// messages with errno 0) are skipped; error messages and a malformed or
+// truncated tail are reported, keeping the payloads read before them.
+func NetlinkPayloads(buf []byte) ([][]byte, error) {
+	hdrLen := int(unsafe.Sizeof(nlMsgHdr{}))
+
+	var payloads [][]byte
+	var errs []error
+	for len(buf) >= hdrLen {
+		nlh := (*nlMsgHdr)(unsafe.Pointer(&buf[0]))
+		msgLen := int(nlh.Len)
+		if msgLen < hdrLen || msgLen > len(buf) {
+			errs = append(errs, fmt.Errorf("invalid netlink message length %d (%d bytes left)", msgLen, len(buf)))
+			break
+		}
+
+		switch nlh.Type {
+		case syscall.NLMSG_NOOP:
+		case syscall.NLMSG_ERROR:
+			if err := netlinkError(buf[hdrLen:msgLen]); err != nil {
+				errs = append(errs, err)
+			}
+		default:
+			payloads = append(payloads, buf[hdrLen:msgLen])
+		}
 
+		// Messages are padded to 4-byte boundaries
+		next := nlmsgAlign(msgLen)
+		if next >= len(buf) {
+			buf = nil
+			break
+		}
+		buf = buf[next:]
+	}
+
+	if len(errs) == 0 && len(buf) > 0 {
+		errs = append(errs, errors.New("message too short for netlink header"))
+	}
+	return payloads, errors.Join(errs...)
+}
+
+// netlinkError returns the error carried by an NLMSG_ERROR payload, or nil
+// when it acknowledges a request
+func netlinkError(payload []byte) error {
+	if len(payload) < 4 {
+		return errors.New("netlink error message too short")
+	}
+	errno := int32(binary.NativeEndian.Uint32(payload))
+	if errno == 0 {
+		return nil
+	}
+	return fmt.Errorf("netlink error message received: %w", syscall.Errno(-errno))
+}
+
+// nlmsgAlign rounds a netlink message length up to the message alignment
+func nlmsgAlign(n int) int {
+	return (n + syscall.NLMSG_ALIGNTO - 1) &^ (syscall.NLMSG_ALIGNTO - 1)
+}
+
+// processConnectorMessage handles the payload of one netlink message, a
+// connector message that may contain a process event
+func (m *ProcessMonitor) processConnectorMessage(buf []byte) error {
 	// Parse connector header
 	if len(buf) < int(unsafe.Sizeof(cnMsgHdr{})) {
 		return errors.New("message too short for connector header")
@@ -377,6 +654,18 @@ func (m *ProcessMonitor) processNetlinkMessage(buf []byte) error {
 
 	// Handle based on event type
 	switch evtHdr.What {
+	case PROC_EVENT_FORK:
+		if len(buf) < int(unsafe.Sizeof(forkProcEvent{})) {
+			return errors.New("message too short for fork event")
+		}
+
+		forkEvt := (*forkProcEvent)(unsafe.Pointer(&buf[0]))
+
+		// New threads share their process's state already
+		if forkEvt.ChildPid == forkEvt.ChildTgid {
+			m.handleForkEvent(int(forkEvt.ParentTgid), int(forkEvt.ChildPid))
+		}
+
 	case PROC_EVENT_EXEC:
 		if len(buf) < int(unsafe.Sizeof(execProcEvent{})) {
 			return errors.New("message too short for exec event")
@@ -386,78 +675,64 @@ func (m *ProcessMonitor) processNetlinkMessage(buf []byte) error {
 		execEvt := (*execProcEvent)(unsafe.Pointer(&buf[0]))
 
 		// Handle the exec event
-		go m.handleExecEvent(int(execEvt.ProcessPid))
-	}
+		m.enqueueExec(int(execEvt.ProcessPid))
 
-	return nil
-}
-
-// isBlockedApp checks if the given executable path is in the list of protected apps
-func (m *ProcessMonitor) isBlockedApp(execPath string, pid int) (bool, string) {
-	// Get absolute path
-	absPath, err := filepath.Abs(execPath)
-	if err != nil {
-		m.logger.Warnf("Failed to get absolute path for %s: %v", execPath, err)
-		return false, ""
-	}
+	case PROC_EVENT_UID, PROC_EVENT_GID:
+		if len(buf) < int(unsafe.Sizeof(idProcEvent{})) {
+			return errors.New("message too short for credential event")
+		}
 
-	// Clean the path
-	cleanPath := filepath.Clean(absPath)
+		idEvt := (*idProcEvent)(unsafe.Pointer(&buf[0]))
+		m.handleCredentialEvent(int(idEvt.ProcessTgid))
 
-	// Get process hash for verification
-	var execHash string
-	if data, err := os.ReadFile(cleanPath); err == nil {
-		h := sha256.New()
-		h.Write(data)
-		execHash = fmt.Sprintf("%x", h.Sum(nil))
-	} else {
-		m.logger.Warnf("Failed to calculate hash for %s: %v", cleanPath, err)
-		return false, ""
-	}
+	case PROC_EVENT_EXIT:
+		if len(buf) < int(unsafe.Sizeof(exitProcEvent{})) {
+			return errors.New("message too short for exit event")
+		}
 
-	// Get parent PID for logging
-	ppid := 0
-	if parentPID, err := m.getProcessParentPID(pid); err == nil {
-		ppid = parentPID
-	}
+		exitEvt := (*exitProcEvent)(unsafe.Pointer(&buf[0]))
 
-	// Check if this executable is protected
-	for _, protectedPath := range m.config.Monitor.ProtectedApps {
-		// Get absolute path for protected app
-		protectedAbs, err := filepath.Abs(protectedPath)
-		if err != nil {
-			m.logger.Warnf("Failed to get absolute path for protected app %s: %v", protectedPath, err)
-			continue
+		// Only whole-process exits matter, not individual threads
+		if exitEvt.ProcessPid == exitEvt.ProcessTgid {
+			m.handleExitEvent(int(exitEvt.ProcessPid))
 		}
+	}
 
-		// Clean the protected path
-		protectedClean := filepath.Clean(protectedAbs)
+	return nil
+}
 
-		// Check if paths match
-		if cleanPath == protectedClean {
-			m.logger.Debugf("Found protected app %s (PID: %d, PPID: %d, Hash: %s)",
-				cleanPath, pid, ppid, execHash)
-			return true, cleanPath
+// getFileHash returns the SHA-256 hash of a process's executable, reading
+// it only when the file changed since it was last hashed. Hashing for a
+// suspended process goes ahead of other waiting launches.
+func (m *ProcessMonitor) getFileHash(filePath string, pid int) (string, error) {
+	// The kernel measured the binary when it was executed. Paths through
+	// /proc name another namespace's files, which IMA records differently.
+	if !strings.HasPrefix(filePath, "/proc/") {
+		if digest, ok := m.ima.lookup(filePath); ok {
+			return digest, nil
 		}
 	}
 
-	return false, ""
+	priority := HashPriorityNormal
+	if state, err := m.getProcessState(pid); err == nil {
+		priority = hashPriority(state)
+	}
+	return m.verifier.hashCache.Hash(filePath, SHA256, priority)
 }
 
-// getFileHash computes the SHA-256 hash of a file
-func (m *ProcessMonitor) getFileHash(filePath string) (string, error) {
-	file, err := os.Open(filePath)
+// execHash returns the hash of a process's executable, or "" when it cannot
+// be read. Executables in another mount namespace are read through the
+// process's root, falling back to the /proc exe link.
+func (m *ProcessMonitor) execHash(execPath string, pid int) string {
+	hash, err := m.getFileHash(hostPath(pid, execPath), pid)
 	if err != nil {
-		return "", fmt.Errorf("failed to open file: %w", err)
+		hash, err = m.getFileHash(fmt.Sprintf("/proc/%d/exe", pid), pid)
 	}
-	defer file.Close()
-
-	hasher := sha256.New()
-	if _, err := io.Copy(hasher, file); err != nil {
-		return "", fmt.Errorf("failed to read file: %w", err)
+	if err != nil {
+		m.logger.Warnf("Failed to calculate hash for %s: %v", execPath, err)
+		return ""
 	}
-
-	return hex.EncodeToString(hasher.Sum(nil)), nil
+	return hash
 }
 
 // getProcessParentPID returns the parent PID of a process
@@ -497,8 +772,9 @@ func (m *ProcessMonitor) verifyProcess(pid int, expectedPath string) error {
 		}
 	}
 
-	// Verify process exists and matches expected path
-	if procInfo.Command != expectedPath {
+	// Verify process exists and matches expected path (either the executable
+	// or, for interpreters, the script being run)
+	if procInfo.Command != expectedPath && procInfo.Script != expectedPath {
 		return &ProcessVerificationError{
 			Reason: "path mismatch",
 			PID:    pid,
@@ -506,8 +782,11 @@ func (m *ProcessMonitor) verifyProcess(pid int, expectedPath string) error {
 		}
 	}
 
+	// Compare against the tracked entry, read once under the lock
+	info, tracked := m.GetProcess(pid)
+
 	// Verify process start time matches (if we have it)
-	if info, exists := m.monitoredProcesses[pid]; exists && info.StartTime > 0 {
+	if tracked && info.StartTime > 0 {
 		if info.StartTime != procInfo.StartTime {
 			return &ProcessVerificationError{
 				Reason: "start time mismatch",
@@ -517,17 +796,11 @@ func (m *ProcessMonitor) verifyProcess(pid int, expectedPath string) error {
 		}
 	}
 
-	// Verify executable hash
-	currentHash, err := m.getFileHash(procInfo.Command)
-	if err != nil {
-		return &ProcessVerificationError{
-			Reason: "failed to get file hash",
-			PID:    pid,
-			Detail: err.Error(),
-		}
-	}
+	// Verify executable hash (computed by getProcessInfo, which also
+	// handles executables in another mount namespace)
+	currentHash := procInfo.ExecHash
 
-	if info, exists := m.monitoredProcesses[pid]; exists && info.ExecHash != "" {
+	if tracked && info.ExecHash != "" {
 		if info.ExecHash != currentHash {
 			return &ProcessVerificationError{
 				Reason: "executable hash mismatch",
@@ -547,7 +820,7 @@ func (m *ProcessMonitor) verifyProcess(pid int, expectedPath string) error {
 		}
 	}
 
-	if info, exists := m.monitoredProcesses[pid]; exists && info.CmdLine != "" {
+	if tracked && info.CmdLine != "" {
 		if info.CmdLine != cmdLine {
 			return &ProcessVerificationError{
 				Reason: "command line mismatch",
@@ -586,8 +859,13 @@ func (m *ProcessMonitor) getProcessInfo(pid int) (*ProcessInfo, error) {
 		return nil, fmt.Errorf("failed to get parent PID: %w", err)
 	}
 
-	// Get file hash
-	hash, err := m.getFileHash(execPath)
+	// Get file hash. Executables in another mount namespace (containers,
+	// Flatpak, snap) are read through the process's root, falling back to
+	// the /proc exe link.
+	hash, err := m.getFileHash(hostPath(pid, execPath), pid)
+	if err != nil {
+		hash, err = m.getFileHash(fmt.Sprintf("/proc/%d/exe", pid), pid)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get file hash: %w", err)
 	}
@@ -598,7 +876,7 @@ func (m *ProcessMonitor) getProcessInfo(pid int) (*ProcessInfo, error) {
 		return nil, fmt.Errorf("failed to get process state: %w", err)
 	}
 
-	return &ProcessInfo{
+	info := &ProcessInfo{
 		PID:       pid,
 		Command:   execPath,
 		ExecHash:  hash,
@@ -606,7 +884,17 @@ func (m *ProcessMonitor) getProcessInfo(pid int) (*ProcessInfo, error) {
 		StartTime: startTime,
 		CmdLine:   cmdLine,
 		State:     state,
-	}, nil
+		Script:    resolveScriptPath(pid, execPath),
+		Container: detectContainer(pid, execPath),
+	}
+
+	// Attribute the launch to its login session so prompts reach the right
+	// seat on multi-seat systems
+	if s, err := session.Resolve(pid); err == nil {
+		info.Session, info.Seat = s.ID, s.Seat
+	}
+
+	return info, nil
 }
 
 // getProcessStartTime retrieves the start time of a process
@@ -674,10 +962,12 @@ func (m *ProcessMonitor) getProcessState(pid int) (string, error) {
 
 	// State is the 3rd field
 	switch fields[2] {
-	case "R":
+	case "R", "S", "D":
+		// Frozen processes sleep in the kernel, waking only to take signals
+		if m.freezer != nil && m.freezer.frozen(pid) {
+			return ProcessStateSuspended, nil
+		}
 		return ProcessStateRunning, nil
-	case "S", "D":
-		return ProcessStateRunning, nil // Also count sleeping as running
 	case "T":
 		return ProcessStateSuspended, nil
 	case "Z", "X":
@@ -687,17 +977,29 @@ func (m *ProcessMonitor) getProcessState(pid int) (string, error) {
 	}
 }
 
-// handleExecEvent handles an exec event
-func (m *ProcessMonitor) handleExecEvent(pid int) error {
-	m.handledMu.Lock()
-	defer m.handledMu.Unlock()
+// GetProcessState returns the scheduler state of a process
+// (running, suspended or terminated)
+func (m *ProcessMonitor) GetProcessState(pid int) (string, error) {
+	return m.getProcessState(pid)
+}
 
-	// Check if PID is already being handled
-	if _, ok := m.handledPids[pid]; ok {
+// handleExecEvent handles an exec event. The PID is claimed in
+// handledPids while it is evaluated so no other worker evaluates it too;
+// handledMu itself is only held to claim it, so workers run side by side.
+func (m *ProcessMonitor) handleExecEvent(pid int) error {
+	if !m.claimHandledPid(pid) {
 		m.logger.Debugf("PID %d is already being handled", pid)
 		return nil
 	}
 
+	// Launches that do not end up locked give the claim back
+	locked := false
+	defer func() {
+		if !locked {
+			m.releaseHandledPid(pid)
+		}
+	}()
+
 	// Get process information
 	procInfo, err := m.getProcessInfo(pid)
 	if err != nil {
@@ -706,71 +1008,131 @@ func (m *ProcessMonitor) handleExecEvent(pid int) error {
 		return nil
 	}
 
-	// Extract command name from full path
-	command := procInfo.Command
-	commandName := filepath.Base(command)
+	// Pin the process so signals below cannot reach a reused PID; the pin
+	// is kept only for launches that end up locked. An exec keeps the
+	// process, so an earlier pin (a locked tree member) still holds.
+	if !m.pidfds.pinned(pid) {
+		if err := m.capturePidfd(pid, procInfo.StartTime); err != nil {
+			m.logger.Debugf("PID %d exited before it could be evaluated", pid)
+			return nil
+		}
+		defer func() {
+			if !locked {
+				m.pidfds.forget(pid)
+			}
+		}()
+	}
 
-	// Check if this application is protected
-	isProtected := false
-	displayName := ""
-	
-	// Use the existing isBlockedApp method to check if the app is protected
-	isProtected, appPath := m.isBlockedApp(command, pid)
-	displayName = filepath.Base(appPath) // Simple display name for now
-	
-	// If configured to verify hashes and process is detected as protected
-	if m.verifyHashes && m.verifier != nil && isProtected {
-		// Get the app name from the path
-		appName := filepath.Base(appPath)
-		
-		// Check if we have any known hashes for this app in the blocked apps list
-		for _, blockedApp := range m.config.BlockedApps {
-			// If path matches and hash verification is enabled for this app
-			if blockedApp.Path == appPath && blockedApp.EnforceFileHash && blockedApp.FileHash != "" {
-				// Add the hash to the verifier
-				m.verifier.AddKnownHash(appName, appPath, blockedApp.FileHash)
-				break
+	// Record the launch when learning
+	m.learn(procInfo)
+
+	// Containers run their own software and may be left to their own policy
+	if procInfo.Container != "" && m.ignoresContainers() {
+		m.logger.Debugf("%s (PID %d) runs in a %s container, ignoring", procInfo.Command, pid, procInfo.Container)
+		return nil
+	}
+
+	// Describe the launch to the policy engine
+	launch := m.launchFacts(pid, procInfo)
+	m.reportSuspiciousLaunch(pid, procInfo, &launch)
+
+	match, decision := m.policy.Match(&launch)
+	if decision == nil {
+		if match.App != "" {
+			if match.Rule != "" {
+				m.logger.Debugf("Regex rule %s matched PID %d (%s)", match.Rule, pid, procInfo.Command)
+			} else if match.Target == procInfo.Script {
+				m.logger.Debugf("Script %s run by %s (PID %d) is protected", procInfo.Script, procInfo.Command, pid)
+			}
+
+			handled, err := m.checkProtectedLaunch(pid, procInfo, match.App)
+			if handled {
+				return err
 			}
 		}
-		
-		// Use the verifier to check if the process matches
-		err := m.verifier.VerifyProcess(pid, appName)
-		if err != nil {
-			// Log the verification error but continue with simple name matching
-			m.logger.Warnf("Error during process verification for %s (pid %d): %v, continuing with simple name matching",
-				appName, pid, err)
+
+		d := m.policy.Resolve(&launch, match, policyState{m})
+		decision = &d
+	}
+
+	if decision.UntrustedDir != "" {
+		m.reportUntrustedDir(pid, procInfo, decision.UntrustedDir)
+	}
+	if decision.Warning != "" {
+		procInfo.Warning = decision.Warning
+	}
+
+	appPath := decision.App
+	displayName := ""
+	if appPath != "" {
+		displayName = m.displayName(appPath)
+	}
+
+	switch decision.Action {
+	case policy.ActionAllow:
+		if decision.Reason == policy.ReasonNotForUser {
+			// Re-evaluate if the process changes credentials later
+			m.watchCredentials(pid, appPath, true)
+		}
+		if decision.Reason == policy.ReasonLaunchQuota {
+			m.allowFromQuota(procInfo)
+		}
+		if appPath != "" {
+			m.logger.Debugf("Allowing %s (PID %d): %s", appPath, pid, decision.Reason)
+		}
+		return nil
+	case policy.ActionLog:
+		m.logLaunch(pid, appPath, displayName)
+		return nil
+	case policy.ActionDeny:
+		if decision.Reason != policy.ReasonPolicyDeny {
+			if m.auditLaunch(pid, appPath, displayName, AuditActionDeny, decision.Reason) {
+				return nil
+			}
+			m.reportDenial(pid, appPath, displayName, decision.Reason, nil)
+			return m.KillProcessTree(pid)
 		}
 	}
 
-	if !isProtected {
+	if match.App == "" {
+		m.logger.Infof("Locking %s (PID %d): %s", appPath, pid, decision.Reason)
+	}
+
+	// Audit-only apps are reported instead of suspended
+	auditAction := AuditActionLock
+	if decision.Action == policy.ActionDeny {
+		auditAction = AuditActionDeny
+	}
+	if m.auditLaunch(pid, appPath, displayName, auditAction, decision.Reason) {
 		return nil
 	}
 
-	// Add PID to handled map to prevent duplicate handling
-	m.handledPids[pid] = commandName
+	// Keep the claim, now naming the app, unless the process exited while
+	// it was evaluated
+	if !m.keepHandledPid(pid, appPath) {
+		m.logger.Debugf("PID %d exited before it could be locked", pid)
+		return nil
+	}
+	locked = true
+
+	// Re-evaluate per-user rules if the process changes credentials later
+	m.watchCredentials(pid, appPath, false)
+
+	// Capture the loader environment while it reflects the exec
+	m.captureEnvironment(pid, appPath)
+
+	// Show the app by its desktop name and icon in events and dialogs
+	m.describeApp(procInfo, appPath)
 
 	// Mark the process as being monitored
 	m.monitoredMu.Lock()
 	m.monitoredProcesses[pid] = *procInfo
 	m.monitoredMu.Unlock()
 
-	// Add to monitored processes for tracking
-	if m.daemonMode {
-		// In daemon mode, notify the event handler
-		m.eventHandlerMu.RLock()
-		handler := m.eventHandler
-		m.eventHandlerMu.RUnlock()
-
-		if handler != nil {
-			m.logger.Debugf("Notifying event handler about PID %d (%s)", pid, commandName)
-			handler(pid, command, displayName)
-		} else {
-			m.logger.Warnf("No event handler registered, can't handle PID %d (%s)", pid, commandName)
-		}
-	} else {
-		// In direct mode, handle the process directly
-		go m.handleBlockedApp(pid, appPath)
-	}
+	// Suspend the process and route it to the event handler (daemon mode)
+	// or the authentication dialog (direct mode)
+	m.logger.Debugf("Handling protected launch of %s (PID %d)", displayName, pid)
+	go m.handleBlockedApp(pid, appPath)
 
 	return nil
 }
@@ -785,17 +1147,30 @@ func (m *ProcessMonitor) getProcessExePath(pid int) (string, error) {
 	return exePath, nil
 }
 
-// updateMonitoredProcessEnhanced adds or updates a process in the monitored processes map with enhanced info
+// updateMonitoredProcessEnhanced adds or updates a process in the monitored
+// processes map with enhanced info, keeping the details captured at exec time
 func (m *ProcessMonitor) updateMonitoredProcessEnhanced(pid int, command string, allowed bool, execHash string, parentPID int) {
 	m.monitoredMu.Lock()
 	defer m.monitoredMu.Unlock()
 
+	previous := m.monitoredProcesses[pid]
 	m.monitoredProcesses[pid] = ProcessInfo{
 		PID:       pid,
 		Command:   command,
 		Allowed:   allowed,
 		ExecHash:  execHash,
 		ParentPID: parentPID,
+		StartTime: previous.StartTime,
+		CmdLine:   previous.CmdLine,
+		Script:    previous.Script,
+		Warning:   previous.Warning,
+		Container: previous.Container,
+		Session:   previous.Session,
+		Seat:      previous.Seat,
+		AppName:   previous.AppName,
+		Icon:      previous.Icon,
+		PinHash:   previous.PinHash,
+		NewBinary: previous.NewBinary,
 	}
 }
 
@@ -804,33 +1179,35 @@ func (m *ProcessMonitor) removeMonitoredProcess(pid int) {
 	m.monitoredMu.Lock()
 	defer m.monitoredMu.Unlock()
 	delete(m.monitoredProcesses, pid)
+
+	m.clearEnvironment(pid)
 }
 
-// handleBlockedApp processes a protected application execution
+// handleBlockedApp processes a protected application execution. The PID has
+// already been claimed in handledPids by handleExecEvent.
 func (m *ProcessMonitor) handleBlockedApp(pid int, execPath string) {
-	// Check if we're already handling this PID
-	m.handledMu.Lock()
-	if _, exists := m.handledPids[pid]; exists {
-		m.handledMu.Unlock()
-		m.logger.Debugf("Already handling PID %d, skipping", pid)
-		return
+	// In direct mode authentication completes here, so release the PID when
+	// done; in daemon mode ResumeProcess still needs the entry, as does a
+	// launch waiting on another launch's prompt
+	joined := false
+	if !m.daemonMode {
+		defer func() {
+			if !joined {
+				m.releaseHandledPid(pid)
+			}
+		}()
 	}
 
-	// Mark as being handled
-	m.handledPids[pid] = execPath
-	m.handledMu.Unlock()
-
-	// Make sure we clean up when done
-	defer func() {
-		m.handledMu.Lock()
-		delete(m.handledPids, pid)
-		m.handledMu.Unlock()
-	}()
+	// Apps whose policy is deny are terminated without a dialog
+	if m.policy.PolicyAction(execPath) == config.RuleActionDeny {
+		m.denySilently(pid, execPath)
+		return
+	}
 
 	// Verify process integrity
 	if err := m.verifyProcess(pid, execPath); err != nil {
 		m.logger.Warnf("Process verification failed: %v", err)
-		if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
+		if err := m.signalProcess(pid, syscall.SIGTERM); err != nil {
 			m.logger.Errorf("Failed to terminate unverified process %d: %v", pid, err)
 		}
 		return
@@ -840,7 +1217,7 @@ func (m *ProcessMonitor) handleBlockedApp(pid int, execPath string) {
 	procInfo, err := m.getProcessInfo(pid)
 	if err != nil {
 		m.logger.Warnf("Failed to get process info: %v", err)
-		if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
+		if err := m.signalProcess(pid, syscall.SIGTERM); err != nil {
 			m.logger.Errorf("Failed to terminate process %d: %v", pid, err)
 		}
 		return
@@ -848,17 +1225,28 @@ func (m *ProcessMonitor) handleBlockedApp(pid int, execPath string) {
 
 	// Stop the process
 	m.logger.Infof("Suspending process %d (%s, parent PID: %d)", pid, execPath, procInfo.ParentPID)
-	if err := syscall.Kill(pid, syscall.SIGSTOP); err != nil {
+	if err := m.suspendProcess(pid); err != nil {
 		m.logger.Errorf("Failed to stop process %d: %v", pid, err)
 		return
 	}
+	m.snapshotLaunch(pid, procInfo)
+
+	// Children forked before the suspension are locked with the parent
+	m.lockDescendants(pid)
+	m.markPending(pid, execPath)
 
 	// Update process state
 	procInfo.State = ProcessStateSuspended
 	m.updateMonitoredProcessEnhanced(pid, execPath, false, procInfo.ExecHash, procInfo.ParentPID)
 
 	// Get display name
-	displayName := filepath.Base(execPath)
+	displayName := m.displayName(execPath)
+
+	// Launches made while a prompt is open are answered with it
+	if joined = m.joinPrompt(pid, execPath); joined {
+		m.logger.Infof("Authentication for %s already pending, PID %d waits for it", displayName, pid)
+		return
+	}
 
 	// Handle daemon mode
 	if m.daemonMode {
@@ -870,7 +1258,7 @@ func (m *ProcessMonitor) handleBlockedApp(pid int, execPath string) {
 			go handler(pid, execPath, displayName)
 		} else {
 			m.logger.Error("No event handler registered in daemon mode")
-			if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
+			if err := m.signalProcess(pid, syscall.SIGTERM); err != nil {
 				m.logger.Errorf("Failed to terminate process %d: %v", pid, err)
 			}
 		}
@@ -878,85 +1266,206 @@ func (m *ProcessMonitor) handleBlockedApp(pid int, execPath string) {
 	}
 
 	// Handle authentication in normal mode
-	if err := m.handleAuthentication(pid, execPath, displayName); err != nil {
+	err = m.handleAuthentication(pid, execPath, displayName)
+	if err != nil {
 		m.logger.Errorf("Authentication failed: %v", err)
-		if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
+		if err := m.KillProcessTree(pid); err != nil {
 			m.logger.Errorf("Failed to terminate process %d: %v", pid, err)
 		}
-		m.removeMonitoredProcess(pid)
 	}
+	m.settlePrompt(pid, err == nil)
 }
 
 // handleAuthentication handles the authentication process for a protected app
 func (m *ProcessMonitor) handleAuthentication(pid int, execPath, displayName string) error {
-	// Check remaining attempts
+	// Auto-deny launches while the app is locked out, without a dialog
 	remainingAttempts := 0
 	if m.authenticator != nil {
+		if locked, remaining := m.authenticator.LockoutStatus(execPath); locked {
+			m.denyLockedOut(pid, execPath, displayName, remaining)
+			return fmt.Errorf("%s is locked out (remaining: %s)", displayName, remaining.Round(time.Second))
+		}
 		remainingAttempts = m.authenticator.GetRemainingAttempts(execPath)
-		if remainingAttempts <= 0 {
-			return fmt.Errorf("no authentication attempts remaining for %s", displayName)
+	}
+
+	if m.config.Auth.FIDO2 == config.FIDO2Primary {
+		// A touch of the security key replaces the password; a recovery
+		// code stands in for a lost key
+		if err := m.verifySecurityKey(m.promptName(pid, displayName)); err != nil {
+			if !m.recoverWithCode(pid, execPath, displayName) {
+				return err
+			}
 		}
+	} else {
+		// A recognized finger replaces the password
+		recovered := false
+		if !m.verifyFingerprint(pid, m.promptName(pid, displayName)) {
+			var err error
+			if recovered, err = m.authenticatePassword(pid, execPath, displayName, remainingAttempts); err != nil {
+				return err
+			}
+		}
+
+		// Second factor, when configured, unless a recovery code was used
+		if !recovered {
+			if err := m.verifySecurityKey(m.promptName(pid, displayName)); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Final verification before resuming
+	if err := m.verifyProcess(pid, execPath); err != nil {
+		return fmt.Errorf("final process verification failed: %w", err)
+	}
+
+	// Check the environment captured at exec time
+	if err := m.checkResumeEnvironment(pid, execPath); err != nil {
+		return err
+	}
+
+	// Resume the process
+	m.logger.Infof("Authentication successful for %s, resuming process %d", displayName, pid)
+	if err := m.resumeProcess(pid); err != nil {
+		return fmt.Errorf("failed to resume process: %w", err)
 	}
 
-	// Show authentication dialog
+	// Update process status
+	procInfo, err := m.getProcessInfo(pid)
+	if err != nil {
+		return fmt.Errorf("failed to get final process info: %w", err)
+	}
+
+	procInfo.Allowed = true
+	procInfo.State = ProcessStateRunning
+	m.updateMonitoredProcessEnhanced(pid, execPath, true, procInfo.ExecHash, procInfo.ParentPID)
+
+	// Instances suspended at startup are unlocked with it
+	m.releaseStartupHeld(execPath)
+
+	return nil
+}
+
+// authenticatePassword shows the password dialog and checks the password,
+// reporting whether it was a recovery code
+func (m *ProcessMonitor) authenticatePassword(pid int, execPath, displayName string, remainingAttempts int) (bool, error) {
 	m.logger.Infof("Showing authentication dialog for %s (attempts remaining: %d)", displayName, remainingAttempts)
-	password, ok, err := m.guiManager.ShowAuthDialog(displayName)
+	password, ok, err := m.guiManager.ShowAuthDialog(m.promptName(pid, displayName), execPath)
 	if err != nil {
-		return fmt.Errorf("error showing auth dialog: %w", err)
+		return false, fmt.Errorf("error showing auth dialog: %w", err)
 	}
+	defer password.Destroy()
 
 	if !ok {
-		return fmt.Errorf("authentication cancelled by user")
+		return false, fmt.Errorf("authentication cancelled by user")
 	}
 
 	// Verify process hasn't changed during authentication
 	if err := m.verifyProcess(pid, execPath); err != nil {
-		return fmt.Errorf("process verification failed after dialog: %w", err)
+		return false, fmt.Errorf("process verification failed after dialog: %w", err)
 	}
 
 	// Authenticate
 	m.logger.Debug("Verifying authentication")
-	authenticated, err := m.authenticator.Authenticate([]byte(password), execPath)
+	authenticated, err := m.authenticator.Authenticate(password.Bytes(), execPath)
 	if err != nil {
-		return fmt.Errorf("authentication error: %w", err)
+		return false, fmt.Errorf("authentication error: %w", err)
 	}
 
 	if !authenticated {
 		remainingAttempts = m.authenticator.GetRemainingAttempts(execPath)
-		return fmt.Errorf("authentication failed (attempts remaining: %d)", remainingAttempts)
+		return false, fmt.Errorf("authentication failed (attempts remaining: %d)", remainingAttempts)
 	}
+	return auth.IsRecoveryCode(password.Bytes()), nil
+}
 
-	// Final verification before resuming
-	if err := m.verifyProcess(pid, execPath); err != nil {
-		return fmt.Errorf("final process verification failed: %w", err)
+// recoverWithCode asks for a recovery code in place of a security key that
+// replaces the password but failed, and reports whether a valid one was
+// entered
+func (m *ProcessMonitor) recoverWithCode(pid int, execPath, displayName string) bool {
+	if m.authenticator == nil {
+		return false
+	}
+	code, ok, err := m.guiManager.ShowAuthDialog(m.promptName(pid, displayName)+" (recovery code)", execPath)
+	if err != nil || !ok {
+		return false
 	}
+	defer code.Destroy()
 
-	// Resume the process
-	m.logger.Infof("Authentication successful for %s, resuming process %d", displayName, pid)
-	if err := syscall.Kill(pid, syscall.SIGCONT); err != nil {
-		return fmt.Errorf("failed to resume process: %w", err)
+	if !auth.IsRecoveryCode(code.Bytes()) || m.verifyProcess(pid, execPath) != nil {
+		return false
 	}
+	authenticated, err := m.authenticator.Authenticate(code.Bytes(), execPath)
+	return err == nil && authenticated
+}
 
-	// Update process status
-	procInfo, err := m.getProcessInfo(pid)
-	if err != nil {
-		return fmt.Errorf("failed to get final process info: %w", err)
+// denyLockedOut reports a launch refused because the app is locked out
+func (m *ProcessMonitor) denyLockedOut(pid int, execPath, displayName string, remaining time.Duration) {
+	reason := "locked after too many failed attempts"
+	if remaining > 0 {
+		reason = fmt.Sprintf("%s, try again in %s", reason, remaining.Round(time.Second))
 	}
 
-	procInfo.Allowed = true
-	procInfo.State = ProcessStateRunning
-	m.updateMonitoredProcessEnhanced(pid, execPath, true, procInfo.ExecHash, procInfo.ParentPID)
+	m.reportDenial(pid, execPath, displayName, reason, map[string]interface{}{
+		"lockout_remaining_seconds": int(remaining.Seconds()),
+	})
+}
 
-	return nil
+// LockoutMessage describes a lockout denial for notifications
+func LockoutMessage(displayName string, remaining time.Duration) string {
+	if remaining <= 0 {
+		return i18n.T("%s is locked after too many failed attempts.", displayName)
+	}
+	return i18n.T("%s is locked after too many failed attempts. Try again in %s.",
+		displayName, remaining.Round(time.Second))
 }
 
 // ResumeProcess resumes a suspended process (for daemon mode)
 func (m *ProcessMonitor) ResumeProcess(pid int) error {
+	// Launches that joined this prompt were unlocked with it
+	defer m.settlePrompt(pid, true)
+
+	// Refuse to resume processes started with a dangerous environment
+	m.monitoredMu.RLock()
+	info, monitored := m.monitoredProcesses[pid]
+	m.monitoredMu.RUnlock()
+
+	if monitored {
+		if err := m.checkResumeEnvironment(pid, info.Target()); err != nil {
+			if termErr := m.KillProcessTree(pid); termErr != nil {
+				m.logger.Errorf("Failed to terminate process %d: %v", pid, termErr)
+			}
+			return err
+		}
+
+		// Apps that require a signature are only resumed when it verifies
+		if err := m.checkResumeSignature(pid, info); err != nil {
+			if termErr := m.KillProcessTree(pid); termErr != nil {
+				m.logger.Errorf("Failed to terminate process %d: %v", pid, termErr)
+			}
+			return err
+		}
+
+		// Nothing may have been swapped in while the dialog was open
+		if err := m.reverifyLaunch(pid, info.Target()); err != nil {
+			if termErr := m.KillProcessTree(pid); termErr != nil {
+				m.logger.Errorf("Failed to terminate process %d: %v", pid, termErr)
+			}
+			return err
+		}
+	}
+	m.forgetSnapshot(pid)
+
 	m.logger.Infof("Resuming process %d", pid)
-	if err := syscall.Kill(pid, syscall.SIGCONT); err != nil {
+	if err := m.resumeProcess(pid); err != nil {
 		return fmt.Errorf("failed to resume process %d: %w", pid, err)
 	}
 
+	// Resuming approves the version that was launched
+	if monitored {
+		m.approveHashPin(info)
+	}
+
 	// Update status in our tracked processes
 	m.handledMu.Lock()
 	execPath, exists := m.handledPids[pid]
@@ -968,7 +1477,7 @@ func (m *ProcessMonitor) ResumeProcess(pid int) error {
 		parentPID := 0
 
 		// Try to get hash if available
-		if hash, err := m.getFileHash(execPath); err == nil {
+		if hash, err := m.getFileHash(execPath, pid); err == nil {
 			execHash = hash
 		}
 
@@ -978,17 +1487,38 @@ func (m *ProcessMonitor) ResumeProcess(pid int) error {
 		}
 
 		m.updateMonitoredProcessEnhanced(pid, execPath, true, execHash, parentPID)
+
+		// Instances suspended at startup are unlocked with it
+		m.releaseStartupHeld(execPath)
 	}
 
+	// Children forked while locked run again with the parent
+	m.resumeTree(pid)
+
 	return nil
 }
 
 // TerminateProcess terminates a process (for daemon mode)
 func (m *ProcessMonitor) TerminateProcess(pid int) error {
+	defer m.settlePrompt(pid, false)
+
 	m.logger.Infof("Terminating process %d", pid)
-	if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
+
+	// Escalate to SIGKILL if the process ignores SIGTERM
+	watch := m.watchExit(pid)
+	if err := m.signalProcess(pid, syscall.SIGTERM); err != nil {
+		watch.close()
 		return fmt.Errorf("failed to terminate process %d: %w", pid, err)
 	}
+	go m.escalateTermination(watch, m.terminateTimeout())
+
+	// A suspended process that handles SIGTERM only sees it once continued
+	if err := m.resumeProcess(pid); err != nil {
+		m.logger.Debugf("Failed to continue terminated process %d: %v", pid, err)
+	}
+
+	// Children of the process go with it
+	m.terminateTree(pid)
 
 	// Remove from tracked processes
 	m.removeMonitoredProcess(pid)
@@ -996,6 +1526,31 @@ func (m *ProcessMonitor) TerminateProcess(pid int) error {
 	return nil
 }
 
+// Running reports whether the monitor has been started and not stopped
+func (m *ProcessMonitor) Running() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.running
+}
+
+// IsMonitored reports whether the PID is currently tracked by the monitor
+func (m *ProcessMonitor) IsMonitored(pid int) bool {
+	m.monitoredMu.RLock()
+	defer m.monitoredMu.RUnlock()
+
+	_, exists := m.monitoredProcesses[pid]
+	return exists
+}
+
+// GetProcess returns the tracked information for a monitored process
+func (m *ProcessMonitor) GetProcess(pid int) (ProcessInfo, bool) {
+	m.monitoredMu.RLock()
+	defer m.monitoredMu.RUnlock()
+
+	info, exists := m.monitoredProcesses[pid]
+	return info, exists
+}
+
 // PollProcesses returns the current state of monitored processes
 func (m *ProcessMonitor) PollProcesses() ([]ProcessInfo, error) {
 	m.monitoredMu.RLock()
@@ -1,9 +1,27 @@
 package monitor_test
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/pem"
 	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
 	"testing"
+	"time"
 
+	"golang.org/x/sys/unix"
+
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/logging"
 	"wyrmlock/internal/monitor"
 )
 
@@ -101,4 +119,1094 @@ func TestProcessInfo(t *testing.T) {
 	if info.State != monitor.ProcessStateRunning {
 		t.Errorf("Expected state %s, got %s", monitor.ProcessStateRunning, info.State)
 	}
-} 
\ No newline at end of file
+} 
+// TestRegexRuleCompilation tests that invalid regex rules are rejected at construction
+func TestRegexRuleCompilation(t *testing.T) {
+	logger := logging.NewLogger("[test]", false)
+
+	cfg := &config.Config{}
+	cfg.Monitor.RegexRules = []config.RegexRule{
+		{Name: "python-scripts", CmdlinePattern: `^python3? .*\.py`, Action: config.RuleActionLock},
+		{Name: "browsers", PathPattern: `^/usr/bin/(firefox|chromium)$`},
+	}
+	if _, err := monitor.NewProcessMonitorDaemon(cfg, logger); err != nil {
+		t.Errorf("Expected valid regex rules to compile, got %v", err)
+	}
+
+	cfg.Monitor.RegexRules = []config.RegexRule{
+		{Name: "broken", PathPattern: `^/usr/bin/(firefox`},
+	}
+	if _, err := monitor.NewProcessMonitorDaemon(cfg, logger); err == nil {
+		t.Errorf("Expected invalid regex rule to be rejected")
+	}
+
+	cfg.Monitor.RegexRules = []config.RegexRule{
+		{Name: "empty"},
+	}
+	if _, err := monitor.NewProcessMonitorDaemon(cfg, logger); err == nil {
+		t.Errorf("Expected rule without patterns to be rejected")
+	}
+}
+
+// TestProcessInfoTarget tests that interpreted programs report their script
+func TestProcessInfoTarget(t *testing.T) {
+	info := monitor.ProcessInfo{Command: "/usr/bin/python3"}
+	if info.Target() != "/usr/bin/python3" {
+		t.Errorf("Expected target /usr/bin/python3, got %s", info.Target())
+	}
+
+	info.Script = "/home/user/bin/secret.py"
+	if info.Target() != "/home/user/bin/secret.py" {
+		t.Errorf("Expected target /home/user/bin/secret.py, got %s", info.Target())
+	}
+}
+
+// TestEventCmdLineRedaction tests that arguments are redacted for listed apps
+func TestEventCmdLineRedaction(t *testing.T) {
+	logger := logging.NewLogger("[test]", false)
+
+	cfg := &config.Config{}
+	cfg.Monitor.RedactArgs = []string{"cat"}
+	m, err := monitor.NewProcessMonitorDaemon(cfg, logger)
+	if err != nil {
+		t.Fatalf("Failed to create monitor: %v", err)
+	}
+
+	cmd := exec.Command("sleep", "5")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("Cannot start helper process: %v", err)
+	}
+	defer cmd.Process.Kill()
+	pid := cmd.Process.Pid
+
+	if got := m.EventCmdLine(pid, "/usr/bin/sleep"); got != "sleep 5" {
+		t.Errorf("Expected full command line, got %q", got)
+	}
+
+	cfg.Monitor.RedactArgs = []string{"sleep"}
+	if got := m.EventCmdLine(pid, "/usr/bin/sleep"); got != "sleep "+monitor.RedactedArgs {
+		t.Errorf("Expected redacted command line, got %q", got)
+	}
+}
+
+// TestLearnerSuggest tests rule suggestions from a learning mode recording
+func TestLearnerSuggest(t *testing.T) {
+	learner := monitor.NewLearner()
+	now := time.Now()
+
+	learner.Record("/usr/bin/steam", "", "alice", now)
+	learner.Record("/usr/lib/systemd/systemd-udevd", "", "alice", now)
+	learner.Record("/usr/sbin/cron", "", "root", now)
+	for i := 0; i < 50; i++ {
+		learner.Record("/usr/bin/ls", "ab12", "alice", now)
+	}
+
+	suggestion := learner.Suggest(20)
+	if len(suggestion.ProtectedApps) != 1 || suggestion.ProtectedApps[0] != "/usr/bin/steam" {
+		t.Errorf("Expected only steam to be suggested, got %v", suggestion.ProtectedApps)
+	}
+	if len(suggestion.Allowlist) != 4 {
+		t.Errorf("Expected every executable in the allowlist, got %v", suggestion.Allowlist)
+	}
+	if suggestion.Allowlist[len(suggestion.Allowlist)-1] != "sha256:ab12" {
+		t.Errorf("Expected hashed executables as hash entries, got %v", suggestion.Allowlist)
+	}
+}
+
+// TestCapabilitiesWarnings tests degraded mode reporting from a feature set
+func TestCapabilitiesWarnings(t *testing.T) {
+	caps := monitor.Capabilities{Features: map[string]bool{
+		monitor.FeatureProcConnector: true,
+		monitor.FeaturePidfd:         false,
+		monitor.FeatureLandlock:      false,
+	}}
+
+	warnings := caps.Warnings()
+	if len(warnings) != 2 {
+		t.Fatalf("Expected a warning per missing feature, got %v", warnings)
+	}
+	if !caps.Has(monitor.FeatureProcConnector) || caps.Has(monitor.FeaturePidfd) {
+		t.Errorf("Unexpected feature availability: %v", caps.Features)
+	}
+
+	probed := monitor.ProbeCapabilities()
+	if probed.Kernel == "" || len(probed.Features) != 5 {
+		t.Errorf("Expected every feature to be probed, got %+v", probed)
+	}
+}
+
+// TestLearnerRecordLaunch tests that launches are kept for replay
+func TestLearnerRecordLaunch(t *testing.T) {
+	learner := monitor.NewLearner()
+	learner.RecordLaunch(monitor.RecordedLaunch{
+		Time:       time.Now(),
+		Executable: "/usr/bin/python3",
+		Script:     "/home/alice/tool.py",
+		Hash:       "ab12",
+		User:       "alice",
+		UID:        1000,
+	})
+
+	if len(learner.Launches) != 1 || learner.Launches[0].UID != 1000 {
+		t.Fatalf("Expected the launch to be kept, got %+v", learner.Launches)
+	}
+	summary := learner.Summary()
+	if len(summary) != 1 || summary[0].Path != "/home/alice/tool.py" || summary[0].Hash != "" {
+		t.Errorf("Expected the script to be summarized without the interpreter hash, got %+v", summary)
+	}
+}
+
+// TestKillProcessTree tests that forked workers do not survive a denial
+func TestKillProcessTree(t *testing.T) {
+	m, err := monitor.NewProcessMonitorDaemon(&config.Config{}, logging.NewLogger("[test]", false))
+	if err != nil {
+		t.Fatalf("Failed to create monitor: %v", err)
+	}
+
+	// The shell forks a worker that would outlive it; a new session keeps
+	// the test's own process group out of reach
+	cmd := exec.Command("sh", "-c", "sleep 30 & echo $!; wait")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("Cannot start helper process: %v", err)
+	}
+	defer cmd.Process.Kill()
+
+	var worker int
+	if _, err := fmt.Fscan(out, &worker); err != nil {
+		t.Fatalf("Failed to read worker PID: %v", err)
+	}
+
+	if err := m.KillProcessTree(cmd.Process.Pid); err != nil {
+		t.Fatalf("KillProcessTree failed: %v", err)
+	}
+	cmd.Wait()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for processAlive(worker) && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if processAlive(worker) {
+		syscall.Kill(worker, syscall.SIGKILL)
+		t.Errorf("Worker %d survived", worker)
+	}
+}
+
+// processAlive reports whether pid exists and is not a zombie waiting to be
+// reaped
+func processAlive(pid int) bool {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return false
+	}
+	fields := strings.Fields(string(data[strings.LastIndexByte(string(data), ')')+1:]))
+	return len(fields) > 0 && fields[0] != "Z"
+}
+
+// TestStopInterruptsEventLoop tests that Stop does not wait for the next
+// process event
+func TestStopInterruptsEventLoop(t *testing.T) {
+	m, err := monitor.NewProcessMonitorDaemon(&config.Config{}, logging.NewLogger("[test]", false))
+	if err != nil {
+		t.Fatalf("Failed to create monitor: %v", err)
+	}
+	if err := m.Start(); err != nil {
+		t.Skipf("Cannot start monitor: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- m.Stop() }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Stop failed: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop did not return while the event loop was idle")
+	}
+}
+
+// TestEventQueueStats tests the exec queue sizing
+func TestEventQueueStats(t *testing.T) {
+	m, err := monitor.NewProcessMonitorDaemon(&config.Config{}, logging.NewLogger("[test]", false))
+	if err != nil {
+		t.Fatalf("Failed to create monitor: %v", err)
+	}
+	stats := m.EventQueueStats()
+	if stats.Workers != monitor.DefaultExecWorkers || stats.Capacity != monitor.DefaultExecQueueSize {
+		t.Errorf("Expected default sizes, got %+v", stats)
+	}
+
+	cfg := &config.Config{}
+	cfg.Monitor.ExecWorkers = 2
+	cfg.Monitor.ExecQueueSize = 16
+	m, err = monitor.NewProcessMonitorDaemon(cfg, logging.NewLogger("[test]", false))
+	if err != nil {
+		t.Fatalf("Failed to create monitor: %v", err)
+	}
+	if stats := m.EventQueueStats(); stats.Workers != 2 || stats.Capacity != 16 || stats.Depth != 0 || stats.Dropped != 0 {
+		t.Errorf("Expected configured sizes, got %+v", stats)
+	}
+}
+
+// TestProcessHashCacheInvalidation tests that a modified file is hashed again
+func TestProcessHashCacheInvalidation(t *testing.T) {
+	path := t.TempDir() + "/app"
+	if err := os.WriteFile(path, []byte("version 1"), 0755); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	cache := monitor.NewProcessHashCache(monitor.SHA256, 8, 0)
+	first, err := cache.Get(path)
+	if err != nil {
+		t.Fatalf("Failed to hash file: %v", err)
+	}
+	if again, _ := cache.Get(path); again != first {
+		t.Errorf("Expected cached hash %s, got %s", first, again)
+	}
+
+	// Same size, so only the timestamps tell the versions apart
+	if err := os.WriteFile(path, []byte("version 2"), 0755); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	second, err := cache.Get(path)
+	if err != nil {
+		t.Fatalf("Failed to hash file: %v", err)
+	}
+	if second == first {
+		t.Error("Expected the modified file to be hashed again")
+	}
+}
+
+// TestProcessHashCacheAlgorithms tests hashing with a non-default algorithm
+func TestProcessHashCacheAlgorithms(t *testing.T) {
+	path := t.TempDir() + "/app"
+	if err := os.WriteFile(path, []byte("abc"), 0755); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	cache := monitor.NewProcessHashCache(monitor.SHA256, 8, 0)
+	want := map[monitor.HashAlgorithm]string{
+		monitor.SHA256: "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+		monitor.BLAKE3: "6437b3ac38465133ffb63b75273a8db548c558465d79db03fd359c6cd5bd9d85",
+	}
+	for algorithm, expected := range want {
+		hash, err := cache.GetWith(path, algorithm)
+		if err != nil {
+			t.Fatalf("Failed to hash with %s: %v", algorithm, err)
+		}
+		if hash != expected {
+			t.Errorf("Expected %s hash %s, got %s", algorithm, expected, hash)
+		}
+	}
+}
+
+// TestProcessHashCacheConcurrent tests that concurrent launches of the same
+// binary get the same hash while hashing is limited to one file at a time
+func TestProcessHashCacheConcurrent(t *testing.T) {
+	path := t.TempDir() + "/app"
+	if err := os.WriteFile(path, []byte("abc"), 0755); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	cache := monitor.NewProcessHashCache(monitor.SHA256, 8, 0)
+	cache.SetConcurrency(1)
+
+	hashes := make(chan string, 16)
+	for i := 0; i < cap(hashes); i++ {
+		priority := monitor.HashPriorityNormal
+		if i%2 == 0 {
+			priority = monitor.HashPrioritySuspended
+		}
+		go func() {
+			hash, err := cache.Hash(path, monitor.SHA256, priority)
+			if err != nil {
+				t.Errorf("Failed to hash file: %v", err)
+			}
+			hashes <- hash
+		}()
+	}
+
+	for i := 0; i < cap(hashes); i++ {
+		if hash := <-hashes; hash != "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" {
+			t.Errorf("Unexpected hash %s", hash)
+		}
+	}
+}
+
+// TestStartupScanSuspend tests that a protected app running before the
+// monitor started is found and suspended
+func TestStartupScanSuspend(t *testing.T) {
+	// A private copy so no other sleep on the system is protected
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skipf("sleep not available: %v", err)
+	}
+	data, err := os.ReadFile(sleep)
+	if err != nil {
+		t.Skipf("Cannot read sleep: %v", err)
+	}
+	app := t.TempDir() + "/sleeper"
+	if err := os.WriteFile(app, data, 0755); err != nil {
+		t.Fatalf("Failed to copy sleep: %v", err)
+	}
+
+	cmd := exec.Command(app, "30")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("Cannot start helper process: %v", err)
+	}
+	defer cmd.Wait()
+	defer cmd.Process.Kill()
+
+	cfg := &config.Config{}
+	cfg.Monitor.ProtectedApps = []string{app}
+	cfg.Monitor.StartupAction = config.StartupActionSuspend
+	m, err := monitor.NewProcessMonitorDaemon(cfg, logging.NewLogger("[test]", false))
+	if err != nil {
+		t.Fatalf("Failed to create monitor: %v", err)
+	}
+	if err := m.Start(); err != nil {
+		t.Skipf("Cannot start monitor: %v", err)
+	}
+	defer m.Stop()
+
+	info, ok := m.GetProcess(cmd.Process.Pid)
+	if !ok || info.Allowed {
+		t.Fatalf("Expected the running app to be tracked as locked, got %+v (tracked: %v)", info, ok)
+	}
+	// SIGSTOP takes effect asynchronously
+	var state string
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if state, _ = m.GetProcessState(cmd.Process.Pid); state == monitor.ProcessStateSuspended {
+			return
+		}
+	}
+	t.Errorf("Expected the running app to be suspended, got %s", state)
+}
+
+func TestPollBackendDetectsLaunch(t *testing.T) {
+	// A private copy so no other sleep on the system is protected
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skipf("sleep not available: %v", err)
+	}
+	data, err := os.ReadFile(sleep)
+	if err != nil {
+		t.Skipf("Cannot read sleep: %v", err)
+	}
+	app := t.TempDir() + "/sleeper"
+	if err := os.WriteFile(app, data, 0755); err != nil {
+		t.Fatalf("Failed to copy sleep: %v", err)
+	}
+
+	cfg := &config.Config{}
+	cfg.Monitor.ProtectedApps = []string{app}
+	cfg.Monitor.Backend = config.BackendPoll
+	cfg.Monitor.PollInterval = 20
+	m, err := monitor.NewProcessMonitorDaemon(cfg, logging.NewLogger("[test]", false))
+	if err != nil {
+		t.Fatalf("Failed to create monitor: %v", err)
+	}
+	m.RegisterEventHandler(func(pid int, execPath string, displayName string) {})
+	if err := m.Start(); err != nil {
+		t.Skipf("Cannot start monitor: %v", err)
+	}
+	defer m.Stop()
+
+	if backend := m.Capabilities().Backend; backend != monitor.BackendPolling {
+		t.Fatalf("Expected the poll backend, got %s", backend)
+	}
+
+	cmd := exec.Command(app, "30")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("Cannot start helper process: %v", err)
+	}
+	defer cmd.Wait()
+	defer cmd.Process.Kill()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if info, ok := m.GetProcess(cmd.Process.Pid); ok && !info.Allowed {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Expected the polled launch to be tracked as locked")
+}
+
+func TestReplacedExecutable(t *testing.T) {
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skipf("sleep not available: %v", err)
+	}
+	data, err := os.ReadFile(sleep)
+	if err != nil {
+		t.Skipf("Cannot read sleep: %v", err)
+	}
+
+	start := func(path string) *exec.Cmd {
+		if err := os.WriteFile(path, data, 0755); err != nil {
+			t.Fatalf("Failed to copy sleep: %v", err)
+		}
+		cmd := exec.Command(path, "30")
+		if err := cmd.Start(); err != nil {
+			t.Skipf("Cannot start helper process: %v", err)
+		}
+		t.Cleanup(func() {
+			cmd.Process.Kill()
+			cmd.Wait()
+		})
+		return cmd
+	}
+
+	dir := t.TempDir()
+	intact := start(dir + "/intact")
+	if reason := monitor.ReplacedExecutable(intact.Process.Pid); reason != "" {
+		t.Errorf("Expected an intact executable, got %q", reason)
+	}
+
+	deleted := start(dir + "/deleted")
+	os.Remove(dir + "/deleted")
+	if reason := monitor.ReplacedExecutable(deleted.Process.Pid); reason == "" {
+		t.Error("Expected a deleted executable to be reported")
+	}
+
+	replaced := start(dir + "/replaced")
+	if err := os.WriteFile(dir+"/new", data, 0755); err != nil {
+		t.Fatalf("Failed to write replacement: %v", err)
+	}
+	if err := os.Rename(dir+"/new", dir+"/replaced"); err != nil {
+		t.Fatalf("Failed to replace executable: %v", err)
+	}
+	if reason := monitor.ReplacedExecutable(replaced.Process.Pid); reason == "" {
+		t.Error("Expected a replaced executable to be reported")
+	}
+}
+
+func TestAnonymousExecutable(t *testing.T) {
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skipf("sleep not available: %v", err)
+	}
+	data, err := os.ReadFile(sleep)
+	if err != nil {
+		t.Skipf("Cannot read sleep: %v", err)
+	}
+
+	fd, err := unix.MemfdCreate("sleeper", 0)
+	if err != nil {
+		t.Skipf("memfd_create not available: %v", err)
+	}
+	memfd := os.NewFile(uintptr(fd), "sleeper")
+	defer memfd.Close()
+	if _, err := memfd.Write(data); err != nil {
+		t.Fatalf("Failed to fill memfd: %v", err)
+	}
+
+	// The child executes the memfd through its inherited descriptor
+	cmd := exec.Command("/dev/fd/3", "30")
+	cmd.ExtraFiles = []*os.File{memfd}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("Cannot execute memfd: %v", err)
+	}
+	defer cmd.Wait()
+	defer cmd.Process.Kill()
+
+	if kind := monitor.AnonymousExecutable(cmd.Process.Pid); kind != monitor.AnonymousMemfd {
+		t.Errorf("Expected a memfd execution, got %q", kind)
+	}
+	if kind := monitor.AnonymousExecutable(os.Getpid()); kind != "" {
+		t.Errorf("Expected the test binary to be on disk, got %q", kind)
+	}
+}
+
+func TestRemovableMount(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skipf("Cannot locate test binary: %v", err)
+	}
+	if mount, ok := monitor.RemovableMount(exe); ok {
+		t.Skipf("Test binary is on removable media at %s", mount)
+	}
+	if _, ok := monitor.RemovableMount("/nonexistent/binary"); ok {
+		t.Error("Expected a missing file not to be on removable media")
+	}
+}
+
+func TestUntrustedDirPrompt(t *testing.T) {
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skipf("sleep not available: %v", err)
+	}
+	data, err := os.ReadFile(sleep)
+	if err != nil {
+		t.Skipf("Cannot read sleep: %v", err)
+	}
+	dir := t.TempDir()
+	app := dir + "/dropped"
+	if err := os.WriteFile(app, data, 0755); err != nil {
+		t.Fatalf("Failed to copy sleep: %v", err)
+	}
+
+	// The app is not protected; only its directory is untrusted
+	cfg := &config.Config{}
+	cfg.Monitor.Backend = config.BackendPoll
+	cfg.Monitor.PollInterval = 20
+	cfg.Monitor.UntrustedDirAction = config.UntrustedDirActionPrompt
+	cfg.Monitor.UntrustedDirs = []string{dir}
+	m, err := monitor.NewProcessMonitorDaemon(cfg, logging.NewLogger("[test]", false))
+	if err != nil {
+		t.Fatalf("Failed to create monitor: %v", err)
+	}
+	prompted := make(chan int, 1)
+	m.RegisterEventHandler(func(pid int, execPath string, displayName string) {
+		prompted <- pid
+	})
+	if err := m.Start(); err != nil {
+		t.Skipf("Cannot start monitor: %v", err)
+	}
+	defer m.Stop()
+
+	cmd := exec.Command(app, "30")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("Cannot start helper process: %v", err)
+	}
+	defer cmd.Wait()
+	defer cmd.Process.Kill()
+
+	select {
+	case pid := <-prompted:
+		if pid != cmd.Process.Pid {
+			t.Fatalf("Expected a prompt for PID %d, got %d", cmd.Process.Pid, pid)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a launch from an untrusted directory to prompt")
+	}
+
+	info, _ := m.GetProcess(cmd.Process.Pid)
+	if !strings.Contains(info.PromptName("dropped"), dir) {
+		t.Errorf("Expected the prompt to warn about %s, got %q", dir, info.PromptName("dropped"))
+	}
+}
+
+func TestFreezerSuspend(t *testing.T) {
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skipf("sleep not available: %v", err)
+	}
+	data, err := os.ReadFile(sleep)
+	if err != nil {
+		t.Skipf("Cannot read sleep: %v", err)
+	}
+	app := t.TempDir() + "/sleeper"
+	if err := os.WriteFile(app, data, 0755); err != nil {
+		t.Fatalf("Failed to copy sleep: %v", err)
+	}
+
+	cmd := exec.Command(app, "30")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("Cannot start helper process: %v", err)
+	}
+	defer cmd.Wait()
+	defer cmd.Process.Kill()
+	pid := cmd.Process.Pid
+
+	cfg := &config.Config{}
+	cfg.Monitor.ProtectedApps = []string{app}
+	cfg.Monitor.StartupAction = config.StartupActionSuspend
+	cfg.Monitor.SuspendMethod = config.SuspendMethodFreezer
+	m, err := monitor.NewProcessMonitorDaemon(cfg, logging.NewLogger("[test]", false))
+	if err != nil {
+		t.Fatalf("Failed to create monitor: %v", err)
+	}
+	if err := m.Start(); err != nil {
+		t.Skipf("Cannot start monitor with the cgroup freezer: %v", err)
+	}
+
+	cgroup, _ := os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if !strings.Contains(string(cgroup), fmt.Sprintf("wyrmlock-%d", pid)) {
+		m.Stop()
+		t.Fatalf("Expected the app in a freezer cgroup, got %q", cgroup)
+	}
+
+	// The freezer cannot be undone with a signal
+	syscall.Kill(pid, syscall.SIGCONT)
+	if state, _ := m.GetProcessState(pid); state != monitor.ProcessStateSuspended {
+		t.Errorf("Expected the app to stay frozen after SIGCONT, got %s", state)
+	}
+
+	// Stopping hands the process back to SIGSTOP
+	m.Stop()
+	cgroup, _ = os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if strings.Contains(string(cgroup), "wyrmlock-") {
+		t.Errorf("Expected the freezer cgroup to be released, got %q", cgroup)
+	}
+
+	// SIGSTOP is taken once the process is thawed
+	var state string
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if state, _ = m.GetProcessState(pid); state == monitor.ProcessStateSuspended {
+			return
+		}
+	}
+	t.Errorf("Expected the app to stay stopped after the monitor stopped, got %s", state)
+}
+
+func TestTerminateExitedLockedProcess(t *testing.T) {
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skipf("sleep not available: %v", err)
+	}
+	data, err := os.ReadFile(sleep)
+	if err != nil {
+		t.Skipf("Cannot read sleep: %v", err)
+	}
+	app := t.TempDir() + "/sleeper"
+	if err := os.WriteFile(app, data, 0755); err != nil {
+		t.Fatalf("Failed to copy sleep: %v", err)
+	}
+
+	cmd := exec.Command(app, "30")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("Cannot start helper process: %v", err)
+	}
+	pid := cmd.Process.Pid
+
+	cfg := &config.Config{}
+	cfg.Monitor.ProtectedApps = []string{app}
+	cfg.Monitor.StartupAction = config.StartupActionSuspend
+	m, err := monitor.NewProcessMonitorDaemon(cfg, logging.NewLogger("[test]", false))
+	if err != nil {
+		t.Fatalf("Failed to create monitor: %v", err)
+	}
+	if err := m.Start(); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		t.Skipf("Cannot start monitor: %v", err)
+	}
+	defer m.Stop()
+
+	// The locked process dies while its dialog would still be open
+	cmd.Process.Kill()
+	cmd.Wait()
+	for deadline := time.Now().Add(time.Second); m.IsMonitored(pid) && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Its PID may now belong to another process and must not be signalled
+	if err := m.TerminateProcess(pid); !errors.Is(err, monitor.ErrProcessExited) {
+		t.Errorf("Expected terminating an exited locked process to be refused, got %v", err)
+	}
+	if err := m.ResumeProcess(pid); !errors.Is(err, monitor.ErrProcessExited) {
+		t.Errorf("Expected resuming an exited locked process to be refused, got %v", err)
+	}
+}
+
+func TestTerminateEscalatesToKill(t *testing.T) {
+	// The shell ignores SIGTERM while it waits
+	cmd := exec.Command("sh", "-c", `trap "" TERM; echo ready; while :; do sleep 0.1; done`)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("Cannot start helper process: %v", err)
+	}
+	defer cmd.Process.Kill()
+	ready := make([]byte, 6)
+	if _, err := stdout.Read(ready); err != nil {
+		t.Fatalf("Helper did not start: %v", err)
+	}
+
+	cfg := &config.Config{}
+	cfg.Monitor.TerminateTimeout = 1
+	m, err := monitor.NewProcessMonitorDaemon(cfg, logging.NewLogger("[test]", false))
+	if err != nil {
+		t.Fatalf("Failed to create monitor: %v", err)
+	}
+
+	if err := m.TerminateProcess(cmd.Process.Pid); err != nil {
+		t.Fatalf("Failed to terminate process: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		if status, ok := err.(*exec.ExitError); !ok || status.Sys().(syscall.WaitStatus).Signal() != syscall.SIGKILL {
+			t.Errorf("Expected the process to be killed, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the process to be killed after the terminate timeout")
+	}
+}
+
+func TestSuspendTimeoutTerminates(t *testing.T) {
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skipf("sleep not available: %v", err)
+	}
+	data, err := os.ReadFile(sleep)
+	if err != nil {
+		t.Skipf("Cannot read sleep: %v", err)
+	}
+	app := t.TempDir() + "/sleeper"
+	if err := os.WriteFile(app, data, 0755); err != nil {
+		t.Fatalf("Failed to copy sleep: %v", err)
+	}
+
+	cmd := exec.Command(app, "30")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("Cannot start helper process: %v", err)
+	}
+	defer cmd.Process.Kill()
+
+	// The prompt is never answered
+	cfg := &config.Config{}
+	cfg.Monitor.ProtectedApps = []string{app}
+	cfg.Monitor.StartupAction = config.StartupActionPrompt
+	cfg.Monitor.SuspendTimeout = 1
+	m, err := monitor.NewProcessMonitorDaemon(cfg, logging.NewLogger("[test]", false))
+	if err != nil {
+		t.Fatalf("Failed to create monitor: %v", err)
+	}
+	m.RegisterEventHandler(func(pid int, execPath string, displayName string) {})
+	if err := m.Start(); err != nil {
+		t.Skipf("Cannot start monitor: %v", err)
+	}
+	defer m.Stop()
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Expected the unanswered launch to be terminated")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the unanswered launch to be terminated after the suspend timeout")
+	}
+}
+
+func TestSimultaneousLaunchesSharePrompt(t *testing.T) {
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skipf("sleep not available: %v", err)
+	}
+	data, err := os.ReadFile(sleep)
+	if err != nil {
+		t.Skipf("Cannot read sleep: %v", err)
+	}
+	app := t.TempDir() + "/sleeper"
+	if err := os.WriteFile(app, data, 0755); err != nil {
+		t.Fatalf("Failed to copy sleep: %v", err)
+	}
+
+	var cmds []*exec.Cmd
+	for i := 0; i < 2; i++ {
+		cmd := exec.Command(app, "30")
+		if err := cmd.Start(); err != nil {
+			t.Skipf("Cannot start helper process: %v", err)
+		}
+		defer cmd.Wait()
+		defer cmd.Process.Kill()
+		cmds = append(cmds, cmd)
+	}
+
+	cfg := &config.Config{}
+	cfg.Monitor.ProtectedApps = []string{app}
+	cfg.Monitor.StartupAction = config.StartupActionPrompt
+	m, err := monitor.NewProcessMonitorDaemon(cfg, logging.NewLogger("[test]", false))
+	if err != nil {
+		t.Fatalf("Failed to create monitor: %v", err)
+	}
+	prompts := make(chan int, 2)
+	m.RegisterEventHandler(func(pid int, execPath string, displayName string) { prompts <- pid })
+	if err := m.Start(); err != nil {
+		t.Skipf("Cannot start monitor: %v", err)
+	}
+	defer m.Stop()
+
+	var leader int
+	select {
+	case leader = <-prompts:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a prompt for the launches")
+	}
+	select {
+	case pid := <-prompts:
+		t.Fatalf("Expected a single prompt, got a second one for PID %d", pid)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	if err := m.ResumeProcess(leader); err != nil {
+		t.Fatalf("Failed to resume process %d: %v", leader, err)
+	}
+	for _, cmd := range cmds {
+		if info, ok := m.GetProcess(cmd.Process.Pid); !ok || !info.Allowed {
+			t.Errorf("Expected PID %d to be unlocked with the prompt, got %+v", cmd.Process.Pid, info)
+		}
+	}
+}
+
+func TestRelockAll(t *testing.T) {
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skipf("sleep not available: %v", err)
+	}
+	data, err := os.ReadFile(sleep)
+	if err != nil {
+		t.Skipf("Cannot read sleep: %v", err)
+	}
+	app := t.TempDir() + "/sleeper"
+	if err := os.WriteFile(app, data, 0755); err != nil {
+		t.Fatalf("Failed to copy sleep: %v", err)
+	}
+
+	cmd := exec.Command(app, "30")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("Cannot start helper process: %v", err)
+	}
+	defer cmd.Wait()
+	defer cmd.Process.Kill()
+
+	// Registered at startup as already unlocked
+	cfg := &config.Config{}
+	cfg.Monitor.ProtectedApps = []string{app}
+	cfg.Monitor.StartupAction = config.StartupActionRegister
+	m, err := monitor.NewProcessMonitorDaemon(cfg, logging.NewLogger("[test]", false))
+	if err != nil {
+		t.Fatalf("Failed to create monitor: %v", err)
+	}
+	prompts := make(chan int, 1)
+	m.RegisterEventHandler(func(pid int, execPath string, displayName string) { prompts <- pid })
+	if err := m.Start(); err != nil {
+		t.Skipf("Cannot start monitor: %v", err)
+	}
+	defer m.Stop()
+
+	if info, ok := m.GetProcess(cmd.Process.Pid); !ok || !info.Allowed {
+		t.Fatalf("Expected the running app to be tracked as unlocked, got %+v (tracked: %v)", info, ok)
+	}
+
+	if count := m.RelockAll(); count != 1 {
+		t.Fatalf("Expected 1 process to be re-locked, got %d", count)
+	}
+	select {
+	case pid := <-prompts:
+		if pid != cmd.Process.Pid {
+			t.Errorf("Expected a prompt for PID %d, got %d", cmd.Process.Pid, pid)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the re-locked app to prompt")
+	}
+	if state, _ := m.GetProcessState(cmd.Process.Pid); state != monitor.ProcessStateSuspended {
+		t.Errorf("Expected the re-locked app to be suspended, got %s", state)
+	}
+}
+
+func TestVerifyPackageFile(t *testing.T) {
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skipf("sleep not available: %v", err)
+	}
+	sleep, _ = filepath.EvalSymlinks(sleep)
+
+	result, err := monitor.VerifyPackageFile(sleep)
+	if err != nil {
+		t.Fatalf("Failed to verify %s: %v", sleep, err)
+	}
+	if !result.Owned() {
+		t.Skipf("%s is not owned by a dpkg or rpm package", sleep)
+	}
+	if !result.Matches {
+		t.Errorf("Expected %s to match package %s", sleep, result.Package)
+	}
+
+	copied := t.TempDir() + "/sleeper"
+	data, err := os.ReadFile(sleep)
+	if err != nil {
+		t.Fatalf("Failed to read sleep: %v", err)
+	}
+	if err := os.WriteFile(copied, data, 0755); err != nil {
+		t.Fatalf("Failed to copy sleep: %v", err)
+	}
+	if result, err := monitor.VerifyPackageFile(copied); err != nil || result.Owned() {
+		t.Errorf("Expected a copy outside the package to be unowned, got %+v (%v)", result, err)
+	}
+}
+
+func TestVerifySignatureX509(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "release signer"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, pub, priv)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+
+	dir := t.TempDir()
+	certPath := dir + "/signer.pem"
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatalf("Failed to write certificate: %v", err)
+	}
+	app := dir + "/tool"
+	content := []byte("#!/bin/sh\necho deploy\n")
+	if err := os.WriteFile(app, content, 0755); err != nil {
+		t.Fatalf("Failed to write app: %v", err)
+	}
+	if err := os.WriteFile(app+".sig", ed25519.Sign(priv, content), 0644); err != nil {
+		t.Fatalf("Failed to write signature: %v", err)
+	}
+
+	signers := []string{"x509:" + certPath}
+	if signer, err := monitor.VerifySignature(app, app+".sig", signers, ""); err != nil || signer != "x509:"+certPath {
+		t.Errorf("Expected the signature to verify, got %q (%v)", signer, err)
+	}
+
+	if _, err := monitor.VerifySignature(app, app+".sig", []string{"x509:" + dir + "/missing.pem"}, ""); !errors.Is(err, monitor.ErrSignatureInvalid) {
+		t.Errorf("Expected an untrusted signer to be refused, got %v", err)
+	}
+
+	if err := os.WriteFile(app, []byte("#!/bin/sh\necho tampered\n"), 0755); err != nil {
+		t.Fatalf("Failed to modify app: %v", err)
+	}
+	if _, err := monitor.VerifySignature(app, app+".sig", signers, ""); !errors.Is(err, monitor.ErrSignatureInvalid) {
+		t.Errorf("Expected a modified file to be refused, got %v", err)
+	}
+}
+
+func TestParseIMAMeasurement(t *testing.T) {
+	tests := []struct {
+		line string
+		want monitor.IMAMeasurement
+		ok   bool
+	}{
+		{
+			line: "10 91f34b5c671d73504b274a919661cf80dab1e127 ima-ng sha256:A1B2C3 /usr/bin/firefox",
+			want: monitor.IMAMeasurement{Path: "/usr/bin/firefox", Algorithm: "sha256", Digest: "a1b2c3"},
+			ok:   true,
+		},
+		{
+			line: "10 91f34b5c671d73504b274a919661cf80dab1e127 ima-sig sha256:d4e5f6 /usr/bin/steam 030204aa",
+			want: monitor.IMAMeasurement{Path: "/usr/bin/steam", Algorithm: "sha256", Digest: "d4e5f6"},
+			ok:   true,
+		},
+		{
+			line: "10 7971593a7ad22a7cce5b234e4bc5d71b04696af4 ima 2c7020ad8cab6b7419e4973171cb704bdbf52f77 /usr/bin/ls",
+			want: monitor.IMAMeasurement{Path: "/usr/bin/ls", Algorithm: "sha1", Digest: "2c7020ad8cab6b7419e4973171cb704bdbf52f77"},
+			ok:   true,
+		},
+		{line: "10 0000000000000000000000000000000000000000 ima-ng sha256:abcd boot_aggregate"},
+		{line: "garbage"},
+	}
+
+	for _, tt := range tests {
+		got, ok := monitor.ParseIMAMeasurement(tt.line)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseIMAMeasurement(%q) = %+v, %v; want %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// netlinkMessage builds one netlink message of the given type, padded to the
+// 4-byte message alignment
+func netlinkMessage(msgType uint16, payload []byte) []byte {
+	msg := make([]byte, 16+len(payload), (16+len(payload)+3)&^3)
+	binary.NativeEndian.PutUint32(msg[0:], uint32(len(msg)))
+	binary.NativeEndian.PutUint16(msg[4:], msgType)
+	copy(msg[16:], payload)
+	return msg[:cap(msg)]
+}
+
+// netlinkErrno builds the payload of an NLMSG_ERROR message
+func netlinkErrno(errno int32) []byte {
+	payload := make([]byte, 20)
+	binary.NativeEndian.PutUint32(payload, uint32(errno))
+	return payload
+}
+
+// TestNetlinkPayloads tests walking the netlink messages of a datagram
+func TestNetlinkPayloads(t *testing.T) {
+	concat := func(msgs ...[]byte) []byte {
+		var buf []byte
+		for _, msg := range msgs {
+			buf = append(buf, msg...)
+		}
+		return buf
+	}
+
+	tests := []struct {
+		name    string
+		buf     []byte
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "two messages back to back",
+			buf:  concat(netlinkMessage(syscall.NLMSG_DONE, []byte("frst")), netlinkMessage(syscall.NLMSG_DONE, []byte("secondly"))),
+			want: []string{"frst", "secondly"},
+		},
+		{
+			name: "unaligned length",
+			buf:  concat(netlinkMessage(syscall.NLMSG_DONE, []byte("odd")), netlinkMessage(syscall.NLMSG_DONE, []byte("next"))),
+			want: []string{"odd", "next"},
+		},
+		{
+			name:    "truncated tail",
+			buf:     concat(netlinkMessage(syscall.NLMSG_DONE, []byte("whole")), netlinkMessage(syscall.NLMSG_DONE, nil)[:8]),
+			want:    []string{"whole"},
+			wantErr: true,
+		},
+		{
+			name:    "message longer than the datagram",
+			buf:     concat(netlinkMessage(syscall.NLMSG_DONE, []byte("whole")), netlinkMessage(syscall.NLMSG_DONE, []byte("cut short"))[:20]),
+			want:    []string{"whole"},
+			wantErr: true,
+		},
+		{
+			name: "ack",
+			buf:  concat(netlinkMessage(syscall.NLMSG_ERROR, netlinkErrno(0)), netlinkMessage(syscall.NLMSG_DONE, []byte("event"))),
+			want: []string{"event"},
+		},
+		{
+			name:    "error",
+			buf:     concat(netlinkMessage(syscall.NLMSG_ERROR, netlinkErrno(-int32(syscall.EPERM))), netlinkMessage(syscall.NLMSG_DONE, []byte("event"))),
+			want:    []string{"event"},
+			wantErr: true,
+		},
+		{
+			name: "no-op",
+			buf:  netlinkMessage(syscall.NLMSG_NOOP, nil),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payloads, err := monitor.NetlinkPayloads(tt.buf)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NetlinkPayloads() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var got []string
+			for _, payload := range payloads {
+				got = append(got, string(payload))
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("NetlinkPayloads() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
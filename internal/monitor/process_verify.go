@@ -7,18 +7,21 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	appErrors "wyrmlock/internal/errors"
 	"wyrmlock/internal/logging"
 
 	"github.com/ZanzyTHEbar/errbuilder-go"
+	"github.com/zeebo/blake3"
 )
 
 var (
@@ -42,31 +45,88 @@ const (
 	SHA256 HashAlgorithm = "sha256"
 	// SHA512 is the SHA-512 hash algorithm
 	SHA512 HashAlgorithm = "sha512"
+	// BLAKE3 is the BLAKE3 hash algorithm, several times faster than SHA-2
+	// on large binaries
+	BLAKE3 HashAlgorithm = "blake3"
 )
 
-// ProcessHashCache caches executable hashes to reduce disk I/O
+// ProcessHashCache caches executable hashes to reduce disk I/O. Entries are
+// keyed by the file's identity (device, inode, size, modification and change
+// times) rather than its path, so a binary that is replaced or modified in
+// place is hashed again on its next launch while an unchanged one is never
+// re-read. Hashing is limited to a few files at a time, and concurrent
+// requests for the same file share one computation.
 type ProcessHashCache struct {
-	cache     map[string]string // Path -> hash mapping
-	algorithm HashAlgorithm     // Hash algorithm in use
-	mu        sync.RWMutex      // Mutex for concurrent access
-	maxSize   int               // Maximum cache size
-	expiry    time.Duration     // Cache entry expiry
-	lastCheck map[string]time.Time // Last check time for each path
+	cache     map[hashKey]hashEntry // Identity -> hash mapping
+	algorithm HashAlgorithm         // Default hash algorithm
+	mu        sync.RWMutex          // Mutex for concurrent access
+	maxSize   int                   // Maximum cache size
+	expiry    time.Duration         // Cache entry expiry, zero for none
+	scheduler *hashScheduler        // Limits concurrent hashing
+	inflight  map[hashKey]*hashCall // Hashes being computed
+}
+
+// hashCall is a hash computation other callers can wait for
+type hashCall struct {
+	done chan struct{}
+	hash string
+	err  error
+}
+
+// fileIdentity identifies one version of a file. The change time is
+// included because, unlike the modification time, it cannot be set back by
+// the file's owner.
+type fileIdentity struct {
+	dev   uint64
+	ino   uint64
+	size  int64
+	mtime int64
+	ctime int64
+}
+
+// hashKey identifies a cached hash of one file version
+type hashKey struct {
+	file      fileIdentity
+	algorithm HashAlgorithm
+}
+
+// hashEntry is a cached hash and when it was computed
+type hashEntry struct {
+	hash     string
+	computed time.Time
 }
 
 // NewProcessHashCache creates a new process hash cache
 func NewProcessHashCache(algorithm HashAlgorithm, maxSize int, expiry time.Duration) *ProcessHashCache {
 	return &ProcessHashCache{
-		cache:     make(map[string]string),
+		cache:     make(map[hashKey]hashEntry),
 		algorithm: algorithm,
 		maxSize:   maxSize,
 		expiry:    expiry,
-		lastCheck: make(map[string]time.Time),
+		scheduler: newHashScheduler(DefaultHashConcurrency),
+		inflight:  make(map[hashKey]*hashCall),
 	}
 }
 
+// SetConcurrency sets how many files may be hashed at once
+func (c *ProcessHashCache) SetConcurrency(limit int) {
+	c.scheduler.setLimit(limit)
+}
+
 // Get retrieves a hash from the cache or computes it if not present
 func (c *ProcessHashCache) Get(path string) (string, error) {
+	return c.GetWith(path, c.algorithm)
+}
+
+// GetWith is Get with a specific hash algorithm, for hashes stored with
+// another algorithm than the default
+func (c *ProcessHashCache) GetWith(path string, algorithm HashAlgorithm) (string, error) {
+	return c.Hash(path, algorithm, HashPriorityNormal)
+}
+
+// Hash retrieves a hash from the cache or computes it, waiting for a
+// hashing slot at the given priority
+func (c *ProcessHashCache) Hash(path string, algorithm HashAlgorithm, priority HashPriority) (string, error) {
 	// Normalize the path
 	var errs errbuilder.ErrorMap
 
@@ -80,55 +140,78 @@ func (c *ProcessHashCache) Get(path string) (string, error) {
 	}
 	path = normalizedPath
 
+	file, err := openExecutable(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	// Identify the file that was opened, not whatever the path points to now
+	id, ok := identifyFile(file)
+	key := hashKey{file: id, algorithm: algorithm}
+
 	// Check cache first
-	c.mu.RLock()
-	hash, ok := c.cache[path]
-	lastCheck := c.lastCheck[path]
-	c.mu.RUnlock()
-
-	// If found and not expired, return from cache
-	if ok && time.Since(lastCheck) < c.expiry {
-		return hash, nil
+	if ok {
+		c.mu.RLock()
+		entry, found := c.cache[key]
+		c.mu.RUnlock()
+
+		// If found and not expired, return from cache
+		if found && (c.expiry == 0 || time.Since(entry.computed) < c.expiry) {
+			return entry.hash, nil
+		}
 	}
 
-	// Compute hash
-	hash, err = computeFileHash(path, c.algorithm)
-	if err != nil {
-		return "", err
+	if !ok {
+		c.scheduler.acquire(priority)
+		defer c.scheduler.release()
+		return hashFile(file, path, algorithm)
+	}
+
+	// Share a computation already running for the same file
+	c.mu.Lock()
+	if call, running := c.inflight[key]; running {
+		c.mu.Unlock()
+		<-call.done
+		return call.hash, call.err
 	}
+	call := &hashCall{done: make(chan struct{})}
+	c.inflight[key] = call
+	c.mu.Unlock()
+
+	// Compute hash
+	c.scheduler.acquire(priority)
+	call.hash, call.err = hashFile(file, path, algorithm)
+	c.scheduler.release()
 
 	// Update cache
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	// Enforce cache size limit with basic LRU (remove oldest entries)
-	if len(c.cache) >= c.maxSize {
-		var oldestPath string
+	delete(c.inflight, key)
+	close(call.done)
+	if call.err != nil {
+		return "", call.err
+	}
+	hash := call.hash
+
+	// Enforce cache size limit by removing the oldest entry
+	if _, exists := c.cache[key]; !exists && len(c.cache) >= c.maxSize {
+		var oldestKey hashKey
 		var oldestTime time.Time
-		
-		// Initialize with first entry
-		for p, t := range c.lastCheck {
-			oldestPath = p
-			oldestTime = t
-			break
-		}
-		
-		// Find oldest entry
-		for p, t := range c.lastCheck {
-			if t.Before(oldestTime) {
-				oldestPath = p
-				oldestTime = t
+
+		for k, e := range c.cache {
+			if oldestTime.IsZero() || e.computed.Before(oldestTime) {
+				oldestKey = k
+				oldestTime = e.computed
 			}
 		}
-		
-		// Remove oldest entry
-		delete(c.cache, oldestPath)
-		delete(c.lastCheck, oldestPath)
+
+		delete(c.cache, oldestKey)
 	}
 
 	// Add new entry
-	c.cache[path] = hash
-	c.lastCheck[path] = time.Now()
+	c.cache[key] = hashEntry{hash: hash, computed: time.Now()}
 
 	return hash, nil
 }
@@ -137,68 +220,79 @@ func (c *ProcessHashCache) Get(path string) (string, error) {
 func (c *ProcessHashCache) Clear() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	
-	c.cache = make(map[string]string)
-	c.lastCheck = make(map[string]time.Time)
+
+	c.cache = make(map[hashKey]hashEntry)
 }
 
-// computeFileHash generates a hash for the specified file
-func computeFileHash(path string, algorithm HashAlgorithm) (string, error) {
-	// Open the file for reading
-	file, err := os.Open(path)
+// identifyFile returns the identity of an open file
+func identifyFile(file *os.File) (fileIdentity, bool) {
+	info, err := file.Stat()
 	if err != nil {
-		if os.IsNotExist(err) {
-			var errs errbuilder.ErrorMap
-			errs.Set("path", path)
-			errs.Set("error_type", "not_found")
-			return "", appErrors.WithDetails(
-				appErrors.ProcessVerificationError("executable not found"),
-				errs,
-			)
-		}
-		if os.IsPermission(err) {
-			var errs errbuilder.ErrorMap
-			errs.Set("path", path)
-			errs.Set("error_type", "permission_denied")
-			return "", appErrors.WithDetails(
-				appErrors.PermissionDenied("permission denied when accessing executable"),
-				errs,
-			)
-		}
+		return fileIdentity{}, false
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileIdentity{}, false
+	}
+	return fileIdentity{
+		dev:   uint64(st.Dev),
+		ino:   uint64(st.Ino),
+		size:  st.Size,
+		mtime: st.Mtim.Nano(),
+		ctime: st.Ctim.Nano(),
+	}, true
+}
+
+// openExecutable opens a file for hashing
+func openExecutable(path string) (*os.File, error) {
+	file, err := os.Open(path)
+	if err == nil {
+		return file, nil
+	}
+
+	if os.IsNotExist(err) {
 		var errs errbuilder.ErrorMap
 		errs.Set("path", path)
-		errs.Set("error_type", "file_access")
-		return "", appErrors.WithDetails(
-			appErrors.MonitorError(fmt.Sprintf("failed to open file: %v", err)),
+		errs.Set("error_type", "not_found")
+		return nil, appErrors.WithDetails(
+			appErrors.ProcessVerificationError("executable not found"),
 			errs,
 		)
 	}
-	defer file.Close()
+	if os.IsPermission(err) {
+		var errs errbuilder.ErrorMap
+		errs.Set("path", path)
+		errs.Set("error_type", "permission_denied")
+		return nil, appErrors.WithDetails(
+			appErrors.PermissionDenied("permission denied when accessing executable"),
+			errs,
+		)
+	}
+	var errs errbuilder.ErrorMap
+	errs.Set("path", path)
+	errs.Set("error_type", "file_access")
+	return nil, appErrors.WithDetails(
+		appErrors.MonitorError(fmt.Sprintf("failed to open file: %v", err)),
+		errs,
+	)
+}
 
+// hashFile hashes an open file's contents
+func hashFile(file io.Reader, path string, algorithm HashAlgorithm) (string, error) {
 	// Choose hash algorithm
-	var hash io.Writer
-	
+	var h hash.Hash
+
 	switch algorithm {
 	case SHA256:
-		h := sha256.New()
-		hash = h
-		defer func() {
-			if err == nil {
-				err = nil // Placeholder for any cleanup needed
-			}
-		}()
+		h = sha256.New()
 	case SHA512:
-		h := sha512.New()
-		hash = h
-		defer func() {
-			if err == nil {
-				err = nil // Placeholder for any cleanup needed
-			}
-		}()
+		h = sha512.New()
+	case BLAKE3:
+		h = blake3.New()
 	default:
 		var errs errbuilder.ErrorMap
 		errs.Set("algorithm", string(algorithm))
-		errs.Set("supported_algorithms", "sha256,sha512")
+		errs.Set("supported_algorithms", "sha256,sha512,blake3")
 		return "", appErrors.WithDetails(
 			appErrors.MonitorError(fmt.Sprintf("unsupported hash algorithm: %s", algorithm)),
 			errs,
@@ -206,7 +300,7 @@ func computeFileHash(path string, algorithm HashAlgorithm) (string, error) {
 	}
 
 	// Compute hash
-	if _, err := io.Copy(hash, file); err != nil {
+	if _, err := io.Copy(h, file); err != nil {
 		var errs errbuilder.ErrorMap
 		errs.Set("path", path)
 		errs.Set("algorithm", string(algorithm))
@@ -217,16 +311,7 @@ func computeFileHash(path string, algorithm HashAlgorithm) (string, error) {
 	}
 
 	// Return hash as hex string
-	var hashBytes []byte
-	
-	switch algorithm {
-	case SHA256:
-		hashBytes = hash.(interface{ Sum([]byte) []byte }).Sum(nil)
-	case SHA512:
-		hashBytes = hash.(interface{ Sum([]byte) []byte }).Sum(nil)
-	}
-	
-	return hex.EncodeToString(hashBytes), nil
+	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
 // ProcessVerifier verifies process identity and integrity
@@ -234,36 +319,47 @@ type ProcessVerifier struct {
 	logger    *logging.Logger
 	hashCache *ProcessHashCache
 	// Known good hashes for applications
-	knownHashes map[string]map[string]string // app name -> path -> hash
+	knownHashes map[string]map[string]knownHash // app name -> path -> hash
 	mu          sync.RWMutex
 }
 
+// knownHash is a stored executable hash and the algorithm it was made with
+type knownHash struct {
+	hash      string
+	algorithm HashAlgorithm
+}
+
 // NewProcessVerifier creates a new process verifier
 func NewProcessVerifier(logger *logging.Logger) *ProcessVerifier {
 	return &ProcessVerifier{
 		logger: logger,
 		hashCache: NewProcessHashCache(
-			SHA256,          // Use SHA-256 by default
-			100,             // Cache up to 100 entries
-			10*time.Minute,  // Cache expires after 10 minutes
+			SHA256, // Use SHA-256 by default
+			512,    // Cache up to 512 executables
+			0,      // Entries stay valid until the file changes
 		),
-		knownHashes: make(map[string]map[string]string),
+		knownHashes: make(map[string]map[string]knownHash),
 	}
 }
 
-// AddKnownHash adds a known good hash for an application
-func (v *ProcessVerifier) AddKnownHash(appName, path, hash string) {
+// AddKnownHash adds a known good hash for an application. An empty
+// algorithm means SHA-256, which hashes stored before the algorithm was
+// recorded use.
+func (v *ProcessVerifier) AddKnownHash(appName, path, hash string, algorithm HashAlgorithm) {
 	v.mu.Lock()
 	defer v.mu.Unlock()
 	
 	// Initialize inner map if not exists
 	if _, ok := v.knownHashes[appName]; !ok {
-		v.knownHashes[appName] = make(map[string]string)
+		v.knownHashes[appName] = make(map[string]knownHash)
+	}
+	if algorithm == "" {
+		algorithm = SHA256
 	}
 	
 	// Add hash
-	v.knownHashes[appName][path] = hash
-	v.logger.Debugf("Added known hash for %s at %s: %s", appName, path, hash)
+	v.knownHashes[appName][path] = knownHash{hash: strings.ToLower(hash), algorithm: algorithm}
+	v.logger.Debugf("Added known %s hash for %s at %s: %s", algorithm, appName, path, hash)
 }
 
 // VerifyProcess verifies a process based on its path and executable hash
@@ -309,7 +405,7 @@ func (v *ProcessVerifier) VerifyProcess(pid int, appName string) error {
 	}
 	
 	// Check if the exact path is expected
-	knownHash, pathMatch := pathMap[execPath]
+	known, pathMatch := pathMap[execPath]
 	
 	// If there's no exact path match, try normalized path comparisons
 	if !pathMatch {
@@ -318,7 +414,7 @@ func (v *ProcessVerifier) VerifyProcess(pid int, appName string) error {
 		for knownPath := range pathMap {
 			// Check for same basename (simple case)
 			if filepath.Base(knownPath) == filepath.Base(execPath) {
-				knownHash = pathMap[knownPath]
+				known = pathMap[knownPath]
 				foundMatch = true
 				break
 			}
@@ -328,7 +424,7 @@ func (v *ProcessVerifier) VerifyProcess(pid int, appName string) error {
 			execPathRel, err2 := filepath.Rel("/", execPath)
 			
 			if err1 == nil && err2 == nil && knownPathRel == execPathRel {
-				knownHash = pathMap[knownPath]
+				known = pathMap[knownPath]
 				foundMatch = true
 				break
 			}
@@ -349,9 +445,9 @@ func (v *ProcessVerifier) VerifyProcess(pid int, appName string) error {
 	}
 	
 	// If we have a hash to verify against, compute the actual hash
-	if knownHash != "" {
-		// Compute hash of the executable
-		actualHash, err := v.hashCache.Get(execPath)
+	if known.hash != "" {
+		// Compute hash of the executable with the algorithm it was stored with
+		actualHash, err := v.hashCache.Hash(execPath, known.algorithm, hashPriority(procInfo.State))
 		if err != nil {
 			// Check for specific error types
 			if errors.Is(err, ErrPermissionDenied) {
@@ -387,15 +483,15 @@ func (v *ProcessVerifier) VerifyProcess(pid int, appName string) error {
 		}
 		
 		// Compare hashes
-		if actualHash != knownHash {
+		if actualHash != known.hash {
 			v.logger.Warnf("Hash mismatch for %s (PID %d): expected %s, got %s",
-				execPath, pid, knownHash, actualHash)
+				execPath, pid, known.hash, actualHash)
 				
 			var errs errbuilder.ErrorMap
 			errs.Set("pid", fmt.Sprintf("%d", pid))
 			errs.Set("app_name", appName)
 			errs.Set("exec_path", execPath)
-			errs.Set("expected_hash", knownHash)
+			errs.Set("expected_hash", known.hash)
 			errs.Set("actual_hash", actualHash)
 			errs.Set("verification_type", "hash")
 			return appErrors.WithDetails(
@@ -507,6 +603,14 @@ func GetProcessInfo(pid int) (*ProcessInfo, error) {
 	}, nil
 }
 
+// hashPriority returns the hashing priority for a process in state
+func hashPriority(state string) HashPriority {
+	if state == ProcessStateSuspended {
+		return HashPrioritySuspended
+	}
+	return HashPriorityNormal
+}
+
 // processStateFromString converts a /proc/[pid]/stat state character to a readable state string
 func processStateFromString(state string) string {
 	switch state {
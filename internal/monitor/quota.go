@@ -0,0 +1,164 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LaunchQuotaUsage is today's use of an app's daily quota (free launches or
+// minutes of screen time)
+type LaunchQuotaUsage struct {
+	App   string
+	Used  int
+	Limit int
+}
+
+// launchQuota keeps per-day launch counters on disk so restarting the daemon
+// does not hand out a fresh quota
+type launchQuota struct {
+	mu     sync.Mutex
+	path   string
+	Day    string         `json:"day"`
+	Counts map[string]int `json:"counts"`
+}
+
+// quotaDay is the calendar day counters belong to, in local time
+func quotaDay(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// loadLaunchQuota reads the counters from path; a missing or unreadable file
+// starts from zero
+func loadLaunchQuota(path string) *launchQuota {
+	q := &launchQuota{path: path, Counts: make(map[string]int)}
+	if path == "" {
+		return q
+	}
+
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, q); err != nil || q.Counts == nil {
+			q.Day, q.Counts = "", make(map[string]int)
+		}
+	}
+	return q
+}
+
+// rollover resets the counters when the day has changed. Caller holds mu.
+func (q *launchQuota) rollover(now time.Time) {
+	if day := quotaDay(now); q.Day != day {
+		q.Day = day
+		q.Counts = make(map[string]int)
+	}
+}
+
+// save writes the counters to disk atomically. Caller holds mu.
+func (q *launchQuota) save() error {
+	if q.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(q.path), 0755); err != nil {
+		return fmt.Errorf("failed to create quota directory: %w", err)
+	}
+
+	data, err := json.Marshal(q)
+	if err != nil {
+		return fmt.Errorf("failed to encode quotas: %w", err)
+	}
+
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write quotas: %w", err)
+	}
+	return os.Rename(tmp, q.path)
+}
+
+// consume uses one free launch of app if any are left today
+func (q *launchQuota) consume(app string, limit int, now time.Time) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.rollover(now)
+	if q.Counts[app] >= limit {
+		return false, nil
+	}
+
+	q.Counts[app]++
+	return true, q.save()
+}
+
+// used returns today's launch count for app
+func (q *launchQuota) used(app string, now time.Time) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.rollover(now)
+	return q.Counts[app]
+}
+
+// reset clears today's counter for app, or for every app when app is empty
+func (q *launchQuota) reset(app string, now time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.rollover(now)
+	if app == "" {
+		q.Counts = make(map[string]int)
+	} else {
+		delete(q.Counts, app)
+	}
+	return q.save()
+}
+
+// LaunchQuotas returns today's usage of every configured launch quota
+func (m *ProcessMonitor) LaunchQuotas() []LaunchQuotaUsage {
+	now := time.Now()
+	usage := make([]LaunchQuotaUsage, 0)
+
+	for _, app := range m.config.BlockedApps {
+		if app.DailyLaunches > 0 {
+			usage = append(usage, LaunchQuotaUsage{
+				App:   app.Path,
+				Used:  m.quotas.used(app.Path, now),
+				Limit: app.DailyLaunches,
+			})
+		}
+	}
+
+	sort.Slice(usage, func(i, j int) bool { return usage[i].App < usage[j].App })
+	return usage
+}
+
+// ResetLaunchQuota gives back today's free launches for app, or for every
+// app when app is empty
+func (m *ProcessMonitor) ResetLaunchQuota(app string) error {
+	if app != "" && m.policy.LaunchLimit(app) == 0 {
+		return fmt.Errorf("no launch quota configured for %s", app)
+	}
+
+	m.logger.Infof("Resetting launch quota for %s", quotaAppName(app))
+	return m.quotas.reset(app, time.Now())
+}
+
+// quotaAppName names the target of a quota reset in logs
+func quotaAppName(app string) string {
+	if app == "" {
+		return "all apps"
+	}
+	return app
+}
+
+// allowFromQuota lets a launch given a free launch from its app's quota
+// run, tracking it as an allowed instance
+func (m *ProcessMonitor) allowFromQuota(procInfo *ProcessInfo) {
+	info := *procInfo
+	info.Allowed = true
+	info.State = ProcessStateRunning
+	m.monitoredMu.Lock()
+	m.monitoredProcesses[procInfo.PID] = info
+	m.monitoredMu.Unlock()
+}
@@ -0,0 +1,28 @@
+package monitor
+
+import "path/filepath"
+
+// RelockAll suspends every unlocked protected process and routes it to
+// authentication again, as when the user's session locks. It returns how
+// many processes were re-locked.
+func (m *ProcessMonitor) RelockAll() int {
+	m.monitoredMu.RLock()
+	unlocked := make(map[int]string)
+	for pid, info := range m.monitoredProcesses {
+		if info.Allowed {
+			unlocked[pid] = info.Target()
+		}
+	}
+	m.monitoredMu.RUnlock()
+
+	count := 0
+	for pid, app := range unlocked {
+		if m.auditLaunch(pid, app, filepath.Base(app), AuditActionLock, "session locked") {
+			continue
+		}
+		if m.relock(pid, app) {
+			count++
+		}
+	}
+	return count
+}
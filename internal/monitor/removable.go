@@ -0,0 +1,168 @@
+package monitor
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// removableMountDirs are where desktop automounters place removable media
+var removableMountDirs = []string{"/media/", "/run/media/"}
+
+// mountEntry is a line of /proc/self/mountinfo
+type mountEntry struct {
+	major, minor uint32
+	mountPoint   string
+	fsType       string
+	source       string
+}
+
+// readMountInfo parses the monitor's mount table
+func readMountInfo() ([]mountEntry, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mountinfo: %w", err)
+	}
+	defer f.Close()
+
+	var mounts []mountEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// id parent major:minor root mountpoint options [optional...] - fstype source superopts
+		fields := strings.Fields(scanner.Text())
+		sep := -1
+		for i := 6; i < len(fields); i++ {
+			if fields[i] == "-" {
+				sep = i
+				break
+			}
+		}
+		if sep < 0 || sep+2 >= len(fields) {
+			continue
+		}
+
+		majorStr, minorStr, ok := strings.Cut(fields[2], ":")
+		if !ok {
+			continue
+		}
+		major, err1 := strconv.ParseUint(majorStr, 10, 32)
+		minor, err2 := strconv.ParseUint(minorStr, 10, 32)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+
+		mounts = append(mounts, mountEntry{
+			major:      uint32(major),
+			minor:      uint32(minor),
+			mountPoint: unescapeMountPath(fields[4]),
+			fsType:     fields[sep+1],
+			source:     unescapeMountPath(fields[sep+2]),
+		})
+	}
+	return mounts, scanner.Err()
+}
+
+// unescapeMountPath decodes the octal escapes mountinfo uses for spaces and
+// other separators
+func unescapeMountPath(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if c, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// RemovableMount returns the mount point when the file at path lives on
+// removable media: a block device the kernel marks removable or that is
+// attached over USB, or anything automounted under /media or /run/media
+func RemovableMount(path string) (string, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", false
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", false
+	}
+	major, minor := unix.Major(uint64(st.Dev)), unix.Minor(uint64(st.Dev))
+
+	mounts, err := readMountInfo()
+	if err != nil {
+		return "", false
+	}
+
+	// Bind mounts share a device; any automounted one marks it removable
+	found := ""
+	for _, mount := range mounts {
+		if mount.major != major || mount.minor != minor {
+			continue
+		}
+		if found == "" {
+			found = mount.mountPoint
+		}
+		for _, dir := range removableMountDirs {
+			if strings.HasPrefix(mount.mountPoint+"/", dir) {
+				return mount.mountPoint, true
+			}
+		}
+	}
+	if found == "" {
+		return "", false
+	}
+
+	if isRemovableDevice(major, minor) {
+		return found, true
+	}
+	return "", false
+}
+
+// isRemovableDevice checks sysfs for a removable or USB-attached block
+// device. Partitions carry the flag on their parent disk.
+func isRemovableDevice(major, minor uint32) bool {
+	sys, err := filepath.EvalSymlinks(fmt.Sprintf("/sys/dev/block/%d:%d", major, minor))
+	if err != nil {
+		return false
+	}
+	if strings.Contains(sys, "/usb") {
+		return true
+	}
+	for _, dir := range []string{sys, filepath.Dir(sys)} {
+		data, err := os.ReadFile(filepath.Join(dir, "removable"))
+		if err == nil && strings.TrimSpace(string(data)) == "1" {
+			return true
+		}
+	}
+	return false
+}
+
+// removableMount returns the mount point of the removable media a launch
+// runs from, or "". The executable is checked through the exe link so it
+// resolves in the process's mount namespace, and for interpreters the
+// script as well.
+func removableMount(pid int, procInfo *ProcessInfo) string {
+	if mount, ok := RemovableMount(fmt.Sprintf("/proc/%d/exe", pid)); ok {
+		return mount
+	}
+	if procInfo.Script != "" {
+		if mount, ok := RemovableMount(procInfo.Script); ok {
+			return mount
+		}
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+
+	"wyrmlock/internal/policy"
+)
+
+// deletedSuffix is appended by the kernel to the exe link of a process whose
+// executable was unlinked
+const deletedSuffix = policy.DeletedSuffix
+
+// ReplacedExecutable reports why the executable a process is running no
+// longer matches the file at its path, or "" when it still does. A deleted
+// or swapped binary is how a payload hides after launch, and path rules and
+// hashes of the file on disk no longer describe what is running.
+func ReplacedExecutable(pid int) string {
+	exePath, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+	if err != nil {
+		return ""
+	}
+	if strings.HasSuffix(exePath, deletedSuffix) {
+		return "executable was deleted"
+	}
+
+	// A path in another mount namespace names a different file here
+	if !sameMountNamespace(pid) {
+		return ""
+	}
+
+	// Stat through the exe link reaches the mapped inode even if the path
+	// was renamed over
+	mapped, err := os.Stat(fmt.Sprintf("/proc/%d/exe", pid))
+	if err != nil {
+		return ""
+	}
+	onDisk, err := os.Stat(exePath)
+	if err != nil {
+		return "executable is missing from disk"
+	}
+
+	m, mok := mapped.Sys().(*syscall.Stat_t)
+	d, dok := onDisk.Sys().(*syscall.Stat_t)
+	if mok && dok && (m.Dev != d.Dev || m.Ino != d.Ino) {
+		return "executable on disk was replaced"
+	}
+	return ""
+}
+
+// sameMountNamespace reports whether pid shares the monitor's mount namespace
+func sameMountNamespace(pid int) bool {
+	self, err := os.Readlink("/proc/self/ns/mnt")
+	if err != nil {
+		return false
+	}
+	other, err := os.Readlink(fmt.Sprintf("/proc/%d/ns/mnt", pid))
+	return err == nil && self == other
+}
@@ -0,0 +1,106 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"wyrmlock/internal/logging"
+)
+
+// ErrExecutableChanged is returned when a resume is refused because the
+// executable was swapped while the user was authenticating
+var ErrExecutableChanged = errors.New("executable changed while awaiting authentication")
+
+// launchSnapshot is what a suspended launch ran when it was locked
+type launchSnapshot struct {
+	// exe is the inode behind /proc/<pid>/exe
+	exe fileIdentity
+
+	// path is the file on disk the launch runs (the script for
+	// interpreted programs) and hash its SHA-256
+	path string
+	hash string
+}
+
+// identifyExe returns the identity of the file a process executes
+func identifyExe(pid int) (fileIdentity, error) {
+	file, err := os.Open(fmt.Sprintf("/proc/%d/exe", pid))
+	if err != nil {
+		return fileIdentity{}, err
+	}
+	defer file.Close()
+
+	id, ok := identifyFile(file)
+	if !ok {
+		return fileIdentity{}, fmt.Errorf("failed to stat executable of %d", pid)
+	}
+	return id, nil
+}
+
+// snapshotLaunch records what a launch runs as it is suspended, so the
+// resume can check nothing was swapped during the dialog
+func (m *ProcessMonitor) snapshotLaunch(pid int, info *ProcessInfo) {
+	exe, err := identifyExe(pid)
+	if err != nil {
+		m.logger.Debugf("Failed to snapshot executable of %d: %v", pid, err)
+		return
+	}
+
+	path := hostPath(pid, info.Command)
+	if info.Script != "" {
+		path = hostPath(pid, info.Script)
+	}
+	hash, err := m.verifier.hashCache.Hash(path, SHA256, HashPrioritySuspended)
+	if err != nil {
+		m.logger.Debugf("Failed to snapshot %s: %v", path, err)
+		return
+	}
+
+	m.snapshotMu.Lock()
+	m.snapshots[pid] = launchSnapshot{exe: exe, path: path, hash: hash}
+	m.snapshotMu.Unlock()
+}
+
+// forgetSnapshot drops the snapshot of pid
+func (m *ProcessMonitor) forgetSnapshot(pid int) {
+	m.snapshotMu.Lock()
+	delete(m.snapshots, pid)
+	m.snapshotMu.Unlock()
+}
+
+// reverifyLaunch checks, right before an authenticated launch is resumed,
+// that the process still runs the inode it was locked with and that the
+// file on disk still has the content it had then
+func (m *ProcessMonitor) reverifyLaunch(pid int, app string) error {
+	m.snapshotMu.Lock()
+	snapshot, ok := m.snapshots[pid]
+	m.snapshotMu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	reason := ""
+	if exe, err := identifyExe(pid); err != nil {
+		reason = fmt.Sprintf("executable cannot be checked: %v", err)
+	} else if exe != snapshot.exe {
+		reason = "process runs a different executable"
+	} else if hash, err := m.verifier.hashCache.Hash(snapshot.path, SHA256, HashPrioritySuspended); err != nil {
+		reason = fmt.Sprintf("%s cannot be hashed: %v", snapshot.path, err)
+	} else if hash != snapshot.hash {
+		reason = fmt.Sprintf("%s was modified", snapshot.path)
+	}
+	if reason == "" {
+		return nil
+	}
+
+	m.logger.Warnf("Refusing to resume %s (PID %d): %s", app, pid, reason)
+	if logging.SecurityLog != nil {
+		logging.SecurityLog.LogProcessEvent(logging.EventSecurityViolation, app, pid, map[string]interface{}{
+			"reason":  "executable changed while awaiting authentication",
+			"detail":  reason,
+			"cmdline": m.eventCmdLine(pid, app),
+		})
+	}
+	return fmt.Errorf("%w: %s", ErrExecutableChanged, reason)
+}
@@ -0,0 +1,117 @@
+package monitor
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// SandboxKind identifies the packaging sandbox a process runs in
+type SandboxKind string
+
+const (
+	// SandboxFlatpak is a Flatpak (bwrap) sandbox
+	SandboxFlatpak SandboxKind = "flatpak"
+
+	// SandboxSnap is a snapd confined application
+	SandboxSnap SandboxKind = "snap"
+)
+
+// SandboxInfo describes the sandbox and application ID of a process
+type SandboxInfo struct {
+	Kind  SandboxKind
+	AppID string
+}
+
+// Key returns the config entry form of the sandbox, e.g. "flatpak:org.telegram.desktop"
+func (s SandboxInfo) Key() string {
+	return string(s.Kind) + ":" + s.AppID
+}
+
+var (
+	// app-flatpak-org.telegram.desktop-12345.scope
+	flatpakScopeRe = regexp.MustCompile(`^app-flatpak-(.+)-[0-9]+\.scope$`)
+
+	// snap.spotify.spotify-<uuid>.scope or snap.spotify.daemon.service
+	snapScopeRe = regexp.MustCompile(`^snap\.([a-z0-9][a-z0-9-]*)\.`)
+)
+
+// detectSandbox determines whether a process runs inside a Flatpak or snap
+// sandbox. The cgroup is checked first since it is set by the launcher
+// before exec, then the sandbox's own metadata in the process mount namespace.
+func detectSandbox(pid int, execPath string) (*SandboxInfo, error) {
+	if info := sandboxFromCgroup(pid); info != nil {
+		return info, nil
+	}
+
+	// bwrap exposes /.flatpak-info inside the sandbox mount namespace
+	if appID, err := readFlatpakInfo(fmt.Sprintf("/proc/%d/root/.flatpak-info", pid)); err == nil && appID != "" {
+		return &SandboxInfo{Kind: SandboxFlatpak, AppID: appID}, nil
+	}
+
+	// snapd runs applications from /snap/<name>/<revision>/...
+	if strings.HasPrefix(execPath, "/snap/") {
+		parts := strings.SplitN(strings.TrimPrefix(execPath, "/snap/"), "/", 2)
+		if len(parts) == 2 && parts[0] != "" && parts[0] != "bin" {
+			return &SandboxInfo{Kind: SandboxSnap, AppID: parts[0]}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// sandboxFromCgroup extracts sandbox info from /proc/<pid>/cgroup
+func sandboxFromCgroup(pid int) *SandboxInfo {
+	file, err := os.Open(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+
+		for _, elem := range strings.Split(fields[2], "/") {
+			if m := flatpakScopeRe.FindStringSubmatch(elem); m != nil {
+				// systemd escapes '-' in unit names as \x2d
+				return &SandboxInfo{Kind: SandboxFlatpak, AppID: strings.ReplaceAll(m[1], `\x2d`, "-")}
+			}
+			if m := snapScopeRe.FindStringSubmatch(elem); m != nil {
+				return &SandboxInfo{Kind: SandboxSnap, AppID: m[1]}
+			}
+		}
+	}
+
+	return nil
+}
+
+// readFlatpakInfo reads the application name from a .flatpak-info keyfile
+func readFlatpakInfo(infoPath string) (string, error) {
+	file, err := os.Open(infoPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	inApplication := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inApplication = line == "[Application]"
+			continue
+		}
+		if inApplication && strings.HasPrefix(line, "name=") {
+			return strings.TrimPrefix(line, "name="), nil
+		}
+	}
+
+	return "", scanner.Err()
+}
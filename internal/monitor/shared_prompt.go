@@ -0,0 +1,106 @@
+package monitor
+
+import "path/filepath"
+
+// sharedPrompt is an authentication prompt answered for every launch of an
+// app suspended while it is open, so a double-click shows one dialog
+type sharedPrompt struct {
+	leader    int
+	followers []int
+}
+
+// joinPrompt attaches pid to the prompt already open for app and reports
+// whether it did; otherwise pid leads a new prompt
+func (m *ProcessMonitor) joinPrompt(pid int, app string) bool {
+	m.promptsMu.Lock()
+	defer m.promptsMu.Unlock()
+
+	if prompt, ok := m.prompts[app]; ok {
+		prompt.followers = append(prompt.followers, pid)
+		return true
+	}
+	m.prompts[app] = &sharedPrompt{leader: pid}
+	return false
+}
+
+// takePrompt removes the prompt led by pid and returns its app and followers
+func (m *ProcessMonitor) takePrompt(leader int) (string, []int) {
+	m.promptsMu.Lock()
+	defer m.promptsMu.Unlock()
+
+	for app, prompt := range m.prompts {
+		if prompt.leader == leader {
+			delete(m.prompts, app)
+			return app, prompt.followers
+		}
+	}
+	return "", nil
+}
+
+// settlePrompt applies the answer to the prompt led by pid to the launches
+// that joined it
+func (m *ProcessMonitor) settlePrompt(leader int, allowed bool) {
+	app, followers := m.takePrompt(leader)
+
+	for _, pid := range followers {
+		if allowed {
+			m.logger.Infof("Resuming %s (PID %d) with PID %d", app, pid, leader)
+			if err := m.ResumeProcess(pid); err != nil {
+				m.logger.Errorf("Failed to resume process %d: %v", pid, err)
+			}
+		} else {
+			m.logger.Infof("Terminating %s (PID %d) with PID %d", app, pid, leader)
+			if err := m.KillProcessTree(pid); err != nil {
+				m.logger.Errorf("Failed to terminate process %d: %v", pid, err)
+			}
+		}
+
+		if !m.daemonMode {
+			m.releaseHandledPid(pid)
+		}
+	}
+}
+
+// leavePrompt drops an exited process from the prompts. In daemon mode a
+// prompt whose leader exited is handed to the next launch, since nothing
+// will answer for the old PID.
+func (m *ProcessMonitor) leavePrompt(pid int) {
+	m.promptsMu.Lock()
+	var app string
+	var next int
+	for promptApp, prompt := range m.prompts {
+		if prompt.leader == pid {
+			if !m.daemonMode {
+				// The dialog is still open and settles the prompt when closed
+				break
+			}
+			if len(prompt.followers) == 0 {
+				delete(m.prompts, promptApp)
+				break
+			}
+			app, next = promptApp, prompt.followers[0]
+			prompt.leader, prompt.followers = next, prompt.followers[1:]
+			break
+		}
+		for i, follower := range prompt.followers {
+			if follower == pid {
+				prompt.followers = append(prompt.followers[:i], prompt.followers[i+1:]...)
+				break
+			}
+		}
+	}
+	m.promptsMu.Unlock()
+
+	if next == 0 {
+		return
+	}
+
+	m.eventHandlerMu.RLock()
+	handler := m.eventHandler
+	m.eventHandlerMu.RUnlock()
+
+	if handler != nil {
+		m.logger.Infof("PID %d exited before authentication, prompting for %s (PID %d)", pid, app, next)
+		go handler(next, app, filepath.Base(app))
+	}
+}
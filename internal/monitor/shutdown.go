@@ -0,0 +1,47 @@
+package monitor
+
+import (
+	"syscall"
+
+	"wyrmlock/internal/config"
+)
+
+// EnforceShutdown applies the daemon shutdown action to every tracked
+// protected process and returns how many were affected. Processes still
+// awaiting authentication are already suspended; with terminate they are
+// killed as well.
+func (m *ProcessMonitor) EnforceShutdown(action string) int {
+	if action != config.ShutdownActionSuspend && action != config.ShutdownActionTerminate {
+		return 0
+	}
+
+	m.monitoredMu.RLock()
+	processes := make([]ProcessInfo, 0, len(m.monitoredProcesses))
+	for _, info := range m.monitoredProcesses {
+		processes = append(processes, info)
+	}
+	m.monitoredMu.RUnlock()
+
+	affected := 0
+	for _, info := range processes {
+		var err error
+		switch action {
+		case config.ShutdownActionTerminate:
+			err = m.TerminateProcess(info.PID)
+		case config.ShutdownActionSuspend:
+			if !info.Allowed {
+				continue
+			}
+			// A freezer cgroup could not be thawed once the daemon is gone
+			m.logger.Infof("Suspending process %d (%s) on shutdown", info.PID, info.Target())
+			err = m.signalProcess(info.PID, syscall.SIGSTOP)
+		}
+		if err != nil {
+			m.logger.Warnf("Failed to %s process %d on shutdown: %v", action, info.PID, err)
+			continue
+		}
+		affected++
+	}
+
+	return affected
+}
@@ -0,0 +1,204 @@
+package monitor
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/logging"
+)
+
+// ErrSignatureInvalid is returned when a resume is refused because the
+// executable has no valid signature by a trusted signer
+var ErrSignatureInvalid = errors.New("executable signature is not valid")
+
+// VerifySignature checks a detached signature of file against the trusted
+// signers and returns the signer that made it. "gpg:<fingerprint>" signers
+// are checked with gpgv against keyring; "x509:<certificate.pem>" signers
+// verify a raw (or base64) signature made with the certificate's key.
+func VerifySignature(file, sigPath string, signers []string, keyring string) (string, error) {
+	sig, err := os.ReadFile(sigPath)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrSignatureInvalid, err)
+	}
+
+	var gpgSigners, certs []string
+	for _, signer := range signers {
+		if fpr, ok := strings.CutPrefix(signer, config.SignerPrefixGPG); ok {
+			gpgSigners = append(gpgSigners, normalizeFingerprint(fpr))
+		} else if cert, ok := strings.CutPrefix(signer, config.SignerPrefixX509); ok {
+			certs = append(certs, cert)
+		}
+	}
+
+	if isOpenPGPSignature(sig) {
+		if len(gpgSigners) == 0 {
+			return "", fmt.Errorf("%w: OpenPGP signature but no trusted GPG signers", ErrSignatureInvalid)
+		}
+		return verifyGPGSignature(file, sigPath, gpgSigners, keyring)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(sig))); err == nil {
+		sig = decoded
+	}
+	for _, certPath := range certs {
+		if err := verifyX509Signature(data, sig, certPath); err == nil {
+			return config.SignerPrefixX509 + certPath, nil
+		}
+	}
+	return "", fmt.Errorf("%w: not signed by a trusted signer", ErrSignatureInvalid)
+}
+
+// isOpenPGPSignature recognises armored and binary OpenPGP signatures
+func isOpenPGPSignature(sig []byte) bool {
+	if bytes.HasPrefix(bytes.TrimSpace(sig), []byte("-----BEGIN PGP SIGNATURE-----")) {
+		return true
+	}
+	// Binary packets start with a tag byte with the high bit set; tag 2 is
+	// a signature packet in either the old or the new format
+	if len(sig) == 0 || sig[0]&0x80 == 0 {
+		return false
+	}
+	if sig[0]&0x40 != 0 {
+		return sig[0]&0x3f == 2
+	}
+	return (sig[0]>>2)&0x0f == 2
+}
+
+// normalizeFingerprint upper-cases a fingerprint and drops its spaces
+func normalizeFingerprint(fpr string) string {
+	return strings.ToUpper(strings.ReplaceAll(fpr, " ", ""))
+}
+
+// verifyGPGSignature runs gpgv and accepts the signature when the signing
+// key, or its primary key, is a trusted signer
+func verifyGPGSignature(file, sigPath string, signers []string, keyring string) (string, error) {
+	out, err := exec.Command("gpgv", "--status-fd", "1", "--keyring", keyring, sigPath, file).Output()
+	if err != nil && len(out) == 0 {
+		return "", fmt.Errorf("%w: gpgv failed: %v", ErrSignatureInvalid, err)
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		// [GNUPG:] VALIDSIG <fpr> <date> <timestamp> <expires> <version> <reserved> <pk-algo> <hash-algo> <class> <primary-fpr>
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[0] != "[GNUPG:]" || fields[1] != "VALIDSIG" {
+			continue
+		}
+		keys := []string{fields[2]}
+		if len(fields) >= 12 {
+			keys = append(keys, fields[11])
+		}
+		for _, key := range keys {
+			for _, signer := range signers {
+				if normalizeFingerprint(key) == signer {
+					return config.SignerPrefixGPG + signer, nil
+				}
+			}
+		}
+		return "", fmt.Errorf("%w: signed by untrusted key %s", ErrSignatureInvalid, fields[2])
+	}
+	return "", fmt.Errorf("%w: no valid GPG signature", ErrSignatureInvalid)
+}
+
+// verifyX509Signature verifies sig over data with the key of a PEM
+// certificate that is currently valid
+func verifyX509Signature(data, sig []byte, certPath string) error {
+	pemData, err := os.ReadFile(certPath)
+	if err != nil {
+		return err
+	}
+	block, _ := pem.Decode(pemData)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return fmt.Errorf("%s is not a PEM certificate", certPath)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return err
+	}
+	if now := time.Now(); now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return fmt.Errorf("certificate %s is not valid now", certPath)
+	}
+
+	digest := sha256.Sum256(data)
+	switch pub := cert.PublicKey.(type) {
+	case ed25519.PublicKey:
+		if !ed25519.Verify(pub, data, sig) {
+			return ErrSignatureInvalid
+		}
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, digest[:], sig) {
+			return ErrSignatureInvalid
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig); err != nil {
+			return ErrSignatureInvalid
+		}
+	default:
+		return fmt.Errorf("unsupported key type in %s", certPath)
+	}
+	return nil
+}
+
+// checkResumeSignature refuses to resume an app that requires a signature
+// unless the executable the process runs is signed by a trusted signer
+func (m *ProcessMonitor) checkResumeSignature(pid int, info ProcessInfo) error {
+	app := info.Target()
+	var blocked *config.BlockedApp
+	for i := range m.config.BlockedApps {
+		if m.config.BlockedApps[i].Path == app {
+			blocked = &m.config.BlockedApps[i]
+			break
+		}
+	}
+	if blocked == nil || !blocked.RequireSignature {
+		return nil
+	}
+
+	sigPath := blocked.SignaturePath
+	if sigPath == "" {
+		sigPath = blocked.Path + ".sig"
+	}
+
+	// Binaries are verified through the exe link so the file checked is the
+	// one running, even if the path was replaced
+	file := app
+	if info.Script == "" {
+		file = fmt.Sprintf("/proc/%d/exe", pid)
+	}
+
+	signer, err := VerifySignature(file, sigPath, m.config.Monitor.TrustedSigners, m.config.Monitor.SignatureKeyring)
+	if err != nil {
+		m.logger.Warnf("Refusing to resume %s (PID %d): %v", app, pid, err)
+		if logging.SecurityLog != nil {
+			logging.SecurityLog.LogProcessEvent(logging.EventSecurityViolation, app, pid, map[string]interface{}{
+				"reason":  "signature verification failed",
+				"error":   err.Error(),
+				"cmdline": m.eventCmdLine(pid, app),
+			})
+		}
+		if !errors.Is(err, ErrSignatureInvalid) {
+			err = fmt.Errorf("%w: %v", ErrSignatureInvalid, err)
+		}
+		return err
+	}
+
+	m.logger.Debugf("%s (PID %d) is signed by %s", app, pid, signer)
+	return nil
+}
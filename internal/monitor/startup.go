@@ -0,0 +1,145 @@
+package monitor
+
+import (
+	"os"
+	"strconv"
+
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/policy"
+)
+
+// scanRunning applies the startup action to protected apps that were
+// already running when the monitor started, which no exec event will
+// report:
+//   - register tracks them as unlocked instances, so instance limits, time
+//     budgets and the shutdown action cover them
+//   - suspend stops them without a dialog until the app is next unlocked
+//   - prompt handles them exactly like a new launch
+func (m *ProcessMonitor) scanRunning(action string) {
+	if action == "" {
+		action = config.StartupActionRegister
+	}
+
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		m.logger.Errorf("Failed to scan running processes: %v", err)
+		return
+	}
+
+	found := 0
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == os.Getpid() {
+			continue
+		}
+
+		app, ok := m.runningProtectedApp(pid)
+		if !ok {
+			continue
+		}
+		found++
+
+		switch action {
+		case config.StartupActionPrompt:
+			m.logger.Infof("Found running protected app %s (PID %d), locking it", app, pid)
+			if err := m.handleExecEvent(pid); err != nil {
+				m.logger.Warnf("Failed to lock running process %d: %v", pid, err)
+			}
+		case config.StartupActionSuspend:
+			m.holdStartupProcess(pid, app)
+		default:
+			m.logger.Infof("Found running protected app %s (PID %d), registering it", app, pid)
+			m.updateMonitoredProcessEnhanced(pid, app, true, "", 0)
+		}
+	}
+
+	m.logger.Infof("Startup scan found %d running protected processes (action: %s)", found, action)
+}
+
+// runningProtectedApp reports which protected app a running process is,
+// using the same policy as exec events but without hashing executables that
+// no hash entry needs
+func (m *ProcessMonitor) runningProtectedApp(pid int) (string, bool) {
+	m.monitoredMu.RLock()
+	_, tracked := m.monitoredProcesses[pid]
+	m.monitoredMu.RUnlock()
+	if tracked {
+		return "", false
+	}
+
+	command, err := m.getProcessExePath(pid)
+	if err != nil {
+		// Kernel threads have no executable
+		return "", false
+	}
+	if m.ignoresContainers() && detectContainer(pid, command) != "" {
+		return "", false
+	}
+	cmdLine, _ := m.getProcessCmdLine(pid)
+	parentPID, _ := m.getProcessParentPID(pid)
+
+	// Without a hash the executable is only hashed if a hash entry needs it
+	launch := m.launchFacts(pid, &ProcessInfo{
+		PID:       pid,
+		Command:   command,
+		CmdLine:   cmdLine,
+		ParentPID: parentPID,
+		Script:    resolveScriptPath(pid, command),
+	})
+	match, decision := m.policy.Match(&launch)
+	if decision != nil {
+		return decision.App, decision.Rule != "" && decision.Action == policy.ActionDeny
+	}
+	return match.App, match.App != ""
+}
+
+// holdStartupProcess suspends a running protected process found at startup
+// and keeps it locked until the app is next unlocked
+func (m *ProcessMonitor) holdStartupProcess(pid int, app string) {
+	if err := m.capturePidfd(pid, 0); err != nil {
+		return
+	}
+	if err := m.suspendProcess(pid); err != nil {
+		m.logger.Warnf("Failed to suspend running process %d (%s): %v", pid, app, err)
+		return
+	}
+	m.logger.Infof("Suspended running protected app %s (PID %d) until it is unlocked", app, pid)
+
+	m.updateMonitoredProcessEnhanced(pid, app, false, "", 0)
+	m.lockDescendants(pid)
+
+	m.startupMu.Lock()
+	m.startupHeld[pid] = app
+	m.startupMu.Unlock()
+}
+
+// releaseStartupHeld resumes the processes of app suspended at startup,
+// once the user has unlocked the app
+func (m *ProcessMonitor) releaseStartupHeld(app string) {
+	m.startupMu.Lock()
+	var pids []int
+	for pid, heldApp := range m.startupHeld {
+		if heldApp == app {
+			pids = append(pids, pid)
+			delete(m.startupHeld, pid)
+		}
+	}
+	m.startupMu.Unlock()
+
+	for _, pid := range pids {
+		m.logger.Infof("Resuming %s (PID %d) suspended at startup", app, pid)
+		if err := m.resumeProcess(pid); err != nil {
+			m.logger.Debugf("Failed to resume process %d: %v", pid, err)
+			continue
+		}
+		m.updateMonitoredProcessEnhanced(pid, app, true, "", 0)
+		m.resumeTree(pid)
+	}
+}
+
+// forgetStartupHeld drops an exited process
+func (m *ProcessMonitor) forgetStartupHeld(pid int) {
+	m.startupMu.Lock()
+	delete(m.startupHeld, pid)
+	m.startupMu.Unlock()
+}
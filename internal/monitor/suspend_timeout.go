@@ -0,0 +1,106 @@
+package monitor
+
+import (
+	"path/filepath"
+	"time"
+
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/logging"
+)
+
+// suspendCheckInterval is how often pending launches are checked against
+// the suspend timeout
+const suspendCheckInterval = time.Second
+
+// pendingLaunch is a launch suspended while the user is asked to
+// authenticate
+type pendingLaunch struct {
+	app   string
+	since time.Time
+}
+
+// markPending records that pid was suspended for authentication
+func (m *ProcessMonitor) markPending(pid int, app string) {
+	now := time.Now()
+	m.pendingMu.Lock()
+	m.pending[pid] = pendingLaunch{app: app, since: now}
+	m.pendingMu.Unlock()
+
+	m.recordSuspended(pid, app, now)
+}
+
+// clearPending drops pid once it is resumed, terminated or exits
+func (m *ProcessMonitor) clearPending(pid int) {
+	m.pendingMu.Lock()
+	delete(m.pending, pid)
+	m.pendingMu.Unlock()
+
+	m.forgetSuspended(pid)
+}
+
+// watchSuspendTimeout enforces the suspend timeout until the monitor stops
+func (m *ProcessMonitor) watchSuspendTimeout() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(suspendCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case now := <-ticker.C:
+			m.expirePending(now)
+		}
+	}
+}
+
+// expirePending applies the suspend timeout action to launches that have
+// waited longer than the timeout, so a prompt nobody answers (the user left,
+// the client crashed) does not leave the app suspended forever
+func (m *ProcessMonitor) expirePending(now time.Time) {
+	timeout := time.Duration(m.config.Monitor.SuspendTimeout) * time.Second
+	if timeout <= 0 {
+		return
+	}
+
+	m.pendingMu.Lock()
+	expired := make(map[int]pendingLaunch)
+	for pid, launch := range m.pending {
+		if now.Sub(launch.since) >= timeout {
+			expired[pid] = launch
+			delete(m.pending, pid)
+		}
+	}
+	m.pendingMu.Unlock()
+
+	for pid, launch := range expired {
+		// Launches that joined the prompt expire on their own
+		m.takePrompt(pid)
+
+		displayName := filepath.Base(launch.app)
+
+		if m.config.Monitor.SuspendTimeoutAction == config.SuspendTimeoutActionKeep {
+			// Held like apps found at startup: resumed when next unlocked
+			m.logger.Infof("%s (PID %d) was not unlocked within %s, keeping it suspended", displayName, pid, timeout)
+			if logging.SecurityLog != nil {
+				logging.SecurityLog.LogProcessEvent(logging.EventProcessBlocked, launch.app, pid, map[string]interface{}{
+					"reason": "authentication timed out",
+					"action": config.SuspendTimeoutActionKeep,
+				})
+			}
+			m.startupMu.Lock()
+			m.startupHeld[pid] = launch.app
+			m.startupMu.Unlock()
+			continue
+		}
+
+		m.reportDenial(pid, launch.app, displayName, "authentication timed out", map[string]interface{}{
+			"timeout_seconds": int(timeout.Seconds()),
+		})
+		if err := m.KillProcessTree(pid); err != nil {
+			m.logger.Errorf("Failed to terminate process %d: %v", pid, err)
+		}
+		m.releaseHandledPid(pid)
+	}
+}
@@ -0,0 +1,141 @@
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"wyrmlock/internal/logging"
+)
+
+// DefaultTerminateTimeout is how long a terminated process has to exit
+// before it is killed, when the config leaves it unset
+const DefaultTerminateTimeout = 5 * time.Second
+
+// killGrace is how long a killed process has to disappear before it is
+// reported as surviving; only a process stuck in the kernel takes longer
+const killGrace = 2 * time.Second
+
+// exitWatch waits for a terminated process to exit. It holds a pidfd when
+// the kernel has them, and otherwise polls /proc, using the start time to
+// tell a reused PID from the process.
+type exitWatch struct {
+	pid   int
+	fd    int
+	start int64
+}
+
+// watchExit starts watching pid; call it before signalling so the watch
+// refers to the process that was signalled
+func (m *ProcessMonitor) watchExit(pid int) *exitWatch {
+	start, _ := m.getProcessStartTime(pid)
+	fd := m.pidfds.dup(pid)
+	if fd < 0 {
+		if opened, err := unix.PidfdOpen(pid, 0); err == nil {
+			fd = opened
+		}
+	}
+	return &exitWatch{pid: pid, fd: fd, start: start}
+}
+
+// wait reports whether the process exited within timeout
+func (w *exitWatch) wait(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+
+	if w.fd >= 0 {
+		// A pidfd becomes readable when its process exits
+		fds := []unix.PollFd{{Fd: int32(w.fd), Events: unix.POLLIN}}
+		for {
+			remaining := time.Until(deadline)
+			if remaining < 0 {
+				remaining = 0
+			}
+			n, err := unix.Poll(fds, int(remaining.Milliseconds()))
+			if err == unix.EINTR {
+				continue
+			}
+			return err == nil && n > 0
+		}
+	}
+
+	for !w.exited() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return true
+}
+
+// exited checks /proc for the process, counting zombies as exited
+func (w *exitWatch) exited() bool {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", w.pid))
+	if err != nil {
+		return true
+	}
+
+	// Fields after the command name, which may contain spaces
+	stat := string(data)
+	fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+	if len(fields) < 20 || fields[0] == "Z" || fields[0] == "X" {
+		return true
+	}
+	return w.start != 0 && fields[19] != fmt.Sprint(w.start)
+}
+
+// kill sends SIGKILL to the watched process
+func (w *exitWatch) kill() error {
+	if w.fd >= 0 {
+		return unix.PidfdSendSignal(w.fd, unix.SIGKILL, nil, 0)
+	}
+	if w.exited() {
+		return nil
+	}
+	return syscall.Kill(w.pid, syscall.SIGKILL)
+}
+
+// close releases the watch's pidfd
+func (w *exitWatch) close() {
+	if w.fd >= 0 {
+		unix.Close(w.fd)
+		w.fd = -1
+	}
+}
+
+// terminateTimeout returns how long a terminated process has to exit
+// before it is killed
+func (m *ProcessMonitor) terminateTimeout() time.Duration {
+	if m.config.Monitor.TerminateTimeout <= 0 {
+		return DefaultTerminateTimeout
+	}
+	return time.Duration(m.config.Monitor.TerminateTimeout) * time.Second
+}
+
+// escalateTermination kills a process that has not exited timeout after
+// SIGTERM, and reports one that survives even SIGKILL
+func (m *ProcessMonitor) escalateTermination(w *exitWatch, timeout time.Duration) {
+	defer w.close()
+
+	if w.wait(timeout) {
+		return
+	}
+
+	m.logger.Warnf("Process %d did not exit within %s of SIGTERM, sending SIGKILL", w.pid, timeout)
+	if err := w.kill(); err != nil {
+		m.logger.Debugf("Failed to kill process %d: %v", w.pid, err)
+	}
+	if w.wait(killGrace) {
+		return
+	}
+
+	m.logger.Errorf("Process %d is still running after SIGKILL", w.pid)
+	if logging.SecurityLog != nil {
+		logging.SecurityLog.LogEvent(logging.EventSystemError, "Terminated process survived SIGKILL", map[string]interface{}{
+			"pid": w.pid,
+		})
+	}
+}
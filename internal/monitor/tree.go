@@ -0,0 +1,265 @@
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Fork event structure
+type forkProcEvent struct {
+	ParentPid  uint32
+	ParentTgid uint32
+	ChildPid   uint32
+	ChildTgid  uint32
+}
+
+// treeMember is a descendant of a monitored protected process. It shares the
+// root's state: stopped while the root is locked, allowed once the root is.
+type treeMember struct {
+	root int
+
+	// stopped is set when the monitor suspended the member and must resume
+	// it together with the root
+	stopped bool
+}
+
+// handleForkEvent adds a new child of a monitored process, or of one of its
+// descendants, to the root's tree. Children of a root that is still locked
+// are suspended at once so they cannot run while the user is prompted.
+// This runs for every fork on the system and only does map lookups unless
+// the parent belongs to a tree.
+func (m *ProcessMonitor) handleForkEvent(parentPID, childPID int) {
+	m.treeMu.Lock()
+	defer m.treeMu.Unlock()
+
+	root := parentPID
+	if member, ok := m.treeMembers[parentPID]; ok {
+		root = member.root
+	}
+
+	m.monitoredMu.RLock()
+	info, ok := m.monitoredProcesses[root]
+	m.monitoredMu.RUnlock()
+	if !ok {
+		return
+	}
+
+	member := &treeMember{root: root}
+	if !info.Allowed {
+		if err := m.capturePidfd(childPID, 0); err != nil {
+			return
+		}
+		if err := m.suspendDescendant(root, childPID); err != nil {
+			m.logger.Debugf("Failed to suspend child %d of locked process %d: %v", childPID, root, err)
+			return
+		}
+		member.stopped = true
+		m.logger.Debugf("Suspended child %d of locked process %d", childPID, root)
+	}
+	m.treeMembers[childPID] = member
+}
+
+// lockDescendants suspends descendants the root forked before it was itself
+// suspended, which the fork handler may have seen before the root was
+// tracked
+func (m *ProcessMonitor) lockDescendants(root int) {
+	m.treeMu.Lock()
+	defer m.treeMu.Unlock()
+
+	for _, pid := range processDescendants(root) {
+		if member, ok := m.treeMembers[pid]; ok && member.stopped {
+			continue
+		}
+		if err := m.capturePidfd(pid, 0); err != nil {
+			continue
+		}
+		if err := m.suspendDescendant(root, pid); err != nil {
+			m.logger.Debugf("Failed to suspend descendant %d of %d: %v", pid, root, err)
+			continue
+		}
+		m.treeMembers[pid] = &treeMember{root: root, stopped: true}
+	}
+}
+
+// resumeTree continues the descendants suspended along with root
+func (m *ProcessMonitor) resumeTree(root int) {
+	m.treeMu.Lock()
+	defer m.treeMu.Unlock()
+
+	for pid, member := range m.treeMembers {
+		if member.root != root || !member.stopped {
+			continue
+		}
+		if err := m.signalProcess(pid, syscall.SIGCONT); err != nil {
+			m.logger.Debugf("Failed to resume descendant %d of %d: %v", pid, root, err)
+		}
+		member.stopped = false
+	}
+}
+
+// terminateTree terminates the tracked descendants of root and forgets them
+func (m *ProcessMonitor) terminateTree(root int) {
+	m.treeMu.Lock()
+	defer m.treeMu.Unlock()
+
+	for pid, member := range m.treeMembers {
+		if member.root != root {
+			continue
+		}
+		if err := m.signalProcess(pid, syscall.SIGTERM); err != nil {
+			m.logger.Debugf("Failed to terminate descendant %d of %d: %v", pid, root, err)
+		}
+		// A stopped process only sees SIGTERM once continued
+		_ = m.signalProcess(pid, syscall.SIGCONT)
+		delete(m.treeMembers, pid)
+	}
+}
+
+// forgetTreeMember drops an exited process from the trees. When a root
+// exits, descendants of an unlocked root are released and keep running,
+// while those still suspended for a locked root are terminated: the launch
+// they belong to was never authorized.
+func (m *ProcessMonitor) forgetTreeMember(pid int) {
+	m.treeMu.Lock()
+	defer m.treeMu.Unlock()
+
+	delete(m.treeMembers, pid)
+	for child, member := range m.treeMembers {
+		if member.root != pid {
+			continue
+		}
+		if member.stopped {
+			_ = m.signalProcess(child, syscall.SIGKILL)
+		}
+		delete(m.treeMembers, child)
+	}
+}
+
+// inheritsAllowed reports whether pid descends from an allowed instance of
+// execPath, so re-executing the same app (helpers, content processes) does
+// not prompt again. Other protected apps started from the tree still do.
+func (m *ProcessMonitor) inheritsAllowed(pid int, execPath string) bool {
+	m.treeMu.Lock()
+	member, ok := m.treeMembers[pid]
+	m.treeMu.Unlock()
+	if !ok {
+		return false
+	}
+
+	m.monitoredMu.RLock()
+	defer m.monitoredMu.RUnlock()
+
+	root, ok := m.monitoredProcesses[member.root]
+	return ok && root.Allowed && root.Target() == execPath
+}
+
+// KillProcessTree ends a launch that failed authentication or was denied:
+// the process is terminated, and its descendants, plus its process group
+// when it leads one, are killed so already-forked workers cannot survive.
+func (m *ProcessMonitor) KillProcessTree(pid int) error {
+	// A locked process that exited may have handed its PID on; its
+	// descendants are then someone else's
+	if err := m.signalProcess(pid, 0); err != nil {
+		return fmt.Errorf("failed to terminate process %d: %w", pid, err)
+	}
+
+	// Freeze the descendants first; once the process is gone they are
+	// reparented and can no longer be found through it
+	descendants := m.freezeDescendants(pid)
+
+	if err := m.TerminateProcess(pid); err != nil {
+		return err
+	}
+
+	m.killDescendants(pid, descendants)
+	return nil
+}
+
+// freezeDescendants suspends every descendant of pid so none can fork
+// further while the tree is terminated, and returns them. The tree is walked
+// again after freezing to catch children forked during the first walk.
+func (m *ProcessMonitor) freezeDescendants(pid int) []int {
+	frozen := make(map[int]bool)
+	var descendants []int
+
+	for pass := 0; pass < 2; pass++ {
+		for _, child := range processDescendants(pid) {
+			if frozen[child] {
+				continue
+			}
+			frozen[child] = true
+			descendants = append(descendants, child)
+			_ = m.signalProcess(child, syscall.SIGSTOP)
+		}
+	}
+	return descendants
+}
+
+// killDescendants kills the frozen descendants of a terminated process and,
+// when the process led its own process group, the rest of that group, which
+// covers workers that were reparented before the tree was walked. The group
+// is only signalled when the process created it, never a shell's group.
+func (m *ProcessMonitor) killDescendants(pid int, descendants []int) {
+	for _, child := range descendants {
+		if err := m.signalProcess(child, syscall.SIGKILL); err != nil {
+			m.logger.Debugf("Failed to kill descendant %d of %d: %v", child, pid, err)
+		}
+	}
+	if len(descendants) > 0 {
+		m.logger.Infof("Killed %d descendants of process %d", len(descendants), pid)
+	}
+
+	pgid, err := syscall.Getpgid(pid)
+	if err != nil || pgid != pid || pgid == syscall.Getpgrp() {
+		return
+	}
+	if err := syscall.Kill(-pgid, syscall.SIGKILL); err != nil && err != syscall.ESRCH {
+		m.logger.Debugf("Failed to kill process group %d: %v", pgid, err)
+	}
+}
+
+// processDescendants returns every descendant of pid, read from the
+// children lists of its threads
+func processDescendants(pid int) []int {
+	var descendants []int
+	seen := map[int]bool{pid: true}
+
+	queue := []int{pid}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, child := range processChildren(current) {
+			if seen[child] {
+				continue
+			}
+			seen[child] = true
+			descendants = append(descendants, child)
+			queue = append(queue, child)
+		}
+	}
+	return descendants
+}
+
+// processChildren returns the direct children of pid
+func processChildren(pid int) []int {
+	files, _ := filepath.Glob(fmt.Sprintf("/proc/%d/task/*/children", pid))
+
+	var children []int
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		for _, field := range strings.Fields(string(data)) {
+			if child, err := strconv.Atoi(field); err == nil {
+				children = append(children, child)
+			}
+		}
+	}
+	return children
+}
@@ -0,0 +1,317 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/i18n"
+)
+
+// usageTickInterval is how often running protected apps are charged against
+// their daily time budget
+const usageTickInterval = 15 * time.Second
+
+// UsageWarningHandler is a callback for apps about to run out of their daily
+// time budget
+type UsageWarningHandler func(execPath string, displayName string, remaining time.Duration)
+
+// usageTracker keeps per-day usage on disk so restarting the daemon does not
+// hand out a fresh time budget
+type usageTracker struct {
+	mu      sync.Mutex
+	path    string
+	Day     string           `json:"day"`
+	Seconds map[string]int64 `json:"seconds"`
+	Warned  map[string]bool  `json:"warned,omitempty"`
+}
+
+// loadUsageTracker reads today's usage from path; a missing or unreadable
+// file starts from zero
+func loadUsageTracker(path string) *usageTracker {
+	u := &usageTracker{path: path, Seconds: make(map[string]int64), Warned: make(map[string]bool)}
+	if path == "" {
+		return u
+	}
+
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, u); err != nil || u.Seconds == nil {
+			u.Day, u.Seconds = "", make(map[string]int64)
+		}
+	}
+	if u.Warned == nil {
+		u.Warned = make(map[string]bool)
+	}
+	return u
+}
+
+// rollover resets usage when the day has changed and reports whether it did.
+// Caller holds mu.
+func (u *usageTracker) rollover(now time.Time) bool {
+	day := quotaDay(now)
+	if u.Day == day {
+		return false
+	}
+	u.Day = day
+	u.Seconds = make(map[string]int64)
+	u.Warned = make(map[string]bool)
+	return true
+}
+
+// save writes usage to disk atomically. Caller holds mu.
+func (u *usageTracker) save() error {
+	if u.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(u.path), 0755); err != nil {
+		return fmt.Errorf("failed to create usage directory: %w", err)
+	}
+
+	data, err := json.Marshal(u)
+	if err != nil {
+		return fmt.Errorf("failed to encode usage: %w", err)
+	}
+
+	tmp := u.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write usage: %w", err)
+	}
+	return os.Rename(tmp, u.path)
+}
+
+// used returns today's usage of app
+func (u *usageTracker) used(app string, now time.Time) time.Duration {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.rollover(now)
+	return time.Duration(u.Seconds[app]) * time.Second
+}
+
+// dailyBudget returns the daily time budget of execPath, or 0 when the app
+// has no screen-time limit
+func (m *ProcessMonitor) dailyBudget(execPath string) time.Duration {
+	for _, app := range m.config.BlockedApps {
+		if app.Path == execPath && app.DailyMinutes > 0 {
+			return time.Duration(app.DailyMinutes) * time.Minute
+		}
+	}
+	return 0
+}
+
+// limitAction returns what happens to execPath once its budget is used up
+func (m *ProcessMonitor) limitAction(execPath string) string {
+	for _, app := range m.config.BlockedApps {
+		if app.Path == execPath && app.LimitAction != "" {
+			return app.LimitAction
+		}
+	}
+	return config.LimitActionSuspend
+}
+
+// usageWarning returns how long before the cutoff the user is warned
+func (m *ProcessMonitor) usageWarning() time.Duration {
+	if m.config.Monitor.UsageWarning > 0 {
+		return time.Duration(m.config.Monitor.UsageWarning) * time.Minute
+	}
+	return 0
+}
+
+// usageExhausted reports whether execPath has used up today's time budget
+func (m *ProcessMonitor) usageExhausted(execPath string, now time.Time) bool {
+	budget := m.dailyBudget(execPath)
+	return budget > 0 && m.usage.used(execPath, now) >= budget
+}
+
+// RegisterUsageWarningHandler registers a callback for apps about to reach
+// their daily time budget
+func (m *ProcessMonitor) RegisterUsageWarningHandler(handler UsageWarningHandler) {
+	m.eventHandlerMu.Lock()
+	m.usageWarningHandler = handler
+	m.eventHandlerMu.Unlock()
+}
+
+// hasUsageLimits reports whether any blocked app has a daily time budget
+func (m *ProcessMonitor) hasUsageLimits() bool {
+	for _, app := range m.config.BlockedApps {
+		if app.DailyMinutes > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// trackUsage charges running protected apps against their daily budget until
+// the monitor stops
+func (m *ProcessMonitor) trackUsage() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(usageTickInterval)
+	defer ticker.Stop()
+
+	last := time.Now()
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case now := <-ticker.C:
+			m.chargeUsage(now.Sub(last), now)
+			last = now
+		}
+	}
+}
+
+// chargeUsage adds elapsed to every app with a running allowed instance,
+// warns apps nearing their budget and enforces exhausted budgets. Several
+// instances of one app are charged once.
+func (m *ProcessMonitor) chargeUsage(elapsed time.Duration, now time.Time) {
+	running := make(map[string][]int)
+	m.monitoredMu.RLock()
+	for pid, info := range m.monitoredProcesses {
+		if info.Allowed && info.State != ProcessStateSuspended {
+			running[info.Target()] = append(running[info.Target()], pid)
+		}
+	}
+	m.monitoredMu.RUnlock()
+
+	m.usage.mu.Lock()
+	if m.usage.rollover(now) {
+		m.usage.mu.Unlock()
+		m.resumeUsageSuspended()
+		m.usage.mu.Lock()
+	}
+
+	var warn, exhausted []string
+	for app := range running {
+		budget := m.dailyBudget(app)
+		if budget == 0 {
+			continue
+		}
+
+		m.usage.Seconds[app] += int64(elapsed.Round(time.Second) / time.Second)
+		used := time.Duration(m.usage.Seconds[app]) * time.Second
+
+		switch {
+		case used >= budget:
+			exhausted = append(exhausted, app)
+		case budget-used <= m.usageWarning() && !m.usage.Warned[app]:
+			m.usage.Warned[app] = true
+			warn = append(warn, app)
+		}
+	}
+	if err := m.usage.save(); err != nil {
+		m.logger.Warnf("Failed to persist usage: %v", err)
+	}
+	m.usage.mu.Unlock()
+
+	for _, app := range warn {
+		m.warnUsage(app, m.dailyBudget(app)-m.usage.used(app, now))
+	}
+	for _, app := range exhausted {
+		m.enforceUsageLimit(app, running[app])
+	}
+}
+
+// warnUsage tells the user an app is about to reach its daily budget
+func (m *ProcessMonitor) warnUsage(execPath string, remaining time.Duration) {
+	displayName := filepath.Base(execPath)
+	m.logger.Infof("%s has %s of its daily time left", displayName, remaining.Round(time.Second))
+
+	m.eventHandlerMu.RLock()
+	handler := m.usageWarningHandler
+	m.eventHandlerMu.RUnlock()
+
+	if handler != nil {
+		go handler(execPath, displayName, remaining)
+		return
+	}
+
+	if m.guiManager != nil {
+		if err := m.guiManager.ShowNotification(i18n.T("Time almost up"), UsageWarningMessage(displayName, remaining)); err != nil {
+			m.logger.Debugf("Failed to show usage warning: %v", err)
+		}
+	}
+}
+
+// UsageWarningMessage describes an upcoming screen-time cutoff for notifications
+func UsageWarningMessage(displayName string, remaining time.Duration) string {
+	return i18n.T("%s will be stopped in %s: daily time limit almost reached.",
+		displayName, remaining.Round(time.Minute))
+}
+
+// enforceUsageLimit suspends or terminates the running instances of an app
+// that has used up its daily budget
+func (m *ProcessMonitor) enforceUsageLimit(execPath string, pids []int) {
+	displayName := filepath.Base(execPath)
+	action := m.limitAction(execPath)
+
+	for _, pid := range pids {
+		m.reportDenial(pid, execPath, displayName, "daily time limit reached", map[string]interface{}{
+			"limit_action": action,
+		})
+
+		if action == config.LimitActionTerminate {
+			if err := m.TerminateProcess(pid); err != nil {
+				m.logger.Errorf("Failed to terminate process %d: %v", pid, err)
+			}
+			continue
+		}
+
+		m.logger.Infof("Suspending process %d (%s): daily time limit reached", pid, execPath)
+		if err := m.suspendProcess(pid); err != nil {
+			m.logger.Errorf("Failed to stop process %d: %v", pid, err)
+			continue
+		}
+		m.monitoredMu.Lock()
+		if info, ok := m.monitoredProcesses[pid]; ok {
+			info.State = ProcessStateSuspended
+			m.monitoredProcesses[pid] = info
+		}
+		m.monitoredMu.Unlock()
+	}
+}
+
+// resumeUsageSuspended continues processes suspended by an exhausted budget
+// once a new day has started
+func (m *ProcessMonitor) resumeUsageSuspended() {
+	m.monitoredMu.Lock()
+	defer m.monitoredMu.Unlock()
+
+	for pid, info := range m.monitoredProcesses {
+		if !info.Allowed || info.State != ProcessStateSuspended || m.dailyBudget(info.Target()) == 0 {
+			continue
+		}
+		m.logger.Infof("Resuming process %d (%s): new daily time budget", pid, info.Target())
+		if err := m.resumeProcess(pid); err != nil {
+			m.logger.Warnf("Failed to resume process %d: %v", pid, err)
+			continue
+		}
+		info.State = ProcessStateRunning
+		m.monitoredProcesses[pid] = info
+	}
+}
+
+// UsageQuotas returns today's usage of every configured daily time budget,
+// in minutes
+func (m *ProcessMonitor) UsageQuotas() []LaunchQuotaUsage {
+	now := time.Now()
+	usage := make([]LaunchQuotaUsage, 0)
+
+	for _, app := range m.config.BlockedApps {
+		if app.DailyMinutes > 0 {
+			usage = append(usage, LaunchQuotaUsage{
+				App:   app.Path,
+				Used:  int(m.usage.used(app.Path, now) / time.Minute),
+				Limit: app.DailyMinutes,
+			})
+		}
+	}
+
+	sort.Slice(usage, func(i, j int) bool { return usage[i].App < usage[j].App })
+	return usage
+}
@@ -0,0 +1,127 @@
+package monitor
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Exec event pool defaults, used when the config leaves them unset
+const (
+	DefaultExecWorkers   = 8
+	DefaultExecQueueSize = 1024
+)
+
+// EventQueueStats describes the exec event queue
+type EventQueueStats struct {
+	Workers   int
+	Capacity  int
+	Depth     int
+	Processed uint64
+	Dropped   uint64
+}
+
+// execQueue hands exec events from the event loop to a fixed set of
+// workers. When the queue is full, events are dropped instead of spawning
+// goroutines without bound, and processes started while dropping are
+// rescanned once the queue drains.
+type execQueue struct {
+	events    chan int
+	workers   int
+	processed atomic.Uint64
+	dropped   atomic.Uint64
+
+	// dropSince is when the current run of drops began, zero when nothing
+	// was dropped since the last rescan
+	dropSince time.Time
+	dropMu    sync.Mutex
+}
+
+// newExecQueue creates the queue with the configured sizes
+func newExecQueue(workers, size int) *execQueue {
+	if workers <= 0 {
+		workers = DefaultExecWorkers
+	}
+	if size <= 0 {
+		size = DefaultExecQueueSize
+	}
+	return &execQueue{events: make(chan int, size), workers: workers}
+}
+
+// startExecWorkers starts the workers. They exit once they see stopCh
+// closed, and Stop waits for the evaluations still running.
+func (m *ProcessMonitor) startExecWorkers() {
+	for i := 0; i < m.execQueue.workers; i++ {
+		m.wg.Add(1)
+		go m.execWorker()
+	}
+}
+
+// enqueueExec queues an exec event without blocking the event loop
+func (m *ProcessMonitor) enqueueExec(pid int) {
+	q := m.execQueue
+	select {
+	case q.events <- pid:
+		return
+	default:
+	}
+
+	q.dropped.Add(1)
+
+	q.dropMu.Lock()
+	defer q.dropMu.Unlock()
+	if q.dropSince.IsZero() {
+		q.dropSince = time.Now()
+		m.logger.Warnf("Exec event queue full (%d events); dropping events until it drains", cap(q.events))
+	}
+}
+
+// execWorker handles queued exec events until the monitor stops
+func (m *ProcessMonitor) execWorker() {
+	defer m.wg.Done()
+
+	q := m.execQueue
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case pid := <-q.events:
+			m.handleExecEvent(pid)
+			q.processed.Add(1)
+
+			if len(q.events) == 0 {
+				m.rescanDropped()
+			}
+		}
+	}
+}
+
+// rescanDropped evaluates processes started while events were dropped
+func (m *ProcessMonitor) rescanDropped() {
+	q := m.execQueue
+
+	q.dropMu.Lock()
+	since := q.dropSince
+	q.dropSince = time.Time{}
+	q.dropMu.Unlock()
+
+	if since.IsZero() {
+		return
+	}
+
+	m.logger.Warnf("Exec event queue drained after dropping events; rescanning processes started since %s",
+		since.Format("15:04:05.000"))
+	m.rescanProcesses(since.Add(-rescanGrace))
+}
+
+// EventQueueStats returns the current state of the exec event queue
+func (m *ProcessMonitor) EventQueueStats() EventQueueStats {
+	q := m.execQueue
+	return EventQueueStats{
+		Workers:   q.workers,
+		Capacity:  cap(q.events),
+		Depth:     len(q.events),
+		Processed: q.processed.Load(),
+		Dropped:   q.dropped.Load(),
+	}
+}
@@ -0,0 +1,89 @@
+package policy
+
+import (
+	"os"
+	"strings"
+
+	"wyrmlock/internal/config"
+)
+
+// allowlistIndex answers "may this executable run?" in deny-by-default mode
+// with map lookups for paths and hashes and a short list of directories
+type allowlistIndex struct {
+	paths  map[string]struct{}
+	hashes map[string]struct{}
+	dirs   []string
+}
+
+// buildAllowlistIndex indexes the allowlist and the system directory
+// carve-outs, or returns nil when allowlist mode is off. The running
+// program's own executable is always allowed, so the daemon cannot lock
+// itself out.
+func buildAllowlistIndex(cfg *config.Config) *allowlistIndex {
+	if !cfg.Monitor.AllowlistEnabled() {
+		return nil
+	}
+
+	index := &allowlistIndex{
+		paths:  make(map[string]struct{}),
+		hashes: make(map[string]struct{}),
+	}
+
+	entries := append([]string(nil), cfg.Monitor.Allowlist...)
+	for _, dir := range cfg.Monitor.AllowlistSystemDirs {
+		if !strings.HasSuffix(dir, "/") {
+			dir += "/"
+		}
+		entries = append(entries, dir)
+	}
+	if self, err := os.Executable(); err == nil {
+		entries = append(entries, self)
+	}
+
+	for _, entry := range entries {
+		if hash, ok := config.ParseHashEntry(entry); ok {
+			index.hashes[hash] = struct{}{}
+			continue
+		}
+		if !config.IsPathEntry(entry) {
+			continue
+		}
+		if isDirEntry(entry) {
+			index.dirs = append(index.dirs, resolveDir(entry))
+			continue
+		}
+		index.paths[resolveDir(entry)] = struct{}{}
+	}
+
+	return index
+}
+
+// allows reports whether the executable of a launch is allowlisted by path,
+// directory or hash
+func (a *allowlistIndex) allows(l *Launch) bool {
+	resolved := resolveExec(l.Executable)
+	if _, ok := a.paths[resolved]; ok {
+		return true
+	}
+	for _, dir := range a.dirs {
+		if isWithinDir(resolved, dir) {
+			return true
+		}
+	}
+	if len(a.hashes) == 0 {
+		return false
+	}
+	hash := l.hash(l.Executable)
+	_, ok := a.hashes[hash]
+	return ok && hash != ""
+}
+
+// checkAllowlist returns the action for a launch that matched no protection:
+// empty when it may run, otherwise the allowlist mode (lock or deny).
+// Launches by root are system services and always carved out.
+func (e *Engine) checkAllowlist(l *Launch) string {
+	if e.allowlist == nil || l.UID == 0 || e.allowlist.allows(l) {
+		return ""
+	}
+	return e.cfg.Monitor.AllowlistMode
+}
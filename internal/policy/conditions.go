@@ -0,0 +1,217 @@
+package policy
+
+import (
+	"fmt"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"wyrmlock/internal/config"
+)
+
+// regexRule is a precompiled config.RegexRule
+type regexRule struct {
+	name    string
+	path    *regexp.Regexp
+	cmdline *regexp.Regexp
+	action  string
+	creds   *credentialCondition
+}
+
+// compileRegexRules precompiles the configured regex rules so matching a
+// launch does not pay compilation cost
+func compileRegexRules(rules []config.RegexRule) ([]regexRule, error) {
+	compiled := make([]regexRule, 0, len(rules))
+
+	for i, rule := range rules {
+		r := regexRule{
+			name:   rule.Name,
+			action: rule.Action,
+			creds:  newCredentialCondition(rule.Users, rule.Groups),
+		}
+		if r.name == "" {
+			r.name = fmt.Sprintf("rule-%d", i)
+		}
+		if r.action == "" {
+			r.action = config.RuleActionLock
+		}
+
+		if rule.PathPattern != "" {
+			re, err := regexp.Compile(rule.PathPattern)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: invalid path pattern: %w", r.name, err)
+			}
+			r.path = re
+		}
+
+		if rule.CmdlinePattern != "" {
+			re, err := regexp.Compile(rule.CmdlinePattern)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: invalid cmdline pattern: %w", r.name, err)
+			}
+			r.cmdline = re
+		}
+
+		if r.path == nil && r.cmdline == nil {
+			return nil, fmt.Errorf("rule %s: no pattern specified", r.name)
+		}
+
+		compiled = append(compiled, r)
+	}
+
+	return compiled, nil
+}
+
+// matches reports whether the rule matches a launch, including its user and
+// group conditions
+func (r *regexRule) matches(l *Launch) bool {
+	if r.path != nil && !r.path.MatchString(l.Executable) {
+		return false
+	}
+	if r.cmdline != nil && !r.cmdline.MatchString(l.CmdLine) {
+		return false
+	}
+	return r.creds.matches(l.UID, l.GID, l.Groups)
+}
+
+// credentialCondition restricts a rule to launches by certain users or
+// members of certain groups. Names are resolved when evaluated so accounts
+// created after startup are picked up.
+type credentialCondition struct {
+	users  []string
+	groups []string
+}
+
+// newCredentialCondition returns nil when the rule applies to everyone
+func newCredentialCondition(users, groups []string) *credentialCondition {
+	if len(users) == 0 && len(groups) == 0 {
+		return nil
+	}
+	return &credentialCondition{users: users, groups: groups}
+}
+
+// matches reports whether a launch with the given real credentials is by
+// one of the listed users or by a member of one of the listed groups. A nil
+// condition matches everyone, and so do unknown credentials (a negative
+// UID) so the app stays locked. If none of the configured names resolve,
+// the condition matches as well so a typo locks the app for everyone rather
+// than no one.
+func (c *credentialCondition) matches(uid, gid int, groups []int) bool {
+	if c == nil || uid < 0 {
+		return true
+	}
+
+	uids := resolveIDs(c.users, lookupUID)
+	gids := resolveIDs(c.groups, lookupGID)
+	if len(uids) == 0 && len(gids) == 0 {
+		return true
+	}
+
+	if uids[uid] || gids[gid] {
+		return true
+	}
+	for _, g := range groups {
+		if gids[g] {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveIDs maps user or group names (or numeric IDs) to IDs, skipping
+// names that do not exist
+func resolveIDs(names []string, lookup func(string) (int, error)) map[int]bool {
+	ids := make(map[int]bool, len(names))
+	for _, name := range names {
+		if id, err := strconv.Atoi(name); err == nil {
+			ids[id] = true
+			continue
+		}
+		if id, err := lookup(name); err == nil {
+			ids[id] = true
+		}
+	}
+	return ids
+}
+
+func lookupUID(name string) (int, error) {
+	u, err := user.Lookup(name)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(u.Uid)
+}
+
+func lookupGID(name string) (int, error) {
+	g, err := user.LookupGroup(name)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(g.Gid)
+}
+
+// contextCondition restricts an app to launches from certain working
+// directories or with certain environment variables
+type contextCondition struct {
+	dirs []string
+	env  []string
+}
+
+// newContextCondition returns nil when the app is locked in every context
+func newContextCondition(dirs, env []string) *contextCondition {
+	if len(dirs) == 0 && len(env) == 0 {
+		return nil
+	}
+
+	cleaned := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		cleaned = append(cleaned, filepath.Clean(dir))
+	}
+	return &contextCondition{dirs: cleaned, env: env}
+}
+
+// matches reports whether a launch is covered. A nil condition matches
+// every launch, and an unknown working directory or environment is treated
+// as a match so the app stays locked.
+func (c *contextCondition) matches(l *Launch) bool {
+	if c == nil {
+		return true
+	}
+	if len(c.dirs) > 0 && l.Cwd != "" && !c.matchesDir(l.Cwd) {
+		return false
+	}
+	if len(c.env) == 0 {
+		return true
+	}
+	environ, ok := l.environ()
+	return !ok || c.matchesEnv(environ)
+}
+
+// matchesDir reports whether cwd is one of the directories or below one
+func (c *contextCondition) matchesDir(cwd string) bool {
+	for _, dir := range c.dirs {
+		if cwd == dir || strings.HasPrefix(cwd, strings.TrimSuffix(dir, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesEnv reports whether every environment condition holds
+func (c *contextCondition) matchesEnv(environ []string) bool {
+	vars := make(map[string]string, len(environ))
+	for _, entry := range environ {
+		name, value, _ := strings.Cut(entry, "=")
+		vars[name] = value
+	}
+	for _, cond := range c.env {
+		name, want, hasValue := strings.Cut(cond, "=")
+		value, set := vars[name]
+		if !set || (hasValue && value != want) {
+			return false
+		}
+	}
+	return true
+}
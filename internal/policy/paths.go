@@ -0,0 +1,152 @@
+package policy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Kinds of anonymous execution
+const (
+	// AnonymousMemfd is an executable run from a memfd_create file
+	AnonymousMemfd = "memfd"
+
+	// AnonymousTmpfile is an executable run from an O_TMPFILE file that was
+	// never linked into the filesystem
+	AnonymousTmpfile = "tmpfile"
+
+	// AnonymousFd is an executable run through a /dev/fd or /proc fd path
+	AnonymousFd = "fd"
+)
+
+// AnonymousExecWarning is shown with the prompt for an anonymous execution
+const AnonymousExecWarning = "runs from memory, not a file on disk"
+
+// DeletedSuffix is appended by the kernel to the exe link of a process whose
+// executable was unlinked
+const DeletedSuffix = " (deleted)"
+
+// tmpfileName is how the kernel names an unlinked O_TMPFILE file
+var tmpfileName = regexp.MustCompile(`^#\d+` + regexp.QuoteMeta(DeletedSuffix) + `$`)
+
+// AnonymousKind classifies the exe link target of a process: how it was
+// executed without an on-disk file, or "" for a regular file
+func AnonymousKind(exePath string) string {
+	switch {
+	case strings.HasPrefix(exePath, "/memfd:"):
+		return AnonymousMemfd
+	case tmpfileName.MatchString(filepath.Base(exePath)):
+		return AnonymousTmpfile
+	case strings.HasPrefix(exePath, "/dev/fd/"), strings.HasPrefix(exePath, "/proc/"):
+		return AnonymousFd
+	}
+	return ""
+}
+
+// HashFile returns the hex SHA-256 of a file, as used by "sha256:" entries
+func HashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// cleanPath returns the cleaned absolute form of path
+func cleanPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return filepath.Clean(path)
+}
+
+// resolveDir returns the cleaned, symlink-resolved absolute form of a
+// directory or file. Paths that do not exist yet are kept in cleaned form.
+func resolveDir(dir string) string {
+	abs := cleanPath(dir)
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return filepath.Clean(resolved)
+	}
+	return abs
+}
+
+// resolveExec returns the symlink-resolved form of an executable path as
+// reported by the kernel
+func resolveExec(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return filepath.Clean(path)
+}
+
+// isWithinDir reports whether path is strictly beneath dir, respecting path
+// component boundaries (/opt/games does not contain /opt/gamesx/bin)
+func isWithinDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != "." && rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
+// isDirEntry reports whether a path entry names a directory: it ends in "/"
+// or names an existing directory
+func isDirEntry(entry string) bool {
+	if strings.HasSuffix(entry, "/") {
+		return true
+	}
+	info, err := os.Stat(entry)
+	return err == nil && info.IsDir()
+}
+
+// untrustedDirFor returns the untrusted directory containing path: one of
+// the configured entries, with "~/" expanded to the home of the launching
+// user, or any world-writable directory above it
+func untrustedDirFor(path string, dirs []string, home string) (string, bool) {
+	resolved := resolveExec(path)
+
+	for _, entry := range dirs {
+		if strings.HasPrefix(entry, "~/") {
+			if home == "" {
+				continue
+			}
+			entry = filepath.Join(home, entry[2:])
+		}
+		if dir := resolveDir(entry); isWithinDir(resolved, dir) {
+			return dir, true
+		}
+	}
+
+	// Anyone can drop a program into a world-writable directory
+	for dir := filepath.Dir(resolved); dir != "/" && dir != "."; dir = filepath.Dir(dir) {
+		if info, err := os.Stat(dir); err == nil && info.Mode().Perm()&0002 != 0 {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+// homeDir returns the home directory of the user with uid, or "" when it is
+// unknown
+func homeDir(uid int) string {
+	if uid < 0 {
+		return ""
+	}
+	u, err := user.LookupId(strconv.Itoa(uid))
+	if err != nil {
+		return ""
+	}
+	return u.HomeDir
+}
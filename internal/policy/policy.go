@@ -0,0 +1,645 @@
+// Package policy decides what happens to a launch under a wyrmlock
+// configuration: whether it runs, is locked until the user authenticates,
+// is refused, or runs and is logged. The daemon's process monitor and the
+// embeddable pkg/applock engine both decide launches here.
+//
+// The engine only sees the facts it is given in a Launch. Reading them from
+// /proc, and acting on the decision, is up to the caller.
+package policy
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"wyrmlock/internal/config"
+)
+
+// Action is the outcome of a launch decision
+type Action string
+
+const (
+	// ActionAllow lets the launch run without authentication
+	ActionAllow Action = "allow"
+
+	// ActionLock holds the launch until the user authenticates
+	ActionLock Action = "lock"
+
+	// ActionDeny refuses the launch without prompting
+	ActionDeny Action = "deny"
+
+	// ActionLog lets the launch run without authentication and records it
+	ActionLog Action = "log"
+)
+
+// Reasons of decisions that callers handle beyond their action
+const (
+	// ReasonNotForUser allows a protected app whose user and group
+	// conditions do not cover the launching user
+	ReasonNotForUser = "not locked for this user"
+
+	// ReasonOutsideContext allows a protected app started outside the
+	// directories or environment it is locked in
+	ReasonOutsideContext = "not locked in this context"
+
+	// ReasonPolicyDeny denies a protected app whose policy action is deny.
+	// Such launches are terminated without a dialog or notification.
+	ReasonPolicyDeny = "denied by policy"
+
+	// ReasonLaunchQuota allows a protected app from its daily launch quota
+	ReasonLaunchQuota = "free launch from the daily quota"
+
+	// ReasonProtected locks a protected app
+	ReasonProtected = "protected app"
+)
+
+// Launch describes a program about to run. Facts that are costly to gather
+// may be left to the Lookup functions, which are only called when a policy
+// needs them.
+type Launch struct {
+	// Executable is the absolute path of the binary being executed, as the
+	// kernel reports it
+	Executable string
+
+	// Script is the script path when Executable is an interpreter
+	Script string
+
+	// CmdLine is the command line, arguments joined by spaces
+	CmdLine string
+
+	// Hash is the hex SHA-256 of the executable. When empty and hash
+	// entries are configured, the file is hashed on demand.
+	Hash string
+
+	// Sandbox is the "flatpak:<app-id>" or "snap:<name>" identity of a
+	// sandboxed application, if any
+	Sandbox string
+
+	// UID, GID and Groups are the real credentials of the launching user. A
+	// negative UID means they are unknown, which user and group conditions
+	// treat as a match so the app stays locked.
+	UID    int
+	GID    int
+	Groups []int
+
+	// PID and ParentPID identify the process and the one that started it,
+	// for callers that track running instances
+	PID       int
+	ParentPID int
+
+	// Ancestors are the executables of the processes that started the
+	// launch, nearest first. They are only consulted for trusted parents.
+	Ancestors []string
+
+	// Cwd and Env are the working directory and "NAME=value" environment
+	// of the launch. They are only consulted by apps locked in certain
+	// directories or environments; an empty Cwd is treated as a match.
+	Cwd string
+	Env []string
+
+	// Removable is the mount point of the removable media the launch runs
+	// from, if any
+	Removable string
+
+	// Replaced explains why the running executable no longer matches the
+	// file at its path, if it does not
+	Replaced string
+
+	// Time is when the launch happens; zero means now
+	Time time.Time
+
+	// HashPath hashes a path of the launch other than by reading it
+	// directly, returning "" when it cannot be read
+	HashPath func(path string) string
+
+	// LookupSandbox, LookupAncestors and LookupEnv supply Sandbox,
+	// Ancestors and Env when those are left empty. An error from LookupEnv
+	// is treated as a match of environment conditions.
+	LookupSandbox   func() string
+	LookupAncestors func() []string
+	LookupEnv       func() ([]string, error)
+}
+
+// Target returns the script for interpreters, otherwise the executable
+func (l *Launch) Target() string {
+	if l.Script != "" {
+		return l.Script
+	}
+	return l.Executable
+}
+
+// time returns when the launch happens
+func (l *Launch) time() time.Time {
+	if l.Time.IsZero() {
+		return time.Now()
+	}
+	return l.Time
+}
+
+// hash returns the SHA-256 of path, "" when it cannot be read
+func (l *Launch) hash(path string) string {
+	if path == l.Executable && l.Hash != "" {
+		return strings.ToLower(l.Hash)
+	}
+	if l.HashPath != nil {
+		return l.HashPath(path)
+	}
+	hash, _ := HashFile(path)
+	return hash
+}
+
+// sandbox returns the sandbox identity of the launch
+func (l *Launch) sandbox() string {
+	if l.Sandbox == "" && l.LookupSandbox != nil {
+		l.Sandbox = l.LookupSandbox()
+	}
+	return l.Sandbox
+}
+
+// ancestors returns the executables of the launch's ancestors
+func (l *Launch) ancestors() []string {
+	if l.Ancestors == nil && l.LookupAncestors != nil {
+		l.Ancestors = l.LookupAncestors()
+	}
+	return l.Ancestors
+}
+
+// environ returns the environment of the launch, reporting false when it is
+// unknown
+func (l *Launch) environ() ([]string, bool) {
+	if l.Env == nil && l.LookupEnv != nil {
+		env, err := l.LookupEnv()
+		if err != nil {
+			return nil, false
+		}
+		l.Env = env
+	}
+	return l.Env, true
+}
+
+// Decision is the result of evaluating a launch
+type Decision struct {
+	Action Action
+
+	// App is what the launch is locked, denied or allowed as: the protected
+	// app it matched, or its executable when it is locked for how it was
+	// started. Empty when the launch is not protected.
+	App string
+
+	// Rule names the regex rule that matched, if any
+	Rule string
+
+	// Reason describes the decision for logs and notifications
+	Reason string
+
+	// Warning is shown with the prompt of a suspicious launch
+	Warning string
+
+	// UntrustedDir is the untrusted directory the launch runs from, once
+	// the untrusted directory policy has been applied
+	UntrustedDir string
+}
+
+// Match is the protection a launch falls under
+type Match struct {
+	// App is the protected app, empty when the launch matched no entry
+	App string
+
+	// Target is the executable or script that matched
+	Target string
+
+	// Rule names the lock rule that matched, if any
+	Rule string
+}
+
+// State answers questions about running processes and today's usage, for
+// policies that depend on them. Engines used without a State never reach
+// instance limits, time budgets or launch quotas.
+type State interface {
+	// RunningInstances counts the allowed instances of app other than the
+	// launch itself
+	RunningInstances(l *Launch, app string) int
+
+	// InstanceChild reports whether the launch was started by an allowed
+	// instance of app and so belongs to it
+	InstanceChild(l *Launch, app string) bool
+
+	// UsageExhausted reports whether app has used up its daily time
+	// budget at t
+	UsageExhausted(app string, t time.Time) bool
+
+	// ConsumeLaunch uses one of the limit free launches of app on the day
+	// of t, reporting false when none are left
+	ConsumeLaunch(app string, limit int, t time.Time) bool
+}
+
+// Engine evaluates launches against a configuration. It is safe for
+// concurrent use.
+type Engine struct {
+	cfg   *config.Config
+	rules []regexRule
+
+	// Plain paths, resolved directories, hashes and sandbox IDs of
+	// protected executables, the latter two mapped to the entry that
+	// declared them
+	paths     map[string]bool
+	dirs      []string
+	hashes    map[string]string
+	sandboxes map[string]string
+
+	// Per-app conditions and settings, keyed by blocked app path
+	credentials map[string]*credentialCondition
+	contexts    map[string]*contextCondition
+	apps        map[string]*config.BlockedApp
+
+	// Executables allowed to run in deny-by-default mode, nil when off
+	allowlist *allowlistIndex
+}
+
+// NewEngine prepares an engine for cfg, compiling its rules
+func NewEngine(cfg *config.Config) (*Engine, error) {
+	rules, err := compileRegexRules(cfg.Monitor.RegexRules)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Engine{
+		cfg:         cfg,
+		rules:       rules,
+		paths:       make(map[string]bool),
+		hashes:      make(map[string]string),
+		sandboxes:   make(map[string]string),
+		credentials: make(map[string]*credentialCondition),
+		contexts:    make(map[string]*contextCondition),
+		apps:        make(map[string]*config.BlockedApp),
+		allowlist:   buildAllowlistIndex(cfg),
+	}
+
+	for _, entry := range cfg.Monitor.ProtectedApps {
+		if hash, ok := config.ParseHashEntry(entry); ok {
+			e.hashes[hash] = entry
+			continue
+		}
+		if kind, id, ok := config.ParseSandboxEntry(entry); ok {
+			e.sandboxes[kind+":"+id] = entry
+			continue
+		}
+		if isDirEntry(entry) {
+			e.dirs = append(e.dirs, resolveDir(entry))
+			continue
+		}
+		e.paths[cleanPath(entry)] = true
+	}
+
+	for i := range cfg.BlockedApps {
+		app := &cfg.BlockedApps[i]
+		if _, ok := e.apps[app.Path]; !ok {
+			e.apps[app.Path] = app
+		}
+		if app.MatchByHash && app.FileHash != "" {
+			e.hashes[strings.ToLower(app.FileHash)] = app.Path
+		}
+		if cond := newCredentialCondition(app.Users, app.Groups); cond != nil {
+			e.credentials[app.Path] = cond
+		}
+		if cond := newContextCondition(app.WorkingDirs, app.Env); cond != nil {
+			e.contexts[app.Path] = cond
+		}
+	}
+
+	return e, nil
+}
+
+// Decide evaluates a launch: Match followed by Resolve. state may be nil.
+func (e *Engine) Decide(l Launch, state State) Decision {
+	match, decision := e.Match(&l)
+	if decision != nil {
+		return *decision
+	}
+	return e.Resolve(&l, match, state)
+}
+
+// Match finds the protection a launch falls under. Launches refused for
+// how they were started, and those decided by a regex rule or exempt by
+// the conditions of their app, are settled here and returned as a
+// decision; the others go on to Resolve.
+func (e *Engine) Match(l *Launch) (Match, *Decision) {
+	// Executables with no file on disk cannot be matched by path
+	anonymous := AnonymousKind(l.Executable)
+	if anonymous != "" && e.AnonymousExecAction() == config.AnonymousExecActionDeny {
+		return Match{}, &Decision{Action: ActionDeny, App: l.Executable, Reason: "anonymous execution"}
+	}
+
+	// A deleted or swapped executable is not what path rules describe
+	if anonymous == "" && l.Replaced != "" && e.ReplacedExecAction() == config.ReplacedExecActionDeny {
+		return Match{}, &Decision{Action: ActionDeny, App: l.Executable, Reason: "executable was replaced"}
+	}
+
+	// Locked-down machines refuse programs brought in on removable media
+	if l.Removable != "" && e.RemovableMediaAction() == config.RemovableMediaActionDeny {
+		return Match{}, &Decision{Action: ActionDeny, App: l.Executable, Reason: "launched from removable media"}
+	}
+
+	// Regex rules take precedence over the protected app entries
+	if rule := e.matchRule(l); rule != nil {
+		switch rule.action {
+		case config.RuleActionAllow:
+			return Match{}, &Decision{Action: ActionAllow, App: l.Executable, Rule: rule.name, Reason: "allowed by rule " + rule.name}
+		case config.RuleActionDeny:
+			return Match{}, &Decision{Action: ActionDeny, App: l.Executable, Rule: rule.name, Reason: "denied by rule " + rule.name}
+		}
+		return Match{App: l.Executable, Target: l.Executable, Rule: rule.name}, nil
+	}
+
+	// For interpreters, the script is what the user asked to protect
+	target := l.Executable
+	app, ok := e.match(l, target)
+	if !ok && l.Script != "" {
+		target = l.Script
+		app, ok = e.match(l, target)
+	}
+	if !ok {
+		return Match{}, nil
+	}
+
+	// Apps restricted to certain users, directories or environments stay
+	// unlocked elsewhere
+	if !e.credentials[app].matches(l.UID, l.GID, l.Groups) {
+		return Match{}, &Decision{Action: ActionAllow, App: app, Reason: ReasonNotForUser}
+	}
+	if !e.contexts[app].matches(l) {
+		return Match{}, &Decision{Action: ActionAllow, App: app, Reason: ReasonOutsideContext}
+	}
+	return Match{App: app, Target: target}, nil
+}
+
+// Resolve decides a launch that Match did not settle. Launches that matched
+// no protection are locked or denied for how they were started, or by the
+// allowlist, and otherwise allowed. Protected launches then go through
+// profiles, schedules, time budgets, instance limits, trusted launchers,
+// the app's policy action and launch quotas, in that order.
+func (e *Engine) Resolve(l *Launch, match Match, state State) Decision {
+	now := l.time()
+	app := match.App
+	reason := ReasonProtected
+	if match.Rule != "" {
+		reason = "locked by rule " + match.Rule
+	}
+
+	var warning, untrusted string
+	decide := func(action Action, why string) Decision {
+		return Decision{Action: action, App: app, Rule: match.Rule, Reason: why, Warning: warning, UntrustedDir: untrusted}
+	}
+	lockUnprotected := func(why string) {
+		if app == "" {
+			app, reason = l.Executable, why
+		}
+	}
+
+	anonymous := AnonymousKind(l.Executable)
+	if anonymous != "" && e.AnonymousExecAction() == config.AnonymousExecActionPrompt {
+		warning = AnonymousExecWarning
+		lockUnprotected("anonymous execution")
+	}
+
+	// Programs dropped into temporary or download directories
+	if action := e.UntrustedDirAction(); action != "" {
+		if dir, ok := untrustedDirFor(l.Target(), e.cfg.Monitor.UntrustedDirs, homeDir(l.UID)); ok {
+			untrusted = dir
+			if action == config.UntrustedDirActionDeny {
+				if app == "" {
+					app = l.Executable
+				}
+				return decide(ActionDeny, "launched from "+dir)
+			}
+			if warning == "" {
+				warning = "runs from " + dir
+			}
+			lockUnprotected("launched from " + dir)
+		}
+	}
+
+	if anonymous == "" && l.Replaced != "" && e.ReplacedExecAction() == config.ReplacedExecActionLock {
+		lockUnprotected("executable was replaced")
+	}
+
+	// In allowlist mode everything else is locked or denied
+	if app == "" {
+		switch e.checkAllowlist(l) {
+		case "":
+			return decide(ActionAllow, "not protected")
+		case config.AllowlistModeDeny:
+			app = l.Executable
+			return decide(ActionDeny, "not in allowlist")
+		}
+		lockUnprotected("not in allowlist")
+	}
+
+	// Child accounts cannot launch protected apps during their profile's
+	// schedule
+	if profile, ok := e.cfg.ProfileFor(l.UID); ok && profile.BlockedAt(now) {
+		return decide(ActionDeny, "blocked by the schedule of profile "+profile.Name)
+	}
+
+	switch e.scheduleAction(app, now) {
+	case config.ScheduleActionAllow:
+		return decide(ActionAllow, "outside schedule")
+	case config.ScheduleActionDeny:
+		return decide(ActionDeny, "blocked by schedule")
+	}
+
+	if state != nil && state.UsageExhausted(app, now) {
+		return decide(ActionDeny, "daily time limit reached")
+	}
+
+	// Processes spawned by an allowed instance (browser content processes,
+	// helpers) belong to it and are not new launches
+	if limit := e.MaxInstances(app); limit > 0 && state != nil && !state.InstanceChild(l, app) {
+		if running := state.RunningInstances(l, app); running >= limit {
+			return decide(ActionDeny, fmt.Sprintf("instance limit reached (%d of %d running)", running, limit))
+		}
+	}
+
+	if parent, ok := e.trustedParent(l, app); ok {
+		return decide(ActionAllow, "started by trusted launcher "+parent)
+	}
+
+	switch e.PolicyAction(app) {
+	case config.RuleActionDeny:
+		return decide(ActionDeny, ReasonPolicyDeny)
+	case config.RuleActionLog:
+		return decide(ActionLog, "allowed and logged by policy")
+	}
+
+	if limit := e.LaunchLimit(app); limit > 0 && state != nil {
+		if state.InstanceChild(l, app) || state.ConsumeLaunch(app, limit, now) {
+			return decide(ActionAllow, ReasonLaunchQuota)
+		}
+	}
+
+	return decide(ActionLock, reason)
+}
+
+// matchRule returns the first regex rule matching the launch, or nil
+func (e *Engine) matchRule(l *Launch) *regexRule {
+	for i := range e.rules {
+		if e.rules[i].matches(l) {
+			return &e.rules[i]
+		}
+	}
+	return nil
+}
+
+// match reports whether path is protected by a sandbox, directory, path or
+// hash entry and returns the app it is protected as. Directories are
+// matched against the symlink-resolved path, so a symlink elsewhere that
+// points into a protected directory matches while one inside it pointing
+// elsewhere does not.
+func (e *Engine) match(l *Launch, path string) (string, bool) {
+	clean := cleanPath(path)
+
+	if len(e.sandboxes) > 0 {
+		if _, ok := e.sandboxes[l.sandbox()]; ok {
+			return clean, true
+		}
+	}
+
+	if len(e.dirs) > 0 {
+		resolved := resolveExec(clean)
+		for _, dir := range e.dirs {
+			if isWithinDir(resolved, dir) {
+				return clean, true
+			}
+		}
+	}
+
+	if e.paths[clean] {
+		return clean, true
+	}
+
+	// Fall back to the hashes so renamed or copied binaries are caught
+	if len(e.hashes) > 0 {
+		if hash := l.hash(path); hash != "" {
+			if _, ok := e.hashes[hash]; ok {
+				return clean, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// trustedParent returns the ancestor of a launch that is a trusted launcher
+// for app, if any
+func (e *Engine) trustedParent(l *Launch, app string) (string, bool) {
+	parents := e.cfg.Monitor.TrustedParents
+	if blocked := e.apps[app]; blocked != nil && len(blocked.TrustedParents) > 0 {
+		parents = append(append([]string(nil), parents...), blocked.TrustedParents...)
+	}
+	if len(parents) == 0 {
+		return "", false
+	}
+
+	for _, exe := range l.ancestors() {
+		for _, parent := range parents {
+			if exe == parent {
+				return exe, true
+			}
+		}
+	}
+	return "", false
+}
+
+// scheduleAction returns how a launch of app at t is handled according to
+// its schedule: allow, prompt or deny
+func (e *Engine) scheduleAction(app string, t time.Time) string {
+	if blocked := e.apps[app]; blocked != nil {
+		return blocked.ScheduleActionAt(t)
+	}
+	return config.ScheduleActionPrompt
+}
+
+// PolicyAction returns what happens to a protected launch of app that
+// passed every other check: lock (prompt for authentication), deny
+// (terminate without a dialog) or log (allow and record). The app's own
+// action wins over the global default.
+func (e *Engine) PolicyAction(app string) string {
+	if blocked := e.apps[app]; blocked != nil && blocked.Action != "" {
+		return blocked.Action
+	}
+	if e.cfg.Monitor.DefaultAction != "" {
+		return e.cfg.Monitor.DefaultAction
+	}
+	return config.RuleActionLock
+}
+
+// MaxInstances returns the instance limit of app, or 0 when unlimited
+func (e *Engine) MaxInstances(app string) int {
+	if blocked := e.apps[app]; blocked != nil {
+		return blocked.MaxInstances
+	}
+	return 0
+}
+
+// LaunchLimit returns the number of free launches per day of app, or 0
+// when the app has no quota
+func (e *Engine) LaunchLimit(app string) int {
+	if blocked := e.apps[app]; blocked != nil {
+		return blocked.DailyLaunches
+	}
+	return 0
+}
+
+// RestrictsUsers reports whether any app is locked only for certain users
+// or groups
+func (e *Engine) RestrictsUsers() bool {
+	return len(e.credentials) > 0
+}
+
+// RestrictsUsersOf reports whether app is locked only for certain users or
+// groups
+func (e *Engine) RestrictsUsersOf(app string) bool {
+	return e.credentials[app] != nil
+}
+
+// LockedFor reports whether app is locked for a process running with the
+// given real credentials
+func (e *Engine) LockedFor(app string, uid, gid int, groups []int) bool {
+	return e.credentials[app].matches(uid, gid, groups)
+}
+
+// AnonymousExecAction returns the action for executables run without an
+// on-disk file
+func (e *Engine) AnonymousExecAction() string {
+	if e.cfg.Monitor.AnonymousExecAction == "" {
+		return config.AnonymousExecActionWarn
+	}
+	return e.cfg.Monitor.AnonymousExecAction
+}
+
+// ReplacedExecAction returns the action for executables deleted or replaced
+// after launch
+func (e *Engine) ReplacedExecAction() string {
+	if e.cfg.Monitor.ReplacedExecAction == "" {
+		return config.ReplacedExecActionWarn
+	}
+	return e.cfg.Monitor.ReplacedExecAction
+}
+
+// RemovableMediaAction returns the action for launches from removable
+// media, or "" when the policy is off
+func (e *Engine) RemovableMediaAction() string {
+	if action := e.cfg.Monitor.RemovableMediaAction; action != config.RemovableMediaActionOff {
+		return action
+	}
+	return ""
+}
+
+// UntrustedDirAction returns the action for launches from untrusted
+// directories, or "" when the policy is off
+func (e *Engine) UntrustedDirAction() string {
+	if action := e.cfg.Monitor.UntrustedDirAction; action != config.UntrustedDirActionOff {
+		return action
+	}
+	return ""
+}
@@ -0,0 +1,213 @@
+package policy_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/policy"
+)
+
+// fakeState reports a fixed number of running instances and counts free
+// launches
+type fakeState struct {
+	running  int
+	child    bool
+	exhaust  bool
+	launches int
+}
+
+func (s *fakeState) RunningInstances(l *policy.Launch, app string) int { return s.running }
+func (s *fakeState) InstanceChild(l *policy.Launch, app string) bool   { return s.child }
+func (s *fakeState) UsageExhausted(app string, t time.Time) bool       { return s.exhaust }
+
+func (s *fakeState) ConsumeLaunch(app string, limit int, t time.Time) bool {
+	if s.launches >= limit {
+		return false
+	}
+	s.launches++
+	return true
+}
+
+func newEngine(t *testing.T, cfg *config.Config) *policy.Engine {
+	t.Helper()
+	engine, err := policy.NewEngine(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	return engine
+}
+
+// TestDecideLaunchPolicies tests the policies that depend on how a launch
+// was started and on the state of running instances
+func TestDecideLaunchPolicies(t *testing.T) {
+	untrusted := t.TempDir()
+	noon := time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name   string
+		setup  func(cfg *config.Config)
+		launch policy.Launch
+		state  *fakeState
+		want   policy.Action
+		reason string
+	}{
+		{
+			name:   "anonymous execution denied",
+			setup:  func(cfg *config.Config) { cfg.Monitor.AnonymousExecAction = config.AnonymousExecActionDeny },
+			launch: policy.Launch{Executable: "/memfd:payload (deleted)"},
+			want:   policy.ActionDeny,
+			reason: "anonymous execution",
+		},
+		{
+			name:   "anonymous execution locked with a warning",
+			setup:  func(cfg *config.Config) { cfg.Monitor.AnonymousExecAction = config.AnonymousExecActionPrompt },
+			launch: policy.Launch{Executable: "/memfd:payload (deleted)"},
+			want:   policy.ActionLock,
+			reason: "anonymous execution",
+		},
+		{
+			name:   "removable media denied",
+			setup:  func(cfg *config.Config) { cfg.Monitor.RemovableMediaAction = config.RemovableMediaActionDeny },
+			launch: policy.Launch{Executable: "/media/usb/tool", Removable: "/media/usb"},
+			want:   policy.ActionDeny,
+			reason: "launched from removable media",
+		},
+		{
+			name:   "removable media only warned",
+			setup:  func(cfg *config.Config) { cfg.Monitor.RemovableMediaAction = config.RemovableMediaActionWarn },
+			launch: policy.Launch{Executable: "/media/usb/tool", Removable: "/media/usb"},
+			want:   policy.ActionAllow,
+		},
+		{
+			name: "untrusted directory denied",
+			setup: func(cfg *config.Config) {
+				cfg.Monitor.UntrustedDirAction = config.UntrustedDirActionDeny
+				cfg.Monitor.UntrustedDirs = []string{untrusted}
+			},
+			launch: policy.Launch{Executable: filepath.Join(untrusted, "tool")},
+			want:   policy.ActionDeny,
+		},
+		{
+			name: "trusted directory allowed",
+			setup: func(cfg *config.Config) {
+				cfg.Monitor.UntrustedDirAction = config.UntrustedDirActionDeny
+				cfg.Monitor.UntrustedDirs = []string{untrusted}
+			},
+			launch: policy.Launch{Executable: "/usr/bin/vim"},
+			want:   policy.ActionAllow,
+		},
+		{
+			name:   "replaced executable locked",
+			setup:  func(cfg *config.Config) { cfg.Monitor.ReplacedExecAction = config.ReplacedExecActionLock },
+			launch: policy.Launch{Executable: "/usr/bin/vim", Replaced: "executable was deleted"},
+			want:   policy.ActionLock,
+			reason: "executable was replaced",
+		},
+		{
+			name: "profile schedule denied",
+			setup: func(cfg *config.Config) {
+				cfg.Profiles = []config.Profile{{Name: "kids", Users: []string{"1500"}, Schedule: []config.ScheduleBlock{{}}}}
+			},
+			launch: policy.Launch{Executable: "/usr/bin/steam", UID: 1500, Time: noon},
+			want:   policy.ActionDeny,
+			reason: "blocked by the schedule of profile kids",
+		},
+		{
+			name: "profile of another user",
+			setup: func(cfg *config.Config) {
+				cfg.Profiles = []config.Profile{{Name: "kids", Users: []string{"1500"}, Schedule: []config.ScheduleBlock{{}}}}
+			},
+			launch: policy.Launch{Executable: "/usr/bin/steam", UID: 1000, Time: noon},
+			want:   policy.ActionLock,
+		},
+		{
+			name:   "instance limit reached",
+			setup:  func(cfg *config.Config) { cfg.BlockedApps[0].MaxInstances = 1 },
+			launch: policy.Launch{Executable: "/usr/bin/steam"},
+			state:  &fakeState{running: 1},
+			want:   policy.ActionDeny,
+			reason: "instance limit reached (1 of 1 running)",
+		},
+		{
+			name:   "instance child not counted",
+			setup:  func(cfg *config.Config) { cfg.BlockedApps[0].MaxInstances = 1 },
+			launch: policy.Launch{Executable: "/usr/bin/steam"},
+			state:  &fakeState{running: 1, child: true},
+			want:   policy.ActionLock,
+		},
+		{
+			name:   "time budget used up",
+			setup:  func(cfg *config.Config) { cfg.BlockedApps[0].DailyMinutes = 30 },
+			launch: policy.Launch{Executable: "/usr/bin/steam"},
+			state:  &fakeState{exhaust: true},
+			want:   policy.ActionDeny,
+			reason: "daily time limit reached",
+		},
+		{
+			name:   "free launch from quota",
+			setup:  func(cfg *config.Config) { cfg.BlockedApps[0].DailyLaunches = 1 },
+			launch: policy.Launch{Executable: "/usr/bin/steam"},
+			state:  &fakeState{},
+			want:   policy.ActionAllow,
+			reason: policy.ReasonLaunchQuota,
+		},
+		{
+			name:   "quota used up",
+			setup:  func(cfg *config.Config) { cfg.BlockedApps[0].DailyLaunches = 1 },
+			launch: policy.Launch{Executable: "/usr/bin/steam"},
+			state:  &fakeState{launches: 1},
+			want:   policy.ActionLock,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Monitor.ProtectedApps = []string{"/usr/bin/steam"}
+			cfg.BlockedApps = []config.BlockedApp{{Path: "/usr/bin/steam"}}
+			tt.setup(cfg)
+			engine := newEngine(t, cfg)
+
+			// A known, non-root user unless the case names one
+			if tt.launch.UID == 0 {
+				tt.launch.UID = 1000
+			}
+
+			var state policy.State
+			if tt.state != nil {
+				state = tt.state
+			}
+			d := engine.Decide(tt.launch, state)
+			if d.Action != tt.want {
+				t.Fatalf("Decide = %+v, want %s", d, tt.want)
+			}
+			if tt.reason != "" && d.Reason != tt.reason {
+				t.Errorf("Reason = %q, want %q", d.Reason, tt.reason)
+			}
+		})
+	}
+}
+
+// TestUnknownContextStaysLocked tests that launches whose credentials or
+// environment cannot be read are locked rather than exempt
+func TestUnknownContextStaysLocked(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Monitor.ProtectedApps = []string{"/usr/bin/code"}
+	cfg.BlockedApps = []config.BlockedApp{{
+		Path:  "/usr/bin/code",
+		Users: []string{"1000"},
+		Env:   []string{"XDG_SESSION_TYPE=wayland"},
+	}}
+	engine := newEngine(t, cfg)
+
+	unreadable := func() ([]string, error) { return nil, errors.New("permission denied") }
+	if d := engine.Decide(policy.Launch{Executable: "/usr/bin/code", UID: -1, LookupEnv: unreadable}, nil); d.Action != policy.ActionLock {
+		t.Errorf("Expected an unknown launch to stay locked, got %+v", d)
+	}
+	if d := engine.Decide(policy.Launch{Executable: "/usr/bin/code", UID: 1001}, nil); d.Action != policy.ActionAllow || d.Reason != policy.ReasonNotForUser {
+		t.Errorf("Expected another user to be exempt, got %+v", d)
+	}
+}
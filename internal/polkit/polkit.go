@@ -0,0 +1,150 @@
+// Package polkit asks polkit whether a launch may be unlocked, so the
+// desktop's polkit agent authenticates the user and administrators can
+// decide with polkit rules
+package polkit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	authorityService   = "org.freedesktop.PolicyKit1"
+	authorityPath      = "/org/freedesktop/PolicyKit1/Authority"
+	authorityInterface = "org.freedesktop.PolicyKit1.Authority"
+
+	// UnlockAction is the polkit action checked before a protected app is
+	// unlocked; docs/org.applock.policy declares it
+	UnlockAction = "org.applock.unlock"
+
+	// allowUserInteraction lets the agent prompt instead of failing
+	allowUserInteraction = 1
+)
+
+// Subject identifies what an authorization is checked for, as the
+// (sa{sv}) structure polkit expects
+type Subject struct {
+	Kind    string
+	Details map[string]dbus.Variant
+}
+
+// Result is polkit's answer
+type Result struct {
+	Authorized bool
+
+	// Challenge is set when authentication would be needed but the agent
+	// could not be asked
+	Challenge bool
+
+	// Details holds extra information, e.g. polkit.dismissed when the user
+	// closed the prompt
+	Details map[string]string
+}
+
+// Dismissed reports whether the user closed the prompt
+func (r Result) Dismissed() bool {
+	return r.Details["polkit.dismissed"] == "true"
+}
+
+// Authority is a connection to the polkit authority on the system bus
+type Authority struct {
+	conn   *dbus.Conn
+	obj    dbus.BusObject
+	checks atomic.Uint64
+}
+
+// NewAuthority connects to the system bus
+func NewAuthority() (*Authority, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the system bus: %w", err)
+	}
+	return &Authority{conn: conn, obj: conn.Object(authorityService, authorityPath)}, nil
+}
+
+// NewProcessSubject describes a process by PID, start time and owner, so
+// polkit does not mistake a reused PID for it
+func NewProcessSubject(pid int) (Subject, error) {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return Subject{}, err
+	}
+	// Fields after the command name, which may contain spaces
+	end := strings.LastIndexByte(string(stat), ')')
+	if end < 0 {
+		return Subject{}, fmt.Errorf("invalid stat of process %d", pid)
+	}
+	fields := strings.Fields(string(stat[end+1:]))
+	if len(fields) < 20 {
+		return Subject{}, fmt.Errorf("invalid stat of process %d", pid)
+	}
+	// starttime is field 22, the 20th after the command name
+	startTime, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return Subject{}, fmt.Errorf("invalid start time of process %d: %w", pid, err)
+	}
+
+	info, err := os.Stat(fmt.Sprintf("/proc/%d", pid))
+	if err != nil {
+		return Subject{}, err
+	}
+	owner, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return Subject{}, fmt.Errorf("failed to read owner of process %d", pid)
+	}
+
+	return Subject{
+		Kind: "unix-process",
+		Details: map[string]dbus.Variant{
+			"pid":        dbus.MakeVariant(uint32(pid)),
+			"start-time": dbus.MakeVariant(startTime),
+			"uid":        dbus.MakeVariant(int32(owner.Uid)),
+		},
+	}, nil
+}
+
+// CheckAuthorization asks whether subject may perform action, letting the
+// polkit agent of the subject's session authenticate the user. details are
+// available to polkit rules and to $(key) in the action's message. The
+// check is cancelled when ctx ends.
+func (a *Authority) CheckAuthorization(ctx context.Context, subject Subject, action string, details map[string]string) (Result, error) {
+	if details == nil {
+		details = map[string]string{}
+	}
+	cancelID := fmt.Sprintf("wyrmlock-%d-%d", os.Getpid(), a.checks.Add(1))
+
+	call := a.obj.GoWithContext(ctx, authorityInterface+".CheckAuthorization", 0, make(chan *dbus.Call, 1),
+		subject, action, details, uint32(allowUserInteraction), cancelID)
+	select {
+	case <-call.Done:
+	case <-ctx.Done():
+		// Close the agent's dialog as well
+		a.obj.Call(authorityInterface+".CancelCheckAuthorization", 0, cancelID)
+		return Result{}, ctx.Err()
+	}
+	if call.Err != nil {
+		return Result{}, fmt.Errorf("polkit check failed: %w", call.Err)
+	}
+
+	var reply struct {
+		Authorized bool
+		Challenge  bool
+		Details    map[string]string
+	}
+	if err := call.Store(&reply); err != nil {
+		return Result{}, fmt.Errorf("invalid polkit reply: %w", err)
+	}
+	return Result{Authorized: reply.Authorized, Challenge: reply.Challenge, Details: reply.Details}, nil
+}
+
+// Close disconnects from the system bus
+func (a *Authority) Close() error {
+	return a.conn.Close()
+}
@@ -0,0 +1,32 @@
+package polkit_test
+
+import (
+	"os"
+	"testing"
+
+	"wyrmlock/internal/polkit"
+)
+
+func TestNewProcessSubject(t *testing.T) {
+	subject, err := polkit.NewProcessSubject(os.Getpid())
+	if err != nil {
+		t.Fatalf("NewProcessSubject failed: %v", err)
+	}
+
+	if subject.Kind != "unix-process" {
+		t.Errorf("Expected a unix-process subject, got %s", subject.Kind)
+	}
+	if pid, ok := subject.Details["pid"].Value().(uint32); !ok || int(pid) != os.Getpid() {
+		t.Errorf("Expected pid %d, got %v", os.Getpid(), subject.Details["pid"])
+	}
+	if start, ok := subject.Details["start-time"].Value().(uint64); !ok || start == 0 {
+		t.Errorf("Expected a start time, got %v", subject.Details["start-time"])
+	}
+	if uid, ok := subject.Details["uid"].Value().(int32); !ok || int(uid) != os.Getuid() {
+		t.Errorf("Expected uid %d, got %v", os.Getuid(), subject.Details["uid"])
+	}
+
+	if _, err := polkit.NewProcessSubject(-1); err == nil {
+		t.Error("Expected an error for a missing process")
+	}
+}
@@ -0,0 +1,256 @@
+package privilege
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/syndtr/gocapability/capability"
+	"golang.org/x/sys/unix"
+)
+
+// CAP_KILL lets the daemon signal launches of other users
+const CAP_KILL Capability = 5
+
+// RetainedCapabilities are the only capabilities the daemon keeps once
+// DropPrivileges has run: signalling launches, reading their executables
+// and /proc entries, and the netlink proc connector
+var RetainedCapabilities = []Capability{CAP_KILL, CAP_SYS_PTRACE, CAP_NET_ADMIN}
+
+// String returns the kernel name of the capability, e.g. "sys_ptrace"
+func (c Capability) String() string {
+	return capability.Cap(c).String()
+}
+
+// CapabilityReport lists the capabilities in each set. Capabilities are
+// per thread, so each set is the union over every thread of the process.
+type CapabilityReport struct {
+	Threads     int
+	Effective   []Capability
+	Permitted   []Capability
+	Inheritable []Capability
+	Bounding    []Capability
+	Ambient     []Capability
+}
+
+// Held returns every capability in any set, sorted
+func (r CapabilityReport) Held() []Capability {
+	seen := make(map[Capability]bool)
+	for _, set := range [][]Capability{r.Effective, r.Permitted, r.Inheritable, r.Bounding, r.Ambient} {
+		for _, c := range set {
+			seen[c] = true
+		}
+	}
+	return sortedCaps(seen)
+}
+
+// Unexpected returns the capabilities held beyond allowed
+func (r CapabilityReport) Unexpected(allowed []Capability) []Capability {
+	permitted := make(map[Capability]bool, len(allowed))
+	for _, c := range allowed {
+		permitted[c] = true
+	}
+
+	extra := make(map[Capability]bool)
+	for _, c := range r.Held() {
+		if !permitted[c] {
+			extra[c] = true
+		}
+	}
+	return sortedCaps(extra)
+}
+
+// Missing returns the required capabilities absent from the effective set
+func (r CapabilityReport) Missing(required []Capability) []Capability {
+	effective := make(map[Capability]bool, len(r.Effective))
+	for _, c := range r.Effective {
+		effective[c] = true
+	}
+
+	missing := make(map[Capability]bool)
+	for _, c := range required {
+		if !effective[c] {
+			missing[c] = true
+		}
+	}
+	return sortedCaps(missing)
+}
+
+// String describes the report for logs
+func (r CapabilityReport) String() string {
+	return fmt.Sprintf("threads=%d effective=%s permitted=%s inheritable=%s bounding=%s ambient=%s",
+		r.Threads, capList(r.Effective), capList(r.Permitted), capList(r.Inheritable),
+		capList(r.Bounding), capList(r.Ambient))
+}
+
+// CapabilityReport reads the capability sets of every thread of the process
+func (p *PrivilegeManager) CapabilityReport() (CapabilityReport, error) {
+	return readCapabilityReport("/proc/self/task")
+}
+
+// AuditCapabilities checks that the process holds exactly the retained
+// capabilities: all of them effective and nothing else in any set
+func (p *PrivilegeManager) AuditCapabilities() (CapabilityReport, error) {
+	report, err := p.CapabilityReport()
+	if err != nil {
+		return report, fmt.Errorf("failed to read capabilities: %w", err)
+	}
+	if extra := report.Unexpected(RetainedCapabilities); len(extra) > 0 {
+		return report, fmt.Errorf("unexpected capabilities remain: %s", capList(extra))
+	}
+	if missing := report.Missing(RetainedCapabilities); len(missing) > 0 {
+		return report, fmt.Errorf("retained capabilities missing: %s", capList(missing))
+	}
+	return report, nil
+}
+
+// KeyringHelperCapabilities are the only capabilities the desktop keyring
+// helper keeps: reaching a user's session bus and running secret-tool as
+// that user
+var KeyringHelperCapabilities = []Capability{CAP_DAC_READ_SEARCH, CAP_SETGID, CAP_SETUID}
+
+// LimitCapabilities reduces every thread of a process that is not the
+// daemon, such as one of its helpers, to keep
+func LimitCapabilities(keep []Capability) error {
+	return minimizeCapabilities(keep)
+}
+
+// minimizeCapabilities reduces every thread to the retained capabilities:
+// ambient cleared, bounding and permitted sets cut down, inheritable empty.
+// The bounding set goes first, as dropping from it needs CAP_SETPCAP.
+func minimizeCapabilities(keep []Capability) error {
+	retained := make(map[Capability]bool, len(keep))
+	var mask uint64
+	for _, c := range keep {
+		retained[c] = true
+		mask |= 1 << uint(c)
+	}
+
+	if _, _, errno := syscall.AllThreadsSyscall6(unix.SYS_PRCTL, unix.PR_CAP_AMBIENT, unix.PR_CAP_AMBIENT_CLEAR_ALL, 0, 0, 0, 0); errno != 0 {
+		return allThreadsError("clear ambient capabilities", errno)
+	}
+	for c := Capability(0); c <= Capability(capability.CAP_LAST_CAP); c++ {
+		if retained[c] {
+			continue
+		}
+		if _, _, errno := syscall.AllThreadsSyscall(unix.SYS_PRCTL, unix.PR_CAPBSET_DROP, uintptr(c), 0); errno != 0 {
+			return allThreadsError(fmt.Sprintf("drop %s from the bounding set", c), errno)
+		}
+	}
+
+	hdr := unix.CapUserHeader{Version: unix.LINUX_CAPABILITY_VERSION_3}
+	data := [2]unix.CapUserData{
+		{Effective: uint32(mask), Permitted: uint32(mask)},
+		{Effective: uint32(mask >> 32), Permitted: uint32(mask >> 32)},
+	}
+	if _, _, errno := syscall.AllThreadsSyscall(unix.SYS_CAPSET, uintptr(unsafe.Pointer(&hdr)), uintptr(unsafe.Pointer(&data[0])), 0); errno != 0 {
+		return allThreadsError("set capabilities", errno)
+	}
+	return nil
+}
+
+// allThreadsError explains a failed all-threads call. Binaries linked with
+// cgo cannot change the credentials of every thread this way.
+func allThreadsError(action string, errno syscall.Errno) error {
+	if errno == syscall.ENOTSUP {
+		return fmt.Errorf("failed to %s on all threads: not supported in binaries built with cgo", action)
+	}
+	return fmt.Errorf("failed to %s: %w", action, errno)
+}
+
+// readCapabilityReport merges the capability lines of each task status file
+// under dir
+func readCapabilityReport(dir string) (CapabilityReport, error) {
+	var report CapabilityReport
+	tasks, err := os.ReadDir(dir)
+	if err != nil {
+		return report, err
+	}
+
+	var effective, permitted, inheritable, bounding, ambient uint64
+	for _, task := range tasks {
+		sets, err := readStatusCapabilities(filepath.Join(dir, task.Name(), "status"))
+		if os.IsNotExist(err) {
+			continue // The thread exited
+		}
+		if err != nil {
+			return report, err
+		}
+		report.Threads++
+		effective |= sets["CapEff"]
+		permitted |= sets["CapPrm"]
+		inheritable |= sets["CapInh"]
+		bounding |= sets["CapBnd"]
+		ambient |= sets["CapAmb"]
+	}
+
+	report.Effective = maskCaps(effective)
+	report.Permitted = maskCaps(permitted)
+	report.Inheritable = maskCaps(inheritable)
+	report.Bounding = maskCaps(bounding)
+	report.Ambient = maskCaps(ambient)
+	return report, nil
+}
+
+// readStatusCapabilities parses the Cap* lines of a status file
+func readStatusCapabilities(path string) (map[string]uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sets := make(map[string]uint64)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok || !strings.HasPrefix(key, "Cap") {
+			continue
+		}
+		mask, err := strconv.ParseUint(strings.TrimSpace(value), 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s in %s: %w", key, path, err)
+		}
+		sets[key] = mask
+	}
+	return sets, scanner.Err()
+}
+
+// maskCaps lists the capabilities set in a bit mask
+func maskCaps(mask uint64) []Capability {
+	var caps []Capability
+	for c := Capability(0); c < 64; c++ {
+		if mask&(1<<uint(c)) != 0 {
+			caps = append(caps, c)
+		}
+	}
+	return caps
+}
+
+// sortedCaps returns the keys of a capability set in order
+func sortedCaps(set map[Capability]bool) []Capability {
+	caps := make([]Capability, 0, len(set))
+	for c := range set {
+		caps = append(caps, c)
+	}
+	sort.Slice(caps, func(i, j int) bool { return caps[i] < caps[j] })
+	return caps
+}
+
+// capList formats capabilities as a comma-separated list
+func capList(caps []Capability) string {
+	if len(caps) == 0 {
+		return "none"
+	}
+	names := make([]string, len(caps))
+	for i, c := range caps {
+		names[i] = c.String()
+	}
+	return strings.Join(names, ",")
+}
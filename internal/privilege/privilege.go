@@ -26,6 +26,8 @@ const (
 	CAP_SETUID     Capability = 7  // For privilege dropping
 	CAP_SETGID     Capability = 6  // For privilege dropping
 	CAP_SETPCAP    Capability = 8  // For capability management
+
+	CAP_DAC_READ_SEARCH Capability = 2 // For reaching users' session buses
 )
 
 // PrivilegeManager handles privilege and capability management
@@ -35,6 +37,10 @@ type PrivilegeManager struct {
 	gid    int
 	caps   capability.Capabilities
 	mu     sync.RWMutex // Protect concurrent access
+
+	// dropped is set once DropPrivileges succeeded, after which only
+	// RetainedCapabilities are expected
+	dropped bool
 }
 
 // NewPrivilegeManager creates a new privilege manager
@@ -83,15 +89,7 @@ func (p *PrivilegeManager) logCapabilityState() error {
 		return errors.PrivilegeError(fmt.Sprintf("failed to load capabilities for logging: %v", err))
 	}
 
-	requiredCaps := []Capability{
-		CAP_NET_ADMIN,
-		CAP_SYS_PTRACE,
-		CAP_SYS_ADMIN,
-		CAP_CHOWN,
-		CAP_SETUID,
-		CAP_SETGID,
-		CAP_SETPCAP,
-	}
+	requiredCaps := p.expectedCapabilities()
 
 	for _, cap := range requiredCaps {
 		capValue := capability.Cap(cap)
@@ -141,6 +139,24 @@ func (p *PrivilegeManager) setCapabilities(caps []Capability) error {
 	return nil
 }
 
+// expectedCapabilities returns the capabilities the process must hold:
+// those needed to set the daemon up, or only the retained ones once
+// privileges were dropped
+func (p *PrivilegeManager) expectedCapabilities() []Capability {
+	if p.dropped {
+		return RetainedCapabilities
+	}
+	return []Capability{
+		CAP_NET_ADMIN,
+		CAP_SYS_PTRACE,
+		CAP_SYS_ADMIN,
+		CAP_CHOWN,
+		CAP_SETUID,
+		CAP_SETGID,
+		CAP_SETPCAP,
+	}
+}
+
 // VerifyCapabilities checks if all required capabilities are present
 func (p *PrivilegeManager) VerifyCapabilities() error {
 	p.mu.RLock()
@@ -152,15 +168,7 @@ func (p *PrivilegeManager) VerifyCapabilities() error {
 	}
 
 	// Required capabilities for operation
-	requiredCaps := []Capability{
-		CAP_NET_ADMIN,
-		CAP_SYS_PTRACE,
-		CAP_SYS_ADMIN,
-		CAP_CHOWN,
-		CAP_SETUID,
-		CAP_SETGID,
-		CAP_SETPCAP,
-	}
+	requiredCaps := p.expectedCapabilities()
 
 	// Check each required capability
 	for _, cap := range requiredCaps {
@@ -179,60 +187,48 @@ func (p *PrivilegeManager) VerifyCapabilities() error {
 	return nil
 }
 
-// DropPrivileges drops root privileges while maintaining required capabilities
+// DropPrivileges reduces the process to RetainedCapabilities on every
+// thread and audits the result. It fails closed: an error is returned when
+// any other capability remains or a retained one is missing.
 func (p *PrivilegeManager) DropPrivileges() error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	// Set required capabilities before dropping privileges
-	requiredCaps := []Capability{
-		CAP_NET_ADMIN,
-		CAP_SYS_PTRACE,
-		CAP_SYS_ADMIN,
-		CAP_CHOWN,
-		CAP_SETUID,
-		CAP_SETGID,
-		CAP_SETPCAP,
-	}
-
 	// Log initial state
 	p.logger.Debug("Current capability state before privilege drop:")
 	if err := p.logCapabilityState(); err != nil {
 		p.logger.Warnf("Failed to log initial capability state: %v", err)
 	}
 
-	// Set capabilities before dropping privileges
-	if err := p.setCapabilities(requiredCaps); err != nil {
-		return fmt.Errorf("failed to set required capabilities: %w", err)
-	}
-
-	// Verify capabilities were set correctly
-	if err := p.VerifyCapabilities(); err != nil {
-		return fmt.Errorf("capability verification failed: %w", err)
-	}
-
 	// Drop root privileges
 	if err := p.dropRootPrivileges(); err != nil {
 		return fmt.Errorf("failed to drop root privileges: %w", err)
 	}
 
-	// Verify capabilities are still present after privilege drop
-	if err := p.VerifyCapabilities(); err != nil {
-		return fmt.Errorf("lost required capabilities after privilege drop: %w", err)
+	// Keep only the retained capabilities
+	if err := minimizeCapabilities(RetainedCapabilities); err != nil {
+		return fmt.Errorf("failed to minimize capabilities: %w", err)
 	}
 
-	// Log final state
-	p.logger.Debug("Current capability state after privilege drop:")
-	if err := p.logCapabilityState(); err != nil {
-		p.logger.Warnf("Failed to log final capability state: %v", err)
+	// Verify nothing else survived
+	report, err := p.AuditCapabilities()
+	if err != nil {
+		return fmt.Errorf("capability audit failed (%s): %w", report, err)
 	}
+	p.dropped = true
 
-	p.logger.Info("Successfully dropped root privileges while maintaining required capabilities")
+	p.logger.Infof("Dropped privileges; capabilities held: %s", capList(report.Held()))
 	return nil
 }
 
-// dropRootPrivileges drops root privileges while maintaining capabilities
+// dropRootPrivileges switches to the real UID and GID when they are not
+// root. A daemon started as root keeps UID 0; its power is then limited by
+// the capability sets alone.
 func (p *PrivilegeManager) dropRootPrivileges() error {
+	if p.uid == 0 {
+		return nil
+	}
+
 	// Set real and effective GID first
 	if err := syscall.Setregid(p.gid, p.gid); err != nil {
 		return fmt.Errorf("failed to set real GID: %w", err)
@@ -356,7 +352,7 @@ func GetRequiredPrivileges() PrivilegeOperations {
 		// Operations that require specific capabilities
 		CapabilitiesRequired: map[string][]Capability{
 			OpSocketStr:    {CAP_CHOWN, CAP_NET_ADMIN},
-			OpProcessCtlStr: {CAP_SYS_PTRACE, CAP_SYS_ADMIN},
+			OpProcessCtlStr: {CAP_KILL, CAP_SYS_PTRACE},
 			OpHashStr:       {CAP_SYS_PTRACE}, // For accessing executable files
 			OpAuthStr:       {}, // Authentication doesn't require special privileges
 			OpConfigStr:     {}, // Configuration doesn't require special privileges
@@ -108,6 +108,41 @@ func TestVerifyCapabilities(t *testing.T) {
 	t.Logf("VerifyCapabilities result: %v", err)
 }
 
+// TestCapabilityReport tests comparing held capabilities with the retained set
+func TestCapabilityReport(t *testing.T) {
+	report := privilege.CapabilityReport{
+		Effective: []privilege.Capability{privilege.CAP_KILL, privilege.CAP_NET_ADMIN},
+		Permitted: []privilege.Capability{privilege.CAP_KILL, privilege.CAP_NET_ADMIN},
+		Bounding:  []privilege.Capability{privilege.CAP_KILL, privilege.CAP_NET_ADMIN, privilege.CAP_SYS_ADMIN},
+	}
+
+	extra := report.Unexpected(privilege.RetainedCapabilities)
+	if len(extra) != 1 || extra[0] != privilege.CAP_SYS_ADMIN {
+		t.Errorf("Expected sys_admin in the bounding set to be unexpected, got %v", extra)
+	}
+
+	missing := report.Missing(privilege.RetainedCapabilities)
+	if len(missing) != 1 || missing[0] != privilege.CAP_SYS_PTRACE {
+		t.Errorf("Expected sys_ptrace to be missing, got %v", missing)
+	}
+
+	if privilege.CAP_KILL.String() != "kill" {
+		t.Errorf("Expected CAP_KILL to be named kill, got %s", privilege.CAP_KILL)
+	}
+
+	manager, err := privilege.NewPrivilegeManager(testutil.SetupTestLogger())
+	if err != nil {
+		t.Skipf("Skipping live report: %v", err)
+	}
+	live, err := manager.CapabilityReport()
+	if err != nil {
+		t.Fatalf("CapabilityReport failed: %v", err)
+	}
+	if live.Threads < 1 {
+		t.Errorf("Expected at least one thread in the report, got %d", live.Threads)
+	}
+}
+
 // Helper function to check if an error is a permission error
 func isPermissionError(err error) bool {
 	return err != nil && (err.Error() == "operation not permitted" || 
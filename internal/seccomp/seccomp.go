@@ -0,0 +1,108 @@
+// Package seccomp confines the daemon to the system calls it needs with a
+// seccomp-BPF allow list, so a compromise of the privileged process cannot
+// load kernel modules, mount filesystems, trace other processes and the like
+package seccomp
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// ErrUnsupported is returned on architectures without a syscall list
+var ErrUnsupported = errors.New("seccomp filter not supported on this architecture")
+
+// seccompData offsets of struct seccomp_data
+const (
+	offsetNr   = 0
+	offsetArch = 4
+)
+
+// auditArch is the AUDIT_ARCH_* value of this architecture, and x32Bit the
+// syscall number bit of an alternate ABI sharing it; both are set by the
+// per-architecture files along with archSyscalls
+var (
+	auditArch    uint32
+	x32Bit       uint32
+	archSyscalls []uintptr
+)
+
+// Syscalls returns the allow list: those every architecture needs followed
+// by the legacy calls only some still have
+func Syscalls() []uintptr {
+	syscalls := make([]uintptr, 0, len(commonSyscalls)+len(archSyscalls))
+	syscalls = append(syscalls, commonSyscalls...)
+	return append(syscalls, archSyscalls...)
+}
+
+// Filter builds the BPF program. Calls not on the allow list, and calls made
+// through another ABI, get deny: SECCOMP_RET_ERRNO to refuse them or
+// SECCOMP_RET_LOG to only record them in the audit log.
+func Filter(deny uint32) ([]unix.SockFilter, error) {
+	if auditArch == 0 {
+		return nil, ErrUnsupported
+	}
+
+	filter := []unix.SockFilter{
+		// Refuse calls from another architecture, whose numbers differ
+		{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: offsetArch},
+		{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, Jt: 1, Jf: 0, K: auditArch},
+		{Code: unix.BPF_RET | unix.BPF_K, K: deny},
+		{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: offsetNr},
+	}
+	if x32Bit != 0 {
+		filter = append(filter,
+			unix.SockFilter{Code: unix.BPF_JMP | unix.BPF_JSET | unix.BPF_K, Jt: 0, Jf: 1, K: x32Bit},
+			unix.SockFilter{Code: unix.BPF_RET | unix.BPF_K, K: deny},
+		)
+	}
+
+	for _, nr := range Syscalls() {
+		filter = append(filter,
+			unix.SockFilter{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, Jt: 0, Jf: 1, K: uint32(nr)},
+			unix.SockFilter{Code: unix.BPF_RET | unix.BPF_K, K: unix.SECCOMP_RET_ALLOW},
+		)
+	}
+	return append(filter, unix.SockFilter{Code: unix.BPF_RET | unix.BPF_K, K: deny}), nil
+}
+
+// Install applies the filter to every thread of the process. With enforce
+// unlisted calls fail with EPERM, otherwise they are only logged. The
+// filter cannot be removed and is inherited by child processes.
+func Install(enforce bool) error {
+	deny := uint32(unix.SECCOMP_RET_LOG)
+	if enforce {
+		deny = unix.SECCOMP_RET_ERRNO | uint32(unix.EPERM)
+	}
+
+	filter, err := Filter(deny)
+	if err != nil {
+		return err
+	}
+	prog := unix.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+
+	err = setFilter(&prog)
+	if errors.Is(err, unix.EACCES) {
+		// Without CAP_SYS_ADMIN the kernel insists on no_new_privs
+		if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+			return fmt.Errorf("failed to set no_new_privs: %w", err)
+		}
+		err = setFilter(&prog)
+	}
+	return err
+}
+
+// setFilter loads prog on all threads
+func setFilter(prog *unix.SockFprog) error {
+	r, _, errno := unix.Syscall(unix.SYS_SECCOMP, unix.SECCOMP_SET_MODE_FILTER,
+		unix.SECCOMP_FILTER_FLAG_TSYNC, uintptr(unsafe.Pointer(prog)))
+	if errno != 0 {
+		return errno
+	}
+	if r != 0 {
+		return fmt.Errorf("thread %d could not be synchronised to the filter", r)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package seccomp_test
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/sys/unix"
+
+	"wyrmlock/internal/seccomp"
+)
+
+func TestSyscallAllowList(t *testing.T) {
+	seen := make(map[uintptr]bool)
+	for _, nr := range seccomp.Syscalls() {
+		if seen[nr] {
+			t.Errorf("Syscall %d is listed twice", nr)
+		}
+		seen[nr] = true
+	}
+
+	for name, nr := range map[string]uintptr{
+		"ptrace":            unix.SYS_PTRACE,
+		"process_vm_writev": unix.SYS_PROCESS_VM_WRITEV,
+		"mount":             unix.SYS_MOUNT,
+		"init_module":       unix.SYS_INIT_MODULE,
+		"finit_module":      unix.SYS_FINIT_MODULE,
+		"kexec_load":        unix.SYS_KEXEC_LOAD,
+		"bpf":               unix.SYS_BPF,
+		"unshare":           unix.SYS_UNSHARE,
+	} {
+		if seen[nr] {
+			t.Errorf("Expected %s to be denied", name)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	filter, err := seccomp.Filter(unix.SECCOMP_RET_LOG)
+	if errors.Is(err, seccomp.ErrUnsupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("Filter failed: %v", err)
+	}
+
+	// The kernel accepts at most BPF_MAXINSNS instructions
+	if len(filter) > 4096 {
+		t.Fatalf("Filter has %d instructions, more than the kernel accepts", len(filter))
+	}
+	last := filter[len(filter)-1]
+	if last.Code != unix.BPF_RET|unix.BPF_K || last.K != unix.SECCOMP_RET_LOG {
+		t.Errorf("Expected the filter to end with the deny action, got %+v", last)
+	}
+}
@@ -0,0 +1,195 @@
+package seccomp
+
+import "golang.org/x/sys/unix"
+
+// commonSyscalls are the calls the daemon, the Go runtime and the tools it
+// runs (the privileged helper, gpgv, dpkg-query and rpm) make. Left out on
+// purpose: ptrace, process_vm_*, mount, pivot_root, module loading, kexec,
+// bpf, perf_event_open, keyctl, unshare, setns, io_uring and userfaultfd.
+var commonSyscalls = []uintptr{
+	// Process lifetime and threads
+	unix.SYS_CLONE,
+	unix.SYS_CLONE3,
+	unix.SYS_EXECVE,
+	unix.SYS_EXECVEAT,
+	unix.SYS_EXIT,
+	unix.SYS_EXIT_GROUP,
+	unix.SYS_WAIT4,
+	unix.SYS_WAITID,
+	unix.SYS_SET_TID_ADDRESS,
+	unix.SYS_SET_ROBUST_LIST,
+	unix.SYS_GET_ROBUST_LIST,
+	unix.SYS_RSEQ,
+	unix.SYS_RESTART_SYSCALL,
+	unix.SYS_PRCTL,
+	unix.SYS_SCHED_YIELD,
+	unix.SYS_SCHED_GETAFFINITY,
+	unix.SYS_SCHED_SETAFFINITY,
+	unix.SYS_GETPRIORITY,
+	unix.SYS_SETPRIORITY,
+	unix.SYS_IOPRIO_GET,
+	unix.SYS_IOPRIO_SET,
+	unix.SYS_FUTEX,
+	unix.SYS_MEMBARRIER,
+
+	// Memory
+	unix.SYS_BRK,
+	unix.SYS_MMAP,
+	unix.SYS_MUNMAP,
+	unix.SYS_MREMAP,
+	unix.SYS_MPROTECT,
+	unix.SYS_MADVISE,
+	unix.SYS_MINCORE,
+	unix.SYS_MLOCK,
+	unix.SYS_MUNLOCK,
+
+	// Signals, including those sent to suspend and kill launches
+	unix.SYS_RT_SIGACTION,
+	unix.SYS_RT_SIGPROCMASK,
+	unix.SYS_RT_SIGRETURN,
+	unix.SYS_RT_SIGSUSPEND,
+	unix.SYS_RT_SIGTIMEDWAIT,
+	unix.SYS_SIGALTSTACK,
+	unix.SYS_KILL,
+	unix.SYS_TKILL,
+	unix.SYS_TGKILL,
+	unix.SYS_PIDFD_OPEN,
+	unix.SYS_PIDFD_SEND_SIGNAL,
+
+	// Credentials and capabilities
+	unix.SYS_GETPID,
+	unix.SYS_GETPPID,
+	unix.SYS_GETTID,
+	unix.SYS_GETUID,
+	unix.SYS_GETEUID,
+	unix.SYS_GETGID,
+	unix.SYS_GETEGID,
+	unix.SYS_GETRESUID,
+	unix.SYS_GETRESGID,
+	unix.SYS_GETGROUPS,
+	unix.SYS_SETUID,
+	unix.SYS_SETGID,
+	unix.SYS_SETREUID,
+	unix.SYS_SETREGID,
+	unix.SYS_SETRESUID,
+	unix.SYS_SETRESGID,
+	unix.SYS_SETGROUPS,
+	unix.SYS_CAPGET,
+	unix.SYS_CAPSET,
+	unix.SYS_GETPGID,
+	unix.SYS_SETPGID,
+	unix.SYS_GETSID,
+	unix.SYS_SETSID,
+	unix.SYS_UMASK,
+
+	// Files: /proc reads, cgroup writes, state and configuration files
+	unix.SYS_OPENAT,
+	unix.SYS_OPENAT2,
+	unix.SYS_CLOSE,
+	unix.SYS_CLOSE_RANGE,
+	unix.SYS_READ,
+	unix.SYS_READV,
+	unix.SYS_PREAD64,
+	unix.SYS_WRITE,
+	unix.SYS_WRITEV,
+	unix.SYS_PWRITE64,
+	unix.SYS_LSEEK,
+	unix.SYS_SENDFILE,
+	unix.SYS_FSTAT,
+	unix.SYS_NEWFSTATAT,
+	unix.SYS_STATX,
+	unix.SYS_STATFS,
+	unix.SYS_FSTATFS,
+	unix.SYS_GETDENTS64,
+	unix.SYS_READLINKAT,
+	unix.SYS_FACCESSAT,
+	unix.SYS_FACCESSAT2,
+	unix.SYS_MKDIRAT,
+	unix.SYS_UNLINKAT,
+	unix.SYS_RENAMEAT,
+	unix.SYS_RENAMEAT2,
+	unix.SYS_SYMLINKAT,
+	unix.SYS_LINKAT,
+	unix.SYS_FCHMOD,
+	unix.SYS_FCHMODAT,
+	unix.SYS_FCHOWN,
+	unix.SYS_FCHOWNAT,
+	unix.SYS_UTIMENSAT,
+	unix.SYS_FTRUNCATE,
+	unix.SYS_FALLOCATE,
+	unix.SYS_FSYNC,
+	unix.SYS_FDATASYNC,
+	unix.SYS_FADVISE64,
+	unix.SYS_FLOCK,
+	unix.SYS_FCNTL,
+	unix.SYS_IOCTL,
+	unix.SYS_DUP,
+	unix.SYS_DUP3,
+	unix.SYS_PIPE2,
+	unix.SYS_GETCWD,
+	unix.SYS_CHDIR,
+	unix.SYS_FCHDIR,
+	unix.SYS_MEMFD_CREATE,
+	unix.SYS_GETXATTR,
+	unix.SYS_LGETXATTR,
+	unix.SYS_FGETXATTR,
+
+	// Event loops and kernel notifications
+	unix.SYS_EPOLL_CREATE1,
+	unix.SYS_EPOLL_CTL,
+	unix.SYS_EPOLL_PWAIT,
+	unix.SYS_EPOLL_PWAIT2,
+	unix.SYS_PPOLL,
+	unix.SYS_PSELECT6,
+	unix.SYS_EVENTFD2,
+	unix.SYS_SIGNALFD4,
+	unix.SYS_TIMERFD_CREATE,
+	unix.SYS_TIMERFD_SETTIME,
+	unix.SYS_TIMERFD_GETTIME,
+	unix.SYS_INOTIFY_INIT1,
+	unix.SYS_INOTIFY_ADD_WATCH,
+	unix.SYS_INOTIFY_RM_WATCH,
+	unix.SYS_FANOTIFY_INIT,
+	unix.SYS_FANOTIFY_MARK,
+
+	// Sockets: the netlink proc connector, the client socket, D-Bus and
+	// the privileged helper
+	unix.SYS_SOCKET,
+	unix.SYS_SOCKETPAIR,
+	unix.SYS_BIND,
+	unix.SYS_LISTEN,
+	unix.SYS_ACCEPT,
+	unix.SYS_ACCEPT4,
+	unix.SYS_CONNECT,
+	unix.SYS_SHUTDOWN,
+	unix.SYS_GETSOCKNAME,
+	unix.SYS_GETPEERNAME,
+	unix.SYS_GETSOCKOPT,
+	unix.SYS_SETSOCKOPT,
+	unix.SYS_SENDTO,
+	unix.SYS_RECVFROM,
+	unix.SYS_SENDMSG,
+	unix.SYS_RECVMSG,
+	unix.SYS_SENDMMSG,
+	unix.SYS_RECVMMSG,
+
+	// Time and system information
+	unix.SYS_CLOCK_GETTIME,
+	unix.SYS_CLOCK_GETRES,
+	unix.SYS_CLOCK_NANOSLEEP,
+	unix.SYS_NANOSLEEP,
+	unix.SYS_GETTIMEOFDAY,
+	unix.SYS_GETRANDOM,
+	unix.SYS_UNAME,
+	unix.SYS_SYSINFO,
+	unix.SYS_GETRUSAGE,
+	unix.SYS_TIMES,
+	unix.SYS_GETRLIMIT,
+	unix.SYS_SETRLIMIT,
+	unix.SYS_PRLIMIT64,
+
+	// Landlock, for restricting the daemon's own filesystem access
+	unix.SYS_LANDLOCK_CREATE_RULESET,
+	unix.SYS_LANDLOCK_ADD_RULE,
+	unix.SYS_LANDLOCK_RESTRICT_SELF,
+}
@@ -0,0 +1,44 @@
+package seccomp
+
+import "golang.org/x/sys/unix"
+
+func init() {
+	auditArch = unix.AUDIT_ARCH_X86_64
+	// x32 syscalls share the architecture but not the numbering
+	x32Bit = 0x40000000
+
+	// Legacy calls x86-64 kept; the runtime and child tools still use some
+	archSyscalls = []uintptr{
+		unix.SYS_ARCH_PRCTL,
+		unix.SYS_OPEN,
+		unix.SYS_CREAT,
+		unix.SYS_STAT,
+		unix.SYS_LSTAT,
+		unix.SYS_ACCESS,
+		unix.SYS_READLINK,
+		unix.SYS_MKDIR,
+		unix.SYS_RMDIR,
+		unix.SYS_UNLINK,
+		unix.SYS_RENAME,
+		unix.SYS_LINK,
+		unix.SYS_SYMLINK,
+		unix.SYS_CHMOD,
+		unix.SYS_CHOWN,
+		unix.SYS_LCHOWN,
+		unix.SYS_GETDENTS,
+		unix.SYS_DUP2,
+		unix.SYS_PIPE,
+		unix.SYS_POLL,
+		unix.SYS_SELECT,
+		unix.SYS_EPOLL_CREATE,
+		unix.SYS_EPOLL_WAIT,
+		unix.SYS_EVENTFD,
+		unix.SYS_SIGNALFD,
+		unix.SYS_INOTIFY_INIT,
+		unix.SYS_FORK,
+		unix.SYS_VFORK,
+		unix.SYS_GETPGRP,
+		unix.SYS_ALARM,
+		unix.SYS_TIME,
+	}
+}
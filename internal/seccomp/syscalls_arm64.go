@@ -0,0 +1,10 @@
+package seccomp
+
+import "golang.org/x/sys/unix"
+
+func init() {
+	auditArch = unix.AUDIT_ARCH_AARCH64
+
+	// arm64 has only the *at and generic calls, all in commonSyscalls
+	archSyscalls = nil
+}
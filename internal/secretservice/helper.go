@@ -0,0 +1,193 @@
+package secretservice
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// HelperCommand is the hidden subcommand of the wyrmlock binary that runs
+// the keyring helper
+const HelperCommand = "keyring-helper"
+
+// helperRequest asks the helper for the secret of account in the keyring
+// of UID
+type helperRequest struct {
+	UID     int           `json:"uid"`
+	Account string        `json:"account"`
+	Timeout time.Duration `json:"timeout"`
+}
+
+// helperResponse carries the secret, or the code of the error that
+// prevented reading it
+type helperResponse struct {
+	Secret []byte `json:"secret,omitempty"`
+	Code   string `json:"code,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Error codes of the sentinel errors, so they survive the pipe
+const (
+	codeNotFound     = "not_found"
+	codeNoSession    = "no_session"
+	codeToolsMissing = "tools_missing"
+)
+
+// Helper reads other users' keyrings in a child process that keeps the
+// capabilities to switch to them, so the daemon can give those up. The
+// helper only looks secrets up; it never stores or clears them.
+type Helper struct {
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+// StartHelper runs the keyring helper from the wyrmlock binary exe. It
+// must be started while the caller still holds the capabilities the
+// helper needs.
+func StartHelper(exe string) (*Helper, error) {
+	cmd := exec.Command(exe, HelperCommand)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start keyring helper: %w", err)
+	}
+	return &Helper{cmd: cmd, stdin: stdin, stdout: bufio.NewReader(stdout)}, nil
+}
+
+// lookup has the helper read the secret of account from the keyring of
+// uid, within the deadline of ctx
+func (h *Helper) lookup(ctx context.Context, uid int, account string) ([]byte, error) {
+	req := helperRequest{UID: uid, Account: account}
+	if deadline, ok := ctx.Deadline(); ok {
+		req.Timeout = time.Until(deadline)
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, err := h.stdin.Write(append(data, '\n')); err != nil {
+		return nil, fmt.Errorf("keyring helper: %w", err)
+	}
+	line, err := h.stdout.ReadBytes('\n')
+	if err != nil {
+		return nil, fmt.Errorf("keyring helper: %w", err)
+	}
+
+	var resp helperResponse
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return nil, fmt.Errorf("keyring helper: invalid response: %w", err)
+	}
+	switch resp.Code {
+	case "":
+		if resp.Error != "" {
+			return nil, errors.New(resp.Error)
+		}
+		return resp.Secret, nil
+	case codeNotFound:
+		return nil, ErrNotFound
+	case codeNoSession:
+		return nil, ErrNoSession
+	case codeToolsMissing:
+		return nil, ErrToolsMissing
+	default:
+		return nil, fmt.Errorf("keyring helper: %s", resp.Error)
+	}
+}
+
+// Close stops the helper
+func (h *Helper) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stdin.Close()
+	return h.cmd.Wait()
+}
+
+// ServeHelper answers lookups read from r on w until r is closed. It is
+// the body of the keyring helper.
+func ServeHelper(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	encoder := json.NewEncoder(w)
+	for scanner.Scan() {
+		var req helperRequest
+		var resp helperResponse
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			resp.Error = fmt.Sprintf("invalid request: %v", err)
+		} else {
+			resp = serveLookup(req)
+		}
+		if err := encoder.Encode(resp); err != nil {
+			return err
+		}
+		for i := range resp.Secret {
+			resp.Secret[i] = 0
+		}
+	}
+	return scanner.Err()
+}
+
+// serveLookup reads the secret a request asks for
+func serveLookup(req helperRequest) helperResponse {
+	ctx := context.Background()
+	if req.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, req.Timeout)
+		defer cancel()
+	}
+
+	var secret []byte
+	keyring, err := ForUser(req.UID)
+	if err == nil {
+		secret, err = keyring.Lookup(ctx, req.Account)
+	}
+	switch {
+	case err == nil:
+		return helperResponse{Secret: secret}
+	case errors.Is(err, ErrNotFound):
+		return helperResponse{Code: codeNotFound}
+	case errors.Is(err, ErrNoSession):
+		return helperResponse{Code: codeNoSession}
+	case errors.Is(err, ErrToolsMissing):
+		return helperResponse{Code: codeToolsMissing}
+	default:
+		return helperResponse{Error: err.Error()}
+	}
+}
+
+var (
+	helperMu sync.RWMutex
+	helper   *Helper
+)
+
+// UseHelper has ForUser reach other users' keyrings through h from now
+// on; nil goes back to running secret-tool directly
+func UseHelper(h *Helper) {
+	helperMu.Lock()
+	defer helperMu.Unlock()
+	helper = h
+}
+
+// currentHelper returns the helper set with UseHelper, if any
+func currentHelper() *Helper {
+	helperMu.RLock()
+	defer helperMu.RUnlock()
+	return helper
+}
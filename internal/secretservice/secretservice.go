@@ -0,0 +1,160 @@
+// Package secretservice keeps secrets in a user's desktop keyring (GNOME
+// Keyring, KWallet) through the freedesktop secret service. It drives
+// libsecret's secret-tool, run as the keyring's owner on their session
+// bus, so the secret is only readable while their login keyring is.
+package secretservice
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Service is the service attribute of every item wyrmlock stores
+const Service = "wyrmlock"
+
+var (
+	// ErrToolsMissing is returned when secret-tool is not installed
+	ErrToolsMissing = errors.New("secret-tool (libsecret) is not installed")
+
+	// ErrNotFound is returned when the keyring has no such item
+	ErrNotFound = errors.New("secret not found in the desktop keyring")
+
+	// ErrNoSession is returned when the user has no session bus to reach
+	// their keyring on, i.e. is not logged in
+	ErrNoSession = errors.New("user has no session bus")
+)
+
+// ErrReadOnly is returned when storing or clearing a keyring reached
+// through the helper, which only looks secrets up
+var ErrReadOnly = errors.New("keyring is read only through the helper")
+
+// Keyring is the desktop keyring of one user
+type Keyring struct {
+	// cred runs secret-tool as the owner; nil runs it as the caller
+	cred *syscall.Credential
+	env  []string
+
+	// helper, when set, reads the keyring of uid in place of secret-tool
+	helper *Helper
+	uid    int
+}
+
+// ForCurrentUser returns the keyring of the calling user, reached through
+// the caller's own session environment
+func ForCurrentUser() *Keyring {
+	return &Keyring{env: os.Environ()}
+}
+
+// ForUser returns the keyring of uid, reached on the session bus under
+// /run/user. Root uses it to read the keyring of a logged-in user; once
+// the daemon has given up the capabilities to switch users, it does so
+// through the helper set with UseHelper.
+func ForUser(uid int) (*Keyring, error) {
+	if uid == os.Getuid() {
+		return ForCurrentUser(), nil
+	}
+	if h := currentHelper(); h != nil {
+		return &Keyring{helper: h, uid: uid}, nil
+	}
+
+	u, err := user.LookupId(strconv.Itoa(uid))
+	if err != nil {
+		return nil, fmt.Errorf("unknown user %d: %w", uid, err)
+	}
+	gid, err := strconv.Atoi(u.Gid)
+	if err != nil {
+		return nil, fmt.Errorf("invalid group of user %d: %w", uid, err)
+	}
+
+	runtimeDir := filepath.Join("/run/user", strconv.Itoa(uid))
+	bus := filepath.Join(runtimeDir, "bus")
+	if _, err := os.Stat(bus); err != nil {
+		return nil, ErrNoSession
+	}
+
+	return &Keyring{
+		cred: &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)},
+		env: []string{
+			"HOME=" + u.HomeDir,
+			"USER=" + u.Username,
+			"PATH=/usr/local/bin:/usr/bin:/bin",
+			"XDG_RUNTIME_DIR=" + runtimeDir,
+			"DBUS_SESSION_BUS_ADDRESS=unix:path=" + bus,
+		},
+	}, nil
+}
+
+// Lookup returns the secret stored for account
+func (k *Keyring) Lookup(ctx context.Context, account string) ([]byte, error) {
+	if k.helper != nil {
+		return k.helper.lookup(ctx, k.uid, account)
+	}
+	out, err := k.run(ctx, nil, "lookup", "service", Service, "account", account)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Store saves the secret of account, replacing any stored before. The
+// label is what the keyring manager shows.
+func (k *Keyring) Store(ctx context.Context, account, label string, secret []byte) error {
+	if k.helper != nil {
+		return ErrReadOnly
+	}
+	_, err := k.run(ctx, secret, "store", "--label="+label, "service", Service, "account", account)
+	return err
+}
+
+// Clear removes the secret of account
+func (k *Keyring) Clear(ctx context.Context, account string) error {
+	if k.helper != nil {
+		return ErrReadOnly
+	}
+	_, err := k.run(ctx, nil, "clear", "service", Service, "account", account)
+	return err
+}
+
+// run runs secret-tool as the keyring's owner. secret-tool exits 1
+// without a message when lookup or clear find no item.
+func (k *Keyring) run(ctx context.Context, input []byte, args ...string) ([]byte, error) {
+	path, err := exec.LookPath("secret-tool")
+	if err != nil {
+		return nil, ErrToolsMissing
+	}
+
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Env = k.env
+	if k.cred != nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Credential: k.cred}
+	}
+	if input != nil {
+		cmd.Stdin = bytes.NewReader(input)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("secret-tool: %s", msg)
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("secret-tool: %w", err)
+	}
+	if args[0] == "lookup" && len(out) == 0 {
+		return nil, ErrNotFound
+	}
+	return out, nil
+}
@@ -0,0 +1,108 @@
+package secretservice_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"wyrmlock/internal/secretservice"
+)
+
+// fakeSecretTool keeps one item in a file, like secret-tool does in the
+// keyring
+const fakeSecretTool = `#!/bin/sh
+store="$FAKE_KEYRING"
+case "$1" in
+lookup) [ -f "$store" ] || exit 1; cat "$store" ;;
+store) cat > "$store" ;;
+clear) [ -f "$store" ] || exit 1; rm "$store" ;;
+esac
+`
+
+func TestStoreLookupClear(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "secret-tool"), []byte(fakeSecretTool), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("FAKE_KEYRING", filepath.Join(dir, "item"))
+
+	ctx := context.Background()
+	k := secretservice.ForCurrentUser()
+
+	if _, err := k.Lookup(ctx, "unlock"); !errors.Is(err, secretservice.ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound before storing, got %v", err)
+	}
+
+	if err := k.Store(ctx, "unlock", "wyrmlock", []byte("hunter2")); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	secret, err := k.Lookup(ctx, "unlock")
+	if err != nil {
+		t.Fatalf("Lookup failed: %v", err)
+	}
+	if string(secret) != "hunter2" {
+		t.Errorf("Expected the stored secret, got %q", secret)
+	}
+
+	if err := k.Clear(ctx, "unlock"); err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	if _, err := k.Lookup(ctx, "unlock"); !errors.Is(err, secretservice.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after Clear, got %v", err)
+	}
+}
+
+func TestMissingTool(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	if _, err := secretservice.ForCurrentUser().Lookup(context.Background(), "unlock"); !errors.Is(err, secretservice.ErrToolsMissing) {
+		t.Errorf("Expected ErrToolsMissing, got %v", err)
+	}
+}
+
+func TestServeHelper(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "secret-tool"), []byte(fakeSecretTool), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	item := filepath.Join(dir, "item")
+	t.Setenv("FAKE_KEYRING", item)
+
+	request := fmt.Sprintf(`{"uid":%d,"account":"unlock"}`+"\n", os.Getuid())
+
+	var out bytes.Buffer
+	if err := secretservice.ServeHelper(strings.NewReader(request), &out); err != nil {
+		t.Fatalf("ServeHelper failed: %v", err)
+	}
+	if !strings.Contains(out.String(), `"code":"not_found"`) {
+		t.Errorf("Expected not_found for a missing item, got %s", out.String())
+	}
+
+	if err := os.WriteFile(item, []byte("hunter2"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := secretservice.ServeHelper(strings.NewReader(request+request), &out); err != nil {
+		t.Fatalf("ServeHelper failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected one response per request, got %q", out.String())
+	}
+	var resp struct {
+		Secret []byte `json:"secret"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Secret) != "hunter2" {
+		t.Errorf("Expected the stored secret, got %q", resp.Secret)
+	}
+}
@@ -0,0 +1,157 @@
+// Package secure holds secrets such as passwords in memory outside the Go
+// heap: locked so it is never swapped, excluded from core dumps and wiped
+// when no longer needed
+package secure
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"runtime"
+
+	"golang.org/x/sys/unix"
+)
+
+// ErrTooLong is returned when input does not fit in the buffer
+var ErrTooLong = errors.New("secret is too long")
+
+// Buffer is a fixed-capacity byte buffer in locked, anonymous memory. The
+// garbage collector never moves or copies it, so Destroy leaves no copy
+// behind. A nil Buffer is empty.
+type Buffer struct {
+	mem    []byte
+	start  int
+	end    int
+	locked bool
+}
+
+// New allocates a buffer of capacity bytes. Locking the memory is best
+// effort: without CAP_IPC_LOCK the RLIMIT_MEMLOCK allowance may be used
+// up, in which case the buffer still works but Locked reports false.
+func New(capacity int) (*Buffer, error) {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	pageSize := os.Getpagesize()
+	size := (capacity + pageSize - 1) / pageSize * pageSize
+
+	mem, err := unix.Mmap(-1, 0, size, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_ANON|unix.MAP_PRIVATE)
+	if err != nil {
+		return nil, err
+	}
+	_ = unix.Madvise(mem, unix.MADV_DONTDUMP)
+
+	b := &Buffer{mem: mem[:capacity]}
+	b.locked = unix.Mlock(mem) == nil
+	return b, nil
+}
+
+// Read reads r to EOF into a new buffer of at most capacity bytes, so the
+// secret is never held in an ordinary slice
+func Read(r io.Reader, capacity int) (*Buffer, error) {
+	b, err := New(capacity)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if b.end == len(b.mem) {
+			// Full: fail unless the reader is exhausted
+			var probe [1]byte
+			if n, _ := r.Read(probe[:]); n > 0 {
+				b.Destroy()
+				return nil, ErrTooLong
+			}
+			return b, nil
+		}
+
+		n, err := r.Read(b.mem[b.end:])
+		b.end += n
+		if err == io.EOF {
+			return b, nil
+		}
+		if err != nil {
+			b.Destroy()
+			return nil, err
+		}
+	}
+}
+
+// FromBytes copies src into a new buffer and wipes src
+func FromBytes(src []byte) (*Buffer, error) {
+	b, err := New(len(src))
+	if err != nil {
+		Wipe(src)
+		return nil, err
+	}
+	b.end = copy(b.mem, src)
+	Wipe(src)
+	return b, nil
+}
+
+// Bytes returns the contents. The slice aliases the locked memory and must
+// not be used after Destroy.
+func (b *Buffer) Bytes() []byte {
+	if b == nil || b.mem == nil {
+		return nil
+	}
+	return b.mem[b.start:b.end]
+}
+
+// Len returns the length of the contents
+func (b *Buffer) Len() int {
+	return len(b.Bytes())
+}
+
+// Locked reports whether the memory is locked against swapping
+func (b *Buffer) Locked() bool {
+	return b != nil && b.locked
+}
+
+// TrimSpace drops leading and trailing white space, such as the newline
+// dialog tools print after the input, wiping the dropped bytes
+func (b *Buffer) TrimSpace() {
+	if b == nil || b.mem == nil {
+		return
+	}
+	content := b.mem[b.start:b.end]
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) == 0 {
+		Wipe(content)
+		b.start, b.end = 0, 0
+		return
+	}
+
+	offset := len(content) - len(bytes.TrimLeft(content, " \t\r\n\v\f"))
+	Wipe(content[:offset])
+	Wipe(content[offset+len(trimmed):])
+	b.start, b.end = b.start+offset, b.start+offset+len(trimmed)
+}
+
+// HasPrefix reports whether the contents start with prefix
+func (b *Buffer) HasPrefix(prefix string) bool {
+	return bytes.HasPrefix(b.Bytes(), []byte(prefix))
+}
+
+// Destroy wipes and releases the memory. It is safe to call more than once.
+func (b *Buffer) Destroy() {
+	if b == nil || b.mem == nil {
+		return
+	}
+	mem := b.mem[:cap(b.mem)]
+	Wipe(mem)
+	if b.locked {
+		_ = unix.Munlock(mem)
+	}
+	_ = unix.Munmap(mem)
+	b.mem, b.start, b.end, b.locked = nil, 0, 0, false
+}
+
+// Wipe zeroes a byte slice in a way the compiler does not optimise away
+func Wipe(data []byte) {
+	for i := range data {
+		data[i] = 0
+	}
+	runtime.KeepAlive(data)
+}
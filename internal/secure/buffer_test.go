@@ -0,0 +1,59 @@
+package secure_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"wyrmlock/internal/secure"
+)
+
+func TestReadTrimDestroy(t *testing.T) {
+	buf, err := secure.Read(strings.NewReader("  hunter2\n"), 64)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	buf.TrimSpace()
+	if got := buf.Bytes(); !bytes.Equal(got, []byte("hunter2")) {
+		t.Fatalf("Expected trimmed contents, got %q", got)
+	}
+	if !buf.HasPrefix("hunt") || buf.HasPrefix("file://") {
+		t.Errorf("HasPrefix reported the wrong result")
+	}
+
+	buf.Destroy()
+	if buf.Len() != 0 || buf.Bytes() != nil {
+		t.Errorf("Expected a destroyed buffer to be empty")
+	}
+	buf.Destroy()
+}
+
+func TestReadTooLong(t *testing.T) {
+	if _, err := secure.Read(strings.NewReader("0123456789"), 4); !errors.Is(err, secure.ErrTooLong) {
+		t.Errorf("Expected ErrTooLong, got %v", err)
+	}
+
+	buf, err := secure.Read(strings.NewReader("0123"), 4)
+	if err != nil || buf.Len() != 4 {
+		t.Errorf("Expected input of exactly the capacity to fit, got %v", err)
+	}
+	buf.Destroy()
+}
+
+func TestFromBytesWipesSource(t *testing.T) {
+	src := []byte("secret")
+	buf, err := secure.FromBytes(src)
+	if err != nil {
+		t.Fatalf("FromBytes failed: %v", err)
+	}
+	defer buf.Destroy()
+
+	if !bytes.Equal(buf.Bytes(), []byte("secret")) {
+		t.Errorf("Expected the buffer to hold the secret, got %q", buf.Bytes())
+	}
+	if !bytes.Equal(src, make([]byte, len(src))) {
+		t.Errorf("Expected the source to be wiped, got %q", src)
+	}
+}
@@ -0,0 +1,93 @@
+package session
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// sessionStateDir is where logind keeps the state of each session
+const sessionStateDir = "/run/systemd/sessions"
+
+// Info identifies the logind session and seat a process belongs to
+type Info struct {
+	ID   string
+	Seat string
+	UID  int
+}
+
+// Resolve returns the session and seat of a process. The session is read
+// from the process's systemd scope in /proc/<pid>/cgroup and the seat from
+// logind's record of that session. Processes outside a session, such as
+// system services, return an error.
+func Resolve(pid int) (Info, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return Info{}, fmt.Errorf("failed to read process cgroup: %w", err)
+	}
+
+	id := SessionFromCgroup(string(data))
+	if id == "" {
+		return Info{}, fmt.Errorf("PID %d is not in a login session", pid)
+	}
+
+	state, err := os.Open(filepath.Join(sessionStateDir, id))
+	if err != nil {
+		// The session is known even when logind's record cannot be read
+		return Info{ID: id, UID: -1}, nil
+	}
+	defer state.Close()
+
+	info, err := ParseSessionState(state)
+	if err != nil {
+		return Info{ID: id, UID: -1}, nil
+	}
+	info.ID = id
+	return info, nil
+}
+
+// SessionFromCgroup returns the session ID from the contents of a
+// /proc/<pid>/cgroup file, taken from the "session-<id>.scope" unit logind
+// places session processes in, or "" when there is none
+func SessionFromCgroup(cgroup string) string {
+	for _, line := range strings.Split(cgroup, "\n") {
+		// hierarchy-ID:controller-list:cgroup-path
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		for _, elem := range strings.Split(fields[2], "/") {
+			if id, ok := strings.CutPrefix(elem, "session-"); ok && strings.HasSuffix(id, ".scope") {
+				return strings.TrimSuffix(id, ".scope")
+			}
+		}
+	}
+	return ""
+}
+
+// ParseSessionState reads the seat and user from a logind session state
+// file (KEY=value lines)
+func ParseSessionState(r io.Reader) (Info, error) {
+	info := Info{UID: -1}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "SEAT":
+			info.Seat = value
+		case "UID":
+			if uid, err := strconv.Atoi(value); err == nil {
+				info.UID = uid
+			}
+		}
+	}
+	return info, scanner.Err()
+}
@@ -0,0 +1,39 @@
+package session_test
+
+import (
+	"strings"
+	"testing"
+
+	"wyrmlock/internal/session"
+)
+
+func TestSessionFromCgroup(t *testing.T) {
+	tests := []struct {
+		name   string
+		cgroup string
+		want   string
+	}{
+		{"cgroup v2", "0::/user.slice/user-1000.slice/session-3.scope\n", "3"},
+		{"cgroup v1", "12:pids:/user.slice/user-1000.slice/session-c2.scope\n1:name=systemd:/user.slice/user-1000.slice/session-c2.scope\n", "c2"},
+		{"user service", "0::/user.slice/user-1000.slice/user@1000.service/app.slice/app-firefox.scope\n", ""},
+		{"system service", "0::/system.slice/cron.service\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := session.SessionFromCgroup(tt.cgroup); got != tt.want {
+				t.Errorf("SessionFromCgroup() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSessionState(t *testing.T) {
+	state := "# This is private data. Do not parse.\nUID=1000\nUSER=alice\nACTIVE=1\nSEAT=seat1\nVTNR=2\n"
+	info, err := session.ParseSessionState(strings.NewReader(state))
+	if err != nil {
+		t.Fatalf("ParseSessionState() error = %v", err)
+	}
+	if info.Seat != "seat1" || info.UID != 1000 {
+		t.Errorf("ParseSessionState() = %+v, want seat1 for UID 1000", info)
+	}
+}
@@ -0,0 +1,149 @@
+// Package session follows the desktop session through systemd-logind so
+// unlocked apps can be locked again when the user steps away
+package session
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	logindService    = "org.freedesktop.login1"
+	sessionInterface = "org.freedesktop.login1.Session"
+	seatInterface    = "org.freedesktop.login1.Seat"
+	propertiesSignal = "org.freedesktop.DBus.Properties.PropertiesChanged"
+)
+
+// Event kinds
+const (
+	// EventLock is sent when a session is locked
+	EventLock = "lock"
+
+	// EventSwitch is sent when a seat switches to another session, as on a
+	// user switch
+	EventSwitch = "switch"
+
+	// EventIdle is sent when the desktop reports a session idle
+	EventIdle = "idle"
+
+	// EventActive is sent when an idle session is used again
+	EventActive = "active"
+)
+
+// Event is a change of the desktop session
+type Event struct {
+	Kind string
+
+	// Path is the D-Bus object of the session or seat that changed
+	Path dbus.ObjectPath
+}
+
+// Watcher reports session locks, user switches and idleness announced by
+// logind
+type Watcher struct {
+	conn    *dbus.Conn
+	signals chan *dbus.Signal
+	handler func(Event)
+	once    sync.Once
+	done    chan struct{}
+}
+
+// NewWatcher connects to the system bus and subscribes to logind's session
+// and seat signals. Events are passed to handler once Start is called.
+func NewWatcher(handler func(Event)) (*Watcher, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the system bus: %w", err)
+	}
+
+	matches := [][]dbus.MatchOption{
+		{dbus.WithMatchSender(logindService), dbus.WithMatchInterface(sessionInterface), dbus.WithMatchMember("Lock")},
+		{dbus.WithMatchSender(logindService), dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+			dbus.WithMatchMember("PropertiesChanged"), dbus.WithMatchPathNamespace("/org/freedesktop/login1")},
+	}
+	for _, match := range matches {
+		if err := conn.AddMatchSignal(match...); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to subscribe to logind signals: %w", err)
+		}
+	}
+
+	w := &Watcher{
+		conn:    conn,
+		signals: make(chan *dbus.Signal, 16),
+		handler: handler,
+		done:    make(chan struct{}),
+	}
+	conn.Signal(w.signals)
+	return w, nil
+}
+
+// Start delivers events until Close is called
+func (w *Watcher) Start() {
+	go func() {
+		for {
+			select {
+			case <-w.done:
+				return
+			case sig, ok := <-w.signals:
+				if !ok {
+					return
+				}
+				if event, ok := ParseSignal(sig); ok {
+					w.handler(event)
+				}
+			}
+		}
+	}()
+}
+
+// Close stops delivering events and disconnects from the bus
+func (w *Watcher) Close() error {
+	var err error
+	w.once.Do(func() {
+		close(w.done)
+		w.conn.RemoveSignal(w.signals)
+		err = w.conn.Close()
+	})
+	return err
+}
+
+// ParseSignal turns a logind signal into a session event. A session is
+// locked by its Lock signal or by its screen locker setting LockedHint, and
+// goes idle or active with its IdleHint; a seat whose ActiveSession changes
+// has switched users. A lock wins over an idle change in the same signal.
+func ParseSignal(sig *dbus.Signal) (Event, bool) {
+	switch sig.Name {
+	case sessionInterface + ".Lock":
+		return Event{Kind: EventLock, Path: sig.Path}, true
+
+	case propertiesSignal:
+		if len(sig.Body) < 2 {
+			return Event{}, false
+		}
+		iface, _ := sig.Body[0].(string)
+		changed, _ := sig.Body[1].(map[string]dbus.Variant)
+
+		switch iface {
+		case sessionInterface:
+			if locked, ok := changed["LockedHint"]; ok {
+				if value, _ := locked.Value().(bool); value {
+					return Event{Kind: EventLock, Path: sig.Path}, true
+				}
+			}
+			if idle, ok := changed["IdleHint"]; ok {
+				if value, _ := idle.Value().(bool); value {
+					return Event{Kind: EventIdle, Path: sig.Path}, true
+				}
+				return Event{Kind: EventActive, Path: sig.Path}, true
+			}
+		case seatInterface:
+			if _, ok := changed["ActiveSession"]; ok {
+				return Event{Kind: EventSwitch, Path: sig.Path}, true
+			}
+		}
+	}
+	return Event{}, false
+}
@@ -0,0 +1,55 @@
+package session_test
+
+import (
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+
+	"wyrmlock/internal/session"
+)
+
+func TestParseSignal(t *testing.T) {
+	properties := func(iface string, changed map[string]dbus.Variant) *dbus.Signal {
+		return &dbus.Signal{
+			Path: "/org/freedesktop/login1/session/_32",
+			Name: "org.freedesktop.DBus.Properties.PropertiesChanged",
+			Body: []interface{}{iface, changed, []string{}},
+		}
+	}
+
+	tests := []struct {
+		name string
+		sig  *dbus.Signal
+		want string
+	}{
+		{"lock signal", &dbus.Signal{Name: "org.freedesktop.login1.Session.Lock"}, session.EventLock},
+		{"locked hint", properties("org.freedesktop.login1.Session",
+			map[string]dbus.Variant{"LockedHint": dbus.MakeVariant(true)}), session.EventLock},
+		{"unlocked hint", properties("org.freedesktop.login1.Session",
+			map[string]dbus.Variant{"LockedHint": dbus.MakeVariant(false)}), ""},
+		{"user switch", properties("org.freedesktop.login1.Seat",
+			map[string]dbus.Variant{"ActiveSession": dbus.MakeVariant([]interface{}{"c2", dbus.ObjectPath("/")})}), session.EventSwitch},
+		{"idle hint", properties("org.freedesktop.login1.Session",
+			map[string]dbus.Variant{"IdleHint": dbus.MakeVariant(true), "IdleSinceHint": dbus.MakeVariant(uint64(1))}), session.EventIdle},
+		{"active again", properties("org.freedesktop.login1.Session",
+			map[string]dbus.Variant{"IdleHint": dbus.MakeVariant(false)}), session.EventActive},
+		{"locked while idle", properties("org.freedesktop.login1.Session",
+			map[string]dbus.Variant{"IdleHint": dbus.MakeVariant(true), "LockedHint": dbus.MakeVariant(true)}), session.EventLock},
+		{"unrelated property", properties("org.freedesktop.login1.Session",
+			map[string]dbus.Variant{"Active": dbus.MakeVariant(true)}), ""},
+		{"unlock signal", &dbus.Signal{Name: "org.freedesktop.login1.Session.Unlock"}, ""},
+	}
+
+	for _, tt := range tests {
+		event, ok := session.ParseSignal(tt.sig)
+		if tt.want == "" {
+			if ok {
+				t.Errorf("%s: expected no event, got %+v", tt.name, event)
+			}
+			continue
+		}
+		if !ok || event.Kind != tt.want {
+			t.Errorf("%s: expected %s event, got %+v (ok: %v)", tt.name, tt.want, event, ok)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// Package token issues short-lived unlock tokens. A token is bound to the
+// user, the app and the executable's hash and carries its expiry, signed
+// with a key kept on tmpfs: tokens survive restarts of the daemon and
+// client but not a reboot.
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// version prefixes tokens so the format can change
+const version = "v1"
+
+var (
+	// ErrInvalid is returned for tokens that are malformed, forged or for
+	// another user or executable
+	ErrInvalid = errors.New("invalid unlock token")
+
+	// ErrExpired is returned for tokens past their expiry
+	ErrExpired = errors.New("unlock token expired")
+)
+
+// Issuer signs and verifies tokens
+type Issuer struct {
+	mu   sync.Mutex
+	path string
+	key  []byte
+}
+
+// Open loads the signing key from path, creating it when missing
+func Open(path string) (*Issuer, error) {
+	i := &Issuer{path: path}
+
+	key, err := os.ReadFile(path)
+	if err == nil && len(key) == 32 {
+		i.key = key
+		return i, nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read token key: %w", err)
+	}
+	if err := i.Rotate(); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// Rotate replaces the signing key, invalidating every token issued so far
+func (i *Issuer) Rotate() error {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return fmt.Errorf("failed to generate token key: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(i.path), 0700); err != nil {
+		return fmt.Errorf("failed to create token key directory: %w", err)
+	}
+	tmp := i.path + ".tmp"
+	if err := os.WriteFile(tmp, key, 0600); err != nil {
+		return fmt.Errorf("failed to write token key: %w", err)
+	}
+	if err := os.Rename(tmp, i.path); err != nil {
+		return fmt.Errorf("failed to write token key: %w", err)
+	}
+
+	i.mu.Lock()
+	i.key = key
+	i.mu.Unlock()
+	return nil
+}
+
+// Issue returns a token for uid launching app with the given executable
+// hash, valid until now+ttl
+func (i *Issuer) Issue(uid int, app, hash string, ttl time.Duration, now time.Time) string {
+	expires := strconv.FormatInt(now.Add(ttl).Unix(), 10)
+	return version + "." + expires + "." + i.sign(uid, app, hash, expires)
+}
+
+// Verify checks a token for uid launching app with the given hash
+func (i *Issuer) Verify(token string, uid int, app, hash string, now time.Time) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != version {
+		return ErrInvalid
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(i.sign(uid, app, hash, parts[1]))) {
+		return ErrInvalid
+	}
+
+	expires, err := Expiry(token)
+	if err != nil {
+		return err
+	}
+	if !now.Before(expires) {
+		return ErrExpired
+	}
+	return nil
+}
+
+// Expiry returns when a token expires, without verifying it
+func Expiry(token string) (time.Time, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != version {
+		return time.Time{}, ErrInvalid
+	}
+	seconds, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return time.Time{}, ErrInvalid
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+// sign returns the MAC binding a token to its user, app, hash and expiry
+func (i *Issuer) sign(uid int, app, hash, expires string) string {
+	i.mu.Lock()
+	mac := hmac.New(sha256.New, i.key)
+	i.mu.Unlock()
+
+	// NUL cannot appear in paths or the other fields
+	fmt.Fprintf(mac, "%s\x00%d\x00%s\x00%s\x00%s", version, uid, app, hash, expires)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,63 @@
+package token_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"wyrmlock/internal/token"
+)
+
+func TestIssueVerify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token.key")
+	issuer, err := token.Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	now := time.Now()
+	tok := issuer.Issue(1000, "/usr/bin/steam", "abc", 10*time.Minute, now)
+
+	if err := issuer.Verify(tok, 1000, "/usr/bin/steam", "abc", now.Add(time.Minute)); err != nil {
+		t.Errorf("Expected a fresh token to verify, got %v", err)
+	}
+
+	tests := []struct {
+		name string
+		uid  int
+		app  string
+		hash string
+		at   time.Time
+		want error
+	}{
+		{"other user", 1001, "/usr/bin/steam", "abc", now, token.ErrInvalid},
+		{"other app", 1000, "/usr/bin/lutris", "abc", now, token.ErrInvalid},
+		{"changed binary", 1000, "/usr/bin/steam", "def", now, token.ErrInvalid},
+		{"expired", 1000, "/usr/bin/steam", "abc", now.Add(11 * time.Minute), token.ErrExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := issuer.Verify(tok, tt.uid, tt.app, tt.hash, tt.at); !errors.Is(err, tt.want) {
+				t.Errorf("Verify = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// A restarted daemon reads the same key
+	reopened, err := token.Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if err := reopened.Verify(tok, 1000, "/usr/bin/steam", "abc", now); err != nil {
+		t.Errorf("Expected the token to survive a restart, got %v", err)
+	}
+
+	// Rotating the key revokes every token
+	if err := reopened.Rotate(); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	if err := reopened.Verify(tok, 1000, "/usr/bin/steam", "abc", now); !errors.Is(err, token.ErrInvalid) {
+		t.Errorf("Expected a rotated key to revoke the token, got %v", err)
+	}
+}
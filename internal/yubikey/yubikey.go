@@ -0,0 +1,212 @@
+// Package yubikey binds the unlock password to a YubiKey's HMAC-SHA1
+// challenge-response slot. Only a random challenge and a hash of the
+// password combined with the key's response are stored, so the unlock
+// secret cannot be recovered, or the password guessed offline, without
+// the key. It drives the ykpers tools (ykchalresp, ykpersonalize).
+package yubikey
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+
+	"wyrmlock/internal/secure"
+)
+
+const (
+	// challengeSize is the length of the stored challenge; HMAC-SHA1 slots
+	// configured with hmac-lt64 accept up to 63 bytes
+	challengeSize = 32
+
+	// Argon2id parameters, as for stored password hashes
+	argonTime    = 3
+	argonMemory  = 64 * 1024
+	argonThreads = 2
+	argonKeyLen  = 32
+)
+
+var (
+	// ErrToolsMissing is returned when the ykpers tools are not installed
+	ErrToolsMissing = errors.New("ykpers tools (ykchalresp, ykpersonalize) are not installed")
+
+	// ErrInvalidSlot is returned for slots other than 1 and 2
+	ErrInvalidSlot = errors.New("YubiKey slot must be 1 or 2")
+)
+
+// RespondFunc computes the HMAC-SHA1 response of a slot to a challenge
+type RespondFunc func(ctx context.Context, slot int, challenge []byte) ([]byte, error)
+
+// Enrollment is what is stored for a password bound to a YubiKey
+type Enrollment struct {
+	Slot int `json:"slot"`
+
+	// Challenge is sent to the key on every unlock (hex)
+	Challenge string `json:"challenge"`
+
+	// Salt and Hash are the Argon2id salt and hash of the password and
+	// the key's response (hex)
+	Salt string `json:"salt"`
+	Hash string `json:"hash"`
+}
+
+// Respond asks the connected YubiKey for the response of slot, which may
+// need a touch when the slot was programmed to require one
+func Respond(ctx context.Context, slot int, challenge []byte) ([]byte, error) {
+	if slot != 1 && slot != 2 {
+		return nil, ErrInvalidSlot
+	}
+	out, err := runTool(ctx, "ykchalresp", "-"+strconv.Itoa(slot), "-x", hex.EncodeToString(challenge))
+	if err != nil {
+		return nil, err
+	}
+	response, err := hex.DecodeString(strings.TrimSpace(string(out)))
+	if err != nil {
+		return nil, fmt.Errorf("unexpected ykchalresp output: %w", err)
+	}
+	return response, nil
+}
+
+// Program writes a new random HMAC-SHA1 key to slot, replacing whatever
+// the slot held. The key only ever exists on the YubiKey afterwards.
+func Program(ctx context.Context, slot int, requireTouch bool) error {
+	if slot != 1 && slot != 2 {
+		return ErrInvalidSlot
+	}
+	key := make([]byte, 20)
+	if _, err := rand.Read(key); err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+	defer secure.Wipe(key)
+
+	args := []string{"-" + strconv.Itoa(slot), "-ochal-resp", "-ochal-hmac", "-ohmac-lt64", "-oserial-api-visible"}
+	if requireTouch {
+		args = append(args, "-ochal-btn-trig")
+	}
+	args = append(args, "-a"+hex.EncodeToString(key), "-y")
+	_, err := runTool(ctx, "ykpersonalize", args...)
+	return err
+}
+
+// Enroll binds password to the key in slot with a fresh challenge
+func Enroll(ctx context.Context, respond RespondFunc, slot int, password []byte) (*Enrollment, error) {
+	challenge := make([]byte, challengeSize)
+	salt := make([]byte, 16)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, fmt.Errorf("failed to generate challenge: %w", err)
+	}
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	response, err := respond(ctx, slot, challenge)
+	if err != nil {
+		return nil, fmt.Errorf("YubiKey did not respond: %w", err)
+	}
+	hash := derive(password, response, salt)
+	secure.Wipe(response)
+
+	return &Enrollment{
+		Slot:      slot,
+		Challenge: hex.EncodeToString(challenge),
+		Salt:      hex.EncodeToString(salt),
+		Hash:      hex.EncodeToString(hash),
+	}, nil
+}
+
+// Verify checks password with the key's response to the stored challenge
+func (e *Enrollment) Verify(ctx context.Context, respond RespondFunc, password []byte) (bool, error) {
+	challenge, err := hex.DecodeString(e.Challenge)
+	if err != nil {
+		return false, fmt.Errorf("invalid challenge: %w", err)
+	}
+	salt, err := hex.DecodeString(e.Salt)
+	if err != nil {
+		return false, fmt.Errorf("invalid salt: %w", err)
+	}
+	want, err := hex.DecodeString(e.Hash)
+	if err != nil {
+		return false, fmt.Errorf("invalid hash: %w", err)
+	}
+
+	response, err := respond(ctx, e.Slot, challenge)
+	if err != nil {
+		return false, fmt.Errorf("YubiKey did not respond: %w", err)
+	}
+	got := derive(password, response, salt)
+	secure.Wipe(response)
+	defer secure.Wipe(got)
+
+	return subtle.ConstantTimeCompare(got, want) == 1, nil
+}
+
+// derive hashes the password together with the key's response
+func derive(password, response, salt []byte) []byte {
+	input := make([]byte, 0, len(password)+len(response))
+	input = append(append(input, password...), response...)
+	defer secure.Wipe(input)
+	return argon2.IDKey(input, salt, argonTime, argonMemory, argonThreads, argonKeyLen)
+}
+
+// Load reads an enrollment written by Save
+func Load(path string) (*Enrollment, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var enrollment Enrollment
+	if err := json.Unmarshal(data, &enrollment); err != nil {
+		return nil, fmt.Errorf("failed to parse YubiKey enrollment: %w", err)
+	}
+	return &enrollment, nil
+}
+
+// Save writes the enrollment atomically, readable by root only
+func (e *Enrollment) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create enrollment directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode YubiKey enrollment: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write YubiKey enrollment: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// runTool runs a ykpers tool
+func runTool(ctx context.Context, tool string, args ...string) ([]byte, error) {
+	path, err := exec.LookPath(tool)
+	if err != nil {
+		return nil, ErrToolsMissing
+	}
+
+	cmd := exec.CommandContext(ctx, path, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %s", tool, msg)
+		}
+		return nil, fmt.Errorf("%s: %w", tool, err)
+	}
+	return out, nil
+}
@@ -0,0 +1,59 @@
+package yubikey_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"path/filepath"
+	"testing"
+
+	"wyrmlock/internal/yubikey"
+)
+
+// fakeKey answers challenges like a slot programmed with key
+func fakeKey(key string) yubikey.RespondFunc {
+	return func(ctx context.Context, slot int, challenge []byte) ([]byte, error) {
+		mac := hmac.New(sha1.New, []byte(key))
+		mac.Write(challenge)
+		return mac.Sum(nil), nil
+	}
+}
+
+func TestEnrollVerify(t *testing.T) {
+	ctx := context.Background()
+	enrollment, err := yubikey.Enroll(ctx, fakeKey("key one"), 2, []byte("hunter2"))
+	if err != nil {
+		t.Fatalf("Enroll failed: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "yubikey.json")
+	if err := enrollment.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, err := yubikey.Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		key      string
+		password string
+		want     bool
+	}{
+		{"right key and password", "key one", "hunter2", true},
+		{"wrong password", "key one", "hunter3", false},
+		{"wrong key", "key two", "hunter2", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := loaded.Verify(ctx, fakeKey(tt.key), []byte(tt.password))
+			if err != nil {
+				t.Fatalf("Verify failed: %v", err)
+			}
+			if ok != tt.want {
+				t.Errorf("Verify = %v, want %v", ok, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+// Package applock exposes wyrmlock's launch decision logic for embedding in
+// other Go programs such as launchers and session managers. It runs the
+// daemon's own policy engine over a description of a launch, without
+// process monitoring, IPC or GUI: regex rules, protected paths,
+// directories, hashes and sandbox IDs, per-user and context conditions,
+// anonymous, replaced and removable executables, untrusted directories,
+// profiles, schedules and the deny-by-default allowlist.
+//
+// Policies that depend on running processes and today's usage (instance
+// limits, screen-time budgets, launch quotas) are evaluated against the
+// State set with SetState, and never reached without one.
+package applock
+
+import (
+	"time"
+
+	"wyrmlock/internal/config"
+	"wyrmlock/internal/policy"
+)
+
+// Config is the wyrmlock configuration shared with the daemon
+type Config = config.Config
+
+// LoadConfig loads and validates a wyrmlock configuration file
+func LoadConfig(path string) (*Config, error) {
+	return config.LoadConfig(path)
+}
+
+// Action is the outcome of a launch decision
+type Action string
+
+const (
+	// ActionAllow lets the launch run without authentication
+	ActionAllow Action = "allow"
+
+	// ActionPrompt requires the user to authenticate first
+	ActionPrompt Action = "prompt"
+
+	// ActionDeny refuses the launch without prompting
+	ActionDeny Action = "deny"
+)
+
+// Launch describes a program about to run. A zero UID is root; a negative
+// UID means the launching user is unknown.
+type Launch = policy.Launch
+
+// State answers questions about running processes and today's usage for
+// instance limits, screen-time budgets and launch quotas
+type State = policy.State
+
+// Decision is the result of evaluating a launch
+type Decision struct {
+	Action Action
+
+	// App is the protected app the launch matched, or its executable when
+	// it is locked for how it was started. Empty when unprotected.
+	App string
+
+	// Rule names the regex rule that decided, if any
+	Rule string
+
+	// Reason describes the decision for logs and notifications
+	Reason string
+
+	// Warning is shown with the prompt of a suspicious launch
+	Warning string
+}
+
+// Engine evaluates launches against a configuration. It is safe for
+// concurrent use.
+type Engine struct {
+	policy *policy.Engine
+	grants *GrantStore
+	state  State
+}
+
+// NewEngine prepares an engine for cfg, compiling its rules. grants may be
+// nil when the caller does not remember earlier authentications.
+func NewEngine(cfg *Config, grants *GrantStore) (*Engine, error) {
+	engine, err := policy.NewEngine(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Engine{policy: engine, grants: grants}, nil
+}
+
+// SetState has instance limits, screen-time budgets and launch quotas
+// evaluated against state. It must be called before the first Decide.
+func (e *Engine) SetState(state State) {
+	e.state = state
+}
+
+// Grants returns the engine's grant store, or nil
+func (e *Engine) Grants() *GrantStore {
+	return e.grants
+}
+
+// Decide evaluates a launch in the same order as the daemon. Launches the
+// daemon would lock are prompted for unless a grant covers them, and those
+// it would log are allowed.
+func (e *Engine) Decide(l Launch) Decision {
+	d := e.policy.Decide(l, e.state)
+	decision := Decision{App: d.App, Rule: d.Rule, Reason: d.Reason, Warning: d.Warning}
+
+	switch d.Action {
+	case policy.ActionAllow, policy.ActionLog:
+		decision.Action = ActionAllow
+	case policy.ActionDeny:
+		decision.Action = ActionDeny
+	default:
+		decision.Action = ActionPrompt
+		now := l.Time
+		if now.IsZero() {
+			now = time.Now()
+		}
+		if e.grants != nil {
+			if grant, ok := e.grants.Active(d.App, l.PID, now); ok {
+				decision.Action = ActionAllow
+				decision.Reason = "granted by " + grant.GrantedBy
+			}
+		}
+	}
+	return decision
+}
@@ -0,0 +1,183 @@
+package applock_test
+
+import (
+	"testing"
+	"time"
+
+	"wyrmlock/internal/config"
+	"wyrmlock/pkg/applock"
+)
+
+func newEngine(t *testing.T, cfg *applock.Config, grants *applock.GrantStore) *applock.Engine {
+	t.Helper()
+	engine, err := applock.NewEngine(cfg, grants)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	return engine
+}
+
+func TestDecideProtectedPath(t *testing.T) {
+	cfg := &applock.Config{}
+	cfg.Monitor.ProtectedApps = []string{"/usr/bin/firefox"}
+	engine := newEngine(t, cfg, nil)
+
+	if d := engine.Decide(applock.Launch{Executable: "/usr/bin/firefox"}); d.Action != applock.ActionPrompt || d.App != "/usr/bin/firefox" {
+		t.Errorf("Expected prompt for protected app, got %+v", d)
+	}
+	if d := engine.Decide(applock.Launch{Executable: "/usr/bin/vim"}); d.Action != applock.ActionAllow {
+		t.Errorf("Expected allow for unprotected app, got %+v", d)
+	}
+}
+
+func TestDecideRegexRules(t *testing.T) {
+	cfg := &applock.Config{}
+	cfg.Monitor.RegexRules = []config.RegexRule{
+		{Name: "no-games", PathPattern: `^/usr/games/`, Action: config.RuleActionDeny},
+		{Name: "scripts", CmdlinePattern: `\.py$`},
+	}
+	engine := newEngine(t, cfg, nil)
+
+	if d := engine.Decide(applock.Launch{Executable: "/usr/games/nethack"}); d.Action != applock.ActionDeny || d.Rule != "no-games" {
+		t.Errorf("Expected deny by rule, got %+v", d)
+	}
+	if d := engine.Decide(applock.Launch{Executable: "/usr/bin/python3", CmdLine: "python3 tool.py"}); d.Action != applock.ActionPrompt || d.Rule != "scripts" {
+		t.Errorf("Expected prompt by rule, got %+v", d)
+	}
+}
+
+func TestDecideScheduleAndUsers(t *testing.T) {
+	cfg := &applock.Config{}
+	cfg.Monitor.ProtectedApps = []string{"/usr/bin/steam"}
+	cfg.BlockedApps = []config.BlockedApp{{
+		Path:     "/usr/bin/steam",
+		Users:    []string{"1000"},
+		Schedule: []config.ScheduleBlock{{Start: "22:00", End: "07:00"}},
+	}}
+	engine := newEngine(t, cfg, nil)
+
+	night := time.Date(2024, 5, 1, 23, 0, 0, 0, time.Local)
+	if d := engine.Decide(applock.Launch{Executable: "/usr/bin/steam", UID: 1000, Time: night}); d.Action != applock.ActionDeny {
+		t.Errorf("Expected deny during schedule, got %+v", d)
+	}
+
+	noon := time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)
+	if d := engine.Decide(applock.Launch{Executable: "/usr/bin/steam", UID: 1000, Time: noon}); d.Action != applock.ActionAllow {
+		t.Errorf("Expected allow outside schedule, got %+v", d)
+	}
+	if d := engine.Decide(applock.Launch{Executable: "/usr/bin/steam", UID: 1001, Time: night}); d.Action != applock.ActionAllow {
+		t.Errorf("Expected allow for other users, got %+v", d)
+	}
+}
+
+func TestDecideGrants(t *testing.T) {
+	cfg := &applock.Config{}
+	cfg.Monitor.ProtectedApps = []string{"/usr/bin/firefox"}
+	grants := applock.NewGrantStore()
+	engine := newEngine(t, cfg, grants)
+
+	now := time.Now()
+	grants.Add(applock.Grant{App: "/usr/bin/firefox", GrantedBy: "password", ExpiresAt: now.Add(time.Minute)})
+
+	if d := engine.Decide(applock.Launch{Executable: "/usr/bin/firefox", Time: now}); d.Action != applock.ActionAllow {
+		t.Errorf("Expected grant to allow launch, got %+v", d)
+	}
+	if d := engine.Decide(applock.Launch{Executable: "/usr/bin/firefox", Time: now.Add(2 * time.Minute)}); d.Action != applock.ActionPrompt {
+		t.Errorf("Expected expired grant to prompt, got %+v", d)
+	}
+	if n := grants.Revoke(""); n != 0 {
+		t.Errorf("Expected expired grant to be pruned, revoked %d", n)
+	}
+}
+
+func TestDecidePolicyAction(t *testing.T) {
+	cfg := &applock.Config{}
+	cfg.Monitor.ProtectedApps = []string{"/usr/bin/firefox", "/usr/bin/steam", "/usr/bin/gimp"}
+	cfg.Monitor.DefaultAction = config.RuleActionDeny
+	cfg.BlockedApps = []config.BlockedApp{
+		{Path: "/usr/bin/firefox", Action: config.RuleActionLock},
+		{Path: "/usr/bin/gimp", Action: config.RuleActionLog},
+	}
+	engine := newEngine(t, cfg, nil)
+
+	if d := engine.Decide(applock.Launch{Executable: "/usr/bin/steam"}); d.Action != applock.ActionDeny {
+		t.Errorf("Expected default action to deny, got %+v", d)
+	}
+	if d := engine.Decide(applock.Launch{Executable: "/usr/bin/firefox"}); d.Action != applock.ActionPrompt {
+		t.Errorf("Expected app action to override the default, got %+v", d)
+	}
+	if d := engine.Decide(applock.Launch{Executable: "/usr/bin/gimp"}); d.Action != applock.ActionAllow {
+		t.Errorf("Expected log action to allow, got %+v", d)
+	}
+}
+
+func TestDecideTrustedParents(t *testing.T) {
+	cfg := &applock.Config{}
+	cfg.Monitor.ProtectedApps = []string{"/usr/bin/rsync", "/usr/bin/firefox"}
+	cfg.Monitor.TrustedParents = []string{"/usr/bin/backup-agent"}
+	cfg.BlockedApps = []config.BlockedApp{{Path: "/usr/bin/firefox", TrustedParents: []string{"/usr/bin/kiosk-launcher"}}}
+	engine := newEngine(t, cfg, nil)
+
+	launch := applock.Launch{Executable: "/usr/bin/rsync", Ancestors: []string{"/usr/bin/bash", "/usr/bin/backup-agent", "/usr/lib/systemd/systemd"}}
+	if d := engine.Decide(launch); d.Action != applock.ActionAllow {
+		t.Errorf("Expected launch by a trusted launcher to be allowed, got %+v", d)
+	}
+	if d := engine.Decide(applock.Launch{Executable: "/usr/bin/rsync", Ancestors: []string{"/usr/bin/bash"}}); d.Action != applock.ActionPrompt {
+		t.Errorf("Expected launch by an untrusted parent to prompt, got %+v", d)
+	}
+	if d := engine.Decide(applock.Launch{Executable: "/usr/bin/firefox", Ancestors: []string{"/usr/bin/kiosk-launcher"}}); d.Action != applock.ActionAllow {
+		t.Errorf("Expected the app's own trusted launcher to be allowed, got %+v", d)
+	}
+	if d := engine.Decide(applock.Launch{Executable: "/usr/bin/rsync", Ancestors: []string{"/usr/bin/kiosk-launcher"}}); d.Action != applock.ActionPrompt {
+		t.Errorf("Expected another app's launcher not to be trusted, got %+v", d)
+	}
+}
+
+func TestDecideContextConditions(t *testing.T) {
+	cfg := &applock.Config{}
+	cfg.Monitor.ProtectedApps = []string{"/usr/bin/code"}
+	cfg.BlockedApps = []config.BlockedApp{{
+		Path:        "/usr/bin/code",
+		WorkingDirs: []string{"/home/alice/work/secret"},
+		Env:         []string{"XDG_SESSION_TYPE=wayland", "DISPLAY"},
+	}}
+	engine := newEngine(t, cfg, nil)
+
+	env := []string{"XDG_SESSION_TYPE=wayland", "DISPLAY=:0"}
+	if d := engine.Decide(applock.Launch{Executable: "/usr/bin/code", Cwd: "/home/alice/work/secret/src", Env: env}); d.Action != applock.ActionPrompt {
+		t.Errorf("Expected prompt inside the locked directory, got %+v", d)
+	}
+	if d := engine.Decide(applock.Launch{Executable: "/usr/bin/code", Cwd: "/home/alice/work/secret-notes", Env: env}); d.Action != applock.ActionAllow {
+		t.Errorf("Expected allow outside the locked directory, got %+v", d)
+	}
+	if d := engine.Decide(applock.Launch{Executable: "/usr/bin/code", Cwd: "/home/alice/work/secret", Env: []string{"XDG_SESSION_TYPE=x11", "DISPLAY=:0"}}); d.Action != applock.ActionAllow {
+		t.Errorf("Expected allow when the environment differs, got %+v", d)
+	}
+	if d := engine.Decide(applock.Launch{Executable: "/usr/bin/code", Cwd: "/home/alice/work/secret", Env: []string{"XDG_SESSION_TYPE=wayland"}}); d.Action != applock.ActionAllow {
+		t.Errorf("Expected allow when a required variable is unset, got %+v", d)
+	}
+}
+
+func TestDecideAllowlistMode(t *testing.T) {
+	cfg := &applock.Config{}
+	cfg.Monitor.AllowlistMode = config.AllowlistModeDeny
+	cfg.Monitor.Allowlist = []string{"/usr/bin/bash", "sha256:ab12"}
+	cfg.Monitor.AllowlistSystemDirs = []string{"/usr/libexec"}
+	engine := newEngine(t, cfg, nil)
+
+	allowed := []applock.Launch{
+		{Executable: "/usr/bin/bash", UID: 1000},
+		{Executable: "/opt/tool", Hash: "AB12", UID: 1000},
+		{Executable: "/usr/libexec/helper", UID: 1000},
+		{Executable: "/usr/bin/nc", UID: 0},
+	}
+	for _, l := range allowed {
+		if d := engine.Decide(l); d.Action != applock.ActionAllow {
+			t.Errorf("Expected %s to be allowed, got %+v", l.Executable, d)
+		}
+	}
+
+	if d := engine.Decide(applock.Launch{Executable: "/usr/bin/nc", Hash: "cd34", UID: 1000}); d.Action != applock.ActionDeny {
+		t.Errorf("Expected unlisted executable to be denied, got %+v", d)
+	}
+}
@@ -0,0 +1,115 @@
+package applock
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"wyrmlock/internal/policy"
+)
+
+// Grant is a permission for an app to run without prompting, recorded after
+// the user authenticated
+type Grant struct {
+	App       string
+	PID       int
+	User      string
+	GrantedBy string
+	GrantedAt time.Time
+
+	// ExpiresAt is zero when the grant does not expire
+	ExpiresAt time.Time
+}
+
+// expired reports whether the grant has lapsed at t
+func (g Grant) expired(t time.Time) bool {
+	return !g.ExpiresAt.IsZero() && !t.Before(g.ExpiresAt)
+}
+
+// GrantStore remembers grants in memory. A grant with a PID covers only that
+// process; a grant without one covers every launch of the app until it
+// expires.
+type GrantStore struct {
+	mu     sync.Mutex
+	grants []Grant
+}
+
+// NewGrantStore creates an empty grant store
+func NewGrantStore() *GrantStore {
+	return &GrantStore{}
+}
+
+// Add records a grant, replacing an earlier grant for the same app and PID
+func (s *GrantStore) Add(grant Grant) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if grant.GrantedAt.IsZero() {
+		grant.GrantedAt = time.Now()
+	}
+	for i, g := range s.grants {
+		if g.App == grant.App && g.PID == grant.PID {
+			s.grants[i] = grant
+			return
+		}
+	}
+	s.grants = append(s.grants, grant)
+}
+
+// Active returns a grant covering a launch of app by pid at t
+func (s *GrantStore) Active(app string, pid int, t time.Time) (Grant, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(t)
+	for _, g := range s.grants {
+		if g.App == app && (g.PID == 0 || g.PID == pid) {
+			return g, true
+		}
+	}
+	return Grant{}, false
+}
+
+// Revoke removes every grant for app, or all grants when app is empty, and
+// returns how many were removed
+func (s *GrantStore) Revoke(app string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.grants[:0]
+	for _, g := range s.grants {
+		if app != "" && g.App != app {
+			kept = append(kept, g)
+		}
+	}
+	removed := len(s.grants) - len(kept)
+	s.grants = kept
+	return removed
+}
+
+// List returns the grants active at t, oldest first
+func (s *GrantStore) List(t time.Time) []Grant {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(t)
+	grants := append([]Grant(nil), s.grants...)
+	sort.Slice(grants, func(i, j int) bool { return grants[i].GrantedAt.Before(grants[j].GrantedAt) })
+	return grants
+}
+
+// prune drops expired grants. Caller holds mu.
+func (s *GrantStore) prune(t time.Time) {
+	kept := s.grants[:0]
+	for _, g := range s.grants {
+		if !g.expired(t) {
+			kept = append(kept, g)
+		}
+	}
+	s.grants = kept
+}
+
+// HashFile returns the hex SHA-256 of a file, as used by "sha256:" entries
+func HashFile(path string) (string, error) {
+	return policy.HashFile(path)
+}
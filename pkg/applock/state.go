@@ -0,0 +1,51 @@
+package applock
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryState counts the free launches handed out from launch quotas in
+// memory, per calendar day. It knows of no running processes and no screen
+// time, so instance limits and time budgets are never reached.
+type MemoryState struct {
+	mu       sync.Mutex
+	day      string
+	launches map[string]int
+}
+
+// NewMemoryState creates a state with every quota unused
+func NewMemoryState() *MemoryState {
+	return &MemoryState{launches: make(map[string]int)}
+}
+
+// RunningInstances reports no running instances
+func (s *MemoryState) RunningInstances(l *Launch, app string) int {
+	return 0
+}
+
+// InstanceChild reports no launch as part of a running instance
+func (s *MemoryState) InstanceChild(l *Launch, app string) bool {
+	return false
+}
+
+// UsageExhausted reports no time budget as used up
+func (s *MemoryState) UsageExhausted(app string, t time.Time) bool {
+	return false
+}
+
+// ConsumeLaunch uses one of the limit free launches of app on the day of t
+func (s *MemoryState) ConsumeLaunch(app string, limit int, t time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if day := t.Format("2006-01-02"); s.day != day {
+		s.day = day
+		s.launches = make(map[string]int)
+	}
+	if s.launches[app] >= limit {
+		return false
+	}
+	s.launches[app]++
+	return true
+}